@@ -29,8 +29,12 @@ reserves the right to occasionally do so for some Go values.
 package arena
 
 import (
+	"errors"
+	"internal/byteorder"
 	"internal/reflectlite"
+	"io"
 	"unsafe"
+	"weak"
 )
 
 // Arena represents a collection of Go values allocated and freed together.
@@ -40,14 +44,204 @@ import (
 // it is no longer referenced, so it must be kept alive (see runtime.KeepAlive)
 // until any memory allocated from it is no longer needed.
 //
-// An Arena must never be used concurrently by multiple goroutines.
+// An Arena must never be used concurrently by multiple goroutines, unless it
+// was created with the WithConcurrentAlloc option.
 type Arena struct {
 	a unsafe.Pointer
 }
 
-// NewArena allocates a new arena.
-func NewArena() *Arena {
-	return &Arena{a: runtime_arena_newArena()}
+// An Option configures an Arena created by NewArena.
+type Option func(*options)
+
+// options is the configuration of a new arena.
+type options struct {
+	// These fields must match the layout of the runtime's userArenaOptions.
+	concurrent     bool
+	pool           unsafe.Pointer
+	noHeapFallback bool
+	region         []byte
+	handle         any
+	numa           bool
+	numaNode       int32
+}
+
+// WithConcurrentAlloc returns an Option that allows values to be allocated
+// from the arena by multiple goroutines concurrently, with New, MakeSlice,
+// and the other functions that allocate from an arena. All other operations,
+// including Free, Reset, and reading the values allocated from the arena,
+// must not happen concurrently with allocations or with each other.
+//
+// Such an arena holds a separate chunk of memory for each processor that
+// allocates from it, so it may use more memory than an arena only used by
+// a single goroutine. Arenas created with this option don't support
+// [Arena.Mark] and [Arena.Release], and [GrowInPlace] always fails for them.
+func WithConcurrentAlloc() Option {
+	return func(o *options) {
+		o.concurrent = true
+	}
+}
+
+// WithChunkPool returns an Option that makes the arena obtain the chunks of
+// memory backing it from p, and return them to p once it's freed, instead of
+// sharing them with all other arenas.
+func WithChunkPool(p *ChunkPool) Option {
+	return func(o *options) {
+		o.pool = p.p
+	}
+}
+
+// WithoutHeapFallback returns an Option that guarantees that every value
+// allocated from the arena is backed by the arena's memory. By default, values
+// too large to be allocated efficiently from an arena are transparently
+// allocated on the heap instead. With this option, allocating such a value
+// from the arena panics, including storage allocated as maps in the arena grow,
+// while [TryNew] and [TrySlice] return [ErrTooLarge].
+func WithoutHeapFallback() Option {
+	return func(o *options) {
+		o.noHeapFallback = true
+	}
+}
+
+// WithNUMANode returns an Option that places the memory backing the arena on
+// NUMA node node, which is worthwhile on machines where accessing memory on
+// a remote node is much slower than the local one. Placement is a preference
+// rather than a requirement: memory comes from other nodes once node is out of
+// it, and the option has no effect if node doesn't exist. It's currently only
+// supported on Linux, and ignored elsewhere.
+func WithNUMANode(node int) Option {
+	return func(o *options) {
+		if node < 0 || int(int32(node)) != node {
+			panic("arena: invalid NUMA node")
+		}
+		o.numa = true
+		o.numaNode = int32(node)
+	}
+}
+
+// WithLocalNUMANode returns an Option like [WithNUMANode], but which places
+// each chunk of memory backing the arena on the NUMA node of the thread that
+// first needs it. For an arena created with [WithConcurrentAlloc], that places
+// each processor's chunks on its own node.
+func WithLocalNUMANode() Option {
+	return func(o *options) {
+		o.numa = true
+		o.numaNode = -1
+	}
+}
+
+// NewArena allocates a new arena, configured by opts.
+func NewArena(opts ...Option) *Arena {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newArena(&o)
+}
+
+// newArena allocates a new arena configured by o.
+func newArena(o *options) *Arena {
+	a := new(Arena)
+	// Let the runtime map the arena's memory back to a without keeping
+	// a alive, so it may still be freed by the garbage collector.
+	o.handle = weak.Make(a)
+	a.a = runtime_arena_newArena(unsafe.Pointer(o))
+	return a
+}
+
+// NewArenaFromBytes returns an arena that allocates from the memory of buf,
+// such as a memory-mapped file or a shared memory segment, instead of from
+// memory managed by the runtime.
+//
+// The garbage collector never scans buf, so only values that contain no
+// pointers may be allocated from the arena. Allocating any other value panics,
+// and so does [MakeMap]. Values are zeroed as they're allocated. Values are
+// never allocated from the heap instead of buf: once it's exhausted,
+// allocations fail as though they exceeded the arena's limit.
+//
+// Freeing the arena only ends its use of buf, which remains valid and owned by
+// the caller. buf must not be modified other than through the values allocated
+// from the arena until then. [Clone] doesn't recognize values allocated from
+// the arena, and returns them unchanged.
+func NewArenaFromBytes(buf []byte) *Arena {
+	if buf == nil {
+		buf = []byte{}
+	}
+	return newArena(&options{region: buf})
+}
+
+// serialMagic identifies the contents of an arena written by [Arena.WriteTo].
+// It's followed by the length of the contents as a little-endian uint64,
+// and then by the contents themselves.
+const serialMagic = "go arena"
+
+var (
+	errNotSerializable = errors.New("arena: only arenas created by NewArenaFromBytes can be serialized")
+	errInvalidSerial   = errors.New("arena: invalid serialized arena")
+	errShortBuffer     = errors.New("arena: buffer too small for serialized arena")
+)
+
+// WriteTo writes the contents of an arena created by [NewArenaFromBytes] to
+// w, so that [ReadArena] can reconstruct an equivalent arena from them. The
+// contents are the prefix of the arena's buffer from which values have been
+// allocated, so the values keep their offsets from the start of the buffer.
+// It returns an error without writing anything if the arena wasn't created
+// by NewArenaFromBytes.
+//
+// Since values allocated from such an arena can't contain pointers, the
+// values reconstructed by ReadArena are identical to the originals, except
+// for values that refer to memory by address, such as uintptrs.
+//
+// WriteTo must not be called concurrently with allocations from the arena.
+func (a *Arena) WriteTo(w io.Writer) (int64, error) {
+	b, ok := runtime_arena_arena_Allocated(a.a)
+	if !ok {
+		return 0, errNotSerializable
+	}
+	var hdr [len(serialMagic) + 8]byte
+	copy(hdr[:], serialMagic)
+	byteorder.LePutUint64(hdr[len(serialMagic):], uint64(len(b)))
+	n, err := w.Write(hdr[:])
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(b)
+	return int64(n + m), err
+}
+
+// ReadArena reads the contents of an arena written by [Arena.WriteTo] from
+// r, and returns a new arena allocating from buf, like one created by
+// [NewArenaFromBytes], in which the contents are at the same offsets from the
+// start of buf as they were from the start of the original arena's buffer.
+// The rest of buf is available to allocate new values from.
+//
+// buf must be at least as long as the contents, and aligned at least as
+// strictly as any value allocated from the original arena. The returned
+// arena's [Stats] count the contents as a single allocation.
+func ReadArena(r io.Reader, buf []byte) (*Arena, error) {
+	var hdr [len(serialMagic) + 8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if string(hdr[:len(serialMagic)]) != serialMagic {
+		return nil, errInvalidSerial
+	}
+	size := byteorder.LeUint64(hdr[len(serialMagic):])
+	if size > uint64(len(buf)) {
+		return nil, errShortBuffer
+	}
+	a := NewArenaFromBytes(buf)
+	if size == 0 {
+		return a, nil
+	}
+	b := unsafe.Slice((*byte)(a.NewAligned(uintptr(size), 1)), size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		a.Free()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return a, nil
 }
 
 // Free frees the arena (and all objects allocated from the arena) so that
@@ -59,6 +253,351 @@ func (a *Arena) Free() {
 	a.a = nil
 }
 
+// Reset frees all objects allocated from the arena, but unlike Free, keeps
+// the memory backing the arena so that it may be immediately reused by
+// subsequent allocations from the same arena. Values obtained from the arena
+// before the call to Reset must not be used after it. Accessing such values
+// will not fault: they may instead observe values allocated after the Reset.
+func (a *Arena) Reset() {
+	runtime_arena_arena_Reset(a.a)
+}
+
+// Contains reports whether p points into a value allocated from the arena's
+// own memory since it was created or last reset. Values that the arena
+// allocated from the heap instead, such as those too large for it, aren't
+// considered part of the arena.
+func (a *Arena) Contains(p unsafe.Pointer) bool {
+	return runtime_arena_arena_Contains(a.a, p)
+}
+
+// Of returns the arena whose memory p points into a value allocated from, as
+// reported by [Arena.Contains], or nil if no live arena contains p. It's meant
+// to help track down which arena a value came from when debugging.
+//
+// Of must not be called concurrently with operations other than allocation on
+// the arena containing p.
+func Of(p unsafe.Pointer) *Arena {
+	h := runtime_arena_of(p)
+	if h == nil {
+		return nil
+	}
+	return h.(weak.Pointer[Arena]).Get()
+}
+
+// Freeze makes the memory of the arena read-only, so that values allocated
+// from it, such as lookup tables built once at startup, can't be corrupted.
+// Any attempt to modify them faults until [Arena.Unfreeze] is called. Nothing
+// may be allocated from a frozen arena, and it may not be reset or released
+// to a mark, but it may be freed.
+//
+// Some operations that appear to only read a value may modify it, such as
+// iterating over a map, so they must not be applied to maps allocated from a
+// frozen arena.
+//
+// The memory is currently only made read-only on Linux, and never for arenas
+// created by [NewArenaFromBytes]. Elsewhere, Freeze only prevents further
+// allocation.
+func (a *Arena) Freeze() {
+	runtime_arena_arena_Freeze(a.a)
+}
+
+// Unfreeze makes the memory of an arena frozen by [Arena.Freeze] writable
+// again, and allows allocating from it.
+func (a *Arena) Unfreeze() {
+	runtime_arena_arena_Unfreeze(a.a)
+}
+
+// Errors returned by TryNew and TrySlice.
+var (
+	// ErrLimitExceeded means the allocation would exceed the limit set
+	// with [Arena.SetLimit].
+	ErrLimitExceeded = errors.New("arena: allocation exceeds arena limit")
+
+	// ErrTooLarge means the value is too large to be allocated from
+	// the arena's memory.
+	ErrTooLarge = errors.New("arena: value too large to allocate from arena")
+)
+
+// allocError returns the error for a failed allocation, given the reason
+// reported by the runtime.
+func allocError(reason int) error {
+	switch reason {
+	case 0:
+		return nil
+	case 1:
+		return ErrLimitExceeded
+	case 2:
+		return ErrTooLarge
+	}
+	panic("arena: unknown allocation error")
+}
+
+// SetLimit sets the maximum total size, in bytes, of the values that may be
+// allocated from the arena since it was created or last reset. Values too
+// large to fit in the arena's memory that are allocated on the heap count
+// toward the limit, but padding between values does not. A limit of zero,
+// the default, means there is no limit.
+//
+// Once the limit is reached, [TryNew] and [TrySlice] return [ErrLimitExceeded],
+// and all other functions that allocate from the arena panic. Lowering the
+// limit does not affect values already allocated. Release restores the
+// total allocated before the mark, along with the arena's memory.
+func (a *Arena) SetLimit(bytes uint64) {
+	runtime_arena_arena_SetLimit(a.a, bytes)
+}
+
+// Stats describes how memory backing an arena is being used.
+type Stats struct {
+	// AllocBytes is the number of bytes of Go values allocated from
+	// the arena's memory since it was created or last reset.
+	//
+	// Values too large to fit in the arena's memory are allocated on
+	// the heap, and are not included.
+	AllocBytes uint64
+
+	// PaddingBytes is the number of bytes of the arena's memory wasted
+	// to satisfy the alignment of the values allocated from it.
+	PaddingBytes uint64
+
+	// Chunks is the number of chunks of memory held by the arena.
+	Chunks uint64
+
+	// Allocs is the number of Go values allocated from the arena's memory
+	// since it was created or last reset. Since values in an arena are
+	// freed all together, this is also the number of live allocations.
+	Allocs uint64
+
+	// TailBytes is the number of bytes left unused at the end of the
+	// arena's chunks because the value allocated next didn't fit in them.
+	TailBytes uint64
+
+	// BySize breaks Allocs, PaddingBytes, and TailBytes down by the
+	// size of the values, using the size classes of the heap.
+	//
+	// BySize[N] covers values of at most BySize[N].Size bytes, and
+	// more than BySize[N-1].Size bytes, for N > 0. BySize[0] covers
+	// values too large for any size class. TailBytes is attributed to
+	// the size of the value that didn't fit.
+	BySize [68]struct {
+		// Size is the maximum size of values in this size class.
+		Size uint32
+
+		// Allocs is the number of values in this size class.
+		Allocs uint64
+
+		// PaddingBytes is the number of bytes wasted to satisfy the
+		// alignment of the values in this size class.
+		PaddingBytes uint64
+
+		// TailBytes is the number of bytes left unused at the end of
+		// chunks because a value in this size class didn't fit.
+		TailBytes uint64
+	}
+}
+
+// Stats returns statistics about the arena's memory usage. All statistics
+// are zero once the arena has been freed.
+func (a *Arena) Stats() Stats {
+	var s Stats
+	if a.a != nil {
+		runtime_arena_arena_Stats(a.a, unsafe.Pointer(&s))
+	}
+	return s
+}
+
+// FreeInfo describes an arena that has been freed. It is passed to the
+// function set with [SetFreeHook].
+type FreeInfo struct {
+	// Stats is the arena's statistics from just before it was freed.
+	Stats Stats
+
+	// Finalized reports whether the arena was freed by the garbage
+	// collector because it became unreachable before Free was called.
+	// This usually indicates that an arena was leaked.
+	Finalized bool
+}
+
+// SetFreeHook sets hook to be called every time an arena is freed, either
+// by Free or by the garbage collector, replacing any previously set hook.
+// If hook is nil, no function is called.
+//
+// hook is called on the goroutine freeing the arena once it's been freed.
+// Arenas freed by the garbage collector are freed on the same goroutine
+// used to run finalizers, so hook should not block.
+func SetFreeHook(hook func(FreeInfo)) {
+	if hook == nil {
+		runtime_arena_setFreeHook(nil)
+		return
+	}
+	runtime_arena_setFreeHook(func(stats unsafe.Pointer, finalized bool) {
+		hook(FreeInfo{Stats: *(*Stats)(stats), Finalized: finalized})
+	})
+}
+
+// A Leak describes arenas created at the same call stack that were freed by
+// the garbage collector because they became unreachable before Free was called.
+// Leaks are only tracked with GODEBUG=arenaleak=1, which also reports them
+// on standard error when the program exits.
+type Leak struct {
+	// Stack is the call stack that created the arenas, as returned by
+	// runtime.Callers, starting with the function that called NewArena.
+	Stack []uintptr
+
+	// Count is the number of arenas created at Stack that were leaked.
+	Count uint64
+
+	// AllocBytes is the total AllocBytes statistic of the leaked arenas
+	// just before they were freed.
+	AllocBytes uint64
+}
+
+// leakRecord is the runtime's description of a Leak.
+type leakRecord struct {
+	// These fields must match the layout of the runtime's userArenaLeak.
+	stack      [32]uintptr
+	count      uint64
+	allocBytes uint64
+}
+
+// Leaks returns the arena leaks detected so far, with one Leak for each call
+// stack that created leaked arenas. It returns nil unless the program is run
+// with GODEBUG=arenaleak=1.
+//
+// An arena is only found to be leaked once the garbage collector has run
+// its finalizer, which may happen long after the arena became unreachable.
+func Leaks() []Leak {
+	var records []leakRecord
+	for {
+		n := runtime_arena_readLeaks(unsafe.Pointer(unsafe.SliceData(records)), len(records))
+		if n <= len(records) {
+			records = records[:n]
+			break
+		}
+		// Allow room for leaks detected in the meantime.
+		records = make([]leakRecord, n+10)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	leaks := make([]Leak, len(records))
+	for i := range records {
+		r := &records[i]
+		n := 0
+		for n < len(r.stack) && r.stack[n] != 0 {
+			n++
+		}
+		leaks[i] = Leak{
+			Stack:      append([]uintptr(nil), r.stack[:n]...),
+			Count:      r.count,
+			AllocBytes: r.allocBytes,
+		}
+	}
+	return leaks
+}
+
+// AddCleanup registers f to be called before the memory of the values
+// allocated from the arena so far is freed or reused: when the arena is freed,
+// including by the garbage collector, or reset, or released to a mark created
+// before f was registered. It allows resources tied to values allocated from
+// the arena, such as file descriptors or memory allocated by C, to be released
+// deterministically, which [runtime.SetFinalizer] can't be used for.
+//
+// Cleanups are called in the reverse order of their registration, and may
+// read the values allocated from the arena, but must not otherwise use the
+// arena. If the arena is freed by the garbage collector, they're called on
+// the goroutine that runs finalizers. f must not refer to a, or a will never
+// be freed by the garbage collector.
+func (a *Arena) AddCleanup(f func()) {
+	runtime_arena_arena_AddCleanup(a.a, f)
+}
+
+// Mark is a checkpoint of the state of an arena, created by [Arena.Mark].
+type Mark struct {
+	// These fields must match the layout of the runtime's userArenaMark.
+	arena    unsafe.Pointer
+	seq      uintptr
+	chunks   uintptr
+	base     uintptr
+	limit    uintptr
+	stats    Stats
+	charged  uint64
+	cleanups uintptr
+}
+
+// Mark returns a checkpoint of the arena's current state which may later be
+// passed to Release to free all the values allocated from the arena after
+// the checkpoint was created, while keeping those created before it.
+//
+// A mark that is no longer needed doesn't have to be released. The arena
+// keeps track of its valid marks in memory that only grows when a mark is
+// created after an older mark was released, and shrinks again when a mark
+// older than that is released or the arena is reset.
+func (a *Arena) Mark() Mark {
+	var m Mark
+	runtime_arena_arena_Mark(a.a, unsafe.Pointer(&m))
+	return m
+}
+
+// Release frees all values allocated from the arena after m was created by
+// [Arena.Mark], making the memory backing them immediately available for
+// reuse by subsequent allocations from the same arena. As with Reset, values
+// allocated after m must not be used after the call to Release, and accessing
+// them will not fault. Cleanups registered with [Arena.AddCleanup] after m
+// was created are called first.
+//
+// Marks must be released in the reverse order in which they were created.
+// Once Release has been called with m, marks created after m are no longer
+// valid, and neither is any mark created before a call to Reset. Release panics
+// if m is not valid. m itself remains valid and may be released again.
+//
+// Mark and Release panic if the arena was created with WithConcurrentAlloc.
+func (a *Arena) Release(m Mark) {
+	runtime_arena_arena_Release(a.a, unsafe.Pointer(&m))
+}
+
+// A ChunkPool is a set of chunks of memory shared by the arenas created with
+// the WithChunkPool option. By default, chunks released by a freed arena may
+// be reused by any other arena. Arenas created with a ChunkPool instead draw
+// chunks from, and return them to, the pool, so the memory used by a group of
+// arenas can be tracked with [ChunkPool.Stats] and released all at once with
+// [ChunkPool.Free].
+//
+// A ChunkPool may be used concurrently by multiple goroutines.
+type ChunkPool struct {
+	p unsafe.Pointer
+}
+
+// NewChunkPool creates a new, empty chunk pool.
+func NewChunkPool() *ChunkPool {
+	return &ChunkPool{p: runtime_arena_newChunkPool()}
+}
+
+// ChunkPoolStats describes the memory held by a chunk pool.
+type ChunkPoolStats struct {
+	// Chunks is the number of chunks obtained through the pool that
+	// have not been freed yet, including those used by arenas.
+	Chunks uint64
+
+	// IdleChunks is the number of chunks held by the pool that are not
+	// used by any arena.
+	IdleChunks uint64
+}
+
+// Stats returns statistics about the memory held by the pool.
+func (p *ChunkPool) Stats() ChunkPoolStats {
+	var s ChunkPoolStats
+	runtime_arena_chunkPool_Stats(p.p, unsafe.Pointer(&s))
+	return s
+}
+
+// Free frees the pool's idle chunks. Arenas created with the pool may still
+// be used and freed afterward, but their chunks are then freed rather than
+// returned to the pool, and no new arena may be created with the pool.
+// Free must be called at most once.
+func (p *ChunkPool) Free() {
+	runtime_arena_chunkPool_Free(p.p)
+}
+
 // New creates a new *T in the provided arena. The *T must not be used after
 // the arena is freed. Accessing the value after free may result in a fault,
 // but this fault is also not guaranteed.
@@ -66,6 +605,20 @@ func New[T any](a *Arena) *T {
 	return runtime_arena_arena_New(a.a, reflectlite.TypeOf((*T)(nil))).(*T)
 }
 
+// NewPinned is like [New], but the new value is pinned, as though by a
+// [runtime.Pinner], until the arena is freed or reset, or released to a mark
+// created before the value was allocated. A pointer to the value may be stored
+// in C memory, or contained in Go memory passed to C, without pinning it
+// separately, and [runtime.Pinner.Pin] does nothing for it. C code must not
+// use the value after it's no longer pinned.
+//
+// Unlike New, NewPinned never allocates the value on the heap instead of the
+// arena's memory, and panics if T is too large for it. If the value contains
+// pointers to other Go values, they must be pinned separately.
+func NewPinned[T any](a *Arena) *T {
+	return runtime_arena_arena_NewPinned(a.a, reflectlite.TypeOf((*T)(nil))).(*T)
+}
+
 // MakeSlice creates a new []T with the provided capacity and length. The []T must
 // not be used after the arena is freed. Accessing the underlying storage of the
 // slice after free may result in a fault, but this fault is also not guaranteed.
@@ -75,6 +628,240 @@ func MakeSlice[T any](a *Arena, len, cap int) []T {
 	return sl[:len]
 }
 
+// MakeSlices creates n new []T, each with the provided length and capacity,
+// and returns them in a new [][]T, all allocated from the arena with one
+// allocation for the [][]T and one for the backing stores of all the []T,
+// which are laid out contiguously. This is much cheaper than calling
+// [MakeSlice] for each []T when n is large. None of the slices may be used
+// after the arena is freed.
+//
+// Appending to one of the []T beyond its capacity reallocates it, without
+// affecting the others.
+func MakeSlices[T any](a *Arena, n, len, cap int) [][]T {
+	if n < 0 || len < 0 || len > cap {
+		panic("arena: invalid MakeSlices size")
+	}
+	if cap > 0 && n > int(^uint(0)>>1)/cap {
+		panic("arena: MakeSlices size overflows")
+	}
+	s := MakeSlice[[]T](a, n, n)
+	all := MakeSlice[T](a, n*cap, n*cap)
+	for i := range s {
+		s[i] = all[i*cap : i*cap+len : (i+1)*cap]
+	}
+	return s
+}
+
+// MaxAlign is the largest alignment that may be requested from
+// [Arena.NewAligned] and [MakeSliceAligned].
+const MaxAlign = 8192
+
+// NewAligned allocates size bytes of zeroed memory in the arena, aligned to
+// align, and returns a pointer to it. align must be a power of two no greater
+// than [MaxAlign], which is enough to align the memory to a page on most
+// systems. The memory must not be used to store pointers, since the garbage
+// collector does not scan it, nor used after the arena is freed.
+func (a *Arena) NewAligned(size, align uintptr) unsafe.Pointer {
+	return runtime_arena_arena_NewAligned(a.a, size, align)
+}
+
+// MakeSliceAligned is like MakeSlice, but the slice's backing store is aligned
+// to align, which must be a power of two no greater than [MaxAlign]. An
+// alignment smaller than T's own is ignored.
+func MakeSliceAligned[T any](a *Arena, len, cap int, align uintptr) []T {
+	var sl []T
+	runtime_arena_arena_SliceAligned(a.a, &sl, cap, align)
+	return sl[:len]
+}
+
+// DeepCopy returns a copy of v allocated from a, along with everything v
+// references through pointers, strings, slices, maps, and interfaces, so that
+// a value built on the heap can be moved into the arena in one call. Note that
+// v itself is returned by value, so only the values it references live in the
+// arena.
+//
+// Values that are referenced through the same pointer or map more than once
+// are only copied once, so sharing and cycles are preserved. Slices are always
+// copied separately, even if they share a backing store, and so are values
+// that are pointed to both directly and through a pointer to a value that
+// contains them, like a struct field. Channels, functions, and unsafe.Pointer
+// values aren't followed, so the copy shares them with v.
+func DeepCopy[T any](a *Arena, v T) T {
+	runtime_arena_arena_DeepCopy(a.a, &v)
+	return v
+}
+
+// TryNew is like New, but guarantees that the value is allocated from the
+// arena's memory rather than the heap. It returns [ErrTooLarge] if T is too
+// large to be allocated from the arena, and [ErrLimitExceeded] if allocating
+// it would exceed the arena's limit.
+func TryNew[T any](a *Arena) (*T, error) {
+	x, reason := runtime_arena_arena_TryNew(a.a, reflectlite.TypeOf((*T)(nil)))
+	if err := allocError(reason); err != nil {
+		return nil, err
+	}
+	return x.(*T), nil
+}
+
+// TrySlice is like MakeSlice, but guarantees that the slice's backing store is
+// allocated from the arena's memory rather than the heap. It returns
+// [ErrTooLarge] if the backing store is too large to be allocated from the
+// arena, and [ErrLimitExceeded] if allocating it would exceed the arena's limit.
+func TrySlice[T any](a *Arena, len, cap int) ([]T, error) {
+	var sl []T
+	if err := allocError(runtime_arena_arena_TrySlice(a.a, &sl, cap)); err != nil {
+		return nil, err
+	}
+	return sl[:len], nil
+}
+
+// NewString creates a new string in the provided arena with the same contents
+// as b. The string must not be used after the arena is freed. Accessing the
+// string after free may result in a fault, but this fault is also not guaranteed.
+func NewString(a *Arena, b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	sl := MakeSlice[byte](a, len(b), len(b))
+	copy(sl, b)
+	return unsafe.String(&sl[0], len(sl))
+}
+
+// Concat creates a new string in the provided arena by concatenating the
+// strings in strs. The string must not be used after the arena is freed.
+// Accessing the string after free may result in a fault, but this fault is
+// also not guaranteed.
+func Concat(a *Arena, strs ...string) string {
+	n := 0
+	for _, s := range strs {
+		n += len(s)
+	}
+	if n == 0 {
+		return ""
+	}
+	sl := MakeSlice[byte](a, 0, n)
+	for _, s := range strs {
+		sl = append(sl, s...)
+	}
+	return unsafe.String(&sl[0], len(sl))
+}
+
+// MakeMap creates a new map[K]V in the provided arena, with enough space
+// for approximately hint elements. The map's storage, including any storage
+// allocated as the map grows, is allocated from the arena. The map must not be
+// used after the arena is freed. Accessing the map after free may result in a
+// fault, but this fault is also not guaranteed.
+//
+// Since writes to the map may allocate from the arena, they must not happen
+// concurrently with other uses of the arena.
+func MakeMap[K comparable, V any](a *Arena, hint int) map[K]V {
+	var m map[K]V
+	runtime_arena_arena_Map(a.a, &m, hint)
+	return m
+}
+
+// GrowInPlace attempts to increase the capacity of s, which must have been
+// allocated from a, to guarantee space for another n elements without moving
+// its backing store. On success, it returns the grown slice and true. Otherwise
+// it returns s unmodified and false.
+//
+// Growing in place is only possible for the most recent slice or value
+// containing pointers allocated from the arena, so callers must always be
+// prepared for it to fail and fall back to allocating a new slice.
+// If n is negative, GrowInPlace panics.
+func GrowInPlace[S ~[]E, E any](a *Arena, s S, n int) (S, bool) {
+	if n < 0 {
+		panic("cannot be negative")
+	}
+	if n -= cap(s) - len(s); n <= 0 {
+		return s, true
+	}
+	sl := []E(s)
+	if !runtime_arena_arena_GrowInPlace(a.a, &sl, cap(s)+n) {
+		return s, false
+	}
+	return S(sl), true
+}
+
+// Append appends the values vals to s like the built-in append, returning the
+// updated slice. If s does not have enough capacity to hold the new values,
+// its backing store is grown in place if possible, and otherwise it is
+// reallocated from the arena a instead of the heap. The returned slice must
+// not be used after the arena is freed.
+//
+// As with MakeSlice, backing stores too large to be allocated efficiently
+// from an arena may still be allocated on the heap.
+func Append[S ~[]E, E any](a *Arena, s S, vals ...E) S {
+	n := len(s) + len(vals)
+	if n <= cap(s) {
+		return append(s, vals...)
+	}
+	if s2, ok := GrowInPlace(a, s, len(vals)); ok {
+		return append(s2, vals...)
+	}
+	newcap := 2 * cap(s)
+	if newcap < n {
+		newcap = n
+	}
+	sl := MakeSlice[E](a, n, newcap)
+	copy(sl[copy(sl, s):], vals)
+	return S(sl)
+}
+
+// A Pool is a set of values of type T allocated from an arena, which may be
+// put back into the pool individually once they're no longer needed, so that
+// they're reused by subsequent calls to [Pool.Get] instead of taking up more
+// of the arena's memory. It's useful when values of the same type are
+// allocated and discarded many times over the arena's lifetime.
+//
+// Values put into the pool are forgotten when the arena is reset, or released
+// to a mark, so a pool may be used across those operations. A pool must not be
+// used after its arena is freed.
+//
+// A Pool must not be used concurrently by multiple goroutines.
+type Pool[T any] struct {
+	a     *Arena
+	free  []*T    // values put back into the pool, allocated from a
+	epoch uintptr // arena's epoch when free was last valid
+}
+
+// NewPool creates a new, empty pool of values allocated from a.
+func NewPool[T any](a *Arena) *Pool[T] {
+	return &Pool[T]{a: a, epoch: runtime_arena_arena_Epoch(a.a)}
+}
+
+// Get returns a pointer to a zeroed T, either reused from the pool or newly
+// allocated from its arena with [New].
+func (p *Pool[T]) Get() *T {
+	p.validate()
+	if n := len(p.free); n > 0 {
+		x := p.free[n-1]
+		p.free[n-1] = nil
+		p.free = p.free[:n-1]
+		var zero T
+		*x = zero
+		return x
+	}
+	return New[T](p.a)
+}
+
+// Put puts x, which must have been returned by [Pool.Get] on p since the
+// arena was last reset or released to a mark, back into the pool. x must not
+// be used after the call to Put.
+func (p *Pool[T]) Put(x *T) {
+	p.validate()
+	p.free = Append(p.a, p.free, x)
+}
+
+// validate forgets the values in the pool if the arena has been reset or
+// released to a mark since they were put there, which may have freed them.
+func (p *Pool[T]) validate() {
+	if e := runtime_arena_arena_Epoch(p.a.a); e != p.epoch {
+		p.free = nil
+		p.epoch = e
+	}
+}
+
 // Clone makes a shallow copy of the input value that is no longer bound to any
 // arena it may have been allocated from, returning the copy. If it was not
 // allocated from an arena, it is returned untouched. This function is useful
@@ -89,20 +876,115 @@ func reflect_arena_New(a *Arena, typ any) any {
 	return runtime_arena_arena_New(a.a, typ)
 }
 
+//go:linkname reflect_arena_Slice reflect.arena_Slice
+func reflect_arena_Slice(a *Arena, slice any, cap int) {
+	runtime_arena_arena_Slice(a.a, slice, cap)
+}
+
 //go:linkname runtime_arena_newArena
-func runtime_arena_newArena() unsafe.Pointer
+func runtime_arena_newArena(opts unsafe.Pointer) unsafe.Pointer
+
+//go:linkname runtime_arena_newChunkPool
+func runtime_arena_newChunkPool() unsafe.Pointer
+
+//go:linkname runtime_arena_chunkPool_Free
+func runtime_arena_chunkPool_Free(pool unsafe.Pointer)
+
+//go:linkname runtime_arena_chunkPool_Stats
+func runtime_arena_chunkPool_Stats(pool unsafe.Pointer, stats unsafe.Pointer)
 
 //go:linkname runtime_arena_arena_New
 func runtime_arena_arena_New(arena unsafe.Pointer, typ any) any
 
+//go:linkname runtime_arena_arena_NewPinned
+func runtime_arena_arena_NewPinned(arena unsafe.Pointer, typ any) any
+
+//go:linkname runtime_arena_arena_NewAligned
+func runtime_arena_arena_NewAligned(arena unsafe.Pointer, size, align uintptr) unsafe.Pointer
+
+//go:linkname runtime_arena_arena_TryNew
+func runtime_arena_arena_TryNew(arena unsafe.Pointer, typ any) (any, int)
+
 // Mark as noescape to avoid escaping the slice header.
 //
 //go:noescape
 //go:linkname runtime_arena_arena_Slice
 func runtime_arena_arena_Slice(arena unsafe.Pointer, slice any, cap int)
 
+// Mark as noescape to avoid escaping the slice header.
+//
+//go:noescape
+//go:linkname runtime_arena_arena_TrySlice
+func runtime_arena_arena_TrySlice(arena unsafe.Pointer, slice any, cap int) int
+
+// Mark as noescape to avoid escaping the slice header.
+//
+//go:noescape
+//go:linkname runtime_arena_arena_SliceAligned
+func runtime_arena_arena_SliceAligned(arena unsafe.Pointer, slice any, cap int, align uintptr)
+
+// Mark as noescape to avoid escaping the value being copied.
+//
+//go:noescape
+//go:linkname runtime_arena_arena_DeepCopy
+func runtime_arena_arena_DeepCopy(arena unsafe.Pointer, p any)
+
+// Mark as noescape to avoid escaping the map header.
+//
+//go:noescape
+//go:linkname runtime_arena_arena_Map
+func runtime_arena_arena_Map(arena unsafe.Pointer, m any, hint int)
+
+// Mark as noescape to avoid escaping the slice header.
+//
+//go:noescape
+//go:linkname runtime_arena_arena_GrowInPlace
+func runtime_arena_arena_GrowInPlace(arena unsafe.Pointer, slice any, cap int) bool
+
 //go:linkname runtime_arena_arena_Free
 func runtime_arena_arena_Free(arena unsafe.Pointer)
 
+//go:linkname runtime_arena_arena_Reset
+func runtime_arena_arena_Reset(arena unsafe.Pointer)
+
+//go:linkname runtime_arena_arena_SetLimit
+func runtime_arena_arena_SetLimit(arena unsafe.Pointer, limit uint64)
+
+//go:linkname runtime_arena_arena_Freeze
+func runtime_arena_arena_Freeze(arena unsafe.Pointer)
+
+//go:linkname runtime_arena_arena_Unfreeze
+func runtime_arena_arena_Unfreeze(arena unsafe.Pointer)
+
+//go:linkname runtime_arena_arena_Allocated
+func runtime_arena_arena_Allocated(arena unsafe.Pointer) ([]byte, bool)
+
+//go:linkname runtime_arena_arena_Epoch
+func runtime_arena_arena_Epoch(arena unsafe.Pointer) uintptr
+
+//go:linkname runtime_arena_arena_AddCleanup
+func runtime_arena_arena_AddCleanup(arena unsafe.Pointer, f func())
+
+//go:linkname runtime_arena_arena_Stats
+func runtime_arena_arena_Stats(arena unsafe.Pointer, stats unsafe.Pointer)
+
+//go:linkname runtime_arena_arena_Mark
+func runtime_arena_arena_Mark(arena unsafe.Pointer, mark unsafe.Pointer)
+
+//go:linkname runtime_arena_arena_Release
+func runtime_arena_arena_Release(arena unsafe.Pointer, mark unsafe.Pointer)
+
+//go:linkname runtime_arena_setFreeHook
+func runtime_arena_setFreeHook(hook func(stats unsafe.Pointer, finalized bool))
+
+//go:linkname runtime_arena_readLeaks
+func runtime_arena_readLeaks(records unsafe.Pointer, n int) int
+
+//go:linkname runtime_arena_arena_Contains
+func runtime_arena_arena_Contains(arena unsafe.Pointer, p unsafe.Pointer) bool
+
+//go:linkname runtime_arena_of
+func runtime_arena_of(p unsafe.Pointer) any
+
 //go:linkname runtime_arena_heapify
 func runtime_arena_heapify(any) any
@@ -8,7 +8,21 @@ package arena_test
 
 import (
 	"arena"
+	"bytes"
+	"internal/race"
+	"internal/testenv"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 type T1 struct {
@@ -40,3 +54,947 @@ func TestSmokeLarge(t *testing.T) {
 		_ = arena.New[T2](a)
 	}
 }
+
+func TestReset(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	for i := 0; i < 3; i++ {
+		ts := arena.MakeSlice[T1](a, 100, 100)
+		for j := range ts {
+			if ts[j].n != 0 {
+				t.Fatalf("iteration %d: value %d not zeroed after Reset: %d", i, j, ts[j].n)
+			}
+			ts[j].n = j + 1
+		}
+		for j := 0; j < 2*64; j++ {
+			_ = arena.New[T2](a)
+		}
+		a.Reset()
+	}
+}
+
+func TestStats(t *testing.T) {
+	a := arena.NewArena()
+
+	_ = arena.New[T1](a)
+	_ = arena.MakeSlice[T1](a, 10, 10)
+	s := a.Stats()
+	if s.Allocs != 2 {
+		t.Errorf("Stats().Allocs = %d, want 2", s.Allocs)
+	}
+	if want := uint64(11 * unsafe.Sizeof(T1{})); s.AllocBytes != want {
+		t.Errorf("Stats().AllocBytes = %d, want %d", s.AllocBytes, want)
+	}
+	if s.Chunks != 1 {
+		t.Errorf("Stats().Chunks = %d, want 1", s.Chunks)
+	}
+
+	a.Free()
+	if s := a.Stats(); s != (arena.Stats{}) {
+		t.Errorf("Stats() after Free = %+v, want zero", s)
+	}
+}
+
+func TestStatsBySize(t *testing.T) {
+	readMetrics := func() (padding, tail uint64) {
+		samples := []metrics.Sample{
+			{Name: "/gc/heap/user-arena/padding:bytes"},
+			{Name: "/gc/heap/user-arena/chunk-tail:bytes"},
+		}
+		metrics.Read(samples)
+		return samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	}
+	padding0, tail0 := readMetrics()
+
+	a := arena.NewArena()
+	_ = arena.New[byte](a)
+	_ = arena.New[int64](a)
+	s := a.Stats()
+	c := &s.BySize[1]
+	if c.Size != 8 || c.Allocs != 2 || c.PaddingBytes != 7 || s.PaddingBytes != 7 {
+		t.Errorf("got stats %+v for 8-byte size class and %d padding bytes, want 2 allocs with 7 padding bytes", *c, s.PaddingBytes)
+	}
+
+	// Fill the first chunk with values too large for any size class, so
+	// that one of them doesn't fit at its end.
+	for a.Stats().Chunks < 2 {
+		_ = arena.MakeSlice[byte](a, 0, 1<<20+1)
+	}
+	s = a.Stats()
+	if s.TailBytes == 0 || s.BySize[0].TailBytes != s.TailBytes || s.BySize[0].Allocs == 0 {
+		t.Errorf("got %d tail bytes, and stats %+v for large values, want non-zero tail bytes for large values", s.TailBytes, s.BySize[0])
+	}
+	if padding, tail := readMetrics(); padding-padding0 < 7 || tail-tail0 < s.TailBytes {
+		t.Errorf("metrics report %d padding and %d tail bytes since the arena was created, want at least 7 and %d", padding-padding0, tail-tail0, s.TailBytes)
+	}
+	a.Free()
+}
+
+func TestNewPinned(t *testing.T) {
+	pinPanics := func(p *T1) (panicked bool) {
+		defer func() {
+			panicked = recover() != nil
+		}()
+		var pinner runtime.Pinner
+		defer pinner.Unpin()
+		pinner.Pin(p)
+		return false
+	}
+
+	a := arena.NewArena()
+	defer a.Free()
+	if x := arena.New[T1](a); !pinPanics(x) {
+		t.Errorf("Pin of arena value did not panic")
+	}
+	x := arena.NewPinned[T1](a)
+	x.n = 1
+	if pinPanics(x) {
+		t.Errorf("Pin of NewPinned value panicked")
+	}
+
+	// Resetting the arena unpins its memory.
+	a.Reset()
+	if x := arena.New[T1](a); !pinPanics(x) {
+		t.Errorf("Pin of arena value after Reset did not panic")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewPinned of value too large for the arena did not panic")
+		}
+	}()
+	arena.NewPinned[[64 << 20]byte](a)
+}
+
+func TestMakeSlices(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	s := arena.MakeSlices[*int](a, 100, 3, 5)
+	if len(s) != 100 {
+		t.Fatalf("MakeSlices returned %d slices, want 100", len(s))
+	}
+	for i := range s {
+		if len(s[i]) != 3 || cap(s[i]) != 5 {
+			t.Fatalf("slice %d has len %d and cap %d, want 3 and 5", i, len(s[i]), cap(s[i]))
+		}
+		s[i] = s[i][:5]
+		for j := range s[i] {
+			if s[i][j] != nil {
+				t.Fatalf("s[%d][%d] is not zero", i, j)
+			}
+			s[i][j] = new(int)
+			*s[i][j] = i
+		}
+	}
+	for i := range s {
+		for j := range s[i] {
+			if *s[i][j] != i {
+				t.Fatalf("s[%d][%d] = %d, want %d; slices overlap", i, j, *s[i][j], i)
+			}
+		}
+	}
+	if st := a.Stats(); st.Allocs != 2 {
+		t.Errorf("MakeSlices made %d allocations, want 2", st.Allocs)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MakeSlices with len > cap did not panic")
+		}
+	}()
+	arena.MakeSlices[int](a, 1, 2, 1)
+}
+
+func TestPool(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	p := arena.NewPool[T1](a)
+	x := p.Get()
+	x.n = 1
+	p.Put(x)
+	allocs := a.Stats().Allocs
+	if y := p.Get(); y != x || y.n != 0 {
+		t.Errorf("Get after Put returned %p with n = %d, want %p with n = 0", y, y.n, x)
+	}
+	if a.Stats().Allocs != allocs {
+		t.Errorf("Get of value from pool allocated from the arena")
+	}
+	if y := p.Get(); y == x {
+		t.Errorf("Get of empty pool returned value already in use")
+	}
+
+	// Resetting the arena empties the pool.
+	p.Put(x)
+	a.Reset()
+	z := arena.New[T1](a)
+	if y := p.Get(); y == z {
+		t.Errorf("Get after Reset returned value put into pool before Reset")
+	}
+}
+
+func TestGrowInPlace(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	type T3 struct {
+		p *int
+	}
+	s := arena.MakeSlice[T3](a, 1, 1)
+	s2, ok := arena.GrowInPlace(a, s, 10)
+	if !ok {
+		t.Fatal("GrowInPlace of most recent allocation failed")
+	}
+	if len(s2) != 1 || cap(s2) < 11 || &s2[0] != &s[0] {
+		t.Errorf("GrowInPlace returned len %d, cap %d, moved %v", len(s2), cap(s2), &s2[0] != &s[0])
+	}
+	if s3, ok := arena.GrowInPlace(a, s2, 5); !ok || cap(s3) != cap(s2) {
+		t.Errorf("GrowInPlace with sufficient capacity = (cap %d, %v), want (cap %d, true)", cap(s3), ok, cap(s2))
+	}
+}
+
+func TestAppend(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	var s []int
+	for i := 0; i < 1000; i++ {
+		s = arena.Append(a, s, i, i+1)
+	}
+	if len(s) != 2000 {
+		t.Fatalf("len = %d, want 2000", len(s))
+	}
+	for i := 0; i < 1000; i++ {
+		if s[2*i] != i || s[2*i+1] != i+1 {
+			t.Fatalf("s[%d:%d] = %v, want [%d %d]", 2*i, 2*i+2, s[2*i:2*i+2], i, i+1)
+		}
+	}
+	if c := arena.Clone(s); &c[0] == &s[0] {
+		t.Error("Append result was not allocated from the arena")
+	}
+
+	type T3 struct {
+		p *int
+	}
+	var ps []T3
+	for i := 0; i < 100; i++ {
+		ps = arena.Append(a, ps, T3{new(int)})
+	}
+	for i := range ps {
+		if ps[i].p == nil {
+			t.Fatalf("ps[%d] is nil", i)
+		}
+	}
+}
+
+func TestMakeMap(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	m := arena.MakeMap[string, *T1](a, 10)
+	for i := 0; i < 100; i++ {
+		m[string(rune('a'+i))] = &T1{i}
+	}
+	if len(m) != 100 {
+		t.Fatalf("len(m) = %d, want 100", len(m))
+	}
+	for i := 0; i < 100; i++ {
+		if v := m[string(rune('a'+i))]; v == nil || v.n != i {
+			t.Fatalf("m[%q] = %v, want &{%d}", rune('a'+i), v, i)
+		}
+	}
+}
+
+func TestNewString(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	b := []byte("hello")
+	s := arena.NewString(a, b)
+	b[0] = 'j'
+	if s != "hello" {
+		t.Errorf("NewString = %q, want %q", s, "hello")
+	}
+	if c := arena.Clone(s); unsafe.StringData(c) == unsafe.StringData(s) {
+		t.Error("NewString result was not allocated from the arena")
+	}
+	if s := arena.NewString(a, nil); s != "" {
+		t.Errorf("NewString(nil) = %q, want empty string", s)
+	}
+	if s := arena.Concat(a, "foo", "", "bar"); s != "foobar" {
+		t.Errorf("Concat = %q, want %q", s, "foobar")
+	}
+}
+
+func TestMarkRelease(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	outer := arena.New[T1](a)
+	outer.n = 1
+	m1 := a.Mark()
+	s1 := a.Stats()
+	for i := 0; i < 3; i++ {
+		inner := arena.New[T1](a)
+		inner.n = 2
+		m2 := a.Mark()
+		for j := 0; j < 2*64; j++ {
+			_ = arena.New[T2](a)
+		}
+		a.Release(m2)
+		if inner.n != 2 {
+			t.Fatalf("value allocated before inner mark was clobbered")
+		}
+		a.Release(m1)
+	}
+	if outer.n != 1 {
+		t.Errorf("value allocated before outer mark was clobbered")
+	}
+	if s := a.Stats(); s.Allocs != s1.Allocs || s.AllocBytes != s1.AllocBytes {
+		t.Errorf("Stats() after Release = %+v, want %+v", s, s1)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	a.SetLimit(64)
+	if _, err := arena.TrySlice[byte](a, 48, 48); err != nil {
+		t.Fatalf("TrySlice within limit: %v", err)
+	}
+	m := a.Mark()
+	if _, err := arena.TryNew[T1](a); err != nil {
+		t.Fatalf("TryNew within limit: %v", err)
+	}
+	if _, err := arena.TrySlice[byte](a, 16, 16); err != arena.ErrLimitExceeded {
+		t.Fatalf("TrySlice beyond limit: got error %v, want %v", err, arena.ErrLimitExceeded)
+	}
+	if _, err := arena.TryNew[T2](a); err != arena.ErrLimitExceeded {
+		t.Fatalf("TryNew of large value beyond limit: got error %v, want %v", err, arena.ErrLimitExceeded)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("New beyond limit did not panic")
+			}
+		}()
+		_ = arena.New[[16]byte](a)
+	}()
+
+	// Release and Reset give back what was allocated.
+	a.Release(m)
+	if _, err := arena.TrySlice[byte](a, 16, 16); err != nil {
+		t.Errorf("TrySlice after Release: %v", err)
+	}
+	a.Reset()
+	if _, err := arena.TrySlice[byte](a, 64, 64); err != nil {
+		t.Errorf("TrySlice after Reset: %v", err)
+	}
+
+	// Removing the limit allows allocations to succeed again.
+	a.SetLimit(0)
+	if _, err := arena.TryNew[T2](a); err != nil {
+		t.Errorf("TryNew without limit: %v", err)
+	}
+}
+
+type T3 [4 << 20]byte // 4MiB, too large for an arena chunk
+
+func TestWithoutHeapFallback(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	// Try functions never fall back to the heap.
+	if _, err := arena.TryNew[T3](a); err != arena.ErrTooLarge {
+		t.Errorf("TryNew of large value: got error %v, want %v", err, arena.ErrTooLarge)
+	}
+	if _, err := arena.TrySlice[byte](a, 0, len(T3{})); err != arena.ErrTooLarge {
+		t.Errorf("TrySlice of large slice: got error %v, want %v", err, arena.ErrTooLarge)
+	}
+	_ = arena.New[T3](a)
+
+	b := arena.NewArena(arena.WithoutHeapFallback())
+	defer b.Free()
+	if _, err := arena.TryNew[T2](b); err != nil {
+		t.Errorf("TryNew of value that fits: %v", err)
+	}
+	for _, alloc := range []func(){
+		func() { _ = arena.New[T3](b) },
+		func() { _ = arena.MakeSlice[byte](b, 0, len(T3{})) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("allocating large value from arena without heap fallback did not panic")
+				}
+			}()
+			alloc()
+		}()
+	}
+}
+
+func TestNewAligned(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	for align := uintptr(1); align <= arena.MaxAlign; align <<= 1 {
+		// Allocate an odd-sized object first, so the next one isn't
+		// aligned by accident.
+		_ = a.NewAligned(3, 1)
+		p := a.NewAligned(100, align)
+		if uintptr(p)%align != 0 {
+			t.Errorf("NewAligned(100, %d) = %p, not aligned", align, p)
+		}
+		for i, b := range unsafe.Slice((*byte)(p), 100) {
+			if b != 0 {
+				t.Fatalf("NewAligned(100, %d): byte %d = %d, want 0", align, i, b)
+			}
+		}
+
+		_ = a.NewAligned(3, 1)
+		sl := arena.MakeSliceAligned[*T1](a, 2, 4, align)
+		if len(sl) != 2 || cap(sl) != 4 {
+			t.Errorf("MakeSliceAligned(2, 4, %d): got len %d cap %d", align, len(sl), cap(sl))
+		}
+		if p := uintptr(unsafe.Pointer(unsafe.SliceData(sl))); p%align != 0 {
+			t.Errorf("MakeSliceAligned(2, 4, %d) = %#x, not aligned", align, p)
+		}
+	}
+
+	// Allocations too large for a chunk are still aligned.
+	large := arena.MakeSliceAligned[byte](a, 0, len(T3{}), arena.MaxAlign)
+	if p := uintptr(unsafe.Pointer(unsafe.SliceData(large))); p%arena.MaxAlign != 0 {
+		t.Errorf("MakeSliceAligned of large slice = %#x, not aligned", p)
+	}
+
+	for _, align := range []uintptr{0, 3, 2 * arena.MaxAlign} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewAligned(8, %d) did not panic", align)
+				}
+			}()
+			_ = a.NewAligned(8, align)
+		}()
+	}
+}
+
+func TestNewArenaFromBytes(t *testing.T) {
+	buf := make([]byte, 4096)
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	base := uintptr(unsafe.Pointer(&buf[0]))
+	in := func(p unsafe.Pointer, size uintptr) bool {
+		return uintptr(p) >= base && uintptr(p)+size <= base+uintptr(len(buf))
+	}
+
+	a := arena.NewArenaFromBytes(buf)
+	x := arena.New[[4]int64](a)
+	if !in(unsafe.Pointer(x), unsafe.Sizeof(*x)) {
+		t.Errorf("New returned %p, outside of buf", x)
+	}
+	if *x != [4]int64{} {
+		t.Errorf("New returned non-zero value %v", *x)
+	}
+	m := a.Mark()
+	s := arena.MakeSlice[byte](a, 100, 100)
+	if !in(unsafe.Pointer(&s[0]), 100) {
+		t.Errorf("MakeSlice returned %p, outside of buf", &s[0])
+	}
+	for i, b := range s {
+		if b != 0 {
+			t.Fatalf("MakeSlice: s[%d] = %d, want 0", i, b)
+		}
+	}
+	if s, ok := arena.GrowInPlace(a, s, 100); !ok || cap(s) < 200 || s[:200][199] != 0 {
+		t.Errorf("GrowInPlace in buf failed")
+	}
+	if st := a.Stats(); st.Allocs != 2 || st.AllocBytes != 32+200 || st.Chunks != 0 {
+		t.Errorf("got stats %+v, want 2 allocs of 232 bytes and 0 chunks", st)
+	}
+	a.Release(m)
+	if s2 := arena.MakeSlice[byte](a, 1, 1); &s2[0] != &s[0] {
+		t.Errorf("MakeSlice after Release returned %p, want %p", &s2[0], &s[0])
+	}
+
+	if _, err := arena.TrySlice[byte](a, 0, len(buf)); err != arena.ErrLimitExceeded {
+		t.Errorf("TrySlice of more than buf holds: got error %v, want %v", err, arena.ErrLimitExceeded)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("New of value with pointers from caller-provided memory did not panic")
+			}
+		}()
+		_ = arena.New[*int](a)
+	}()
+
+	a.Reset()
+	if y := arena.New[int64](a); uintptr(unsafe.Pointer(y)) != base {
+		t.Errorf("New after Reset returned %p, want %#x", y, base)
+	}
+	a.Free()
+	if buf[len(buf)-1] != 0xff {
+		t.Errorf("arena modified unallocated memory of buf")
+	}
+}
+
+func TestWriteToReadArena(t *testing.T) {
+	buf := make([]byte, 4096)
+	a := arena.NewArenaFromBytes(buf)
+	x := arena.New[[4]int64](a)
+	*x = [4]int64{1, 2, 3, 4}
+	s := arena.MakeSlice[uint32](a, 10, 10)
+	for i := range s {
+		s[i] = uint32(i)
+	}
+	xOff := uintptr(unsafe.Pointer(x)) - uintptr(unsafe.Pointer(&buf[0]))
+	sOff := uintptr(unsafe.Pointer(&s[0])) - uintptr(unsafe.Pointer(&buf[0]))
+
+	var w bytes.Buffer
+	n, err := a.WriteTo(&w)
+	if err != nil || n != int64(w.Len()) {
+		t.Fatalf("WriteTo = %d, %v; want %d, nil", n, err, w.Len())
+	}
+	serial := w.Bytes()
+
+	buf2 := make([]byte, 4096)
+	a2, err := arena.ReadArena(bytes.NewReader(serial), buf2)
+	if err != nil {
+		t.Fatalf("ReadArena: %v", err)
+	}
+	x2 := (*[4]int64)(unsafe.Pointer(&buf2[xOff]))
+	s2 := unsafe.Slice((*uint32)(unsafe.Pointer(&buf2[sOff])), len(s))
+	if *x2 != *x || !slices.Equal(s2, s) {
+		t.Errorf("ReadArena contents = %v, %v; want %v, %v", *x2, s2, *x, s)
+	}
+	if y := arena.New[int64](a2); uintptr(unsafe.Pointer(y)) < uintptr(unsafe.Pointer(&s2[len(s2)-1])) {
+		t.Errorf("New after ReadArena reused serialized contents")
+	}
+	a2.Free()
+	a.Free()
+
+	if _, err := arena.ReadArena(bytes.NewReader(serial), make([]byte, 8)); err == nil {
+		t.Errorf("ReadArena into short buffer succeeded")
+	}
+	if _, err := arena.ReadArena(bytes.NewReader(serial[:len(serial)-1]), make([]byte, 4096)); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadArena of truncated contents: got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := arena.ReadArena(strings.NewReader("not an arena at all"), make([]byte, 4096)); err == nil {
+		t.Errorf("ReadArena of invalid contents succeeded")
+	}
+
+	c := arena.NewArena()
+	defer c.Free()
+	if _, err := c.WriteTo(&w); err == nil {
+		t.Errorf("WriteTo of arena not created by NewArenaFromBytes succeeded")
+	}
+}
+
+func TestContains(t *testing.T) {
+	a := arena.NewArena()
+	b := arena.NewArena(arena.WithConcurrentAlloc())
+	c := arena.NewArenaFromBytes(make([]byte, 1024))
+	defer b.Free()
+	defer c.Free()
+
+	x := unsafe.Pointer(arena.New[T1](a))
+	y := unsafe.Pointer(&arena.MakeSlice[byte](b, 10, 10)[5])
+	z := unsafe.Pointer(arena.New[int64](c))
+	large := unsafe.Pointer(arena.New[T3](a))
+	heap := unsafe.Pointer(new(T1))
+	for _, test := range []struct {
+		name string
+		p    unsafe.Pointer
+		want *arena.Arena
+	}{
+		{"New", x, a},
+		{"concurrent MakeSlice", y, b},
+		{"NewArenaFromBytes", z, c},
+		{"large New", large, nil},
+		{"heap", heap, nil},
+	} {
+		for _, ar := range []*arena.Arena{a, b, c} {
+			if got, want := ar.Contains(test.p), ar == test.want; got != want {
+				t.Errorf("%s: Contains = %v, want %v", test.name, got, want)
+			}
+		}
+		if got := arena.Of(test.p); got != test.want {
+			t.Errorf("%s: Of = %p, want %p", test.name, got, test.want)
+		}
+	}
+
+	c.Reset()
+	if c.Contains(z) || arena.Of(z) != nil {
+		t.Errorf("arena still contains value after Reset")
+	}
+	a.Free()
+	if arena.Of(x) != nil {
+		t.Errorf("Of reported arena after Free")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	a := arena.NewArena()
+	x := arena.New[T1](a)
+	x.n = 1
+	a.Freeze()
+	runtime.GC()
+	if x.n != 1 {
+		t.Errorf("frozen value changed to %d, want 1", x.n)
+	}
+	for _, op := range []struct {
+		name string
+		f    func()
+	}{
+		{"New", func() { _ = arena.New[T1](a) }},
+		{"Reset", a.Reset},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s on frozen arena did not panic", op.name)
+				}
+			}()
+			op.f()
+		}()
+	}
+	if runtime.GOOS == "linux" {
+		func() {
+			defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+			defer func() {
+				if recover() == nil {
+					t.Errorf("write to frozen arena did not fault")
+				}
+			}()
+			x.n = 2
+		}()
+	}
+
+	a.Unfreeze()
+	x.n = 3
+	_ = arena.New[T1](a)
+	a.Freeze()
+	a.Free()
+}
+
+func TestNUMANode(t *testing.T) {
+	for _, opts := range [][]arena.Option{
+		{arena.WithNUMANode(0)},
+		{arena.WithLocalNUMANode()},
+		{arena.WithLocalNUMANode(), arena.WithConcurrentAlloc()},
+	} {
+		a := arena.NewArena(opts...)
+		for range 3 {
+			// Allocate enough to need several chunks.
+			x := arena.New[T2](a)
+			x[len(x)-1] = 1
+		}
+		a.Free()
+	}
+}
+
+type node struct {
+	name  string
+	next  *node
+	tags  []string
+	attrs map[string]*node
+	value any
+}
+
+func TestDeepCopy(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	n1 := &node{name: "n1", tags: []string{"x", "y"}, value: &T1{n: 5}}
+	n2 := &node{name: "n2", next: n1, value: T1{n: 6}}
+	n1.next = n2
+	n1.attrs = map[string]*node{"self": n1, "next": n2}
+
+	c1 := arena.DeepCopy(a, n1)
+	c2 := c1.next
+	for _, p := range []unsafe.Pointer{
+		unsafe.Pointer(c1),
+		unsafe.Pointer(c2),
+		unsafe.Pointer(unsafe.StringData(c1.name)),
+		unsafe.Pointer(unsafe.SliceData(c1.tags)),
+		unsafe.Pointer(unsafe.StringData(c1.tags[1])),
+		unsafe.Pointer(c1.value.(*T1)),
+	} {
+		if !a.Contains(p) {
+			t.Errorf("copy references %p outside of the arena", p)
+		}
+	}
+	if c1 == n1 || c2 == n2 {
+		t.Fatalf("DeepCopy did not copy")
+	}
+	if c2.next != c1 || c1.attrs["self"] != c1 || c1.attrs["next"] != c2 {
+		t.Errorf("DeepCopy did not preserve cycles")
+	}
+	if c1.name != "n1" || c2.name != "n2" || c1.tags[1] != "y" || c1.value.(*T1).n != 5 || c2.value.(T1).n != 6 {
+		t.Errorf("DeepCopy did not copy values")
+	}
+
+	c1.attrs["new"] = c1
+	c1.tags[0] = "z"
+	c1.value.(*T1).n = 7
+	if len(n1.attrs) != 2 || n1.tags[0] != "x" || n1.value.(*T1).n != 5 {
+		t.Errorf("modifying copy modified original")
+	}
+}
+
+func TestConcurrentAlloc(t *testing.T) {
+	a := arena.NewArena(arena.WithConcurrentAlloc())
+	defer a.Free()
+
+	const goroutines = 8
+	var wg sync.WaitGroup
+	results := make([][]*T1, goroutines)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vals := arena.MakeSlice[*T1](a, 0, 0)
+			for i := range 1000 {
+				v := arena.New[T1](a)
+				v.n = g*1000 + i
+				vals = arena.Append(a, vals, v)
+			}
+			results[g] = vals
+		}()
+	}
+	wg.Wait()
+
+	for g, vals := range results {
+		for i, v := range vals {
+			if v.n != g*1000+i {
+				t.Fatalf("goroutine %d: value %d = %d, want %d", g, i, v.n, g*1000+i)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Mark did not panic on a concurrent arena")
+		}
+	}()
+	a.Mark()
+}
+
+func TestChunkPool(t *testing.T) {
+	p := arena.NewChunkPool()
+	for i := 0; i < 3; i++ {
+		a := arena.NewArena(arena.WithChunkPool(p))
+		v := arena.New[T1](a)
+		v.n = i
+		if s := p.Stats(); s.Chunks == 0 || s.IdleChunks != 0 {
+			t.Errorf("Stats() with active arena = %+v", s)
+		}
+		a.Free()
+	}
+	p.Free()
+	if s := p.Stats(); s.Chunks != 0 || s.IdleChunks != 0 {
+		t.Errorf("Stats() after Free = %+v, want zero", s)
+	}
+}
+
+func TestFreeHook(t *testing.T) {
+	// Arenas leaked by other tests may be freed at any time, so only
+	// report the ones with an unusual number of allocations.
+	const explicitAllocs, leakedAllocs = 37, 41
+	freed := make(chan arena.FreeInfo, 2)
+	arena.SetFreeHook(func(info arena.FreeInfo) {
+		if info.Stats.Allocs == explicitAllocs || info.Stats.Allocs == leakedAllocs {
+			freed <- info
+		}
+	})
+	defer arena.SetFreeHook(nil)
+
+	a := arena.NewArena()
+	for range explicitAllocs {
+		_ = arena.New[T1](a)
+	}
+	a.Free()
+	info := <-freed
+	if info.Finalized || info.Stats.Allocs != explicitAllocs || info.Stats.Chunks == 0 {
+		t.Errorf("got %+v for explicitly freed arena", info)
+	}
+
+	// Drop an arena without freeing it.
+	func() {
+		a := arena.NewArena()
+		for range leakedAllocs {
+			_ = arena.New[T1](a)
+		}
+	}()
+	for range 10 {
+		runtime.GC()
+		select {
+		case info := <-freed:
+			if !info.Finalized || info.Stats.Allocs != leakedAllocs {
+				t.Errorf("got %+v for leaked arena", info)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Errorf("free hook not called for leaked arena")
+}
+
+//go:noinline
+func leakArena() {
+	a := arena.NewArena()
+	for range 3 {
+		_ = arena.New[T1](a)
+	}
+}
+
+func TestAddCleanup(t *testing.T) {
+	var got []int
+	cleanup := func(i int) func() {
+		return func() { got = append(got, i) }
+	}
+	check := func(op string, want ...int) {
+		t.Helper()
+		if !slices.Equal(got, want) {
+			t.Errorf("cleanups run by %s = %v, want %v", op, got, want)
+		}
+		got = nil
+	}
+
+	a := arena.NewArena()
+	a.AddCleanup(cleanup(1))
+	a.AddCleanup(cleanup(2))
+	a.Reset()
+	check("Reset", 2, 1)
+
+	a.AddCleanup(cleanup(1))
+	m := a.Mark()
+	a.AddCleanup(cleanup(2))
+	a.AddCleanup(cleanup(3))
+	a.Release(m)
+	check("Release", 3, 2)
+
+	a.AddCleanup(cleanup(4))
+	a.Free()
+	check("Free", 4, 1)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("AddCleanup after Free did not panic")
+		}
+	}()
+	a.AddCleanup(cleanup(5))
+}
+
+func TestLeaks(t *testing.T) {
+	// Run the test in a subprocess, since GODEBUG=arenaleak can only be
+	// set at startup, and the leak report is printed on exit.
+	if os.Getenv("TEST_ARENA_LEAKS") == "" {
+		testenv.MustHaveExec(t)
+		cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^TestLeaks$", "-test.v"))
+		cmd.Env = append(cmd.Env, "TEST_ARENA_LEAKS=1", "GODEBUG=arenaleak=1")
+		out, _ := cmd.CombinedOutput()
+		for _, want := range []string{"PASS\n", "arena leak: 1 arenas with 24 bytes allocated", "arena_test.leakArena(...)"} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("output does not contain %q:\n%s", want, out)
+			}
+		}
+		return
+	}
+
+	if leaks := arena.Leaks(); leaks != nil {
+		t.Fatalf("Leaks() = %+v before leaking an arena, want nil", leaks)
+	}
+	a := arena.NewArena()
+	a.Free()
+	leakArena()
+	for range 10 {
+		runtime.GC()
+		leaks := arena.Leaks()
+		if len(leaks) == 0 {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if len(leaks) != 1 || leaks[0].Count != 1 || leaks[0].AllocBytes != 24 {
+			t.Fatalf("Leaks() = %+v, want one leaked arena with 24 bytes allocated", leaks)
+		}
+		frame, _ := runtime.CallersFrames(leaks[0].Stack).Next()
+		if frame.Function != "arena_test.leakArena" {
+			t.Errorf("leaked arena created by %s, want arena_test.leakArena", frame.Function)
+		}
+		return
+	}
+	t.Errorf("leaked arena not reported")
+}
+
+func TestRaceFree(t *testing.T) {
+	if !race.Enabled {
+		t.Skip("race detector not enabled")
+	}
+	// Run the test in a subprocess, since the race it provokes would
+	// fail this test.
+	if os.Getenv("TEST_ARENA_RACE_FREE") == "" {
+		testenv.MustHaveExec(t)
+		cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^TestRaceFree$", "-test.v"))
+		cmd.Env = append(cmd.Env, "TEST_ARENA_RACE_FREE=1")
+		out, _ := cmd.CombinedOutput()
+		for _, want := range []string{"WARNING: DATA RACE", "arena.(*Arena).Free()", "arena_test.TestRaceFree.func1()"} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("output does not contain %q:\n%s", want, out)
+			}
+		}
+		return
+	}
+
+	a := arena.NewArena()
+	x := arena.New[T1](a)
+	go func() {
+		_ = x.n
+	}()
+	// Give the goroutine time to read x without synchronizing with it,
+	// so the race is reported against the free.
+	time.Sleep(100 * time.Millisecond)
+	a.Free()
+}
+
+func TestArenaHeap(t *testing.T) {
+	// Run the test in a subprocess, since the setting is read at startup.
+	if os.Getenv("TEST_ARENA_HEAP") == "" {
+		testenv.MustHaveExec(t)
+		cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^TestArenaHeap$", "-test.v"))
+		cmd.Env = append(cmd.Env, "TEST_ARENA_HEAP=1", "GODEBUG=arenaheap=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil || !strings.Contains(string(out), "PASS") {
+			t.Errorf("subprocess failed: %v\n%s", err, out)
+		}
+		return
+	}
+
+	a := arena.NewArena()
+	x := arena.New[T1](a)
+	x.n = 1
+	s := arena.MakeSlice[*int](a, 10, 10)
+	s[9] = new(int)
+	if a.Contains(unsafe.Pointer(x)) || a.Contains(unsafe.Pointer(&s[0])) {
+		t.Errorf("arena values allocated from the arena's memory")
+	}
+	p := a.NewAligned(100, arena.MaxAlign)
+	if uintptr(p)%arena.MaxAlign != 0 {
+		t.Errorf("NewAligned returned %p, not aligned to %d", p, arena.MaxAlign)
+	}
+	var pinner runtime.Pinner
+	pinner.Pin(arena.NewPinned[T1](a))
+	pinner.Unpin()
+
+	// The values outlive the arena.
+	a.Free()
+	runtime.GC()
+	if x.n != 1 || s[9] == nil {
+		t.Errorf("arena values changed after Free")
+	}
+}
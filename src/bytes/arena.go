@@ -0,0 +1,50 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package bytes
+
+import "arena"
+
+// SetAllocator causes the Buffer to allocate the memory it grows into from
+// the provided arena instead of the heap, growing its contents in place when
+// possible. The Buffer's contents, including slices returned by [Buffer.Bytes]
+// and [Buffer.AvailableBuffer], must not be used after the arena is freed.
+// Strings returned by [Buffer.String] are still allocated on the heap.
+//
+// Calling SetAllocator with a nil arena restores heap allocation.
+func (b *Buffer) SetAllocator(a *arena.Arena) { b.arena.a = a }
+
+// bufferArena is the arena, if any, that a Buffer grows into.
+type bufferArena struct {
+	a *arena.Arena
+}
+
+// makeSlice is like make([]byte, n, c), but allocates from the buffer's
+// arena if it has one.
+func (b *Buffer) makeSlice(n, c int) []byte {
+	if b.arena.a == nil {
+		return make([]byte, n, c)
+	}
+	return arena.MakeSlice[byte](b.arena.a, n, c)
+}
+
+// growSlice is like the package-level growSlice, but allocates from the
+// buffer's arena if it has one.
+func (b *Buffer) growSlice(s []byte, n int) []byte {
+	if b.arena.a == nil {
+		return growSlice(s, n)
+	}
+	c := len(s) + n
+	if c < 2*cap(s) {
+		c = 2 * cap(s)
+	}
+	if s2, ok := arena.GrowInPlace(b.arena.a, s, c-len(s)); ok {
+		return s2
+	}
+	s2 := arena.MakeSlice[byte](b.arena.a, len(s), c)
+	copy(s2, s)
+	return s2
+}
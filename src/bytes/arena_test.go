@@ -0,0 +1,64 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package bytes_test
+
+import (
+	"arena"
+	. "bytes"
+	"testing"
+	"unsafe"
+)
+
+func TestBufferSetAllocator(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	var b Buffer
+	b.SetAllocator(a)
+	var want []byte
+	for i := 0; i < 1000; i++ {
+		b.WriteString("hello, ")
+		b.WriteByte('0' + byte(i%10))
+		want = append(want, "hello, "...)
+		want = append(want, '0'+byte(i%10))
+	}
+	if !Equal(b.Bytes(), want) {
+		t.Fatalf("Buffer contents = %q, want %q", b.Bytes(), want)
+	}
+	if !a.Contains(unsafe.Pointer(unsafe.SliceData(b.Bytes()))) {
+		t.Errorf("Buffer with arena grew into the heap")
+	}
+
+	// Without an allocator, the buffer grows into the heap again.
+	b.SetAllocator(nil)
+	b.Write(make([]byte, 2*b.Cap()))
+	if a.Contains(unsafe.Pointer(unsafe.SliceData(b.Bytes()))) {
+		t.Errorf("Buffer without arena grew into the arena")
+	}
+}
+
+func TestBufferSetAllocatorGrowsInPlace(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	var b Buffer
+	b.SetAllocator(a)
+	b.WriteByte('x')
+	data := unsafe.SliceData(b.Bytes())
+	cap0 := b.Cap()
+	for b.Cap() < 64*cap0 {
+		b.Write(make([]byte, b.Available()+1))
+		if got := unsafe.SliceData(b.Bytes()); got != data {
+			t.Fatalf("Buffer moved from %p to %p when growing to capacity %d", data, got, b.Cap())
+		}
+	}
+	// Nothing but the buffer was allocated, so the arena holds no
+	// abandoned copies of it.
+	if s := a.Stats(); s.AllocBytes > uint64(b.Cap()) {
+		t.Errorf("arena has %d bytes allocated for a buffer of capacity %d", s.AllocBytes, b.Cap())
+	}
+}
@@ -18,9 +18,10 @@ const smallBufferSize = 64
 // A Buffer is a variable-sized buffer of bytes with [Buffer.Read] and [Buffer.Write] methods.
 // The zero value for Buffer is an empty buffer ready to use.
 type Buffer struct {
-	buf      []byte // contents are the bytes buf[off : len(buf)]
-	off      int    // read at &buf[off], write at &buf[len(buf)]
-	lastRead readOp // last read operation, so that Unread* can work correctly.
+	buf      []byte      // contents are the bytes buf[off : len(buf)]
+	off      int         // read at &buf[off], write at &buf[len(buf)]
+	arena    bufferArena // arena to grow buf in, if any
+	lastRead readOp      // last read operation, so that Unread* can work correctly.
 }
 
 // The readOp constants describe the last action performed on
@@ -134,7 +135,7 @@ func (b *Buffer) grow(n int) int {
 		return i
 	}
 	if b.buf == nil && n <= smallBufferSize {
-		b.buf = make([]byte, n, smallBufferSize)
+		b.buf = b.makeSlice(n, smallBufferSize)
 		return 0
 	}
 	c := cap(b.buf)
@@ -148,7 +149,7 @@ func (b *Buffer) grow(n int) int {
 		panic(ErrTooLarge)
 	} else {
 		// Add b.off to account for b.buf[:b.off] being sliced off the front.
-		b.buf = growSlice(b.buf[b.off:], b.off+n)
+		b.buf = b.growSlice(b.buf[b.off:], b.off+n)
 	}
 	// Restore b.off and len(b.buf).
 	b.off = 0
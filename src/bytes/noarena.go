@@ -0,0 +1,15 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !goexperiment.arenas
+
+package bytes
+
+// bufferArena is empty without the arenas experiment; a Buffer always
+// grows into the heap.
+type bufferArena struct{}
+
+func (b *Buffer) makeSlice(n, c int) []byte { return make([]byte, n, c) }
+
+func (b *Buffer) growSlice(s []byte, n int) []byte { return growSlice(s, n) }
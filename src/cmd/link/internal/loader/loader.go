@@ -2337,8 +2337,15 @@ var blockedLinknames = map[string][]string{
 	"runtime.coroswitch": {"iter"},
 	"runtime.newcoro":    {"iter"},
 	// weak references
-	"internal/weak.runtime_registerWeakPointer": {"internal/weak"},
-	"internal/weak.runtime_makeStrongFromWeak":  {"internal/weak"},
+	"weak.runtime_registerWeakPointer": {"weak"},
+	"weak.runtime_makeStrongFromWeak":  {"weak"},
+	"weak.runtime_setEphemeron":        {"weak"},
+	"weak.runtime_getEphemeron":        {"weak"},
+	// explicitly managed memory
+	"runtime/mem.runtime_allocNoScan": {"runtime/mem"},
+	"runtime/mem.runtime_freeNoScan":  {"runtime/mem"},
+	"runtime/mem.runtime_mapFile":     {"runtime/mem"},
+	"runtime/mem.runtime_unmapFile":   {"runtime/mem"},
 }
 
 // check if a linkname reference to symbol s from pkg is allowed
@@ -0,0 +1,69 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package json
+
+import (
+	"arena"
+	"reflect"
+)
+
+// SetAllocator causes the Decoder to allocate the pointers, slices, strings,
+// and byte slices it creates while decoding into the provided arena instead
+// of the heap. Maps, including their keys, and values stored in interfaces
+// are still allocated on the heap. Decoded values must not be used after the
+// arena is freed.
+//
+// Calling SetAllocator with a nil arena restores heap allocation.
+func (dec *Decoder) SetAllocator(a *arena.Arena) { dec.d.arena.a = a }
+
+// decodeArena is the arena, if any, that a decodeState allocates from.
+type decodeArena struct {
+	a *arena.Arena
+}
+
+// newValue is like reflect.New, but allocates from the decoder's arena
+// if it has one.
+func (d *decodeState) newValue(t reflect.Type) reflect.Value {
+	if d.arena.a == nil {
+		return reflect.New(t)
+	}
+	return reflect.ArenaNew(d.arena.a, t)
+}
+
+// growSlice is like v.Grow(n), but allocates from the decoder's arena
+// if it has one.
+func (d *decodeState) growSlice(v reflect.Value, n int) {
+	if d.arena.a == nil {
+		v.Grow(n)
+		return
+	}
+	if v.Cap()-v.Len() >= n {
+		return
+	}
+	newCap := max(2*v.Cap(), v.Len()+n, 4)
+	s := reflect.ArenaMakeSlice(d.arena.a, v.Type(), v.Len(), newCap)
+	reflect.Copy(s, v)
+	v.Set(s)
+}
+
+// makeBytes returns a byte slice of length n, allocated from the decoder's
+// arena if it has one.
+func (d *decodeState) makeBytes(n int) []byte {
+	if d.arena.a == nil {
+		return make([]byte, n)
+	}
+	return arena.MakeSlice[byte](d.arena.a, n, n)
+}
+
+// string returns a copy of b as a string, allocated from the decoder's arena
+// if it has one.
+func (d *decodeState) string(b []byte) string {
+	if d.arena.a == nil {
+		return string(b)
+	}
+	return arena.NewString(d.arena.a, b)
+}
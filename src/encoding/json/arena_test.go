@@ -0,0 +1,67 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package json
+
+import (
+	"arena"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderSetAllocator(t *testing.T) {
+	type inner struct {
+		N int
+	}
+	type value struct {
+		S      string
+		B      []byte
+		P      *inner
+		L      []int
+		Nested []*inner
+		Num    Number
+	}
+	const input = `{"S":"hello","B":"aGVsbG8=","P":{"N":1},"L":[1,2,3,4,5,6,7,8,9],"Nested":[{"N":2},{"N":3}],"Num":42}`
+	want := value{
+		S:      "hello",
+		B:      []byte("hello"),
+		P:      &inner{1},
+		L:      []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Nested: []*inner{{2}, {3}},
+		Num:    "42",
+	}
+
+	a := arena.NewArena()
+	defer a.Free()
+
+	dec := NewDecoder(strings.NewReader(input + input))
+	dec.SetAllocator(a)
+	var got value
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode:\n\tgot:  %#v\n\twant: %#v", got, want)
+	}
+	stats := a.Stats()
+	if stats.Allocs == 0 {
+		t.Fatalf("Decode with arena made no arena allocations")
+	}
+
+	// Without an allocator, decoding goes back to the heap.
+	dec.SetAllocator(nil)
+	var got2 value
+	if err := dec.Decode(&got2); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !reflect.DeepEqual(got2, want) {
+		t.Errorf("Decode:\n\tgot:  %#v\n\twant: %#v", got2, want)
+	}
+	if got := a.Stats().Allocs; got != stats.Allocs {
+		t.Errorf("Decode without arena made %d arena allocations, want 0", got-stats.Allocs)
+	}
+}
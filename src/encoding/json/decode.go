@@ -217,6 +217,7 @@ type decodeState struct {
 	savedError            error
 	useNumber             bool
 	disallowUnknownFields bool
+	arena                 decodeArena
 }
 
 // readIndex returns the position of the last byte read.
@@ -427,7 +428,7 @@ func (d *decodeState) valueQuoted() any {
 // If it encounters an Unmarshaler, indirect stops and returns that.
 // If decodingNull is true, indirect stops at the first settable pointer so it
 // can be set to nil.
-func indirect(v reflect.Value, decodingNull bool) (Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
+func (d *decodeState) indirect(v reflect.Value, decodingNull bool) (Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
 	// Issue #24153 indicates that it is generally not a guaranteed property
 	// that you may round-trip a reflect.Value by calling Value.Addr().Elem()
 	// and expect the value to still be settable for values derived from
@@ -477,7 +478,7 @@ func indirect(v reflect.Value, decodingNull bool) (Unmarshaler, encoding.TextUnm
 			break
 		}
 		if v.IsNil() {
-			v.Set(reflect.New(v.Type().Elem()))
+			v.Set(d.newValue(v.Type().Elem()))
 		}
 		if v.Type().NumMethod() > 0 && v.CanInterface() {
 			if u, ok := v.Interface().(Unmarshaler); ok {
@@ -504,7 +505,7 @@ func indirect(v reflect.Value, decodingNull bool) (Unmarshaler, encoding.TextUnm
 // The first byte of the array ('[') has been read already.
 func (d *decodeState) array(v reflect.Value) error {
 	// Check for unmarshaler.
-	u, ut, pv := indirect(v, false)
+	u, ut, pv := d.indirect(v, false)
 	if u != nil {
 		start := d.readIndex()
 		d.skip()
@@ -547,7 +548,7 @@ func (d *decodeState) array(v reflect.Value) error {
 		// Expand slice length, growing the slice if necessary.
 		if v.Kind() == reflect.Slice {
 			if i >= v.Cap() {
-				v.Grow(1)
+				d.growSlice(v, 1)
 			}
 			if i >= v.Len() {
 				v.SetLen(i + 1)
@@ -601,7 +602,7 @@ var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
 // The first byte ('{') of the object has been read already.
 func (d *decodeState) object(v reflect.Value) error {
 	// Check for unmarshaler.
-	u, ut, pv := indirect(v, false)
+	u, ut, pv := d.indirect(v, false)
 	if u != nil {
 		start := d.readIndex()
 		d.skip()
@@ -720,7 +721,7 @@ func (d *decodeState) object(v reflect.Value) error {
 								destring = false
 								break
 							}
-							subv.Set(reflect.New(subv.Type().Elem()))
+							subv.Set(d.newValue(subv.Type().Elem()))
 						}
 						subv = subv.Elem()
 					}
@@ -859,7 +860,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 		return nil
 	}
 	isNull := item[0] == 'n' // null
-	u, ut, pv := indirect(v, isNull)
+	u, ut, pv := d.indirect(v, isNull)
 	if u != nil {
 		return u.UnmarshalJSON(item)
 	}
@@ -945,7 +946,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 				d.saveError(&UnmarshalTypeError{Value: "string", Type: v.Type(), Offset: int64(d.readIndex())})
 				break
 			}
-			b := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
+			b := d.makeBytes(base64.StdEncoding.DecodedLen(len(s)))
 			n, err := base64.StdEncoding.Decode(b, s)
 			if err != nil {
 				d.saveError(err)
@@ -953,7 +954,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			}
 			v.SetBytes(b[:n])
 		case reflect.String:
-			t := string(s)
+			t := d.string(s)
 			if v.Type() == numberType && !isValidNumber(t) {
 				return fmt.Errorf("json: invalid number literal, trying to unmarshal %q into Number", item)
 			}
@@ -978,7 +979,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			if v.Kind() == reflect.String && v.Type() == numberType {
 				// s must be a valid number, because it's
 				// already been tokenized.
-				v.SetString(string(item))
+				v.SetString(d.string(item))
 				break
 			}
 			if fromQuoted {
@@ -0,0 +1,21 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !goexperiment.arenas
+
+package json
+
+import "reflect"
+
+// decodeArena is empty without the arenas experiment; the decoder always
+// allocates from the heap.
+type decodeArena struct{}
+
+func (d *decodeState) newValue(t reflect.Type) reflect.Value { return reflect.New(t) }
+
+func (d *decodeState) growSlice(v reflect.Value, n int) { v.Grow(n) }
+
+func (d *decodeState) makeBytes(n int) []byte { return make([]byte, n) }
+
+func (d *decodeState) string(b []byte) string { return string(b) }
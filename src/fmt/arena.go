@@ -0,0 +1,39 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package fmt
+
+import "arena"
+
+// ArenaSprintf is like [Sprintf], but allocates the resulting string in the
+// provided arena. The string must not be used after the arena is freed.
+func ArenaSprintf(a *arena.Arena, format string, args ...any) string {
+	p := newPrinter()
+	p.doPrintf(format, args)
+	s := arena.NewString(a, p.buf)
+	p.free()
+	return s
+}
+
+// ArenaSprint is like [Sprint], but allocates the resulting string in the
+// provided arena. The string must not be used after the arena is freed.
+func ArenaSprint(a *arena.Arena, args ...any) string {
+	p := newPrinter()
+	p.doPrint(args)
+	s := arena.NewString(a, p.buf)
+	p.free()
+	return s
+}
+
+// ArenaSprintln is like [Sprintln], but allocates the resulting string in the
+// provided arena. The string must not be used after the arena is freed.
+func ArenaSprintln(a *arena.Arena, args ...any) string {
+	p := newPrinter()
+	p.doPrintln(args)
+	s := arena.NewString(a, p.buf)
+	p.free()
+	return s
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package fmt_test
+
+import (
+	"arena"
+	. "fmt"
+	"testing"
+)
+
+func TestArenaSprint(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	if got, want := ArenaSprintf(a, "%d-%s", 42, "x"), "42-x"; got != want {
+		t.Errorf("ArenaSprintf = %q, want %q", got, want)
+	}
+	if got, want := ArenaSprint(a, "a", 1, 2), "a1 2"; got != want {
+		t.Errorf("ArenaSprint = %q, want %q", got, want)
+	}
+	if got, want := ArenaSprintln(a, "a", 1), "a 1\n"; got != want {
+		t.Errorf("ArenaSprintln = %q, want %q", got, want)
+	}
+}
@@ -93,7 +93,7 @@ var depsRules = `
 	< internal/race
 	< internal/msan
 	< internal/asan
-	< internal/weak
+	< weak
 	< sync
 	< internal/bisect
 	< internal/godebug
@@ -115,7 +115,7 @@ var depsRules = `
 	RUNTIME
 	< io;
 
-	RUNTIME
+	RUNTIME, io
 	< arena;
 
 	syscall !< io;
@@ -134,6 +134,9 @@ var depsRules = `
 	< internal/syscall/execenv
 	< SYSCALL;
 
+	SYSCALL
+	< runtime/mem;
+
 	# TIME is SYSCALL plus the core packages about time, including context.
 	SYSCALL
 	< time/tzdata
@@ -157,19 +160,19 @@ var depsRules = `
 	MATH
 	< math/rand, math/rand/v2;
 
-	MATH
-	< runtime/metrics;
-
 	RUNTIME, math/rand/v2
 	< internal/concurrent;
 
 	MATH, unicode/utf8
 	< strconv;
 
+	MATH, TIME, io, strconv
+	< runtime/metrics;
+
 	unicode !< strconv;
 
 	# STR is basic string and buffer manipulation.
-	RUNTIME, io, unicode/utf8, unicode/utf16, unicode
+	RUNTIME, arena, io, unicode/utf8, unicode/utf16, unicode
 	< bytes, strings
 	< bufio;
 
@@ -591,6 +594,9 @@ var depsRules = `
 	OS, compress/gzip, internal/lazyregexp
 	< internal/profile;
 
+	FMT, compress/gzip, internal/saferio
+	< runtime/heapdump;
+
 	html, internal/profile, net/http, runtime/pprof, runtime/trace
 	< net/http/pprof;
 
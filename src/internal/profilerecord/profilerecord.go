@@ -9,18 +9,27 @@
 package profilerecord
 
 type StackRecord struct {
-	Stack []uintptr
+	Stack      []uintptr
+	AllocBytes uint64 // bytes allocated by the goroutine, for goroutine profiles
 }
 
 type MemProfileRecord struct {
 	AllocBytes, FreeBytes     int64
 	AllocObjects, FreeObjects int64
+	FreeLifetime              int64  // total lifetime of the freed objects, in nanoseconds
+	Type                      string // type of the allocated objects, with GODEBUG=memprofiletype=1
 	Stack                     []uintptr
 }
 
 func (r *MemProfileRecord) InUseBytes() int64   { return r.AllocBytes - r.FreeBytes }
 func (r *MemProfileRecord) InUseObjects() int64 { return r.AllocObjects - r.FreeObjects }
 
+type ArenaAllocRecord struct {
+	AllocBytes, AllocObjects int64
+	Origin                   uintptr // return PC in the function that created the arenas, or 0
+	Stack                    []uintptr
+}
+
 type BlockProfileRecord struct {
 	Count  int64
 	Cycles int64
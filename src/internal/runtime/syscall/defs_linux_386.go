@@ -12,6 +12,19 @@ const (
 	SYS_EPOLL_CREATE1 = 329
 	SYS_EPOLL_PWAIT2  = 441
 	SYS_EVENTFD2      = 328
+	SYS_MBIND         = 274
+	SYS_GETCPU        = 318
+
+	SYS_SCHED_SETAFFINITY  = 241
+	SYS_SCHED_GETPARAM     = 155
+	SYS_SCHED_SETSCHEDULER = 156
+	SYS_SCHED_GETSCHEDULER = 157
+	SYS_GETPRIORITY        = 96
+	SYS_SETPRIORITY        = 97
+
+	SYS_IO_URING_SETUP = 425
+	SYS_IO_URING_ENTER = 426
+	SYS_STATX          = 383
 
 	EFD_NONBLOCK = 0x800
 )
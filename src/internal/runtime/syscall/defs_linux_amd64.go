@@ -12,6 +12,19 @@ const (
 	SYS_EPOLL_CREATE1 = 291
 	SYS_EPOLL_PWAIT2  = 441
 	SYS_EVENTFD2      = 290
+	SYS_MBIND         = 237
+	SYS_GETCPU        = 309
+
+	SYS_SCHED_SETAFFINITY  = 203
+	SYS_SCHED_GETPARAM     = 143
+	SYS_SCHED_SETSCHEDULER = 144
+	SYS_SCHED_GETSCHEDULER = 145
+	SYS_GETPRIORITY        = 140
+	SYS_SETPRIORITY        = 141
+
+	SYS_IO_URING_SETUP = 425
+	SYS_IO_URING_ENTER = 426
+	SYS_STATX          = 332
 
 	EFD_NONBLOCK = 0x800
 )
@@ -12,6 +12,19 @@ const (
 	SYS_MPROTECT      = 226
 	SYS_EPOLL_PWAIT2  = 441
 	SYS_EVENTFD2      = 19
+	SYS_MBIND         = 235
+	SYS_GETCPU        = 168
+
+	SYS_SCHED_SETAFFINITY  = 122
+	SYS_SCHED_GETPARAM     = 121
+	SYS_SCHED_SETSCHEDULER = 119
+	SYS_SCHED_GETSCHEDULER = 120
+	SYS_GETPRIORITY        = 141
+	SYS_SETPRIORITY        = 140
+
+	SYS_IO_URING_SETUP = 425
+	SYS_IO_URING_ENTER = 426
+	SYS_STATX          = 291
 
 	EFD_NONBLOCK = 0x800
 )
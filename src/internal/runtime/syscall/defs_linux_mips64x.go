@@ -14,6 +14,19 @@ const (
 	SYS_EPOLL_CREATE1 = 5285
 	SYS_EPOLL_PWAIT2  = 5441
 	SYS_EVENTFD2      = 5284
+	SYS_MBIND         = 5227
+	SYS_GETCPU        = 5271
+
+	SYS_SCHED_SETAFFINITY  = 5195
+	SYS_SCHED_GETPARAM     = 5140
+	SYS_SCHED_SETSCHEDULER = 5141
+	SYS_SCHED_GETSCHEDULER = 5142
+	SYS_GETPRIORITY        = 5137
+	SYS_SETPRIORITY        = 5138
+
+	SYS_IO_URING_SETUP = 5425
+	SYS_IO_URING_ENTER = 5426
+	SYS_STATX          = 5326
 
 	EFD_NONBLOCK = 0x80
 )
@@ -14,6 +14,19 @@ const (
 	SYS_EPOLL_CREATE1 = 4326
 	SYS_EPOLL_PWAIT2  = 4441
 	SYS_EVENTFD2      = 4325
+	SYS_MBIND         = 4268
+	SYS_GETCPU        = 4312
+
+	SYS_SCHED_SETAFFINITY  = 4239
+	SYS_SCHED_GETPARAM     = 4159
+	SYS_SCHED_SETSCHEDULER = 4160
+	SYS_SCHED_GETSCHEDULER = 4161
+	SYS_GETPRIORITY        = 4096
+	SYS_SETPRIORITY        = 4097
+
+	SYS_IO_URING_SETUP = 4425
+	SYS_IO_URING_ENTER = 4426
+	SYS_STATX          = 4366
 
 	EFD_NONBLOCK = 0x80
 )
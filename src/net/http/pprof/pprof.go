@@ -34,7 +34,7 @@
 //
 //   - debug=N (all profiles): response format: N = 0: binary (default), N > 0: plaintext
 //   - gc=N (heap profile): N > 0: run a garbage collection cycle before profiling
-//   - seconds=N (allocs, block, goroutine, heap, mutex, threadcreate profiles): return a delta profile
+//   - seconds=N (allocs, arenaallocs, block, goroutine, heap, lifetime, mutex, offcpu, threadcreate profiles): return a delta profile
 //   - seconds=N (cpu (profile), trace profiles): profile for the given duration
 //
 // # Usage examples
@@ -352,20 +352,26 @@ func collectProfile(p *pprof.Profile) (*profile.Profile, error) {
 
 var profileSupportsDelta = map[handler]bool{
 	"allocs":       true,
+	"arenaallocs":  true,
 	"block":        true,
 	"goroutine":    true,
 	"heap":         true,
+	"lifetime":     true,
 	"mutex":        true,
+	"offcpu":       true,
 	"threadcreate": true,
 }
 
 var profileDescriptions = map[string]string{
 	"allocs":       "A sampling of all past memory allocations",
+	"arenaallocs":  "A sampling of all past memory allocations from arenas",
 	"block":        "Stack traces that led to blocking on synchronization primitives",
 	"cmdline":      "The command line invocation of the current program",
 	"goroutine":    "Stack traces of all current goroutines. Use debug=2 as a query parameter to export in the same format as an unrecovered panic.",
 	"heap":         "A sampling of memory allocations of live objects. You can specify the gc GET parameter to run GC before taking the heap sample.",
+	"lifetime":     "A sampling of the lifetimes of freed objects, by allocation site",
 	"mutex":        "Stack traces of holders of contended mutexes",
+	"offcpu":       "Stack traces that led to time spent blocked or in system calls",
 	"profile":      "CPU profile. You can specify the duration in the seconds GET parameter. After you get the profile file, use the go tool pprof command to investigate the profile.",
 	"threadcreate": "Stack traces that led to the creation of new OS threads",
 	"trace":        "A trace of execution of the current program. You can specify the duration in the seconds GET parameter. After you get the trace file, use the go tool trace command to investigate the trace.",
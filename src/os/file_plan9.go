@@ -5,6 +5,7 @@
 package os
 
 import (
+	"errors"
 	"internal/bytealg"
 	"internal/poll"
 	"internal/stringslite"
@@ -61,6 +62,12 @@ func NewFile(fd uintptr, name string) *File {
 	return f
 }
 
+// NewPollFile is not supported on this system: it returns an error
+// wrapping [errors.ErrUnsupported].
+func NewPollFile(fd uintptr, name string) (*File, error) {
+	return nil, &PathError{Op: "newpollfile", Path: name, Err: errors.ErrUnsupported}
+}
+
 // Auxiliary information if the File describes a directory
 type dirInfo struct {
 	mu   sync.Mutex
@@ -118,6 +118,76 @@ func NewFile(fd uintptr, name string) *File {
 	return f
 }
 
+// NewPollFile returns a new File with the given file descriptor and
+// name, like [NewFile], but it always adds the descriptor to the
+// runtime's poller, putting it in non-blocking mode first if needed,
+// and it returns an error if it can't. Reads and writes of the File
+// block only the calling goroutine, not an operating system thread,
+// and its SetDeadline methods work.
+//
+// NewPollFile is meant for descriptors that the program creates with
+// system calls, such as event, timer, and signal descriptors, and
+// character devices. Descriptors for regular files and directories
+// can't be polled.
+//
+// To wait for a descriptor to be ready, without reading or writing
+// it through the File, use the Read or Write method of the File's
+// [syscall.RawConn], whose function can do the system call and report
+// whether it would block.
+//
+// After passing it to NewPollFile, fd may become invalid under the same
+// conditions described in the comments of the Fd method, and the same
+// constraints apply.
+func NewPollFile(fd uintptr, name string) (*File, error) {
+	fdi := int(fd)
+	if fdi < 0 {
+		return nil, &PathError{Op: "newpollfile", Path: name, Err: syscall.EBADF}
+	}
+
+	var st syscall.Stat_t
+	err := ignoringEINTR(func() error {
+		return syscall.Fstat(fdi, &st)
+	})
+	if err != nil {
+		return nil, &PathError{Op: "fstat", Path: name, Err: err}
+	}
+	// Some pollers accept regular files and directories, but always
+	// report them ready, or misreport them. See newFile.
+	if typ := st.Mode & syscall.S_IFMT; typ == syscall.S_IFREG || typ == syscall.S_IFDIR {
+		return nil, &PathError{Op: "newpollfile", Path: name, Err: syscall.EPERM}
+	}
+
+	flags, err := unix.Fcntl(fdi, syscall.F_GETFL, 0)
+	if err != nil {
+		return nil, &PathError{Op: "fcntl", Path: name, Err: err}
+	}
+	f := &File{&file{
+		pfd: poll.FD{
+			Sysfd:         fdi,
+			IsStream:      true,
+			ZeroReadIsEOF: true,
+		},
+		name:        name,
+		stdoutOrErr: fdi == 1 || fdi == 2,
+		appendMode:  flags&syscall.O_APPEND != 0,
+	}}
+	if !unix.HasNonblockFlag(flags) {
+		if err := syscall.SetNonblock(fdi, true); err != nil {
+			return nil, &PathError{Op: "setnonblock", Path: name, Err: err}
+		}
+		f.nonblock = true
+	}
+	if err := f.pfd.Init("file", true); err != nil {
+		if f.nonblock {
+			syscall.SetNonblock(fdi, false)
+		}
+		return nil, &PathError{Op: "newpollfile", Path: name, Err: err}
+	}
+
+	runtime.SetFinalizer(f.file, (*file).close)
+	return f, nil
+}
+
 // net_newUnixFile is a hidden entry point called by net.conn.File.
 // This is used so that a nonblocking network connection will become
 // blocking if code calls the Fd method. We don't want that for direct
@@ -90,6 +90,12 @@ func NewFile(fd uintptr, name string) *File {
 	return newFile(h, name, "file")
 }
 
+// NewPollFile is not supported on this system: it returns an error
+// wrapping [errors.ErrUnsupported].
+func NewPollFile(fd uintptr, name string) (*File, error) {
+	return nil, &PathError{Op: "newpollfile", Path: name, Err: errors.ErrUnsupported}
+}
+
 func epipecheck(file *File, e error) {
 }
 
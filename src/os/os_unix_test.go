@@ -7,6 +7,7 @@
 package os_test
 
 import (
+	"errors"
 	"internal/testenv"
 	"io"
 	. "os"
@@ -342,6 +343,63 @@ func TestNewFileInvalid(t *testing.T) {
 	}
 }
 
+func TestNewPollFile(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		t.Skipf("syscall.Pipe is not available on %s.", runtime.GOOS)
+	}
+
+	p := make([]int, 2)
+	if err := syscall.Pipe(p); err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	defer syscall.Close(p[1])
+
+	// The read side is in blocking mode, which NewFile would keep.
+	file, err := NewPollFile(uintptr(p[0]), "pipe")
+	if err != nil {
+		syscall.Close(p[0])
+		t.Fatalf("NewPollFile: %v", err)
+	}
+	defer file.Close()
+
+	b := make([]byte, 1)
+	file.SetReadDeadline(time.Now().Add(time.Millisecond))
+	if _, err := file.Read(b); !isDeadlineExceeded(err) {
+		t.Fatalf("No timeout reading from file: %v", err)
+	}
+	file.SetReadDeadline(time.Time{})
+
+	// Wait for the pipe to be readable, doing the reads ourselves.
+	rc, err := file.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timer := time.AfterFunc(10*time.Millisecond, func() { syscall.Write(p[1], []byte("a")) })
+	defer timer.Stop()
+	var n int
+	var rerr error
+	err = rc.Read(func(fd uintptr) bool {
+		n, rerr = syscall.Read(int(fd), b)
+		return rerr != syscall.EAGAIN
+	})
+	if err != nil || rerr != nil || n != 1 || b[0] != 'a' {
+		t.Fatalf("read %d bytes %q, errors %v, %v; want 1 byte %q", n, b[:max(n, 0)], err, rerr, "a")
+	}
+}
+
+func TestNewPollFileRegular(t *testing.T) {
+	t.Parallel()
+	f, err := Open("os_unix_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := NewPollFile(f.Fd(), f.Name()); !errors.Is(err, syscall.EPERM) {
+		t.Errorf("NewPollFile of a regular file got error %v, want %v", err, syscall.EPERM)
+	}
+}
+
 func TestSplitPath(t *testing.T) {
 	t.Parallel()
 	for _, tt := range []struct{ path, wantDir, wantBase string }{
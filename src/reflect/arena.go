@@ -15,4 +15,27 @@ func ArenaNew(a *arena.Arena, typ Type) Value {
 	return ValueOf(arena_New(a, PointerTo(typ)))
 }
 
+// ArenaMakeSlice is like [MakeSlice], except that it allocates the backing
+// store for the new slice in the provided arena.
+func ArenaMakeSlice(a *arena.Arena, typ Type, len, cap int) Value {
+	if typ.Kind() != Slice {
+		panic("reflect.ArenaMakeSlice of non-slice type")
+	}
+	if len < 0 {
+		panic("reflect.ArenaMakeSlice: negative len")
+	}
+	if cap < 0 {
+		panic("reflect.ArenaMakeSlice: negative cap")
+	}
+	if len > cap {
+		panic("reflect.ArenaMakeSlice: len > cap")
+	}
+
+	p := New(typ)
+	arena_Slice(a, p.Interface(), cap)
+	return p.Elem().Slice(0, len)
+}
+
 func arena_New(a *arena.Arena, typ any) any
+
+func arena_Slice(a *arena.Arena, slice any, cap int)
@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package reflect_test
+
+import (
+	"arena"
+	. "reflect"
+	"testing"
+)
+
+func TestArenaNew(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	type T struct {
+		P *int
+		N int
+	}
+	v := ArenaNew(a, TypeFor[T]())
+	if v.Type() != TypeFor[*T]() {
+		t.Fatalf("ArenaNew returned value of type %v, want %v", v.Type(), TypeFor[*T]())
+	}
+	v.Elem().Field(1).SetInt(42)
+	if got := v.Interface().(*T).N; got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+}
+
+func TestArenaMakeSlice(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	typ := TypeFor[[]*int]()
+	v := ArenaMakeSlice(a, typ, 3, 10)
+	if v.Type() != typ || v.Len() != 3 || v.Cap() != 10 {
+		t.Fatalf("ArenaMakeSlice = %v with len %d and cap %d, want %v with len 3 and cap 10", v.Type(), v.Len(), v.Cap(), typ)
+	}
+	x := 1
+	v.Index(2).Set(ValueOf(&x))
+	if s := v.Interface().([]*int); *s[2] != 1 {
+		t.Errorf("got %d, want 1", *s[2])
+	}
+
+	shouldPanic("negative len", func() { ArenaMakeSlice(a, typ, -1, 1) })
+	shouldPanic("len > cap", func() { ArenaMakeSlice(a, typ, 2, 1) })
+	shouldPanic("non-slice type", func() { ArenaMakeSlice(a, TypeFor[int](), 0, 0) })
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Soft goroutine-to-P affinity.
+//
+// A goroutine that has called SetPAffinity(true) records in g.affinity
+// the P it last ran on. When another P makes it runnable, ready puts it
+// in that P's affinityNext slot, if the P is running and the slot is
+// free, rather than on the readying P's run queue. The P moves the
+// goroutine from the slot to the tail of its local run queue the next
+// time it looks for work. If the P is idle, ready takes it off the idle
+// list, puts the goroutine on its run queue, and starts an M to run it.
+// Otherwise, the goroutine is queued as usual.
+//
+// The hint never keeps a goroutine from running:
+//
+//   - A P with nothing else to do steals from another P's affinityNext
+//     slot, as from its runnext slot, once that P's run queue is empty.
+//
+//   - ready checks that the P is still running after it fills the slot,
+//     and pidleput empties the slot onto the global run queue after the
+//     P stops running. Since both use sequentially consistent atomics,
+//     one of them sees the other, and the goroutine doesn't get stuck on
+//     an idle P.
+//
+// Background and idle goroutines are queued according to their class
+// whatever their affinity.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	"unsafe"
+)
+
+// SetPAffinity sets whether the scheduler should try to run the calling
+// goroutine on the same processor, the P in the scheduler's terms, each
+// time it becomes runnable, and returns the previous setting. Goroutines
+// start without affinity.
+//
+// Affinity is a hint, for goroutines that benefit from finding their
+// data in the same CPU caches each time they run, such as the stages of
+// a packet processing pipeline. When the goroutine's last processor is
+// running another goroutine, it waits there, behind that processor's
+// other goroutines, rather than running elsewhere sooner, unless an
+// idle processor takes it. Unlike LockOSThread, it doesn't tie the
+// goroutine to an operating system thread, or guarantee where it runs.
+func SetPAffinity(on bool) bool {
+	gp := getg()
+	old := gp.affinity != 0
+	if !on {
+		gp.affinity = 0
+	} else if !old {
+		mp := acquirem()
+		gp.affinity = uint16(mp.p.ptr().id + 1)
+		releasem(mp)
+	}
+	return old
+}
+
+// runqputAffinity tries to put gp, which has just become runnable on pp,
+// in the affinityNext slot of the P it last ran on, and reports whether
+// it did.
+//
+// pp may be nil.
+func runqputAffinity(pp *p, gp *g) bool {
+	id := int32(gp.affinity) - 1
+	if pp != nil && pp.id == id || id >= gomaxprocs {
+		return false
+	}
+	if c := SchedClass(gp.schedClass); c == SchedClassBackground || c == schedClassIdle {
+		return false
+	}
+	ap := allp[id]
+	switch atomic.Load(&ap.status) {
+	case _Prunning:
+	case _Pidle:
+		lock(&sched.lock)
+		ok := pidletake(ap)
+		unlock(&sched.lock)
+		if !ok {
+			return false
+		}
+		runqput(ap, gp, false)
+		startm(ap, false, false)
+		return true
+	default:
+		return false
+	}
+	if !ap.affinityNext.cas(0, guintptr(unsafe.Pointer(gp))) {
+		return false
+	}
+	// If ap stopped running meanwhile, it may have missed gp. If it
+	// took gp anyway, it's on the global run queue.
+	if atomic.Load(&ap.status) != _Prunning && ap.affinityNext.cas(guintptr(unsafe.Pointer(gp)), 0) {
+		return false
+	}
+	return true
+}
+
+// affinityget moves the goroutine in pp's affinityNext slot, if any, to
+// the tail of pp's local run queue. It's called by the owner P.
+func affinityget(pp *p) {
+	if next := guintptr(atomic.Loaduintptr((*uintptr)(unsafe.Pointer(&pp.affinityNext)))); next != 0 && pp.affinityNext.cas(next, 0) {
+		runqput(pp, next.ptr(), false)
+	}
+}
+
+// affinityflush moves the goroutine in pp's affinityNext slot, if any,
+// to the global run queue. It's called when pp stops running.
+//
+// sched.lock must be held.
+func affinityflush(pp *p) {
+	assertLockHeld(&sched.lock)
+
+	// Swap, rather than load and compare-and-swap, so that the slot is
+	// read after pp's status changed. See the top of this file.
+	next := guintptr(atomic.Xchguintptr((*uintptr)(unsafe.Pointer(&pp.affinityNext)), 0))
+	if next != 0 {
+		globrunqput(next.ptr())
+	}
+}
+
+// pidletake takes pp off the _Pidle list, like pidleget, and reports
+// whether it was there.
+//
+// sched.lock must be held.
+func pidletake(pp *p) bool {
+	assertLockHeld(&sched.lock)
+
+	for link := &sched.pidle; *link != 0; link = &link.ptr().link {
+		if link.ptr() != pp {
+			continue
+		}
+		timerpMask.set(pp.id)
+		idlepMask.clear(pp.id)
+		*link = pp.link
+		sched.npidle.Add(-1)
+		pp.limiterEvent.stop(limiterEventIdle, nanotime())
+		return true
+	}
+	return false
+}
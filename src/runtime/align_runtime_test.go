@@ -30,6 +30,8 @@ var AtomicFields = []uintptr{
 	unsafe.Offsetof(heapStatsDelta{}.inStacks),
 	unsafe.Offsetof(heapStatsDelta{}.inPtrScalarBits),
 	unsafe.Offsetof(heapStatsDelta{}.inWorkBufs),
+	unsafe.Offsetof(heapStatsDelta{}.inUserArena),
+	unsafe.Offsetof(heapStatsDelta{}.inUserArenaReusable),
 	unsafe.Offsetof(lfnode{}.next),
 	unsafe.Offsetof(mstats{}.last_gc_nanotime),
 	unsafe.Offsetof(mstats{}.last_gc_unix),
@@ -42,6 +44,7 @@ var AtomicVariables = []unsafe.Pointer{
 	unsafe.Pointer(&ncgocall),
 	unsafe.Pointer(&test_z64),
 	unsafe.Pointer(&blockprofilerate),
+	unsafe.Pointer(&offcpuprofilerate),
 	unsafe.Pointer(&mutexprofilerate),
 	unsafe.Pointer(&gcController),
 	unsafe.Pointer(&memstats),
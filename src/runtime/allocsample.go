@@ -0,0 +1,194 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Allocation sampling.
+//
+// SetAllocSampler registers a function to be called with a sample of
+// the program's heap allocations. Each P's mcache counts down the bytes
+// until its next sample, like nextSample for the memory profiler, and
+// mallocgc records the sampled allocations in a fixed-size ring buffer
+// once it's no longer mallocing. A goroutine started by the first call
+// to SetAllocSampler delivers the samples to the registered function,
+// so that the function can allocate and block without affecting the
+// allocator. Samples taken while the buffer is full are dropped.
+
+package runtime
+
+import (
+	"internal/goarch"
+	"internal/runtime/atomic"
+	"unsafe"
+)
+
+const (
+	allocSampleBufLen = 256 // samples buffered for delivery
+	allocSampleDepth  = 32  // maximum length of a sample's stack
+)
+
+// AllocSample describes a heap allocation sampled by the function
+// registered with SetAllocSampler.
+type AllocSample struct {
+	// Size is the number of bytes allocated, including the rounding
+	// up to the allocator's size classes.
+	Size uintptr
+
+	// Type is the type of the allocated object, or of its elements
+	// if it's an array or the backing store of a slice, formatted
+	// like the %T verb of package fmt. It is empty for allocations
+	// without a type, such as those of strings.
+	Type string
+
+	// Stack is the call stack of the allocation, as program counters
+	// in the format returned by Callers. Use CallersFrames to
+	// translate them to function names and line numbers. Stacks
+	// deeper than 32 frames are truncated.
+	Stack []uintptr
+
+	// Dropped is the number of samples that were dropped since the
+	// previous one was delivered because the sampling function
+	// didn't keep up with them.
+	Dropped uint64
+}
+
+// allocSampleRecord is a sample waiting to be delivered.
+type allocSampleRecord struct {
+	size uintptr
+	typ  *_type
+	nstk int
+	stk  [allocSampleDepth]uintptr
+}
+
+var allocSampler struct {
+	// rate is the average number of bytes allocated between samples,
+	// or 0 if sampling is disabled. It is only written with the
+	// world stopped.
+	rate atomic.Int32
+
+	lock mutex
+
+	f       func(AllocSample)
+	started bool // the delivery goroutine is running
+	g       *g   // the delivery goroutine, if it's waiting for samples
+
+	// buf holds the samples in [head, tail), modulo its length.
+	// Types are never freed, so buf doesn't need to be scanned.
+	buf        *[allocSampleBufLen]allocSampleRecord
+	head, tail uint32
+	dropped    uint64
+}
+
+// SetAllocSampler arranges for f to be called with a sample of the
+// heap allocations of the program, about one for every rate bytes
+// allocated, following the same random distribution as the memory
+// profiler controlled by MemProfileRate. To sample every allocation,
+// set rate to 1. If rate is zero or negative, or f is nil, sampling is
+// turned off. Only the most recently registered function is called.
+//
+// The samples are delivered asynchronously, in the order they were
+// taken, by a single goroutine, so f is not called concurrently with
+// itself. If f doesn't keep up, samples are dropped, as reported by
+// AllocSample.Dropped. Samples that haven't been delivered when
+// SetAllocSampler is called again are discarded.
+//
+// Allocations made by f are sampled too.
+func SetAllocSampler(rate int, f func(AllocSample)) {
+	if rate <= 0 || f == nil {
+		rate, f = 0, nil
+	}
+	rate = min(rate, 1<<31-1)
+
+	stw := stopTheWorld(stwSetAllocSampler)
+	if f != nil && allocSampler.buf == nil {
+		lockInit(&allocSampler.lock, lockRankAllocSampler)
+		p := persistentalloc(unsafe.Sizeof(*allocSampler.buf), goarch.PtrSize, &memstats.other_sys)
+		allocSampler.buf = (*[allocSampleBufLen]allocSampleRecord)(p)
+	}
+	allocSampler.f = f
+	allocSampler.head = allocSampler.tail
+	allocSampler.dropped = 0
+	allocSampler.rate.Store(int32(rate))
+	for _, pp := range allp {
+		if c := pp.mcache; c != nil {
+			c.nextAllocSample = allocSamplerNext(int32(rate))
+		}
+	}
+	start := f != nil && !allocSampler.started
+	if start {
+		allocSampler.started = true
+	}
+	startTheWorld(stw)
+
+	if start {
+		go allocSamplerLoop()
+	}
+}
+
+// allocSamplerNext returns the number of bytes to allocate until the
+// next sample at the given rate.
+func allocSamplerNext(rate int32) uintptr {
+	return uintptr(fastexprand(int(rate)))
+}
+
+// allocSamplerRecord records a sample of an allocation of size bytes of
+// type typ. It is called by mallocgc, which must not be mallocing.
+func allocSamplerRecord(typ *_type, size uintptr) {
+	var stk [allocSampleDepth]uintptr
+	n := callers(3, stk[:])
+
+	lock(&allocSampler.lock)
+	if allocSampler.f == nil {
+		// Sampling was turned off.
+		unlock(&allocSampler.lock)
+		return
+	}
+	if allocSampler.tail-allocSampler.head == allocSampleBufLen {
+		allocSampler.dropped++
+		unlock(&allocSampler.lock)
+		return
+	}
+	r := &allocSampler.buf[allocSampler.tail%allocSampleBufLen]
+	r.size = size
+	r.typ = typ
+	r.nstk = n
+	r.stk = stk
+	allocSampler.tail++
+	gp := allocSampler.g
+	allocSampler.g = nil
+	unlock(&allocSampler.lock)
+
+	if gp != nil {
+		goready(gp, 0)
+	}
+}
+
+// allocSamplerLoop delivers samples to the sampling function.
+func allocSamplerLoop() {
+	for {
+		lock(&allocSampler.lock)
+		for allocSampler.head == allocSampler.tail {
+			allocSampler.g = getg()
+			goparkunlock(&allocSampler.lock, waitReasonAllocSamplerWait, traceBlockSystemGoroutine, 1)
+			lock(&allocSampler.lock)
+		}
+		// Copy the sample and release the lock before allocating,
+		// which may record another sample.
+		r := allocSampler.buf[allocSampler.head%allocSampleBufLen]
+		allocSampler.head++
+		f := allocSampler.f
+		dropped := allocSampler.dropped
+		allocSampler.dropped = 0
+		unlock(&allocSampler.lock)
+
+		s := AllocSample{
+			Size:    r.size,
+			Stack:   make([]uintptr, r.nstk),
+			Dropped: dropped,
+		}
+		if r.typ != nil {
+			s.Type = toRType(r.typ).string()
+		}
+		copy(s.Stack, r.stk[:r.nstk])
+		f(s)
+	}
+}
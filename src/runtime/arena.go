@@ -59,7 +59,10 @@
 // addresses. The reason for this is to take advantage of a GC optimization wherein
 // the GC will stop scanning an object when there are no more pointers in it, which
 // also allows us to elide clearing the heap bitmap for pointer-free Go values
-// allocated into arenas.
+// allocated into arenas. The exception is pointer-free slices, which are
+// allocated from the front like pointer-ful memory, so that the most recent one
+// can be grown in place into the free space after it. The heap bitmap of the free
+// space is always clear, so they don't need their bits written either.
 //
 // Note that arenas are not safe to use concurrently.
 //
@@ -84,10 +87,14 @@ package runtime
 
 import (
 	"internal/abi"
+	"internal/cpu"
 	"internal/goarch"
+	"internal/profilerecord"
 	"internal/runtime/atomic"
+	"internal/runtime/exithook"
 	"internal/runtime/math"
 	"internal/runtime/sys"
+	"internal/stringslite"
 	"unsafe"
 )
 
@@ -98,9 +105,34 @@ import (
 
 // arena_newArena is a wrapper around newUserArena.
 //
+// opts must point to a value whose layout matches userArenaOptions.
+//
 //go:linkname arena_newArena arena.runtime_arena_newArena
-func arena_newArena() unsafe.Pointer {
-	return unsafe.Pointer(newUserArena())
+func arena_newArena(opts unsafe.Pointer) unsafe.Pointer {
+	return unsafe.Pointer(newUserArena(*(*userArenaOptions)(opts)))
+}
+
+// arena_newChunkPool is a wrapper around newUserArenaChunkPool.
+//
+//go:linkname arena_newChunkPool arena.runtime_arena_newChunkPool
+func arena_newChunkPool() unsafe.Pointer {
+	return unsafe.Pointer(newUserArenaChunkPool())
+}
+
+// arena_chunkPool_Free is a wrapper around (*userArenaChunkPool).free.
+//
+//go:linkname arena_chunkPool_Free arena.runtime_arena_chunkPool_Free
+func arena_chunkPool_Free(pool unsafe.Pointer) {
+	((*userArenaChunkPool)(pool)).free()
+}
+
+// arena_chunkPool_Stats is a wrapper around (*userArenaChunkPool).readStats.
+//
+// stats must point to a value whose layout matches userArenaChunkPoolStats.
+//
+//go:linkname arena_chunkPool_Stats arena.runtime_arena_chunkPool_Stats
+func arena_chunkPool_Stats(pool unsafe.Pointer, stats unsafe.Pointer) {
+	*(*userArenaChunkPoolStats)(stats) = ((*userArenaChunkPool)(pool)).readStats()
 }
 
 // arena_arena_New is a wrapper around (*userArena).new, except that typ
@@ -123,6 +155,53 @@ func arena_arena_New(arena unsafe.Pointer, typ any) any {
 	return result
 }
 
+// arena_arena_NewPinned is like arena_arena_New, but pins the new value
+// for as long as it's in the arena. See (*userArena).newPinned.
+//
+//go:linkname arena_arena_NewPinned arena.runtime_arena_arena_NewPinned
+func arena_arena_NewPinned(arena unsafe.Pointer, typ any) any {
+	t := (*_type)(efaceOf(&typ).data)
+	if t.Kind_&abi.KindMask != abi.Pointer {
+		throw("arena_NewPinned: non-pointer type")
+	}
+	te := (*ptrtype)(unsafe.Pointer(t)).Elem
+	x := ((*userArena)(arena)).newPinned(te)
+	var result any
+	e := efaceOf(&result)
+	e._type = t
+	e.data = x
+	return result
+}
+
+// arena_arena_TryNew is like arena_arena_New, but never allocates from the
+// heap, and returns nil and the reason instead of panicking if the allocation
+// fails.
+//
+//go:linkname arena_arena_TryNew arena.runtime_arena_arena_TryNew
+func arena_arena_TryNew(arena unsafe.Pointer, typ any) (any, userArenaAllocErr) {
+	t := (*_type)(efaceOf(&typ).data)
+	if t.Kind_&abi.KindMask != abi.Pointer {
+		throw("arena_TryNew: non-pointer type")
+	}
+	te := (*ptrtype)(unsafe.Pointer(t)).Elem
+	x, err := ((*userArena)(arena)).tryAlloc(te, -1, uintptr(te.Align_), false)
+	if err != userArenaAllocOK {
+		return nil, err
+	}
+	var result any
+	e := efaceOf(&result)
+	e._type = t
+	e.data = x
+	return result, userArenaAllocOK
+}
+
+// arena_arena_NewAligned is a wrapper around (*userArena).allocAligned.
+//
+//go:linkname arena_arena_NewAligned arena.runtime_arena_arena_NewAligned
+func arena_arena_NewAligned(arena unsafe.Pointer, size, align uintptr) unsafe.Pointer {
+	return ((*userArena)(arena)).allocAligned(size, align)
+}
+
 // arena_arena_Slice is a wrapper around (*userArena).slice.
 //
 //go:linkname arena_arena_Slice arena.runtime_arena_arena_Slice
@@ -130,11 +209,182 @@ func arena_arena_Slice(arena unsafe.Pointer, slice any, cap int) {
 	((*userArena)(arena)).slice(slice, cap)
 }
 
+// arena_arena_TrySlice is a wrapper around (*userArena).trySlice that never
+// allocates from the heap.
+//
+//go:linkname arena_arena_TrySlice arena.runtime_arena_arena_TrySlice
+func arena_arena_TrySlice(arena unsafe.Pointer, slice any, cap int) userArenaAllocErr {
+	return ((*userArena)(arena)).trySlice(slice, cap, 0, false)
+}
+
+// arena_arena_SliceAligned is a wrapper around (*userArena).sliceAligned.
+//
+//go:linkname arena_arena_SliceAligned arena.runtime_arena_arena_SliceAligned
+func arena_arena_SliceAligned(arena unsafe.Pointer, slice any, cap int, align uintptr) {
+	((*userArena)(arena)).sliceAligned(slice, cap, align)
+}
+
+// arena_arena_SetLimit is a wrapper around (*userArena).setLimit.
+//
+//go:linkname arena_arena_SetLimit arena.runtime_arena_arena_SetLimit
+func arena_arena_SetLimit(arena unsafe.Pointer, limit uint64) {
+	((*userArena)(arena)).setLimit(limit)
+}
+
+// arena_arena_Map is a wrapper around (*userArena).makemap.
+//
+//go:linkname arena_arena_Map arena.runtime_arena_arena_Map
+func arena_arena_Map(arena unsafe.Pointer, m any, hint int) {
+	((*userArena)(arena)).makemap(m, hint)
+}
+
+// arena_arena_DeepCopy is a wrapper around (*userArena).deepCopy.
+//
+//go:linkname arena_arena_DeepCopy arena.runtime_arena_arena_DeepCopy
+func arena_arena_DeepCopy(arena unsafe.Pointer, p any) {
+	((*userArena)(arena)).deepCopy(p)
+}
+
+// arena_arena_GrowInPlace is a wrapper around (*userArena).growInPlace.
+//
+//go:linkname arena_arena_GrowInPlace arena.runtime_arena_arena_GrowInPlace
+func arena_arena_GrowInPlace(arena unsafe.Pointer, slice any, cap int) bool {
+	return ((*userArena)(arena)).growInPlace(slice, cap)
+}
+
 // arena_arena_Free is a wrapper around (*userArena).free.
 //
 //go:linkname arena_arena_Free arena.runtime_arena_arena_Free
 func arena_arena_Free(arena unsafe.Pointer) {
-	((*userArena)(arena)).free()
+	a := (*userArena)(arena)
+	if raceenabled && !a.defunct.Load() {
+		a.raceFree(sys.GetCallerPC(), abi.FuncPCABIInternal(arena_arena_Free))
+	}
+	a.free(false)
+}
+
+// arena_arena_Reset is a wrapper around (*userArena).reset.
+//
+//go:linkname arena_arena_Reset arena.runtime_arena_arena_Reset
+func arena_arena_Reset(arena unsafe.Pointer) {
+	((*userArena)(arena)).reset()
+}
+
+// arena_arena_Freeze is a wrapper around (*userArena).freeze.
+//
+//go:linkname arena_arena_Freeze arena.runtime_arena_arena_Freeze
+func arena_arena_Freeze(arena unsafe.Pointer) {
+	((*userArena)(arena)).freeze()
+}
+
+// arena_arena_Unfreeze is a wrapper around (*userArena).unfreeze.
+//
+//go:linkname arena_arena_Unfreeze arena.runtime_arena_arena_Unfreeze
+func arena_arena_Unfreeze(arena unsafe.Pointer) {
+	((*userArena)(arena)).unfreeze()
+}
+
+// arena_arena_Allocated returns the allocated part of the memory the arena
+// allocates from, if it was created with caller-provided memory.
+//
+//go:linkname arena_arena_Allocated arena.runtime_arena_arena_Allocated
+func arena_arena_Allocated(arena unsafe.Pointer) ([]byte, bool) {
+	r := ((*userArena)(arena)).region
+	if r == nil {
+		return nil, false
+	}
+	return r.allocated(), true
+}
+
+// arena_arena_Epoch returns the arena's epoch, which changes whenever values
+// allocated from it may have been freed for reuse.
+//
+//go:linkname arena_arena_Epoch arena.runtime_arena_arena_Epoch
+func arena_arena_Epoch(arena unsafe.Pointer) uintptr {
+	return ((*userArena)(arena)).epoch
+}
+
+// arena_arena_AddCleanup is a wrapper around (*userArena).addCleanup.
+//
+//go:linkname arena_arena_AddCleanup arena.runtime_arena_arena_AddCleanup
+func arena_arena_AddCleanup(arena unsafe.Pointer, f func()) {
+	((*userArena)(arena)).addCleanup(f)
+}
+
+// arena_arena_Stats is a wrapper around (*userArena).readStats.
+//
+// stats must point to a value whose layout matches userArenaStats.
+//
+//go:linkname arena_arena_Stats arena.runtime_arena_arena_Stats
+func arena_arena_Stats(arena unsafe.Pointer, stats unsafe.Pointer) {
+	*(*userArenaStats)(stats) = ((*userArena)(arena)).readStats()
+}
+
+// arena_arena_Mark is a wrapper around (*userArena).mark.
+//
+// mark must point to a value whose layout matches userArenaMark.
+//
+//go:linkname arena_arena_Mark arena.runtime_arena_arena_Mark
+func arena_arena_Mark(arena unsafe.Pointer, mark unsafe.Pointer) {
+	*(*userArenaMark)(mark) = ((*userArena)(arena)).mark()
+}
+
+// arena_arena_Release is a wrapper around (*userArena).release.
+//
+// mark must point to a value whose layout matches userArenaMark.
+//
+//go:linkname arena_arena_Release arena.runtime_arena_arena_Release
+func arena_arena_Release(arena unsafe.Pointer, mark unsafe.Pointer) {
+	((*userArena)(arena)).release((*userArenaMark)(mark))
+}
+
+// userArenaFreeHook, if set, is called every time a user arena is freed.
+// See arena_setFreeHook.
+var userArenaFreeHook atomic.Pointer[func(stats unsafe.Pointer, finalized bool)]
+
+// arena_setFreeHook sets a function to be called every time a user arena is
+// freed, or removes it if hook is nil. hook is passed a pointer to the arena's
+// statistics from just before it was freed, whose layout matches userArenaStats,
+// and whether the arena was freed by its finalizer.
+//
+//go:linkname arena_setFreeHook arena.runtime_arena_setFreeHook
+func arena_setFreeHook(hook func(stats unsafe.Pointer, finalized bool)) {
+	if hook == nil {
+		userArenaFreeHook.Store(nil)
+		return
+	}
+	p := new(func(stats unsafe.Pointer, finalized bool))
+	*p = hook
+	userArenaFreeHook.Store(p)
+}
+
+// arena_readLeaks is a wrapper around readUserArenaLeaks.
+//
+// records must point to an array of n values whose layout matches
+// userArenaLeak.
+//
+//go:linkname arena_readLeaks arena.runtime_arena_readLeaks
+func arena_readLeaks(records unsafe.Pointer, n int) int {
+	return readUserArenaLeaks(unsafe.Slice((*userArenaLeak)(records), n))
+}
+
+// arena_arena_Contains is a wrapper around (*userArena).contains.
+//
+//go:linkname arena_arena_Contains arena.runtime_arena_arena_Contains
+func arena_arena_Contains(arena unsafe.Pointer, p unsafe.Pointer) bool {
+	return ((*userArena)(arena)).contains(uintptr(p))
+}
+
+// arena_of returns the handle of the live user arena p was allocated from,
+// or nil if there isn't one. See userArenaOf.
+//
+//go:linkname arena_of arena.runtime_arena_of
+func arena_of(p unsafe.Pointer) any {
+	a := userArenaOf(uintptr(p))
+	if a == nil {
+		return nil
+	}
+	return a.handle
 }
 
 // arena_heapify takes a value that lives in an arena and makes a copy
@@ -188,21 +438,39 @@ func arena_heapify(s any) any {
 }
 
 const (
-	// userArenaChunkBytes is the size of a user arena chunk.
+	// userArenaChunkBytesMax is the maximum size of a user arena chunk.
 	userArenaChunkBytesMax = 8 << 20
-	userArenaChunkBytes    = uintptr(int64(userArenaChunkBytesMax-heapArenaBytes)&(int64(userArenaChunkBytesMax-heapArenaBytes)>>63) + heapArenaBytes) // min(userArenaChunkBytesMax, heapArenaBytes)
+
+	// userArenaChunkBytesDefault is the default size of a user arena chunk.
+	userArenaChunkBytesDefault = uintptr(int64(userArenaChunkBytesMax-heapArenaBytes)&(int64(userArenaChunkBytesMax-heapArenaBytes)>>63) + heapArenaBytes) // min(userArenaChunkBytesMax, heapArenaBytes)
+
+	// userArenaChunkBytesMin is the minimum size of a user arena chunk that
+	// may be selected with GODEBUG=arenachunksize.
+	userArenaChunkBytesMin = 64 << 10
+)
+
+// User arena chunk size parameters. These are set once during runtime
+// initialization and never change afterward.
+var (
+	// userArenaChunkBytes is the size of a user arena chunk.
+	userArenaChunkBytes uintptr = userArenaChunkBytesDefault
 
 	// userArenaChunkPages is the number of pages a user arena chunk uses.
-	userArenaChunkPages = userArenaChunkBytes / pageSize
+	userArenaChunkPages uintptr = userArenaChunkBytesDefault / pageSize
 
 	// userArenaChunkMaxAllocBytes is the maximum size of an object that can
 	// be allocated from an arena. This number is chosen to cap worst-case
 	// fragmentation of user arenas to 25%. Larger allocations are redirected
 	// to the heap.
-	userArenaChunkMaxAllocBytes = userArenaChunkBytes / 4
+	userArenaChunkMaxAllocBytes uintptr = userArenaChunkBytesDefault / 4
 )
 
 func init() {
+	if n := debug.arenachunksize; n > 0 {
+		userArenaChunkBytes = userArenaChunkBytesFor(uintptr(n))
+		userArenaChunkPages = userArenaChunkBytes / pageSize
+		userArenaChunkMaxAllocBytes = userArenaChunkBytes / 4
+	}
 	if userArenaChunkPages*pageSize != userArenaChunkBytes {
 		throw("user arena chunk size is not a multiple of the page size")
 	}
@@ -219,6 +487,25 @@ func init() {
 		}
 	}
 	lockInit(&userArenaState.lock, lockRankUserArenaState)
+	lockInit(&userArenaLeaks.lock, lockRankUserArenaState)
+	lockInit(&userArenaProf.lock, lockRankUserArenaState)
+}
+
+// userArenaChunkBytesFor returns the user arena chunk size to use when
+// n bytes is requested via GODEBUG=arenachunksize.
+//
+// The chunk size is n rounded up to a power of two, so that it always evenly
+// divides a heap arena, and then clamped to be at least userArenaChunkBytesMin
+// and the physical page size, and at most userArenaChunkBytesDefault.
+func userArenaChunkBytesFor(n uintptr) uintptr {
+	size := uintptr(userArenaChunkBytesMin)
+	for size < n && size < userArenaChunkBytesDefault {
+		size <<= 1
+	}
+	for size < physPageSize && size < userArenaChunkBytesDefault {
+		size <<= 1
+	}
+	return size
 }
 
 // userArenaChunkReserveBytes returns the amount of additional bytes to reserve for
@@ -251,6 +538,93 @@ type userArena struct {
 	// list.
 	refs []unsafe.Pointer
 
+	// spare is a list of chunks owned by this arena that were emptied by a
+	// call to reset. They're used to refill the arena before going to the
+	// global reuse list, and are freed along with the rest of the arena.
+	spare []liveUserArenaChunk
+
+	// stats tracks how the arena's chunks have been used since the arena
+	// was created or last reset.
+	stats userArenaStats
+
+	// heapPins pins the values newPinned allocated from the heap because
+	// of GODEBUG=arenaheap=1, until the arena is reset or freed.
+	heapPins Pinner
+
+	// published is the padding and chunk tail bytes in stats already
+	// added to the totals for all arenas. See publishStats.
+	published struct {
+		paddingBytes, tailBytes uint64
+	}
+
+	// cleanups are the functions to call, in reverse order, before the
+	// values allocated from the arena are freed or reused. For arenas with
+	// concurrent allocation enabled, it's protected by conc.lock.
+	cleanups []func()
+
+	// marks is the stack of the sequence numbers of the marks that are
+	// currently valid for this arena, as runs of consecutive numbers, and
+	// markSeq is the sequence number of the most recently created mark.
+	//
+	// Marks created in a row, whether or not they're ever released, share
+	// a run, as does a mark created right after the most recent mark is
+	// released, so the stack only grows when a mark is created after an
+	// older mark was released, and shrinks again as older marks are.
+	marks   []userArenaMarkRun
+	markSeq uintptr
+
+	// epoch is incremented whenever values allocated from the arena may
+	// have been freed for reuse, by reset or release.
+	epoch uintptr
+
+	// pool is the chunk pool the arena obtains chunks from and returns
+	// them to, instead of the global reuse list, if any.
+	pool *userArenaChunkPool
+
+	// origin is the PC of the code that created the arena. Only recorded
+	// with GODEBUG=arenapoison or when memory profiling is enabled.
+	origin uintptr
+
+	// leakStack is the stack that created the arena. Only recorded
+	// with GODEBUG=arenaleak.
+	leakStack *[userArenaLeakStackDepth]uintptr
+
+	// noHeapFallback is true if values too large for a chunk must not be
+	// allocated from the heap instead.
+	noHeapFallback bool
+
+	// frozen is true if the arena's chunks have been made read-only by
+	// freeze, in which case nothing may be allocated from the arena.
+	frozen bool
+
+	// numa is true if the arena's chunks are placed on NUMA node numaNode,
+	// or if numaNode is negative, on the node of the thread that obtains
+	// each of them.
+	numa     bool
+	numaNode int32
+
+	// limit is the maximum value of charged, or zero if there is none.
+	limit uint64
+
+	// charged is the total size of the values allocated through the arena
+	// since it was created or last reset, including those too large to be
+	// allocated from its chunks. It's atomically updated, since arenas
+	// with concurrent allocation enabled are charged by multiple Ps.
+	charged atomic.Uint64
+
+	// region is non-nil if the arena allocates from memory provided by its
+	// creator. In that case fullList, active, and refs are unused.
+	region *userArenaRegion
+
+	// handle is an opaque value provided by the creator of the arena to
+	// identify it, which userArenaOf's callers return instead of the arena.
+	handle any
+
+	// conc is non-nil if the arena allows allocation from multiple
+	// goroutines concurrently. In that case, fullList, active, and refs
+	// are unused, and chunks are tracked by conc instead.
+	conc *userArenaConcurrent
+
 	// defunct is true if free has been called on this arena.
 	//
 	// This is just a best-effort way to discover a concurrent allocation
@@ -258,15 +632,207 @@ type userArena struct {
 	defunct atomic.Bool
 }
 
+// userArenaOptions configures a new user arena.
+//
+// Its layout must match arena.options.
+type userArenaOptions struct {
+	concurrent     bool                // allow concurrent allocation
+	pool           *userArenaChunkPool // chunk pool to use, if any
+	noHeapFallback bool                // never allocate large values from the heap
+	region         []byte              // memory to allocate from instead of chunks, if non-nil
+	handle         any                 // value identifying the arena to its creator
+	numa           bool                // place chunks on a NUMA node
+	numaNode       int32               // NUMA node to place chunks on, or -1 for the local one
+}
+
+// userArenaRegion is the state of a user arena that allocates from memory
+// provided by its creator, rather than from chunks.
+//
+// The GC never scans the region, since it's either not part of the heap or
+// a pointer-free heap object, so only values without pointers are allocated
+// from it. They're zeroed as they're allocated, since the region's memory
+// may contain anything.
+type userArenaRegion struct {
+	buf    []byte    // the region, kept alive in case it's a heap object
+	free   addrRange // part of the region not yet allocated
+	pinned bool      // whether newPinned pinned the region
+
+	// next is the next *userArena on userArenaState.regions. It doesn't keep
+	// that arena alive, since it's removed from the list once it's freed.
+	//
+	// Protected by userArenaState.lock.
+	next uintptr
+}
+
+// allocated returns the part of the region that has been allocated, which
+// is always a prefix of the region.
+func (r *userArenaRegion) allocated() []byte {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(r.buf)))
+	return r.buf[:r.free.base.addr()-base]
+}
+
+// reset makes the whole region free again.
+func (r *userArenaRegion) reset() {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(r.buf)))
+	r.free = makeAddrRange(base, base+uintptr(len(r.buf)))
+	r.unpin()
+}
+
+// unpin unpins the region, if newPinned pinned it.
+func (r *userArenaRegion) unpin() {
+	if r.pinned {
+		setPinned(unsafe.Pointer(unsafe.SliceData(r.buf)), false)
+		r.pinned = false
+	}
+}
+
+// userArenaConcurrent is the state of a user arena that allows allocation
+// from multiple goroutines concurrently.
+//
+// Each P allocates into its own chunk, so allocating only requires disabling
+// preemption. The lock is only acquired to obtain a new chunk for a P.
+type userArenaConcurrent struct {
+	lock mutex
+
+	// perP is the per-P allocation state, indexed by P ID. It's replaced
+	// with a larger copy under lock when a P with a higher ID first needs
+	// a chunk.
+	//
+	// Each userArenaP is only accessed by the P it belongs to, with preemption
+	// disabled, except by operations that require exclusive access to the arena.
+	perP atomic.Pointer[[]*userArenaP]
+
+	// chunks is a set of references to all the chunks that have been
+	// handed out to Ps, so that they're kept alive.
+	//
+	// Protected by lock.
+	chunks []liveUserArenaChunk
+}
+
+// acquire locks c.lock.
+//
+// The race detector can't see runtime locks, but the slices protected by
+// c.lock are copied and grown with instrumented operations, so tell it
+// about the lock too.
+func (c *userArenaConcurrent) acquire() {
+	lock(&c.lock)
+	if raceenabled {
+		raceacquire(unsafe.Pointer(&c.lock))
+	}
+}
+
+// release unlocks c.lock.
+func (c *userArenaConcurrent) release() {
+	if raceenabled {
+		racerelease(unsafe.Pointer(&c.lock))
+	}
+	unlock(&c.lock)
+}
+
+// userArenaP is the allocation state of a single P in a concurrent user arena.
+type userArenaP struct {
+	// active is the user arena chunk this P is allocating into.
+	active *mspan
+
+	// stats tracks how this P has used the arena's chunks.
+	stats userArenaStats
+
+	_ cpu.CacheLinePad // prevents false-sharing between Ps
+}
+
+// userArenaStats contains statistics about the memory usage of a user arena.
+//
+// Its layout must match arena.Stats.
+type userArenaStats struct {
+	allocBytes   uint64 // bytes of Go values allocated into chunks
+	paddingBytes uint64 // bytes lost to alignment padding between values
+	chunks       uint64 // number of chunks held by the arena
+	allocs       uint64 // number of Go values allocated into chunks
+	tailBytes    uint64 // bytes left unused at the end of full chunks
+
+	// bySize breaks allocs, paddingBytes, and tailBytes down by the size
+	// class of the values, with class 0 for values larger than any size
+	// class. The size field is only filled in by readStats.
+	bySize [_NumSizeClasses]userArenaSizeClassStats
+}
+
+// userArenaSizeClassStats contains the statistics for the values of one size
+// class allocated from a user arena.
+type userArenaSizeClassStats struct {
+	size         uint32 // size of the size class
+	allocs       uint64 // number of values of this size class
+	paddingBytes uint64 // bytes lost to padding before these values
+	tailBytes    uint64 // bytes left at the end of chunks these values didn't fit in
+}
+
+// userArenaMark is a checkpoint of the allocation state of a user arena,
+// created by mark and consumed by release.
+//
+// Its layout must match arena.Mark.
+type userArenaMark struct {
+	arena    *userArena     // arena the mark was taken from
+	seq      uintptr        // sequence number of the mark
+	chunks   uintptr        // number of chunks referenced by the arena
+	free     addrRange      // free space in the active chunk
+	stats    userArenaStats // arena's statistics
+	charged  uint64         // arena's charged bytes
+	cleanups uintptr        // number of cleanups registered with the arena
+}
+
+// userArenaMarkRun is a run of the sequence numbers of valid marks, from
+// first to last inclusive.
+type userArenaMarkRun struct {
+	first, last uintptr
+}
+
 // newUserArena creates a new userArena ready to be used.
-func newUserArena() *userArena {
+func newUserArena(opts userArenaOptions) *userArena {
 	a := new(userArena)
+	if p := opts.pool; p != nil {
+		lock(&p.lock)
+		defunct := p.defunct
+		unlock(&p.lock)
+		if defunct {
+			panic("arena created from freed chunk pool")
+		}
+		a.pool = p
+	}
+	a.noHeapFallback = opts.noHeapFallback
+	if debug.arenapoison > 0 || MemProfileRate > 0 {
+		a.origin = userArenaCallerPC()
+	}
+	if debug.arenaleak > 0 {
+		a.leakStack = new([userArenaLeakStackDepth]uintptr)
+		// Skip newUserArena, arena_newArena, and the arena package's
+		// newArena and its exported caller.
+		callers(4, a.leakStack[:])
+		if userArenaLeaks.hooked.CompareAndSwap(0, 1) {
+			exithook.Add(exithook.Hook{F: printUserArenaLeaks, RunOnFailure: true})
+		}
+	}
 	SetFinalizer(a, func(a *userArena) {
 		// If arena handle is dropped without being freed, then call
 		// free on the arena, so the arena chunks are never reclaimed
 		// by the garbage collector.
-		a.free()
+		a.free(true)
 	})
+	a.handle = opts.handle
+	a.numa, a.numaNode = opts.numa, opts.numaNode
+	if opts.region != nil {
+		a.region = &userArenaRegion{buf: opts.region}
+		a.region.reset()
+		lock(&userArenaState.lock)
+		a.region.next = userArenaState.regions
+		userArenaState.regions = uintptr(unsafe.Pointer(a))
+		unlock(&userArenaState.lock)
+		return a
+	}
+	if opts.concurrent {
+		// Chunks are handed out to Ps lazily, as they first allocate.
+		a.conc = new(userArenaConcurrent)
+		lockInit(&a.conc.lock, lockRankUserArenaState)
+		return a
+	}
 	a.refill()
 	return a
 }
@@ -275,19 +841,72 @@ func newUserArena() *userArena {
 // its pointer.
 //
 // This operation is not safe to call concurrently with other operations on the
-// same arena.
+// same arena, unless the arena was created with concurrent allocation enabled,
+// in which case it may be called concurrently with other allocations.
 func (a *userArena) new(typ *_type) unsafe.Pointer {
 	return a.alloc(typ, -1)
 }
 
+// newPinned is like new, but never allocates from the heap, and pins the new
+// value until the memory it's allocated from is reset or freed, so that
+// cgocheck allows it to be referenced from C.
+//
+// Pinning is tracked per chunk, rather than per value, by pinning the chunk
+// like any other heap object, so every value in the chunk is treated as pinned
+// from then on. That's harmless, since no arena value moves or is freed by the
+// garbage collector while it's in the arena anyway. For arenas that allocate
+// from memory provided by their creator, the memory is pinned instead, if it's
+// a heap object.
+func (a *userArena) newPinned(typ *_type) unsafe.Pointer {
+	x, err := a.tryAlloc(typ, -1, uintptr(typ.Align_), false)
+	if err != userArenaAllocOK {
+		panic(err.error())
+	}
+	if debug.arenaheap > 0 && a.region == nil {
+		a.heapPins.Pin(x)
+		return x
+	}
+	if r := a.region; r != nil {
+		if !r.pinned {
+			setPinned(unsafe.Pointer(unsafe.SliceData(r.buf)), true)
+			r.pinned = true
+		}
+		return x
+	}
+	if s := spanOfHeap(uintptr(x)); s != nil && s.isUserArenaChunk && !isPinned(unsafe.Pointer(s.base())) {
+		setPinned(unsafe.Pointer(s.base()), true)
+	}
+	return x
+}
+
 // slice allocates a new slice backing store. slice must be a pointer to a slice
 // (i.e. *[]T), because userArenaSlice will update the slice directly.
 //
 // cap determines the capacity of the slice backing store and must be non-negative.
 //
 // This operation is not safe to call concurrently with other operations on the
-// same arena.
+// same arena, unless the arena was created with concurrent allocation enabled,
+// in which case it may be called concurrently with other allocations.
 func (a *userArena) slice(sl any, cap int) {
+	if err := a.trySlice(sl, cap, 0, true); err != userArenaAllocOK {
+		panic(err.error())
+	}
+}
+
+// sliceAligned is like slice, but aligns the backing store to align, which
+// must be a power of two no greater than pageSize.
+func (a *userArena) sliceAligned(sl any, cap int, align uintptr) {
+	checkUserArenaAlign(align)
+	if err := a.trySlice(sl, cap, align, true); err != userArenaAllocOK {
+		panic(err.error())
+	}
+}
+
+// trySlice is like slice, but leaves the slice untouched and returns the
+// reason instead of panicking if the allocation fails. The backing store is
+// aligned to align, if it's greater than the element type's alignment.
+// If heapOK is false, the backing store is never allocated from the heap.
+func (a *userArena) trySlice(sl any, cap int, align uintptr, heapOK bool) userArenaAllocErr {
 	if cap < 0 {
 		panic("userArena.slice: negative cap")
 	}
@@ -303,80 +922,1169 @@ func (a *userArena) slice(sl any, cap int) {
 	typ = (*slicetype)(unsafe.Pointer(typ)).Elem
 	// t is now the element type of the slice we want to allocate.
 
-	*((*slice)(i.data)) = slice{a.alloc(typ, cap), cap, cap}
+	align = max(align, uintptr(typ.Align_))
+	x, err := a.tryAlloc(typ, cap, align, heapOK)
+	if err != userArenaAllocOK {
+		return err
+	}
+	*((*slice)(i.data)) = slice{x, cap, cap}
+	return userArenaAllocOK
 }
 
-// free returns the userArena's chunks back to mheap and marks it as defunct.
+// userArenaAllocErr is the reason an allocation from a user arena failed.
 //
-// Must be called at most once for any given arena.
+// Its values must match those the arena package expects.
+type userArenaAllocErr int
+
+const (
+	userArenaAllocOK        userArenaAllocErr = iota
+	userArenaAllocOverLimit                   // would exceed the arena's limit
+	userArenaAllocTooLarge                    // too large for a chunk, and may not use the heap
+)
+
+// The values user arena allocations panic with when they fail.
+var (
+	userArenaLimitError    = error(plainError("arena: allocation exceeds arena limit"))
+	userArenaTooLargeError = error(plainError("arena: value too large to allocate from arena"))
+
+	userArenaRegionPointersError = error(plainError("arena: value with pointers allocated from caller-provided memory"))
+	userArenaFrozenError         = error(plainError("arena: allocation from frozen arena"))
+)
+
+// error returns the value to panic with for a failed allocation.
+func (e userArenaAllocErr) error() error {
+	switch e {
+	case userArenaAllocOverLimit:
+		return userArenaLimitError
+	case userArenaAllocTooLarge:
+		return userArenaTooLargeError
+	}
+	throw("bad user arena allocation error")
+	return nil
+}
+
+// setLimit sets the maximum total size of the values that may be allocated
+// through the arena, or removes the limit if limit is zero.
 //
 // This operation is not safe to call concurrently with other operations on the
 // same arena.
-func (a *userArena) free() {
-	// Check for a double-free.
+func (a *userArena) setLimit(limit uint64) {
 	if a.defunct.Load() {
-		panic("arena double free")
-	}
-
-	// Mark ourselves as defunct.
-	a.defunct.Store(true)
-	SetFinalizer(a, nil)
-
-	// Free all the full arenas.
-	//
-	// The refs on this list are in reverse order from the second-to-last.
-	s := a.fullList
-	i := len(a.refs) - 2
-	for s != nil {
-		a.fullList = s.next
-		s.next = nil
-		freeUserArenaChunk(s, a.refs[i])
-		s = a.fullList
-		i--
-	}
-	if a.fullList != nil || i >= 0 {
-		// There's still something left on the full list, or we
-		// failed to actually iterate over the entire refs list.
-		throw("full list doesn't match refs list in length")
+		panic("arena set limit after free")
 	}
+	a.limit = limit
+}
 
-	// Put the active chunk onto the reuse list.
-	//
-	// Note that active's reference is always the last reference in refs.
-	s = a.active
-	if s != nil {
-		if raceenabled || msanenabled || asanenabled {
-			// Don't reuse arenas with sanitizers enabled. We want to catch
-			// any use-after-free errors aggressively.
-			freeUserArenaChunk(s, a.refs[len(a.refs)-1])
-		} else {
-			lock(&userArenaState.lock)
-			userArenaState.reuse = append(userArenaState.reuse, liveUserArenaChunk{s, a.refs[len(a.refs)-1]})
-			unlock(&userArenaState.lock)
+// charge accounts for size bytes of values allocated through the arena,
+// returning false without doing so if that would exceed the arena's limit.
+func (a *userArena) charge(size uintptr) bool {
+	for {
+		old := a.charged.Load()
+		if a.limit != 0 && (old > a.limit || uint64(size) > a.limit-old) {
+			return false
+		}
+		if a.charged.CompareAndSwap(old, old+uint64(size)) {
+			return true
 		}
 	}
-	// nil out a.active so that a race with freeing will more likely cause a crash.
-	a.active = nil
-	a.refs = nil
 }
 
-// alloc reserves space in the current chunk or calls refill and reserves space
-// in a new chunk. If cap is negative, the type will be taken literally, otherwise
-// it will be considered as an element type for a slice backing store with capacity
-// cap.
-func (a *userArena) alloc(typ *_type, cap int) unsafe.Pointer {
-	s := a.active
-	var x unsafe.Pointer
-	for {
-		x = s.userArenaNextFree(typ, cap)
-		if x != nil {
-			break
-		}
-		s = a.refill()
+// allocAligned allocates size bytes of zeroed memory that contains no pointers,
+// aligned to align, which must be a power of two no greater than pageSize.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena, unless the arena was created with concurrent allocation enabled,
+// in which case it may be called concurrently with other allocations.
+func (a *userArena) allocAligned(size, align uintptr) unsafe.Pointer {
+	checkUserArenaAlign(align)
+	if int(size) < 0 {
+		panic("arena: allocation size out of range")
+	}
+	if size == 0 {
+		// Zero-sized allocations all share one address, which isn't
+		// necessarily aligned.
+		size = 1
+	}
+	x, err := a.tryAlloc(abi.TypeOf(byte(0)), int(size), align, true)
+	if err != userArenaAllocOK {
+		panic(err.error())
 	}
 	return x
 }
 
+// checkUserArenaAlign panics if align isn't a valid alignment to request for
+// an allocation from a user arena.
+//
+// Alignments are limited to pageSize because allocations too large for a chunk
+// are redirected to the heap, where they get their own spans, whose base is
+// only guaranteed to be aligned to pageSize.
+func checkUserArenaAlign(align uintptr) {
+	if align == 0 || align&(align-1) != 0 || align > pageSize {
+		panic("arena: alignment is not a power of two between 1 and 8192")
+	}
+}
+
+// makemap allocates a new map in the arena. m must be a pointer to a map
+// (i.e. *map[K]V), because makemap will update the map directly.
+//
+// The map's header and bucket arrays, including those allocated as the map
+// grows, are all allocated from the arena.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena. Since the map allocates from the arena as it grows, this includes
+// writing to the map.
+func (a *userArena) makemap(m any, hint int) {
+	i := efaceOf(&m)
+	typ := i._type
+	if typ.Kind_&abi.KindMask != abi.Pointer {
+		panic("map result of non-ptr type")
+	}
+	typ = (*ptrtype)(unsafe.Pointer(typ)).Elem
+	if typ.Kind_&abi.KindMask != abi.Map {
+		panic("map of non-ptr-to-map type")
+	}
+	*(**hmap)(i.data) = makemapInUserArena((*maptype)(unsafe.Pointer(typ)), hint, a)
+}
+
+// deepCopy replaces the value p points to with a deep copy of it allocated in
+// the arena, by copying everything it references through pointers, strings,
+// slices, maps, and interfaces into the arena. p must be a pointer (i.e. *T),
+// and the value it points to is updated directly, but it isn't itself moved.
+//
+// Values reached through the same pointer or map more than once are copied
+// once, preserving sharing and cycles. Slices are always copied separately,
+// even if they share a backing store. Channels, functions, and unsafe pointers
+// aren't followed, so the copy shares them with the original.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena, unless the arena was created with concurrent allocation enabled,
+// in which case it may be called concurrently with other allocations.
+func (a *userArena) deepCopy(p any) {
+	i := efaceOf(&p)
+	typ := i._type
+	if typ.Kind_&abi.KindMask != abi.Pointer {
+		panic("deep copy of non-ptr type")
+	}
+	typ = (*ptrtype)(unsafe.Pointer(typ)).Elem
+	c := userArenaCopier{a: a}
+	c.copyRefs(typ, i.data)
+}
+
+// userArenaCopier holds the state of a deep copy into a user arena.
+type userArenaCopier struct {
+	a *userArena
+
+	// copies maps values that have been reached through pointers and maps
+	// to their copies.
+	copies map[userArenaCopyKey]unsafe.Pointer
+}
+
+// userArenaCopyKey identifies a value of type typ at p reached during a deep
+// copy. The type is needed since values of different types may share an
+// address, like a struct and its first field.
+type userArenaCopyKey struct {
+	p   unsafe.Pointer
+	typ *_type
+}
+
+// copyRefs replaces everything that the value of type typ at p references with
+// copies allocated from the arena.
+func (c *userArenaCopier) copyRefs(typ *_type, p unsafe.Pointer) {
+	if !typ.Pointers() {
+		return
+	}
+	switch typ.Kind_ & abi.KindMask {
+	case abi.Pointer:
+		if x := *(*unsafe.Pointer)(p); x != nil {
+			*(*unsafe.Pointer)(p) = c.copyValue((*ptrtype)(unsafe.Pointer(typ)).Elem, x)
+		}
+	case abi.String:
+		if s := *(*string)(p); len(s) > 0 {
+			b := unsafe.Slice((*byte)(c.a.alloc(abi.TypeOf(byte(0)), len(s))), len(s))
+			copy(b, s)
+			*(*string)(p) = unsafe.String(&b[0], len(b))
+		}
+	case abi.Slice:
+		sp := (*slice)(p)
+		if sp.array == nil {
+			return
+		}
+		et := (*slicetype)(unsafe.Pointer(typ)).Elem
+		x := c.a.alloc(et, sp.cap)
+		typedslicecopy(et, x, sp.cap, sp.array, sp.cap)
+		if et.Pointers() {
+			for i := 0; i < sp.cap; i++ {
+				c.copyRefs(et, add(x, uintptr(i)*et.Size_))
+			}
+		}
+		sp.array = x
+	case abi.Array:
+		at := (*arraytype)(unsafe.Pointer(typ))
+		for i := uintptr(0); i < at.Len; i++ {
+			c.copyRefs(at.Elem, add(p, i*at.Elem.Size_))
+		}
+	case abi.Struct:
+		for _, f := range (*structtype)(unsafe.Pointer(typ)).Fields {
+			c.copyRefs(f.Typ, add(p, f.Offset))
+		}
+	case abi.Interface:
+		var t *_type
+		if len((*interfacetype)(unsafe.Pointer(typ)).Methods) == 0 {
+			t = (*eface)(p)._type
+		} else if tab := (*iface)(p).tab; tab != nil {
+			t = tab.Type
+		}
+		if t == nil {
+			return
+		}
+		data := add(p, goarch.PtrSize)
+		if isDirectIface(t) {
+			// The data word is the value itself.
+			c.copyRefs(t, data)
+		} else if x := *(*unsafe.Pointer)(data); x != nil {
+			*(*unsafe.Pointer)(data) = c.copyValue(t, x)
+		}
+	case abi.Map:
+		if h := *(**hmap)(p); h != nil {
+			*(**hmap)(p) = c.copyMap((*maptype)(unsafe.Pointer(typ)), h)
+		}
+	}
+}
+
+// copyValue returns a copy allocated from the arena of the value of type typ
+// at p, and everything it references, reusing the copy made earlier if p was
+// already reached.
+func (c *userArenaCopier) copyValue(typ *_type, p unsafe.Pointer) unsafe.Pointer {
+	if typ.Size_ == 0 {
+		return p
+	}
+	k := userArenaCopyKey{p, typ}
+	if x, ok := c.copies[k]; ok {
+		return x
+	}
+	if c.copies == nil {
+		c.copies = make(map[userArenaCopyKey]unsafe.Pointer)
+	}
+	x := c.a.alloc(typ, -1)
+	c.copies[k] = x
+	typedmemmove(typ, x, p)
+	c.copyRefs(typ, x)
+	return x
+}
+
+// copyMap returns a copy allocated from the arena of the map h of type t, and
+// everything its keys and elements reference, reusing the copy made earlier
+// if h was already reached.
+func (c *userArenaCopier) copyMap(t *maptype, h *hmap) *hmap {
+	k := userArenaCopyKey{unsafe.Pointer(h), &t.Type}
+	if x, ok := c.copies[k]; ok {
+		return (*hmap)(x)
+	}
+	if c.copies == nil {
+		c.copies = make(map[userArenaCopyKey]unsafe.Pointer)
+	}
+	m := makemapInUserArena(t, h.count, c.a)
+	c.copies[k] = unsafe.Pointer(m)
+
+	// Copy each key and element before inserting them, since copying them
+	// may allocate from the arena and the map must not be modified while
+	// it's being iterated over.
+	key := newobject(t.Key)
+	elem := newobject(t.Elem)
+	var it hiter
+	for mapiterinit(t, h, &it); it.key != nil; mapiternext(&it) {
+		typedmemmove(t.Key, key, it.key)
+		c.copyRefs(t.Key, key)
+		typedmemmove(t.Elem, elem, it.elem)
+		c.copyRefs(t.Elem, elem)
+		typedmemmove(t.Elem, mapassign(t, m, key), elem)
+	}
+	return m
+}
+
+// growInPlace attempts to increase the capacity of a slice backing store to cap
+// without moving it, by extending it into the free space of the active chunk.
+// slice must be a pointer to a slice (i.e. *[]T), and growInPlace updates the
+// slice's capacity directly. Returns true on success.
+//
+// This only succeeds if the backing store is the most recent allocation made
+// from the front of the active chunk, which is where slices and values
+// containing pointers are allocated, and there's enough free space left in the
+// chunk. It always fails for arenas with concurrent allocation enabled.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) growInPlace(sl any, cap int) bool {
+	i := efaceOf(&sl)
+	typ := i._type
+	if typ.Kind_&abi.KindMask != abi.Pointer {
+		panic("slice result of non-ptr type")
+	}
+	typ = (*ptrtype)(unsafe.Pointer(typ)).Elem
+	if typ.Kind_&abi.KindMask != abi.Slice {
+		panic("slice of non-ptr-to-slice type")
+	}
+	typ = (*slicetype)(unsafe.Pointer(typ)).Elem
+	// t is now the element type of the slice we want to grow.
+
+	sp := (*slice)(i.data)
+	if cap <= sp.cap {
+		return true
+	}
+	if a.frozen {
+		panic(userArenaFrozenError)
+	}
+	if typ.Size_ == 0 || sp.array == nil {
+		return false
+	}
+	oldSize := typ.Size_ * uintptr(sp.cap)
+	newSize, overflow := math.MulUintptr(typ.Size_, uintptr(cap))
+	if overflow {
+		return false
+	}
+	start := uintptr(sp.array)
+	if a.region != nil {
+		return a.growInRegion(sp, start+oldSize, newSize-oldSize, cap)
+	}
+	s := a.active
+	if s == nil {
+		return false
+	}
+	// Allocations at the front of the chunk are rounded up to a multiple of
+	// the pointer size.
+	oldEnd, newEnd := alignUp(start+oldSize, goarch.PtrSize), alignUp(start+newSize, goarch.PtrSize)
+	if start < s.base() || oldEnd != s.userArenaChunkFree.base.addr() {
+		// Not the most recent allocation at the front of the active chunk.
+		return false
+	}
+	if newEnd-oldEnd > s.userArenaChunkFree.size() || !a.charge(newSize-oldSize) {
+		return false
+	}
+
+	// Prevent preemption as we set up the space for the extension.
+	//
+	// Act like we're allocating.
+	mp := acquirem()
+	if mp.mallocing != 0 {
+		throw("malloc deadlock")
+	}
+	if mp.gsignal == getg() {
+		throw("malloc during signal")
+	}
+	mp.mallocing = 1
+
+	ext, ok := s.userArenaChunkFree.takeFromFront(newEnd-oldEnd, 1)
+	if !ok {
+		throw("arena chunk has no space for extension")
+	}
+	if ext != oldEnd {
+		throw("arena chunk extension is not contiguous")
+	}
+	// The extension is already zeroed, so just set up the heap bitmap for
+	// the new elements and do extra accounting.
+	if typ.Pointers() {
+		n := cap - sp.cap
+		userArenaHeapBitsSetSliceType(typ, n, unsafe.Pointer(ext), s)
+		c := getMCache(mp)
+		if c == nil {
+			throw("mallocgc called without a P or outside bootstrapping")
+		}
+		c.scanAlloc += newSize - oldSize - (typ.Size_ - typ.PtrBytes)
+	}
+
+	// Ensure the heap bits are visible before the new elements are observable.
+	publicationBarrier()
+
+	mp.mallocing = 0
+	releasem(mp)
+
+	a.stats.allocBytes += uint64(newSize - oldSize)
+	sp.cap = cap
+	return true
+}
+
+// growInRegion is like growInPlace, but for arenas that allocate from memory
+// provided by their creator. end is the end of the slice's backing store, and
+// n is the number of bytes to extend it by.
+func (a *userArena) growInRegion(sp *slice, end, n uintptr, cap int) bool {
+	r := a.region
+	if end != r.free.base.addr() || n > r.free.size() || !a.charge(n) {
+		return false
+	}
+	if _, ok := r.free.takeFromFront(n, 1); !ok {
+		throw("arena region has no space for extension")
+	}
+	memclrNoHeapPointers(unsafe.Pointer(end), n)
+	if raceenabled {
+		racemalloc(unsafe.Pointer(end), n)
+	}
+	a.stats.allocBytes += uint64(n)
+	sp.cap = cap
+	return true
+}
+
+// free returns the userArena's chunks back to mheap and marks it as defunct.
+// finalized indicates whether it's being called by the arena's finalizer.
+//
+// Must be called at most once for any given arena.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) free(finalized bool) {
+	// Check for a double-free.
+	if a.defunct.Load() {
+		panic("arena double free")
+	}
+
+	// Run the cleanups while the arena's values are still accessible.
+	a.runCleanups(0)
+
+	// Snapshot the statistics for the free hook before they're gone.
+	hook := userArenaFreeHook.Load()
+	var stats *userArenaStats
+	if hook != nil {
+		stats = new(userArenaStats)
+		*stats = a.readStats()
+	}
+	var leakBytes uint64
+	if finalized && a.leakStack != nil {
+		leakBytes = a.readStats().allocBytes
+	}
+	a.publishStats()
+
+	// Mark ourselves as defunct.
+	a.defunct.Store(true)
+	SetFinalizer(a, nil)
+
+	// The chunks are about to be reused or set to fault, both of which
+	// expect them to be writable.
+	a.unfreeze()
+	a.heapPins.Unpin()
+
+	if a.conc != nil {
+		a.freeConcurrent()
+	}
+
+	// Free all the full arenas.
+	//
+	// The refs on this list are in reverse order from the second-to-last.
+	s := a.fullList
+	i := len(a.refs) - 2
+	for s != nil {
+		a.fullList = s.next
+		s.next = nil
+		a.freeChunk(s, a.refs[i])
+		s = a.fullList
+		i--
+	}
+	if a.fullList != nil || i >= 0 {
+		// There's still something left on the full list, or we
+		// failed to actually iterate over the entire refs list.
+		throw("full list doesn't match refs list in length")
+	}
+
+	// Put the active chunk onto the reuse list.
+	//
+	// Note that active's reference is always the last reference in refs.
+	s = a.active
+	if s != nil {
+		a.reuseChunk(s, a.refs[len(a.refs)-1])
+	}
+	// Free any spare chunks left over from a reset.
+	for i := range a.spare {
+		a.freeChunk(a.spare[i].mspan, a.spare[i].x)
+		a.spare[i] = liveUserArenaChunk{}
+	}
+	// nil out a.active so that a race with freeing will more likely cause a crash.
+	a.active = nil
+	a.refs = nil
+	a.spare = nil
+	a.marks = nil
+	if a.region != nil {
+		a.region.unpin()
+		a.unlinkRegion()
+		a.region = nil
+	}
+
+	if finalized && a.leakStack != nil {
+		recordUserArenaLeak(a.leakStack, leakBytes)
+	}
+	if hook != nil {
+		(*hook)(unsafe.Pointer(stats), finalized)
+	}
+}
+
+// unlinkRegion removes an arena that allocates from memory provided by its
+// creator from userArenaState.regions.
+func (a *userArena) unlinkRegion() {
+	lock(&userArenaState.lock)
+	link := &userArenaState.regions
+	for *link != uintptr(unsafe.Pointer(a)) {
+		if *link == 0 {
+			throw("user arena missing from region list")
+		}
+		link = &(*userArena)(unsafe.Pointer(*link)).region.next
+	}
+	*link = a.region.next
+	unlock(&userArenaState.lock)
+}
+
+// raceFree tells the race detector that the values allocated from the arena
+// are written to at pc, called from callerpc, by the goroutine freeing the
+// arena. Any access to them that isn't synchronized with the free, including
+// one after the free, is then reported as a race with it, rather than going
+// unnoticed.
+func (a *userArena) raceFree(callerpc, pc uintptr) {
+	if r := a.region; r != nil {
+		base := uintptr(unsafe.Pointer(unsafe.SliceData(r.buf)))
+		if n := r.free.base.addr() - base; n > 0 {
+			racewriterangepc(unsafe.Pointer(base), n, callerpc, pc)
+		}
+		return
+	}
+	for _, x := range a.refs {
+		raceWriteUserArenaChunk(spanOf(uintptr(x)), callerpc, pc)
+	}
+	if c := a.conc; c != nil {
+		for i := range c.chunks {
+			raceWriteUserArenaChunk(c.chunks[i].mspan, callerpc, pc)
+		}
+	}
+}
+
+// raceWriteUserArenaChunk tells the race detector that the values allocated
+// from the chunk s are written to at pc, called from callerpc.
+func raceWriteUserArenaChunk(s *mspan, callerpc, pc uintptr) {
+	// Values are allocated from both ends of the chunk.
+	free := s.userArenaChunkFree
+	if n := free.base.addr() - s.base(); n > 0 {
+		racewriterangepc(unsafe.Pointer(s.base()), n, callerpc, pc)
+	}
+	if n := s.base() + s.elemsize - free.limit.addr(); n > 0 {
+		racewriterangepc(unsafe.Pointer(free.limit.addr()), n, callerpc, pc)
+	}
+}
+
+// freeConcurrent frees the chunks of an arena with concurrent allocation
+// enabled. The chunks Ps were allocating into are put onto the reuse list,
+// like the active chunk of any other arena, and the rest are freed.
+func (a *userArena) freeConcurrent() {
+	c := a.conc
+	var perP []*userArenaP
+	if p := c.perP.Load(); p != nil {
+		perP = *p
+	}
+	for i := range c.chunks {
+		s, x := c.chunks[i].mspan, c.chunks[i].x
+		active := false
+		for _, pp := range perP {
+			if pp.active == s {
+				active = true
+				break
+			}
+		}
+		if active {
+			a.reuseChunk(s, x)
+		} else {
+			a.freeChunk(s, x)
+		}
+		c.chunks[i] = liveUserArenaChunk{}
+	}
+	for _, pp := range perP {
+		pp.active = nil
+	}
+	c.chunks = nil
+}
+
+// freeChunk frees a chunk that belonged to the arena.
+func (a *userArena) freeChunk(s *mspan, x unsafe.Pointer) {
+	s.userArenaOwner = 0
+	s.unpinUserArenaChunk()
+	if a.pool != nil {
+		a.pool.chunks.Add(-1)
+	}
+	if debug.arenapoison > 0 {
+		userArenaPoisonInfoOf(s.base()).origin = a.origin
+	}
+	freeUserArenaChunk(s, x)
+}
+
+// reuseChunk makes a chunk that belonged to the arena available to other
+// arenas, by putting it back into the arena's chunk pool, if it has one, or
+// otherwise onto the global reuse list.
+func (a *userArena) reuseChunk(s *mspan, x unsafe.Pointer) {
+	s.userArenaOwner = 0
+	s.unpinUserArenaChunk()
+	if raceenabled || msanenabled || asanenabled {
+		// Don't reuse arenas with sanitizers enabled. We want to catch
+		// any use-after-free errors aggressively.
+		a.freeChunk(s, x)
+		return
+	}
+	if p := a.pool; p != nil {
+		lock(&p.lock)
+		if !p.defunct {
+			p.idle = append(p.idle, liveUserArenaChunk{s, x})
+			unlock(&p.lock)
+			userArenaChunksReusable(1)
+			return
+		}
+		unlock(&p.lock)
+		a.freeChunk(s, x)
+		return
+	}
+	lock(&userArenaState.lock)
+	userArenaState.reuse = append(userArenaState.reuse, liveUserArenaChunk{s, x})
+	unlock(&userArenaState.lock)
+	userArenaChunksReusable(1)
+}
+
+// userArenaChunksReusable updates memory stats for n user arena chunks that
+// just became available for reuse by any arena, or for -n chunks that were
+// taken for reuse.
+func userArenaChunksReusable(n int) {
+	mp := acquirem()
+	stats := memstats.heapStats.acquire()
+	atomic.Xaddint64(&stats.inUserArenaReusable, int64(n)*int64(userArenaChunkBytes-userArenaChunkReserveBytes()))
+	memstats.heapStats.release()
+	releasem(mp)
+}
+
+// reset logically frees all objects allocated into the arena, but keeps
+// the arena's chunks owned by the arena so that they may be immediately
+// reused for new allocations, skipping the quarantine that free goes through.
+//
+// Unlike free, memory is reused without waiting for the GC to prove that there
+// are no more references into it, so any reference into the arena obtained
+// before the reset will silently observe new values.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) reset() {
+	if a.defunct.Load() {
+		panic("arena reset after free")
+	}
+	if a.frozen {
+		panic("arena reset while frozen")
+	}
+	a.runCleanups(0)
+	a.publishStats()
+	a.published.paddingBytes, a.published.tailBytes = 0, 0
+	a.heapPins.Unpin()
+	a.epoch++
+
+	if r := a.region; r != nil {
+		r.reset()
+		a.stats = userArenaStats{}
+		a.charged.Store(0)
+		a.marks = a.marks[:0]
+		return
+	}
+
+	if c := a.conc; c != nil {
+		// Collect all the chunks, which will be handed out to Ps again
+		// as they allocate.
+		if p := c.perP.Load(); p != nil {
+			for _, pp := range *p {
+				pp.active = nil
+				pp.stats = userArenaStats{}
+			}
+		}
+		for i := range c.chunks {
+			c.chunks[i].resetUserArenaChunk()
+			a.spare = append(a.spare, c.chunks[i])
+			c.chunks[i] = liveUserArenaChunk{}
+		}
+		c.chunks = c.chunks[:0]
+		a.charged.Store(0)
+		return
+	}
+
+	// Collect all the chunks. The active chunk is always the last reference,
+	// and fullList is in reverse order from the second-to-last.
+	s := a.active
+	if s != nil {
+		s.resetUserArenaChunk()
+		a.spare = append(a.spare, liveUserArenaChunk{s, a.refs[len(a.refs)-1]})
+	}
+	s = a.fullList
+	i := len(a.refs) - 2
+	for s != nil {
+		a.fullList = s.next
+		s.next = nil
+		s.resetUserArenaChunk()
+		a.spare = append(a.spare, liveUserArenaChunk{s, a.refs[i]})
+		s = a.fullList
+		i--
+	}
+	if i >= 0 {
+		throw("full list doesn't match refs list in length")
+	}
+	a.active = nil
+	clear(a.refs)
+	a.refs = a.refs[:0]
+	a.stats = userArenaStats{}
+	a.charged.Store(0)
+	a.marks = a.marks[:0]
+
+	// Make the most recently active chunk active again.
+	a.refill()
+}
+
+// freeze makes the arena's chunks read-only, so that any attempt to modify the
+// values allocated from them faults, until unfreeze is called. Nothing may be
+// allocated from the arena while it's frozen, and it may not be reset or
+// released to a mark.
+//
+// Protecting the chunks is best-effort: on platforms where memory can't be
+// made read-only, or for arenas that allocate from memory provided by their
+// creator, freeze only prevents further allocation.
+//
+// The GC only ever reads a chunk while it's live, so it's unaffected.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) freeze() {
+	if a.defunct.Load() {
+		panic("arena freeze after free")
+	}
+	if a.frozen {
+		return
+	}
+	a.frozen = true
+	a.protectChunks(true)
+}
+
+// unfreeze makes the arena's chunks writable again after a call to freeze.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) unfreeze() {
+	if !a.frozen {
+		return
+	}
+	a.protectChunks(false)
+	a.frozen = false
+}
+
+// protectChunks makes all the chunks held by the arena read-only if readOnly
+// is true, or writable otherwise.
+func (a *userArena) protectChunks(readOnly bool) {
+	for _, x := range a.refs {
+		sysReadOnly(x, userArenaChunkBytes, readOnly)
+	}
+	if c := a.conc; c != nil {
+		for i := range c.chunks {
+			sysReadOnly(c.chunks[i].x, userArenaChunkBytes, readOnly)
+		}
+	}
+	for i := range a.spare {
+		sysReadOnly(a.spare[i].x, userArenaChunkBytes, readOnly)
+	}
+}
+
+// addCleanup registers f to be called before the values allocated from the
+// arena are freed or reused, by free or reset, or by release, if f was
+// registered after the mark being released. Cleanups are called in the
+// reverse order of their registration.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena, unless the arena was created with concurrent allocation enabled,
+// in which case it may be called concurrently with allocations.
+func (a *userArena) addCleanup(f func()) {
+	if a.defunct.Load() {
+		panic("arena cleanup added after free")
+	}
+	if c := a.conc; c != nil {
+		c.acquire()
+		a.cleanups = append(a.cleanups, f)
+		c.release()
+		return
+	}
+	a.cleanups = append(a.cleanups, f)
+}
+
+// runCleanups calls the cleanups registered after the first n, most recent
+// first, and forgets them.
+func (a *userArena) runCleanups(n int) {
+	for len(a.cleanups) > n {
+		i := len(a.cleanups) - 1
+		f := a.cleanups[i]
+		a.cleanups[i] = nil
+		a.cleanups = a.cleanups[:i]
+		f()
+	}
+}
+
+// mark returns a checkpoint of the arena's current allocation state, which
+// may later be passed to release to free everything allocated after it.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) mark() userArenaMark {
+	if a.defunct.Load() {
+		panic("arena mark after free")
+	}
+	if a.conc != nil {
+		panic("arena mark with concurrent allocation enabled")
+	}
+	a.markSeq++
+	if n := len(a.marks); n > 0 && a.marks[n-1].last == a.markSeq-1 {
+		a.marks[n-1].last = a.markSeq
+	} else {
+		a.marks = append(a.marks, userArenaMarkRun{a.markSeq, a.markSeq})
+	}
+	m := userArenaMark{
+		arena:    a,
+		seq:      a.markSeq,
+		chunks:   uintptr(len(a.refs)),
+		stats:    a.stats,
+		charged:  a.charged.Load(),
+		cleanups: uintptr(len(a.cleanups)),
+	}
+	if r := a.region; r != nil {
+		m.free = r.free
+	} else {
+		m.free = a.active.userArenaChunkFree
+	}
+	return m
+}
+
+// release frees all objects allocated into the arena after m was created
+// by mark, making their memory immediately available for new allocations
+// from this arena. Chunks that were obtained after m was created are kept
+// by the arena for reuse, as with reset.
+//
+// m must have been created from this arena, and not invalidated by a reset
+// or by releasing a mark created before it. m itself remains valid. Like reset,
+// memory is reused without waiting for the GC to prove that there are no more
+// references into it.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) release(m *userArenaMark) {
+	if a.defunct.Load() {
+		panic("arena release after free")
+	}
+	if a.frozen {
+		panic("arena release while frozen")
+	}
+	if a.conc != nil {
+		panic("arena release with concurrent allocation enabled")
+	}
+	// Find the mark, invalidating every mark created after it.
+	valid := false
+	if m.arena == a {
+		for i := len(a.marks) - 1; i >= 0; i-- {
+			if r := &a.marks[i]; r.first <= m.seq && m.seq <= r.last {
+				r.last = m.seq
+				a.marks = a.marks[:i+1]
+				valid = true
+				break
+			}
+		}
+	}
+	if !valid {
+		panic("arena: release of invalid mark")
+	}
+	if m.cleanups <= uintptr(len(a.cleanups)) {
+		a.runCleanups(int(m.cleanups))
+	}
+	a.publishStats()
+	a.published.paddingBytes, a.published.tailBytes = m.stats.paddingBytes, m.stats.tailBytes
+	a.epoch++
+	if r := a.region; r != nil {
+		// Memory is zeroed as it's allocated, so there's nothing to clear.
+		r.free = m.free
+		a.stats = m.stats
+		a.charged.Store(m.charged)
+		return
+	}
+	if m.chunks == 0 || m.chunks > uintptr(len(a.refs)) {
+		panic("arena: release of invalid mark")
+	}
+
+	// Reset all the chunks obtained after the mark, most recent first,
+	// and keep them for reuse.
+	for uintptr(len(a.refs)) > m.chunks {
+		s := a.active
+		n := len(a.refs) - 1
+		s.resetUserArenaChunk()
+		a.spare = append(a.spare, liveUserArenaChunk{s, a.refs[n]})
+		a.refs[n] = nil
+		a.refs = a.refs[:n]
+
+		// Make the most recently filled chunk active again.
+		a.active = a.fullList
+		a.fullList = a.active.next
+		a.active.next = nil
+	}
+	a.active.rewindUserArenaChunk(m.free)
+	a.stats = m.stats
+	a.charged.Store(m.charged)
+}
+
+// alloc reserves space in the current chunk or calls refill and reserves space
+// in a new chunk. If cap is negative, the type will be taken literally, otherwise
+// it will be considered as an element type for a slice backing store with capacity
+// cap.
+//
+// alloc panics if the allocation would exceed the arena's limit, or if it's
+// too large for a chunk and the arena doesn't allow heap fallback.
+func (a *userArena) alloc(typ *_type, cap int) unsafe.Pointer {
+	x, err := a.tryAlloc(typ, cap, uintptr(typ.Align_), true)
+	if err != userArenaAllocOK {
+		panic(err.error())
+	}
+	return x
+}
+
+// tryAlloc is like alloc, but returns the reason instead of panicking if the
+// allocation fails, and aligns the allocation to align, which must be at least
+// typ's alignment. Allocations too large for a chunk are redirected to the
+// heap only if heapOK is true and the arena allows it.
+func (a *userArena) tryAlloc(typ *_type, cap int, align uintptr, heapOK bool) (unsafe.Pointer, userArenaAllocErr) {
+	size := typ.Size_
+	if cap >= 0 {
+		var overflow bool
+		size, overflow = math.MulUintptr(size, uintptr(cap))
+		if overflow {
+			throw("out of memory")
+		}
+	}
+	if a.frozen {
+		panic(userArenaFrozenError)
+	}
+	if a.region != nil {
+		return a.allocFromRegion(typ, cap, size, align)
+	}
+	if debug.arenaheap > 0 {
+		if !a.charge(size) {
+			return nil, userArenaAllocOverLimit
+		}
+		return userArenaAllocHeap(typ, cap, align), userArenaAllocOK
+	}
+	if size > userArenaChunkMaxAllocBytes && (!heapOK || a.noHeapFallback) {
+		return nil, userArenaAllocTooLarge
+	}
+	if !a.charge(size) {
+		return nil, userArenaAllocOverLimit
+	}
+	var x unsafe.Pointer
+	if a.conc != nil {
+		x = a.allocConcurrent(typ, cap, align)
+	} else {
+		s := a.active
+		for {
+			free := s.userArenaChunkFree.size()
+			x = s.userArenaNextFree(typ, cap, align)
+			if x != nil {
+				a.stats.recordAlloc(typ, cap, free-s.userArenaChunkFree.size())
+				break
+			}
+			a.stats.recordTail(typ, cap, free)
+			s = a.refill()
+		}
+	}
+	if rate := MemProfileRate; rate > 0 && size > 0 {
+		a.profileAlloc(size, rate)
+	}
+	return x, userArenaAllocOK
+}
+
+// allocFromRegion is like tryAlloc, but for arenas that allocate from memory
+// provided by their creator. size is the total size of the allocation. Values
+// never go to the heap, and allocations that don't fit in the free part of
+// the region fail as though they exceeded the arena's limit.
+func (a *userArena) allocFromRegion(typ *_type, cap int, size, align uintptr) (unsafe.Pointer, userArenaAllocErr) {
+	if typ.Pointers() {
+		panic(userArenaRegionPointersError)
+	}
+	if size == 0 {
+		return unsafe.Pointer(&zerobase), userArenaAllocOK
+	}
+	r := a.region
+	free := r.free
+	x, ok := r.free.takeFromFront(size, align)
+	if !ok {
+		return nil, userArenaAllocOverLimit
+	}
+	if !a.charge(size) {
+		r.free = free
+		return nil, userArenaAllocOverLimit
+	}
+	memclrNoHeapPointers(unsafe.Pointer(x), size)
+	if raceenabled {
+		racemalloc(unsafe.Pointer(x), size)
+	}
+	a.stats.recordAlloc(typ, cap, r.free.base.addr()-free.base.addr())
+	if rate := MemProfileRate; rate > 0 {
+		a.profileAlloc(size, rate)
+	}
+	return unsafe.Pointer(x), userArenaAllocOK
+}
+
+// profileAlloc samples an allocation of size bytes from the arena for the
+// arena allocation profile, at the same rate heap allocations are sampled for
+// the heap profile.
+func (a *userArena) profileAlloc(size uintptr, rate int) {
+	mp := acquirem()
+	c := getMCache(mp)
+	if c == nil {
+		throw("profileAlloc called without a P or outside bootstrapping")
+	}
+	if rate != 1 && size < c.nextArenaSample {
+		c.nextArenaSample -= size
+		releasem(mp)
+		return
+	}
+	c.nextArenaSample = nextSample()
+	releasem(mp)
+
+	var stk [userArenaProfStackDepth]uintptr
+	callers(2, stk[:])
+	recordUserArenaAllocSample(&stk, a.origin, size)
+}
+
+// allocConcurrent is like alloc, but for arenas with concurrent allocation
+// enabled. It reserves space in the current P's chunk, obtaining a new one
+// for the P if necessary.
+func (a *userArena) allocConcurrent(typ *_type, cap int, align uintptr) unsafe.Pointer {
+	// Handle allocations that don't go into a chunk before disabling
+	// preemption, since they may allocate from the heap.
+	if x := userArenaAllocOutsideChunk(typ, cap); x != nil {
+		return x
+	}
+	c := a.conc
+	for {
+		// Disable preemption so that the P's state is ours for the
+		// duration of the allocation.
+		mp := acquirem()
+		if pp := c.forP(mp.p.ptr().id); pp != nil && pp.active != nil {
+			s := pp.active
+			free := s.userArenaChunkFree.size()
+			if x := s.userArenaNextFree(typ, cap, align); x != nil {
+				pp.stats.recordAlloc(typ, cap, free-s.userArenaChunkFree.size())
+				releasem(mp)
+				return x
+			}
+			pp.stats.recordTail(typ, cap, free)
+		}
+		releasem(mp)
+		a.refillConcurrent()
+	}
+}
+
+// recordAlloc updates the statistics for an allocation of typ (or of cap
+// elements of typ, if cap is non-negative) that used up used bytes of a chunk.
+func (st *userArenaStats) recordAlloc(typ *_type, cap int, used uintptr) {
+	if used == 0 {
+		return
+	}
+	size := typ.Size_
+	if cap >= 0 {
+		size *= uintptr(cap)
+	}
+	st.allocBytes += uint64(size)
+	st.paddingBytes += uint64(used - size)
+	st.allocs++
+	c := &st.bySize[userArenaSizeClass(size)]
+	c.paddingBytes += uint64(used - size)
+	c.allocs++
+}
+
+// recordTail updates the statistics for n bytes left unused at the end of a
+// chunk because an allocation of typ (or of cap elements of typ, if cap is
+// non-negative) didn't fit in them.
+func (st *userArenaStats) recordTail(typ *_type, cap int, n uintptr) {
+	size := typ.Size_
+	if cap >= 0 {
+		size *= uintptr(cap)
+	}
+	st.tailBytes += uint64(n)
+	st.bySize[userArenaSizeClass(size)].tailBytes += uint64(n)
+}
+
+// add adds the statistics in o to st, other than the number of chunks.
+func (st *userArenaStats) add(o *userArenaStats) {
+	st.allocBytes += o.allocBytes
+	st.paddingBytes += o.paddingBytes
+	st.allocs += o.allocs
+	st.tailBytes += o.tailBytes
+	for i := range st.bySize {
+		c, oc := &st.bySize[i], &o.bySize[i]
+		c.allocs += oc.allocs
+		c.paddingBytes += oc.paddingBytes
+		c.tailBytes += oc.tailBytes
+	}
+}
+
+// userArenaSizeClass returns the size class a heap object of size bytes
+// would have, or 0 if it's too large for any size class.
+func userArenaSizeClass(size uintptr) uint8 {
+	if size <= smallSizeMax-8 {
+		return size_to_class8[divRoundUp(size, smallSizeDiv)]
+	}
+	if size <= maxSmallSize {
+		return size_to_class128[divRoundUp(size-smallSizeMax, largeSizeDiv)]
+	}
+	return 0
+}
+
+// publishStats adds the padding and chunk tail bytes the arena has incurred
+// since it last published them to the totals for all arenas, which are
+// reported by runtime/metrics.
+//
+// For arenas with concurrent allocation enabled, it must not be called
+// concurrently with allocations.
+func (a *userArena) publishStats() {
+	padding, tail := a.stats.paddingBytes, a.stats.tailBytes
+	if c := a.conc; c != nil {
+		if p := c.perP.Load(); p != nil {
+			for _, pp := range *p {
+				padding += pp.stats.paddingBytes
+				tail += pp.stats.tailBytes
+			}
+		}
+	}
+	if padding > a.published.paddingBytes {
+		mheap_.userArena.paddingBytes.Add(int64(padding - a.published.paddingBytes))
+	}
+	if tail > a.published.tailBytes {
+		mheap_.userArena.tailBytes.Add(int64(tail - a.published.tailBytes))
+	}
+	a.published.paddingBytes, a.published.tailBytes = padding, tail
+}
+
+// readStats returns a snapshot of the arena's memory usage statistics.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena. For arenas with concurrent allocation enabled, it may be called
+// concurrently with allocations, but then the result is only approximate.
+func (a *userArena) readStats() userArenaStats {
+	if a.defunct.Load() {
+		return userArenaStats{}
+	}
+	var stats userArenaStats
+	if c := a.conc; c == nil {
+		stats = a.stats
+		stats.chunks = uint64(len(a.refs) + len(a.spare))
+	} else {
+		if p := c.perP.Load(); p != nil {
+			for _, pp := range *p {
+				stats.add(&pp.stats)
+			}
+		}
+		c.acquire()
+		stats.chunks = uint64(len(c.chunks) + len(a.spare))
+		c.release()
+	}
+	for i := range stats.bySize {
+		stats.bySize[i].size = uint32(class_to_size[i])
+	}
+	return stats
+}
+
 // refill inserts the current arena chunk onto the full list and obtains a new
 // one, either from the partial list or allocating a new one, both from mheap.
 func (a *userArena) refill() *mspan {
@@ -393,9 +2101,94 @@ func (a *userArena) refill() *mspan {
 		s.next = a.fullList
 		a.fullList = s
 		a.active = nil
-		s = nil
+		a.publishStats()
+	}
+	x, s := a.nextChunk()
+	s.userArenaOwner = uintptr(unsafe.Pointer(a))
+	a.placeChunk(x)
+	a.refs = append(a.refs, x)
+	a.active = s
+	return s
+}
+
+// refillConcurrent obtains a new chunk for the current P to allocate into, for
+// an arena with concurrent allocation enabled. The P's previous chunk, if any,
+// stays referenced by the arena until it's reset or freed.
+func (a *userArena) refillConcurrent() {
+	c := a.conc
+
+	// Get the chunk before taking the lock, since allocating a new one
+	// may need to assist the GC.
+	x, s := a.nextChunk()
+	s.userArenaOwner = uintptr(unsafe.Pointer(a))
+	a.placeChunk(x)
+
+	// Holding the lock also disables preemption, so the P can't change
+	// from under us.
+	c.acquire()
+	pp := c.forP(getg().m.p.ptr().id)
+	if pp == nil {
+		pp = c.growPerP(getg().m.p.ptr().id)
+	}
+	if pp.active != nil && pp.active.userArenaChunkFree.size() > userArenaChunkMaxAllocBytes {
+		// We were rescheduled onto a P that isn't out of space yet.
+		// Hold onto the chunk for later.
+		a.spare = append(a.spare, liveUserArenaChunk{s, x})
+	} else {
+		c.chunks = append(c.chunks, liveUserArenaChunk{s, x})
+		pp.active = s
 	}
+	c.release()
+}
+
+// placeChunk moves the memory of a chunk the arena just obtained to the
+// arena's NUMA node, if it has one.
+//
+// Chunks keep their placement when they're reused by other arenas, which
+// shouldn't matter much, since they'd otherwise just be placed wherever
+// they were first touched.
+func (a *userArena) placeChunk(x unsafe.Pointer) {
+	if !a.numa {
+		return
+	}
+	node := a.numaNode
+	if node < 0 {
+		if node = sysNUMANode(); node < 0 {
+			return
+		}
+	}
+	sysPreferNUMANode(x, userArenaChunkBytes, node)
+}
+
+// nextChunk obtains a chunk for the arena to allocate into, first from the
+// chunks left over from a reset, then from the global reuse list, and finally
+// by allocating a new one.
+func (a *userArena) nextChunk() (unsafe.Pointer, *mspan) {
 	var x unsafe.Pointer
+	var s *mspan
+
+	// Check for chunks left over from a reset.
+	c := a.conc
+	if c != nil {
+		c.acquire()
+	}
+	if n := len(a.spare); n > 0 {
+		x = a.spare[0].x
+		s = a.spare[0].mspan
+		copy(a.spare, a.spare[1:])
+		a.spare[n-1] = liveUserArenaChunk{}
+		a.spare = a.spare[:n-1]
+	}
+	if c != nil {
+		c.release()
+	}
+	if s != nil {
+		return x, s
+	}
+
+	if p := a.pool; p != nil {
+		return p.get()
+	}
 
 	// Check the partially-used list.
 	lock(&userArenaState.lock)
@@ -409,16 +2202,152 @@ func (a *userArena) refill() *mspan {
 		userArenaState.reuse = userArenaState.reuse[:n]
 	}
 	unlock(&userArenaState.lock)
-	if s == nil {
+	if s != nil {
+		userArenaChunksReusable(-1)
+	} else {
 		// Allocate a new one.
 		x, s = newUserArenaChunk()
 		if s == nil {
 			throw("out of memory")
 		}
 	}
-	a.refs = append(a.refs, x)
-	a.active = s
-	return s
+	return x, s
+}
+
+// forP returns the allocation state for the P with the given ID, or nil if
+// there isn't any yet.
+//
+// Must be called with preemption disabled.
+func (c *userArenaConcurrent) forP(id int32) *userArenaP {
+	p := c.perP.Load()
+	if p == nil || int(id) >= len(*p) {
+		return nil
+	}
+	return (*p)[id]
+}
+
+// growPerP extends perP to cover the P with the given ID and returns the
+// allocation state for it.
+//
+// c.lock must be held.
+func (c *userArenaConcurrent) growPerP(id int32) *userArenaP {
+	assertLockHeld(&c.lock)
+
+	var old []*userArenaP
+	if p := c.perP.Load(); p != nil {
+		old = *p
+	}
+	perP := new([]*userArenaP)
+	*perP = make([]*userArenaP, max(int(id)+1, int(gomaxprocs)))
+	copy(*perP, old)
+	for i, pp := range *perP {
+		if pp == nil {
+			(*perP)[i] = new(userArenaP)
+		}
+	}
+	c.perP.Store(perP)
+	return (*perP)[id]
+}
+
+// userArenaChunkPool is a set of user arena chunks shared by the arenas
+// created with it, which obtain chunks from it and return them to it in lieu
+// of the global reuse list. This makes it possible to account for, and bound,
+// the memory used by a particular group of arenas.
+type userArenaChunkPool struct {
+	lock mutex
+
+	// idle is a list of live chunks that aren't being used by any arena.
+	//
+	// Protected by lock.
+	idle []liveUserArenaChunk
+
+	// defunct is true if free has been called on this pool.
+	//
+	// Protected by lock.
+	defunct bool
+
+	// chunks is the number of chunks obtained through the pool that
+	// haven't been freed yet, whether they're idle or in use by an arena.
+	chunks atomic.Int64
+}
+
+// userArenaChunkPoolStats contains statistics about a user arena chunk pool.
+//
+// Its layout must match arena.ChunkPoolStats.
+type userArenaChunkPoolStats struct {
+	chunks     uint64 // number of chunks obtained through the pool
+	idleChunks uint64 // number of those chunks not used by any arena
+}
+
+// newUserArenaChunkPool creates a new, empty userArenaChunkPool.
+func newUserArenaChunkPool() *userArenaChunkPool {
+	p := new(userArenaChunkPool)
+	lockInit(&p.lock, lockRankUserArenaState)
+	SetFinalizer(p, func(p *userArenaChunkPool) {
+		// Arenas keep their pool reachable, so this pool isn't used
+		// by any arena anymore. Free its idle chunks so that they're
+		// not leaked.
+		p.free()
+	})
+	return p
+}
+
+// get obtains a chunk from the pool, allocating a new one if the pool
+// has no idle chunks.
+func (p *userArenaChunkPool) get() (unsafe.Pointer, *mspan) {
+	lock(&p.lock)
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle[n-1] = liveUserArenaChunk{}
+		p.idle = p.idle[:n-1]
+		unlock(&p.lock)
+		userArenaChunksReusable(-1)
+		return c.x, c.mspan
+	}
+	unlock(&p.lock)
+
+	x, s := newUserArenaChunk()
+	if s == nil {
+		throw("out of memory")
+	}
+	p.chunks.Add(1)
+	return x, s
+}
+
+// free frees all the pool's idle chunks and marks the pool as defunct, so that
+// any chunk returned to it by an arena from now on is freed instead.
+//
+// New arenas may not be created with a defunct pool, but arenas created before
+// the call to free may continue to be used.
+func (p *userArenaChunkPool) free() {
+	lock(&p.lock)
+	if p.defunct {
+		unlock(&p.lock)
+		panic("arena chunk pool double free")
+	}
+	p.defunct = true
+	idle := p.idle
+	p.idle = nil
+	unlock(&p.lock)
+	SetFinalizer(p, nil)
+
+	userArenaChunksReusable(-len(idle))
+	for i := range idle {
+		freeUserArenaChunk(idle[i].mspan, idle[i].x)
+		idle[i] = liveUserArenaChunk{}
+		p.chunks.Add(-1)
+	}
+}
+
+// readStats returns a snapshot of the pool's statistics.
+func (p *userArenaChunkPool) readStats() userArenaChunkPoolStats {
+	lock(&p.lock)
+	idle := len(p.idle)
+	unlock(&p.lock)
+	return userArenaChunkPoolStats{
+		chunks:     uint64(p.chunks.Load()),
+		idleChunks: uint64(idle),
+	}
 }
 
 type liveUserArenaChunk struct {
@@ -442,30 +2371,27 @@ var userArenaState struct {
 	//
 	// Protected by lock.
 	fault []liveUserArenaChunk
+
+	// regions is the first *userArena in the list of live user arenas that
+	// allocate from memory provided by their creators, linked through
+	// userArenaRegion.next. Like the rest of the list, it doesn't keep
+	// the arena alive.
+	//
+	// Protected by lock.
+	regions uintptr
 }
 
 // userArenaNextFree reserves space in the user arena for an item of the specified
-// type. If cap is not -1, this is for an array of cap elements of type t.
-func (s *mspan) userArenaNextFree(typ *_type, cap int) unsafe.Pointer {
+// type, aligned to align. If cap is not -1, this is for an array of cap elements
+// of type t.
+func (s *mspan) userArenaNextFree(typ *_type, cap int, align uintptr) unsafe.Pointer {
+	if x := userArenaAllocOutsideChunk(typ, cap); x != nil {
+		return x
+	}
 	size := typ.Size_
 	if cap > 0 {
-		if size > ^uintptr(0)/uintptr(cap) {
-			// Overflow.
-			throw("out of memory")
-		}
 		size *= uintptr(cap)
 	}
-	if size == 0 || cap == 0 {
-		return unsafe.Pointer(&zerobase)
-	}
-	if size > userArenaChunkMaxAllocBytes {
-		// Redirect allocations that don't fit into a chunk well directly
-		// from the heap.
-		if cap >= 0 {
-			return newarray(typ, cap)
-		}
-		return newobject(typ)
-	}
 
 	// Prevent preemption as we set up the space for a new object.
 	//
@@ -480,14 +2406,17 @@ func (s *mspan) userArenaNextFree(typ *_type, cap int) unsafe.Pointer {
 	mp.mallocing = 1
 
 	var ptr unsafe.Pointer
-	if !typ.Pointers() {
+	if !typ.Pointers() && cap < 0 {
 		// Allocate pointer-less objects from the tail end of the chunk.
-		v, ok := s.userArenaChunkFree.takeFromBack(size, typ.Align_)
+		// Slices are allocated from the front, so they can grow in place.
+		v, ok := s.userArenaChunkFree.takeFromBack(size, align)
 		if ok {
 			ptr = unsafe.Pointer(v)
 		}
 	} else {
-		v, ok := s.userArenaChunkFree.takeFromFront(size, typ.Align_)
+		// Keep the front of the free space pointer-aligned, for the
+		// values containing pointers that may be allocated next.
+		v, ok := s.userArenaChunkFree.takeFromFront(alignUp(size, goarch.PtrSize), align)
 		if ok {
 			ptr = unsafe.Pointer(v)
 		}
@@ -530,9 +2459,58 @@ func (s *mspan) userArenaNextFree(typ *_type, cap int) unsafe.Pointer {
 	mp.mallocing = 0
 	releasem(mp)
 
+	if raceenabled {
+		// The memory may have held other values before a reset or
+		// a release, so make sure the race detector treats it as
+		// fresh memory for this value.
+		racemalloc(ptr, size)
+	}
 	return ptr
 }
 
+// userArenaAllocOutsideChunk handles allocations of an item of the specified
+// type (or an array of cap elements, if cap is not -1) that don't need space in
+// a user arena chunk, because they're either zero-sized or too large. It returns
+// nil if the allocation does need space in a chunk.
+func userArenaAllocOutsideChunk(typ *_type, cap int) unsafe.Pointer {
+	size := typ.Size_
+	if cap > 0 {
+		if size > ^uintptr(0)/uintptr(cap) {
+			// Overflow.
+			throw("out of memory")
+		}
+		size *= uintptr(cap)
+	}
+	if size == 0 || cap == 0 {
+		return unsafe.Pointer(&zerobase)
+	}
+	if size > userArenaChunkMaxAllocBytes {
+		// Redirect allocations that don't fit into a chunk well directly
+		// from the heap.
+		if cap >= 0 {
+			return newarray(typ, cap)
+		}
+		return newobject(typ)
+	}
+	return nil
+}
+
+// userArenaAllocHeap allocates a value of typ (or cap elements of typ, if cap
+// is non-negative) aligned to align from the heap, for GODEBUG=arenaheap=1.
+func userArenaAllocHeap(typ *_type, cap int, align uintptr) unsafe.Pointer {
+	if align <= uintptr(typ.Align_) || typ.Size_ == 0 {
+		if cap >= 0 {
+			return newarray(typ, cap)
+		}
+		return newobject(typ)
+	}
+	// Objects too large for any size class get their own spans, which
+	// are aligned to pageSize, the largest alignment that may be requested.
+	// Make the allocation at least that large.
+	n := max(cap, 1, int(maxSmallSize/typ.Size_)+1)
+	return newarray(typ, n)
+}
+
 // userArenaHeapBitsSetSliceType is the equivalent of heapBitsSetType but for
 // Go slice backing store values allocated in a user arena chunk. It sets up the
 // heap bitmap for n consecutive values with type typ allocated at address ptr.
@@ -816,7 +2794,7 @@ func newUserArenaChunk() (unsafe.Pointer, *mspan) {
 		if rate != 1 && userArenaChunkBytes < c.nextSample {
 			c.nextSample -= userArenaChunkBytes
 		} else {
-			profilealloc(mp, unsafe.Pointer(span.base()), userArenaChunkBytes)
+			profilealloc(mp, unsafe.Pointer(span.base()), userArenaChunkBytes, nil)
 		}
 	}
 	mp.mallocing = 0
@@ -886,6 +2864,12 @@ func (s *mspan) setUserArenaChunkToFault() {
 	// barrier for this update.
 	s.spanclass = makeSpanClass(0, true)
 
+	// Record when the chunk was set to fault, so that with GODEBUG=arenapoison
+	// the sweeper can keep it that way for longer.
+	if debug.arenapoison > 0 {
+		userArenaPoisonInfoOf(s.base()).faultCycle = work.cycles.Load()
+	}
+
 	// Actually set the arena chunk to fault, so we'll get dangling pointer errors.
 	// sysFault currently uses a method on each OS that forces it to evacuate all
 	// memory backing the chunk.
@@ -909,6 +2893,7 @@ func (s *mspan) setUserArenaChunkToFault() {
 	stats := memstats.heapStats.acquire()
 	atomic.Xaddint64(&stats.committed, -int64(s.npages*pageSize))
 	atomic.Xaddint64(&stats.inHeap, -int64(s.npages*pageSize))
+	atomic.Xaddint64(&stats.inUserArena, -int64(s.elemsize))
 	atomic.Xadd64(&stats.largeFreeCount, 1)
 	atomic.Xadd64(&stats.largeFree, int64(s.elemsize))
 	memstats.heapStats.release()
@@ -925,10 +2910,151 @@ func (s *mspan) setUserArenaChunkToFault() {
 		// Add the user arena to the quarantine list.
 		lock(&mheap_.lock)
 		mheap_.userArena.quarantineList.insert(s)
+		mheap_.userArena.quarantinedBytes.Add(s.npages * pageSize)
 		unlock(&mheap_.lock)
 	})
 }
 
+// resetUserArenaChunk clears all memory allocated from the user arena chunk
+// and makes all of it available for allocation again.
+//
+// s must be a live user arena chunk owned exclusively by the caller.
+func (s *mspan) resetUserArenaChunk() {
+	if !s.isUserArenaChunk {
+		throw("invalid span for user arena reset")
+	}
+	base, limit := s.base(), s.base()+s.elemsize
+	front, back := s.userArenaChunkFree.base.addr(), s.userArenaChunkFree.limit.addr()
+
+	// Pointer-ful memory lives at the front of the chunk. Clear it with
+	// write barriers so that the GC doesn't miss anything that was only
+	// reachable through the chunk while it's marking. Pointer-free memory
+	// at the back can just be cleared.
+	if front > base {
+		memclrHasPointers(unsafe.Pointer(base), front-base)
+	}
+	if limit > back {
+		memclrNoHeapPointersLarge(unsafe.Pointer(back), limit-back)
+	}
+
+	// Reset the heap bitmap and the dummy type so the GC doesn't bother
+	// scanning any of the chunk until new pointer-ful values are allocated.
+	s.initHeapBits(true)
+	s.largeType.PtrBytes = 0
+	s.userArenaChunkFree = makeAddrRange(base, limit)
+	s.unpinUserArenaChunk()
+
+	// Model the reset as a free of everything followed by a fresh allocation
+	// for the sanitizers.
+	if raceenabled {
+		racefree(unsafe.Pointer(base), s.elemsize)
+		racemalloc(unsafe.Pointer(base), s.elemsize)
+	}
+	if msanenabled {
+		msanfree(unsafe.Pointer(base), s.elemsize)
+		msanmalloc(unsafe.Pointer(base), s.elemsize)
+	}
+}
+
+// unpinUserArenaChunk unpins the user arena chunk, if newPinned pinned it.
+func (s *mspan) unpinUserArenaChunk() {
+	// Concurrent calls to newPinned may have pinned the chunk more
+	// than once.
+	for isPinned(unsafe.Pointer(s.base())) {
+		setPinned(unsafe.Pointer(s.base()), false)
+	}
+}
+
+// rewindUserArenaChunk clears all memory allocated from the user arena chunk
+// outside of free, which must be a superset of the chunk's current free space,
+// and makes all of free available for allocation again.
+//
+// s must be a live user arena chunk owned exclusively by the caller.
+func (s *mspan) rewindUserArenaChunk(free addrRange) {
+	if !s.isUserArenaChunk {
+		throw("invalid span for user arena rewind")
+	}
+	front, back := s.userArenaChunkFree.base.addr(), s.userArenaChunkFree.limit.addr()
+	if base := free.base.addr(); front > base {
+		// Clear pointer-ful memory with write barriers, as in
+		// resetUserArenaChunk, and then clear its heap bits.
+		memclrHasPointers(unsafe.Pointer(base), front-base)
+		s.writeUserArenaHeapBits(base).flush(s, base, front-base)
+		if raceenabled {
+			racefree(unsafe.Pointer(base), front-base)
+			racemalloc(unsafe.Pointer(base), front-base)
+		}
+		if msanenabled {
+			msanfree(unsafe.Pointer(base), front-base)
+			msanmalloc(unsafe.Pointer(base), front-base)
+		}
+	}
+	if limit := free.limit.addr(); limit > back {
+		memclrNoHeapPointersLarge(unsafe.Pointer(back), limit-back)
+		if raceenabled {
+			racefree(unsafe.Pointer(back), limit-back)
+			racemalloc(unsafe.Pointer(back), limit-back)
+		}
+		if msanenabled {
+			msanfree(unsafe.Pointer(back), limit-back)
+			msanmalloc(unsafe.Pointer(back), limit-back)
+		}
+	}
+	// Nothing past the new front of the free space contains pointers anymore.
+	if off := free.base.addr() - s.base(); s.largeType.PtrBytes > off {
+		s.largeType.PtrBytes = off
+	}
+	s.userArenaChunkFree = free
+}
+
+// contains reports whether p points into a value allocated from the arena's own
+// memory since it was created or last reset. That excludes values too large
+// for a chunk, which are allocated from the heap.
+//
+// This operation is not safe to call concurrently with other operations on the
+// same arena.
+func (a *userArena) contains(p uintptr) bool {
+	if a.defunct.Load() {
+		return false
+	}
+	if r := a.region; r != nil {
+		base := uintptr(unsafe.Pointer(unsafe.SliceData(r.buf)))
+		return p >= base && p < r.free.base.addr()
+	}
+	s := spanOf(p)
+	if s == nil || !s.isUserArenaChunk || s.userArenaOwner != uintptr(unsafe.Pointer(a)) {
+		return false
+	}
+	// Values are allocated from both ends of the chunk, leaving the free
+	// space in the middle.
+	return p >= s.base() && p < s.base()+s.elemsize && !s.userArenaChunkFree.contains(p)
+}
+
+// userArenaOf returns the live user arena whose memory p points into a value
+// allocated from, or nil if there isn't one.
+//
+// This operation is not safe to call concurrently with operations other than
+// allocation on the arena p points into.
+func userArenaOf(p uintptr) *userArena {
+	if s := spanOf(p); s != nil && s.isUserArenaChunk {
+		a := (*userArena)(unsafe.Pointer(s.userArenaOwner))
+		if a == nil || !a.contains(p) {
+			return nil
+		}
+		return a
+	}
+	lock(&userArenaState.lock)
+	var owner *userArena
+	for a := (*userArena)(unsafe.Pointer(userArenaState.regions)); a != nil; a = (*userArena)(unsafe.Pointer(a.region.next)) {
+		if a.contains(p) {
+			owner = a
+			break
+		}
+	}
+	unlock(&userArenaState.lock)
+	return owner
+}
+
 // inUserArenaChunk returns true if p points to a user arena chunk.
 func inUserArenaChunk(p uintptr) bool {
 	s := spanOf(p)
@@ -938,6 +3064,295 @@ func inUserArenaChunk(p uintptr) bool {
 	return s.isUserArenaChunk
 }
 
+// userArenaCallerPC returns the PC of the innermost caller outside of the
+// runtime and the arena package, which is where a new user arena is being
+// created from.
+func userArenaCallerPC() uintptr {
+	var pcs [16]uintptr
+	n := callers(2, pcs[:])
+	for _, pc := range pcs[:n] {
+		if _, _, _, ok := userArenaOriginFrame(pc); ok {
+			return pc
+		}
+	}
+	return 0
+}
+
+// userArenaOriginFrame returns the name, file, and line of the innermost
+// logical frame at return PC pc that's outside of the runtime and the arena
+// package.
+func userArenaOriginFrame(pc uintptr) (name, file string, line int, ok bool) {
+	f := findfunc(pc)
+	if !f.valid() {
+		return "", "", 0, false
+	}
+	tracepc := pc - 1 // back up to CALL instruction for funcline.
+	u, uf := newInlineUnwinder(f, tracepc)
+	for ; uf.valid(); uf = u.next(uf) {
+		name := u.srcFunc(uf).name()
+		if stringslite.HasPrefix(name, "runtime.") || stringslite.HasPrefix(name, "arena.") {
+			continue
+		}
+		file, line := u.fileLine(uf)
+		return name, file, line, true
+	}
+	return "", "", 0, false
+}
+
+// printUserArenaOrigin prints where the user arena that freed the chunk
+// containing p was created, if known.
+func printUserArenaOrigin(p uintptr) {
+	s := spanOf(p)
+	if s == nil || !s.isUserArenaChunk {
+		return
+	}
+	info := userArenaPoisonInfoOf(s.base())
+	if info == nil || info.origin == 0 {
+		return
+	}
+	name, file, line, ok := userArenaOriginFrame(info.origin)
+	if !ok {
+		return
+	}
+	print("arena created by ")
+	printFuncName(name)
+	print("\n\t", file, ":", line, "\n")
+}
+
+// userArenaPoisonInfo is what GODEBUG=arenapoison records about a user
+// arena chunk.
+type userArenaPoisonInfo struct {
+	origin     uintptr // PC that created the user arena that freed the chunk
+	faultCycle uint32  // GC cycle in which the chunk was set to fault
+}
+
+// userArenaPoisonMap holds the userArenaPoisonInfo of each user arena chunk
+// in a heap arena, indexed by the chunk's offset in the heap arena divided by
+// userArenaChunkBytes. It has room for chunks of the smallest size.
+type userArenaPoisonMap [heapArenaBytes / userArenaChunkBytesMin]userArenaPoisonInfo
+
+// initUserArenaPoisonInfo clears the userArenaPoisonInfo of the user arena
+// chunk at base, which is being allocated, allocating the userArenaPoisonMap
+// of its heap arena if needed.
+//
+// h.lock must be held.
+func (h *mheap) initUserArenaPoisonInfo(base uintptr) {
+	assertLockHeld(&h.lock)
+
+	ha := h.arenas[arenaIndex(base).l1()][arenaIndex(base).l2()]
+	if ha.userArenaPoison == nil {
+		ha.userArenaPoison = (*userArenaPoisonMap)(persistentalloc(unsafe.Sizeof(userArenaPoisonMap{}), goarch.PtrSize, &memstats.gcMiscSys))
+		if ha.userArenaPoison == nil {
+			throw("out of memory allocating arenapoison map")
+		}
+	}
+	ha.userArenaPoison[base%heapArenaBytes/userArenaChunkBytes] = userArenaPoisonInfo{}
+}
+
+// userArenaPoisonInfoOf returns the userArenaPoisonInfo of the user arena
+// chunk at base, or nil if nothing was recorded for it.
+func userArenaPoisonInfoOf(base uintptr) *userArenaPoisonInfo {
+	ha := mheap_.arenas[arenaIndex(base).l1()][arenaIndex(base).l2()]
+	if ha == nil || ha.userArenaPoison == nil {
+		return nil
+	}
+	return &ha.userArenaPoison[base%heapArenaBytes/userArenaChunkBytes]
+}
+
+// userArenaLeakStackDepth is the maximum number of frames of the stack that
+// created a user arena recorded with GODEBUG=arenaleak.
+const userArenaLeakStackDepth = 32
+
+// userArenaLeaks tracks the user arenas that were freed by their finalizer
+// rather than explicitly, grouped by the stack that created them. Only used
+// with GODEBUG=arenaleak.
+var userArenaLeaks struct {
+	lock mutex
+
+	// records contains one record per distinct creation stack.
+	//
+	// Protected by lock.
+	records []userArenaLeak
+
+	// hooked is set once printUserArenaLeaks is registered to run on exit.
+	hooked atomic.Uint32
+}
+
+// userArenaLeak describes the leaked user arenas created at one stack.
+//
+// Its layout must match arena.leakRecord.
+type userArenaLeak struct {
+	stack      [userArenaLeakStackDepth]uintptr // creation stack, zero-terminated
+	count      uint64                           // number of leaked arenas
+	allocBytes uint64                           // bytes allocated from them when freed
+}
+
+// lockUserArenaLeaks locks userArenaLeaks.lock.
+//
+// records is appended to and copied by user goroutines, which the race
+// detector tracks, so tell it about the lock too.
+func lockUserArenaLeaks() {
+	lock(&userArenaLeaks.lock)
+	if raceenabled {
+		raceacquire(unsafe.Pointer(&userArenaLeaks.lock))
+	}
+}
+
+// unlockUserArenaLeaks unlocks userArenaLeaks.lock.
+func unlockUserArenaLeaks() {
+	if raceenabled {
+		racerelease(unsafe.Pointer(&userArenaLeaks.lock))
+	}
+	unlock(&userArenaLeaks.lock)
+}
+
+// recordUserArenaLeak records that a user arena created at stack with
+// allocBytes bytes allocated from it was freed by its finalizer.
+func recordUserArenaLeak(stack *[userArenaLeakStackDepth]uintptr, allocBytes uint64) {
+	lockUserArenaLeaks()
+	defer unlockUserArenaLeaks()
+
+	for i := range userArenaLeaks.records {
+		r := &userArenaLeaks.records[i]
+		if r.stack == *stack {
+			r.count++
+			r.allocBytes += allocBytes
+			return
+		}
+	}
+	userArenaLeaks.records = append(userArenaLeaks.records, userArenaLeak{
+		stack:      *stack,
+		count:      1,
+		allocBytes: allocBytes,
+	})
+}
+
+// readUserArenaLeaks copies as many leak records as fit into dst and returns
+// the total number of records.
+func readUserArenaLeaks(dst []userArenaLeak) int {
+	lockUserArenaLeaks()
+	defer unlockUserArenaLeaks()
+
+	for i := range dst {
+		if i >= len(userArenaLeaks.records) {
+			break
+		}
+		dst[i] = userArenaLeaks.records[i]
+	}
+	return len(userArenaLeaks.records)
+}
+
+// printUserArenaLeaks prints a report of the leaked user arenas recorded so
+// far to standard error. It runs when the program exits with GODEBUG=arenaleak.
+func printUserArenaLeaks() {
+	var records []userArenaLeak
+	for {
+		n := readUserArenaLeaks(records)
+		if n <= len(records) {
+			records = records[:n]
+			break
+		}
+		records = make([]userArenaLeak, n+10)
+	}
+	for i := range records {
+		r := &records[i]
+		n := 0
+		for n < len(r.stack) && r.stack[n] != 0 {
+			n++
+		}
+		print("arena leak: ", r.count, " arenas with ", r.allocBytes, " bytes allocated were freed by the garbage collector rather than Free, created at:\n")
+		frames := CallersFrames(r.stack[:n])
+		for {
+			f, more := frames.Next()
+			print("\t")
+			printFuncName(f.Function)
+			print("(...)\n\t\t", f.File, ":", f.Line, "\n")
+			if !more {
+				break
+			}
+		}
+	}
+}
+
+// userArenaProfStackDepth is the maximum number of frames of the stacks
+// recorded in the arena allocation profile.
+const userArenaProfStackDepth = 32
+
+// userArenaProf is the arena allocation profile, which contains the sampled
+// allocations from user arenas, grouped by stack and by where the arena they
+// were allocated from was created.
+var userArenaProf struct {
+	lock mutex
+
+	// records contains one record per distinct stack and arena origin.
+	//
+	// Protected by lock.
+	records []userArenaProfRecord
+}
+
+// userArenaProfRecord describes the sampled user arena allocations made at
+// one stack from arenas created at one place.
+type userArenaProfRecord struct {
+	stack      [userArenaProfStackDepth]uintptr // zero-terminated
+	origin     uintptr                          // PC that created the arenas, or 0
+	allocs     int64                            // number of sampled allocations
+	allocBytes int64                            // bytes of sampled allocations
+}
+
+// recordUserArenaAllocSample records a sampled allocation of size bytes made
+// at stack from an arena created at origin in the arena allocation profile.
+func recordUserArenaAllocSample(stack *[userArenaProfStackDepth]uintptr, origin, size uintptr) {
+	lock(&userArenaProf.lock)
+	defer unlock(&userArenaProf.lock)
+
+	for i := range userArenaProf.records {
+		r := &userArenaProf.records[i]
+		if r.origin == origin && r.stack == *stack {
+			r.allocs++
+			r.allocBytes += int64(size)
+			return
+		}
+	}
+	userArenaProf.records = append(userArenaProf.records, userArenaProfRecord{
+		stack:      *stack,
+		origin:     origin,
+		allocs:     1,
+		allocBytes: int64(size),
+	})
+}
+
+// pprof_arenaAllocProfile returns the arena allocation profile. If len(p) is
+// large enough for every record, it copies the profile into p and returns
+// the number of records and true. Otherwise it returns the number of records
+// and false, leaving p untouched.
+//
+//go:linkname pprof_arenaAllocProfile
+func pprof_arenaAllocProfile(p []profilerecord.ArenaAllocRecord) (n int, ok bool) {
+	lock(&userArenaProf.lock)
+	defer unlock(&userArenaProf.lock)
+
+	n = len(userArenaProf.records)
+	if n > len(p) {
+		return n, false
+	}
+	for i := range userArenaProf.records {
+		// A record's stack never changes once it's recorded, so p can
+		// share it.
+		r := &userArenaProf.records[i]
+		nstk := 0
+		for nstk < len(r.stack) && r.stack[nstk] != 0 {
+			nstk++
+		}
+		p[i] = profilerecord.ArenaAllocRecord{
+			AllocBytes:   r.allocBytes,
+			AllocObjects: r.allocs,
+			Origin:       r.origin,
+			Stack:        r.stack[:nstk],
+		}
+	}
+	return n, true
+}
+
 // freeUserArenaChunk releases the user arena represented by s back to the runtime.
 //
 // x must be a live pointer within s.
@@ -1047,6 +3462,9 @@ func (h *mheap) allocUserArenaChunk() *mspan {
 		}
 		s = h.allocMSpanLocked()
 	}
+	if debug.arenapoison > 0 {
+		h.initUserArenaPoisonInfo(base)
+	}
 	unlock(&h.lock)
 
 	// sysAlloc returns Reserved address space, and any span we're
@@ -1057,6 +3475,11 @@ func (h *mheap) allocUserArenaChunk() *mspan {
 	// asked for. We're likely going to use it all.
 	sysMap(unsafe.Pointer(base), userArenaChunkBytes, &gcController.heapReleased)
 	sysUsed(unsafe.Pointer(base), userArenaChunkBytes, userArenaChunkBytes)
+	if debug.arenahugepages > 0 {
+		// Mark the chunk as eligible for huge pages. This has to be done
+		// every time, since faulting a chunk remaps it, dropping the advice.
+		sysHugePage(unsafe.Pointer(base), userArenaChunkBytes)
+	}
 
 	// Model the user arena as a heap span for a large object.
 	spc := makeSpanClass(0, false)
@@ -1078,6 +3501,7 @@ func (h *mheap) allocUserArenaChunk() *mspan {
 	// Model the arena as a single large malloc.
 	atomic.Xadd64(&stats.largeAlloc, int64(s.elemsize))
 	atomic.Xadd64(&stats.largeAllocCount, 1)
+	atomic.Xaddint64(&stats.inUserArena, int64(s.elemsize))
 	memstats.heapStats.release()
 
 	// Count the alloc in inconsistent, internal stats.
@@ -1099,9 +3523,18 @@ func (h *mheap) allocUserArenaChunk() *mspan {
 	// gains are almost always worth it. Note: it's important that we
 	// clear even if it's freshly mapped and we know there's no point
 	// to zeroing as *that* is the critical signal to use huge pages.
-	memclrNoHeapPointers(unsafe.Pointer(s.base()), s.elemsize)
+	//
+	// Chunks are cleared with non-temporal stores if they're large
+	// enough, so that recycling chunks doesn't flush the cache.
+	memclrNoHeapPointersLarge(unsafe.Pointer(s.base()), s.elemsize)
 	s.needzero = 0
 
+	// If requested, collapse the freshly zeroed chunk into huge pages
+	// right away instead of leaving it to the background khugepaged.
+	if debug.arenahugepages >= 2 {
+		sysHugePageCollapse(unsafe.Pointer(base), userArenaChunkBytes)
+	}
+
 	s.freeIndexForScan = 1
 
 	// Set up the range for allocation.
@@ -7,9 +7,15 @@ package runtime_test
 import (
 	"internal/goarch"
 	"internal/runtime/atomic"
+	"internal/testenv"
+	"os"
+	"os/exec"
 	"reflect"
 	. "runtime"
 	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 	"unsafe"
@@ -376,6 +382,427 @@ func TestUserArenaClearsPointerBits(t *testing.T) {
 	GC()
 }
 
+func TestUserArenaReset(t *testing.T) {
+	// Disable the GC so that no arena chunks end up on the fault list.
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+
+	a := NewUserArena()
+
+	// Fill a few chunks with pointer-ful data, and store a reference
+	// to a heap object in the arena.
+	var first *smallPointer
+	chunks := make(map[uintptr]bool)
+	n := int(UserArenaChunkBytes/unsafe.Sizeof(smallPointer{})) * 2
+	for i := 0; i < n; i++ {
+		var x any
+		x = (*smallPointer)(nil)
+		a.New(&x)
+		p := x.(*smallPointer)
+		chunks[uintptr(unsafe.Pointer(p))&^(UserArenaChunkBytes-1)] = true
+		if i == 0 {
+			first = p
+		}
+	}
+	var finalized atomic.Bool
+	first.X = new(smallPointer)
+	SetFinalizer(first.X, func(_ *smallPointer) {
+		finalized.Store(true)
+	})
+	first = nil
+
+	a.Reset()
+
+	// The reset arena should reuse its own memory and hand out zeroed values.
+	var x any
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	v := x.(*smallPointer)
+	if got := uintptr(unsafe.Pointer(v)); !chunks[got&^(UserArenaChunkBytes-1)] {
+		t.Errorf("expected reset arena to reuse its memory: got %#x", got)
+	}
+	if v.X != nil {
+		t.Errorf("expected zeroed value after reset, got %p", v.X)
+	}
+	v = nil
+
+	// The heap object is no longer referenced by the arena.
+	GC()
+	GC()
+	if !BlockUntilEmptyFinalizerQueue(int64(2 * time.Second)) {
+		t.Fatal("finalizer queue was never emptied")
+	}
+	if !finalized.Load() {
+		t.Error("expected object only referenced by reset arena to be finalized")
+	}
+
+	// Allocate past the first chunk again, then free the arena.
+	for i := 0; i < n; i++ {
+		var x any
+		x = (*smallScalar)(nil)
+		a.New(&x)
+	}
+	a.Free()
+	GC()
+	GC()
+	if n := GlobalWaitingArenaChunks(); n != 0 {
+		t.Errorf("expected zero waiting arena chunks, found %d", n)
+	}
+}
+
+func TestUserArenaStats(t *testing.T) {
+	a := NewUserArena()
+
+	if s := a.ArenaStats(); s.Chunks != 1 || s.Allocs != 0 || s.AllocBytes != 0 {
+		t.Errorf("unexpected stats for new arena: %+v", s)
+	}
+
+	// Allocate a byte, followed by a pointer-ful value, followed by
+	// a pointer-free value that requires alignment.
+	var b []byte
+	a.Slice(&b, 1)
+	var x any
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	x = (*smallScalar)(nil)
+	a.New(&x)
+
+	s := a.ArenaStats()
+	if s.Allocs != 3 {
+		t.Errorf("got %d allocations, want 3", s.Allocs)
+	}
+	if want := uint64(1 + 2*goarch.PtrSize); s.AllocBytes != want {
+		t.Errorf("got %d allocated bytes, want %d", s.AllocBytes, want)
+	}
+	if want := uint64(goarch.PtrSize - 1); s.PaddingBytes != want {
+		t.Errorf("got %d padding bytes, want %d", s.PaddingBytes, want)
+	}
+
+	// Fill up more than one chunk.
+	for i := 0; i < int(UserArenaChunkBytes/unsafe.Sizeof(mediumScalarEven{}))+1; i++ {
+		x = (*mediumScalarEven)(nil)
+		a.New(&x)
+	}
+	if s := a.ArenaStats(); s.Chunks != 2 {
+		t.Errorf("got %d chunks, want 2", s.Chunks)
+	}
+
+	a.Reset()
+	if s := a.ArenaStats(); s.Chunks != 2 || s.Allocs != 0 || s.AllocBytes != 0 || s.PaddingBytes != 0 {
+		t.Errorf("unexpected stats for reset arena: %+v", s)
+	}
+
+	a.Free()
+	if s := a.ArenaStats(); s != (UserArenaStats{}) {
+		t.Errorf("unexpected stats for freed arena: %+v", s)
+	}
+}
+
+func TestUserArenaGrowInPlace(t *testing.T) {
+	a := NewUserArena()
+	defer a.Free()
+
+	var sl []*smallPointer
+	a.Slice(&sl, 4)
+	base := unsafe.Pointer(&sl[0])
+	if !a.GrowInPlace(&sl, 16) {
+		t.Fatal("failed to grow most recent allocation")
+	}
+	if cap(sl) != 16 || len(sl) != 4 {
+		t.Fatalf("unexpected slice after growth: len %d, cap %d", len(sl), cap(sl))
+	}
+	if unsafe.Pointer(&sl[0]) != base {
+		t.Fatal("slice moved when growing in place")
+	}
+	sl = sl[:16]
+	for i := range sl {
+		if sl[i] != nil {
+			t.Fatalf("element %d not zeroed after growth", i)
+		}
+	}
+
+	// Make sure the GC sees pointers in the extension.
+	var finalized atomic.Bool
+	sl[15] = new(smallPointer)
+	SetFinalizer(sl[15], func(_ *smallPointer) {
+		finalized.Store(true)
+	})
+	GC()
+	GC()
+	if finalized.Load() {
+		t.Error("object referenced from grown arena slice was finalized")
+	}
+	sl[15] = nil
+
+	// Once something else is allocated, it's no longer possible to grow.
+	var x any
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	if a.GrowInPlace(&sl, 32) {
+		t.Error("unexpectedly grew a slice that is not the most recent allocation")
+	}
+
+	// Pointer-free slices grow in place too, even after other pointer-free
+	// values are allocated, since those come from the back of the chunk.
+	var b []byte
+	a.Slice(&b, 8)
+	copy(b, "01234567")
+	bbase := unsafe.Pointer(&b[0])
+	var y any
+	y = (*[4]int)(nil)
+	a.New(&y)
+	if !a.GrowInPlace(&b, 1024) {
+		t.Fatal("failed to grow most recent pointer-free slice")
+	}
+	if cap(b) != 1024 || unsafe.Pointer(&b[0]) != bbase || string(b) != "01234567" {
+		t.Fatalf("unexpected slice after growth: cap %d, moved %t, contents %q", cap(b), unsafe.Pointer(&b[0]) != bbase, b)
+	}
+	for i, c := range b[len(b):cap(b)] {
+		if c != 0 {
+			t.Fatalf("byte %d not zeroed after growth", len(b)+i)
+		}
+	}
+}
+
+func TestUserArenaMap(t *testing.T) {
+	a := NewUserArena()
+	defer a.Free()
+
+	t.Run("scalar", func(t *testing.T) {
+		var m map[int]int
+		a.Map(&m, 0)
+		for i := 0; i < 10000; i++ {
+			m[i] = i * 2
+		}
+		GC()
+		for i := 0; i < 10000; i++ {
+			if m[i] != i*2 {
+				t.Fatalf("m[%d] = %d, want %d", i, m[i], i*2)
+			}
+		}
+		delete(m, 5)
+		if len(m) != 9999 {
+			t.Errorf("len(m) = %d, want 9999", len(m))
+		}
+		clear(m)
+		m[1] = 1
+		if len(m) != 1 || m[1] != 1 {
+			t.Errorf("unexpected map contents after clear: %v", m)
+		}
+	})
+	t.Run("pointer", func(t *testing.T) {
+		var m map[string]*smallPointer
+		a.Map(&m, 100)
+		for i := 0; i < 1000; i++ {
+			m[string(rune(i))] = &smallPointer{}
+		}
+		GC()
+		for i := 0; i < 1000; i++ {
+			if p, ok := m[string(rune(i))]; !ok || p == nil || p.X != nil {
+				t.Fatalf("bad map entry for %d: %v %v", i, p, ok)
+			}
+		}
+	})
+	t.Run("large", func(t *testing.T) {
+		var m map[mediumScalarOdd]mediumScalarOdd
+		a.Map(&m, 0)
+		var k, v mediumScalarOdd
+		for i := 0; i < 10; i++ {
+			k[0], v[0] = byte(i), byte(i+1)
+			m[k] = v
+		}
+		for i := 0; i < 10; i++ {
+			k[0] = byte(i)
+			if m[k][0] != byte(i+1) {
+				t.Fatalf("bad map entry for %d", i)
+			}
+		}
+	})
+}
+
+func TestUserArenaChunkBytesFor(t *testing.T) {
+	min := uintptr(64 << 10)
+	for min < uintptr(PhysPageSize) {
+		min <<= 1
+	}
+	for _, test := range []struct {
+		n, want uintptr
+	}{
+		{1, min},
+		{min - 1, min},
+		{min, min},
+		{min + 1, 2 * min},
+		{1 << 20, 1 << 20},
+		{3 << 20, 4 << 20},
+		{UserArenaChunkBytes, UserArenaChunkBytes},
+		{UserArenaChunkBytes + 1, UserArenaChunkBytes},
+		{1 << 30, UserArenaChunkBytes},
+	} {
+		want := test.want
+		if want > UserArenaChunkBytes {
+			want = UserArenaChunkBytes
+		}
+		if got := UserArenaChunkBytesFor(test.n); got != want {
+			t.Errorf("UserArenaChunkBytesFor(%d) = %d, want %d", test.n, got, want)
+		}
+	}
+}
+
+func TestUserArenaChunkBytesGODEBUG(t *testing.T) {
+	// Run the test in a subprocess, since the chunk size can only be
+	// set at startup.
+	if os.Getenv("TEST_USER_ARENA_CHUNK_BYTES") != "1" {
+		testenv.MustHaveExec(t)
+		cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^(TestUserArenaChunkBytesGODEBUG|TestUserArena|TestUserArenaReset|TestUserArenaMap)$", "-test.v"))
+		cmd.Env = append(cmd.Env, "TEST_USER_ARENA_CHUNK_BYTES=1", "GODEBUG=arenachunksize=65536")
+		out, err := cmd.CombinedOutput()
+		if !strings.Contains(string(out), "PASS\n") || err != nil {
+			t.Fatalf("%s\n(exit status %v)", string(out), err)
+		}
+		return
+	}
+
+	// Allocate 256 KiB of data, which should span multiple 64 KiB chunks.
+	a := NewUserArena()
+	defer a.Free()
+	for i := 0; i < 32; i++ {
+		var x any
+		x = (*mediumScalarEven)(nil)
+		a.New(&x)
+	}
+	if s := a.ArenaStats(); s.Chunks < 4 {
+		t.Errorf("expected allocations to span at least 4 chunks, got %d", s.Chunks)
+	}
+}
+
+func TestUserArenaHugePagesGODEBUG(t *testing.T) {
+	// Run the test in a subprocess, since huge page settings can only be
+	// changed at startup.
+	if os.Getenv("TEST_USER_ARENA_HUGE_PAGES") != "1" {
+		testenv.MustHaveExec(t)
+		for _, mode := range []string{"1", "2"} {
+			cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^(TestUserArenaHugePagesGODEBUG|TestUserArena|TestUserArenaReset)$", "-test.v"))
+			cmd.Env = append(cmd.Env, "TEST_USER_ARENA_HUGE_PAGES=1", "GODEBUG=arenahugepages="+mode)
+			out, err := cmd.CombinedOutput()
+			if !strings.Contains(string(out), "PASS\n") || err != nil {
+				t.Fatalf("arenahugepages=%s: %s\n(exit status %v)", mode, string(out), err)
+			}
+		}
+		return
+	}
+
+	// Allocate enough to require a few fresh chunks, and make sure the
+	// memory is usable.
+	a := NewUserArena()
+	defer a.Free()
+	for i := 0; i < 3*int(UserArenaChunkBytes/unsafe.Sizeof(mediumPointerEven{})); i++ {
+		var x any
+		x = (*mediumPointerEven)(nil)
+		a.New(&x)
+		x.(*mediumPointerEven)[0] = new(smallPointer)
+	}
+}
+
+func TestUserArenaMarkRelease(t *testing.T) {
+	// Disable the GC so that no arena chunks end up on the fault list.
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+
+	a := NewUserArena()
+	defer a.Free()
+
+	var x any
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	before := x.(*smallPointer)
+	before.X = before
+
+	m := a.Mark()
+	stats := a.ArenaStats()
+
+	// Allocate pointer-ful and pointer-free values after the mark, spanning
+	// multiple chunks, and make them reference a heap object.
+	var finalized atomic.Bool
+	obj := new(smallPointer)
+	SetFinalizer(obj, func(_ *smallPointer) {
+		finalized.Store(true)
+	})
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	after := x.(*smallPointer)
+	after.X = obj
+	afterAddr := uintptr(unsafe.Pointer(after))
+	for i := 0; i < int(UserArenaChunkBytes/unsafe.Sizeof(mediumPointerEven{}))+1; i++ {
+		x = (*mediumPointerEven)(nil)
+		a.New(&x)
+		x.(*mediumPointerEven)[0] = obj
+	}
+	var b []byte
+	a.Slice(&b, 16)
+	after, obj, x = nil, nil, nil
+
+	a.Release(m)
+
+	// Chunks obtained after the mark are kept by the arena for reuse.
+	stats.Chunks = 2
+	if got := a.ArenaStats(); got != stats {
+		t.Errorf("unexpected stats after release: got %+v, want %+v", got, stats)
+	}
+	if before.X != before {
+		t.Error("value allocated before mark was clobbered by release")
+	}
+
+	// Memory after the mark should be reused, and zeroed.
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	if v := x.(*smallPointer); uintptr(unsafe.Pointer(v)) != afterAddr || v.X != nil {
+		t.Errorf("expected zeroed value at %#x after release, got %p at %p", afterAddr, v.X, v)
+	}
+	x = nil
+
+	// The heap object should no longer be referenced from the arena.
+	GC()
+	GC()
+	if !BlockUntilEmptyFinalizerQueue(int64(2 * time.Second)) {
+		t.Fatal("finalizer queue was never emptied")
+	}
+	if !finalized.Load() {
+		t.Error("expected object only referenced by released values to be finalized")
+	}
+
+	// Marks created after a released mark are invalid, but the released
+	// mark itself stays valid.
+	inner := a.Mark()
+	a.Release(m)
+	a.Release(m)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic when releasing an invalidated mark")
+			}
+		}()
+		a.Release(inner)
+	}()
+	KeepAlive(before)
+}
+
+func TestUserArenaMarkMemory(t *testing.T) {
+	a := NewUserArena()
+	defer a.Free()
+
+	// Neither marks that are released right away, nor marks that
+	// are dropped, should take up memory in the arena.
+	outer := a.Mark()
+	for range 100 {
+		m := a.Mark()
+		a.Release(m)
+		a.Mark()
+		a.Mark()
+		a.Release(outer)
+	}
+	if n := a.MarkRuns(); n != 1 {
+		t.Errorf("arena has %d runs of valid marks, want 1", n)
+	}
+}
+
 func TestUserArenaCloneString(t *testing.T) {
 	a := NewUserArena()
 
@@ -524,3 +951,246 @@ func TestUserArenaClonePanic(t *testing.T) {
 		t.Errorf("expected panic from Clone")
 	}
 }
+
+func TestUserArenaConcurrent(t *testing.T) {
+	defer GOMAXPROCS(GOMAXPROCS(4))
+
+	a := NewConcurrentUserArena()
+	defer a.Free()
+
+	const (
+		goroutines = 8
+		perG       = 10000
+	)
+	var wg sync.WaitGroup
+	heads := make([]*smallPointer, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Build a linked list in the arena, mixing in pointer-free
+			// allocations, and make sure nothing got clobbered.
+			var head *smallPointer
+			for i := 0; i < perG; i++ {
+				var x any
+				x = (*smallPointer)(nil)
+				a.New(&x)
+				p := x.(*smallPointer)
+				p.X = head
+				head = p
+
+				x = (*smallScalar)(nil)
+				a.New(&x)
+				x.(*smallScalar).X = uintptr(i)
+				if x.(*smallScalar).X != uintptr(i) {
+					t.Errorf("scalar value clobbered")
+					return
+				}
+				if i%1000 == 0 {
+					GC()
+				}
+			}
+			heads[g] = head
+		}()
+	}
+	wg.Wait()
+
+	GC()
+	for g, head := range heads {
+		n := 0
+		for p := head; p != nil; p = p.X {
+			n++
+		}
+		if n != perG {
+			t.Errorf("goroutine %d: list has %d elements, want %d", g, n, perG)
+		}
+	}
+	if s := a.ArenaStats(); s.Allocs != 2*goroutines*perG {
+		t.Errorf("got %d allocations, want %d", s.Allocs, 2*goroutines*perG)
+	}
+
+	// Reset, and make sure allocation still works.
+	heads = nil
+	a.Reset()
+	if s := a.ArenaStats(); s.Allocs != 0 || s.Chunks == 0 {
+		t.Errorf("unexpected stats after reset: %+v", s)
+	}
+	var x any
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	x.(*smallPointer).X = x.(*smallPointer)
+	if s := a.ArenaStats(); s.Allocs != 1 {
+		t.Errorf("got %d allocations after reset, want 1", s.Allocs)
+	}
+
+	// The arena shouldn't allow growing slices in place.
+	var sl []*smallPointer
+	a.Slice(&sl, 1)
+	if a.GrowInPlace(&sl, 2) {
+		t.Errorf("grew slice in place in concurrent arena")
+	}
+}
+
+func TestUserArenaChunkPool(t *testing.T) {
+	if Raceenabled {
+		t.Skip("arena chunks are never reused with the race detector enabled")
+	}
+	// Disable the GC so that the pool's chunks are not freed from under us.
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+
+	p := NewUserArenaChunkPool()
+	reuse := GlobalReusableArenaChunks()
+
+	a := p.NewUserArena()
+	var x any
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	chunk := uintptr(unsafe.Pointer(x.(*smallPointer))) &^ (UserArenaChunkBytes - 1)
+	if chunks, idle := p.Stats(); chunks != 1 || idle != 0 {
+		t.Errorf("got %d chunks (%d idle) in pool, want 1 (0 idle)", chunks, idle)
+	}
+	x = nil
+	a.Free()
+
+	// The arena's chunk should have gone back to the pool, not the global list.
+	if chunks, idle := p.Stats(); chunks != 1 || idle != 1 {
+		t.Errorf("got %d chunks (%d idle) in pool after free, want 1 (1 idle)", chunks, idle)
+	}
+	if n := GlobalReusableArenaChunks(); n != reuse {
+		t.Errorf("global reuse list changed from %d to %d chunks", reuse, n)
+	}
+
+	// A new arena from the pool should pick the chunk back up.
+	a = p.NewUserArena()
+	x = (*smallPointer)(nil)
+	a.New(&x)
+	if got := uintptr(unsafe.Pointer(x.(*smallPointer))) &^ (UserArenaChunkBytes - 1); got != chunk {
+		t.Errorf("new arena didn't reuse the pool's chunk")
+	}
+	x = nil
+
+	// Freeing the pool with an arena still using it only frees idle chunks,
+	// and the arena's chunk is freed when the arena is.
+	p.Free()
+	if chunks, idle := p.Stats(); chunks != 1 || idle != 0 {
+		t.Errorf("got %d chunks (%d idle) in freed pool, want 1 (0 idle)", chunks, idle)
+	}
+	a.Free()
+	if chunks, idle := p.Stats(); chunks != 0 || idle != 0 {
+		t.Errorf("got %d chunks (%d idle) in freed pool after arena free, want 0", chunks, idle)
+	}
+	if n := GlobalReusableArenaChunks(); n != reuse {
+		t.Errorf("global reuse list changed from %d to %d chunks", reuse, n)
+	}
+}
+
+func TestUserArenaMetrics(t *testing.T) {
+	if Raceenabled {
+		t.Skip("arena chunks are never reused with the race detector enabled")
+	}
+	// Disable the GC so that quarantined chunks stay quarantined.
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/heap/user-arena/inuse:bytes"},
+		{Name: "/memory/classes/heap/user-arena/reusable:bytes"},
+		{Name: "/gc/heap/user-arena/quarantined:bytes"},
+	}
+	read := func() (inuse, reusable, quarantined uint64) {
+		metrics.Read(samples)
+		return samples[0].Value.Uint64(), samples[1].Value.Uint64(), samples[2].Value.Uint64()
+	}
+
+	inuse0, _, quarantined0 := read()
+	a := NewUserArena()
+	for i := 0; i < int(UserArenaChunkBytes/unsafe.Sizeof(mediumScalarEven{}))+1; i++ {
+		var x any
+		x = (*mediumScalarEven)(nil)
+		a.New(&x)
+	}
+	inuse1, reusable1, _ := read()
+	if inuse1 < inuse0+uint64(UserArenaChunkBytes) {
+		t.Errorf("in-use arena memory went from %d to %d bytes, want at least two more chunks", inuse0, inuse1)
+	}
+
+	// Freeing the arena should make the active chunk reusable, and quarantine
+	// the full one.
+	a.Free()
+	inuse2, reusable2, quarantined2 := read()
+	if reusable2 <= reusable1 || inuse1-inuse2 < reusable2-reusable1 {
+		t.Errorf("after free, in-use went from %d to %d bytes and reusable from %d to %d bytes", inuse1, inuse2, reusable1, reusable2)
+	}
+	if quarantined2 < quarantined0+uint64(UserArenaChunkBytes) {
+		t.Errorf("quarantined arena memory went from %d to %d bytes, want at least one more chunk", quarantined0, quarantined2)
+	}
+}
+
+func TestUserArenaPoisonGODEBUG(t *testing.T) {
+	// Run the test in a subprocess, since GODEBUG=arenapoison can only be
+	// set at startup, and since it crashes.
+	switch os.Getenv("TEST_USER_ARENA_POISON") {
+	case "":
+		testenv.MustHaveExec(t)
+		run := func(mode string) string {
+			cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^TestUserArenaPoisonGODEBUG$", "-test.v"))
+			cmd.Env = append(cmd.Env, "TEST_USER_ARENA_POISON="+mode, "GODEBUG=arenapoison=3")
+			out, _ := cmd.CombinedOutput()
+			return string(out)
+		}
+		if out := run("quarantine"); !strings.Contains(out, "PASS\n") {
+			t.Errorf("quarantine: %s", out)
+		}
+		out := run("fault")
+		for _, want := range []string{"accessed data from freed user arena", "arena created by runtime_test.TestUserArenaPoisonGODEBUG"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("fault: output does not contain %q:\n%s", want, out)
+			}
+		}
+		return
+	case "quarantine":
+		if Raceenabled {
+			t.Skip("arena chunks are freed differently with the race detector enabled")
+		}
+	}
+
+	// Make sure chunks are set to fault as soon as they're freed.
+	GC()
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+
+	// Allocate enough to fill up the first chunk, so that it gets set to
+	// fault instead of being reused when the arena is freed.
+	a := NewUserArena()
+	var first *mediumPointerEven
+	for i := 0; i < int(UserArenaChunkBytes/unsafe.Sizeof(mediumPointerEven{}))+1; i++ {
+		var x any
+		x = (*mediumPointerEven)(nil)
+		a.New(&x)
+		if first == nil {
+			first = x.(*mediumPointerEven)
+		}
+	}
+	a.Free()
+
+	if os.Getenv("TEST_USER_ARENA_POISON") == "fault" {
+		first[0] = nil
+		t.Fatal("accessing freed arena memory did not fault")
+	}
+
+	// Drop the reference to the chunk, and make sure it stays quarantined
+	// for the requested number of GC cycles.
+	first = nil
+	quarantined := []metrics.Sample{{Name: "/gc/heap/user-arena/quarantined:bytes"}}
+	for i := 0; i < 6; i++ {
+		GC()
+		metrics.Read(quarantined)
+		q := quarantined[0].Value.Uint64()
+		if i < 2 && q == 0 {
+			t.Fatalf("chunk left quarantine after %d GC cycles", i+1)
+		}
+		if q == 0 {
+			return
+		}
+	}
+	t.Fatalf("chunk still quarantined after 6 GC cycles")
+}
@@ -31,10 +31,15 @@ _cgo_sys_thread_start(ThreadStart *ts)
 	sigfillset(&ign);
 	pthread_sigmask(SIG_SETMASK, &ign, &oset);
 
-	size = pthread_get_stacksize_np(pthread_self());
 	pthread_attr_init(&attr);
-	pthread_attr_setstacksize(&attr, size);
 	pthread_attr_setdetachstate(&attr, PTHREAD_CREATE_DETACHED);
+	// Use the stack size the runtime asked for, if any, and otherwise
+	// the size of the main thread's stack.
+	size = ts->g->stackhi;
+	if (size == 0 || pthread_attr_setstacksize(&attr, size) != 0) {
+		size = pthread_get_stacksize_np(pthread_self());
+		pthread_attr_setstacksize(&attr, size);
+	}
 	// Leave stacklo=0 and set stackhi=size; mstart will do the rest.
 	ts->g->stackhi = size;
 	err = _cgo_try_pthread_create(&p, &attr, threadentry, ts);
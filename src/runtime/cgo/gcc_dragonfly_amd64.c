@@ -26,7 +26,6 @@ _cgo_sys_thread_start(ThreadStart *ts)
 	pthread_attr_t attr;
 	sigset_t ign, oset;
 	pthread_t p;
-	size_t size;
 	int err;
 
 	SIGFILLSET(ign);
@@ -34,10 +33,9 @@ _cgo_sys_thread_start(ThreadStart *ts)
 
 	pthread_attr_init(&attr);
 	pthread_attr_setdetachstate(&attr, PTHREAD_CREATE_DETACHED);
-	pthread_attr_getstacksize(&attr, &size);
 
-	// Leave stacklo=0 and set stackhi=size; mstart will do the rest.
-	ts->g->stackhi = size;
+	// Leave stacklo=0 and set stackhi to the stack size; mstart will do the rest.
+	_cgo_set_stacksize(&attr, &ts->g->stackhi);
 	err = _cgo_try_pthread_create(&p, &attr, threadentry, ts);
 
 	pthread_sigmask(SIG_SETMASK, &oset, nil);
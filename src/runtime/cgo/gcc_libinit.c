@@ -169,6 +169,20 @@ _cgo_try_pthread_create(pthread_t* thread, const pthread_attr_t* attr, void* (*p
 	return EAGAIN;
 }
 
+// _cgo_set_stacksize sets the stack size in attr to *stackhi, the size
+// the runtime asked for, if that's not 0 and pthread accepts it.
+// Otherwise it sets *stackhi to the default size in attr.
+void
+_cgo_set_stacksize(pthread_attr_t* attr, uintptr* stackhi) {
+	size_t size;
+
+	if (*stackhi != 0 && pthread_attr_setstacksize(attr, *stackhi) == 0) {
+		return;
+	}
+	pthread_attr_getstacksize(attr, &size);
+	*stackhi = size;
+}
+
 static void
 pthread_key_destructor(void* g) {
 	if (x_crosscall2_ptr != NULL) {
@@ -64,7 +64,7 @@ _cgo_maybe_run_preinit() {
 
 void
 x_cgo_sys_thread_create(void (*func)(void*), void* arg) {
-	_cgo_beginthread(func, arg);
+	_cgo_beginthread(func, arg, 0);
 }
 
 int
@@ -135,12 +135,12 @@ void (*(_cgo_get_context_function(void)))(struct context_arg*) {
 	return ret;
 }
 
-void _cgo_beginthread(void (*func)(void*), void* arg) {
+void _cgo_beginthread(void (*func)(void*), void* arg, uintptr stacksize) {
 	int tries;
 	uintptr_t thandle;
 
 	for (tries = 0; tries < 20; tries++) {
-		thandle = _beginthread(func, 0, arg);
+		thandle = _beginthread(func, stacksize, arg);
 		if (thandle == -1 && errno == EACCES) {
 			// "Insufficient resources", try again in a bit.
 			//
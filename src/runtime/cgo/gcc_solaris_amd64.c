@@ -52,8 +52,10 @@ _cgo_sys_thread_start(ThreadStart *ts)
 
 	if (pthread_attr_getstack(&attr, &base, &size) != 0)
 		perror("runtime/cgo: pthread_attr_getstack failed");
-	if (size == 0) {
-		ts->g->stackhi = 2 << 20;
+	if (size == 0 || ts->g->stackhi != 0) {
+		// Use the stack size the runtime asked for, or else 2 MB.
+		if (ts->g->stackhi == 0)
+			ts->g->stackhi = 2 << 20;
 		if (pthread_attr_setstack(&attr, NULL, ts->g->stackhi) != 0)
 			perror("runtime/cgo: pthread_attr_setstack failed");
 	} else {
@@ -26,7 +26,7 @@ x_cgo_init(G *g, void (*setg)(void*), void **tlsg, void **tlsbase)
 void
 _cgo_sys_thread_start(ThreadStart *ts)
 {
-	_cgo_beginthread(threadentry, ts);
+	_cgo_beginthread(threadentry, ts, ts->g->stackhi);
 }
 
 extern void crosscall1(void (*fn)(void), void (*setg_gcc)(void*), void *g);
@@ -7,6 +7,11 @@
  */
 extern void _cgo_set_stacklo(G *, uintptr *);
 
+/*
+ * Set the stack size of a thread the runtime is creating.
+ */
+extern void _cgo_set_stacksize(pthread_attr_t*, uintptr*);
+
 /*
  * Call pthread_create, retrying on EAGAIN.
  */
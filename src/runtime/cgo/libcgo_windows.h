@@ -2,5 +2,6 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Call _beginthread, aborting on failure.
-void _cgo_beginthread(void (*func)(void*), void* arg);
+// Call _beginthread, aborting on failure. A stacksize of 0 means the
+// default stack size.
+void _cgo_beginthread(void (*func)(void*), void* arg, uintptr stacksize);
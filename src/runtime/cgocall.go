@@ -281,11 +281,18 @@ func callbackUpdateSystemStack(mp *m, sp uintptr, signal bool) {
 
 	// Set the stack bounds to match the current stack. If we don't
 	// actually know how big the stack is, like we don't know how big any
-	// scheduling stack is, but we assume there's at least 32 kB. If we
-	// can get a more accurate stack bound from pthread, use that, provided
-	// it actually contains SP..
+	// scheduling stack is, but we assume there's at least 32 kB, or as
+	// much as GODEBUG=cgocallbackstack says. If we can get a more accurate
+	// stack bound from pthread, use that, provided it actually contains
+	// SP..
+	size := uintptr(32 * 1024)
+	if !signal && debug.cgocallbackstack > 0 && uintptr(debug.cgocallbackstack) < sp {
+		// Signal handlers run on the signal stack, which is
+		// small whatever GODEBUG says.
+		size = uintptr(debug.cgocallbackstack)
+	}
 	g0.stack.hi = sp + 1024
-	g0.stack.lo = sp - 32*1024
+	g0.stack.lo = sp - size
 	if !signal && _cgo_getstackbound != nil {
 		// Don't adjust if called from the signal handler.
 		// We are on the signal stack, not the pthread stack.
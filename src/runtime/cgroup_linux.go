@@ -0,0 +1,273 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"internal/stringslite"
+	"unsafe"
+)
+
+// cgroupCPU reads the CPU bandwidth limit of the program's cgroup, in
+// the cpu controller's files of either cgroup v1 or v2.
+//
+// The limit of a cgroup also applies to its descendants, so the
+// program's limit is the lowest of the limits of its cgroup and those
+// above it in the hierarchy, up to the root of the mounted hierarchy.
+//
+// limit is called by sysmon, so it must not allocate.
+type cgroupCPU struct {
+	v1 bool // the cpu controller is in a cgroup v1 hierarchy
+
+	// NUL-terminated names of the files holding the limits of the
+	// program's cgroup and its ancestors: cpu.max, or, for cgroup v1,
+	// cpu.cfs_quota_us and cpu.cfs_period_us.
+	files   [][]byte
+	periods [][]byte
+
+	buf [2][64]byte
+}
+
+// init locates the program's cgroup, and reports whether it did.
+func (c *cgroupCPU) init() bool {
+	cgroups, ok := readFileAll("/proc/self/cgroup")
+	if !ok {
+		return false
+	}
+	v1Path, v2Path, ok := cgroupPaths(cgroups)
+	if !ok {
+		return false
+	}
+	mountinfo, ok := readFileAll("/proc/self/mountinfo")
+	if !ok {
+		return false
+	}
+	var dir, mount string
+	if v1Path != "" {
+		dir, mount, ok = cgroupDir(mountinfo, v1Path, true)
+		c.v1 = ok
+	}
+	if !ok && v2Path != "" {
+		dir, mount, ok = cgroupDir(mountinfo, v2Path, false)
+	}
+	if !ok {
+		return false
+	}
+	for {
+		if c.v1 {
+			c.files = append(c.files, cgroupFileName(dir+"/cpu.cfs_quota_us"))
+			c.periods = append(c.periods, cgroupFileName(dir+"/cpu.cfs_period_us"))
+		} else {
+			c.files = append(c.files, cgroupFileName(dir+"/cpu.max"))
+		}
+		if len(dir) <= len(mount) {
+			break
+		}
+		i := len(dir) - 1
+		for i > len(mount) && dir[i] != '/' {
+			i--
+		}
+		dir = dir[:i]
+	}
+	return true
+}
+
+// cgroupFileName returns name, NUL-terminated.
+func cgroupFileName(name string) []byte {
+	b := make([]byte, len(name)+1)
+	copy(b, name)
+	return b
+}
+
+// limit returns the CPU bandwidth limit, in CPUs, and reports whether
+// there is one.
+func (c *cgroupCPU) limit() (float64, bool) {
+	limit, found := 0.0, false
+	for i, name := range c.files {
+		s, ok := c.readFile(name, &c.buf[0])
+		if !ok {
+			continue
+		}
+		var l float64
+		if c.v1 {
+			period, ok1 := c.readFile(c.periods[i], &c.buf[1])
+			if !ok1 {
+				continue
+			}
+			l, ok = parseCgroupV1CPULimit(s, period)
+		} else {
+			l, ok = parseCgroupCPUMax(s)
+		}
+		if ok && (!found || l < limit) {
+			limit, found = l, true
+		}
+	}
+	return limit, found
+}
+
+// readFile reads the file name, which must be NUL-terminated, into buf,
+// and returns its contents, truncated to len(buf). The result is only
+// valid until buf is reused.
+func (c *cgroupCPU) readFile(name []byte, buf *[64]byte) (string, bool) {
+	fd := open(&name[0], 0 /* O_RDONLY */, 0)
+	if fd < 0 {
+		return "", false
+	}
+	n := read(fd, unsafe.Pointer(&buf[0]), int32(len(buf)))
+	closefd(fd)
+	if n <= 0 {
+		return "", false
+	}
+	// Not unsafe.String, whose length check could panic, which sysmon
+	// mustn't.
+	ss := stringStruct{str: unsafe.Pointer(&buf[0]), len: int(n)}
+	return *(*string)(unsafe.Pointer(&ss)), true
+}
+
+// readFileAll returns the contents of the file name, which may be
+// large, such as /proc/self/mountinfo.
+func readFileAll(name string) (string, bool) {
+	fd := open(&cgroupFileName(name)[0], 0 /* O_RDONLY */, 0)
+	if fd < 0 {
+		return "", false
+	}
+	defer closefd(fd)
+	buf := make([]byte, 0, 16<<10)
+	for {
+		if len(buf) == cap(buf) {
+			if len(buf) >= 16<<20 {
+				return "", false
+			}
+			nbuf := make([]byte, len(buf), 2*cap(buf))
+			copy(nbuf, buf)
+			buf = nbuf
+		}
+		n := read(fd, unsafe.Pointer(&buf[:cap(buf)][len(buf)]), int32(cap(buf)-len(buf)))
+		if n < 0 {
+			return "", false
+		}
+		if n == 0 {
+			return string(buf), true
+		}
+		buf = buf[:len(buf)+int(n)]
+	}
+}
+
+// cgroupPaths returns the paths of the program's cgroup in the cgroup
+// v1 hierarchy with the cpu controller, and in the cgroup v2 hierarchy,
+// from the contents of /proc/self/cgroup, lines of the form
+//
+//	hierarchy-ID:controllers:path
+//
+// The v2 hierarchy has ID 0 and no controllers.
+func cgroupPaths(s string) (v1, v2 string, ok bool) {
+	for s != "" {
+		var line string
+		line, s, _ = stringslite.Cut(s, "\n")
+		id, rest, ok1 := stringslite.Cut(line, ":")
+		controllers, path, ok2 := stringslite.Cut(rest, ":")
+		if !ok1 || !ok2 || path == "" {
+			continue
+		}
+		if id == "0" && controllers == "" {
+			v2 = path
+			continue
+		}
+		for controllers != "" {
+			var c string
+			c, controllers, _ = stringslite.Cut(controllers, ",")
+			if c == "cpu" {
+				v1 = path
+			}
+		}
+	}
+	return v1, v2, v1 != "" || v2 != ""
+}
+
+// cgroupDir returns the directory of the cgroup at path, in the cgroup
+// v1 hierarchy with the cpu controller or the cgroup v2 hierarchy, and
+// the mount point of the hierarchy, from the contents of
+// /proc/self/mountinfo, lines of the form
+//
+//	36 35 98:0 /root /mnt1 rw,noatime master:1 - cgroup cgroup rw,cpu,cpuacct
+//
+// The fourth field is the path of the mount's root within the
+// hierarchy, and the fifth is the mount point. After the optional
+// fields and a "-" separator come the file system type and its mount
+// options, which list the controllers of a cgroup v1 hierarchy.
+func cgroupDir(mountinfo, path string, v1 bool) (dir, mount string, ok bool) {
+	for mountinfo != "" {
+		var line string
+		line, mountinfo, _ = stringslite.Cut(mountinfo, "\n")
+		var fields [5]string
+		for i := range fields {
+			fields[i], line, _ = stringslite.Cut(line, " ")
+		}
+		root, point := fields[3], fields[4]
+		_, line, ok := stringslite.Cut(line, " - ")
+		if !ok {
+			continue
+		}
+		fstype, line, _ := stringslite.Cut(line, " ")
+		_, opts, _ := stringslite.Cut(line, " ")
+		if v1 {
+			if fstype != "cgroup" || !hasOption(opts, "cpu") {
+				continue
+			}
+		} else if fstype != "cgroup2" {
+			continue
+		}
+		// The cgroup must be within the mounted part of the
+		// hierarchy.
+		rel := path
+		if root != "/" {
+			rel, ok = stringslite.CutPrefix(path, root)
+			if !ok || rel != "" && rel[0] != '/' {
+				continue
+			}
+		}
+		return point + stringslite.TrimSuffix(rel, "/"), point, true
+	}
+	return "", "", false
+}
+
+// hasOption reports whether the comma-separated options include opt.
+func hasOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = stringslite.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCgroupCPUMax parses the contents of a cgroup v2 cpu.max file,
+// "quota period" or "max period", and returns the limit in CPUs.
+func parseCgroupCPUMax(s string) (float64, bool) {
+	quota, period, ok := stringslite.Cut(stringslite.TrimSuffix(s, "\n"), " ")
+	if !ok || quota == "max" {
+		return 0, false
+	}
+	return cgroupCPUQuota(quota, period)
+}
+
+// parseCgroupV1CPULimit parses the contents of the cgroup v1
+// cpu.cfs_quota_us and cpu.cfs_period_us files, and returns the limit
+// in CPUs. A quota of -1 means there's no limit.
+func parseCgroupV1CPULimit(quota, period string) (float64, bool) {
+	return cgroupCPUQuota(stringslite.TrimSuffix(quota, "\n"), stringslite.TrimSuffix(period, "\n"))
+}
+
+// cgroupCPUQuota returns the limit in CPUs of a quota of CPU time per
+// period, both in microseconds.
+func cgroupCPUQuota(quota, period string) (float64, bool) {
+	q, ok1 := atoi64(quota)
+	p, ok2 := atoi64(period)
+	if !ok1 || !ok2 || q <= 0 || p <= 0 {
+		return 0, false
+	}
+	return float64(q) / float64(p), true
+}
@@ -0,0 +1,19 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package runtime
+
+// cgroupCPU reads the CPU bandwidth limit of the program's cgroup.
+// There are no cgroups on this system.
+type cgroupCPU struct{}
+
+func (c *cgroupCPU) init() bool {
+	return false
+}
+
+func (c *cgroupCPU) limit() (float64, bool) {
+	return 0, false
+}
@@ -159,6 +159,8 @@ func coroswitch_m(gp *g) {
 			// The CAS failed: use casgstatus, which will take care of
 			// coordinating with the garbage collector about the state change.
 			casgstatus(gp, _Grunning, _Gwaiting)
+		} else {
+			gp.accountCPUTime(_Grunning, _Gwaiting, cpuTimeNow())
 		}
 
 		// Clear gp.m.
@@ -216,6 +218,8 @@ func coroswitch_m(gp *g) {
 		// coordinating with the garbage collector about the state change.
 		casgstatus(gnext, _Gwaiting, _Grunnable)
 		casgstatus(gnext, _Grunnable, _Grunning)
+	} else {
+		gnext.accountCPUTime(_Gwaiting, _Grunning, cpuTimeNow())
 	}
 
 	// Donate locked state.
@@ -860,6 +860,38 @@ func TestStackSwitchCallback(t *testing.T) {
 	}
 }
 
+func TestCgoThreadStack(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" || runtime.GOOS == "android" {
+		t.Skipf("skipping test on %s", runtime.GOOS)
+	}
+	got := runTestProg(t, "testprogcgo", "CgoThreadStack", "GODEBUG=cgothreadstack=4194304")
+	if want := "OK\n"; got != want {
+		t.Errorf("expected %q, got %v", want, got)
+	}
+}
+
+func TestCgoCallbackStack(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" || runtime.GOOS == "android" {
+		t.Skipf("skipping test on %s", runtime.GOOS)
+	}
+	got := runTestProg(t, "testprogcgo", "CgoCallbackStack", "GODEBUG=cgocallbackstack=524288")
+	if got == "SKIP\n" {
+		t.Skip("skipping on musl libc")
+	}
+	if want := "OK\n"; got != want {
+		t.Errorf("expected %q, got %v", want, got)
+	}
+
+	// Without the setting, the runtime thinks the calls overflow the
+	// stack, and crashes.
+	got = runTestProg(t, "testprogcgo", "CgoCallbackStack")
+	if got == "OK\n" {
+		t.Errorf("without cgocallbackstack, expected a crash, got %v", got)
+	}
+}
+
 func TestCgoToGoCallGoexit(t *testing.T) {
 	if runtime.GOOS == "plan9" || runtime.GOOS == "windows" {
 		t.Skipf("no pthreads on %s", runtime.GOOS)
@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Non-preemptible critical sections.
+//
+// While a goroutine is in a critical section, it ignores requests to
+// preempt it, both at function calls, in newstack, and asynchronously,
+// in wantAsyncPreempt. The scheduler, the GC and stop-the-world keep
+// requesting preemption until the goroutine ends the critical section.
+// When one of them finds that the critical section has lasted longer
+// than criticalMaxNS, it marks the section expired, and preempts the
+// goroutine as usual. That bounds the time a goroutine can hold off a
+// stop-the-world. EndCritical then panics, as it does if it finds the
+// section took too long itself.
+
+package runtime
+
+const (
+	criticalNone    = iota // not in a critical section
+	criticalActive         // in a critical section
+	criticalExpired        // in a critical section that ran too long
+)
+
+// criticalMaxNS is the longest a critical section may last.
+const criticalMaxNS = 1000 * 1000 // 1ms
+
+// BeginCritical starts a critical section of the calling goroutine, in
+// which the scheduler doesn't preempt it, until it calls [EndCritical].
+// The goroutine may still be descheduled if it blocks, and the
+// operating system may still deschedule its thread.
+//
+// Critical sections are for short sequences of code that mustn't be
+// interrupted, such as the write side of a sequence lock. They hold
+// off garbage collection and other goroutines, so they may last at
+// most 1ms. After that the goroutine can be preempted again, and
+// EndCritical panics. Critical sections don't nest: BeginCritical
+// panics if the goroutine is already in one.
+func BeginCritical() {
+	gp := getg()
+	if gp.critical.Load() != criticalNone {
+		panic("runtime: BeginCritical in a critical section")
+	}
+	gp.criticalStart = nanotime()
+	gp.critical.Store(criticalActive)
+}
+
+// EndCritical ends the critical section that the calling goroutine
+// started with [BeginCritical]. It panics if the goroutine isn't in a
+// critical section, or if the critical section lasted too long.
+func EndCritical() {
+	gp := getg()
+	state := gp.critical.Swap(criticalNone)
+	if state == criticalNone {
+		panic("runtime: EndCritical outside a critical section")
+	}
+	if gp.preempt {
+		// Restore the preemption request in case newstack
+		// cleared it.
+		gp.stackguard0 = stackPreempt
+	}
+	if state == criticalExpired || nanotime()-gp.criticalStart > criticalMaxNS {
+		panic("runtime: critical section exceeded its time limit")
+	}
+}
+
+// inCritical reports whether gp is in a critical section that holds off
+// preemption.
+//
+//go:nosplit
+func inCritical(gp *g) bool {
+	return gp.critical.Load() == criticalActive
+}
+
+// expireCritical marks gp's critical section as expired if it has lasted
+// longer than criticalMaxNS, so that gp can be preempted. It's called
+// before requesting preemption.
+func expireCritical(gp *g) {
+	if gp.critical.Load() == criticalActive && nanotime()-gp.criticalStart > criticalMaxNS {
+		gp.critical.CompareAndSwap(criticalActive, criticalExpired)
+	}
+}
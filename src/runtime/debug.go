@@ -10,14 +10,23 @@ import (
 )
 
 // GOMAXPROCS sets the maximum number of CPUs that can be executing
-// simultaneously and returns the previous setting. It defaults to
-// the value of [runtime.NumCPU]. If n < 1, it does not change the current setting.
+// simultaneously and returns the previous setting. Unless the GOMAXPROCS
+// environment variable is set, it defaults to the value of [runtime.NumCPU],
+// or to the CPU limit of the program's cgroup on Linux, if that's lower;
+// see the package documentation. If n < 1, it does not change the current setting.
+// Otherwise, the runtime no longer updates the setting when the cgroup's
+// CPU limit changes.
 // This call will go away when the scheduler improves.
 func GOMAXPROCS(n int) int {
 	if GOARCH == "wasm" && n > 1 {
 		n = 1 // WebAssembly has no threads yet, so only one CPU is possible.
 	}
 
+	if n > 0 {
+		// Stop following the cgroup CPU limit.
+		maxProcsDefault.Store(false)
+	}
+
 	lock(&sched.lock)
 	ret := int(gomaxprocs)
 	unlock(&sched.lock)
@@ -0,0 +1,23 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+// GoroutineAllocBytes returns the number of bytes of heap memory the
+// calling goroutine has requested since it was created. It counts the
+// sizes requested by the program, not including the rounding up of
+// allocations to the allocator's size classes.
+//
+// Counting adds work to every allocation, so the runtime only counts
+// with GODEBUG=goroutineallocbytes=1, set when the program starts.
+// Otherwise, GoroutineAllocBytes returns 0.
+//
+// GoroutineAllocBytes is cheap enough to call at the start and end of
+// every request a server handles, to measure how much each request
+// allocates. With the setting on, the goroutine profile of package
+// runtime/pprof reports the same counts, summed over the goroutines of
+// each sample, so they can be broken down by profiler label.
+func GoroutineAllocBytes() uint64 {
+	return goroutineAllocBytes()
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"internal/testenv"
+	"os"
+	"os/exec"
+	. "runtime/debug"
+	"testing"
+)
+
+var goroutineAllocSink []byte
+
+func TestGoroutineAllocBytes(t *testing.T) {
+	const size = 1 << 16
+	if os.Getenv("GODEBUG") != "goroutineallocbytes=1" {
+		// Allocations aren't counted by default.
+		goroutineAllocSink = make([]byte, size)
+		if got := GoroutineAllocBytes(); got != 0 {
+			t.Errorf("GoroutineAllocBytes = %d without goroutineallocbytes=1, want 0", got)
+		}
+
+		cmd := testenv.CleanCmdEnv(exec.Command(testenv.Executable(t), "-test.run=^TestGoroutineAllocBytes$"))
+		cmd.Env = append(cmd.Env, "GODEBUG=goroutineallocbytes=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %v\n%s", cmd, err, out)
+		}
+		return
+	}
+
+	done := make(chan uint64)
+	go func() {
+		before := GoroutineAllocBytes()
+		goroutineAllocSink = make([]byte, size)
+		done <- GoroutineAllocBytes() - before
+	}()
+	if got := <-done; got < size || got > 2*size {
+		t.Errorf("goroutine allocated %d bytes, want about %d", got, size)
+	}
+
+	// Allocations by other goroutines aren't counted.
+	before := GoroutineAllocBytes()
+	go func() {
+		goroutineAllocSink = make([]byte, size)
+		done <- 0
+	}()
+	<-done
+	if got := GoroutineAllocBytes() - before; got >= size {
+		t.Errorf("GoroutineAllocBytes grew by %d bytes after another goroutine allocated", got)
+	}
+}
@@ -94,6 +94,75 @@ func SetGCPercent(percent int) int {
 	return int(setGCPercent(int32(percent)))
 }
 
+// GCPolicy is the configuration of the garbage collector that determines
+// when it runs. It combines the settings of SetGCPercent and SetMemoryLimit
+// with bounds on the heap size, so that they can be read and changed together.
+//
+// The zero GCPolicy is not the default configuration: it collects garbage
+// continuously. Policies should be derived from the one returned by
+// ReadGCPolicy.
+type GCPolicy struct {
+	// GCPercent is the garbage collection target percentage, as set by
+	// SetGCPercent. A negative value disables garbage collection unless
+	// the memory limit or MaxHeap is reached.
+	GCPercent int
+
+	// MemoryLimit is the soft memory limit in bytes, as set by
+	// SetMemoryLimit. math.MaxInt64 means there is no limit. It must
+	// not be negative.
+	MemoryLimit int64
+
+	// MinHeap is the heap size below which garbage collection is not
+	// triggered, which amortizes the cost of collection for programs with
+	// very small live heaps. The memory limit still applies. Zero means the
+	// runtime's default, 4 MiB scaled by GCPercent/100.
+	MinHeap uint64
+
+	// MaxHeap is the maximum heap size the garbage collector targets, even
+	// if GCPercent would let the heap grow larger, or garbage collection is
+	// disabled. Like the memory limit, it's a soft limit, but it only
+	// applies to memory occupied by heap objects. Zero means there is no
+	// maximum.
+	MaxHeap uint64
+
+	// MaxAssistLatency, if non-zero, is a target maximum duration for GC
+	// assists. During a collection, a goroutine that allocates may first
+	// have to assist the garbage collector, stalling it. When assists take
+	// longer than MaxAssistLatency, the garbage collector lets the heap
+	// grow past its goal in the following collections, within the memory
+	// limit and MaxHeap, so that each allocation requires less assist
+	// work. The /gc/assist/slow:assists metric counts the assists that
+	// missed the target. It must not be negative.
+	MaxAssistLatency time.Duration
+}
+
+// ReadGCPolicy returns the garbage collector's current configuration, including
+// any changes made by SetGCPercent, SetMemoryLimit, and the GOGC and GOMEMLIMIT
+// environment variables.
+func ReadGCPolicy() GCPolicy {
+	var p GCPolicy
+	readGCPolicy(&p)
+	return p
+}
+
+// SetGCPolicy changes the garbage collector's configuration to policy all at
+// once, and returns the previous configuration. It panics if policy has a
+// negative MemoryLimit or MaxAssistLatency, or a MaxHeap smaller than its
+// MinHeap.
+func SetGCPolicy(policy GCPolicy) GCPolicy {
+	if policy.MemoryLimit < 0 {
+		panic("runtime/debug: GCPolicy with negative MemoryLimit")
+	}
+	if policy.MaxHeap != 0 && policy.MinHeap > policy.MaxHeap {
+		panic("runtime/debug: GCPolicy with MinHeap larger than MaxHeap")
+	}
+	if policy.MaxAssistLatency < 0 {
+		panic("runtime/debug: GCPolicy with negative MaxAssistLatency")
+	}
+	setGCPolicy(&policy)
+	return policy
+}
+
 // FreeOSMemory forces a garbage collection followed by an
 // attempt to return as much memory to the operating system
 // as possible. (Even if this is not called, the runtime gradually
@@ -102,6 +171,50 @@ func FreeOSMemory() {
 	freeOSMemory()
 }
 
+// FreeOSMemoryTo is like FreeOSMemory, but returns only as much memory
+// to the operating system as is needed for the heap to retain at most
+// retained bytes, as measured by runtime.MemStats.HeapSys minus
+// HeapReleased. Memory that stays retained can be reused without the cost
+// of faulting it back in. If the heap's in-use memory alone exceeds
+// retained, FreeOSMemoryTo behaves like FreeOSMemory.
+func FreeOSMemoryTo(retained uint64) {
+	freeOSMemoryTo(retained)
+}
+
+// PrefaultHeap makes sure that the heap has at least bytes of memory
+// mapped and backed by physical memory, as measured by
+// runtime.MemStats.HeapSys minus HeapReleased, and keeps the runtime from
+// returning that memory to the operating system in the background. Memory
+// beyond what the heap uses is faulted in by touching it, so that a
+// program can pay for the page faults up front, before a burst of
+// allocation. Unlike a ballast allocation, the memory is free and does not
+// count toward the heap size used to pace the garbage collector.
+//
+// Each call replaces the amount set by the previous one; PrefaultHeap(0)
+// lets the runtime return the memory again. The memory is still returned
+// when needed to stay under the memory limit set by SetMemoryLimit, and
+// by FreeOSMemory and FreeOSMemoryTo.
+func PrefaultHeap(bytes uint64) {
+	prefaultHeap(bytes)
+}
+
+// SetGCWorkers sets the number of dedicated background mark workers
+// the garbage collector runs during each collection, and returns the
+// previous setting. Each worker occupies a P for the duration of the
+// mark phase. By default, the collector runs enough workers to use 25%
+// of GOMAXPROCS, and reports a setting of 0. A setting of 0 or less
+// restores the default; a setting above GOMAXPROCS acts like GOMAXPROCS.
+// The initial setting is the value of GODEBUG=gcworkers, if set.
+//
+// More workers shorten the mark phase, and with it the time during which
+// goroutines may have to assist the collector, at the cost of more CPU
+// during collection; fewer workers leave more CPU to the application.
+// The setting takes effect at the start of the next collection.
+func SetGCWorkers(n int) int {
+	n = max(0, min(n, 1<<31-1))
+	return int(setGCWorkers(int32(n)))
+}
+
 // SetMaxStack sets the maximum amount of memory that
 // can be used by a single goroutine stack.
 // If any goroutine exceeds this limit while growing its stack,
@@ -164,7 +277,9 @@ func SetPanicOnFault(enabled bool) bool {
 // connected to a pipe or socket whose other end is in the same Go
 // process; instead, use a temporary file or network socket.
 //
-// The heap dump format is defined at https://golang.org/s/go15heapdump.
+// The heap dump format is documented in package runtime/heapdump,
+// which can also read it. See WriteHeapDumpTo for writing a dump to
+// an io.Writer.
 func WriteHeapDump(fd uintptr)
 
 // SetTraceback sets the amount of detail printed by the runtime in
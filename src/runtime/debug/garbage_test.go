@@ -6,6 +6,7 @@ package debug_test
 
 import (
 	"internal/testenv"
+	"math"
 	"os"
 	"runtime"
 	. "runtime/debug"
@@ -150,6 +151,51 @@ func TestFreeOSMemory(t *testing.T) {
 	}
 }
 
+func TestFreeOSMemoryTo(t *testing.T) {
+	const bigBytes = 32 << 20
+	big = make([]byte, bigBytes)
+	runtime.GC()
+	big = nil
+
+	// Ask to keep half of the big allocation, which is still counted as
+	// in use in before. Don't check how much was released: the background
+	// scavenger may release more concurrently.
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	target := before.HeapInuse - bigBytes/2
+	FreeOSMemoryTo(target)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	// Allow some slack for pages allocated since FreeOSMemoryTo returned,
+	// such as GC work bufs.
+	slack := uint64(bigBytes / 4)
+	if retained := after.HeapSys - after.HeapReleased; retained > target+slack {
+		t.Errorf("retained %d bytes after FreeOSMemoryTo(%d)", retained, target)
+	}
+}
+
+func TestPrefaultHeap(t *testing.T) {
+	const extra = 64 << 20
+	defer PrefaultHeap(0)
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	target := before.HeapSys - before.HeapReleased + extra
+	PrefaultHeap(target)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	if retained := after.HeapSys - after.HeapReleased; retained < target {
+		t.Errorf("retained %d bytes after PrefaultHeap(%d)", retained, target)
+	}
+	// The prefaulted memory is free, so it doesn't grow the heap. Allow
+	// some slack for allocations since ReadMemStats.
+	if after.HeapInuse > before.HeapInuse+extra/4 {
+		t.Errorf("HeapInuse grew from %d to %d bytes after PrefaultHeap", before.HeapInuse, after.HeapInuse)
+	}
+}
+
 var (
 	setGCPercentBallast any
 	setGCPercentSink    any
@@ -217,6 +263,62 @@ func TestSetGCPercent(t *testing.T) {
 	}
 }
 
+func TestSetGCPolicy(t *testing.T) {
+	old := ReadGCPolicy()
+	defer SetGCPolicy(old)
+
+	p := old
+	p.GCPercent = 123
+	p.MinHeap = 64 << 20
+	p.MaxAssistLatency = time.Millisecond
+	if prev := SetGCPolicy(p); prev != old {
+		t.Errorf("SetGCPolicy returned %+v, want %+v", prev, old)
+	}
+	if got := ReadGCPolicy(); got != p {
+		t.Errorf("ReadGCPolicy() = %+v, want %+v", got, p)
+	}
+	if prev := SetGCPercent(100); prev != 123 {
+		t.Errorf("SetGCPercent after SetGCPolicy returned %d, want 123", prev)
+	}
+	if got := ReadGCPolicy(); got.GCPercent != 100 || got.MinHeap != p.MinHeap {
+		t.Errorf("ReadGCPolicy() after SetGCPercent(100) = %+v, want GCPercent 100 and MinHeap %d", got, p.MinHeap)
+	}
+
+	// The minimum heap size holds the goal up for the test's small heap.
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if ms.Alloc > 16<<20 {
+		t.Skipf("live heap of %d MB too large for test", ms.Alloc>>20)
+	}
+	if ms.NextGC < p.MinHeap {
+		t.Errorf("NextGC = %d MB with MinHeap of %d MB", ms.NextGC>>20, p.MinHeap>>20)
+	}
+
+	// The maximum heap size brings the goal down, even with GC disabled.
+	SetGCPolicy(GCPolicy{GCPercent: -1, MemoryLimit: math.MaxInt64, MaxHeap: 32 << 20})
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	if ms.NextGC > 32<<20 {
+		t.Errorf("NextGC = %d MB with MaxHeap of 32 MB", ms.NextGC>>20)
+	}
+
+	for _, p := range []GCPolicy{
+		{GCPercent: 100, MemoryLimit: -1},
+		{GCPercent: 100, MemoryLimit: math.MaxInt64, MinHeap: 2, MaxHeap: 1},
+		{GCPercent: 100, MemoryLimit: math.MaxInt64, MaxAssistLatency: -1},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SetGCPolicy(%+v) did not panic", p)
+				}
+			}()
+			SetGCPolicy(p)
+		}()
+	}
+}
+
 func abs64(a int64) int64 {
 	if a < 0 {
 		return -a
@@ -236,3 +338,20 @@ func TestSetMaxThreadsOvf(t *testing.T) {
 	nt := SetMaxThreads(1 << (30 + ^uint(0)>>63))
 	SetMaxThreads(nt) // restore previous value
 }
+
+func TestSetGCWorkers(t *testing.T) {
+	old := SetGCWorkers(3)
+	defer SetGCWorkers(old)
+	if got := SetGCWorkers(runtime.GOMAXPROCS(0) + 10); got != 3 {
+		t.Errorf("SetGCWorkers(3); SetGCWorkers(x) = %d, want 3", got)
+	}
+	// A collection with more workers than Ps must still work.
+	runtime.GC()
+	if got := SetGCWorkers(-1); got != runtime.GOMAXPROCS(0)+10 {
+		t.Errorf("SetGCWorkers(-1) = %d, want %d", got, runtime.GOMAXPROCS(0)+10)
+	}
+	if got := SetGCWorkers(1); got != 0 {
+		t.Errorf("SetGCWorkers(1) after SetGCWorkers(-1) = %d, want 0", got)
+	}
+	runtime.GC()
+}
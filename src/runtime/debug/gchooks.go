@@ -0,0 +1,147 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// GCPhase identifies a boundary between phases of a garbage collection cycle.
+type GCPhase int
+
+const (
+	// GCSweepTermination is just before a cycle starts. Hooks for this
+	// phase run synchronously on the goroutine that is starting the
+	// cycle, before the world is stopped, so references they drop are
+	// collected by the cycle that is about to start.
+	GCSweepTermination GCPhase = iota
+
+	// GCMark is just after concurrent marking starts. Hooks for this
+	// phase run synchronously on the goroutine that started the cycle.
+	GCMark
+
+	// GCMarkTermination is just after marking finishes and the world
+	// is restarted. Hooks for this phase run asynchronously.
+	GCMarkTermination
+
+	// GCCycleEnd is when sweeping finishes, which completes the cycle.
+	// Hooks for this phase run asynchronously, after the
+	// GCMarkTermination hooks for the same cycle.
+	GCCycleEnd
+)
+
+func (p GCPhase) String() string {
+	switch p {
+	case GCSweepTermination:
+		return "GCSweepTermination"
+	case GCMark:
+		return "GCMark"
+	case GCMarkTermination:
+		return "GCMarkTermination"
+	case GCCycleEnd:
+		return "GCCycleEnd"
+	}
+	return "GCPhase(" + strconv.Itoa(int(p)) + ")"
+}
+
+type gcHook struct {
+	f func()
+}
+
+var gcHooks struct {
+	mu      sync.Mutex // serializes changes to hooks and starting the loop
+	hooks   [GCCycleEnd + 1]atomic.Pointer[[]*gcHook]
+	runtime bool // registered runGCPhaseHooks with the runtime
+	loop    bool // started gcHookLoop
+}
+
+// AddGCHook arranges for f to be called each time the garbage collector
+// reaches phase, until the returned function is called to remove it.
+// Hooks for the same phase are called in the order they were added.
+//
+// Hooks are meant to let memory-sensitive code react to the collector,
+// for example by dropping caches just before marking begins, and must
+// follow strict constraints. They must be fast, must not block, and must
+// not panic. Hooks for GCSweepTermination and GCMark run on whichever
+// goroutine triggers or starts a cycle, which may be any goroutine that
+// allocates memory, so they must not acquire locks that such a goroutine
+// might already hold. If a GCSweepTermination hook allocates enough to
+// trigger the cycle itself, the cycle may start before the hook returns.
+//
+// Hooks for GCMarkTermination and GCCycleEnd run one at a time on a
+// goroutine dedicated to them. If that goroutine falls behind, hooks
+// for some cycles are skipped. To reach GCCycleEnd promptly, that
+// goroutine helps sweep the heap when GCCycleEnd hooks are present.
+func AddGCHook(phase GCPhase, f func()) (remove func()) {
+	if phase < GCSweepTermination || phase > GCCycleEnd {
+		panic("runtime/debug: AddGCHook with invalid phase " + phase.String())
+	}
+	if f == nil {
+		panic("runtime/debug: AddGCHook with nil function")
+	}
+	h := &gcHook{f}
+
+	gcHooks.mu.Lock()
+	defer gcHooks.mu.Unlock()
+	hooks := gcHooks.hooks[phase].Load()
+	var newHooks []*gcHook
+	if hooks != nil {
+		newHooks = slices.Clone(*hooks)
+	}
+	newHooks = append(newHooks, h)
+	gcHooks.hooks[phase].Store(&newHooks)
+
+	switch phase {
+	case GCSweepTermination, GCMark:
+		if !gcHooks.runtime {
+			gcHooks.runtime = true
+			registerGCPhaseHook(runGCPhaseHooks)
+		}
+	case GCMarkTermination, GCCycleEnd:
+		if !gcHooks.loop {
+			gcHooks.loop = true
+			var e gcEvent
+			readGCEvent(unsafe.Pointer(&e))
+			go gcHookLoop(e.numGC)
+		}
+	}
+
+	return func() {
+		gcHooks.mu.Lock()
+		defer gcHooks.mu.Unlock()
+		hooks := gcHooks.hooks[phase].Load()
+		if i := slices.Index(*hooks, h); i >= 0 {
+			newHooks := slices.Delete(slices.Clone(*hooks), i, i+1)
+			gcHooks.hooks[phase].Store(&newHooks)
+		}
+	}
+}
+
+// runGCPhaseHooks runs the hooks for phase.
+func runGCPhaseHooks(phase int) {
+	if hooks := gcHooks.hooks[phase].Load(); hooks != nil {
+		for _, h := range *hooks {
+			h.f()
+		}
+	}
+}
+
+// gcHookLoop runs the asynchronous hooks for the cycles after cycle n.
+func gcHookLoop(n uint32) {
+	for {
+		var e gcEvent
+		waitGCEvent(unsafe.Pointer(&e), n)
+		n = e.numGC
+		runGCPhaseHooks(int(GCMarkTermination))
+		if hooks := gcHooks.hooks[GCCycleEnd].Load(); hooks != nil && len(*hooks) > 0 {
+			finishSweep(n)
+			runGCPhaseHooks(int(GCCycleEnd))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"sync/atomic"
+	"testing"
+	"time"
+	"weak"
+)
+
+var gcHookCache *[1 << 10]byte
+
+func TestAddGCHook(t *testing.T) {
+	var counts [GCCycleEnd + 1]atomic.Int32
+	var removes []func()
+	for phase := GCSweepTermination; phase <= GCCycleEnd; phase++ {
+		removes = append(removes, AddGCHook(phase, func() { counts[phase].Add(1) }))
+	}
+	defer func() {
+		for _, remove := range removes {
+			remove()
+		}
+	}()
+
+	// A sweep termination hook that drops a cache lets the cycle
+	// that is starting collect it.
+	gcHookCache = new([1 << 10]byte)
+	wp := weak.Make(gcHookCache)
+	removeDrop := AddGCHook(GCSweepTermination, func() { gcHookCache = nil })
+	runtime.GC()
+	removeDrop()
+	if wp.Get() != nil {
+		t.Error("cache dropped by GCSweepTermination hook survived the GC")
+	}
+
+	for phase := GCSweepTermination; phase <= GCMark; phase++ {
+		if counts[phase].Load() == 0 {
+			t.Errorf("%v hook did not run during runtime.GC", phase)
+		}
+	}
+	// The other hooks run asynchronously.
+	deadline := time.Now().Add(10 * time.Second)
+	for counts[GCCycleEnd].Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for phase := GCMarkTermination; phase <= GCCycleEnd; phase++ {
+		if counts[phase].Load() == 0 {
+			t.Errorf("%v hook did not run after runtime.GC", phase)
+		}
+	}
+
+	// Removed hooks don't run.
+	removes[GCMark]()
+	n := counts[GCMark].Load()
+	runtime.GC()
+	if got := counts[GCMark].Load(); got != n {
+		t.Errorf("removed GCMark hook ran %d times", got-n)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// GCEvent describes a completed garbage collection cycle.
+type GCEvent struct {
+	// NumGC is the number of the cycle, counting from 1, as reported
+	// by runtime.MemStats.NumGC once the cycle has completed. Events
+	// for cycles that complete while an earlier event is still being
+	// delivered are coalesced, so NumGC may skip values.
+	NumGC uint32
+
+	// Trigger is the reason the cycle started.
+	Trigger GCTrigger
+
+	// End is the time the cycle's mark phase ended.
+	End time.Time
+
+	// Pause is the total time the world was stopped during the cycle.
+	Pause time.Duration
+
+	// HeapLive is the number of bytes of heap objects the cycle
+	// found to be reachable.
+	HeapLive uint64
+
+	// HeapGoal is the heap size at which the next cycle is expected
+	// to complete, as reported by runtime.MemStats.NextGC.
+	HeapGoal uint64
+}
+
+// GCTrigger is the reason a garbage collection cycle started.
+type GCTrigger int
+
+const (
+	// GCTriggerHeap means the heap grew to the size at which the pacer,
+	// following GOGC and the memory limit, started a cycle.
+	GCTriggerHeap GCTrigger = iota
+
+	// GCTriggerTime means the cycle was forced because no cycle had
+	// run for two minutes.
+	GCTriggerTime
+
+	// GCTriggerForced means the cycle was requested by runtime.GC or
+	// FreeOSMemory.
+	GCTriggerForced
+)
+
+func (t GCTrigger) String() string {
+	switch t {
+	case GCTriggerHeap:
+		return "heap"
+	case GCTriggerTime:
+		return "time"
+	case GCTriggerForced:
+		return "forced"
+	}
+	return "GCTrigger(" + strconv.Itoa(int(t)) + ")"
+}
+
+// gcEvent is the runtime's description of a completed GC cycle.
+//
+// Its layout must match runtime.gcEvent.
+type gcEvent struct {
+	numGC    uint32
+	trigger  int
+	endUnix  int64
+	pauseNS  int64
+	heapGoal uint64
+	heapLive uint64
+}
+
+var gcNotify struct {
+	sync.Mutex
+	started bool
+	chans   []chan<- GCEvent
+}
+
+// NotifyGC causes package debug to send an event on ch at the end
+// of each garbage collection cycle.
+//
+// Package debug will not block sending to ch: the caller must ensure
+// that ch has sufficient buffer space to keep up with the rate of
+// collections. Events that cannot be sent immediately are dropped;
+// gaps in GCEvent.NumGC reveal them.
+//
+// It is allowed to call NotifyGC multiple times with the same channel,
+// which has no additional effect.
+func NotifyGC(ch chan<- GCEvent) {
+	if ch == nil {
+		panic("runtime/debug: NotifyGC using nil channel")
+	}
+	gcNotify.Lock()
+	defer gcNotify.Unlock()
+	if slices.Contains(gcNotify.chans, ch) {
+		return
+	}
+	gcNotify.chans = append(gcNotify.chans, ch)
+	if !gcNotify.started {
+		gcNotify.started = true
+		var e gcEvent
+		readGCEvent(unsafe.Pointer(&e))
+		go gcNotifyLoop(e.numGC)
+	}
+}
+
+// StopNotifyGC causes package debug to stop sending events on ch.
+// When StopNotifyGC returns, it is guaranteed that ch will receive
+// no more events.
+func StopNotifyGC(ch chan<- GCEvent) {
+	gcNotify.Lock()
+	defer gcNotify.Unlock()
+	gcNotify.chans = slices.DeleteFunc(gcNotify.chans, func(c chan<- GCEvent) bool {
+		return c == ch
+	})
+}
+
+// gcNotifyLoop delivers events for the cycles after cycle n.
+func gcNotifyLoop(n uint32) {
+	for {
+		var e gcEvent
+		waitGCEvent(unsafe.Pointer(&e), n)
+		n = e.numGC
+		ev := GCEvent{
+			NumGC:    e.numGC,
+			Trigger:  GCTrigger(e.trigger),
+			End:      time.Unix(0, e.endUnix),
+			Pause:    time.Duration(e.pauseNS),
+			HeapLive: e.heapLive,
+			HeapGoal: e.heapGoal,
+		}
+		gcNotify.Lock()
+		for _, ch := range gcNotify.chans {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+		gcNotify.Unlock()
+	}
+}
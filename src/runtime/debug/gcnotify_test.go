@@ -0,0 +1,58 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestNotifyGC(t *testing.T) {
+	ch := make(chan GCEvent, 10)
+	NotifyGC(ch)
+	NotifyGC(ch) // no additional effect
+	defer StopNotifyGC(ch)
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	runtime.GC()
+
+	var ev GCEvent
+	select {
+	case ev = <-ch:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no GCEvent after runtime.GC")
+	}
+	if ev.NumGC <= before.NumGC {
+		t.Errorf("NumGC = %d, want > %d", ev.NumGC, before.NumGC)
+	}
+	if ev.Trigger != GCTriggerForced {
+		t.Errorf("Trigger = %v, want %v", ev.Trigger, GCTriggerForced)
+	}
+	if ev.HeapLive == 0 || ev.HeapGoal < ev.HeapLive {
+		t.Errorf("HeapLive = %d, HeapGoal = %d", ev.HeapLive, ev.HeapGoal)
+	}
+	if ev.Pause <= 0 {
+		t.Errorf("Pause = %v, want > 0", ev.Pause)
+	}
+	if d := time.Since(ev.End); d < 0 || d > time.Minute {
+		t.Errorf("End = %v, %v ago", ev.End, d)
+	}
+
+	StopNotifyGC(ch)
+	for len(ch) > 0 {
+		<-ch
+	}
+	runtime.GC()
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case ev := <-ch:
+		t.Errorf("got %+v after StopNotifyGC", ev)
+	default:
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "strconv"
+
+// A GoroutineLimitAction is what a go statement does when the goroutine
+// it starts would exceed the limit set by [SetMaxGoroutines].
+type GoroutineLimitAction int
+
+const (
+	// GoroutineLimitPanic makes the go statement panic, without
+	// starting the goroutine.
+	GoroutineLimitPanic GoroutineLimitAction = iota
+
+	// GoroutineLimitBlock makes the go statement wait until the number
+	// of goroutines is below the limit, and then start the goroutine.
+	GoroutineLimitBlock
+
+	// GoroutineLimitAllow starts the goroutine anyway. A policy that
+	// only records where goroutines are started beyond the limit returns
+	// it.
+	GoroutineLimitAllow
+)
+
+func (a GoroutineLimitAction) String() string {
+	switch a {
+	case GoroutineLimitPanic:
+		return "GoroutineLimitPanic"
+	case GoroutineLimitBlock:
+		return "GoroutineLimitBlock"
+	case GoroutineLimitAllow:
+		return "GoroutineLimitAllow"
+	}
+	return "GoroutineLimitAction(" + strconv.Itoa(int(a)) + ")"
+}
+
+// SetMaxGoroutines sets the maximum number of goroutines that the
+// program can have, as counted by [runtime.NumGoroutine], and returns
+// the previous limit. A limit of 0 or less, the initial setting, means
+// there is no limit.
+//
+// The limit is a guardrail against goroutine leaks, not a way to bound
+// concurrency exactly: go statements executed at the same time may
+// briefly exceed it, and the runtime's own goroutines aren't limited.
+//
+// When a go statement would start a goroutine beyond the limit, it calls
+// policy, on the goroutine executing the go statement, with the program
+// counter of the go statement, which [runtime.CallersFrames] translates
+// to a function and line. The go statement then does what policy
+// returns. A nil policy always returns [GoroutineLimitPanic]. Policy
+// must not start goroutines itself.
+//
+// Go statements blocked by [GoroutineLimitBlock] check the limit again
+// when it's changed.
+func SetMaxGoroutines(n int, policy func(pc uintptr) GoroutineLimitAction) int {
+	var p func(pc uintptr) int
+	if policy != nil {
+		p = func(pc uintptr) int {
+			return int(policy(pc))
+		}
+	}
+	return setMaxGoroutines(n, p)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestSetMaxGoroutines(t *testing.T) {
+	defer SetMaxGoroutines(0, nil)
+	block := make(chan bool)
+	release := make(chan bool)
+	go func() { <-block }()
+	go func() {
+		<-release
+		close(block)
+	}()
+
+	// Without a policy, going beyond the limit panics.
+	if old := SetMaxGoroutines(runtime.NumGoroutine(), nil); old != 0 {
+		t.Errorf("SetMaxGoroutines returned %d, want 0", old)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("go statement beyond the limit didn't panic")
+			}
+		}()
+		go func() {}()
+	}()
+
+	// A policy can record where goroutines are started.
+	var pcs []uintptr
+	SetMaxGoroutines(runtime.NumGoroutine(), func(pc uintptr) GoroutineLimitAction {
+		pcs = append(pcs, pc)
+		return GoroutineLimitAllow
+	})
+	done := make(chan bool)
+	go func() { done <- true }()
+	<-done
+	if len(pcs) != 1 {
+		t.Fatalf("policy called %d times, want 1", len(pcs))
+	}
+	if f, _ := runtime.CallersFrames(pcs).Next(); !strings.HasSuffix(f.Function, ".TestSetMaxGoroutines") {
+		t.Errorf("policy called for a go statement in %s, want TestSetMaxGoroutines", f.Function)
+	}
+
+	// A go statement can wait for a goroutine to exit.
+	SetMaxGoroutines(runtime.NumGoroutine(), func(uintptr) GoroutineLimitAction {
+		return GoroutineLimitBlock
+	})
+	release <- true
+	go func() { done <- true }()
+	<-done
+}
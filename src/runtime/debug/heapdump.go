@@ -0,0 +1,38 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"io"
+	"os"
+)
+
+// WriteHeapDumpTo writes a heap dump, as described by WriteHeapDump,
+// to w.
+//
+// Since no goroutine may run while the runtime writes the dump,
+// WriteHeapDumpTo stages it in a temporary file in [os.TempDir] and
+// then copies it to w after execution resumes. Unlike with
+// WriteHeapDump, w may therefore be a pipe or a connection read by
+// the same process.
+//
+// The dump is not compressed. To compress it, pass a
+// [compress/gzip.Writer] that wraps the destination; the
+// [runtime/heapdump] package reads such dumps.
+func WriteHeapDumpTo(w io.Writer) error {
+	f, err := os.CreateTemp("", "heapdump")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	WriteHeapDump(f.Fd())
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
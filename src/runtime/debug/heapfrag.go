@@ -0,0 +1,80 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+// HeapFragmentationStats describes how the heap memory that isn't
+// occupied by objects is spread out, as returned by HeapFragmentation.
+type HeapFragmentationStats struct {
+	// SizeClasses describes the spans of each of the allocator's
+	// size classes for small objects that has any, in increasing
+	// order of Size. Large objects have spans of their own and
+	// aren't included.
+	SizeClasses []SizeClassFragmentation
+
+	// FreeBytes is the memory in free spans that the runtime has
+	// not yet returned to the operating system. It can be reused
+	// for objects of any size class without growing the heap.
+	//
+	// This is the same as the /memory/classes/heap/free:bytes metric.
+	FreeBytes uint64
+
+	// ReleasedBytes is the memory in free spans that the runtime
+	// has returned to the operating system.
+	//
+	// This is the same as the /memory/classes/heap/released:bytes
+	// metric.
+	ReleasedBytes uint64
+
+	// LargestFree is the size of the largest contiguous run of free
+	// memory in the heap, released or not. It is the size of the
+	// largest object the heap can allocate without growing.
+	//
+	// This is the same as the /gc/heap/largest-free:bytes metric.
+	LargestFree uint64
+}
+
+// SizeClassFragmentation describes the in-use spans of one size class.
+type SizeClassFragmentation struct {
+	// Size is the size of the objects in the size class.
+	Size uint64
+
+	// Spans is the number of in-use spans of the size class, and
+	// SpanBytes the memory they occupy.
+	Spans     uint64
+	SpanBytes uint64
+
+	// Objects is the number of objects allocated in the spans.
+	Objects uint64
+
+	// Slack is the memory in the spans that isn't occupied by
+	// objects: free object slots, which can only be reused for
+	// objects of the same size class, and the tail of each span
+	// that is too small for an object.
+	//
+	// The sum of Slack over all size classes corresponds to the
+	// /memory/classes/heap/unused:bytes metric.
+	Slack uint64
+}
+
+// HeapFragmentation returns a report of the fragmentation of the heap.
+//
+// HeapFragmentation stops the world to walk every span in the heap,
+// so it takes time proportional to the size of the heap. Like
+// [runtime.MemStats.HeapAlloc], the object counts include unreachable
+// objects that the garbage collector has not yet freed. Call
+// [runtime.GC] first to count only reachable objects.
+func HeapFragmentation() HeapFragmentationStats {
+	var st HeapFragmentationStats
+	st.FreeBytes, st.ReleasedBytes, st.LargestFree = readHeapFragmentation(func(size, spans, spanBytes, objects uint64) {
+		st.SizeClasses = append(st.SizeClasses, SizeClassFragmentation{
+			Size:      size,
+			Spans:     spans,
+			SpanBytes: spanBytes,
+			Objects:   objects,
+			Slack:     spanBytes - objects*size,
+		})
+	})
+	return st
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"testing"
+	"unsafe"
+)
+
+var heapFragmentationSink []*[6]uintptr
+
+func TestHeapFragmentation(t *testing.T) {
+	const n = 1000
+	heapFragmentationSink = make([]*[6]uintptr, n)
+	for i := range heapFragmentationSink {
+		heapFragmentationSink[i] = new([6]uintptr)
+	}
+	const bigBytes = 32 << 20
+	big = make([]byte, bigBytes)
+	big = nil
+	runtime.GC()
+
+	st := HeapFragmentation()
+	runtime.KeepAlive(heapFragmentationSink)
+	size := uint64(unsafe.Sizeof([6]uintptr{}))
+	var found bool
+	for i, c := range st.SizeClasses {
+		if i > 0 && c.Size <= st.SizeClasses[i-1].Size {
+			t.Errorf("size class %d is not larger than %d", c.Size, st.SizeClasses[i-1].Size)
+		}
+		if c.Spans == 0 || c.Objects*c.Size > c.SpanBytes || c.Slack != c.SpanBytes-c.Objects*c.Size {
+			t.Errorf("inconsistent size class: %+v", c)
+		}
+		if c.Size == size {
+			found = true
+			if c.Objects < n {
+				t.Errorf("size class %d has %d objects, want at least %d", size, c.Objects, n)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no size class of size %d", size)
+	}
+	// The big allocation left a free run at least as large, unless
+	// something was allocated in the middle of it.
+	if st.LargestFree < bigBytes/2 {
+		t.Errorf("largest free run is %d bytes, want at least %d", st.LargestFree, bigBytes/2)
+	}
+	if st.LargestFree > st.FreeBytes+st.ReleasedBytes {
+		t.Errorf("largest free run is %d bytes, more than the %d free bytes", st.LargestFree, st.FreeBytes+st.ReleasedBytes)
+	}
+}
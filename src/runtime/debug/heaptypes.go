@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"cmp"
+	"slices"
+)
+
+// HeapTypeStats describes the heap objects of one type.
+type HeapTypeStats struct {
+	// Type is the name of the type, as formatted by the %T verb of
+	// package fmt. Distinct types may have the same name.
+	//
+	// Type is empty for memory allocated without a type, such as
+	// the contents of strings, and "(other)" for types the runtime
+	// had no room to count separately.
+	Type string
+
+	// Objects is the number of objects of the type. Memory allocated
+	// for a slice or array counts as one object of its element type.
+	Objects uint64
+
+	// Bytes is the heap memory the objects occupy, including the
+	// rounding of their sizes up to the allocator's size classes.
+	Bytes uint64
+}
+
+// ReadHeapTypeStats returns the number of heap objects of each type
+// and the memory they occupy, sorted by decreasing Bytes.
+//
+// The runtime only counts objects by type if the program was started
+// with GODEBUG=heaptypes=1, since doing so slows down every allocation
+// and uses extra memory for each heap span. Otherwise ReadHeapTypeStats
+// returns nil.
+//
+// Like [runtime.MemStats.HeapAlloc], the counts include unreachable
+// objects that the garbage collector has not yet freed. Call
+// [runtime.GC] first to count only reachable objects.
+func ReadHeapTypeStats() []HeapTypeStats {
+	var stats []HeapTypeStats
+	readHeapTypeStats(func(name string, objects, bytes uint64) {
+		stats = append(stats, HeapTypeStats{Type: name, Objects: objects, Bytes: bytes})
+	})
+	slices.SortFunc(stats, func(a, b HeapTypeStats) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Type, b.Type))
+	})
+	return stats
+}
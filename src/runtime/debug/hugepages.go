@@ -0,0 +1,59 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+// HugePageMode controls whether the runtime asks the operating system
+// to back the heap with transparent huge pages. Only Linux supports
+// such advice; on other platforms the mode has no effect.
+type HugePageMode int
+
+// The values must match those in package runtime.
+const (
+	// HugePagesDefault gives the operating system no advice, leaving
+	// the use of huge pages to its configuration. This is the initial
+	// mode unless the program was started with GODEBUG=disablethp=1.
+	HugePagesDefault HugePageMode = iota
+
+	// HugePagesEnabled asks the operating system to back the heap
+	// with huge pages, even if it is configured to use them only
+	// where requested (on Linux, the "madvise" setting).
+	HugePagesEnabled
+
+	// HugePagesDisabled asks the operating system not to back the
+	// heap with huge pages. This is the initial mode with
+	// GODEBUG=disablethp=1.
+	HugePagesDisabled
+)
+
+// SetHeapHugePages sets the huge page mode for the heap and returns
+// the previous mode. The mode applies both to memory the heap already
+// has and to memory it maps later.
+//
+// The operating system has no way to withdraw advice already given, so
+// setting HugePagesDefault only affects memory the heap maps later.
+// Disabling huge pages also doesn't split huge pages that already back
+// the heap.
+//
+// Programs that map memory of their own, such as embedded databases,
+// can use SetHeapHugePages to make the heap's use of huge pages match
+// theirs.
+func SetHeapHugePages(mode HugePageMode) HugePageMode {
+	if mode < HugePagesDefault || mode > HugePagesDisabled {
+		panic("debug: invalid HugePageMode")
+	}
+	return HugePageMode(setHeapHugePages(int32(mode)))
+}
+
+// CollapseHeapHugePages asks the operating system to back the heap with
+// huge pages now, rather than in the background, where it can. It skips
+// the parts of the heap that the runtime has returned to the operating
+// system, so as not to back them with memory again.
+//
+// Collapsing is best-effort and may take a long time, during which
+// the calling goroutine is blocked in the operating system. On Linux,
+// it requires kernel support for MADV_COLLAPSE (Linux 6.1 and later).
+func CollapseHeapHugePages() {
+	collapseHeapHugePages()
+}
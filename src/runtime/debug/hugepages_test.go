@@ -0,0 +1,88 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	. "runtime/debug"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+var hugePagesSink []byte
+
+func TestSetHeapHugePages(t *testing.T) {
+	initial := SetHeapHugePages(HugePagesEnabled)
+	defer SetHeapHugePages(initial)
+	if prev := SetHeapHugePages(HugePagesDisabled); prev != HugePagesEnabled {
+		t.Errorf("SetHeapHugePages returned %v, want %v", prev, HugePagesEnabled)
+	}
+	CollapseHeapHugePages()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("SetHeapHugePages(3) did not panic")
+			}
+		}()
+		SetHeapHugePages(3)
+	}()
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	if _, err := os.Stat("/sys/kernel/mm/transparent_hugepage"); err != nil {
+		t.Skip("transparent huge pages are not supported")
+	}
+	hugePagesSink = make([]byte, 8<<20)
+	addr := uintptr(unsafe.Pointer(&hugePagesSink[0]))
+	for _, tt := range []struct {
+		mode HugePageMode
+		flag string
+	}{
+		{HugePagesEnabled, "hg"},
+		{HugePagesDisabled, "nh"},
+	} {
+		SetHeapHugePages(tt.mode)
+		flags, err := vmFlags(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(" "+flags+" ", " "+tt.flag+" ") {
+			t.Errorf("with mode %d, heap mapping has flags %q, want %q", tt.mode, flags, tt.flag)
+		}
+	}
+	hugePagesSink = nil
+}
+
+// vmFlags returns the VmFlags of the mapping containing addr.
+func vmFlags(addr uintptr) (string, error) {
+	f, err := os.Open("/proc/self/smaps")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	found := false
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		var lo, hi uintptr
+		if n, _ := fmt.Sscanf(line, "%x-%x ", &lo, &hi); n == 2 {
+			found = lo <= addr && addr < hi
+			continue
+		}
+		if flags, ok := strings.CutPrefix(line, "VmFlags:"); ok && found {
+			return strings.TrimSpace(flags), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no mapping contains %#x", addr)
+}
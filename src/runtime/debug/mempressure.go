@@ -0,0 +1,145 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// MemoryPressure is a level of memory pressure on the program, as
+// observed by the runtime.
+type MemoryPressure int
+
+const (
+	// MemoryPressureNone means the runtime sees no sign of memory
+	// pressure.
+	MemoryPressureNone MemoryPressure = iota
+
+	// MemoryPressureModerate means memory is getting short: on Linux,
+	// the program's cgroup was throttled for exceeding memory.high, or
+	// tasks spent at least 10% of the last 10 seconds waiting for
+	// memory; on macOS, the kernel reports a warning pressure level.
+	MemoryPressureModerate
+
+	// MemoryPressureCritical means memory has run out: on Linux, the
+	// program's cgroup reached memory.max or had tasks killed for lack
+	// of memory, or all tasks spent at least 10% of the last 10 seconds
+	// waiting for memory; on macOS, the kernel reports a critical
+	// pressure level.
+	MemoryPressureCritical
+)
+
+func (p MemoryPressure) String() string {
+	switch p {
+	case MemoryPressureNone:
+		return "none"
+	case MemoryPressureModerate:
+		return "moderate"
+	case MemoryPressureCritical:
+		return "critical"
+	}
+	return "MemoryPressure(" + strconv.Itoa(int(p)) + ")"
+}
+
+// MemoryPressureEvent describes a change of the level of memory
+// pressure.
+type MemoryPressureEvent struct {
+	// Level is the new level of memory pressure.
+	Level MemoryPressure
+
+	// Time is when the runtime observed the change.
+	Time time.Time
+}
+
+// memPressureEvent is the runtime's description of a change of memory
+// pressure level.
+//
+// Its layout must match runtime.memPressureEvent.
+type memPressureEvent struct {
+	seq      uint32
+	level    int32
+	unixNano int64
+}
+
+var memPressureNotify struct {
+	sync.Mutex
+	started bool
+	chans   []chan<- MemoryPressureEvent
+}
+
+// NotifyMemoryPressure causes package debug to send an event on ch each
+// time the level of memory pressure on the program changes.
+//
+// The runtime observes memory pressure on Linux, through the files of
+// the program's cgroup v2 under /sys/fs/cgroup or the system-wide
+// pressure stall information, and on macOS, through the kernel's
+// memory pressure level. It checks for changes once a second. On other
+// systems, or if the files can't be read, no events are sent.
+//
+// Events are only delivered to the program; to also have the runtime
+// collect garbage and return memory to the operating system when
+// pressure rises, set GODEBUG=mempressure=1.
+//
+// Package debug will not block sending to ch: the caller must ensure
+// that ch has sufficient buffer space. Events that cannot be sent
+// immediately are dropped.
+//
+// It is allowed to call NotifyMemoryPressure multiple times with the
+// same channel, which has no additional effect.
+func NotifyMemoryPressure(ch chan<- MemoryPressureEvent) {
+	if ch == nil {
+		panic("runtime/debug: NotifyMemoryPressure using nil channel")
+	}
+	memPressureNotify.Lock()
+	defer memPressureNotify.Unlock()
+	if slices.Contains(memPressureNotify.chans, ch) {
+		return
+	}
+	memPressureNotify.chans = append(memPressureNotify.chans, ch)
+	if !memPressureNotify.started {
+		memPressureNotify.started = true
+		var e memPressureEvent
+		readMemPressureEvent(unsafe.Pointer(&e))
+		go memPressureNotifyLoop(e.seq)
+		startMemPressureWatcher()
+	}
+}
+
+// StopNotifyMemoryPressure causes package debug to stop sending events
+// on ch. When StopNotifyMemoryPressure returns, it is guaranteed that
+// ch will receive no more events.
+func StopNotifyMemoryPressure(ch chan<- MemoryPressureEvent) {
+	memPressureNotify.Lock()
+	defer memPressureNotify.Unlock()
+	memPressureNotify.chans = slices.DeleteFunc(memPressureNotify.chans, func(c chan<- MemoryPressureEvent) bool {
+		return c == ch
+	})
+}
+
+// memPressureNotifyLoop delivers the changes of memory pressure level
+// after change seq.
+func memPressureNotifyLoop(seq uint32) {
+	for {
+		var e memPressureEvent
+		waitMemPressureEvent(unsafe.Pointer(&e), seq)
+		seq = e.seq
+		ev := MemoryPressureEvent{
+			Level: MemoryPressure(e.level),
+			Time:  time.Unix(0, e.unixNano),
+		}
+		memPressureNotify.Lock()
+		for _, ch := range memPressureNotify.chans {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+		memPressureNotify.Unlock()
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	. "runtime/debug"
+	"testing"
+)
+
+func TestNotifyMemoryPressure(t *testing.T) {
+	// Pressure can't be caused on demand, so only check that
+	// notification can be turned on and off.
+	ch := make(chan MemoryPressureEvent, 1)
+	NotifyMemoryPressure(ch)
+	NotifyMemoryPressure(ch) // no additional effect
+	StopNotifyMemoryPressure(ch)
+
+	for p, want := range map[MemoryPressure]string{
+		MemoryPressureNone:     "none",
+		MemoryPressureModerate: "moderate",
+		MemoryPressureCritical: "critical",
+		MemoryPressure(7):      "MemoryPressure(7)",
+	} {
+		if got := p.String(); got != want {
+			t.Errorf("MemoryPressure(%d).String() = %q, want %q", int(p), got, want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NotifyMemoryPressure with nil channel did not panic")
+		}
+	}()
+	NotifyMemoryPressure(nil)
+}
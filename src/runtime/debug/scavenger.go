@@ -0,0 +1,121 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "time"
+
+// ScavengerPolicy is the configuration of the scavenger, which returns
+// memory the heap no longer needs to the operating system, as reported
+// by runtime.MemStats.HeapReleased.
+//
+// The scavenger retains enough memory for the heap to grow to its goal
+// without asking the operating system for more, plus an extra margin,
+// since reusing retained memory is cheaper than faulting released memory
+// back in. Independently of this policy, it also releases memory as
+// needed to stay under the memory limit set by SetMemoryLimit.
+//
+// The zero ScavengerPolicy is not valid. Policies should be derived from
+// the one returned by ReadScavengerPolicy.
+type ScavengerPolicy struct {
+	// RetainExtraPercent is the margin of memory the scavenger retains
+	// beyond the heap goal, in percent of the heap goal. The default
+	// is 10. Lower values return memory to the operating system more
+	// eagerly; higher values make it cheaper for the heap to grow again.
+	RetainExtraPercent int
+
+	// CPUPercent is the portion of CPU time, in percent of GOMAXPROCS,
+	// that the background scavenger aims to spend releasing memory. The
+	// default is 1. It must be greater than 0 and at most 100. Higher
+	// values make memory use converge to the goal faster.
+	CPUPercent float64
+
+	// Release selects how memory is returned to the operating system.
+	// It only has an effect on systems that offer a choice, currently
+	// Linux, the BSDs, and Illumos/Solaris. The default is
+	// ReleaseDontNeed on Linux and ReleaseFree elsewhere, unless
+	// overridden with GODEBUG=madvdontneed.
+	Release ReleaseMode
+}
+
+// A ReleaseMode is a way of returning memory to the operating system.
+type ReleaseMode int
+
+const (
+	// ReleaseDontNeed returns memory with MADV_DONTNEED. The memory is
+	// reclaimed, and the process's resident set size drops, immediately.
+	ReleaseDontNeed ReleaseMode = iota
+
+	// ReleaseFree returns memory with MADV_FREE. This is cheaper, but
+	// the operating system reclaims the memory only under memory
+	// pressure, so until then it still counts toward the process's
+	// resident set size, and toward the usage of containers that
+	// account memory that way.
+	ReleaseFree
+)
+
+// ReadScavengerPolicy returns the scavenger's current configuration.
+func ReadScavengerPolicy() ScavengerPolicy {
+	var p ScavengerPolicy
+	readScavengerPolicy(&p)
+	return p
+}
+
+// SetScavengerPolicy changes the scavenger's configuration to policy and
+// returns the previous configuration. It panics if policy has a negative
+// RetainExtraPercent, a CPUPercent that is not greater than 0 and at most
+// 100, or an unknown Release mode.
+func SetScavengerPolicy(policy ScavengerPolicy) ScavengerPolicy {
+	if policy.RetainExtraPercent < 0 {
+		panic("runtime/debug: ScavengerPolicy with negative RetainExtraPercent")
+	}
+	if !(policy.CPUPercent > 0 && policy.CPUPercent <= 100) {
+		panic("runtime/debug: ScavengerPolicy with CPUPercent out of range")
+	}
+	if policy.Release != ReleaseDontNeed && policy.Release != ReleaseFree {
+		panic("runtime/debug: ScavengerPolicy with unknown Release mode")
+	}
+	setScavengerPolicy(&policy)
+	return policy
+}
+
+// A SpanSizeTier is a range of sizes of runs of heap memory freed at
+// once, for SetSpanRetention.
+type SpanSizeTier int
+
+const (
+	// SpanSizeSmall is memory freed in runs under 64 KiB. Most of it
+	// held small objects.
+	SpanSizeSmall SpanSizeTier = iota
+
+	// SpanSizeMedium is memory freed in runs from 64 KiB up to 1 MiB.
+	SpanSizeMedium
+
+	// SpanSizeLarge is memory freed in runs of 1 MiB or more. Most of
+	// it held large objects.
+	SpanSizeLarge
+)
+
+// SetSpanRetention sets how long heap memory freed in runs of the given
+// size tier is retained before the scavenger may return it to the
+// operating system, and returns the previous setting. A negative d
+// does not change the setting, and allows it to be retrieved. The
+// default is 0, which lets the scavenger return memory as soon as its
+// policy calls for it.
+//
+// Programs whose heap shrinks and grows back in bursts can retain the
+// memory freed after a burst to avoid faulting it back in for the next
+// one. Retention is tracked in regions of a few megabytes, so memory
+// freed next to recently freed memory may be retained too.
+//
+// Retained memory is still returned when needed to stay under the
+// memory limit set by SetMemoryLimit, and by FreeOSMemory.
+//
+// SetSpanRetention panics if tier is not a known SpanSizeTier.
+func SetSpanRetention(tier SpanSizeTier, d time.Duration) time.Duration {
+	if tier < SpanSizeSmall || tier > SpanSizeLarge {
+		panic("runtime/debug: SetSpanRetention with unknown SpanSizeTier")
+	}
+	return time.Duration(setSpanRetention(int(tier), int64(d)))
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"math"
+	"runtime"
+	. "runtime/debug"
+	"testing"
+	"time"
+)
+
+var scavengerPolicySink []byte
+
+func TestSetScavengerPolicy(t *testing.T) {
+	old := ReadScavengerPolicy()
+	defer SetScavengerPolicy(old)
+	if old.RetainExtraPercent != 10 || old.CPUPercent != 1 {
+		t.Errorf("default policy is %+v, want RetainExtraPercent 10 and CPUPercent 1", old)
+	}
+
+	p := ScavengerPolicy{RetainExtraPercent: 0, CPUPercent: 50, Release: ReleaseFree}
+	if prev := SetScavengerPolicy(p); prev != old {
+		t.Errorf("SetScavengerPolicy returned %+v, want %+v", prev, old)
+	}
+	if got := ReadScavengerPolicy(); got != p {
+		t.Errorf("ReadScavengerPolicy() = %+v, want %+v", got, p)
+	}
+
+	// With no extra retained memory and a generous CPU budget, the
+	// background scavenger should quickly release a large heap that
+	// became garbage.
+	p.Release = ReleaseDontNeed
+	SetScavengerPolicy(p)
+	const size = 64 << 20
+	scavengerPolicySink = make([]byte, size)
+	scavengerPolicySink = nil
+	runtime.GC()
+	runtime.GC()
+	var ms runtime.MemStats
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		runtime.ReadMemStats(&ms)
+		if ms.HeapSys-ms.HeapReleased < size/2 {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("scavenger retained %d MB of heap, want less than %d MB", (ms.HeapSys-ms.HeapReleased)>>20, size>>21)
+		}
+	}
+
+	for _, p := range []ScavengerPolicy{
+		{RetainExtraPercent: -1, CPUPercent: 1},
+		{RetainExtraPercent: 10, CPUPercent: 0},
+		{RetainExtraPercent: 10, CPUPercent: 101},
+		{RetainExtraPercent: 10, CPUPercent: math.NaN()},
+		{RetainExtraPercent: 10, CPUPercent: 1, Release: 2},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SetScavengerPolicy(%+v) did not panic", p)
+				}
+			}()
+			SetScavengerPolicy(p)
+		}()
+	}
+}
+
+func TestSetSpanRetention(t *testing.T) {
+	defer SetScavengerPolicy(ReadScavengerPolicy())
+	for tier := SpanSizeSmall; tier <= SpanSizeLarge; tier++ {
+		defer SetSpanRetention(tier, SetSpanRetention(tier, -1))
+	}
+	if got := SetSpanRetention(SpanSizeLarge, time.Hour); got != 0 {
+		t.Errorf("default retention is %v, want 0", got)
+	}
+	if got := SetSpanRetention(SpanSizeLarge, -1); got != time.Hour {
+		t.Errorf("SetSpanRetention(SpanSizeLarge, -1) = %v, want %v", got, time.Hour)
+	}
+
+	// With an eager scavenger, a large heap that became garbage is
+	// retained until the retention is lifted.
+	SetScavengerPolicy(ScavengerPolicy{RetainExtraPercent: 0, CPUPercent: 50, Release: ReleaseDontNeed})
+	const size = 64 << 20
+	retained := func() uint64 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		return ms.HeapSys - ms.HeapReleased
+	}
+	scavengerPolicySink = make([]byte, size)
+	scavengerPolicySink = nil
+	runtime.GC()
+	runtime.GC()
+	time.Sleep(100 * time.Millisecond)
+	if r := retained(); r < size {
+		t.Errorf("scavenger released retained memory: %d MB retained, want at least %d MB", r>>20, size>>20)
+	}
+	SetSpanRetention(SpanSizeLarge, 0)
+	runtime.GC()
+	for start := time.Now(); retained() >= size/2; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("scavenger retained %d MB of heap, want less than %d MB", retained()>>20, size>>21)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("SetSpanRetention with unknown tier did not panic")
+			}
+		}()
+		SetSpanRetention(SpanSizeLarge+1, 0)
+	}()
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "time"
+
+// SetSchedulerAging sets the scheduler aging threshold and returns the
+// previous setting. A threshold of 0, the initial setting, disables
+// aging. Thresholds below a millisecond are treated as a millisecond.
+//
+// With aging enabled, goroutines that have been ready to run for longer
+// than the threshold without running are promoted ahead of the other
+// goroutines waiting to run, and a running goroutine is preempted, if
+// necessary, to run them. This bounds scheduling delays under sustained
+// load more tightly, at the cost of some throughput, since promoted
+// goroutines may run on a different thread than the one that readied
+// them. The runtime checks for such goroutines every quarter of the
+// threshold.
+//
+// Goroutines in [runtime.SchedClassBackground] are not aged.
+//
+// Aging needs to know when each goroutine became ready to run, which
+// costs a clock read each time one does. While aging is enabled, the
+// /sched/latencies/all:seconds metric in [runtime/metrics] reports the
+// distribution of these waits. The /sched/latencies/longest-runnable:seconds
+// metric reports how long the longest-waiting goroutine has been ready
+// to run, and is only an estimate while aging is disabled.
+func SetSchedulerAging(threshold time.Duration) time.Duration {
+	return time.Duration(setSchedulerAging(int64(threshold)))
+}
+
+// SpinPolicy is the configuration of how the scheduler looks for work
+// for idle threads, trading CPU time spent while the program is mostly
+// idle for how quickly goroutines start running once they're ready.
+//
+// The zero SpinPolicy is not valid. Policies should be derived from the
+// one returned by ReadSpinPolicy.
+type SpinPolicy struct {
+	// MaxSpinningPercent limits the threads that spin, looking for
+	// goroutines to run rather than sleeping, to this percent of the
+	// processors running goroutines. The default is 50. It must be
+	// between 0 and 100. A thread woken to run a goroutine that just
+	// became ready spins whatever the limit.
+	MaxSpinningPercent int
+
+	// SpinDuration is how long a spinning thread keeps looking for
+	// goroutines before it sleeps. The default is 0, which makes it look
+	// at every processor a few times and then sleep. Longer durations
+	// let goroutines that become ready soon after run sooner, at the
+	// cost of CPU time. It must not be negative.
+	SpinDuration time.Duration
+
+	// WakeThreshold is how many goroutines must be waiting to run on a
+	// processor before it wakes another thread to run them, when it
+	// makes a goroutine ready or starts one. The default is 1, which
+	// wakes a thread for every goroutine, as long as no thread is
+	// spinning already. Higher values save the CPU time of waking
+	// threads for goroutines the processor soon gets to itself, but may
+	// keep them waiting for up to the scheduler's time slice of 10ms.
+	// It must be at least 1.
+	WakeThreshold int
+}
+
+// ReadSpinPolicy returns the scheduler's current spinning and wakeup
+// policy.
+func ReadSpinPolicy() SpinPolicy {
+	var p SpinPolicy
+	readSpinPolicy(&p)
+	return p
+}
+
+// SetSpinPolicy changes the scheduler's spinning and wakeup policy to
+// policy and returns the previous policy. It panics if policy has a
+// MaxSpinningPercent out of range, a negative SpinDuration, or a
+// WakeThreshold less than 1.
+func SetSpinPolicy(policy SpinPolicy) SpinPolicy {
+	if policy.MaxSpinningPercent < 0 || policy.MaxSpinningPercent > 100 {
+		panic("runtime/debug: SpinPolicy with MaxSpinningPercent out of range")
+	}
+	if policy.SpinDuration < 0 {
+		panic("runtime/debug: SpinPolicy with negative SpinDuration")
+	}
+	if policy.WakeThreshold < 1 {
+		panic("runtime/debug: SpinPolicy with WakeThreshold less than 1")
+	}
+	setSpinPolicy(&policy)
+	return policy
+}
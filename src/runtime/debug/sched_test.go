@@ -0,0 +1,110 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetSchedulerAging(t *testing.T) {
+	defer SetSchedulerAging(SetSchedulerAging(time.Microsecond))
+	if old := SetSchedulerAging(20 * time.Millisecond); old != time.Millisecond {
+		t.Errorf("SetSchedulerAging returned %v, want %v", old, time.Millisecond)
+	}
+
+	// Keep a P's run queue busy while other goroutines wait to run,
+	// so that sysmon promotes them.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	stop := make(chan bool)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				start := time.Now()
+				for time.Since(start) < time.Millisecond {
+				}
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	sample := []metrics.Sample{{Name: "/sched/latencies/longest-runnable:seconds"}}
+	metrics.Read(sample)
+	close(stop)
+	wg.Wait()
+	if wait := sample[0].Value.Float64(); wait <= 0 || wait > 10 {
+		t.Errorf("longest runnable wait is %vs while goroutines wait to run", wait)
+	}
+}
+
+func TestSetSpinPolicy(t *testing.T) {
+	def := ReadSpinPolicy()
+	if want := (SpinPolicy{MaxSpinningPercent: 50, WakeThreshold: 1}); def != want {
+		t.Fatalf("default SpinPolicy is %+v, want %+v", def, want)
+	}
+	defer SetSpinPolicy(def)
+
+	p := SpinPolicy{MaxSpinningPercent: 100, SpinDuration: time.Millisecond, WakeThreshold: 4}
+	if old := SetSpinPolicy(p); old != def {
+		t.Errorf("SetSpinPolicy returned %+v, want %+v", old, def)
+	}
+	if got := ReadSpinPolicy(); got != p {
+		t.Errorf("ReadSpinPolicy returned %+v, want %+v", got, p)
+	}
+
+	// Goroutines still run under either extreme of the policy.
+	for _, p := range []SpinPolicy{p, {MaxSpinningPercent: 0, WakeThreshold: 1 << 20}} {
+		SetSpinPolicy(p)
+		var wg sync.WaitGroup
+		c := make(chan int)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					c <- 1
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(c)
+		}()
+		n := 0
+		for range c {
+			n++
+		}
+		if n != 400 {
+			t.Errorf("with SpinPolicy %+v, received %d values, want 400", p, n)
+		}
+	}
+
+	for _, p := range []SpinPolicy{
+		{MaxSpinningPercent: -1, WakeThreshold: 1},
+		{MaxSpinningPercent: 101, WakeThreshold: 1},
+		{MaxSpinningPercent: 50, SpinDuration: -1, WakeThreshold: 1},
+		{MaxSpinningPercent: 50},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SetSpinPolicy(%+v) didn't panic", p)
+				}
+			}()
+			SetSpinPolicy(p)
+		}()
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A GoroutineEventKind is a kind of goroutine state transition.
+type GoroutineEventKind int
+
+const (
+	// GoroutineCreated is when a goroutine is created. It's then
+	// runnable, unless it's a system goroutine created blocked.
+	GoroutineCreated GoroutineEventKind = iota
+
+	// GoroutineRunnable is when a goroutine becomes ready to run: it
+	// was unblocked, or stopped running without blocking, for example
+	// by calling runtime.Gosched or being preempted.
+	GoroutineRunnable
+
+	// GoroutineRunning is when a goroutine starts or resumes running,
+	// including when it returns from a system call.
+	GoroutineRunning
+
+	// GoroutineBlocked is when a goroutine blocks, for the reason in
+	// GoroutineEvent.Reason.
+	GoroutineBlocked
+
+	// GoroutineSyscall is when a goroutine enters a system call, or a
+	// call into C code.
+	GoroutineSyscall
+
+	// GoroutineExited is when a goroutine exits.
+	GoroutineExited
+)
+
+func (k GoroutineEventKind) String() string {
+	switch k {
+	case GoroutineCreated:
+		return "GoroutineCreated"
+	case GoroutineRunnable:
+		return "GoroutineRunnable"
+	case GoroutineRunning:
+		return "GoroutineRunning"
+	case GoroutineBlocked:
+		return "GoroutineBlocked"
+	case GoroutineSyscall:
+		return "GoroutineSyscall"
+	case GoroutineExited:
+		return "GoroutineExited"
+	}
+	return "GoroutineEventKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A GoroutineEvent is a goroutine state transition.
+type GoroutineEvent struct {
+	Kind      GoroutineEventKind
+	Goroutine uint64    // the goroutine's ID, as printed in stack traces
+	Time      time.Time // when the transition happened
+	Reason    string    // for GoroutineBlocked, what the goroutine waits for, as in stack traces
+}
+
+// schedEvent is a goroutine event recorded by the runtime.
+//
+// Its layout must match runtime.schedEvent.
+type schedEvent struct {
+	kind     int
+	goid     uint64
+	unixNano int64
+	reason   string
+}
+
+type goroutineEventSub struct {
+	f func(events []GoroutineEvent, dropped int)
+}
+
+var goroutineEvents struct {
+	mu   sync.Mutex // serializes changes to subs and starting and stopping the loop
+	subs atomic.Pointer[[]*goroutineEventSub]
+	stop chan struct{} // closed to stop the current loop
+	done chan struct{} // closed once the current loop has stopped
+}
+
+// goroutineEventPeriod is how often the runtime's events are delivered.
+const goroutineEventPeriod = 10 * time.Millisecond
+
+// SubscribeGoroutineEvents arranges for f to be called with the state
+// transitions of goroutines, until the returned function is called to
+// cancel the subscription. It lets observability tools follow the
+// scheduler more cheaply than with a full execution trace from
+// [runtime/trace].
+//
+// The runtime records events in a buffer, which is drained every 10ms,
+// in batches that are passed to the subscribers in order. f is called
+// only with events recorded after it subscribed, but may be called with
+// a few more once its subscription is canceled. dropped is the number of
+// events lost since the previous batch because the buffer was full.
+// Events are in the order their goroutines reached them, which across
+// goroutines is only approximately the order of their times.
+//
+// Subscribers are called one at a time, on a goroutine dedicated to
+// them, whose own transitions aren't reported. f must not retain events
+// after it returns, and should return quickly, since the buffer fills up
+// while it runs. Recording events slows down every state transition, so
+// subscriptions shouldn't be left in place when they're not needed.
+func SubscribeGoroutineEvents(f func(events []GoroutineEvent, dropped int)) (cancel func()) {
+	if f == nil {
+		panic("runtime/debug: SubscribeGoroutineEvents with nil function")
+	}
+	s := &goroutineEventSub{f}
+
+	goroutineEvents.mu.Lock()
+	defer goroutineEvents.mu.Unlock()
+	subs := goroutineEvents.subs.Load()
+	var newSubs []*goroutineEventSub
+	if subs != nil {
+		newSubs = slices.Clone(*subs)
+	}
+	newSubs = append(newSubs, s)
+	goroutineEvents.subs.Store(&newSubs)
+	if len(newSubs) == 1 {
+		prevDone := goroutineEvents.done
+		stop, done := make(chan struct{}), make(chan struct{})
+		goroutineEvents.stop, goroutineEvents.done = stop, done
+		started := make(chan struct{})
+		go goroutineEventLoop(prevDone, started, stop, done)
+		// Don't return before events are enabled.
+		<-started
+	}
+
+	return func() {
+		goroutineEvents.mu.Lock()
+		defer goroutineEvents.mu.Unlock()
+		subs := goroutineEvents.subs.Load()
+		i := slices.Index(*subs, s)
+		if i < 0 {
+			return
+		}
+		newSubs := slices.Delete(slices.Clone(*subs), i, i+1)
+		goroutineEvents.subs.Store(&newSubs)
+		if len(newSubs) == 0 {
+			// Don't wait for the loop to stop, since cancel may be
+			// called by a subscriber. The next loop waits instead.
+			close(goroutineEvents.stop)
+		}
+	}
+}
+
+// goroutineEventLoop reads events from the runtime and passes them to
+// the subscribers until stop is closed, then closes done. It waits for
+// the previous loop, if any, to close prevDone first, since the runtime
+// only supports one reader at a time, and closes started once events
+// are enabled.
+func goroutineEventLoop(prevDone, started, stop, done chan struct{}) {
+	if prevDone != nil {
+		<-prevDone
+	}
+	setSchedEvents(true)
+	close(started)
+
+	buf := make([]schedEvent, 1024)
+	events := make([]GoroutineEvent, len(buf))
+	ticker := time.NewTicker(goroutineEventPeriod)
+	defer ticker.Stop()
+	for {
+		n := readSchedEvents(buf)
+		dropped := int(schedEventsDropped())
+		if n > 0 || dropped > 0 {
+			for i, e := range buf[:n] {
+				events[i] = GoroutineEvent{
+					Kind:      GoroutineEventKind(e.kind),
+					Goroutine: e.goid,
+					Time:      time.Unix(0, e.unixNano),
+					Reason:    e.reason,
+				}
+			}
+			if subs := goroutineEvents.subs.Load(); subs != nil {
+				for _, s := range *subs {
+					s.f(events[:n], dropped)
+				}
+			}
+		}
+		if n == len(buf) {
+			// There may be more.
+			continue
+		}
+		select {
+		case <-stop:
+			setSchedEvents(false)
+			close(done)
+			return
+		case <-ticker.C:
+		}
+	}
+}
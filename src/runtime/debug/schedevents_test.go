@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribeGoroutineEvents(t *testing.T) {
+	var mu sync.Mutex
+	var got []GoroutineEvent
+	cancel := SubscribeGoroutineEvents(func(events []GoroutineEvent, dropped int) {
+		mu.Lock()
+		got = append(got, events...)
+		mu.Unlock()
+	})
+	defer cancel()
+
+	start := time.Now()
+	c := make(chan bool)
+	goid := make(chan uint64)
+	go func() {
+		buf := make([]byte, 64)
+		buf = buf[:runtime.Stack(buf, false)]
+		id, _ := strconv.ParseUint(strings.Fields(string(buf))[1], 10, 64)
+		goid <- id
+		<-c
+	}()
+	id := <-goid
+	time.Sleep(10 * time.Millisecond)
+	c <- true
+
+	// Wait for the goroutine's events, in the order the goroutine
+	// reached them.
+	want := []GoroutineEventKind{GoroutineCreated, GoroutineRunning, GoroutineBlocked, GoroutineRunnable, GoroutineRunning, GoroutineExited}
+	var kinds []GoroutineEventKind
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		mu.Lock()
+		kinds = kinds[:0]
+		for _, e := range got {
+			if e.Goroutine != id {
+				continue
+			}
+			if e.Time.Before(start) {
+				t.Errorf("event %v at %v, before the goroutine started at %v", e.Kind, e.Time, start)
+			}
+			if e.Kind == GoroutineBlocked && e.Reason != "chan receive" && e.Reason != "chan send" {
+				t.Errorf("goroutine blocked for %q, want chan receive or send", e.Reason)
+			}
+			kinds = append(kinds, e.Kind)
+		}
+		mu.Unlock()
+		if slices.Contains(kinds, GoroutineExited) {
+			break
+		}
+	}
+
+	// The goroutine blocks on goid and c, or only on c, and may be
+	// preempted, but it always reaches want's events in order.
+	i := 0
+	for _, k := range kinds {
+		if i < len(want) && k == want[i] {
+			i++
+		}
+	}
+	if i < len(want) {
+		t.Errorf("goroutine events %v, want a sequence containing %v", kinds, want)
+	}
+}
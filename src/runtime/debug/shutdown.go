@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"runtime"
+	"time"
+)
+
+// Shutdown prepares the runtime for the program to exit, and returns
+// once it's done, or once timeout has elapsed if timeout is positive.
+// It reports whether it finished before the timeout. It's meant to be
+// called once the program's own work is finished, right before it
+// exits.
+//
+// Shutdown, in order:
+//
+//   - stops accepting new timers: timers created or reset through
+//     package time from then on never fire, although timers started
+//     earlier, including Shutdown's own timeout, fire as usual;
+//   - runs a garbage collection, and waits until every finalizer and
+//     cleanup it queues, along with those already queued, has run;
+//   - stops CPU profiling and execution tracing, as
+//     [runtime/pprof.StopCPUProfile] and [runtime/trace.Stop] do, and
+//     waits for their output to be written.
+//
+// Timers stay stopped for the rest of the program, even if Shutdown
+// times out, for example because a finalizer blocks.
+func Shutdown(timeout time.Duration) bool {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		// Start the timer before new timers are stopped.
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+	startShutdown()
+	done := make(chan struct{})
+	go func() {
+		runtime.GC()
+		<-finalizersDrained()
+		for _, f := range shutdownHooks() {
+			f()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-expired:
+		return false
+	}
+}
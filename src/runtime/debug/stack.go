@@ -89,5 +89,31 @@ func SetCrashOutput(f *os.File, opts CrashOptions) error {
 	return nil
 }
 
+// SetPanicHook sets a function to be called when a panic that no
+// deferred call recovers is about to crash the program, before the
+// panic and stack trace are printed. The function receives the panic
+// value and the stack trace of the panicking goroutine, as formatted
+// by [Stack], and is meant to record diagnostics, such as reporting
+// the crash to a monitoring service. It can't stop the crash: the
+// program exits when it returns, and calling recover in it returns nil.
+//
+// The function runs on the panicking goroutine, after that goroutine's
+// deferred calls have run, while the program's other goroutines keep
+// running. It's called at most once: if other goroutines panic while
+// it runs, they wait for it to return, and if it panics itself, the
+// program crashes without calling it again. It should return promptly,
+// since the program may also exit before it returns, when the main
+// function returns or another goroutine calls [os.Exit].
+//
+// The function isn't called for fatal errors detected by the runtime
+// that aren't panics, such as running out of memory or concurrent map
+// writes. [SetCrashOutput] reports those too.
+//
+// There is only one panic hook: calling SetPanicHook again replaces
+// it. SetPanicHook(nil) removes it.
+func SetPanicHook(f func(value any, stack []byte)) {
+	setPanicHook(f)
+}
+
 //go:linkname runtime_setCrashFD runtime.setCrashFD
 func runtime_setCrashFD(uintptr) uintptr
@@ -35,6 +35,15 @@ func TestMain(m *testing.M) {
 		}
 		println("hello")
 		panic("oops")
+
+	case "setpanichook":
+		SetPanicHook(func(value any, stack []byte) {
+			report := fmt.Sprintf("hook: %v\n%s", value, stack)
+			if err := os.WriteFile(os.Getenv("CRASHOUTPUT"), []byte(report), 0666); err != nil {
+				log.Fatal(err)
+			}
+		})
+		panic("oops")
 	}
 
 	// default: run the tests.
@@ -196,3 +205,43 @@ func TestSetCrashOutput(t *testing.T) {
 		t.Errorf("stderr output does not contain %q, but should", printlnOnly)
 	}
 }
+
+func TestSetPanicHook(t *testing.T) {
+	testenv.MustHaveExec(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crashOutput := filepath.Join(t.TempDir(), "crash.out")
+
+	cmd := exec.Command(exe)
+	cmd.Stderr = new(strings.Builder)
+	cmd.Env = append(os.Environ(), "GO_RUNTIME_DEBUG_TEST_ENTRYPOINT=setpanichook", "CRASHOUTPUT="+crashOutput)
+	err = cmd.Run()
+	stderr := fmt.Sprint(cmd.Stderr)
+	if err == nil {
+		t.Fatalf("child process succeeded unexpectedly (stderr: %s)", stderr)
+	}
+
+	// The hook should have written the panic value and the stack
+	// trace, and the panic should still have been printed.
+	data, err := os.ReadFile(crashOutput)
+	if err != nil {
+		t.Fatalf("panic hook didn't write its report: %v", err)
+	}
+	report := string(data)
+	t.Logf("report = <<%s>>", report)
+	for _, want := range []string{
+		"hook: oops",
+		"goroutine 1 [running]",
+		"debug_test.TestMain",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("panic hook report does not contain %q", want)
+		}
+	}
+	if !strings.Contains(stderr, "panic: oops") {
+		t.Errorf("stderr does not contain the panic: <<%s>>", stderr)
+	}
+}
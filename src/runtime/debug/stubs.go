@@ -6,13 +6,46 @@ package debug
 
 import (
 	"time"
+	"unsafe"
 )
 
 // Implemented in package runtime.
 func readGCStats(*[]time.Duration)
 func freeOSMemory()
+func freeOSMemoryTo(uint64)
+func prefaultHeap(uint64)
 func setMaxStack(int) int
 func setGCPercent(int32) int32
+func setGCWorkers(int32) int32
 func setPanicOnFault(bool) bool
 func setMaxThreads(int) int
 func setMemoryLimit(int64) int64
+func readGCPolicy(*GCPolicy)
+func setGCPolicy(*GCPolicy)
+func readScavengerPolicy(*ScavengerPolicy)
+func setScavengerPolicy(*ScavengerPolicy)
+func setSpanRetention(tier int, d int64) int64
+func readGCEvent(unsafe.Pointer)
+func waitGCEvent(unsafe.Pointer, uint32)
+func startMemPressureWatcher()
+func readMemPressureEvent(unsafe.Pointer)
+func waitMemPressureEvent(unsafe.Pointer, uint32)
+func registerGCPhaseHook(func(phase int))
+func finishSweep(n uint32)
+func readHeapTypeStats(func(name string, objects, bytes uint64))
+func readHeapFragmentation(class func(size, spans, spanBytes, objects uint64)) (free, released, largestFree uint64)
+func goroutineAllocBytes() uint64
+func setHeapHugePages(int32) int32
+func collapseHeapHugePages()
+func setPanicHook(func(value any, stack []byte))
+func setMaxGoroutines(int, func(pc uintptr) int) int
+func setSchedulerAging(int64) int64
+func setDefaultTimerSlack(int64) int64
+func readSpinPolicy(*SpinPolicy)
+func setSpinPolicy(*SpinPolicy)
+func setSchedEvents(on bool)
+func readSchedEvents(buf []schedEvent) int
+func schedEventsDropped() uint64
+func startShutdown()
+func finalizersDrained() <-chan struct{}
+func shutdownHooks() []func()
@@ -0,0 +1,23 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "time"
+
+// SetTimerSlack sets the slack of the timers and tickers that package
+// time creates from then on, and returns the previous setting. The
+// initial setting is 0. A negative slack is treated as 0.
+//
+// A timer with slack may expire up to that long after it otherwise
+// would, which lets the runtime expire many timers together and wake
+// the program less often. For a service that's mostly idle with many
+// heartbeat or keepalive timers, this saves CPU time and power.
+// The slack of a single timer can be changed with [time.Timer.SetSlack]
+// or [time.Ticker.SetSlack].
+//
+// The setting doesn't apply to [time.Sleep] or to I/O deadlines.
+func SetTimerSlack(d time.Duration) time.Duration {
+	return time.Duration(setDefaultTimerSlack(int64(d)))
+}
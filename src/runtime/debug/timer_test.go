@@ -0,0 +1,29 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	. "runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestSetTimerSlack(t *testing.T) {
+	defer SetTimerSlack(SetTimerSlack(time.Second))
+	if old := SetTimerSlack(-1); old != time.Second {
+		t.Errorf("SetTimerSlack returned %v, want %v", old, time.Second)
+	}
+	if old := SetTimerSlack(time.Hour); old != 0 {
+		t.Errorf("SetTimerSlack returned %v after setting a negative slack, want 0", old)
+	}
+
+	// Timers created with a default slack expire within it.
+	start := time.Now()
+	SetTimerSlack(10 * time.Millisecond)
+	<-time.After(time.Millisecond)
+	if d := time.Since(start); d < time.Millisecond {
+		t.Errorf("timer expired after %v, before its duration", d)
+	}
+}
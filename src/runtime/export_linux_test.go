@@ -6,6 +6,11 @@
 
 package runtime
 
+import (
+	"internal/goarch"
+	"unsafe"
+)
+
 const SiginfoMaxSize = _si_max_size
 const SigeventMaxSize = _sigev_max_size
 
@@ -14,3 +19,27 @@ var Mincore = mincore
 
 type Siginfo siginfo
 type Sigevent sigevent
+
+var MemEventsCount = memEventsCount
+var MemPSIAvg10 = memPSIAvg10
+
+var CgroupPaths = cgroupPaths
+var CgroupDir = cgroupDir
+var ParseCgroupCPUMax = parseCgroupCPUMax
+var ParseCgroupV1CPULimit = parseCgroupV1CPULimit
+
+// ThreadCPUs returns the CPUs in the current thread's affinity mask.
+func ThreadCPUs() []int {
+	mask, _ := threadAffinity()
+	const bits = goarch.PtrSize * 8
+	words := unsafe.Slice((*uintptr)(unsafe.Pointer(&mask[0])), len(mask)/goarch.PtrSize)
+	var cpus []int
+	for i, w := range words {
+		for j := 0; j < bits; j++ {
+			if w&(1<<j) != 0 {
+				cpus = append(cpus, i*bits+j)
+			}
+		}
+	}
+	return cpus
+}
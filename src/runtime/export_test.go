@@ -49,6 +49,8 @@ var NetpollGenericInit = netpollGenericInit
 
 var Memmove = memmove
 var MemclrNoHeapPointers = memclrNoHeapPointers
+var MemmoveNonTemporal = memmoveNonTemporal
+var MemclrNoHeapPointersNonTemporal = memclrNoHeapPointersNonTemporal
 
 var CgoCheckPointer = cgoCheckPointer
 
@@ -101,6 +103,8 @@ func GCMask(x any) (ret []byte) {
 	return
 }
 
+const SchedClassIdle = schedClassIdle
+
 func RunSchedLocalQueueTest() {
 	pp := new(p)
 	gs := make([]g, len(pp.runq))
@@ -124,6 +128,41 @@ func RunSchedLocalQueueTest() {
 	}
 }
 
+func RunSchedAgingLocalQueueTest() {
+	pp := new(p)
+	gs := make([]g, 4)
+	Escape(gs) // Ensure gs doesn't move, since we use guintptrs
+	for i := range gs {
+		gs[i].runnableSince = int64(i+1) * 10
+		runqput(pp, &gs[i], false)
+	}
+	for i := 0; i < 2; i++ {
+		if gp := runqgetaged(pp, 25); gp != &gs[i] {
+			print("bad element at iter ", i, "\n")
+			throw("bad element")
+		}
+	}
+	if gp := runqgetaged(pp, 25); gp != nil {
+		throw("got goroutine that isn't aged")
+	}
+	for i := 2; i < len(gs); i++ {
+		if gp, _ := runqget(pp); gp != &gs[i] {
+			throw("bad element left")
+		}
+	}
+	if gp := runqgetaged(pp, 100); gp != nil {
+		throw("got goroutine from empty runq")
+	}
+}
+
+// ProcID returns the ID of the P running the calling goroutine.
+func ProcID() int32 {
+	mp := acquirem()
+	id := mp.p.ptr().id
+	releasem(mp)
+	return id
+}
+
 func RunSchedLocalQueueStealTest() {
 	p1 := new(p)
 	p2 := new(p)
@@ -835,6 +874,21 @@ func (p *PageAlloc) Scavenge(nbytes uintptr) (r uintptr) {
 	})
 	return
 }
+func (p *PageAlloc) ScavengeBg(nbytes uintptr) (r uintptr) {
+	pp := (*pageAlloc)(p)
+	systemstack(func() {
+		r = pp.scavenge(nbytes, nil, false)
+	})
+	return
+}
+func (p *PageAlloc) NextGen() {
+	pp := (*pageAlloc)(p)
+	systemstack(func() {
+		lock(pp.mheapLock)
+		pp.scav.index.nextGen()
+		unlock(pp.mheapLock)
+	})
+}
 func (p *PageAlloc) InUse() []AddrRange {
 	ranges := make([]AddrRange, 0, len(p.inUse.ranges))
 	for _, r := range p.inUse.ranges {
@@ -967,6 +1021,13 @@ type BitRange struct {
 	I, N uint // bit index and length in bits
 }
 
+const (
+	ScavengeRetainSmall = scavengeRetainSmall
+	ScavengeRetainLarge = scavengeRetainLarge
+)
+
+var SetSpanRetention = setSpanRetention
+
 // NewPageAlloc creates a new page allocator for testing and
 // initializes it with the scav and chunks maps. Each key in these maps
 // represents a chunk index and each value is a series of bit ranges to
@@ -1329,6 +1390,10 @@ func GCTestPointerClass(p unsafe.Pointer) string {
 
 const Raceenabled = raceenabled
 
+func HeapTypesEnabled() bool {
+	return heapTypes.enabled
+}
+
 const (
 	GCBackgroundUtilization            = gcBackgroundUtilization
 	GCGoalUtilization                  = gcGoalUtilization
@@ -1408,6 +1473,22 @@ func (c *GCController) EndCycle(bytesMarked uint64, assistTime, elapsed int64, g
 	c.commit(false)
 }
 
+func (c *GCController) SetMaxAssistLatency(d int64) {
+	c.maxAssistLatency.Store(d)
+}
+
+func (c *GCController) RecordAssist(d int64) {
+	c.recordAssist(d)
+}
+
+func (c *GCController) SetDedicatedWorkers(n int) {
+	c.dedicatedWorkers.Store(int32(n))
+}
+
+func (c *GCController) MarkWorkers() (dedicated int64, fractional float64) {
+	return c.dedicatedMarkWorkersNeeded.Load(), c.fractionalUtilizationGoal
+}
+
 func (c *GCController) AddIdleMarkWorker() bool {
 	return c.addIdleMarkWorker()
 }
@@ -1499,6 +1580,14 @@ func (l *GCCPULimiter) Limiting() bool {
 	return l.limiter.limiting()
 }
 
+func (l *GCCPULimiter) EnabledCount() uint64 {
+	return l.limiter.enabledCount.Load()
+}
+
+func (l *GCCPULimiter) EnabledTime() int64 {
+	return l.limiter.enabledTime.Load()
+}
+
 func (l *GCCPULimiter) NeedUpdate(now int64) bool {
 	return l.limiter.needUpdate(now)
 }
@@ -1726,14 +1815,20 @@ const GTrackingPeriod = gTrackingPeriod
 
 var ZeroBase = unsafe.Pointer(&zerobase)
 
-const UserArenaChunkBytes = userArenaChunkBytes
+const UserArenaChunkBytes = userArenaChunkBytesDefault
+
+var UserArenaChunkBytesFor = userArenaChunkBytesFor
 
 type UserArena struct {
 	arena *userArena
 }
 
 func NewUserArena() *UserArena {
-	return &UserArena{newUserArena()}
+	return &UserArena{newUserArena(userArenaOptions{})}
+}
+
+func NewConcurrentUserArena() *UserArena {
+	return &UserArena{newUserArena(userArenaOptions{concurrent: true})}
 }
 
 func (a *UserArena) New(out *any) {
@@ -1750,8 +1845,74 @@ func (a *UserArena) Slice(sl any, cap int) {
 	a.arena.slice(sl, cap)
 }
 
+func (a *UserArena) Map(m any, hint int) {
+	a.arena.makemap(m, hint)
+}
+
+func (a *UserArena) GrowInPlace(sl any, cap int) bool {
+	return a.arena.growInPlace(sl, cap)
+}
+
 func (a *UserArena) Free() {
-	a.arena.free()
+	a.arena.free(false)
+}
+
+func (a *UserArena) Reset() {
+	a.arena.reset()
+}
+
+type UserArenaMark = userArenaMark
+
+func (a *UserArena) Mark() UserArenaMark {
+	return a.arena.mark()
+}
+
+func (a *UserArena) Release(m UserArenaMark) {
+	a.arena.release(&m)
+}
+
+func (a *UserArena) MarkRuns() int {
+	return len(a.arena.marks)
+}
+
+type UserArenaStats struct {
+	AllocBytes   uint64
+	PaddingBytes uint64
+	Chunks       uint64
+	Allocs       uint64
+}
+
+func (a *UserArena) ArenaStats() UserArenaStats {
+	s := a.arena.readStats()
+	return UserArenaStats{s.allocBytes, s.paddingBytes, s.chunks, s.allocs}
+}
+
+type UserArenaChunkPool struct {
+	pool *userArenaChunkPool
+}
+
+func NewUserArenaChunkPool() *UserArenaChunkPool {
+	return &UserArenaChunkPool{newUserArenaChunkPool()}
+}
+
+func (p *UserArenaChunkPool) NewUserArena() *UserArena {
+	return &UserArena{newUserArena(userArenaOptions{pool: p.pool})}
+}
+
+func (p *UserArenaChunkPool) Free() {
+	p.pool.free()
+}
+
+func (p *UserArenaChunkPool) Stats() (chunks, idle uint64) {
+	s := p.pool.readStats()
+	return s.chunks, s.idleChunks
+}
+
+func GlobalReusableArenaChunks() int {
+	lock(&userArenaState.lock)
+	n := len(userArenaState.reuse)
+	unlock(&userArenaState.lock)
+	return n
 }
 
 func GlobalWaitingArenaChunks() int {
@@ -1843,3 +2004,20 @@ func (m *TraceMap) PutString(s string) (uint64, bool) {
 func (m *TraceMap) Reset() {
 	m.traceMap.reset()
 }
+
+const (
+	MemPressureNone     = memPressureNone
+	MemPressureModerate = memPressureModerate
+	MemPressureCritical = memPressureCritical
+)
+
+var MemPressureRecord = memPressureRecord
+
+// WaitMemPressure waits for a change of memory pressure level after
+// change seq and returns the number of the most recent change and the
+// level it changed to.
+func WaitMemPressure(seq uint32) (uint32, int32) {
+	var e memPressureEvent
+	waitMemPressureEvent(unsafe.Pointer(&e), seq)
+	return e.seq, e.level
+}
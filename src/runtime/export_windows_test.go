@@ -18,6 +18,16 @@ var (
 	TimeBeginPeriodRetValue = &timeBeginPeriodRetValue
 )
 
+func HaveHighResTimer() bool {
+	return haveHighResTimer
+}
+
+// Semasleep waits for up to ns nanoseconds for the calling thread's
+// semaphore, and reports whether it was signaled.
+func Semasleep(ns int64) bool {
+	return semasleep(ns) == 0
+}
+
 func NumberOfProcessors() int32 {
 	var info systeminfo
 	stdcall1(_GetSystemInfo, uintptr(unsafe.Pointer(&info)))
@@ -35,16 +35,64 @@ time.
 The GODEBUG variable controls debugging variables within the runtime.
 It is a comma-separated list of name=val pairs setting these named variables:
 
+	arenachunksize: setting arenachunksize=N sets the size of the chunks of memory
+	that user arenas (see the arena package, available with GOEXPERIMENT=arenas)
+	are built from to approximately N bytes. N is rounded up to a power of two
+	that is at least 64 KiB and at most the default chunk size, which depends on
+	the platform. Smaller chunks reduce the memory held by each arena at the cost
+	of more frequent chunk allocation, and lower the maximum size of a value that
+	is allocated from an arena rather than the heap to a quarter of the chunk size.
+
+	arenahugepages: setting arenahugepages=1 advises the operating system to back
+	the chunks of memory that user arenas are built from with transparent huge
+	pages, even if the system is configured to only use them when requested.
+	Setting arenahugepages=2 additionally asks the operating system to collapse
+	each new chunk into huge pages right away, instead of eventually in the
+	background, at the cost of extra latency when the chunk is allocated.
+	This setting currently only has an effect on Linux.
+
+	arenaheap: setting arenaheap=1 makes user arenas allocate all values from the
+	regular garbage-collected heap instead of their own memory, so that freeing an
+	arena has no effect on its values, and the heap profile attributes them to the
+	code that allocated them from the arena. This helps determine whether a memory
+	corruption bug is related to the use of arenas, and allows running programs that
+	use arenas under tools that don't understand them. Arenas that allocate from
+	memory provided by their creator are unaffected.
+
+	arenaleak: setting arenaleak=1 records the stack that created each user arena,
+	and keeps track of the arenas the garbage collector frees because they became
+	unreachable before their Free method was called. These leaked arenas, grouped
+	by creation stack, are reported on standard error when the program exits, and
+	may be retrieved at any time with arena.Leaks. An arena is only found to be
+	leaked once the garbage collector has run its finalizer.
+
+	arenapoison: setting arenapoison=N keeps the memory of freed user arena chunks
+	inaccessible for at least N garbage collection cycles, instead of allowing it
+	to be reused as soon as the garbage collector finds no more references into it.
+	It also records where each arena is created, so that an access to memory from a
+	freed arena reports where that arena was created.
+
 	clobberfree: setting clobberfree=1 causes the garbage collector to
 	clobber the memory content of an object with bad content when it frees
 	the object.
 
+	containermaxprocs: setting containermaxprocs=0 makes GOMAXPROCS default to
+	the number of CPUs, ignoring the CPU bandwidth limit of the program's Linux cgroup.
+
 	cpu.*: cpu.all=off disables the use of all optional instruction set extensions.
 	cpu.extension=off disables use of instructions from the specified instruction set extension.
 	extension is the lower case name for the instruction set extension such as sse41 or avx
 	as listed in internal/cpu package. As an example cpu.avx=off disables runtime detection
 	and thereby use of AVX instructions.
 
+	cgocallbackstack: setting cgocallbackstack=N makes the runtime assume that a
+	thread created outside Go that calls into Go has at least N bytes of stack
+	below the call, when it can't find out the thread's stack bounds, as on
+	Windows, or for a call made on a stack other than the thread's own, such as
+	one allocated by a coroutine library. The default is 32 kB. C code called
+	from Go on such a thread must use less than that much stack before calling
+	back into Go.
+
 	cgocheck: setting cgocheck=0 disables all checks for packages
 	using cgo to incorrectly pass Go pointers to non-Go code.
 	Setting cgocheck=1 (the default) enables relatively cheap
@@ -52,12 +100,20 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	cgocheck mode can be enabled using GOEXPERIMENT (which
 	requires a rebuild), see https://pkg.go.dev/internal/goexperiment for details.
 
+	cgothreadstack: setting cgothreadstack=N makes the threads that the runtime
+	creates, when the program uses cgo, have stacks of N bytes, rounded up to a
+	multiple of the page size, instead of the C library's default size. C code
+	called from Go runs on these stacks. A size the C library doesn't accept,
+	such as one below its minimum, is ignored.
+
 	disablethp: setting disablethp=1 on Linux disables transparent huge pages for the heap.
 	It has no effect on other platforms. disablethp is meant for compatibility with versions
 	of Go before 1.21, which stopped working around a Linux kernel default that can result
 	in significant memory overuse. See https://go.dev/issue/64332. This setting will be
 	removed in a future release, so operators should tweak their Linux configuration to suit
 	their needs before then. See https://go.dev/doc/gc-guide#Linux_transparent_huge_pages.
+	Programs can also control the use of huge pages for the heap at run time with
+	runtime/debug.SetHeapHugePages.
 
 	dontfreezetheworld: by default, the start of a fatal panic or throw
 	"freezes the world", preempting all threads to stop all running
@@ -89,6 +145,10 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	making every garbage collection a stop-the-world event. Setting gcstoptheworld=2
 	also disables concurrent sweeping after the garbage collection finishes.
 
+	gcworkers: setting gcworkers=N runs N dedicated background mark workers,
+	up to GOMAXPROCS, during each garbage collection, instead of enough to use
+	25% of GOMAXPROCS. See also runtime/debug.SetGCWorkers.
+
 	gctrace: setting gctrace=1 causes the garbage collector to emit a single line to standard
 	error at each collection, summarizing the amount of memory collected and the
 	length of the pause. The format of this line is subject to change. Included in
@@ -112,11 +172,75 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	If the line ends with "(forced)", this GC was forced by a
 	runtime.GC() call.
 
+	gctracejson: setting gctracejson=1 causes the garbage collector to emit a single
+	line of JSON to standard error at each collection, independently of gctrace. Each
+	line is an object with the same information as the gctrace line, in bytes and
+	nanoseconds rather than rounded to megabytes and milliseconds, and fields named
+	for what they hold, so that new fields can be added without breaking parsers.
+	Currently, the fields are:
+		gc                         the GC number, incremented at each GC
+		start_ns                   time the GC started, since program start
+		gc_cpu_fraction            fraction of CPU time spent in GC since program start
+		trigger                    why the GC started: "heap", "time" (periodic), or "forced"
+		sweep_term_ns              wall-clock time of STW sweep termination
+		mark_ns                    wall-clock time of concurrent mark and scan
+		mark_term_ns               wall-clock time of STW mark termination
+		pause_ns                   total stop-the-world time
+		sweep_term_cpu_ns          CPU time of sweep termination
+		assist_cpu_ns              CPU time of mark assists
+		background_cpu_ns          CPU time of background mark workers
+		idle_cpu_ns                CPU time of idle mark workers
+		mark_term_cpu_ns           CPU time of mark termination
+		heap_start_bytes           heap size at GC start
+		heap_end_bytes             heap size at GC end
+		heap_live_bytes            live heap, or /gc/scan/heap:bytes
+		heap_goal_bytes            goal heap size, or /gc/heap/goal:bytes
+		stack_scan_bytes           estimated scannable stack size, or /gc/scan/stack:bytes
+		globals_scan_bytes         scannable global size, or /gc/scan/globals:bytes
+		heap_scan_work_bytes       heap memory scanned
+		stack_scan_work_bytes      stack memory scanned
+		globals_scan_work_bytes    global memory scanned
+		scan_rate_bytes_per_cpu_s  memory scanned per second of mark CPU time
+		procs                      number of processors used, or /sched/gomaxprocs:threads
+
+	goroutineallocbytes: setting goroutineallocbytes=1 causes the runtime to count the bytes
+	each goroutine allocates, as reported by runtime/debug.GoroutineAllocBytes and the
+	goroutine profile of runtime/pprof. This adds a little work to every allocation.
+
+	goroutinecputime: setting goroutinecputime=1 causes the runtime to measure the time each
+	goroutine spends running, as reported by GoroutineCPUTime and GoroutineCPUTimeOf. This
+	reads the clock each time a goroutine starts or stops running, which makes goroutine
+	switches a little more expensive. The setting can be changed at run time with os.Setenv.
+
+	guardpages: setting guardpages=1 places a page set to fault after each large heap
+	object (larger than 32 kB), so that writes that run off the end of the object's
+	pages fault immediately. The rest of the object's last page is filled with a
+	pattern that the garbage collector checks when it frees the object, and the
+	program crashes if it was overwritten. This includes the objects that user arenas
+	are too small for. It helps to find buffer overflows, such as those caused by C
+	code writing to Go memory, at the cost of an extra page per large object. On
+	systems whose memory pages are larger than 8 kB, only the pattern is used.
+
 	harddecommit: setting harddecommit=1 causes memory that is returned to the OS to
 	also have protections removed on it. This is the only mode of operation on Windows,
 	but is helpful in debugging scavenger-related issues on other platforms. Currently,
 	only supported on Linux.
 
+	heaprandomize: setting heaprandomize=1 randomizes the layout of the heap, to make
+	it harder for an attacker who can corrupt memory to predict where objects are. The
+	heap starts at a random address, and small objects are allocated in a random order
+	within each span rather than in address order. This costs a few percent in
+	allocation throughput and some locality of reference. The start of the heap is only
+	randomized on 64-bit systems, and not on iOS or AIX or with the race detector. The
+	setting only takes effect at program start.
+
+	heaptypes: setting heaptypes=1 causes the runtime to count the live heap objects
+	of each type and the memory they occupy, which runtime/debug.ReadHeapTypeStats
+	reports. This slows down allocation, uses extra memory for each span of the heap,
+	and disables the combining of small allocations into blocks, so it is meant for
+	finding out what occupies the heap rather than for routine use. The setting only
+	takes effect at program start.
+
 	inittrace: setting inittrace=1 causes the runtime to emit a single line to standard
 	error for each package with init work, summarizing the execution time and memory
 	allocation. No information is printed for inits executed as part of plugin loading
@@ -136,12 +260,39 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	drop only when the OS is under memory pressure. On the BSDs and
 	Illumos/Solaris, setting madvdontneed=1 will use MADV_DONTNEED instead
 	of MADV_FREE. This is less efficient, but causes RSS numbers to drop
-	more quickly.
+	more quickly. The setting can be changed at run time with
+	runtime/debug.SetScavengerPolicy.
+
+	mempressure: setting mempressure=1 causes the runtime to watch for memory pressure
+	and to respond by running a garbage collection, and when the pressure is critical,
+	by also returning as much memory as possible to the operating system. On Linux,
+	the runtime reads the memory.events and memory.pressure files of the program's
+	cgroup v2, or /proc/pressure/memory; on macOS, it reads the kernel's memory
+	pressure level. The runtime checks once a second. Other systems are not supported.
+	See also runtime/debug.NotifyMemoryPressure.
 
 	memprofilerate: setting memprofilerate=X will update the value of runtime.MemProfileRate.
 	When set to 0 memory profiling is disabled.  Refer to the description of
 	MemProfileRate for the default value.
 
+	memprofiletype: setting memprofiletype=1 causes the memory profile to record
+	the type of the objects allocated at each sampled allocation, in addition to
+	their stack. Allocations of different types at the same stack, such as those
+	made through a generic function or a pool, are then profiled separately, and
+	runtime/pprof labels them with their type. This makes sampled allocations a
+	little more expensive. The setting can be changed at run time with os.Setenv.
+
+	netpolluring: setting netpolluring=1 makes the network poller use an io_uring instead
+	of epoll on Linux, which needs Linux 5.19 or later. If the io_uring can't be set up,
+	the poller uses epoll. Other systems ignore the setting.
+
+	nontemporal: nontemporal=4194304 (the default) causes the runtime to clear and copy
+	the memory of allocations of at least 4 MiB with non-temporal stores, which bypass
+	the CPU caches, so that large allocations and the reuse of user arena chunks don't
+	evict data that the program is using. Setting nontemporal=N changes the threshold
+	to N bytes, and nontemporal=0 disables non-temporal stores. Non-temporal stores
+	are only used on amd64 and arm64.
+
 	profstackdepth: profstackdepth=128 (the default) will set the maximum stack
 	depth used by all pprof profilers except for the CPU profiler to 128 frames.
 	Stack traces that exceed this limit will be truncated to the limit starting
@@ -159,6 +310,14 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	panicnil: setting panicnil=1 disables the runtime error when calling panic with nil
 	interface value or an untyped nil.
 
+	quarantine: setting quarantine=N causes the garbage collector to keep small
+	objects that have become unreachable for N more GC cycles before reusing their
+	memory. During that time the objects are filled with a poison pattern, and the
+	program crashes if one is written to, printing the stack that allocated it. Such
+	writes go through pointers the garbage collector cannot see, such as pointers
+	stored as uintptr values or held by C code. This setting also sets
+	MemProfileRate to 1, which makes allocation considerably slower.
+
 	runtimecontentionstacks: setting runtimecontentionstacks=1 enables inclusion of call stacks
 	related to contention on runtime-internal locks in the "mutex" profile, subject to the
 	MutexProfileFraction setting. When runtimecontentionstacks=0, contention on
@@ -200,6 +359,15 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	schedtrace: setting schedtrace=X causes the scheduler to emit a single line to standard
 	error every X milliseconds, summarizing the scheduler state.
 
+	sizeclasses: setting sizeclasses=fine or sizeclasses=pow2 replaces the size classes
+	that the allocator rounds small objects up to. With fine, every multiple of 8 bytes
+	up to 256 bytes is a size class, which reduces the memory wasted by small objects
+	whose sizes fall between the default classes; to keep the number of classes the same,
+	some of the classes above 256 bytes are merged into the next larger class. With pow2,
+	objects are only allocated in the power-of-two size classes, which makes their sizes
+	predictable at the cost of more wasted memory. The default is sizeclasses=default.
+	The setting only takes effect at program start, and only on Unix-like systems.
+
 	tracebackancestors: setting tracebackancestors=N extends tracebacks with the stacks at
 	which goroutines were created, where N limits the number of ancestor goroutines to
 	report. This also extends the information returned by runtime.Stack.
@@ -217,6 +385,10 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	tracecheckstackownership: setting tracecheckstackownership=1 enables a debug check in the
 	execution tracer to double-check stack ownership before taking a stack trace.
 
+	updatemaxprocs: setting updatemaxprocs=0 keeps the runtime from updating the
+	default GOMAXPROCS when the CPU bandwidth limit of the program's Linux cgroup
+	changes.
+
 	asyncpreemptoff: asyncpreemptoff=1 disables signal-based
 	asynchronous goroutine preemption. This makes some loops
 	non-preemptible for long periods, which may delay GC and
@@ -231,7 +403,10 @@ The GOMAXPROCS variable limits the number of operating system threads that
 can execute user-level Go code simultaneously. There is no limit to the number of threads
 that can be blocked in system calls on behalf of Go code; those do not count against
 the GOMAXPROCS limit. This package's [GOMAXPROCS] function queries and changes
-the limit.
+the limit. If GOMAXPROCS is unset, the limit defaults to the number of CPUs the
+program may use. On Linux, that's the lower of [NumCPU] and the CPU bandwidth
+limit of the program's cgroup, rounded up and at least 2, and the runtime updates
+it when the cgroup's limit changes, until the program calls [GOMAXPROCS].
 
 The GORACE variable configures the race detector, for programs built using -race.
 See the [Race Detector article] for details.
@@ -5,6 +5,7 @@
 package runtime_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/bits"
 	"math/rand"
@@ -207,6 +208,43 @@ func TestGcZombieReporting(t *testing.T) {
 	}
 }
 
+func TestHeapTypes(t *testing.T) {
+	got := runTestProg(t, "testprog", "HeapTypes", "GODEBUG=heaptypes=1")
+	if want := "OK\n"; got != want {
+		t.Fatalf("expected %q, but got %q", want, got)
+	}
+}
+
+func TestGCTraceJSON(t *testing.T) {
+	got := runTestProg(t, "testprog", "GCTraceJSON", "GODEBUG=gctracejson=1")
+	var forced int
+	for _, line := range strings.Split(got, "\n") {
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var rec struct {
+			GC            *uint32 `json:"gc"`
+			CPUFraction   float64 `json:"gc_cpu_fraction"`
+			Trigger       string  `json:"trigger"`
+			HeapGoalBytes uint64  `json:"heap_goal_bytes"`
+			PauseNS       int64   `json:"pause_ns"`
+			Procs         int     `json:"procs"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad gctracejson line %q: %v", line, err)
+		}
+		if rec.GC == nil || rec.HeapGoalBytes == 0 || rec.PauseNS <= 0 || rec.Procs <= 0 || rec.CPUFraction < 0 || rec.CPUFraction > 1 {
+			t.Errorf("incomplete gctracejson line %q", line)
+		}
+		if rec.Trigger == "forced" {
+			forced++
+		}
+	}
+	if forced < 2 {
+		t.Errorf("got %d forced GCs in gctracejson output, want at least 2:\n%s", forced, got)
+	}
+}
+
 func TestGCTestMoveStackOnNextCall(t *testing.T) {
 	t.Parallel()
 	var onStack int
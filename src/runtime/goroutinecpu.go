@@ -0,0 +1,129 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Per-goroutine CPU time.
+//
+// With GODEBUG=goroutinecputime=1, each goroutine accumulates the time
+// it spends in _Grunning, as measured by nanotime on its transitions in
+// and out of that status. That's the time it spends running on an M,
+// excluding the time in syscalls and cgo calls, which is in _Gsyscall,
+// and including the time the OS deschedules the M, which the runtime
+// can't see.
+//
+// Reading the clock on every transition into and out of _Grunning
+// slows down the scheduler measurably, so accounting is off by default.
+// While it's off, a goroutine's cpuStart is 0, and its cpuTime doesn't
+// grow.
+//
+// The goroutine's M updates gp.cpuStart and gp.cpuTime. Other
+// goroutines read them under the gp.cpuSeq sequence lock.
+
+package runtime
+
+// A GoroutineHandle identifies a goroutine for the functions that report
+// on other goroutines. The zero GoroutineHandle identifies no goroutine.
+//
+// A GoroutineHandle doesn't keep its goroutine alive, and the functions
+// that take one report whether the goroutine still exists.
+type GoroutineHandle struct {
+	gp   *g
+	goid uint64
+}
+
+// CurrentGoroutine returns a handle for the calling goroutine.
+func CurrentGoroutine() GoroutineHandle {
+	gp := getg()
+	return GoroutineHandle{gp: gp, goid: gp.goid}
+}
+
+// GoroutineCPUTime returns the time, in nanoseconds, that the calling
+// goroutine has spent running since it was created.
+//
+// This is the time the goroutine has been scheduled on an OS thread,
+// excluding the time spent in system calls and calls to C. The
+// operating system may deschedule the thread in that time, so it can
+// exceed the CPU time the operating system accounts to the thread.
+//
+// Measuring this time has a cost on every goroutine switch, so the
+// runtime only measures it with GODEBUG=goroutinecputime=1. The setting
+// can be changed at run time with os.Setenv, and time spent running
+// while it's off is not counted. With the setting off from the start,
+// GoroutineCPUTime returns 0.
+func GoroutineCPUTime() int64 {
+	mp := acquirem()
+	gp := mp.curg
+	t := gp.cpuTime
+	if gp.cpuStart != 0 {
+		t += nanotime() - gp.cpuStart
+	}
+	releasem(mp)
+	return t
+}
+
+// GoroutineCPUTimeOf is like GoroutineCPUTime, but returns the running
+// time of the goroutine identified by h. It reports false if that
+// goroutine has exited.
+func GoroutineCPUTimeOf(h GoroutineHandle) (int64, bool) {
+	gp := h.gp
+	if gp == nil {
+		return 0, false
+	}
+	var t int64
+	for {
+		seq := gp.cpuSeq.Load()
+		if seq%2 != 0 {
+			// gp's M is updating the times.
+			osyield()
+			continue
+		}
+		t = gp.cpuTime
+		start := gp.cpuStart
+		if gp.cpuSeq.Load() != seq {
+			continue
+		}
+		if start != 0 {
+			t += max(nanotime()-start, 0)
+		}
+		break
+	}
+	// newproc1 sets a reused G's goid before it takes it out of _Gdead,
+	// so if gp has exited, we'll see either status or goid change.
+	if readgstatus(gp) == _Gdead || gp.goid != h.goid {
+		return 0, false
+	}
+	return t, true
+}
+
+// cpuTimeNow returns the current nanotime if GODEBUG=goroutinecputime=1,
+// and 0 otherwise, for accountCPUTime.
+//
+//go:nosplit
+func cpuTimeNow() int64 {
+	if debug.goroutinecputime.Load() == 0 {
+		return 0
+	}
+	return nanotime()
+}
+
+// accountCPUTime updates gp's running time for its transition, at time
+// now, from oldval to newval, one of which is _Grunning. now is 0 if
+// accounting is off, as returned by cpuTimeNow. It must be called on the
+// M that gp is running on, or starting to run on.
+//
+//go:nosplit
+func (gp *g) accountCPUTime(oldval, newval uint32, now int64) {
+	if now == 0 && gp.cpuStart == 0 {
+		// Accounting is, and was, off.
+		return
+	}
+	gp.cpuSeq.Add(1)
+	if oldval == _Grunning && gp.cpuStart != 0 && now != 0 {
+		gp.cpuTime += now - gp.cpuStart
+	}
+	gp.cpuStart = 0
+	if newval == _Grunning {
+		gp.cpuStart = now
+	}
+	gp.cpuSeq.Add(1)
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+// A GoroutineState is the scheduling state of a goroutine.
+type GoroutineState uint8
+
+const (
+	// GoroutineRunnable goroutines are ready to run, and waiting for
+	// the scheduler to run them.
+	GoroutineRunnable GoroutineState = iota
+
+	// GoroutineRunning goroutines are running on an OS thread.
+	GoroutineRunning
+
+	// GoroutineSyscall goroutines are in a system call or a call to C.
+	GoroutineSyscall
+
+	// GoroutineWaiting goroutines are blocked, for example on a channel
+	// operation, a lock, or I/O.
+	GoroutineWaiting
+)
+
+// String returns the name of the state, as it appears in stack traces.
+func (s GoroutineState) String() string {
+	switch s {
+	case GoroutineRunnable:
+		return "runnable"
+	case GoroutineRunning:
+		return "running"
+	case GoroutineSyscall:
+		return "syscall"
+	case GoroutineWaiting:
+		return "waiting"
+	}
+	return "unknown"
+}
+
+// A GoroutineInfo describes a goroutine.
+type GoroutineInfo struct {
+	// ID is the goroutine's ID, as shown in stack traces.
+	ID uint64
+
+	// ParentID is the ID of the goroutine that created this one, or 0
+	// for the main goroutine.
+	ParentID uint64
+
+	// Handle identifies the goroutine for the functions that report on
+	// other goroutines, such as GoroutineCPUTimeOf.
+	Handle GoroutineHandle
+
+	State GoroutineState
+
+	// WaitReason is what a waiting goroutine is waiting for, as shown
+	// in stack traces, such as "chan receive" or "sync.Mutex.Lock".
+	// It's empty unless State is GoroutineWaiting.
+	WaitReason string
+
+	// BlockedSince is the Unix time, in nanoseconds, since which a
+	// waiting goroutine, or one in a system call, has been blocked, or
+	// 0 if it isn't known.
+	//
+	// The runtime doesn't time every block. It records the time at
+	// which it first finds a goroutine blocked, when the garbage
+	// collector starts or when Goroutines is called, so a goroutine
+	// may have been blocked for longer.
+	BlockedSince int64
+
+	// CreationPC is the program counter of the go statement that
+	// created the goroutine. It's a return address, like those
+	// returned by Callers, for use with CallersFrames.
+	CreationPC uintptr
+
+	// StartPC is the entry program counter of the goroutine's function.
+	StartPC uintptr
+
+	// Labels are the goroutine's profiler labels, as set with
+	// runtime/pprof.SetGoroutineLabels, or nil if it has none.
+	Labels map[string]string
+}
+
+// Goroutines returns descriptions of the program's goroutines, like
+// the stack traces reported by Stack with all set, including those of
+// the calling goroutine but excluding those of the runtime's own
+// goroutines.
+//
+// Goroutines doesn't stop the world, so the goroutines may be running,
+// and change state, while they're being described: each description
+// is consistent, but they aren't taken at exactly the same time.
+// Goroutines that start while Goroutines runs may be missing.
+func Goroutines() []GoroutineInfo {
+	n := int(gcount()) + 16
+	for {
+		infos := make([]GoroutineInfo, n)
+		labels := make([]unsafe.Pointer, n)
+		var count int
+		systemstack(func() {
+			count = goroutineInfos(infos, labels)
+		})
+		if count > n {
+			n = count + count/4
+			continue
+		}
+		if raceenabled {
+			// Match racereleasemerge in runtime_setProfLabel.
+			raceacquire(unsafe.Pointer(&labelSync))
+		}
+		for i, p := range labels[:count] {
+			if p != nil {
+				// The labels are a runtime/pprof.labelMap, which is
+				// never modified once it's set, so it can be copied
+				// while its goroutine runs.
+				src := *(*map[string]string)(p)
+				m := make(map[string]string, len(src))
+				for k, v := range src {
+					m[k] = v
+				}
+				infos[i].Labels = m
+			}
+		}
+		return infos[:count]
+	}
+}
+
+// goroutineInfos fills in infos and labels with the descriptions and
+// profiler labels of the program's goroutines, and returns how many
+// there are. If that's more than len(infos), it fills in none.
+//
+// It runs on the system stack, so that the calling goroutine can't be
+// preempted while holding the _Gscan bit of a waiting goroutine.
+//
+//go:systemstack
+func goroutineInfos(infos []GoroutineInfo, labels []unsafe.Pointer) int {
+	sec, nsec, now := time_now()
+	wall := sec*1e9 + int64(nsec)
+	n := 0
+	forEachGRace(func(gp *g) {
+		if readgstatus(gp) == _Gdead || isSystemGoroutine(gp, false) {
+			return
+		}
+		if n >= len(infos) {
+			n++
+			return
+		}
+		if goroutineInfo(gp, &infos[n], &labels[n], now, wall) {
+			n++
+		}
+	})
+	return n
+}
+
+// goroutineInfo fills in *info and *labels for gp, given the current
+// nanotime and Unix time, and reports whether gp is still alive.
+func goroutineInfo(gp *g, info *GoroutineInfo, labels *unsafe.Pointer, now, wall int64) bool {
+	goid := gp.goid
+	*info = GoroutineInfo{
+		ID:         goid,
+		ParentID:   gp.parentGoid,
+		Handle:     GoroutineHandle{gp: gp, goid: goid},
+		CreationPC: gp.gopc,
+		StartPC:    gp.startpc,
+	}
+	*labels = gp.labels
+
+	var since int64
+	for {
+		switch s := readgstatus(gp); s &^ _Gscan {
+		case _Gdead:
+			return false
+		case _Grunnable, _Gpreempted:
+			info.State = GoroutineRunnable
+		case _Grunning, _Gcopystack:
+			info.State = GoroutineRunning
+		case _Gsyscall:
+			info.State = GoroutineSyscall
+			since = gp.waitsince
+		case _Gwaiting:
+			// Hold the _Gscan bit, like the garbage collector, to keep
+			// the goroutine waiting while waitsince is set and the
+			// wait reason is read.
+			if s&_Gscan != 0 || !castogscanstatus(gp, _Gwaiting, _Gscanwaiting) {
+				continue
+			}
+			info.State = GoroutineWaiting
+			if gp.waitsince == 0 {
+				gp.waitsince = now
+			}
+			since = gp.waitsince
+			info.WaitReason = gp.waitreason.String()
+			casfrom_Gscanstatus(gp, _Gscanwaiting, _Gwaiting)
+		}
+		break
+	}
+	if since != 0 {
+		info.BlockedSince = wall - (now - since)
+	}
+
+	// gp may have exited and been reused by a new goroutine while it
+	// was being described.
+	return readgstatus(gp) != _Gdead && gp.goid == goid
+}
+
+// GoroutineCounts counts the program's goroutines by state.
+type GoroutineCounts struct {
+	Runnable int
+	Running  int
+	Syscall  int
+	Waiting  int
+
+	// WaitReasons counts the waiting goroutines by what they're
+	// waiting for, as reported in GoroutineInfo.WaitReason.
+	WaitReasons map[string]int
+}
+
+// CountGoroutines counts the program's goroutines in each state,
+// excluding the runtime's own goroutines, like Goroutines but without
+// describing each goroutine. It's much cheaper than Goroutines, but it
+// still looks at every goroutine.
+//
+// Like Goroutines, CountGoroutines doesn't stop the world, so the counts
+// aren't taken at exactly the same time, and their total may differ
+// from NumGoroutine.
+func CountGoroutines() GoroutineCounts {
+	var counts goroutineCounts
+	var reasons [len(waitReasonStrings)]uint64
+	countGoroutines(&counts, &reasons)
+	c := GoroutineCounts{
+		Runnable:    int(counts.runnable),
+		Running:     int(counts.running),
+		Syscall:     int(counts.syscall),
+		Waiting:     int(counts.waiting),
+		WaitReasons: make(map[string]int),
+	}
+	for w, n := range reasons {
+		if n != 0 {
+			c.WaitReasons[waitReason(w).String()] += int(n)
+		}
+	}
+	return c
+}
+
+// goroutineCounts counts goroutines by state.
+type goroutineCounts struct {
+	runnable, running, syscall, waiting uint64
+}
+
+// countGoroutines adds the program's goroutines, other than system
+// goroutines, to counts, and, if reasons isn't nil, the waiting ones to
+// reasons, by wait reason. The wait reasons are read without
+// holding the goroutines' _Gscan bits, so they may be stale.
+func countGoroutines(counts *goroutineCounts, reasons *[len(waitReasonStrings)]uint64) {
+	forEachGRace(func(gp *g) {
+		s := readgstatus(gp) &^ _Gscan
+		if s == _Gdead || isSystemGoroutine(gp, false) {
+			return
+		}
+		switch s {
+		case _Grunnable, _Gpreempted:
+			counts.runnable++
+		case _Grunning, _Gcopystack:
+			counts.running++
+		case _Gsyscall:
+			counts.syscall++
+		case _Gwaiting:
+			counts.waiting++
+			w := gp.waitreason
+			if reasons != nil && int(w) < len(reasons) {
+				reasons[w]++
+			}
+		}
+	})
+}
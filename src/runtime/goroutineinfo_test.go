@@ -0,0 +1,114 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGoroutines(t *testing.T) {
+	ready := make(chan bool)
+	block := make(chan bool)
+	start := time.Now()
+	go func() {
+		pprof.Do(context.Background(), pprof.Labels("k", "v"), func(context.Context) {
+			ready <- true
+			<-block
+		})
+	}()
+	<-ready
+	defer close(block)
+
+	self := runtime.CurrentGoroutine()
+	var me, blocked *runtime.GoroutineInfo
+	// The goroutine may not have blocked yet.
+	for range 100 {
+		infos := runtime.Goroutines()
+		me, blocked = nil, nil
+		for i := range infos {
+			info := &infos[i]
+			if info.Handle == self {
+				me = info
+			}
+			if info.Labels["k"] == "v" {
+				blocked = info
+			}
+		}
+		if blocked != nil && blocked.State == runtime.GoroutineWaiting {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if me == nil {
+		t.Fatalf("calling goroutine not found")
+	}
+	if me.State != runtime.GoroutineRunning {
+		t.Errorf("calling goroutine is %v, want running", me.State)
+	}
+	if blocked == nil {
+		t.Fatalf("goroutine with labels not found")
+	}
+	if blocked.State != runtime.GoroutineWaiting || blocked.WaitReason != "chan receive" {
+		t.Errorf("blocked goroutine is %v (%q), want waiting (%q)", blocked.State, blocked.WaitReason, "chan receive")
+	}
+	if blocked.ParentID != me.ID {
+		t.Errorf("blocked goroutine's parent is %d, want %d", blocked.ParentID, me.ID)
+	}
+	if since := time.Unix(0, blocked.BlockedSince); since.Before(start.Add(-time.Second)) || since.After(time.Now().Add(time.Second)) {
+		t.Errorf("blocked goroutine blocked since %v, want between %v and now", since, start)
+	}
+	frames := runtime.CallersFrames([]uintptr{blocked.CreationPC})
+	if f, _ := frames.Next(); !strings.HasSuffix(f.Function, "TestGoroutines") {
+		t.Errorf("blocked goroutine created in %s, want TestGoroutines", f.Function)
+	}
+	if f := runtime.FuncForPC(blocked.StartPC); f == nil || !strings.HasPrefix(f.Name(), "runtime_test.TestGoroutines.func") {
+		t.Errorf("blocked goroutine starts at %v, want TestGoroutines.func", f)
+	}
+}
+
+func TestCountGoroutines(t *testing.T) {
+	const n = 10
+	var ready sync.WaitGroup
+	block := make(chan bool)
+	ready.Add(n)
+	for range n {
+		go func() {
+			ready.Done()
+			<-block
+		}()
+	}
+	ready.Wait()
+	defer close(block)
+
+	// The goroutines may not have blocked yet.
+	var c runtime.GoroutineCounts
+	for range 100 {
+		c = runtime.CountGoroutines()
+		if c.WaitReasons["chan receive"] >= n {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := c.WaitReasons["chan receive"]; got < n {
+		t.Errorf("%d goroutines waiting on chan receive, want at least %d", got, n)
+	}
+	if c.Running < 1 {
+		t.Errorf("%d goroutines running, want at least 1", c.Running)
+	}
+	total := 0
+	for _, count := range c.WaitReasons {
+		total += count
+	}
+	if total != c.Waiting {
+		t.Errorf("wait reasons add up to %d goroutines, want %d waiting", total, c.Waiting)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	"internal/stringslite"
+	_ "unsafe" // for go:linkname
+)
+
+// Limit on the number of goroutines, set by runtime/debug.SetMaxGoroutines.
+//
+// newproc checks the limit, against gcount, before starting a goroutine,
+// so concurrent go statements may briefly exceed it. A go statement that
+// blocks at the limit parks on goroutineLimit.waiting, and gdestroy wakes
+// one waiter for each goroutine that exits.
+var goroutineLimit struct {
+	max    atomic.Int32                         // 0 for no limit
+	policy atomic.Pointer[func(pc uintptr) int] // nil for goroutineLimitPanic
+
+	lock    mutex
+	waiting gList        // go statements blocked at the limit
+	nwait   atomic.Int32 // length of waiting
+
+	// exits counts goroutines that exit while there's a limit. Its
+	// atomic increment orders an exit, which gcount sees, before the
+	// check for waiters, as the increment of nwait orders a waiter
+	// before its check of gcount, so no wakeup is missed.
+	exits atomic.Uint32
+}
+
+// Actions returned by the goroutine limit policy. They must match
+// runtime/debug.GoroutineLimitAction.
+const (
+	goroutineLimitPanic = 0
+	goroutineLimitBlock = 1
+	goroutineLimitAllow = 2
+)
+
+//go:linkname setMaxGoroutines runtime/debug.setMaxGoroutines
+func setMaxGoroutines(n int, policy func(pc uintptr) int) int {
+	var p *func(pc uintptr) int
+	if policy != nil {
+		p = new(func(pc uintptr) int)
+		*p = policy
+	}
+	n = max(n, 0)
+	if n > 0x7fffffff { // MaxInt32
+		n = 0x7fffffff
+	}
+
+	lock(&goroutineLimit.lock)
+	old := goroutineLimit.max.Load()
+	goroutineLimit.policy.Store(p)
+	goroutineLimit.max.Store(int32(n))
+	// Let blocked go statements check the new limit.
+	waiting := goroutineLimit.waiting
+	goroutineLimit.waiting = gList{}
+	goroutineLimit.nwait.Store(0)
+	unlock(&goroutineLimit.lock)
+
+	for !waiting.empty() {
+		goready(waiting.pop(), 0)
+	}
+	return int(old)
+}
+
+// checkGoroutineLimit applies the goroutine limit to the go statement at
+// pc, which is about to start a goroutine. It returns if the goroutine
+// may start, possibly after blocking, or panics.
+func checkGoroutineLimit(pc uintptr) {
+	limit := goroutineLimit.max.Load()
+	if limit == 0 || gcount() < limit {
+		return
+	}
+	gp := getg()
+	if gp != gp.m.curg || stringslite.HasPrefix(funcname(findfunc(pc)), "runtime.") {
+		// The runtime's own goroutines aren't limited.
+		return
+	}
+
+	action := goroutineLimitPanic
+	if policy := goroutineLimit.policy.Load(); policy != nil {
+		action = (*policy)(pc)
+	}
+	switch action {
+	case goroutineLimitAllow:
+	case goroutineLimitBlock:
+		goroutineLimitWait()
+	default:
+		panic(plainError("runtime: goroutine limit exceeded"))
+	}
+}
+
+// goroutineLimitWait blocks until there are fewer goroutines than the
+// limit, or there is no limit.
+func goroutineLimitWait() {
+	for {
+		lock(&goroutineLimit.lock)
+		goroutineLimit.nwait.Add(1)
+		if limit := goroutineLimit.max.Load(); limit == 0 || gcount() < limit {
+			goroutineLimit.nwait.Add(-1)
+			unlock(&goroutineLimit.lock)
+			return
+		}
+		goroutineLimit.waiting.push(getg())
+		goparkunlock(&goroutineLimit.lock, waitReasonGoroutineLimit, traceBlockSync, 1)
+	}
+}
+
+// goroutineLimitExit wakes a go statement blocked at the goroutine
+// limit, if there is one, now that a goroutine has exited.
+func goroutineLimitExit() {
+	goroutineLimit.exits.Add(1)
+	if goroutineLimit.nwait.Load() == 0 {
+		return
+	}
+	lock(&goroutineLimit.lock)
+	var gp *g
+	if !goroutineLimit.waiting.empty() {
+		gp = goroutineLimit.waiting.pop()
+		goroutineLimit.nwait.Add(-1)
+	}
+	unlock(&goroutineLimit.lock)
+	if gp != nil {
+		ready(gp, 0, true)
+	}
+}
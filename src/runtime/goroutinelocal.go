@@ -0,0 +1,94 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+// A GoroutineLocal holds a value of type T for each goroutine.
+//
+// A goroutine starts with the values its creator had when it executed
+// the go statement, like it does with pprof labels. After that, the two
+// goroutines' values are independent: setting a value in one goroutine
+// doesn't change it in any other.
+//
+// Goroutine-local values are for carrying request-scoped data through
+// code that can't pass a context.Context, such as callbacks from
+// third-party libraries. Where a context can be passed, it's the better
+// choice, since it makes the data flow visible.
+type GoroutineLocal[T any] struct {
+	// Keep the struct from being zero-sized, so that every
+	// GoroutineLocal has a distinct address to use as its key.
+	_ byte
+}
+
+// NewGoroutineLocal returns a new GoroutineLocal, which has no value in
+// any goroutine.
+func NewGoroutineLocal[T any]() *GoroutineLocal[T] {
+	return new(GoroutineLocal[T])
+}
+
+// Get returns the calling goroutine's value of l, and reports whether
+// it has one.
+func (l *GoroutineLocal[T]) Get() (T, bool) {
+	if v, ok := getGoroutineLocal(unsafe.Pointer(l)); ok {
+		return v.(T), true
+	}
+	var zero T
+	return zero, false
+}
+
+// Set sets the calling goroutine's value of l to v. Goroutines it
+// creates afterwards start with that value.
+func (l *GoroutineLocal[T]) Set(v T) {
+	setGoroutineLocal(unsafe.Pointer(l), v, true)
+}
+
+// Delete removes the calling goroutine's value of l.
+func (l *GoroutineLocal[T]) Delete() {
+	setGoroutineLocal(unsafe.Pointer(l), nil, false)
+}
+
+// goroutineLocal is a list of goroutine-local values. A list is never
+// modified once it's been stored in g.locals, so that a goroutine can
+// share its list with the goroutines it creates.
+type goroutineLocal struct {
+	key   unsafe.Pointer // the *GoroutineLocal
+	value any
+	next  *goroutineLocal
+}
+
+func getGoroutineLocal(key unsafe.Pointer) (any, bool) {
+	for l := getg().locals; l != nil; l = l.next {
+		if l.key == key {
+			return l.value, true
+		}
+	}
+	return nil, false
+}
+
+// setGoroutineLocal sets the calling goroutine's value for key to
+// value, or deletes it if set is false.
+func setGoroutineLocal(key unsafe.Pointer, value any, set bool) {
+	gp := getg()
+	head := gp.locals
+	if _, ok := getGoroutineLocal(key); ok {
+		// Copy the values before key's, and share the ones after it.
+		var list *goroutineLocal
+		tail := &list
+		for l := head; ; l = l.next {
+			if l.key == key {
+				*tail = l.next
+				break
+			}
+			*tail = &goroutineLocal{key: l.key, value: l.value}
+			tail = &(*tail).next
+		}
+		head = list
+	}
+	if set {
+		head = &goroutineLocal{key: key, value: value, next: head}
+	}
+	gp.locals = head
+}
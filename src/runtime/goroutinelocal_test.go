@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGoroutineLocal(t *testing.T) {
+	a := runtime.NewGoroutineLocal[string]()
+	b := runtime.NewGoroutineLocal[int]()
+	c := runtime.NewGoroutineLocal[int]()
+
+	if v, ok := a.Get(); ok {
+		t.Fatalf("new GoroutineLocal has value %q", v)
+	}
+	a.Set("x")
+	b.Set(1)
+	c.Set(2)
+	b.Set(3)
+	if v, _ := a.Get(); v != "x" {
+		t.Errorf("a = %q, want %q", v, "x")
+	}
+	if v, _ := b.Get(); v != 3 {
+		t.Errorf("b = %d, want 3", v)
+	}
+	if v, _ := c.Get(); v != 2 {
+		t.Errorf("c = %d, want 2", v)
+	}
+
+	// A new goroutine inherits the values, and its changes don't
+	// affect its creator.
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		if v, _ := a.Get(); v != "x" {
+			t.Errorf("new goroutine has a = %q, want %q", v, "x")
+		}
+		if v, _ := b.Get(); v != 3 {
+			t.Errorf("new goroutine has b = %d, want 3", v)
+		}
+		a.Set("y")
+		c.Delete()
+	}()
+	<-done
+	if v, _ := a.Get(); v != "x" {
+		t.Errorf("a = %q after the new goroutine set it, want %q", v, "x")
+	}
+	if _, ok := c.Get(); !ok {
+		t.Errorf("c deleted after the new goroutine deleted it")
+	}
+
+	b.Delete()
+	if _, ok := b.Get(); ok {
+		t.Errorf("b has a value after Delete")
+	}
+	if v, _ := c.Get(); v != 2 {
+		t.Errorf("c = %d after deleting b, want 2", v)
+	}
+	a.Delete()
+	c.Delete()
+}
+
+func BenchmarkGoroutineLocalGet(b *testing.B) {
+	keys := make([]*runtime.GoroutineLocal[int], 4)
+	for i := range keys {
+		keys[i] = runtime.NewGoroutineLocal[int]()
+		keys[i].Set(i)
+		defer keys[i].Delete()
+	}
+	b.ResetTimer()
+	for range b.N {
+		keys[0].Get()
+	}
+}
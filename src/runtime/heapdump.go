@@ -6,8 +6,8 @@
 // objects in the heap plus additional info (roots, threads,
 // finalizers, etc.) to a file.
 
-// The format of the dumped file is described at
-// https://golang.org/s/go15heapdump.
+// The format of the dumped file is described in the documentation
+// for package runtime/heapdump, which can also read it.
 
 package runtime
 
@@ -190,6 +190,14 @@ func dumptype(t *_type) {
 	}
 	b.t[0] = t
 
+	// Field types must be dumped first, since a record can't
+	// contain other records.
+	if t.Kind_&abi.KindMask == abi.Struct {
+		for _, f := range (*structtype)(unsafe.Pointer(t)).Fields {
+			dumptype(f.Typ)
+		}
+	}
+
 	// dump the type
 	dumpint(tagType)
 	dumpint(uint64(uintptr(unsafe.Pointer(t))))
@@ -206,12 +214,70 @@ func dumptype(t *_type) {
 		dwrite(unsafe.Pointer(unsafe.StringData(name)), uintptr(len(name)))
 	}
 	dumpbool(t.Kind_&abi.KindDirectIface == 0 || t.Pointers())
+	dumpint(uint64(t.Kind_ & abi.KindMask))
+	dumptypefields(t)
+}
+
+// dump the offsets of the pointers in a value of type t, and the
+// names, offsets and types of its fields if t is a struct.
+func dumptypefields(t *_type) {
+	if t.Kind_&abi.KindGCProg == 0 {
+		// GC programs aren't expanded. Objects of such types
+		// still record their pointers in their own field lists.
+		for i := uintptr(0); i < t.PtrBytes/goarch.PtrSize; i++ {
+			if *addb(t.GCData, i/8)>>(i%8)&1 != 0 {
+				dumpint(fieldKindPtr)
+				dumpint(uint64(i * goarch.PtrSize))
+			}
+		}
+	}
+	dumpint(fieldKindEol)
+
+	if t.Kind_&abi.KindMask != abi.Struct {
+		dumpint(0)
+		return
+	}
+	st := (*structtype)(unsafe.Pointer(t))
+	dumpint(uint64(len(st.Fields)))
+	for _, f := range st.Fields {
+		dumpstr(f.Name.Name())
+		dumpint(uint64(f.Offset))
+		dumpint(uint64(uintptr(unsafe.Pointer(f.Typ))))
+	}
+}
+
+// heapobjtype returns the type of the object at p in span s and the
+// offset of its first value of that type, if the heap records it.
+// Only objects with a malloc header, which are those that contain
+// pointers and are too large to use the span's heap bits, have one.
+func heapobjtype(s *mspan, p uintptr) (*_type, uintptr) {
+	if s.spanclass.noscan() || heapBitsInSpan(s.elemsize) || s.isUserArenaChunk {
+		// User arena chunks have a fake type spanning the chunk.
+		return nil, 0
+	}
+	var typ *_type
+	var off uintptr
+	if s.spanclass.sizeclass() != 0 {
+		typ = *(**_type)(unsafe.Pointer(p))
+		off = mallocHeaderSize
+	} else {
+		typ = s.largeType
+	}
+	if typ == nil || typ.TFlag&abi.TFlagUnrolledBitmap != 0 {
+		// No type, or a type synthesized from a GC program,
+		// which has no name.
+		return nil, 0
+	}
+	return typ, off
 }
 
 // dump an object.
-func dumpobj(obj unsafe.Pointer, size uintptr, bv bitvector) {
+func dumpobj(obj unsafe.Pointer, size uintptr, typ *_type, off uintptr, bv bitvector) {
+	dumptype(typ)
 	dumpint(tagObject)
 	dumpint(uint64(uintptr(obj)))
+	dumpint(uint64(uintptr(unsafe.Pointer(typ))))
+	dumpint(uint64(off))
 	dumpmemrange(obj, size)
 	dumpfields(bv)
 }
@@ -251,7 +317,7 @@ func dumpbv(cbv *bitvector, offset uintptr) {
 	}
 }
 
-func dumpframe(s *stkframe, child *childInfo) {
+func dumpframe(gp *g, s *stkframe, child *childInfo) {
 	f := s.fn
 
 	// Figure out what we can about our stack map
@@ -278,6 +344,7 @@ func dumpframe(s *stkframe, child *childInfo) {
 
 	// Dump main body of stack frame.
 	dumpint(tagStackFrame)
+	dumpint(uint64(uintptr(unsafe.Pointer(gp))))       // goroutine owning the stack
 	dumpint(uint64(s.sp))                              // lowest address in frame
 	dumpint(uint64(child.depth))                       // # of frames deep on the stack
 	dumpint(uint64(uintptr(unsafe.Pointer(child.sp)))) // sp of child, or 0 if bottom of stack
@@ -372,7 +439,7 @@ func dumpgoroutine(gp *g) {
 	child.depth = 0
 	var u unwinder
 	for u.initAt(pc, sp, lr, gp, 0); u.valid(); u.next() {
-		dumpframe(&u.frame, &child)
+		dumpframe(gp, &u.frame, &child)
 	}
 
 	// dump defer & panic records
@@ -499,7 +566,8 @@ func dumpobjs() {
 				freemark[j] = false
 				continue
 			}
-			dumpobj(unsafe.Pointer(p), size, makeheapobjbv(p, size))
+			typ, off := heapobjtype(s, p)
+			dumpobj(unsafe.Pointer(p), size, typ, off, makeheapobjbv(p, size))
 		}
 	}
 }
@@ -666,7 +734,7 @@ func dumpmemprof() {
 	}
 }
 
-var dumphdr = []byte("go1.7 heap dump\n")
+var dumphdr = []byte("go heap dump v2\n")
 
 func mdump(m *MemStats) {
 	assertWorldStopped()
@@ -0,0 +1,88 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package heapdump reads heap dumps written by [runtime/debug.WriteHeapDump]
+and [runtime/debug.WriteHeapDumpTo].
+
+A heap dump describes every object in the heap, along with the type
+information the runtime has for them, the stacks of all goroutines,
+and other roots such as global variables and finalizers. It is a
+snapshot taken with all goroutines stopped, so addresses in one record
+are consistent with the contents of every other record.
+
+A [Reader] decodes a dump one record at a time, so that tools can
+process dumps larger than memory. Records refer to each other by the
+addresses of the runtime structures they describe; for example, an
+[Object] refers to its [Type] by the type's address.
+
+# Format
+
+This section documents version 2 of the format. A [Reader] also reads
+dumps that were gzip-compressed as a whole, for example with
+[compress/gzip]; the description below is of the uncompressed stream.
+
+A dump starts with the 16-byte header "go heap dump v2\n", followed by
+a sequence of records and ends with an EOF record. Every record starts
+with a tag that identifies its kind. The rest of the record is a
+sequence of values of these kinds:
+
+  - uint: an unsigned integer encoded as a varint, as written by
+    [encoding/binary.PutUvarint]. Tags are uints too.
+  - bool: a uint that is 0 for false and 1 for true.
+  - string: a uint length, followed by that many bytes.
+  - fields: a list of (kind, offset) uint pairs terminated by a
+    kind of 0. Kind 1 denotes a pointer; kinds 2 and 3, an interface
+    value with and without methods, are reserved. Offsets are
+    relative to the start of the enclosing contents.
+
+The records are, by tag:
+
+	0  EOF:             nothing
+	1  Object:          address uint, type uint, value offset uint,
+	                    contents string, fields
+	2  OtherRoot:       description string, pointer uint
+	3  Type:            address uint, size uint, name string,
+	                    indirect bool, kind uint, fields,
+	                    field count uint, then for each field:
+	                    name string, offset uint, type uint
+	4  Goroutine:       address uint, sp uint, id uint, go pc uint,
+	                    status uint, system bool, background bool,
+	                    wait since uint, wait reason string,
+	                    context uint, M uint, defer uint, panic uint
+	5  StackFrame:      goroutine uint, sp uint, depth uint,
+	                    child sp uint, contents string, entry pc uint,
+	                    pc uint, continuation pc uint, function string,
+	                    fields
+	6  Params:          big-endian bool, pointer size uint,
+	                    heap start uint, heap end uint, GOARCH string,
+	                    Go version string, CPU count uint
+	7  Finalizer:       object uint, funcval uint, function pc uint,
+	                    argument type uint, object type uint
+	8  Itab:            address uint, type uint
+	9  OSThread:        address uint, id uint, OS id uint
+	10 MemStats:        the uint fields of [runtime.MemStats] from Alloc
+	                    through PauseTotalNs, in declaration order,
+	                    then 256 PauseNs uints, then NumGC uint
+	11 QueuedFinalizer: as Finalizer
+	12 Data:            address uint, contents string, fields
+	13 BSS:             address uint, contents string, fields
+	14 Defer:           address uint, goroutine uint, sp uint, pc uint,
+	                    funcval uint, function pc uint, link uint
+	15 Panic:           address uint, goroutine uint, argument type uint,
+	                    argument data uint, unused uint, link uint
+	16 MemProf:         bucket uint, size uint, frame count uint,
+	                    then for each frame: function string,
+	                    file string, line uint;
+	                    then allocs uint, frees uint
+	17 AllocSample:     object uint, bucket uint
+
+A Type record is written before any Object or Type record that refers
+to it. Other records may refer to types that are not in the dump.
+
+Version 2 differs from the earlier "go1.7 heap dump" format by the
+type, value offset, kind and struct field information in Object and
+Type records, and by the goroutine in StackFrame records.
+*/
+package heapdump
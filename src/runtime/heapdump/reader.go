@@ -0,0 +1,521 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package heapdump
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"internal/saferio"
+	"io"
+	"reflect"
+	"runtime"
+)
+
+// header is the header of a version 2 heap dump.
+const header = "go heap dump v2\n"
+
+// Record tags.
+const (
+	tagEOF             = 0
+	tagObject          = 1
+	tagOtherRoot       = 2
+	tagType            = 3
+	tagGoroutine       = 4
+	tagStackFrame      = 5
+	tagParams          = 6
+	tagFinalizer       = 7
+	tagItab            = 8
+	tagOSThread        = 9
+	tagMemStats        = 10
+	tagQueuedFinalizer = 11
+	tagData            = 12
+	tagBSS             = 13
+	tagDefer           = 14
+	tagPanic           = 15
+	tagMemProf         = 16
+	tagAllocSample     = 17
+)
+
+// A Record is one record of a heap dump. It is one of *Object,
+// *OtherRoot, *Type, *Goroutine, *StackFrame, *Params, *Finalizer,
+// *Itab, *OSThread, *MemStats, *Segment, *Defer, *Panic, *MemProf
+// or *AllocSample.
+type Record interface {
+	isRecord()
+}
+
+// A FieldKind is the kind of a field that refers to other memory.
+type FieldKind int
+
+const (
+	FieldPtr   FieldKind = 1 // a pointer
+	FieldIface FieldKind = 2 // an interface value with methods
+	FieldEface FieldKind = 3 // an empty interface value
+)
+
+// A Field is a field of some memory that refers to other memory.
+type Field struct {
+	Kind   FieldKind
+	Offset uint64 // relative to the start of the enclosing memory
+}
+
+// An Object is an allocated heap object.
+type Object struct {
+	Addr uint64
+
+	// Type is the address of the Type of the values in the object,
+	// or 0 if the runtime doesn't record it. If it is non-zero, the
+	// object holds one or more consecutive values of that type,
+	// starting at ValueOffset in Contents.
+	Type        uint64
+	ValueOffset uint64
+
+	Contents []byte
+	Fields   []Field // pointers in Contents
+}
+
+// An OtherRoot is a GC root that isn't a global variable or on a stack.
+type OtherRoot struct {
+	Description string
+	Pointer     uint64
+}
+
+// A Type is a Go type.
+type Type struct {
+	Addr uint64
+	Size uint64
+	Name string
+
+	// Indirect reports whether interface values of this type hold
+	// a pointer to the value rather than the value itself.
+	Indirect bool
+
+	Kind reflect.Kind
+
+	// Fields are the pointers in a value of the type. They are not
+	// recorded for some large types.
+	Fields []Field
+
+	// StructFields are the fields of a struct type.
+	StructFields []StructField
+}
+
+// A StructField is a field of a struct type.
+type StructField struct {
+	Name   string
+	Offset uint64
+	Type   uint64 // address of the field's Type
+}
+
+// A Goroutine is a goroutine that has not exited.
+type Goroutine struct {
+	Addr       uint64
+	SP         uint64 // stack pointer of the top frame
+	ID         uint64
+	GoPC       uint64 // PC of the go statement that created it
+	Status     uint64 // the runtime's internal status code
+	System     bool   // started by the runtime
+	WaitSince  uint64 // approximate time it started waiting, in nanoseconds
+	WaitReason string
+	Context    uint64 // closure context pointer
+	M          uint64 // address of the OSThread running it, or 0
+	Defer      uint64 // address of the top Defer, or 0
+	Panic      uint64 // address of the top Panic, or 0
+}
+
+// A StackFrame is a frame of a goroutine's stack.
+type StackFrame struct {
+	Goroutine      uint64 // address of the Goroutine owning the stack
+	SP             uint64 // lowest address in the frame
+	Depth          uint64 // number of frames above it; 0 for the top frame
+	ChildSP        uint64 // SP of the frame above it, or 0
+	Contents       []byte
+	Entry          uint64 // entry PC of the function
+	PC             uint64
+	ContinuationPC uint64 // PC where execution resumes, or 0 if it can't
+	Func           string
+	Fields         []Field // pointers in Contents
+}
+
+// Params describes the process that wrote the dump.
+type Params struct {
+	BigEndian bool
+	PtrSize   int
+	HeapStart uint64
+	HeapEnd   uint64
+	GOARCH    string
+	GoVersion string
+	NCPU      int
+}
+
+// A Finalizer is a finalizer set on an object.
+type Finalizer struct {
+	// Queued reports whether the object was found unreachable
+	// and the finalizer is ready to run.
+	Queued bool
+
+	Obj     uint64
+	FuncVal uint64 // address of the finalizer's closure
+	FuncPC  uint64 // entry PC of the finalizer function
+	ArgType uint64 // address of the Type of the finalizer's argument
+	ObjType uint64 // address of the Type of a pointer to the object
+}
+
+// An Itab is an interface table.
+type Itab struct {
+	Addr uint64
+	Type uint64 // address of the concrete Type
+}
+
+// An OSThread is an operating system thread used by the runtime.
+type OSThread struct {
+	Addr uint64
+	ID   uint64 // the runtime's ID for the thread
+	OSID uint64
+}
+
+// MemStats holds memory statistics at the time of the dump. Only
+// the fields up to and including NumGC are set, other than
+// PauseEnd.
+type MemStats struct {
+	runtime.MemStats
+}
+
+// A Segment is the data or BSS segment of the executable, which hold
+// global variables.
+type Segment struct {
+	BSS      bool
+	Addr     uint64
+	Contents []byte
+	Fields   []Field // pointers in Contents
+}
+
+// A Defer is a deferred call that has not run yet.
+type Defer struct {
+	Addr      uint64
+	Goroutine uint64
+	SP        uint64
+	PC        uint64
+	FuncVal   uint64
+	FuncPC    uint64
+	Link      uint64 // address of the next Defer, or 0
+}
+
+// A Panic is a panic in progress.
+type Panic struct {
+	Addr      uint64
+	Goroutine uint64
+	ArgType   uint64 // address of the Type of the panic value
+	ArgData   uint64 // data word of the panic value
+	Link      uint64 // address of the next Panic, or 0
+}
+
+// A MemProf is a bucket of the memory profile.
+type MemProf struct {
+	Addr   uint64
+	Size   uint64
+	Stack  []MemProfFrame
+	Allocs uint64
+	Frees  uint64
+}
+
+// A MemProfFrame is a frame of a MemProf stack.
+type MemProfFrame struct {
+	Func string
+	File string
+	Line uint64
+}
+
+// An AllocSample is a heap object sampled by the memory profiler.
+type AllocSample struct {
+	Addr   uint64
+	Bucket uint64 // address of the MemProf bucket
+}
+
+func (*Object) isRecord()      {}
+func (*OtherRoot) isRecord()   {}
+func (*Type) isRecord()        {}
+func (*Goroutine) isRecord()   {}
+func (*StackFrame) isRecord()  {}
+func (*Params) isRecord()      {}
+func (*Finalizer) isRecord()   {}
+func (*Itab) isRecord()        {}
+func (*OSThread) isRecord()    {}
+func (*MemStats) isRecord()    {}
+func (*Segment) isRecord()     {}
+func (*Defer) isRecord()       {}
+func (*Panic) isRecord()       {}
+func (*MemProf) isRecord()     {}
+func (*AllocSample) isRecord() {}
+
+// A Reader reads the records of a heap dump.
+type Reader struct {
+	r    *bufio.Reader
+	err  error
+	done bool
+}
+
+// NewReader returns a Reader that reads a heap dump from r, which may
+// be gzip-compressed. It reads and checks the dump's header.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("heapdump: %w", err)
+		}
+		br = bufio.NewReader(zr)
+	}
+	hdr := make([]byte, len(header))
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("heapdump: reading header: %w", err)
+	}
+	if string(hdr) != header {
+		return nil, fmt.Errorf("heapdump: unsupported header %q", hdr)
+	}
+	return &Reader{r: br}, nil
+}
+
+// Next returns the next record of the dump. It returns io.EOF after
+// the dump's final record. If the dump ends before its final record,
+// the error wraps io.ErrUnexpectedEOF.
+func (r *Reader) Next() (Record, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.done {
+		return nil, io.EOF
+	}
+	var rec Record
+	switch tag := r.uint(); tag {
+	case tagEOF:
+		if r.err == nil {
+			r.done = true
+			return nil, io.EOF
+		}
+	case tagObject:
+		rec = &Object{
+			Addr:        r.uint(),
+			Type:        r.uint(),
+			ValueOffset: r.uint(),
+			Contents:    r.bytes(),
+			Fields:      r.fields(),
+		}
+	case tagOtherRoot:
+		rec = &OtherRoot{
+			Description: r.string(),
+			Pointer:     r.uint(),
+		}
+	case tagType:
+		t := &Type{
+			Addr:     r.uint(),
+			Size:     r.uint(),
+			Name:     r.string(),
+			Indirect: r.bool(),
+			Kind:     reflect.Kind(r.uint()),
+			Fields:   r.fields(),
+		}
+		n := r.uint()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			t.StructFields = append(t.StructFields, StructField{
+				Name:   r.string(),
+				Offset: r.uint(),
+				Type:   r.uint(),
+			})
+		}
+		rec = t
+	case tagGoroutine:
+		g := &Goroutine{
+			Addr:   r.uint(),
+			SP:     r.uint(),
+			ID:     r.uint(),
+			GoPC:   r.uint(),
+			Status: r.uint(),
+			System: r.bool(),
+		}
+		r.bool() // background; no longer used
+		g.WaitSince = r.uint()
+		g.WaitReason = r.string()
+		g.Context = r.uint()
+		g.M = r.uint()
+		g.Defer = r.uint()
+		g.Panic = r.uint()
+		rec = g
+	case tagStackFrame:
+		rec = &StackFrame{
+			Goroutine:      r.uint(),
+			SP:             r.uint(),
+			Depth:          r.uint(),
+			ChildSP:        r.uint(),
+			Contents:       r.bytes(),
+			Entry:          r.uint(),
+			PC:             r.uint(),
+			ContinuationPC: r.uint(),
+			Func:           r.string(),
+			Fields:         r.fields(),
+		}
+	case tagParams:
+		rec = &Params{
+			BigEndian: r.bool(),
+			PtrSize:   int(r.uint()),
+			HeapStart: r.uint(),
+			HeapEnd:   r.uint(),
+			GOARCH:    r.string(),
+			GoVersion: r.string(),
+			NCPU:      int(r.uint()),
+		}
+	case tagFinalizer, tagQueuedFinalizer:
+		rec = &Finalizer{
+			Queued:  tag == tagQueuedFinalizer,
+			Obj:     r.uint(),
+			FuncVal: r.uint(),
+			FuncPC:  r.uint(),
+			ArgType: r.uint(),
+			ObjType: r.uint(),
+		}
+	case tagItab:
+		rec = &Itab{
+			Addr: r.uint(),
+			Type: r.uint(),
+		}
+	case tagOSThread:
+		rec = &OSThread{
+			Addr: r.uint(),
+			ID:   r.uint(),
+			OSID: r.uint(),
+		}
+	case tagMemStats:
+		m := new(MemStats)
+		for _, p := range []*uint64{
+			&m.Alloc, &m.TotalAlloc, &m.Sys, &m.Lookups, &m.Mallocs,
+			&m.Frees, &m.HeapAlloc, &m.HeapSys, &m.HeapIdle,
+			&m.HeapInuse, &m.HeapReleased, &m.HeapObjects,
+			&m.StackInuse, &m.StackSys, &m.MSpanInuse, &m.MSpanSys,
+			&m.MCacheInuse, &m.MCacheSys, &m.BuckHashSys, &m.GCSys,
+			&m.OtherSys, &m.NextGC, &m.LastGC, &m.PauseTotalNs,
+		} {
+			*p = r.uint()
+		}
+		for i := range m.PauseNs {
+			m.PauseNs[i] = r.uint()
+		}
+		m.NumGC = uint32(r.uint())
+		rec = m
+	case tagData, tagBSS:
+		rec = &Segment{
+			BSS:      tag == tagBSS,
+			Addr:     r.uint(),
+			Contents: r.bytes(),
+			Fields:   r.fields(),
+		}
+	case tagDefer:
+		rec = &Defer{
+			Addr:      r.uint(),
+			Goroutine: r.uint(),
+			SP:        r.uint(),
+			PC:        r.uint(),
+			FuncVal:   r.uint(),
+			FuncPC:    r.uint(),
+			Link:      r.uint(),
+		}
+	case tagPanic:
+		p := &Panic{
+			Addr:      r.uint(),
+			Goroutine: r.uint(),
+			ArgType:   r.uint(),
+			ArgData:   r.uint(),
+		}
+		r.uint() // unused
+		p.Link = r.uint()
+		rec = p
+	case tagMemProf:
+		b := &MemProf{
+			Addr: r.uint(),
+			Size: r.uint(),
+		}
+		n := r.uint()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			b.Stack = append(b.Stack, MemProfFrame{
+				Func: r.string(),
+				File: r.string(),
+				Line: r.uint(),
+			})
+		}
+		b.Allocs = r.uint()
+		b.Frees = r.uint()
+		rec = b
+	case tagAllocSample:
+		rec = &AllocSample{
+			Addr:   r.uint(),
+			Bucket: r.uint(),
+		}
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("heapdump: unknown record tag %d", tag)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return rec, nil
+}
+
+// fail records err as the reader's error, if it doesn't have one.
+func (r *Reader) fail(err error) {
+	if r.err != nil {
+		return
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	r.err = fmt.Errorf("heapdump: %w", err)
+}
+
+func (r *Reader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		r.fail(err)
+	}
+	return v
+}
+
+func (r *Reader) bool() bool {
+	return r.uint() != 0
+}
+
+func (r *Reader) bytes() []byte {
+	n := r.uint()
+	if r.err != nil {
+		return nil
+	}
+	b, err := saferio.ReadData(r.r, n)
+	if err != nil {
+		r.fail(err)
+	}
+	return b
+}
+
+func (r *Reader) string() string {
+	return string(r.bytes())
+}
+
+func (r *Reader) fields() []Field {
+	var fields []Field
+	for {
+		kind := r.uint()
+		if kind == 0 || r.err != nil {
+			return fields
+		}
+		fields = append(fields, Field{Kind: FieldKind(kind), Offset: r.uint()})
+	}
+}
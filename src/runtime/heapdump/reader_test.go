@@ -0,0 +1,168 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package heapdump_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"runtime/heapdump"
+	"testing"
+	"unsafe"
+)
+
+// bigObj is large enough to have its type recorded in the heap.
+type bigObj struct {
+	next *bigObj
+	pad  [100]uintptr
+	name string
+}
+
+var live *bigObj
+
+func writeDump(t *testing.T, compress bool) []byte {
+	if runtime.GOOS == "js" {
+		t.Skipf("WriteHeapDump is not available on %s.", runtime.GOOS)
+	}
+	var buf bytes.Buffer
+	if err := debug.WriteHeapDumpTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !compress {
+		return buf.Bytes()
+	}
+	var zbuf bytes.Buffer
+	zw := gzip.NewWriter(&zbuf)
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zbuf.Bytes()
+}
+
+func readDump(t *testing.T, dump []byte) []heapdump.Record {
+	r, err := heapdump.NewReader(bytes.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []heapdump.Record
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return recs
+		}
+		if err != nil {
+			t.Fatalf("after %d records: %v", len(recs), err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func TestReader(t *testing.T) {
+	live = &bigObj{name: "live"}
+	live.next = live
+	addr := uint64(uintptr(unsafe.Pointer(live)))
+
+	for _, compress := range []bool{false, true} {
+		recs := readDump(t, writeDump(t, compress))
+		if _, ok := recs[0].(*heapdump.Params); !ok {
+			t.Fatalf("first record is %T, want *heapdump.Params", recs[0])
+		}
+
+		types := make(map[uint64]*heapdump.Type)
+		gs := make(map[uint64]bool)
+		var obj *heapdump.Object
+		var frames, memstats int
+		for _, rec := range recs {
+			switch rec := rec.(type) {
+			case *heapdump.Type:
+				types[rec.Addr] = rec
+			case *heapdump.Goroutine:
+				gs[rec.Addr] = true
+			case *heapdump.Object:
+				if rec.Addr+rec.ValueOffset == addr {
+					obj = rec
+				}
+			case *heapdump.StackFrame:
+				frames++
+				if !gs[rec.Goroutine] {
+					t.Errorf("stack frame of %s refers to unknown goroutine %#x", rec.Func, rec.Goroutine)
+				}
+			case *heapdump.MemStats:
+				memstats++
+			}
+		}
+		if frames == 0 {
+			t.Error("dump has no stack frames")
+		}
+		if memstats != 1 {
+			t.Errorf("dump has %d MemStats records, want 1", memstats)
+		}
+		if obj == nil {
+			t.Fatalf("object %#x not found in dump", addr)
+		}
+		typ := types[obj.Type]
+		if typ == nil {
+			t.Fatalf("object %#x has type %#x, which isn't in the dump", addr, obj.Type)
+		}
+		if want := "runtime/heapdump_test.bigObj"; typ.Name != want {
+			t.Errorf("object type is %s, want %s", typ.Name, want)
+		}
+		if typ.Kind != reflect.Struct || typ.Size != uint64(unsafe.Sizeof(bigObj{})) {
+			t.Errorf("object type has kind %v and size %d, want struct and %d", typ.Kind, typ.Size, unsafe.Sizeof(bigObj{}))
+		}
+		var names []string
+		for _, f := range typ.StructFields {
+			names = append(names, f.Name)
+			if types[f.Type] == nil {
+				t.Errorf("type of field %s isn't in the dump", f.Name)
+			}
+		}
+		if want := []string{"next", "pad", "name"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("object type has fields %q, want %q", names, want)
+		}
+		wantPtrs := []heapdump.Field{
+			{Kind: heapdump.FieldPtr, Offset: 0},
+			{Kind: heapdump.FieldPtr, Offset: uint64(unsafe.Offsetof(bigObj{}.name))},
+		}
+		if !reflect.DeepEqual(typ.Fields, wantPtrs) {
+			t.Errorf("object type has pointers %v, want %v", typ.Fields, wantPtrs)
+		}
+		if !bytes.Contains(obj.Contents, unsafe.Slice((*byte)(unsafe.Pointer(&live)), unsafe.Sizeof(live))) {
+			t.Error("object contents don't contain its next pointer")
+		}
+	}
+	runtime.KeepAlive(live)
+}
+
+func TestReaderTruncated(t *testing.T) {
+	dump := writeDump(t, false)
+	r, err := heapdump.NewReader(bytes.NewReader(dump[:len(dump)/2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		_, err := r.Next()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Next on truncated dump returned %v, want io.ErrUnexpectedEOF", err)
+		}
+		break
+	}
+}
+
+func TestReaderBadHeader(t *testing.T) {
+	if _, err := heapdump.NewReader(bytes.NewReader([]byte("go1.7 heap dump\n"))); err == nil {
+		t.Error("NewReader accepted a go1.7 heap dump")
+	}
+}
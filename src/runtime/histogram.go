@@ -147,6 +147,24 @@ func (h *timeHistogram) write(out *metricValue) {
 	hist.counts[len(hist.counts)-1] = h.overflow.Load()
 }
 
+// flushTo adds the counts in h to dst, and resets h.
+func (h *timeHistogram) flushTo(dst *timeHistogram) {
+	for i := range h.counts {
+		dst.counts[i].Add(int64(h.counts[i].Swap(0)))
+	}
+	dst.underflow.Add(int64(h.underflow.Swap(0)))
+	dst.overflow.Add(int64(h.overflow.Swap(0)))
+}
+
+// addTo adds the counts in h to hist, which was produced by write.
+func (h *timeHistogram) addTo(hist *metricFloat64Histogram) {
+	hist.counts[0] += h.underflow.Load()
+	for i := range h.counts {
+		hist.counts[i+1] += h.counts[i].Load()
+	}
+	hist.counts[len(hist.counts)-1] += h.overflow.Load()
+}
+
 const (
 	fInf    = 0x7FF0000000000000
 	fNegInf = 0xFFF0000000000000
@@ -502,7 +502,20 @@ func mallocinit() {
 		//
 		// In race mode we have no choice but to just use the same hints because
 		// the race detector requires that the heap be mapped contiguously.
+		//
+		// With GODEBUG=heaprandomize=1, the hints for each of the heap and
+		// user arenas are rotated by a random amount, and each is moved by
+		// a random offset. See mheaprandom.go.
+		randomize := heapRandomizeHints()
+		var rotate int
+		if randomize {
+			rotate = int(bootstrapRand() & 0x3f)
+		}
 		for i := 0x7f; i >= 0; i-- {
+			j := i
+			if randomize {
+				j = i&0x40 | (i+rotate)&0x3f
+			}
 			var p uintptr
 			switch {
 			case raceenabled:
@@ -516,7 +529,7 @@ func mallocinit() {
 			case GOARCH == "arm64" && GOOS == "ios":
 				p = uintptr(i)<<40 | uintptrMask&(0x0013<<28)
 			case GOARCH == "arm64":
-				p = uintptr(i)<<40 | uintptrMask&(0x0040<<32)
+				p = uintptr(j)<<40 | uintptrMask&(0x0040<<32)
 			case GOOS == "aix":
 				if i == 0 {
 					// We don't use addresses directly after 0x0A00000000000000
@@ -525,7 +538,10 @@ func mallocinit() {
 				}
 				p = uintptr(i)<<40 | uintptrMask&(0xa0<<52)
 			default:
-				p = uintptr(i)<<40 | uintptrMask&(0x00c0<<32)
+				p = uintptr(j)<<40 | uintptrMask&(0x00c0<<32)
+			}
+			if randomize {
+				p += heapRandomizeHintOffset()
 			}
 			// Switch to generating hints for user arenas if we've gone
 			// through about half the hints. In race mode, take only about
@@ -1030,6 +1046,14 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 			// Init functions are executed sequentially in a single goroutine.
 			inittrace.allocs += 1
 		}
+
+		// Charge the allocation to the user goroutine, even if we're
+		// on the system stack.
+		if debug.goroutineallocbytes != 0 {
+			if gp := getg().m.curg; gp != nil {
+				gp.allocBytes += uint64(userSize)
+			}
+		}
 	}
 
 	// assistG is the G to charge for this allocation, or nil if
@@ -1059,6 +1083,9 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 	// In some cases block zeroing can profitably (for latency reduction purposes)
 	// be delayed till preemption is possible; delayedZeroing tracks that state.
 	delayedZeroing := false
+	// outOfOrder is set if a small object was allocated ahead of the
+	// span's freeindex, with GODEBUG=heaprandomize=1.
+	outOfOrder := false
 	// Determine if it's a 'small' object that goes into a size-classed span.
 	//
 	// Note: This comparison looks a little strange, but it exists to smooth out
@@ -1069,7 +1096,7 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 	// size class has a single object in it already, precisely to make the transition
 	// to large objects smooth.
 	if size <= maxSmallSize-mallocHeaderSize {
-		if noscan && size < maxTinySize {
+		if noscan && size < maxTinySize && debug.heaptypes == 0 {
 			// Tiny allocator.
 			//
 			// Tiny allocator combines several tiny allocation requests
@@ -1127,7 +1154,14 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 			}
 			// Allocate a new maxTinySize block.
 			span = c.alloc[tinySpanClass]
-			v := nextFreeFast(span)
+			var v gclinkptr
+			if debug.heaprandomize > 0 {
+				v = nextFreeRandom(span)
+				outOfOrder = v != 0
+			}
+			if v == 0 {
+				v = nextFreeFast(span)
+			}
 			if v == 0 {
 				v, span, shouldhelpgc = c.nextFree(tinySpanClass)
 			}
@@ -1156,7 +1190,14 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 			size = uintptr(class_to_size[sizeclass])
 			spc := makeSpanClass(sizeclass, noscan)
 			span = c.alloc[spc]
-			v := nextFreeFast(span)
+			var v gclinkptr
+			if debug.heaprandomize > 0 {
+				v = nextFreeRandom(span)
+				outOfOrder = v != 0
+			}
+			if v == 0 {
+				v = nextFreeFast(span)
+			}
 			if v == 0 {
 				v, span, shouldhelpgc = c.nextFree(spc)
 			}
@@ -1209,6 +1250,11 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 	// conservative scanning considers this pointer dead until
 	// this point.
 	span.freeIndexForScan = span.freeindex
+	if outOfOrder {
+		// Likewise, the object wasn't seen as allocated until its
+		// alloc bit is set, since it's at or above freeindex.
+		span.allocBitsForIndex(span.objIndex(uintptr(x))).setMarked()
+	}
 
 	// Allocate black during GC.
 	// All slots hold nil so no scanning is needed.
@@ -1250,7 +1296,16 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 		if rate != 1 && fullSize < c.nextSample {
 			c.nextSample -= fullSize
 		} else {
-			profilealloc(mp, x, fullSize)
+			profilealloc(mp, x, fullSize, typ)
+		}
+	}
+	allocSample := false
+	if rate := allocSampler.rate.Load(); rate > 0 {
+		if fullSize < c.nextAllocSample {
+			c.nextAllocSample -= fullSize
+		} else {
+			c.nextAllocSample = allocSamplerNext(rate)
+			allocSample = true
 		}
 	}
 	mp.mallocing = 0
@@ -1275,6 +1330,10 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 		}
 	}
 
+	if allocSample {
+		allocSamplerRecord(typ, fullSize)
+	}
+
 	if debug.malloc {
 		if inittrace.active && inittrace.id == getg().goid {
 			// Init functions are executed sequentially in a single goroutine.
@@ -1288,6 +1347,14 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 				traceRelease(trace)
 			}
 		}
+
+		if debug.heaptypes != 0 {
+			heapTypesAlloc(span, uintptr(x), typ)
+		}
+
+		if span.largeGuard {
+			span.fillLargeGuard()
+		}
 	}
 
 	if assistG != nil {
@@ -1352,11 +1419,18 @@ func deductAssistCredit(size uintptr) *g {
 	return assistG
 }
 
+//go:linkname runtime_debug_goroutineAllocBytes runtime/debug.goroutineAllocBytes
+func runtime_debug_goroutineAllocBytes() uint64 {
+	return getg().allocBytes
+}
+
 // memclrNoHeapPointersChunked repeatedly calls memclrNoHeapPointers
 // on chunks of the buffer to be zeroed, with opportunities for preemption
 // along the way.  memclrNoHeapPointers contains no safepoints and also
 // cannot be preemptively scheduled, so this provides a still-efficient
 // block copy that can also be preempted on a reasonable granularity.
+// If size is large enough, every chunk is cleared with non-temporal
+// stores; see memnt.go.
 //
 // Use this with care; if the data being cleared is tagged to contain
 // pointers, this allows the GC to run before it is all cleared.
@@ -1364,6 +1438,7 @@ func memclrNoHeapPointersChunked(size uintptr, x unsafe.Pointer) {
 	v := uintptr(x)
 	// got this from benchmarking. 128k is too small, 512k is too large.
 	const chunkBytes = 256 * 1024
+	nonTemporal := useNonTemporal(size)
 	vsize := v + size
 	for voff := v; voff < vsize; voff = voff + chunkBytes {
 		if getg().preempt {
@@ -1375,7 +1450,11 @@ func memclrNoHeapPointersChunked(size uintptr, x unsafe.Pointer) {
 		if n > chunkBytes {
 			n = chunkBytes
 		}
-		memclrNoHeapPointers(unsafe.Pointer(voff), n)
+		if nonTemporal {
+			memclrNoHeapPointersNonTemporal(unsafe.Pointer(voff), n)
+		} else {
+			memclrNoHeapPointers(unsafe.Pointer(voff), n)
+		}
 	}
 }
 
@@ -1450,13 +1529,13 @@ func reflect_unsafe_NewArray(typ *_type, n int) unsafe.Pointer {
 	return newarray(typ, n)
 }
 
-func profilealloc(mp *m, x unsafe.Pointer, size uintptr) {
+func profilealloc(mp *m, x unsafe.Pointer, size uintptr, typ *_type) {
 	c := getMCache(mp)
 	if c == nil {
 		throw("profilealloc called without a P or outside bootstrapping")
 	}
 	c.nextSample = nextSample()
-	mProf_Malloc(mp, x, size)
+	mProf_Malloc(mp, x, size, typ)
 }
 
 // nextSample returns the next sampling point for heap profiling. The goal is
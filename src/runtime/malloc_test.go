@@ -157,6 +157,9 @@ func TestTinyAlloc(t *testing.T) {
 	if runtime.Raceenabled {
 		t.Skip("tinyalloc suppressed when running in race mode")
 	}
+	if runtime.HeapTypesEnabled() {
+		t.Skip("tinyalloc suppressed with GODEBUG=heaptypes=1")
+	}
 	const N = 16
 	var v [N]unsafe.Pointer
 	for i := range v {
@@ -182,6 +185,9 @@ func TestTinyAllocIssue37262(t *testing.T) {
 	if runtime.Raceenabled {
 		t.Skip("tinyalloc suppressed when running in race mode")
 	}
+	if runtime.HeapTypesEnabled() {
+		t.Skip("tinyalloc suppressed with GODEBUG=heaptypes=1")
+	}
 	// Try to cause an alignment access fault
 	// by atomically accessing the first 64-bit
 	// value of a tiny-allocated object.
@@ -243,6 +249,133 @@ func TestPhysicalMemoryUtilization(t *testing.T) {
 	}
 }
 
+func TestSizeClassesGODEBUG(t *testing.T) {
+	switch GOOS {
+	case "aix", "darwin", "ios", "dragonfly", "freebsd", "netbsd", "openbsd", "illumos", "solaris", "linux":
+	default:
+		t.Skipf("GODEBUG=sizeclasses is not supported on %s", GOOS)
+	}
+	for _, tc := range []struct {
+		setting string
+		want    string
+	}{
+		{"default", "20:24\n33:48\n100:112\n300:320\n5000:5376\n"},
+		{"fine", "20:24\n33:40\n100:104\n"},
+		{"pow2", "20:32\n33:64\n100:128\n300:512\n5000:8192\n"},
+		{"bogus", "runtime: unknown GODEBUG setting sizeclasses=bogus"},
+	} {
+		t.Run(tc.setting, func(t *testing.T) {
+			got := runTestProg(t, "testprog", "SizeClasses", "GODEBUG=sizeclasses="+tc.setting)
+			if !strings.HasPrefix(got, tc.want) {
+				t.Errorf("got output:\n%s\nwant prefix:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGuardPages(t *testing.T) {
+	if got := runTestProg(t, "testprog", "GuardPages", "GODEBUG=guardpages=1"); got != "OK\n" {
+		t.Errorf("expected %q, but got %q", "OK\n", got)
+	}
+	// There's only a guard page if it's a whole number of system pages.
+	if GOOS != "windows" && GOOS != "plan9" && GOOS != "js" && GOOS != "wasip1" && os.Getpagesize() <= 8192 {
+		got := runTestProg(t, "testprog", "GuardPagesFault", "GODEBUG=guardpages=1")
+		if !strings.Contains(got, "unexpected fault address") {
+			t.Errorf("write to guard page didn't fault; output:\n%s", got)
+		}
+	}
+	got := runTestProg(t, "testprog", "GuardPagesOverwrite", "GODEBUG=guardpages=1")
+	if want := "fatal error: write past the end of a large object"; !strings.Contains(got, want) {
+		t.Errorf("output doesn't contain %q:\n%s", want, got)
+	}
+}
+
+func TestHeapRandomize(t *testing.T) {
+	first := runTestProg(t, "testprog", "HeapRandomize", "GODEBUG=heaprandomize=1")
+	if !strings.HasPrefix(first, "0x") {
+		t.Fatalf("unexpected output:\n%s", first)
+	}
+	if second := runTestProg(t, "testprog", "HeapRandomize", "GODEBUG=heaprandomize=1"); second == first {
+		t.Errorf("heap object at %s in two runs", strings.TrimSpace(first))
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	if got := runTestProg(t, "testprog", "Quarantine", "GODEBUG=quarantine=2"); got != "OK\n" {
+		t.Errorf("expected %q, but got %q", "OK\n", got)
+	}
+	got := runTestProg(t, "testprog", "QuarantineWrite", "GODEBUG=quarantine=5")
+	for _, want := range []string{"fatal error: write to freed object", "main.quarantineAlloc"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+type allocSamplerT struct {
+	a [6]int64 // 48 bytes, a size class
+}
+
+var allocSamplerSink *allocSamplerT
+
+func TestSetAllocSampler(t *testing.T) {
+	samples := make(chan AllocSample, 100)
+	SetAllocSampler(1, func(s AllocSample) {
+		if s.Type == "runtime_test.allocSamplerT" {
+			select {
+			case samples <- s:
+			default:
+			}
+		}
+	})
+	defer SetAllocSampler(0, nil)
+
+	var s AllocSample
+	for i := 0; ; i++ {
+		allocSamplerSink = new(allocSamplerT)
+		select {
+		case s = <-samples:
+		case <-time.After(time.Millisecond):
+			if i == 1000 {
+				t.Fatal("no sample of allocSamplerT")
+			}
+			continue
+		}
+		break
+	}
+	if s.Size != 48 {
+		t.Errorf("got Size %d, want 48", s.Size)
+	}
+	found := false
+	frames := CallersFrames(s.Stack)
+	for {
+		f, more := frames.Next()
+		if f.Function == "runtime_test.TestSetAllocSampler" {
+			found = true
+		}
+		if !more {
+			break
+		}
+	}
+	if !found {
+		t.Errorf("sample stack doesn't contain TestSetAllocSampler: %v", s.Stack)
+	}
+
+	// No samples are delivered once sampling is turned off.
+	SetAllocSampler(0, nil)
+	time.Sleep(10 * time.Millisecond) // let a delivery in progress finish
+	for len(samples) > 0 {
+		<-samples
+	}
+	for range 100 {
+		allocSamplerSink = new(allocSamplerT)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := len(samples); n != 0 {
+		t.Errorf("got %d samples after turning sampling off", n)
+	}
+}
+
 func TestScavengedBitsCleared(t *testing.T) {
 	var mismatches [128]BitsMismatch
 	if n, ok := CheckScavengedBitsCleared(mismatches[:]); !ok {
@@ -142,6 +142,10 @@ type mapextra struct {
 
 	// nextOverflow holds a pointer to a free overflow bucket.
 	nextOverflow *bmap
+
+	// arena is the user arena that the map's memory is allocated from,
+	// if the map was created with makemapInUserArena.
+	arena *userArena
 }
 
 // A bucket for a Go map.
@@ -258,7 +262,7 @@ func (h *hmap) newoverflow(t *maptype, b *bmap) *bmap {
 			h.extra.nextOverflow = nil
 		}
 	} else {
-		ovf = (*bmap)(newobject(t.Bucket))
+		ovf = (*bmap)(h.newobject(t.Bucket))
 	}
 	h.incrnoverflow()
 	if !t.Bucket.Pointers() {
@@ -269,6 +273,23 @@ func (h *hmap) newoverflow(t *maptype, b *bmap) *bmap {
 	return ovf
 }
 
+// userArena returns the user arena h's memory is allocated from, or nil.
+func (h *hmap) userArena() *userArena {
+	if h.extra == nil {
+		return nil
+	}
+	return h.extra.arena
+}
+
+// newobject allocates a new value of type typ for use by h, from h's
+// user arena if it has one.
+func (h *hmap) newobject(typ *_type) unsafe.Pointer {
+	if a := h.userArena(); a != nil {
+		return a.new(typ)
+	}
+	return newobject(typ)
+}
+
 func (h *hmap) createOverflow() {
 	if h.extra == nil {
 		h.extra = new(mapextra)
@@ -324,7 +345,7 @@ func makemap(t *maptype, hint int, h *hmap) *hmap {
 	// If hint is large zeroing this memory could take a while.
 	if h.B != 0 {
 		var nextOverflow *bmap
-		h.buckets, nextOverflow = makeBucketArray(t, h.B, nil)
+		h.buckets, nextOverflow = makeBucketArray(t, h, h.B, nil)
 		if nextOverflow != nil {
 			h.extra = new(mapextra)
 			h.extra.nextOverflow = nextOverflow
@@ -334,13 +355,40 @@ func makemap(t *maptype, hint int, h *hmap) *hmap {
 	return h
 }
 
-// makeBucketArray initializes a backing array for map buckets.
+// makemapInUserArena is like makemap, but allocates the map header, its
+// bucket arrays, and any overflow buckets from the user arena a instead
+// of the heap, so that the map's memory is freed along with the arena.
+//
+// Unlike makemap, the initial bucket array is always allocated eagerly,
+// so that no code path lazily allocates buckets on the heap.
+func makemapInUserArena(t *maptype, hint int, a *userArena) *hmap {
+	mem, overflow := math.MulUintptr(uintptr(hint), t.Bucket.Size_)
+	if overflow || mem > maxAlloc {
+		hint = 0
+	}
+
+	h := (*hmap)(a.new(abi.TypeOf((*hmap)(nil)).Elem()))
+	h.hash0 = uint32(rand())
+	h.extra = (*mapextra)(a.new(abi.TypeOf((*mapextra)(nil)).Elem()))
+	h.extra.arena = a
+
+	// Find the size parameter B which will hold the requested # of elements.
+	B := uint8(0)
+	for overLoadFactor(hint, B) {
+		B++
+	}
+	h.B = B
+	h.buckets, h.extra.nextOverflow = makeBucketArray(t, h, h.B, nil)
+	return h
+}
+
+// makeBucketArray initializes a backing array for map buckets of h.
 // 1<<b is the minimum number of buckets to allocate.
 // dirtyalloc should either be nil or a bucket array previously
 // allocated by makeBucketArray with the same t and b parameters.
 // If dirtyalloc is nil a new backing array will be alloced and
 // otherwise dirtyalloc will be cleared and reused as backing array.
-func makeBucketArray(t *maptype, b uint8, dirtyalloc unsafe.Pointer) (buckets unsafe.Pointer, nextOverflow *bmap) {
+func makeBucketArray(t *maptype, h *hmap, b uint8, dirtyalloc unsafe.Pointer) (buckets unsafe.Pointer, nextOverflow *bmap) {
 	base := bucketShift(b)
 	nbuckets := base
 	// For small b, overflow buckets are unlikely.
@@ -358,7 +406,11 @@ func makeBucketArray(t *maptype, b uint8, dirtyalloc unsafe.Pointer) (buckets un
 	}
 
 	if dirtyalloc == nil {
-		buckets = newarray(t.Bucket, int(nbuckets))
+		if a := h.userArena(); a != nil {
+			buckets = a.alloc(t.Bucket, int(nbuckets))
+		} else {
+			buckets = newarray(t.Bucket, int(nbuckets))
+		}
 	} else {
 		// dirtyalloc was previously generated by
 		// the above newarray(t.Bucket, int(nbuckets))
@@ -667,12 +719,12 @@ bucketloop:
 
 	// store new key/elem at insert position
 	if t.IndirectKey() {
-		kmem := newobject(t.Key)
+		kmem := h.newobject(t.Key)
 		*(*unsafe.Pointer)(insertk) = kmem
 		insertk = kmem
 	}
 	if t.IndirectElem() {
-		vmem := newobject(t.Elem)
+		vmem := h.newobject(t.Elem)
 		*(*unsafe.Pointer)(elem) = vmem
 	}
 	typedmemmove(t.Key, insertk, key)
@@ -1026,15 +1078,16 @@ func mapclear(t *maptype, h *hmap) {
 	// repeatedly trigger hash collisions. See issue 25237.
 	h.hash0 = uint32(rand())
 
-	// Keep the mapextra allocation but clear any extra information.
+	// Keep the mapextra allocation but clear any extra information,
+	// other than the arena the map's memory comes from.
 	if h.extra != nil {
-		*h.extra = mapextra{}
+		*h.extra = mapextra{arena: h.extra.arena}
 	}
 
 	// makeBucketArray clears the memory pointed to by h.buckets
 	// and recovers any overflow buckets by generating them
 	// as if h.buckets was newly alloced.
-	_, nextOverflow := makeBucketArray(t, h.B, h.buckets)
+	_, nextOverflow := makeBucketArray(t, h, h.B, h.buckets)
 	if nextOverflow != nil {
 		// If overflow buckets are created then h.extra
 		// will have been allocated during initial bucket creation.
@@ -1057,7 +1110,7 @@ func hashGrow(t *maptype, h *hmap) {
 		h.flags |= sameSizeGrow
 	}
 	oldbuckets := h.buckets
-	newbuckets, nextOverflow := makeBucketArray(t, h.B+bigger, nil)
+	newbuckets, nextOverflow := makeBucketArray(t, h, h.B+bigger, nil)
 
 	flags := h.flags &^ (iterator | oldIterator)
 	if h.flags&iterator != 0 {
@@ -0,0 +1,125 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Default GOMAXPROCS.
+//
+// Unless the GOMAXPROCS environment variable is set, GOMAXPROCS
+// defaults to the number of CPUs the program may run on, or, on Linux,
+// to the CPU bandwidth limit of the program's cgroup, rounded up, if
+// that's lower. A limit below 2 CPUs gives 2, since a program with a
+// fractional limit can still run on several CPUs at once for part of
+// each period, and GOMAXPROCS=1 would serialize its GC with it.
+//
+// The CPU limit can change while the program runs, for example when
+// a container orchestrator resizes the container. sysmon reads it again
+// every maxProcsPeriod and, if the default has changed, wakes a system
+// goroutine to update GOMAXPROCS, which needs to stop the world. This
+// continues until the program calls GOMAXPROCS itself.
+//
+// GODEBUG=containermaxprocs=0 ignores the cgroup's limit, and
+// GODEBUG=updatemaxprocs=0 keeps GOMAXPROCS from being updated.
+
+package runtime
+
+import "internal/runtime/atomic"
+
+// maxProcsPeriod is how often, in nanoseconds, the CPU limit is read
+// again.
+const maxProcsPeriod = 1e9
+
+// maxProcsDefault is set while GOMAXPROCS is the default, rather than
+// set by the environment or the program.
+var maxProcsDefault atomic.Bool
+
+var maxProcs struct {
+	lock   mutex
+	cgroup cgroupCPU
+	update bool // sysmon checks for changes to the default
+
+	lastCheck int64        // time of sysmon's last check
+	procs     atomic.Int32 // the new default, for the updater
+	idle      atomic.Bool  // the updater is parked, waiting for sysmon
+	g         *g           // the updater goroutine
+}
+
+// defaultGOMAXPROCS returns the default GOMAXPROCS. cgroupOK is whether
+// maxProcs.cgroup has been initialized successfully.
+//
+// Called by sysmon, so it must not allocate.
+func defaultGOMAXPROCS(cgroupOK bool) int32 {
+	procs := ncpu
+	if !cgroupOK {
+		return procs
+	}
+	if limit, ok := maxProcs.cgroup.limit(); ok {
+		n := int32(limit)
+		if float64(n) < limit {
+			n++
+		}
+		procs = min(procs, max(n, 2))
+	}
+	return procs
+}
+
+// initMaxProcs returns the GOMAXPROCS to start with. It's called by
+// schedinit.
+func initMaxProcs() int32 {
+	lockInit(&maxProcs.lock, lockRankMaxProcs)
+	if n, ok := atoi32(gogetenv("GOMAXPROCS")); ok && n > 0 {
+		return n
+	}
+	maxProcsDefault.Store(true)
+	cgroupOK := debug.containermaxprocs != 0 && maxProcs.cgroup.init()
+	maxProcs.update = cgroupOK && debug.updatemaxprocs != 0
+	return defaultGOMAXPROCS(cgroupOK)
+}
+
+func init() {
+	if maxProcs.update {
+		go maxProcsUpdater()
+	}
+}
+
+// maxProcsUpdater sets GOMAXPROCS to the new default each time sysmon
+// finds it has changed.
+func maxProcsUpdater() {
+	maxProcs.g = getg()
+	for {
+		lock(&maxProcs.lock)
+		maxProcs.idle.Store(true)
+		goparkunlock(&maxProcs.lock, waitReasonMaxProcsIdle, traceBlockSystemGoroutine, 1)
+		// Resumed by sysmonCheckMaxProcs.
+		stw := stopTheWorldGC(stwGOMAXPROCS)
+		// The program may have set GOMAXPROCS since sysmon's check.
+		if maxProcsDefault.Load() {
+			newprocs = maxProcs.procs.Load()
+		}
+		startTheWorldGC(stw)
+	}
+}
+
+// sysmonCheckMaxProcs checks whether the default GOMAXPROCS has changed,
+// at most every maxProcsPeriod, and wakes maxProcsUpdater if it has.
+//
+//go:nowritebarrierrec
+func sysmonCheckMaxProcs(now int64) {
+	if !maxProcs.update || now-maxProcs.lastCheck < maxProcsPeriod || !maxProcs.idle.Load() {
+		return
+	}
+	maxProcs.lastCheck = now
+	if !maxProcsDefault.Load() {
+		return
+	}
+	procs := defaultGOMAXPROCS(true)
+	if procs == gomaxprocs {
+		return
+	}
+	maxProcs.procs.Store(procs)
+	lock(&maxProcs.lock)
+	maxProcs.idle.Store(false)
+	var list gList
+	list.push(maxProcs.g)
+	injectglist(&list)
+	unlock(&maxProcs.lock)
+}
@@ -21,8 +21,9 @@ type mcache struct {
 
 	// The following members are accessed on every malloc,
 	// so they are grouped here for better caching.
-	nextSample uintptr // trigger heap sample after allocating this many bytes
-	scanAlloc  uintptr // bytes of scannable heap allocated
+	nextSample      uintptr // trigger heap sample after allocating this many bytes
+	nextAllocSample uintptr // trigger SetAllocSampler sample after allocating this many bytes
+	scanAlloc       uintptr // bytes of scannable heap allocated
 
 	// Allocator cache for tiny objects w/o pointers.
 	// See "Tiny allocator" comment in malloc.go.
@@ -46,6 +47,10 @@ type mcache struct {
 
 	stackcache [_NumStackOrders]stackfreelist
 
+	// nextArenaSample is like nextSample, but for allocations from user
+	// arenas, which are sampled for the arena allocation profile instead.
+	nextArenaSample uintptr
+
 	// flushGen indicates the sweepgen during which this mcache
 	// was last flushed. If flushGen != mheap_.sweepgen, the spans
 	// in this mcache are stale and need to the flushed so they
@@ -94,6 +99,8 @@ func allocmcache() *mcache {
 		c.alloc[i] = &emptymspan
 	}
 	c.nextSample = nextSample()
+	c.nextArenaSample = nextSample()
+	c.nextAllocSample = allocSamplerNext(allocSampler.rate.Load())
 	return c
 }
 
@@ -231,7 +238,7 @@ func (c *mcache) allocLarge(size uintptr, noscan bool) *mspan {
 	deductSweepCredit(npages*_PageSize, npages)
 
 	spc := makeSpanClass(0, noscan)
-	s := mheap_.alloc(npages, spc)
+	s := mheap_.alloc(npages+largeGuardPages(), spc)
 	if s == nil {
 		throw("out of memory")
 	}
@@ -252,6 +259,9 @@ func (c *mcache) allocLarge(size uintptr, noscan bool) *mspan {
 	// visible to the background sweeper.
 	mheap_.central[spc].mcentral.fullSwept(mheap_.sweepgen).push(s)
 	s.limit = s.base() + size
+	if debug.guardpages > 0 {
+		s.initLargeGuard(npages)
+	}
 	s.initHeapBits(false)
 	return s
 }
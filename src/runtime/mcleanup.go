@@ -0,0 +1,184 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"internal/abi"
+	"unsafe"
+)
+
+// AddCleanup attaches a cleanup function to ptr. Some time after ptr is no longer
+// reachable, the runtime will call cleanup(arg) in a separate goroutine.
+//
+// If ptr is reachable from cleanup or arg, ptr will never be collected
+// and the cleanup will never run. AddCleanup panics if arg is equal to ptr.
+//
+// The cleanup(arg) call is not always guaranteed to run; in particular it is not
+// guaranteed to run before program exit.
+//
+// Cleanups are not guaranteed to run if the size of T is zero bytes, because
+// it may share same address with other zero-size objects in memory. See
+// https://go.dev/ref/spec#Size_and_alignment_guarantees.
+//
+// There is no specified order in which cleanups will run.
+//
+// A single goroutine runs all cleanup calls for a program, sequentially, along
+// with finalizers set by [SetFinalizer]. If a cleanup function must run for a
+// long time, it should create a new goroutine.
+//
+// Unlike [SetFinalizer], AddCleanup may be called any number of times for the
+// same ptr, and each call attaches an independent cleanup. ptr may point
+// anywhere inside an object, such as to a field of a struct or an element of
+// an array, and the cleanup is tied to the whole object. ptr is not passed to
+// cleanup, so the object is not resurrected, and its memory may be reclaimed
+// in the same cycle that queues the cleanup. Cleanups for objects that also
+// have a finalizer run only once the object is unreachable after the
+// finalizer has run.
+//
+// As with finalizers, the cleanup for an unreachable object may never run if
+// the object is batched into the same allocation slot as a reachable one.
+// Typically, this batching only happens for tiny (on the order of 16 bytes
+// or less) and pointer-free objects.
+//
+// It is not guaranteed that a cleanup will run for objects allocated in
+// initializers for package-level variables, nor for objects allocated in
+// an arena. Such objects may be linker-allocated, not heap-allocated.
+//
+// In the terminology of the Go memory model, a call AddCleanup(ptr, f, arg)
+// “synchronizes before” the cleanup call f(arg).
+func AddCleanup[T, S any](ptr *T, cleanup func(S), arg S) Cleanup {
+	// Explicitly force ptr to escape to the heap.
+	ptr = abi.Escape(ptr)
+
+	// The pointer to the object must be valid.
+	if ptr == nil {
+		throw("runtime.AddCleanup: ptr is nil")
+	}
+	usptr := uintptr(unsafe.Pointer(ptr))
+
+	// Check that arg is not equal to ptr.
+	if kind := abi.TypeFor[S]().Kind(); kind == abi.Pointer || kind == abi.UnsafePointer {
+		if unsafe.Pointer(ptr) == *((*unsafe.Pointer)(unsafe.Pointer(&arg))) {
+			panic("runtime.AddCleanup: ptr is equal to arg, cleanup will never run")
+		}
+	}
+	if inUserArenaChunk(usptr) {
+		// Arena-allocated objects are not eligible for cleanup.
+		return Cleanup{}
+	}
+	if debug.sbrk != 0 {
+		// debug.sbrk never frees memory, so no cleanup will ever run
+		// (and we don't have the data structures to record them).
+		return Cleanup{}
+	}
+
+	fn := func() {
+		cleanup(arg)
+	}
+	fv := *(**funcval)(unsafe.Pointer(&fn))
+	fv = abi.Escape(fv)
+
+	// Find the containing object.
+	base, _, _ := findObject(usptr, 0, 0)
+	if base == 0 {
+		if isGoPointerWithoutSpan(unsafe.Pointer(ptr)) {
+			return Cleanup{}
+		}
+		throw("runtime.AddCleanup: ptr not in allocated block")
+	}
+
+	// Make sure we have a finalizer goroutine to run cleanups.
+	createfing()
+
+	var id uint64
+	systemstack(func() {
+		id = addCleanup(unsafe.Pointer(ptr), fv)
+	})
+	return Cleanup{
+		id:  id,
+		ptr: usptr,
+	}
+}
+
+// Cleanup is a handle to a cleanup call for a specific object.
+type Cleanup struct {
+	// id is the unique identifier for the cleanup within the program.
+	id uint64
+	// ptr contains the pointer to the object.
+	ptr uintptr
+}
+
+// Stop cancels the cleanup call. Stop will have no effect if the cleanup
+// has already been queued for execution (because ptr became unreachable).
+// To guarantee that Stop removes the cleanup function, the caller must
+// ensure that the pointer that was passed to AddCleanup is reachable
+// across the call to Stop.
+func (c Cleanup) Stop() {
+	if c.id == 0 {
+		// id is set to zero when the cleanup is not attached.
+		return
+	}
+	systemstack(func() {
+		c.stop()
+	})
+}
+
+// stop removes the special record for c, if it's still attached.
+// It must run on the system stack.
+func (c Cleanup) stop() {
+	// The following block removes the Special record of type cleanup
+	// for the object c.ptr.
+	span := spanOfHeap(c.ptr)
+	if span == nil {
+		return
+	}
+	// Ensure that the span is swept.
+	// Sweeping accesses the specials list w/o locks, so we have
+	// to synchronize with it. And it's just much safer.
+	mp := acquirem()
+	span.ensureSwept()
+
+	offset := c.ptr - span.base()
+
+	var found *special
+	lock(&span.speciallock)
+
+	iter, exists := span.specialFindSplicePoint(offset, _KindSpecialCleanup)
+	if exists {
+		for {
+			s := *iter
+			if s == nil {
+				// Reached the end of the linked list. Stop searching at this point.
+				break
+			}
+			if offset == uintptr(s.offset) && _KindSpecialCleanup == s.kind &&
+				(*specialCleanup)(unsafe.Pointer(s)).id == c.id {
+				// The special is a cleanup and contains a matching cleanup id.
+				*iter = s.next
+				found = s
+				break
+			}
+			if offset < uintptr(s.offset) || (offset == uintptr(s.offset) && _KindSpecialCleanup < s.kind) {
+				// The special is outside the region specified for that kind of
+				// special. The specials are sorted by kind.
+				break
+			}
+			// Try the next special.
+			iter = &s.next
+		}
+	}
+	if span.specials == nil {
+		spanHasNoSpecials(span)
+	}
+	unlock(&span.speciallock)
+	releasem(mp)
+
+	if found == nil {
+		return
+	}
+	lock(&mheap_.speciallock)
+	mheap_.specialCleanupAlloc.free(unsafe.Pointer(found))
+	unlock(&mheap_.speciallock)
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+func TestCleanup(t *testing.T) {
+	ch := make(chan bool, 1)
+	done := make(chan bool, 1)
+	want := 97531
+	go func() {
+		// Allocate struct with pointer to avoid hitting tinyalloc.
+		// Otherwise we can't be sure when the allocation will
+		// be freed.
+		type T struct {
+			v int
+			p unsafe.Pointer
+		}
+		v := &new(T).v
+		*v = 97531
+		cleanup := func(x int) {
+			if x != want {
+				t.Errorf("cleanup %d, want %d", x, want)
+			}
+			ch <- true
+		}
+		runtime.AddCleanup(v, cleanup, 97531)
+		v = nil
+		done <- true
+	}()
+	<-done
+	runtime.GC()
+	<-ch
+}
+
+func TestCleanupMultiple(t *testing.T) {
+	ch := make(chan bool, 3)
+	done := make(chan bool, 1)
+	want := 97531
+	go func() {
+		// Allocate struct with pointer to avoid hitting tinyalloc.
+		// Otherwise we can't be sure when the allocation will
+		// be freed.
+		type T struct {
+			v int
+			p unsafe.Pointer
+		}
+		v := &new(T).v
+		*v = 97531
+		cleanup := func(x int) {
+			if x != want {
+				t.Errorf("cleanup %d, want %d", x, want)
+			}
+			ch <- true
+		}
+		runtime.AddCleanup(v, cleanup, 97531)
+		runtime.AddCleanup(v, cleanup, 97531)
+		runtime.AddCleanup(v, cleanup, 97531)
+		v = nil
+		done <- true
+	}()
+	<-done
+	runtime.GC()
+	<-ch
+	<-ch
+	<-ch
+}
+
+func TestCleanupInteriorPointer(t *testing.T) {
+	ch := make(chan bool, 3)
+	done := make(chan bool, 1)
+	want := 97531
+	go func() {
+		// Allocate struct with pointer to avoid hitting tinyalloc.
+		// Otherwise we can't be sure when the allocation will
+		// be freed.
+		type T struct {
+			p unsafe.Pointer
+			i int
+			a int
+			b int
+			c int
+		}
+		ts := new(T)
+		ts.a = 97531
+		ts.b = 97531
+		ts.c = 97531
+		cleanup := func(x int) {
+			if x != want {
+				t.Errorf("cleanup %d, want %d", x, want)
+			}
+			ch <- true
+		}
+		runtime.AddCleanup(&ts.a, cleanup, 97531)
+		runtime.AddCleanup(&ts.b, cleanup, 97531)
+		runtime.AddCleanup(&ts.c, cleanup, 97531)
+		ts = nil
+		done <- true
+	}()
+	<-done
+	runtime.GC()
+	<-ch
+	<-ch
+	<-ch
+}
+
+func TestCleanupStop(t *testing.T) {
+	done := make(chan bool, 1)
+	go func() {
+		// Allocate struct with pointer to avoid hitting tinyalloc.
+		// Otherwise we can't be sure when the allocation will
+		// be freed.
+		type T struct {
+			v int
+			p unsafe.Pointer
+		}
+		v := &new(T).v
+		*v = 97531
+		cleanup := func(x int) {
+			t.Error("cleanup called, want no cleanup called")
+		}
+		c := runtime.AddCleanup(v, cleanup, 97531)
+		c.Stop()
+		v = nil
+		done <- true
+	}()
+	<-done
+	runtime.GC()
+	// Ensure the cleanup queue has been drained.
+	runtime.BlockUntilEmptyFinalizerQueue(1e9)
+}
+
+func TestCleanupStopOne(t *testing.T) {
+	ch := make(chan int, 2)
+	done := make(chan bool, 1)
+	go func() {
+		// Allocate struct with pointer to avoid hitting tinyalloc.
+		// Otherwise we can't be sure when the allocation will
+		// be freed.
+		type T struct {
+			v int
+			p unsafe.Pointer
+		}
+		v := &new(T).v
+		*v = 97531
+		cleanup := func(x int) {
+			ch <- x
+		}
+		c1 := runtime.AddCleanup(v, cleanup, 1)
+		runtime.AddCleanup(v, cleanup, 2)
+		c1.Stop()
+		c1.Stop() // no effect
+		v = nil
+		done <- true
+	}()
+	<-done
+	runtime.GC()
+	if x := <-ch; x != 2 {
+		t.Errorf("cleanup %d ran, want 2", x)
+	}
+	runtime.BlockUntilEmptyFinalizerQueue(1e9)
+	select {
+	case x := <-ch:
+		t.Errorf("stopped cleanup %d ran", x)
+	default:
+	}
+}
+
+func TestCleanupAfterFinalizer(t *testing.T) {
+	ch := make(chan string, 2)
+	done := make(chan bool, 1)
+	go func() {
+		// Allocate struct with pointer to avoid hitting tinyalloc.
+		// Otherwise we can't be sure when the allocation will
+		// be freed.
+		type T struct {
+			v int
+			p unsafe.Pointer
+		}
+		v := &new(T).v
+		*v = 97531
+		runtime.SetFinalizer(v, func(*int) { ch <- "finalizer" })
+		runtime.AddCleanup(v, func(string) { ch <- "cleanup" }, "")
+		v = nil
+		done <- true
+	}()
+	<-done
+	// The first cycle resurrects the object for its finalizer. The
+	// cleanup only runs once a later cycle finds it unreachable again.
+	runtime.GC()
+	if got := <-ch; got != "finalizer" {
+		t.Fatalf("got %s first, want finalizer", got)
+	}
+	runtime.GC()
+	if got := <-ch; got != "cleanup" {
+		t.Fatalf("got %s second, want cleanup", got)
+	}
+}
+
+func TestCleanupArgEqualsPtr(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("AddCleanup with arg equal to ptr did not panic")
+		}
+	}()
+	v := new(int)
+	runtime.AddCleanup(v, func(*int) {}, v)
+}
@@ -97,6 +97,26 @@ func sysHugePageCollapse(v unsafe.Pointer, n uintptr) {
 	sysHugePageCollapseOS(v, n)
 }
 
+// sysReadOnly makes a Ready memory region read-only if readOnly is true, or
+// readable and writable again otherwise. It is best-effort and may do nothing,
+// in which case it returns false.
+func sysReadOnly(v unsafe.Pointer, n uintptr, readOnly bool) bool {
+	return sysReadOnlyOS(v, n, readOnly)
+}
+
+// sysPreferNUMANode asks for the physical memory backing a Ready memory region
+// to be placed on NUMA node node, migrating any pages already placed elsewhere.
+// It is best-effort and may fail silently.
+func sysPreferNUMANode(v unsafe.Pointer, n uintptr, node int32) {
+	sysPreferNUMANodeOS(v, n, node)
+}
+
+// sysNUMANode returns the NUMA node of the CPU the current thread is running
+// on, or -1 if it's unknown.
+func sysNUMANode() int32 {
+	return sysNUMANodeOS()
+}
+
 // sysFree transitions a memory region from any state to None. Therefore, it
 // returns memory unconditionally. It is used if an out-of-memory error has been
 // detected midway through an allocation or to carve out an aligned section of
@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mem
+
+// A MapMode specifies how MapFile maps a file.
+type MapMode int
+
+const (
+	// MapReadOnly maps the file for reading. Writing to the Region
+	// faults.
+	MapReadOnly MapMode = iota
+
+	// MapPrivate maps the file copy-on-write: writes to the Region
+	// are private to the process and never reach the file.
+	MapPrivate
+
+	// MapShared maps the file for reading and writing. Writes to the
+	// Region are written back to the file, and are seen by other
+	// processes that map it.
+	MapShared
+)
+
+// MapFile returns a Region backed by the first n bytes of the open file
+// fd, such as the one returned by [os.File.Fd]. The file must be open
+// for reading, and also for writing for MapShared. Closing the file
+// doesn't affect the Region.
+//
+// Unlike the memory of other Regions, the operating system reads the
+// pages of a file Region from the file when they are first accessed,
+// and can drop them again when memory is short, so mapping a file much
+// larger than memory is fine. With MapReadOnly and MapShared, other
+// processes mapping the same file share the memory.
+//
+// Accessing bytes of the Region beyond the end of the file faults. The
+// error is a [syscall.Errno] if the file cannot be mapped, or
+// [errors.ErrUnsupported] on systems other than Unix-like ones. MapFile
+// panics if n is negative or mode is invalid.
+func MapFile(fd uintptr, n int, mode MapMode) (*Region, error) {
+	if n < 0 {
+		panic("runtime/mem: negative region size")
+	}
+	if mode < MapReadOnly || mode > MapShared {
+		panic("runtime/mem: invalid MapMode")
+	}
+	if n == 0 {
+		return &Region{}, nil
+	}
+	return mapFile(fd, n, mode)
+}
@@ -0,0 +1,17 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package mem
+
+import "errors"
+
+func mapFile(fd uintptr, n int, mode MapMode) (*Region, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func unmapFile(b []byte) {
+	panic("unreachable")
+}
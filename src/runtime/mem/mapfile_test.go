@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package mem_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/mem"
+	"runtime/metrics"
+	"syscall"
+	"testing"
+)
+
+func readMappedFiles() uint64 {
+	s := []metrics.Sample{{Name: "/memory/mapped-files:bytes"}}
+	metrics.Read(s)
+	return s[0].Value.Uint64()
+}
+
+func TestMapFile(t *testing.T) {
+	const n = 1 << 20
+	data := bytes.Repeat([]byte("0123456789abcdef"), n/16)
+	name := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(name, data, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	before := readMappedFiles()
+	ro, err := mem.MapFile(f.Fd(), n, mem.MapReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readMappedFiles() - before; got < n {
+		t.Errorf("/memory/mapped-files:bytes grew by %d, want at least %d", got, n)
+	}
+	if !bytes.Equal(ro.Bytes(), data) {
+		t.Error("read-only region doesn't hold the file's contents")
+	}
+
+	priv, err := mem.MapFile(f.Fd(), n, mem.MapPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(priv.Bytes(), "private")
+	shared, err := mem.MapFile(f.Fd(), n, mem.MapShared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(shared.Bytes(), "shared")
+
+	if got := string(ro.Bytes()[:6]); got != "shared" {
+		t.Errorf("read-only region starts with %q after write to shared region, want %q", got, "shared")
+	}
+	if got := string(priv.Bytes()[:7]); got != "private" {
+		t.Errorf("private region starts with %q, want %q", got, "private")
+	}
+	priv.Free()
+	shared.Free()
+	ro.Free()
+	if got := readMappedFiles(); got != before {
+		t.Errorf("/memory/mapped-files:bytes is %d after Free, want %d", got, before)
+	}
+
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(got, []byte("shared6789")) {
+		t.Errorf("file starts with %q after write to shared region, want %q", got[:10], "shared6789")
+	}
+}
+
+func TestMapFileBadFD(t *testing.T) {
+	_, err := mem.MapFile(^uintptr(0)>>1, 4096, mem.MapReadOnly)
+	if !errors.Is(err, syscall.EBADF) {
+		t.Errorf("MapFile of bad fd returned %v, want EBADF", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package mem
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func mapFile(fd uintptr, n int, mode MapMode) (*Region, error) {
+	var writable, shared bool
+	switch mode {
+	case MapReadOnly:
+		shared = true
+	case MapPrivate:
+		writable = true
+	case MapShared:
+		writable, shared = true, true
+	}
+	p, errno := runtime_mapFile(fd, uintptr(n), writable, shared)
+	if errno != 0 {
+		return nil, syscall.Errno(errno)
+	}
+	return &Region{b: unsafe.Slice((*byte)(p), n), file: true}, nil
+}
+
+func unmapFile(b []byte) {
+	runtime_unmapFile(unsafe.Pointer(unsafe.SliceData(b)), uintptr(len(b)))
+}
+
+// Implemented in runtime.
+
+//go:linkname runtime_mapFile
+func runtime_mapFile(fd uintptr, n uintptr, writable, shared bool) (unsafe.Pointer, int)
+
+//go:linkname runtime_unmapFile
+func runtime_unmapFile(p unsafe.Pointer, n uintptr)
@@ -0,0 +1,131 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mem provides memory that is managed explicitly instead of by
+// the garbage collector.
+//
+// It is meant for large, long-lived, pointer-free data, such as the
+// contents of in-memory caches. Even though the garbage collector
+// doesn't scan pointer-free heap objects, such data still occupies the
+// heap: it makes the heap goal grow with it, and large objects are
+// subject to being scavenged and faulted back in. Memory from this
+// package is outside the heap altogether, and never moves, so it also
+// suits buffers shared with C code or the operating system.
+//
+// [ReserveNoScan] provides a [Region] of bytes, and [Alloc] a slice of
+// pointer-free values of any type. [MapFile] maps a file into a Region,
+// so that large read-mostly data can be shared with other processes
+// through the operating system's page cache.
+package mem
+
+import (
+	"errors"
+	"internal/abi"
+	"unsafe"
+)
+
+// A Region is a block of memory outside the garbage-collected heap.
+//
+// The garbage collector never scans a Region, and doesn't consider
+// pointers in it, so a Region must not be used to hold the only
+// reference to a Go object. Storing Go pointers in it is unsafe.
+//
+// The memory of a Region is only released by [Region.Free]. The memory
+// of a Region returned by [ReserveNoScan] counts toward the memory limit
+// set by [runtime/debug.SetMemoryLimit], and in
+// [runtime.MemStats.OtherSys], but not toward the heap goal. The memory
+// of a Region returned by [MapFile] is backed by the file, and is
+// reported by the /memory/mapped-files:bytes metric instead.
+type Region struct {
+	b    []byte
+	file bool // mapped by MapFile
+}
+
+// ReserveNoScan returns a Region of n bytes of zeroed memory. It returns
+// an error if the operating system cannot provide the memory. It panics
+// if n is negative.
+func ReserveNoScan(n int) (*Region, error) {
+	if n < 0 {
+		panic("runtime/mem: negative region size")
+	}
+	if n == 0 {
+		return &Region{}, nil
+	}
+	p := runtime_allocNoScan(uintptr(n))
+	if p == nil {
+		return nil, errors.New("runtime/mem: out of memory")
+	}
+	return &Region{b: unsafe.Slice((*byte)(p), n)}, nil
+}
+
+// Bytes returns the memory of r. The slice is only valid until r is
+// freed, after which accessing it faults or corrupts memory.
+func (r *Region) Bytes() []byte {
+	return r.b
+}
+
+// Free returns the memory of r to the operating system. Freeing a
+// Region that has already been freed does nothing. Free must not be
+// called concurrently with other methods of r.
+func (r *Region) Free() {
+	if len(r.b) == 0 {
+		return
+	}
+	if r.file {
+		unmapFile(r.b)
+	} else {
+		runtime_freeNoScan(unsafe.Pointer(unsafe.SliceData(r.b)), uintptr(len(r.b)))
+	}
+	r.b = nil
+}
+
+// Alloc returns a slice of n zeroed values of type T in memory outside
+// the garbage-collected heap. The memory never moves, and is only
+// released by [Free].
+//
+// Like a [Region], the memory is never scanned by the garbage
+// collector, so Alloc panics if T contains pointers. It also panics if
+// n is negative, if the size of the slice overflows, or if the
+// operating system cannot provide the memory.
+func Alloc[T any](n int) []T {
+	if n < 0 {
+		panic("runtime/mem: negative Alloc length")
+	}
+	if abi.TypeFor[T]().Pointers() {
+		panic("runtime/mem: Alloc of type containing pointers")
+	}
+	size := unsafe.Sizeof(*new(T))
+	if n == 0 || size == 0 {
+		return make([]T, n)
+	}
+	if uintptr(n) > ^uintptr(0)/size {
+		panic("runtime/mem: Alloc size overflows")
+	}
+	p := runtime_allocNoScan(uintptr(n) * size)
+	if p == nil {
+		panic("runtime/mem: out of memory")
+	}
+	return unsafe.Slice((*T)(p), n)
+}
+
+// Free releases the memory of s, which must have been returned by
+// [Alloc], or be a slice of it with the same first element and
+// capacity. Freeing a slice with no capacity does nothing. After Free,
+// accessing any slice of the memory faults or corrupts memory, and so
+// does freeing it again.
+func Free[T any](s []T) {
+	size := unsafe.Sizeof(*new(T))
+	if cap(s) == 0 || size == 0 {
+		return
+	}
+	runtime_freeNoScan(unsafe.Pointer(unsafe.SliceData(s)), uintptr(cap(s))*size)
+}
+
+// Implemented in runtime.
+
+//go:linkname runtime_allocNoScan
+func runtime_allocNoScan(n uintptr) unsafe.Pointer
+
+//go:linkname runtime_freeNoScan
+func runtime_freeNoScan(p unsafe.Pointer, n uintptr)
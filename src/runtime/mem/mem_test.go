@@ -0,0 +1,137 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mem_test
+
+import (
+	"math"
+	"runtime"
+	"runtime/mem"
+	"testing"
+	"unsafe"
+)
+
+func TestReserveNoScan(t *testing.T) {
+	const n = 64 << 20
+
+	var before, reserved, freed runtime.MemStats
+	runtime.ReadMemStats(&before)
+	r, err := mem.ReserveNoScan(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&reserved)
+
+	b := r.Bytes()
+	if len(b) != n {
+		t.Fatalf("len(Bytes()) = %d, want %d", len(b), n)
+	}
+	for i := 0; i < n; i += 4096 {
+		if b[i] != 0 {
+			t.Fatalf("byte %d of new region is %d, want 0", i, b[i])
+		}
+		b[i] = byte(i)
+	}
+	if got := reserved.OtherSys - before.OtherSys; got < n {
+		t.Errorf("OtherSys grew by %d, want at least %d", got, n)
+	}
+	if reserved.HeapSys-before.HeapSys >= n {
+		t.Errorf("HeapSys grew by %d, want less than %d", reserved.HeapSys-before.HeapSys, n)
+	}
+
+	r.Free()
+	runtime.ReadMemStats(&freed)
+	if got := reserved.OtherSys - freed.OtherSys; got < n {
+		t.Errorf("OtherSys shrank by %d after Free, want at least %d", got, n)
+	}
+	if r.Bytes() != nil {
+		t.Error("Bytes() is non-nil after Free")
+	}
+	r.Free() // must be a no-op
+}
+
+func TestReserveNoScanZero(t *testing.T) {
+	r, err := mem.ReserveNoScan(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Bytes()) != 0 {
+		t.Errorf("len(Bytes()) = %d, want 0", len(r.Bytes()))
+	}
+	r.Free()
+}
+
+func TestReserveNoScanNegative(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("ReserveNoScan(-1) didn't panic")
+		}
+	}()
+	mem.ReserveNoScan(-1)
+}
+
+func TestAlloc(t *testing.T) {
+	type point struct{ x, y float64 }
+	const n = 1 << 20
+
+	var before, allocated, freed runtime.MemStats
+	runtime.ReadMemStats(&before)
+	s := mem.Alloc[point](n)
+	runtime.ReadMemStats(&allocated)
+
+	if len(s) != n || cap(s) != n {
+		t.Fatalf("len, cap = %d, %d, want %d, %d", len(s), cap(s), n, n)
+	}
+	for i := range s {
+		if s[i] != (point{}) {
+			t.Fatalf("element %d of new slice is %v, want zero", i, s[i])
+		}
+		s[i] = point{float64(i), -float64(i)}
+	}
+	runtime.GC()
+	for i := range s {
+		if s[i] != (point{float64(i), -float64(i)}) {
+			t.Fatalf("element %d is %v after GC", i, s[i])
+		}
+	}
+	size := uint64(n * unsafe.Sizeof(point{}))
+	if got := allocated.OtherSys - before.OtherSys; got < size {
+		t.Errorf("OtherSys grew by %d, want at least %d", got, size)
+	}
+
+	mem.Free(s[:10])
+	runtime.ReadMemStats(&freed)
+	if got := allocated.OtherSys - freed.OtherSys; got < size {
+		t.Errorf("OtherSys shrank by %d after Free, want at least %d", got, size)
+	}
+}
+
+func TestAllocEmpty(t *testing.T) {
+	if s := mem.Alloc[int64](0); len(s) != 0 {
+		t.Errorf("len(Alloc(0)) = %d, want 0", len(s))
+	}
+	if s := mem.Alloc[struct{}](10); len(s) != 10 {
+		t.Errorf("len(Alloc[struct{}](10)) = %d, want 10", len(s))
+	} else {
+		mem.Free(s)
+	}
+	mem.Free[int64](nil)
+}
+
+func TestAllocPanics(t *testing.T) {
+	for name, f := range map[string]func(){
+		"negative": func() { mem.Alloc[byte](-1) },
+		"pointers": func() { mem.Alloc[*int](1) },
+		"overflow": func() { mem.Alloc[[1 << 20]byte](math.MaxInt) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Alloc didn't panic")
+				}
+			}()
+			f()
+		})
+	}
+}
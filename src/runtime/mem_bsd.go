@@ -23,7 +23,7 @@ func sysAllocOS(n uintptr) unsafe.Pointer {
 }
 
 func sysUnusedOS(v unsafe.Pointer, n uintptr) {
-	if debug.madvdontneed != 0 {
+	if scavenge.dontNeed.Load() {
 		madvise(v, n, _MADV_DONTNEED)
 	} else {
 		madvise(v, n, _MADV_FREE)
@@ -42,6 +42,17 @@ func sysNoHugePageOS(v unsafe.Pointer, n uintptr) {
 func sysHugePageCollapseOS(v unsafe.Pointer, n uintptr) {
 }
 
+func sysReadOnlyOS(v unsafe.Pointer, n uintptr, readOnly bool) bool {
+	return false
+}
+
+func sysPreferNUMANodeOS(v unsafe.Pointer, n uintptr, node int32) {
+}
+
+func sysNUMANodeOS() int32 {
+	return -1
+}
+
 // Don't split the stack as this function may be invoked without a valid G,
 // which prevents us from allocating more stack.
 //
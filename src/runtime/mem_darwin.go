@@ -42,6 +42,17 @@ func sysNoHugePageOS(v unsafe.Pointer, n uintptr) {
 func sysHugePageCollapseOS(v unsafe.Pointer, n uintptr) {
 }
 
+func sysReadOnlyOS(v unsafe.Pointer, n uintptr, readOnly bool) bool {
+	return false
+}
+
+func sysPreferNUMANodeOS(v unsafe.Pointer, n uintptr, node int32) {
+}
+
+func sysNUMANodeOS() int32 {
+	return -1
+}
+
 // Don't split the stack as this function may be invoked without a valid G,
 // which prevents us from allocating more stack.
 //
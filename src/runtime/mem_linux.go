@@ -12,6 +12,9 @@ import (
 const (
 	_EACCES = 13
 	_EINVAL = 22
+
+	_MPOL_PREFERRED = 1
+	_MPOL_MF_MOVE   = 1 << 1
 )
 
 // Don't split the stack as this method may be invoked without a valid G, which
@@ -47,7 +50,7 @@ func sysUnusedOS(v unsafe.Pointer, n uintptr) {
 	}
 
 	advise := atomic.Load(&adviseUnused)
-	if debug.madvdontneed != 0 && advise != madviseUnsupported {
+	if scavenge.dontNeed.Load() && advise != madviseUnsupported {
 		advise = _MADV_DONTNEED
 	}
 	switch advise {
@@ -149,6 +152,33 @@ func sysFreeOS(v unsafe.Pointer, n uintptr) {
 	munmap(v, n)
 }
 
+func sysReadOnlyOS(v unsafe.Pointer, n uintptr, readOnly bool) bool {
+	prot := int32(_PROT_READ | _PROT_WRITE)
+	if readOnly {
+		prot = _PROT_READ
+	}
+	_, err := mprotect(v, n, prot)
+	return err == 0
+}
+
+func sysPreferNUMANodeOS(v unsafe.Pointer, n uintptr, node int32) {
+	var mask [16]uint64
+	// The kernel only looks at the first maxnode-1 bits of the mask.
+	if node < 0 || int(node) >= len(mask)*64-1 {
+		return
+	}
+	mask[node/64] = 1 << (node % 64)
+	mbind(v, n, _MPOL_PREFERRED, &mask[0], uintptr(len(mask)*64), _MPOL_MF_MOVE)
+}
+
+func sysNUMANodeOS() int32 {
+	var cpu, node uint32
+	if getcpu(&cpu, &node) != 0 {
+		return -1
+	}
+	return int32(node)
+}
+
 func sysFaultOS(v unsafe.Pointer, n uintptr) {
 	mprotect(v, n, _PROT_NONE)
 	madvise(v, n, _MADV_DONTNEED)
@@ -172,11 +202,15 @@ func sysMapOS(v unsafe.Pointer, n uintptr) {
 		throw("runtime: cannot map pages in arena address space")
 	}
 
-	// Disable huge pages if the GODEBUG for it is set.
+	// Apply the heap's huge page mode. Huge pages are disabled if the
+	// GODEBUG for it is set.
 	//
 	// Note that there are a few sysHugePage calls that can override this, but
 	// they're all for GC metadata.
-	if debug.disablethp != 0 {
+	switch heapHugePages.Load() {
+	case hugePagesEnabled:
+		sysHugePageOS(v, n)
+	case hugePagesDisabled:
 		sysNoHugePageOS(v, n)
 	}
 }
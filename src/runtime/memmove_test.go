@@ -400,6 +400,52 @@ func TestMemclr(t *testing.T) {
 	}
 }
 
+func TestMemclrNonTemporal(t *testing.T) {
+	const size = 4096
+	mem := make([]byte, size+128)
+	for _, n := range []int{0, 1, 255, 256, 257, 319, 320, 1000, size} {
+		for x := 0; x < 64; x++ { // offset in mem
+			for i := range mem {
+				mem[i] = 0xee
+			}
+			MemclrNoHeapPointersNonTemporal(unsafe.Pointer(&mem[x]), uintptr(n))
+			for i, b := range mem {
+				if in := i >= x && i < x+n; in && b != 0 {
+					t.Fatalf("n=%d x=%d: failed clear mem[%d] = %d", n, x, i, b)
+				} else if !in && b != 0xee {
+					t.Fatalf("n=%d x=%d: overwrite mem[%d] = %d", n, x, i, b)
+				}
+			}
+		}
+	}
+}
+
+func TestMemmoveNonTemporal(t *testing.T) {
+	const size = 4096
+	src := make([]byte, size+128)
+	dst := make([]byte, size+128)
+	for i := range src {
+		src[i] = byte(128 + (i & 127))
+	}
+	for _, n := range []int{0, 1, 255, 256, 257, 319, 320, 1000, size} {
+		for x := 0; x < 64; x += 7 { // offset in src
+			for y := 0; y < 64; y++ { // offset in dst
+				for i := range dst {
+					dst[i] = byte(i & 127)
+				}
+				MemmoveNonTemporal(unsafe.Pointer(&dst[y]), unsafe.Pointer(&src[x]), uintptr(n))
+				for i, b := range dst {
+					if in := i >= y && i < y+n; in && b != src[i-y+x] {
+						t.Fatalf("n=%d x=%d y=%d: copied dst[%d] = %d", n, x, y, i, b)
+					} else if !in && b != byte(i&127) {
+						t.Fatalf("n=%d x=%d y=%d: overwrite dst[%d] = %d", n, x, y, i, b)
+					}
+				}
+			}
+		}
+	}
+}
+
 func BenchmarkMemclr(b *testing.B) {
 	for _, n := range []int{5, 16, 64, 256, 4096, 65536} {
 		x := make([]byte, n)
@@ -0,0 +1,58 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Non-temporal clearing and copying of large allocations.
+//
+// Clearing or copying many megabytes with ordinary stores pulls all of
+// the memory through the cache, evicting data that other goroutines,
+// and other programs, are using, only for most of the new memory to be
+// evicted in turn before it's touched again. Recycling user arena
+// chunks is the worst case: each one is cleared in full before it's
+// reused. So at and above a threshold, the memory of large allocations
+// is cleared and copied with stores that bypass the cache instead:
+// MOVNTDQ on amd64 and STNP on arm64. The threshold is set with
+// GODEBUG=nontemporal, and defaults to 4 MiB, which is more than the
+// share of the last-level cache that each core gets on most current
+// parts. Smaller clears are often read back soon enough to benefit from
+// the cache.
+
+package runtime
+
+import "unsafe"
+
+// defaultNonTemporalThreshold is the default value of
+// GODEBUG=nontemporal.
+const defaultNonTemporalThreshold = 4 << 20
+
+// useNonTemporal reports whether n bytes of a large allocation should be
+// cleared or copied with non-temporal stores.
+//
+//go:nosplit
+func useNonTemporal(n uintptr) bool {
+	return haveNonTemporal && debug.nontemporal > 0 && n >= uintptr(debug.nontemporal)
+}
+
+// memclrNoHeapPointersLarge is like memclrNoHeapPointers, but clears
+// the memory with non-temporal stores if n is at least the threshold.
+//
+//go:nosplit
+func memclrNoHeapPointersLarge(ptr unsafe.Pointer, n uintptr) {
+	if useNonTemporal(n) {
+		memclrNoHeapPointersNonTemporal(ptr, n)
+		return
+	}
+	memclrNoHeapPointers(ptr, n)
+}
+
+// memmoveLarge is like memmove, but copies the memory with non-temporal
+// stores if n is at least the threshold. to and from must not overlap.
+//
+//go:nosplit
+func memmoveLarge(to, from unsafe.Pointer, n uintptr) {
+	if useNonTemporal(n) {
+		memmoveNonTemporal(to, from, n)
+		return
+	}
+	memmove(to, from, n)
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !plan9
+
+#include "textflag.h"
+
+// See memnt.go for when these are used.
+
+// func memclrNoHeapPointersNonTemporal(ptr unsafe.Pointer, n uintptr)
+// ABIInternal for performance.
+TEXT runtime·memclrNoHeapPointersNonTemporal<ABIInternal>(SB), NOSPLIT, $0-16
+	// AX = ptr
+	// BX = n
+	CMPQ	BX, $256
+	JB	small
+
+	// Clear the first 16 and the last 64 bytes with ordinary stores.
+	// This leaves a 16-byte aligned middle for the MOVNTDQ loop to clear
+	// in whole 64-byte blocks.
+	LEAQ	(AX)(BX*1), CX
+	MOVOU	X15, (AX)
+	MOVOU	X15, -64(CX)
+	MOVOU	X15, -48(CX)
+	MOVOU	X15, -32(CX)
+	MOVOU	X15, -16(CX)
+	LEAQ	16(AX), DI
+	ANDQ	$~15, DI
+	SUBQ	DI, CX	// CX = bytes left from DI
+loop:
+	MOVNTO	X15, 0(DI)
+	MOVNTO	X15, 16(DI)
+	MOVNTO	X15, 32(DI)
+	MOVNTO	X15, 48(DI)
+	ADDQ	$64, DI
+	SUBQ	$64, CX
+	CMPQ	CX, $64
+	JAE	loop
+	// Non-temporal stores are weakly ordered. Make them visible before
+	// any store that could publish the memory.
+	SFENCE
+	RET
+
+small:
+	JMP	runtime·memclrNoHeapPointers<ABIInternal>(SB)
+
+// func memmoveNonTemporal(to, from unsafe.Pointer, n uintptr)
+// ABIInternal for performance.
+TEXT runtime·memmoveNonTemporal<ABIInternal>(SB), NOSPLIT, $0-24
+	// AX = to
+	// BX = from
+	// CX = n
+	CMPQ	CX, $256
+	JB	small
+
+	// Copy the first 16 and the last 64 bytes with ordinary stores, as
+	// in memclrNoHeapPointersNonTemporal. to and from don't overlap, so
+	// the order of the copies doesn't matter.
+	LEAQ	(BX)(CX*1), SI
+	LEAQ	(AX)(CX*1), DX
+	MOVOU	(BX), X0
+	MOVOU	-64(SI), X1
+	MOVOU	-48(SI), X2
+	MOVOU	-32(SI), X3
+	MOVOU	-16(SI), X4
+	MOVOU	X0, (AX)
+	MOVOU	X1, -64(DX)
+	MOVOU	X2, -48(DX)
+	MOVOU	X3, -32(DX)
+	MOVOU	X4, -16(DX)
+	LEAQ	16(AX), DI
+	ANDQ	$~15, DI
+	MOVQ	DI, SI
+	SUBQ	AX, SI
+	ADDQ	BX, SI	// SI = from + (DI - to)
+	SUBQ	DI, DX	// DX = bytes left from DI
+loop:
+	// Prefetch the source without polluting the cache either.
+	PREFETCHNTA	0x1C0(SI)
+	MOVOU	0(SI), X0
+	MOVOU	16(SI), X1
+	MOVOU	32(SI), X2
+	MOVOU	48(SI), X3
+	MOVNTO	X0, 0(DI)
+	MOVNTO	X1, 16(DI)
+	MOVNTO	X2, 32(DI)
+	MOVNTO	X3, 48(DI)
+	ADDQ	$64, SI
+	ADDQ	$64, DI
+	SUBQ	$64, DX
+	CMPQ	DX, $64
+	JAE	loop
+	SFENCE
+	RET
+
+small:
+	JMP	runtime·memmove<ABIInternal>(SB)
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+#include "textflag.h"
+
+// See memnt.go for when these are used.
+//
+// The assembler doesn't support LDNP and STNP, so they're encoded by hand.
+
+// func memclrNoHeapPointersNonTemporal(ptr unsafe.Pointer, n uintptr)
+TEXT runtime·memclrNoHeapPointersNonTemporal<ABIInternal>(SB),NOSPLIT,$0-16
+	// R0 = ptr
+	// R1 = n
+	CMP	$256, R1
+	BLO	small
+
+	// Clear the first and the last 64 bytes with ordinary stores. This
+	// leaves a 64-byte aligned middle for the STNP loop to clear in whole
+	// cache lines.
+	ADD	R0, R1, R4
+	STP	(ZR, ZR), (R0)
+	STP	(ZR, ZR), 16(R0)
+	STP	(ZR, ZR), 32(R0)
+	STP	(ZR, ZR), 48(R0)
+	STP	(ZR, ZR), -64(R4)
+	STP	(ZR, ZR), -48(R4)
+	STP	(ZR, ZR), -32(R4)
+	STP	(ZR, ZR), -16(R4)
+	ADD	$64, R0, R3
+	AND	$~63, R3
+	SUB	$64, R4, R5	// R5 = start of the last whole line
+loop:
+	WORD	$0xa8007c7f	// STNP ZR, ZR, (R3)
+	WORD	$0xa8017c7f	// STNP ZR, ZR, 16(R3)
+	WORD	$0xa8027c7f	// STNP ZR, ZR, 32(R3)
+	WORD	$0xa8037c7f	// STNP ZR, ZR, 48(R3)
+	ADD	$64, R3
+	CMP	R5, R3
+	BLS	loop
+	RET
+
+small:
+	B	runtime·memclrNoHeapPointers<ABIInternal>(SB)
+
+// func memmoveNonTemporal(to, from unsafe.Pointer, n uintptr)
+TEXT runtime·memmoveNonTemporal<ABIInternal>(SB),NOSPLIT,$0-24
+	// R0 = to
+	// R1 = from
+	// R2 = n
+	CMP	$256, R2
+	BLO	small
+
+	// Copy the first and the last 64 bytes with ordinary stores, as in
+	// memclrNoHeapPointersNonTemporal. to and from don't overlap, so the
+	// order of the copies doesn't matter.
+	ADD	R1, R2, R4
+	ADD	R0, R2, R5
+	LDP	(R1), (R6, R7)
+	LDP	16(R1), (R8, R9)
+	LDP	32(R1), (R10, R11)
+	LDP	48(R1), (R12, R13)
+	STP	(R6, R7), (R0)
+	STP	(R8, R9), 16(R0)
+	STP	(R10, R11), 32(R0)
+	STP	(R12, R13), 48(R0)
+	LDP	-64(R4), (R6, R7)
+	LDP	-48(R4), (R8, R9)
+	LDP	-32(R4), (R10, R11)
+	LDP	-16(R4), (R12, R13)
+	STP	(R6, R7), -64(R5)
+	STP	(R8, R9), -48(R5)
+	STP	(R10, R11), -32(R5)
+	STP	(R12, R13), -16(R5)
+	ADD	$64, R0, R3
+	AND	$~63, R3
+	SUB	R0, R3, R14
+	ADD	R1, R14, R14	// R14 = from + (R3 - to)
+	SUB	$64, R5, R5	// R5 = start of the last whole line
+loop:
+	WORD	$0xa8401dc6	// LDNP (R14), (R6, R7)
+	WORD	$0xa84125c8	// LDNP 16(R14), (R8, R9)
+	WORD	$0xa8422dca	// LDNP 32(R14), (R10, R11)
+	WORD	$0xa84335cc	// LDNP 48(R14), (R12, R13)
+	WORD	$0xa8001c66	// STNP (R6, R7), (R3)
+	WORD	$0xa8012468	// STNP (R8, R9), 16(R3)
+	WORD	$0xa8022c6a	// STNP (R10, R11), 32(R3)
+	WORD	$0xa803346c	// STNP (R12, R13), 48(R3)
+	ADD	$64, R14
+	ADD	$64, R3
+	CMP	R5, R3
+	BLS	loop
+	RET
+
+small:
+	B	runtime·memmove<ABIInternal>(SB)
@@ -0,0 +1,24 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (amd64 && plan9) || (!amd64 && !arm64)
+
+package runtime
+
+import "unsafe"
+
+// haveNonTemporal indicates that memclrNoHeapPointersNonTemporal and
+// memmoveNonTemporal use non-temporal stores. They don't on this
+// system.
+const haveNonTemporal = false
+
+//go:nosplit
+func memclrNoHeapPointersNonTemporal(ptr unsafe.Pointer, n uintptr) {
+	memclrNoHeapPointers(ptr, n)
+}
+
+//go:nosplit
+func memmoveNonTemporal(to, from unsafe.Pointer, n uintptr) {
+	memmove(to, from, n)
+}
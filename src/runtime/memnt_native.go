@@ -0,0 +1,32 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (amd64 && !plan9) || arm64
+
+package runtime
+
+import "unsafe"
+
+// haveNonTemporal indicates that memclrNoHeapPointersNonTemporal and
+// memmoveNonTemporal use non-temporal stores.
+const haveNonTemporal = true
+
+// memclrNoHeapPointersNonTemporal is like memclrNoHeapPointers, but
+// clears the memory with stores that bypass the cache, except for
+// the unaligned ends. Clears of fewer than 256 bytes use
+// memclrNoHeapPointers.
+//
+// Implementations are in memnt_*.s.
+//
+//go:noescape
+func memclrNoHeapPointersNonTemporal(ptr unsafe.Pointer, n uintptr)
+
+// memmoveNonTemporal is like memmove, but copies the memory with stores
+// that bypass the cache, except for the unaligned ends. to and from
+// must not overlap. Copies of fewer than 256 bytes use memmove.
+//
+// Implementations are in memnt_*.s.
+//
+//go:noescape
+func memmoveNonTemporal(to, from unsafe.Pointer, n uintptr)
@@ -0,0 +1,144 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Memory pressure watching.
+//
+// The runtime can watch for signs that the system, or the cgroup the
+// program runs in, is running short of memory: on Linux, the counters
+// in the cgroup v2 memory.events file and the pressure stall
+// information in memory.pressure; on macOS, the kernel's VM pressure
+// level. A system goroutine polls the OS-specific memPressureSource
+// every memPressurePeriod and records changes of level.
+//
+// With GODEBUG=mempressure=1 the watcher starts with the program, and
+// rising pressure makes the runtime collect garbage and, when pressure
+// is critical, return free memory to the OS. runtime/debug starts the
+// watcher for NotifyMemoryPressure, and delivers the changes recorded
+// here to the program.
+
+package runtime
+
+import "unsafe"
+
+// memPressurePeriod is how often, in nanoseconds, the watcher polls
+// for memory pressure.
+const memPressurePeriod = 1e9
+
+// Memory pressure levels. They must match the values of
+// runtime/debug.MemoryPressure.
+const (
+	memPressureNone = iota
+	memPressureModerate
+	memPressureCritical
+)
+
+// memPressureEvent describes a change of memory pressure level.
+//
+// Its layout must match runtime/debug.memPressureEvent.
+type memPressureEvent struct {
+	seq      uint32 // number of changes of level so far
+	level    int32
+	unixNano int64 // Unix time in nanoseconds of the change
+}
+
+var memPressure struct {
+	lock    mutex
+	started bool             // the watcher has been started
+	last    memPressureEvent // the most recent change of level
+	g       *g               // goroutine waiting for a change, if any
+}
+
+// startMemPressureWatcher starts the goroutine watching for memory
+// pressure, unless it's already running.
+//
+//go:linkname startMemPressureWatcher runtime/debug.startMemPressureWatcher
+func startMemPressureWatcher() {
+	lock(&memPressure.lock)
+	start := !memPressure.started
+	memPressure.started = true
+	unlock(&memPressure.lock)
+
+	if start {
+		go memPressureWatcher()
+	}
+}
+
+// memPressureWatcher polls for memory pressure and records changes of
+// level. It exits if memory pressure can't be observed on this system.
+func memPressureWatcher() {
+	var src memPressureSource
+	if !src.init() {
+		return
+	}
+	for {
+		timeSleep(memPressurePeriod)
+		level := src.read()
+		old := memPressureRecord(level)
+		if debug.mempressure != 0 && level > old {
+			memPressureRespond(level)
+		}
+	}
+}
+
+// memPressureRecord records the current memory pressure level, waking
+// the goroutine waiting for a change if it differs from the previous
+// one, and returns the previous level.
+func memPressureRecord(level int32) (old int32) {
+	lock(&memPressure.lock)
+	old = memPressure.last.level
+	if level == old {
+		unlock(&memPressure.lock)
+		return old
+	}
+	sec, nsec, _ := time_now()
+	memPressure.last = memPressureEvent{
+		seq:      memPressure.last.seq + 1,
+		level:    level,
+		unixNano: sec*1e9 + int64(nsec),
+	}
+	gp := memPressure.g
+	memPressure.g = nil
+	unlock(&memPressure.lock)
+
+	if gp != nil {
+		goready(gp, 0)
+	}
+	return old
+}
+
+// memPressureRespond frees memory in response to memory pressure
+// rising to level.
+func memPressureRespond(level int32) {
+	GC()
+	if level >= memPressureCritical {
+		systemstack(func() { mheap_.scavengeAll() })
+	}
+}
+
+// readMemPressureEvent copies the most recent change of memory
+// pressure level into *e. If there hasn't been one, e.seq is 0.
+//
+//go:linkname readMemPressureEvent runtime/debug.readMemPressureEvent
+func readMemPressureEvent(e unsafe.Pointer) {
+	lock(&memPressure.lock)
+	*(*memPressureEvent)(e) = memPressure.last
+	unlock(&memPressure.lock)
+}
+
+// waitMemPressureEvent blocks until the memory pressure level changes
+// after change seq, then copies the most recent change into *e.
+// Changes between seq and e.seq were missed. Only one goroutine may
+// wait at a time.
+//
+//go:linkname waitMemPressureEvent runtime/debug.waitMemPressureEvent
+func waitMemPressureEvent(e unsafe.Pointer, seq uint32) {
+	lock(&memPressure.lock)
+	for memPressure.last.seq == seq {
+		memPressure.g = getg()
+		goparkunlock(&memPressure.lock, waitReasonMemPressureWait, traceBlockGeneric, 1)
+		lock(&memPressure.lock)
+	}
+	*(*memPressureEvent)(e) = memPressure.last
+	unlock(&memPressure.lock)
+}
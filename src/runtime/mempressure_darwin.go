@@ -0,0 +1,40 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+var sysctlVMPressureLevel = []byte("kern.memorystatus_vm_pressure_level\x00")
+
+// Values of kern.memorystatus_vm_pressure_level.
+const (
+	_kVMPressureNormal   = 1
+	_kVMPressureWarning  = 2
+	_kVMPressureCritical = 4
+)
+
+// memPressureSource observes memory pressure on macOS through the VM
+// pressure level the kernel reports for dispatch memory pressure
+// sources, polled with sysctl.
+type memPressureSource struct{}
+
+// init reports whether the pressure level can be read.
+func (s *memPressureSource) init() bool {
+	ret, _ := sysctlbynameInt32(sysctlVMPressureLevel)
+	return ret == 0
+}
+
+// read returns the current memory pressure level.
+func (s *memPressureSource) read() int32 {
+	ret, v := sysctlbynameInt32(sysctlVMPressureLevel)
+	if ret != 0 {
+		return memPressureNone
+	}
+	switch v {
+	case _kVMPressureWarning:
+		return memPressureModerate
+	case _kVMPressureCritical:
+		return memPressureCritical
+	}
+	return memPressureNone
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"internal/bytealg"
+	"unsafe"
+)
+
+// Thresholds of the pressure stall information, in percent of the
+// last 10 seconds in which some or all tasks were stalled on memory.
+const (
+	memPressurePSISome = 10 // moderate pressure
+	memPressurePSIFull = 10 // critical pressure
+)
+
+var procSelfCgroup = []byte("/proc/self/cgroup\x00")
+
+// memPressureSource observes memory pressure on Linux through the files
+// of the cgroup v2 hierarchy mounted at /sys/fs/cgroup.
+//
+// Rises of the counters of memory.events report that the cgroup was
+// throttled for exceeding memory.high (moderate pressure), or reached
+// memory.max or had tasks killed for lack of memory (critical
+// pressure). The pressure stall information in memory.pressure, or in
+// /proc/pressure/memory if the program isn't in a cgroup, reports
+// whether tasks spent a significant share of time waiting for memory.
+type memPressureSource struct {
+	events   []byte // NUL-terminated path of memory.events, or nil
+	pressure []byte // NUL-terminated path of the PSI file, or nil
+	buf      []byte
+
+	high, max, oom uint64 // counters of memory.events at the last read
+}
+
+// init locates the files to read, and reports whether any were found.
+func (s *memPressureSource) init() bool {
+	s.buf = make([]byte, 4096)
+	if dir, ok := s.cgroupDir(); ok {
+		s.events = s.path(dir + "/memory.events")
+		s.pressure = s.path(dir + "/memory.pressure")
+	}
+	if s.pressure == nil {
+		s.pressure = s.path("/proc/pressure/memory")
+	}
+	if s.events != nil {
+		s.readEvents()
+	}
+	return s.events != nil || s.pressure != nil
+}
+
+// cgroupDir returns the directory of the program's cgroup v2.
+func (s *memPressureSource) cgroupDir() (string, bool) {
+	b, ok := s.readFile(&procSelfCgroup[0])
+	if !ok {
+		return "", false
+	}
+	// The cgroup v2 line is the one with hierarchy ID 0 and no
+	// controllers: "0::/path".
+	for len(b) > 0 {
+		line := b
+		if i := bytealg.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		if len(line) > 3 && string(line[:3]) == "0::" {
+			return "/sys/fs/cgroup" + string(line[3:]), true
+		}
+	}
+	return "", false
+}
+
+// path returns name as a NUL-terminated path, or nil if it can't be
+// opened.
+func (s *memPressureSource) path(name string) []byte {
+	p := make([]byte, len(name)+1)
+	copy(p, name)
+	fd := open(&p[0], 0 /* O_RDONLY */, 0)
+	if fd < 0 {
+		return nil
+	}
+	closefd(fd)
+	return p
+}
+
+// readFile reads the file at the NUL-terminated path into s.buf, and
+// returns its contents, truncated to len(s.buf).
+func (s *memPressureSource) readFile(path *byte) ([]byte, bool) {
+	fd := open(path, 0 /* O_RDONLY */, 0)
+	if fd < 0 {
+		return nil, false
+	}
+	n := read(fd, unsafe.Pointer(&s.buf[0]), int32(len(s.buf)))
+	closefd(fd)
+	if n < 0 {
+		return nil, false
+	}
+	return s.buf[:n], true
+}
+
+// read returns the current memory pressure level.
+func (s *memPressureSource) read() int32 {
+	level := int32(memPressureNone)
+	if s.events != nil {
+		level = max(level, s.readEvents())
+	}
+	if s.pressure != nil {
+		level = max(level, s.readPressure())
+	}
+	return level
+}
+
+// readEvents updates the counters of memory.events, and returns the
+// memory pressure level their changes report.
+func (s *memPressureSource) readEvents() int32 {
+	b, ok := s.readFile(&s.events[0])
+	if !ok {
+		return memPressureNone
+	}
+	high := memEventsCount(b, "high")
+	limit := memEventsCount(b, "max")
+	oom := memEventsCount(b, "oom") + memEventsCount(b, "oom_kill")
+	level := int32(memPressureNone)
+	if high > s.high {
+		level = memPressureModerate
+	}
+	if limit > s.max || oom > s.oom {
+		level = memPressureCritical
+	}
+	s.high, s.max, s.oom = high, limit, oom
+	return level
+}
+
+// readPressure returns the memory pressure level reported by the
+// pressure stall information.
+func (s *memPressureSource) readPressure() int32 {
+	b, ok := s.readFile(&s.pressure[0])
+	if !ok {
+		return memPressureNone
+	}
+	switch {
+	case memPSIAvg10(b, "full") >= memPressurePSIFull:
+		return memPressureCritical
+	case memPSIAvg10(b, "some") >= memPressurePSISome:
+		return memPressureModerate
+	}
+	return memPressureNone
+}
+
+// memEventsCount returns the count of the given key in the contents of
+// a memory.events file, lines of the form "key count", or 0 if the key
+// isn't there.
+func memEventsCount(b []byte, key string) uint64 {
+	for len(b) > 0 {
+		line := b
+		if i := bytealg.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		if len(line) > len(key) && string(line[:len(key)]) == key && line[len(key)] == ' ' {
+			n, ok := atoi64(string(line[len(key)+1:]))
+			if !ok || n < 0 {
+				return 0
+			}
+			return uint64(n)
+		}
+	}
+	return 0
+}
+
+// memPSIAvg10 returns the integer part of the avg10 field of the given
+// kind ("some" or "full") in the contents of a pressure stall
+// information file, lines of the form
+//
+//	some avg10=1.23 avg60=0.50 avg300=0.10 total=123456
+//
+// or 0 if it isn't there.
+func memPSIAvg10(b []byte, kind string) int {
+	prefix := kind + " avg10="
+	for len(b) > 0 {
+		line := b
+		if i := bytealg.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		if len(line) < len(prefix) || string(line[:len(prefix)]) != prefix {
+			continue
+		}
+		line = line[len(prefix):]
+		if i := bytealg.IndexByte(line, '.'); i >= 0 {
+			line = line[:i]
+		}
+		n, ok := atoi(string(line))
+		if !ok {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
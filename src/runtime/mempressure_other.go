@@ -0,0 +1,19 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !linux
+
+package runtime
+
+// memPressureSource observes memory pressure. It's not supported on
+// this system.
+type memPressureSource struct{}
+
+func (s *memPressureSource) init() bool {
+	return false
+}
+
+func (s *memPressureSource) read() int32 {
+	return memPressureNone
+}
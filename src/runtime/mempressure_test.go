@@ -0,0 +1,46 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	. "runtime"
+	"testing"
+	"time"
+)
+
+func TestMemPressureEvents(t *testing.T) {
+	old := MemPressureRecord(MemPressureNone)
+	defer MemPressureRecord(old)
+	seq, _ := WaitMemPressure(^uint32(0))
+
+	type change struct {
+		seq   uint32
+		level int32
+	}
+	c := make(chan change)
+	go func() {
+		s, l := WaitMemPressure(seq)
+		c <- change{s, l}
+	}()
+	select {
+	case ch := <-c:
+		t.Fatalf("got change %+v without recording one", ch)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if got := MemPressureRecord(MemPressureCritical); got != MemPressureNone {
+		t.Errorf("MemPressureRecord returned previous level %d, want %d", got, MemPressureNone)
+	}
+	if ch := <-c; ch.seq != seq+1 || ch.level != MemPressureCritical {
+		t.Errorf("got change %+v, want {seq:%d level:%d}", ch, seq+1, MemPressureCritical)
+	}
+
+	// Recording the same level again isn't a change.
+	MemPressureRecord(MemPressureCritical)
+	MemPressureRecord(MemPressureModerate)
+	if s, l := WaitMemPressure(seq + 1); s != seq+2 || l != MemPressureModerate {
+		t.Errorf("got change {seq:%d level:%d}, want {seq:%d level:%d}", s, l, seq+2, MemPressureModerate)
+	}
+}
@@ -8,6 +8,8 @@ package runtime
 
 import (
 	"internal/godebugs"
+	"internal/runtime/atomic"
+	"internal/runtime/sys"
 	"unsafe"
 )
 
@@ -24,6 +26,7 @@ var (
 
 	sizeClassBuckets []float64
 	timeHistBuckets  []float64
+	runqLenBuckets   []float64
 )
 
 type metricData struct {
@@ -84,6 +87,9 @@ func initMetrics() {
 	sizeClassBuckets = append(sizeClassBuckets, float64Inf())
 
 	timeHistBuckets = timeHistogramMetricsBuckets()
+
+	// A local run queue holds up to 256 goroutines, plus one in runnext.
+	runqLenBuckets = []float64{0, 1, 2, 4, 8, 16, 32, 64, 128, 256, float64Inf()}
 	metrics = map[string]metricData{
 		"/cgo/go-to-c-calls:calls": {
 			compute: func(_ *statAggregate, out *metricValue) {
@@ -168,6 +174,17 @@ func initMetrics() {
 				out.scalar = float64bits(nsToSec(in.cpuStats.UserTime))
 			},
 		},
+		"/gc/assist/latency:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				gcController.assistLatency.write(out)
+			},
+		},
+		"/gc/assist/slow:assists": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = gcController.slowAssists.Load()
+			},
+		},
 		"/gc/cycles/automatic:gc-cycles": {
 			deps: makeStatDepSet(sysStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -189,6 +206,30 @@ func initMetrics() {
 				out.scalar = in.sysStats.gcCyclesDone
 			},
 		},
+		"/gc/pauses/mark-termination:cpu-seconds": {
+			deps: makeStatDepSet(cpuStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindFloat64
+				out.scalar = float64bits(nsToSec(in.cpuStats.GCMarkTermTime))
+			},
+		},
+		"/gc/pauses/mark-termination:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				sched.stwTotalTimeMarkTerm.write(out)
+			},
+		},
+		"/gc/pauses/sweep-termination:cpu-seconds": {
+			deps: makeStatDepSet(cpuStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindFloat64
+				out.scalar = float64bits(nsToSec(in.cpuStats.GCSweepTermTime))
+			},
+		},
+		"/gc/pauses/sweep-termination:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				sched.stwTotalTimeSweepTerm.write(out)
+			},
+		},
 		"/gc/scan/globals:bytes": {
 			deps: makeStatDepSet(gcStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -288,6 +329,13 @@ func initMetrics() {
 				out.scalar = uint64(gcController.gcPercent.Load())
 			},
 		},
+		"/gc/heap/largest-free:bytes": {
+			deps: makeStatDepSet(sysStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.sysStats.largestFree
+			},
+		},
 		"/gc/heap/live:bytes": {
 			deps: makeStatDepSet(heapStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -295,6 +343,26 @@ func initMetrics() {
 				out.scalar = gcController.heapMarked
 			},
 		},
+		"/gc/heap/objects-by-class:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(sizeClassBuckets)
+				hist.counts[len(hist.counts)-1] = sweep.live.lastLargeBytes.Load()
+				// As for allocs-by-size, size class 0 is for large objects,
+				// which go in the last bucket.
+				for i := range hist.counts[:len(hist.counts)-1] {
+					hist.counts[i] = sweep.live.lastObjects[i+1].Load() * uint64(class_to_size[i+1])
+				}
+			},
+		},
+		"/gc/heap/objects-by-class:objects": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(sizeClassBuckets)
+				hist.counts[len(hist.counts)-1] = sweep.live.lastObjects[0].Load()
+				for i := range hist.counts[:len(hist.counts)-1] {
+					hist.counts[i] = sweep.live.lastObjects[i+1].Load()
+				}
+			},
+		},
 		"/gc/heap/objects:objects": {
 			deps: makeStatDepSet(heapStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -302,6 +370,12 @@ func initMetrics() {
 				out.scalar = in.heapStats.numObjects
 			},
 		},
+		"/gc/heap/pinned:objects": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = uint64(pinnedObjects.Load())
+			},
+		},
 		"/gc/heap/tiny/allocs:objects": {
 			deps: makeStatDepSet(heapStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -309,12 +383,68 @@ func initMetrics() {
 				out.scalar = in.heapStats.tinyAllocCount
 			},
 		},
+		"/gc/heap/types:types": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = 0
+				heapTypesForEach(func(typ *_type, other bool, _, _ int64) {
+					if typ != nil {
+						out.scalar++
+					}
+				})
+			},
+		},
+		"/gc/heap/user-arena/chunk-tail:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = mheap_.userArena.tailBytes.Load()
+			},
+		},
+		"/gc/heap/user-arena/padding:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = mheap_.userArena.paddingBytes.Load()
+			},
+		},
+		"/gc/heap/user-arena/quarantined:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = uint64(mheap_.userArena.quarantinedBytes.Load())
+			},
+		},
+		"/gc/limiter/enabled-time:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindFloat64
+				out.scalar = float64bits(nsToSec(gcCPULimiter.enabledTime.Load()))
+			},
+		},
+		"/gc/limiter/enabled:boolean": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = 0
+				if gcCPULimiter.limiting() {
+					out.scalar = 1
+				}
+			},
+		},
+		"/gc/limiter/enabled:events": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = gcCPULimiter.enabledCount.Load()
+			},
+		},
 		"/gc/limiter/last-enabled:gc-cycle": {
 			compute: func(_ *statAggregate, out *metricValue) {
 				out.kind = metricKindUint64
 				out.scalar = uint64(gcCPULimiter.lastEnabledCycle.Load())
 			},
 		},
+		"/gc/limiter/skipped-assists:assists": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = gcCPULimiter.skippedAssists.Load()
+			},
+		},
 		"/gc/pauses:seconds": {
 			compute: func(_ *statAggregate, out *metricValue) {
 				// N.B. this is identical to /sched/pauses/total/gc:seconds.
@@ -340,7 +470,7 @@ func initMetrics() {
 			deps: makeStatDepSet(heapStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
 				out.kind = metricKindUint64
-				out.scalar = in.heapStats.inObjects
+				out.scalar = in.heapStats.inObjects - uint64(in.heapStats.inUserArena)
 			},
 		},
 		"/memory/classes/heap/released:bytes": {
@@ -364,6 +494,20 @@ func initMetrics() {
 				out.scalar = uint64(in.heapStats.inHeap) - in.heapStats.inObjects
 			},
 		},
+		"/memory/classes/heap/user-arena/inuse:bytes": {
+			deps: makeStatDepSet(heapStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = uint64(in.heapStats.inUserArena - in.heapStats.inUserArenaReusable)
+			},
+		},
+		"/memory/classes/heap/user-arena/reusable:bytes": {
+			deps: makeStatDepSet(heapStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = uint64(in.heapStats.inUserArenaReusable)
+			},
+		},
 		"/memory/classes/metadata/mcache/free:bytes": {
 			deps: makeStatDepSet(sysStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -430,6 +574,12 @@ func initMetrics() {
 					in.sysStats.gcMiscSys + in.sysStats.otherSys
 			},
 		},
+		"/memory/mapped-files:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = mappedFileBytes.Load()
+			},
+		},
 		"/sched/gomaxprocs:threads": {
 			compute: func(_ *statAggregate, out *metricValue) {
 				out.kind = metricKindUint64
@@ -442,11 +592,101 @@ func initMetrics() {
 				out.scalar = uint64(gcount())
 			},
 		},
+		"/sched/goroutines-created:goroutines": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = sched.goroutinesCreated.Load()
+				lock(&allpLock)
+				for _, pp := range allp {
+					out.scalar += pp.goroutinesCreated.Load()
+				}
+				unlock(&allpLock)
+			},
+		},
+		"/sched/goroutines/lifetimes:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(timeHistBuckets)
+				clear(hist.counts)
+				sched.goroutineLifetimes.addTo(hist)
+				lock(&allpLock)
+				for _, pp := range allp {
+					pp.goroutineLifetimes.addTo(hist)
+				}
+				unlock(&allpLock)
+			},
+		},
+		"/sched/goroutines/runnable:goroutines": {
+			deps: makeStatDepSet(schedStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.schedStats.goroutines.runnable
+			},
+		},
+		"/sched/goroutines/running:goroutines": {
+			deps: makeStatDepSet(schedStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.schedStats.goroutines.running
+			},
+		},
+		"/sched/goroutines/syscall:goroutines": {
+			deps: makeStatDepSet(schedStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.schedStats.goroutines.syscall
+			},
+		},
+		"/sched/goroutines/waiting:goroutines": {
+			deps: makeStatDepSet(schedStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.schedStats.goroutines.waiting
+			},
+		},
+		"/sched/latencies/all:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(timeHistBuckets)
+				clear(hist.counts)
+				sched.runLatency.addTo(hist)
+				lock(&allpLock)
+				for _, pp := range allp {
+					pp.runLatency.addTo(hist)
+				}
+				unlock(&allpLock)
+			},
+		},
+		"/sched/latencies/longest-runnable:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindFloat64
+				out.scalar = float64bits(nsToSec(longestRunnableWait(nanotime())))
+			},
+		},
 		"/sched/latencies:seconds": {
 			compute: func(_ *statAggregate, out *metricValue) {
 				sched.timeToRun.write(out)
 			},
 		},
+		"/sched/runqueue/global:goroutines": {
+			deps: makeStatDepSet(runqStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.runqStats.global
+			},
+		},
+		"/sched/runqueue/local:goroutines": {
+			deps: makeStatDepSet(runqStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = in.runqStats.local
+			},
+		},
+		"/sched/runqueue/per-p:goroutines": {
+			deps: makeStatDepSet(runqStatsDep),
+			compute: func(in *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(runqLenBuckets)
+				copy(hist.counts, in.runqStats.perP[:])
+			},
+		},
 		"/sched/pauses/stopping/gc:seconds": {
 			compute: func(_ *statAggregate, out *metricValue) {
 				sched.stwStoppingTimeGC.write(out)
@@ -514,10 +754,12 @@ func godebug_registerMetric(name string, read func() uint64) {
 type statDep uint
 
 const (
-	heapStatsDep statDep = iota // corresponds to heapStatsAggregate
-	sysStatsDep                 // corresponds to sysStatsAggregate
-	cpuStatsDep                 // corresponds to cpuStatsAggregate
-	gcStatsDep                  // corresponds to gcStatsAggregate
+	heapStatsDep  statDep = iota // corresponds to heapStatsAggregate
+	sysStatsDep                  // corresponds to sysStatsAggregate
+	cpuStatsDep                  // corresponds to cpuStatsAggregate
+	gcStatsDep                   // corresponds to gcStatsAggregate
+	schedStatsDep                // corresponds to schedStatsAggregate
+	runqStatsDep                 // corresponds to runqStatsAggregate
 	numStatsDeps
 )
 
@@ -641,6 +883,7 @@ type sysStatsAggregate struct {
 	heapGoal       uint64
 	gcCyclesDone   uint64
 	gcCyclesForced uint64
+	largestFree    uint64
 }
 
 // compute populates the sysStatsAggregate with values from the runtime.
@@ -659,6 +902,7 @@ func (a *sysStatsAggregate) compute() {
 		a.mSpanInUse = uint64(mheap_.spanalloc.inuse)
 		a.mCacheSys = memstats.mcache_sys.load()
 		a.mCacheInUse = uint64(mheap_.cachealloc.inuse)
+		a.largestFree = uint64(mheap_.pages.largestFree() * pageSize)
 		unlock(&mheap_.lock)
 	})
 }
@@ -696,6 +940,61 @@ func (a *gcStatsAggregate) compute() {
 	a.totalScan = a.heapScan + a.stackScan + a.globalsScan
 }
 
+// schedStatsAggregate represents goroutine counts obtained from the
+// scheduler together, so that they're roughly consistent.
+type schedStatsAggregate struct {
+	goroutines goroutineCounts
+}
+
+// compute populates the schedStatsAggregate with values from the runtime.
+func (a *schedStatsAggregate) compute() {
+	a.goroutines = goroutineCounts{}
+	countGoroutines(&a.goroutines, nil)
+}
+
+// runqStatsAggregate represents the lengths of the run queues
+// obtained from the runtime.
+type runqStatsAggregate struct {
+	global uint64
+	local  uint64
+
+	// perP counts the Ps by the length of their local run queue,
+	// including runnext, in runqLenBuckets.
+	perP [10]uint64
+}
+
+// compute populates the runqStatsAggregate with values from the runtime.
+//
+// It doesn't lock the local run queues, so the lengths may be slightly
+// stale.
+func (a *runqStatsAggregate) compute() {
+	*a = runqStatsAggregate{}
+	lock(&sched.lock)
+	a.global = uint64(sched.runqsize)
+	unlock(&sched.lock)
+
+	lock(&allpLock)
+	for _, pp := range allp {
+		h := atomic.Load(&pp.runqhead)
+		t := atomic.Load(&pp.runqtail)
+		n := t - h
+		if n > uint32(len(pp.runq)) {
+			// Read an inconsistent h and t.
+			n = 0
+		}
+		if pp.runnext != 0 {
+			n++
+		}
+		a.local += uint64(n)
+		bucket := 0
+		if n > 0 {
+			bucket = min(sys.Len64(uint64(n)), len(a.perP)-1)
+		}
+		a.perP[bucket]++
+	}
+	unlock(&allpLock)
+}
+
 // nsToSec takes a duration in nanoseconds and converts it to seconds as
 // a float64.
 func nsToSec(ns int64) float64 {
@@ -708,11 +1007,13 @@ func nsToSec(ns int64) float64 {
 // as a set of these aggregates that it has populated. The aggregates
 // are populated lazily by its ensure method.
 type statAggregate struct {
-	ensured   statDepSet
-	heapStats heapStatsAggregate
-	sysStats  sysStatsAggregate
-	cpuStats  cpuStatsAggregate
-	gcStats   gcStatsAggregate
+	ensured    statDepSet
+	heapStats  heapStatsAggregate
+	sysStats   sysStatsAggregate
+	cpuStats   cpuStatsAggregate
+	gcStats    gcStatsAggregate
+	schedStats schedStatsAggregate
+	runqStats  runqStatsAggregate
 }
 
 // ensure populates statistics aggregates determined by deps if they
@@ -735,6 +1036,10 @@ func (a *statAggregate) ensure(deps *statDepSet) {
 			a.cpuStats.compute()
 		case gcStatsDep:
 			a.gcStats.compute()
+		case schedStatsDep:
+			a.schedStats.compute()
+		case runqStatsDep:
+			a.runqStats.compute()
 		}
 	}
 	a.ensured = a.ensured.union(missing)
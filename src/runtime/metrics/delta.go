@@ -0,0 +1,191 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"cmp"
+	"iter"
+	"math"
+	"slices"
+	"time"
+	"unsafe"
+)
+
+// Sub returns the change of a metric from prev to cur, two values read
+// for the same metric, prev first.
+//
+// For a KindUint64 or KindFloat64 value, the result is cur minus prev.
+// For a KindFloat64Histogram value, it's a new histogram whose counts
+// are those of cur minus those of prev, and whose buckets are those of
+// cur. Uint64 values and counts never go below zero, since cumulative
+// metrics don't decrease.
+//
+// Sub is meant for cumulative metrics; for others, the difference is
+// rarely meaningful. Sub panics if cur and prev have different kinds, or
+// are histograms with different buckets.
+func Sub(cur, prev Value) Value {
+	if cur.kind != prev.kind {
+		panic("metrics: Sub of values of different kinds")
+	}
+	switch cur.kind {
+	case KindUint64:
+		var d uint64
+		if c, p := cur.Uint64(), prev.Uint64(); c > p {
+			d = c - p
+		}
+		return Value{kind: KindUint64, scalar: d}
+	case KindFloat64:
+		return float64Value(cur.Float64() - prev.Float64())
+	case KindFloat64Histogram:
+		c, p := cur.Float64Histogram(), prev.Float64Histogram()
+		if !slices.Equal(c.Buckets, p.Buckets) {
+			panic("metrics: Sub of histograms with different buckets")
+		}
+		d := &Float64Histogram{
+			Counts:  make([]uint64, len(c.Counts)),
+			Buckets: c.Buckets,
+		}
+		for i := range d.Counts {
+			if c.Counts[i] > p.Counts[i] {
+				d.Counts[i] = c.Counts[i] - p.Counts[i]
+			}
+		}
+		return histogramValue(d)
+	}
+	return Value{}
+}
+
+// Rate returns the rate per second at which a scalar metric changed from
+// prev to cur, two values read interval apart, prev first. It panics if
+// the values aren't scalars of the same kind.
+func Rate(cur, prev Value, interval time.Duration) float64 {
+	return rate(Sub(cur, prev), interval)
+}
+
+// rate returns the rate per second of a change d of a scalar metric
+// over interval.
+func rate(d Value, interval time.Duration) float64 {
+	var x float64
+	switch d.kind {
+	case KindUint64:
+		x = float64(d.Uint64())
+	case KindFloat64:
+		x = d.Float64()
+	default:
+		panic("metrics: Rate of a non-scalar metric")
+	}
+	if interval <= 0 {
+		return 0
+	}
+	return x / interval.Seconds()
+}
+
+// A Delta is how a set of metrics changed over an interval.
+type Delta struct {
+	// Samples holds, for each cumulative metric, its change over the
+	// interval, as computed by Sub, and for each other metric, its
+	// value at the end of the interval.
+	Samples []Sample
+
+	// Interval is the time between the two reads of the metrics.
+	Interval time.Duration
+}
+
+// Rate returns the rate per second at which Samples[i], a cumulative
+// scalar metric, changed over the interval. It panics if the metric is
+// a histogram.
+func (d *Delta) Rate(i int) float64 {
+	return rate(d.Samples[i].Value, d.Interval)
+}
+
+// NewDelta returns how the metrics in cur changed since they were read
+// in prev, interval earlier. prev and cur must name the same metrics in
+// the same order.
+func NewDelta(prev, cur []Sample, interval time.Duration) *Delta {
+	if len(prev) != len(cur) {
+		panic("metrics: NewDelta of samples of different lengths")
+	}
+	d := &Delta{Samples: make([]Sample, len(cur)), Interval: interval}
+	descs := All()
+	for i := range cur {
+		if prev[i].Name != cur[i].Name {
+			panic("metrics: NewDelta of samples of different metrics")
+		}
+		d.Samples[i].Name = cur[i].Name
+		j, ok := slices.BinarySearchFunc(descs, cur[i].Name, func(d Description, name string) int {
+			return cmp.Compare(d.Name, name)
+		})
+		if ok && descs[j].Cumulative && cur[i].Value.kind != KindBad {
+			d.Samples[i].Value = Sub(cur[i].Value, prev[i].Value)
+		} else {
+			d.Samples[i].Value = cloneValue(cur[i].Value)
+		}
+	}
+	return d
+}
+
+// Stream returns an iterator over how the named metrics change, from
+// one interval to the next. It reads the metrics when iteration starts,
+// and then every interval, and yields how they changed since the
+// previous read, until the loop over it stops.
+//
+// For example, this prints the rate of garbage collection every second:
+//
+//	for d := range metrics.Stream([]string{"/gc/cycles/total:gc-cycles"}, time.Second) {
+//		fmt.Printf("%.1f GCs/s\n", d.Rate(0))
+//	}
+func Stream(names []string, interval time.Duration) iter.Seq[*Delta] {
+	if interval <= 0 {
+		panic("metrics: non-positive interval for Stream")
+	}
+	return func(yield func(*Delta) bool) {
+		prev := make([]Sample, len(names))
+		cur := make([]Sample, len(names))
+		for i, name := range names {
+			prev[i].Name = name
+			cur[i].Name = name
+		}
+		if len(names) > 0 {
+			Read(prev)
+		}
+		last := time.Now()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if len(names) > 0 {
+				Read(cur)
+			}
+			now := time.Now()
+			if !yield(NewDelta(prev, cur, now.Sub(last))) {
+				return
+			}
+			// The metrics just read are the baseline for the next
+			// interval, and the previous baseline's memory is reused.
+			prev, cur = cur, prev
+			last = now
+		}
+	}
+}
+
+func float64Value(x float64) Value {
+	return Value{kind: KindFloat64, scalar: math.Float64bits(x)}
+}
+
+func histogramValue(h *Float64Histogram) Value {
+	return Value{kind: KindFloat64Histogram, pointer: unsafe.Pointer(h)}
+}
+
+// cloneValue returns a copy of v that doesn't share memory with it,
+// except for histogram buckets, which are never modified.
+func cloneValue(v Value) Value {
+	if v.kind != KindFloat64Histogram {
+		return v
+	}
+	h := v.Float64Histogram()
+	return histogramValue(&Float64Histogram{
+		Counts:  slices.Clone(h.Counts),
+		Buckets: h.Buckets,
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDelta(t *testing.T) {
+	names := []string{
+		"/gc/cycles/total:gc-cycles",   // cumulative uint64
+		"/gc/pauses:seconds",           // cumulative histogram
+		"/sched/goroutines:goroutines", // uint64 gauge
+		appMetricName("delta:events"),  // application counter
+		appMetricName("unknown:events"),
+	}
+	c := metrics.NewCounter(names[3], "Count of events.")
+	prev := make([]metrics.Sample, len(names))
+	cur := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		prev[i].Name = name
+		cur[i].Name = name
+	}
+	metrics.Read(prev)
+	runtime.GC()
+	runtime.GC()
+	c.Add(10)
+	metrics.Read(cur)
+
+	d := metrics.NewDelta(prev, cur, 2*time.Second)
+	if got := d.Samples[0].Value.Uint64(); got < 2 {
+		t.Errorf("GC cycles delta is %d, want at least 2", got)
+	}
+	pauses := d.Samples[1].Value.Float64Histogram()
+	var n uint64
+	for _, c := range pauses.Counts {
+		n += c
+	}
+	if n < 4 {
+		t.Errorf("GC pauses delta has %d pauses, want at least 4", n)
+	}
+	if !slices.Equal(pauses.Buckets, cur[1].Value.Float64Histogram().Buckets) {
+		t.Errorf("GC pauses delta has different buckets from the metric")
+	}
+	if got, want := d.Samples[2].Value.Uint64(), cur[2].Value.Uint64(); got != want {
+		t.Errorf("goroutines in delta is %d, want the last value %d", got, want)
+	}
+	if got := d.Rate(3); got != 5 {
+		t.Errorf("event rate is %v, want 5", got)
+	}
+	if k := d.Samples[4].Value.Kind(); k != metrics.KindBad {
+		t.Errorf("unknown metric in delta has kind %v, want KindBad", k)
+	}
+
+	// The delta doesn't share memory with the samples.
+	counts := slices.Clone(pauses.Counts)
+	runtime.GC()
+	metrics.Read(cur)
+	if !slices.Equal(pauses.Counts, counts) {
+		t.Errorf("GC pauses delta changed by Read")
+	}
+}
+
+func TestSubHistogram(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 0, 1, math.Inf(1)}
+	s := []metrics.Sample{{Name: appMetricName("sub-cur:seconds")}, {Name: appMetricName("sub-prev:seconds")}}
+	cur := metrics.NewHistogram(s[0].Name, "", buckets)
+	prev := metrics.NewHistogram(s[1].Name, "", buckets)
+	for _, x := range []float64{-1, 0.5} {
+		prev.Observe(x)
+		cur.Observe(x)
+	}
+	cur.Observe(0.5)
+	cur.Observe(math.Inf(1))
+	metrics.Read(s)
+	h := metrics.Sub(s[0].Value, s[1].Value).Float64Histogram()
+	if want := []uint64{0, 1, 1}; !slices.Equal(h.Counts, want) {
+		t.Errorf("Sub of histograms has counts %v, want %v", h.Counts, want)
+	}
+}
+
+func TestStream(t *testing.T) {
+	names := []string{appMetricName("stream:events"), "/gc/pauses:seconds"}
+	c := metrics.NewCounter(names[0], "Count of events.")
+	i := 0
+	for d := range metrics.Stream(names, 10*time.Millisecond) {
+		if d.Interval < 10*time.Millisecond {
+			t.Errorf("Stream interval is %v, want at least 10ms", d.Interval)
+		}
+		if i > 0 {
+			if got := d.Samples[0].Value.Uint64(); got != uint64(i) {
+				t.Errorf("iteration %d: counter delta is %d, want %d", i, got, i)
+			}
+		}
+		if d.Samples[1].Value.Kind() != metrics.KindFloat64Histogram {
+			t.Errorf("iteration %d: histogram kind is %v", i, d.Samples[1].Value.Kind())
+		}
+		i++
+		if i == 3 {
+			break
+		}
+		c.Add(uint64(i))
+	}
+}
@@ -174,6 +174,23 @@ var allDesc = []Description{
 		Kind:       KindFloat64,
 		Cumulative: true,
 	},
+	{
+		Name: "/gc/assist/latency:seconds",
+		Description: "Distribution of the durations of individual GC assists, " +
+			"from when a goroutine starts assisting the GC until it may allocate " +
+			"again, including time spent waiting for the GC to make progress. " +
+			"Bucket counts increase monotonically.",
+		Kind:       KindFloat64Histogram,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/assist/slow:assists",
+		Description: "Count of GC assists that took longer than the target set by " +
+			"the MaxAssistLatency field of runtime/debug.GCPolicy. Always zero if " +
+			"no target is set.",
+		Kind:       KindUint64,
+		Cumulative: true,
+	},
 	{
 		Name:        "/gc/cycles/automatic:gc-cycles",
 		Description: "Count of completed GC cycles generated by the Go runtime.",
@@ -258,16 +275,47 @@ var allDesc = []Description{
 		Description: "Heap size target for the end of the GC cycle.",
 		Kind:        KindUint64,
 	},
+	{
+		Name: "/gc/heap/largest-free:bytes",
+		Description: "Size of the largest contiguous run of free heap memory, whether or not it has been " +
+			"returned to the underlying system. This is the largest object the heap can allocate " +
+			"without growing. Compare with /memory/classes/heap/free:bytes and " +
+			"/memory/classes/heap/released:bytes to gauge the fragmentation of free memory.",
+		Kind: KindUint64,
+	},
 	{
 		Name:        "/gc/heap/live:bytes",
 		Description: "Heap memory occupied by live objects that were marked by the previous GC.",
 		Kind:        KindUint64,
 	},
+	{
+		Name: "/gc/heap/objects-by-class:bytes",
+		Description: "Distribution of the heap memory occupied by objects that were live at the end of " +
+			"the last GC cycle whose sweep has completed, by size class. Each bucket covers the " +
+			"object sizes of one size class and counts the bytes of the objects in it. The last " +
+			"bucket is for large objects, which have no size class. Objects in user arenas aren't " +
+			"included. Compare with /gc/heap/objects-by-class:objects.",
+		Kind: KindFloat64Histogram,
+	},
+	{
+		Name: "/gc/heap/objects-by-class:objects",
+		Description: "Distribution of the heap objects that were live at the end of the last GC cycle " +
+			"whose sweep has completed, by size class. Each bucket covers the object sizes of one " +
+			"size class and counts the objects in it. The last bucket is for large objects, which " +
+			"have no size class. Objects in user arenas aren't included. Note that tiny objects, " +
+			"as defined by /gc/heap/tiny/allocs:objects, are counted by their blocks.",
+		Kind: KindFloat64Histogram,
+	},
 	{
 		Name:        "/gc/heap/objects:objects",
 		Description: "Number of objects, live or unswept, occupying heap memory.",
 		Kind:        KindUint64,
 	},
+	{
+		Name:        "/gc/heap/pinned:objects",
+		Description: "Number of heap objects currently pinned, for example by runtime.Pinner.",
+		Kind:        KindUint64,
+	},
 	{
 		Name: "/gc/heap/tiny/allocs:objects",
 		Description: "Count of small allocations that are packed together into blocks. " +
@@ -278,6 +326,56 @@ var allDesc = []Description{
 		Kind:       KindUint64,
 		Cumulative: true,
 	},
+	{
+		Name: "/gc/heap/types:types",
+		Description: "Number of distinct types of live or unswept heap objects. " +
+			"Only counted with GODEBUG=heaptypes=1, and zero otherwise. " +
+			"See runtime/debug.ReadHeapTypeStats for the objects and bytes of each type.",
+		Kind: KindUint64,
+	},
+	{
+		Name: "/gc/heap/user-arena/chunk-tail:bytes",
+		Description: "Cumulative bytes left unused at the end of user arena chunks because " +
+			"the next value allocated from the arena didn't fit, counted when the arena " +
+			"moves on to another chunk, or is reset, released to a mark, or freed.",
+		Kind:       KindUint64,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/heap/user-arena/padding:bytes",
+		Description: "Cumulative bytes of user arena chunks lost to alignment padding between " +
+			"values, counted when the arena moves on to another chunk, or is reset, " +
+			"released to a mark, or freed.",
+		Kind:       KindUint64,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/heap/user-arena/quarantined:bytes",
+		Description: "Address space of user arena chunks that have been freed and set to fault, " +
+			"but are waiting for the garbage collector to find no more references into them " +
+			"before they may be reused. This memory is not backed by physical memory, so it " +
+			"is not counted in /memory/classes.",
+		Kind: KindUint64,
+	},
+	{
+		Name: "/gc/limiter/enabled-time:seconds",
+		Description: "Estimated total wall-clock time the GC CPU limiter has been enabled. " +
+			"While enabled, the limiter reduces the CPU time the GC spends on assists, " +
+			"letting the heap grow beyond its goal.",
+		Kind:       KindFloat64,
+		Cumulative: true,
+	},
+	{
+		Name:        "/gc/limiter/enabled:boolean",
+		Description: "1 if the GC CPU limiter is currently enabled, 0 otherwise.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/gc/limiter/enabled:events",
+		Description: "Count of times the GC CPU limiter has been enabled.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
 	{
 		Name: "/gc/limiter/last-enabled:gc-cycle",
 		Description: "GC cycle the last time the GC CPU limiter was enabled. " +
@@ -287,6 +385,51 @@ var allDesc = []Description{
 			"The first GC cycle is cycle 1, so a value of 0 indicates that it was never enabled.",
 		Kind: KindUint64,
 	},
+	{
+		Name: "/gc/limiter/skipped-assists:assists",
+		Description: "Count of times a goroutine that owed the GC assist work did not assist " +
+			"because the GC CPU limiter was enabled. The goroutine remains in debt and tries " +
+			"again on its next allocation, so one goroutine may be counted many times.",
+		Kind:       KindUint64,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/pauses/mark-termination:cpu-seconds",
+		Description: "Estimated total CPU time spent with the application paused by " +
+			"the GC for mark termination, computed like " +
+			"/cpu/classes/gc/pause:cpu-seconds. Mark termination finishes the mark " +
+			"phase, including processing finalizers, and prepares the sweep phase. " +
+			"Together with /gc/pauses/sweep-termination:cpu-seconds, this adds up " +
+			"to /cpu/classes/gc/pause:cpu-seconds.",
+		Kind:       KindFloat64,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/pauses/mark-termination:seconds",
+		Description: "Distribution of individual GC mark termination pause latencies. " +
+			"These are the samples of /sched/pauses/total/gc:seconds taken at the " +
+			"end of the mark phase. Bucket counts increase monotonically.",
+		Kind:       KindFloat64Histogram,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/pauses/sweep-termination:cpu-seconds",
+		Description: "Estimated total CPU time spent with the application paused by " +
+			"the GC for sweep termination, computed like " +
+			"/cpu/classes/gc/pause:cpu-seconds. Sweep termination finishes " +
+			"sweeping from the previous cycle and prepares the mark phase, " +
+			"including enabling the write barrier.",
+		Kind:       KindFloat64,
+		Cumulative: true,
+	},
+	{
+		Name: "/gc/pauses/sweep-termination:seconds",
+		Description: "Distribution of individual GC sweep termination pause latencies. " +
+			"These are the samples of /sched/pauses/total/gc:seconds taken at the " +
+			"start of a GC cycle. Bucket counts increase monotonically.",
+		Kind:       KindFloat64Histogram,
+		Cumulative: true,
+	},
 	{
 		Name:        "/gc/pauses:seconds",
 		Description: "Deprecated. Prefer the identical /sched/pauses/total/gc:seconds.",
@@ -327,9 +470,10 @@ var allDesc = []Description{
 		Kind: KindUint64,
 	},
 	{
-		Name:        "/memory/classes/heap/objects:bytes",
-		Description: "Memory occupied by live objects and dead objects that have not yet been marked free by the garbage collector.",
-		Kind:        KindUint64,
+		Name: "/memory/classes/heap/objects:bytes",
+		Description: "Memory occupied by live objects and dead objects that have not yet been marked free by the garbage collector. " +
+			"Memory in user arena chunks is counted separately, in /memory/classes/heap/user-arena.",
+		Kind: KindUint64,
 	},
 	{
 		Name: "/memory/classes/heap/released:bytes",
@@ -350,6 +494,18 @@ var allDesc = []Description{
 		Description: "Memory that is reserved for heap objects but is not currently used to hold heap objects.",
 		Kind:        KindUint64,
 	},
+	{
+		Name: "/memory/classes/heap/user-arena/inuse:bytes",
+		Description: "Memory in user arena chunks that are held by arenas, or have been freed " +
+			"by arenas but not yet set to fault.",
+		Kind: KindUint64,
+	},
+	{
+		Name: "/memory/classes/heap/user-arena/reusable:bytes",
+		Description: "Memory in user arena chunks that have been released by arenas and are " +
+			"ready to be reused by other arenas without going through the garbage collector.",
+		Kind: KindUint64,
+	},
 	{
 		Name:        "/memory/classes/metadata/mcache/free:bytes",
 		Description: "Memory that is reserved for runtime mcache structures, but not in-use.",
@@ -399,16 +555,65 @@ var allDesc = []Description{
 		Description: "All memory mapped by the Go runtime into the current process as read-write. Note that this does not include memory mapped by code called via cgo or via the syscall package. Sum of all metrics in /memory/classes.",
 		Kind:        KindUint64,
 	},
+	{
+		Name: "/memory/mapped-files:bytes",
+		Description: "Memory mapped from files by runtime/mem.MapFile. This memory is backed by the files, " +
+			"so it is not part of /memory/classes/total:bytes and does not count toward the memory limit.",
+		Kind: KindUint64,
+	},
 	{
 		Name:        "/sched/gomaxprocs:threads",
 		Description: "The current runtime.GOMAXPROCS setting, or the number of operating system threads that can execute user-level Go code simultaneously.",
 		Kind:        KindUint64,
 	},
+	{
+		Name:        "/sched/goroutines-created:goroutines",
+		Description: "Count of goroutines created since the program started. System goroutines are not counted. Compared with /sched/goroutines:goroutines, its rate of increase tells steady goroutine churn from accumulating goroutines.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/goroutines/lifetimes:seconds",
+		Description: "Distribution of the time goroutines existed, from their creation until they exited. Goroutines that have not exited, and system goroutines, are not counted. Bucket counts increase monotonically.",
+		Kind:        KindFloat64Histogram,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/goroutines/runnable:goroutines",
+		Description: "Approximate count of goroutines, other than the runtime's own, that are ready to run but not running.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/sched/goroutines/running:goroutines",
+		Description: "Approximate count of goroutines, other than the runtime's own, that are running on an OS thread.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/sched/goroutines/syscall:goroutines",
+		Description: "Approximate count of goroutines, other than the runtime's own, that are in a system call or a call into C.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/sched/goroutines/waiting:goroutines",
+		Description: "Approximate count of goroutines, other than the runtime's own, that are blocked, for example on a channel operation, a lock, or I/O. runtime.CountGoroutines breaks this count down by what the goroutines are waiting for.",
+		Kind:        KindUint64,
+	},
 	{
 		Name:        "/sched/goroutines:goroutines",
 		Description: "Count of live goroutines.",
 		Kind:        KindUint64,
 	},
+	{
+		Name:        "/sched/latencies/all:seconds",
+		Description: "Distribution of the time goroutines have spent in the scheduler in a runnable state before actually running, recorded for every transition to running while scheduler aging is enabled (see runtime/debug.SetSchedulerAging), and not otherwise. Unlike /sched/latencies:seconds, goroutines are not sampled. Bucket counts increase monotonically.",
+		Kind:        KindFloat64Histogram,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/latencies/longest-runnable:seconds",
+		Description: "Approximate time for which the goroutine that has been waiting to run the longest, among those that are runnable but not running, has been waiting. Background goroutines are not counted. Unless scheduler aging is enabled, this is estimated from the same sample of goroutines as /sched/latencies:seconds. See runtime/debug.SetSchedulerAging to limit this time.",
+		Kind:        KindFloat64,
+	},
 	{
 		Name:        "/sched/latencies:seconds",
 		Description: "Distribution of the time goroutines have spent in the scheduler in a runnable state before actually running. Bucket counts increase monotonically.",
@@ -439,6 +644,21 @@ var allDesc = []Description{
 		Kind:        KindFloat64Histogram,
 		Cumulative:  true,
 	},
+	{
+		Name:        "/sched/runqueue/global:goroutines",
+		Description: "Approximate number of goroutines waiting to run on the global run queue.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/sched/runqueue/local:goroutines",
+		Description: "Approximate number of goroutines waiting to run on the local run queues of all Ps.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/sched/runqueue/per-p:goroutines",
+		Description: "Distribution of the approximate lengths of the local run queues of the Ps. Each P is counted once.",
+		Kind:        KindFloat64Histogram,
+	},
 	{
 		Name:        "/sync/mutex/wait/total:seconds",
 		Description: "Approximate cumulative time goroutines have spent blocked on a sync.Mutex, sync.RWMutex, or runtime-internal lock. This metric is useful for identifying global changes in lock contention. Collect a mutex or block profile using the runtime/pprof package for more detailed contention data.",
@@ -471,7 +691,11 @@ func init() {
 	allDesc = append(more, allDesc[i:]...)
 }
 
-// All returns a slice of containing metric descriptions for all supported metrics.
+// All returns a slice of containing metric descriptions for all supported metrics,
+// including application metrics registered so far, ordered by name.
 func All() []Description {
+	if r := registry.metrics.Load(); r != nil {
+		return r.all
+	}
 	return allDesc
 }
@@ -51,6 +51,20 @@ This package supports metrics whose values have a floating-point representation.
 order to improve ease-of-use, this package promises to never produce the following
 classes of floating-point values: NaN, infinity.
 
+# Application metrics
+
+Programs can add their own metrics, created with [NewCounter], [NewGauge] and
+[NewHistogram]. [Read] samples them along with the runtime's metrics, right after
+them, so that a single call returns both, and [All] describes them, so that tools
+that export runtime metrics export them too.
+
+An application metric's name must have the format described by the Name field of
+the Description struct, and must not already be in use, by the runtime or by another
+application metric; the functions that create metrics panic otherwise. To avoid
+conflicts with future runtime metrics, the path of the name should start with the
+import path of the package that creates it, as in
+"/example.com/server/requests:requests". Application metrics can't be removed.
+
 # Supported metrics
 
 Below is the full list of supported metrics, ordered lexicographically.
@@ -137,6 +151,17 @@ Below is the full list of supported metrics, ordered lexicographically.
 		to system CPU time measurements. Compare only with other
 		/cpu/classes metrics.
 
+	/gc/assist/latency:seconds
+		Distribution of the durations of individual GC assists, from
+		when a goroutine starts assisting the GC until it may allocate
+		again, including time spent waiting for the GC to make progress.
+		Bucket counts increase monotonically.
+
+	/gc/assist/slow:assists
+		Count of GC assists that took longer than the target set by the
+		MaxAssistLatency field of runtime/debug.GCPolicy. Always zero if
+		no target is set.
+
 	/gc/cycles/automatic:gc-cycles
 		Count of completed GC cycles generated by the Go runtime.
 
@@ -189,13 +214,43 @@ Below is the full list of supported metrics, ordered lexicographically.
 	/gc/heap/goal:bytes
 		Heap size target for the end of the GC cycle.
 
+	/gc/heap/largest-free:bytes
+		Size of the largest contiguous run of free heap memory,
+		whether or not it has been returned to the underlying system.
+		This is the largest object the heap can allocate without
+		growing. Compare with /memory/classes/heap/free:bytes and
+		/memory/classes/heap/released:bytes to gauge the fragmentation
+		of free memory.
+
 	/gc/heap/live:bytes
 		Heap memory occupied by live objects that were marked by the
 		previous GC.
 
+	/gc/heap/objects-by-class:bytes
+		Distribution of the heap memory occupied by objects that were
+		live at the end of the last GC cycle whose sweep has completed,
+		by size class. Each bucket covers the object sizes of one
+		size class and counts the bytes of the objects in it.
+		The last bucket is for large objects, which have no size class.
+		Objects in user arenas aren't included. Compare with
+		/gc/heap/objects-by-class:objects.
+
+	/gc/heap/objects-by-class:objects
+		Distribution of the heap objects that were live at the end of
+		the last GC cycle whose sweep has completed, by size class.
+		Each bucket covers the object sizes of one size class and counts
+		the objects in it. The last bucket is for large objects, which
+		have no size class. Objects in user arenas aren't included. Note
+		that tiny objects, as defined by /gc/heap/tiny/allocs:objects,
+		are counted by their blocks.
+
 	/gc/heap/objects:objects
 		Number of objects, live or unswept, occupying heap memory.
 
+	/gc/heap/pinned:objects
+		Number of heap objects currently pinned, for example by
+		runtime.Pinner.
+
 	/gc/heap/tiny/allocs:objects
 		Count of small allocations that are packed together into blocks.
 		These allocations are counted separately from other allocations
@@ -203,6 +258,41 @@ Below is the full list of supported metrics, ordered lexicographically.
 		runtime, only their block. Each block is already accounted for
 		in allocs-by-size and frees-by-size.
 
+	/gc/heap/types:types
+		Number of distinct types of live or unswept heap objects.
+		Only counted with GODEBUG=heaptypes=1, and zero otherwise.
+		See runtime/debug.ReadHeapTypeStats for the objects and bytes of
+		each type.
+
+	/gc/heap/user-arena/chunk-tail:bytes
+		Cumulative bytes left unused at the end of user arena chunks
+		because the next value allocated from the arena didn't fit,
+		counted when the arena moves on to another chunk, or is reset,
+		released to a mark, or freed.
+
+	/gc/heap/user-arena/padding:bytes
+		Cumulative bytes of user arena chunks lost to alignment padding
+		between values, counted when the arena moves on to another
+		chunk, or is reset, released to a mark, or freed.
+
+	/gc/heap/user-arena/quarantined:bytes
+		Address space of user arena chunks that have been freed and set
+		to fault, but are waiting for the garbage collector to find
+		no more references into them before they may be reused. This
+		memory is not backed by physical memory, so it is not counted in
+		/memory/classes.
+
+	/gc/limiter/enabled-time:seconds
+		Estimated total wall-clock time the GC CPU limiter has been
+		enabled. While enabled, the limiter reduces the CPU time the GC
+		spends on assists, letting the heap grow beyond its goal.
+
+	/gc/limiter/enabled:boolean
+		1 if the GC CPU limiter is currently enabled, 0 otherwise.
+
+	/gc/limiter/enabled:events
+		Count of times the GC CPU limiter has been enabled.
+
 	/gc/limiter/last-enabled:gc-cycle
 		GC cycle the last time the GC CPU limiter was enabled.
 		This metric is useful for diagnosing the root cause of an
@@ -211,6 +301,38 @@ Below is the full list of supported metrics, ordered lexicographically.
 		to occur with use of SetMemoryLimit. The first GC cycle is cycle
 		1, so a value of 0 indicates that it was never enabled.
 
+	/gc/limiter/skipped-assists:assists
+		Count of times a goroutine that owed the GC assist work did not
+		assist because the GC CPU limiter was enabled. The goroutine
+		remains in debt and tries again on its next allocation, so one
+		goroutine may be counted many times.
+
+	/gc/pauses/mark-termination:cpu-seconds
+		Estimated total CPU time spent with the application
+		paused by the GC for mark termination, computed like
+		/cpu/classes/gc/pause:cpu-seconds. Mark termination
+		finishes the mark phase, including processing finalizers,
+		and prepares the sweep phase. Together with
+		/gc/pauses/sweep-termination:cpu-seconds, this adds up to
+		/cpu/classes/gc/pause:cpu-seconds.
+
+	/gc/pauses/mark-termination:seconds
+		Distribution of individual GC mark termination pause latencies.
+		These are the samples of /sched/pauses/total/gc:seconds taken at
+		the end of the mark phase. Bucket counts increase monotonically.
+
+	/gc/pauses/sweep-termination:cpu-seconds
+		Estimated total CPU time spent with the application
+		paused by the GC for sweep termination, computed like
+		/cpu/classes/gc/pause:cpu-seconds. Sweep termination finishes
+		sweeping from the previous cycle and prepares the mark phase,
+		including enabling the write barrier.
+
+	/gc/pauses/sweep-termination:seconds
+		Distribution of individual GC sweep termination pause latencies.
+		These are the samples of /sched/pauses/total/gc:seconds taken at
+		the start of a GC cycle. Bucket counts increase monotonically.
+
 	/gc/pauses:seconds
 		Deprecated. Prefer the identical /sched/pauses/total/gc:seconds.
 
@@ -379,8 +501,10 @@ Below is the full list of supported metrics, ordered lexicographically.
 		physical memory.
 
 	/memory/classes/heap/objects:bytes
-		Memory occupied by live objects and dead objects that have not
-		yet been marked free by the garbage collector.
+		Memory occupied by live objects and dead objects that
+		have not yet been marked free by the garbage collector.
+		Memory in user arena chunks is counted separately, in
+		/memory/classes/heap/user-arena.
 
 	/memory/classes/heap/released:bytes
 		Memory that is completely free and has been returned to the
@@ -399,6 +523,15 @@ Below is the full list of supported metrics, ordered lexicographically.
 		Memory that is reserved for heap objects but is not currently
 		used to hold heap objects.
 
+	/memory/classes/heap/user-arena/inuse:bytes
+		Memory in user arena chunks that are held by arenas, or have
+		been freed by arenas but not yet set to fault.
+
+	/memory/classes/heap/user-arena/reusable:bytes
+		Memory in user arena chunks that have been released by arenas
+		and are ready to be reused by other arenas without going through
+		the garbage collector.
+
 	/memory/classes/metadata/mcache/free:bytes
 		Memory that is reserved for runtime mcache structures, but not
 		in-use.
@@ -441,14 +574,66 @@ Below is the full list of supported metrics, ordered lexicographically.
 		by code called via cgo or via the syscall package. Sum of all
 		metrics in /memory/classes.
 
+	/memory/mapped-files:bytes
+		Memory mapped from files by runtime/mem.MapFile.
+		This memory is backed by the files, so it is not part of
+		/memory/classes/total:bytes and does not count toward the memory
+		limit.
+
 	/sched/gomaxprocs:threads
 		The current runtime.GOMAXPROCS setting, or the number of
 		operating system threads that can execute user-level Go code
 		simultaneously.
 
+	/sched/goroutines-created:goroutines
+		Count of goroutines created since the program started.
+		System goroutines are not counted. Compared with
+		/sched/goroutines:goroutines, its rate of increase tells steady
+		goroutine churn from accumulating goroutines.
+
+	/sched/goroutines/lifetimes:seconds
+		Distribution of the time goroutines existed, from their
+		creation until they exited. Goroutines that have not exited,
+		and system goroutines, are not counted. Bucket counts increase
+		monotonically.
+
+	/sched/goroutines/runnable:goroutines
+		Approximate count of goroutines, other than the runtime's own,
+		that are ready to run but not running.
+
+	/sched/goroutines/running:goroutines
+		Approximate count of goroutines, other than the runtime's own,
+		that are running on an OS thread.
+
+	/sched/goroutines/syscall:goroutines
+		Approximate count of goroutines, other than the runtime's own,
+		that are in a system call or a call into C.
+
+	/sched/goroutines/waiting:goroutines
+		Approximate count of goroutines, other than the runtime's own,
+		that are blocked, for example on a channel operation, a lock,
+		or I/O. runtime.CountGoroutines breaks this count down by what
+		the goroutines are waiting for.
+
 	/sched/goroutines:goroutines
 		Count of live goroutines.
 
+	/sched/latencies/all:seconds
+		Distribution of the time goroutines have spent in the scheduler
+		in a runnable state before actually running, recorded for
+		every transition to running while scheduler aging is enabled
+		(see runtime/debug.SetSchedulerAging), and not otherwise.
+		Unlike /sched/latencies:seconds, goroutines are not sampled.
+		Bucket counts increase monotonically.
+
+	/sched/latencies/longest-runnable:seconds
+		Approximate time for which the goroutine that has been waiting
+		to run the longest, among those that are runnable but not
+		running, has been waiting. Background goroutines are not
+		counted. Unless scheduler aging is enabled, this is estimated
+		from the same sample of goroutines as /sched/latencies:seconds.
+		See runtime/debug.SetSchedulerAging to limit this time.
+
 	/sched/latencies:seconds
 		Distribution of the time goroutines have spent in the scheduler
 		in a runnable state before actually running. Bucket counts
@@ -486,6 +671,18 @@ Below is the full list of supported metrics, ordered lexicographically.
 		/sched/pauses/stopping/other:seconds). Bucket counts increase
 		monotonically.
 
+	/sched/runqueue/global:goroutines
+		Approximate number of goroutines waiting to run on the global
+		run queue.
+
+	/sched/runqueue/local:goroutines
+		Approximate number of goroutines waiting to run on the local run
+		queues of all Ps.
+
+	/sched/runqueue/per-p:goroutines
+		Distribution of the approximate lengths of the local run queues
+		of the Ps. Each P is counted once.
+
 	/sync/mutex/wait/total:seconds
 		Approximate cumulative time goroutines have spent blocked on a
 		sync.Mutex, sync.RWMutex, or runtime-internal lock. This metric
@@ -0,0 +1,198 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"cmp"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// PrometheusName returns the name under which [WritePrometheus] exposes
+// the metric named name. The name starts with "go", followed by the
+// metric's path, and then its unit, with slashes in the path, and
+// characters not allowed in Prometheus metric names, replaced with
+// underscores. In the unit, slashes become "_per_". For example,
+// "/gc/heap/allocs:bytes" becomes "go_gc_heap_allocs_bytes", and
+// "/gc/scan/rate:bytes/second" would become
+// "go_gc_scan_rate_bytes_per_second". Cumulative scalar metrics are
+// exposed as counters, with "_total" appended to this name.
+func PrometheusName(name string) string {
+	path, unit := name, ""
+	for i := 0; i < len(name); i++ {
+		if name[i] == ':' {
+			path, unit = name[:i], name[i+1:]
+			break
+		}
+	}
+	b := []byte("go")
+	b = appendSanitized(b, path, "_")
+	if unit != "" {
+		b = append(b, '_')
+		b = appendSanitized(b, unit, "_per_")
+	}
+	return string(b)
+}
+
+// appendSanitized appends s to b, replacing slashes with slash and other
+// characters that aren't allowed in Prometheus metric names with
+// underscores.
+func appendSanitized(b []byte, s, slash string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '/':
+			b = append(b, slash...)
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+	return b
+}
+
+// WritePrometheus writes the metrics in samples to w, in the Prometheus
+// text exposition format, which OpenMetrics parsers also accept. Each
+// metric is named as [PrometheusName] describes, and is preceded by HELP
+// and TYPE lines with its description and type, as [All] describes it.
+// Samples of unknown metrics, whose Value has kind [KindBad], are left
+// out.
+//
+// Cumulative scalar metrics are exposed as counters, and other scalar
+// metrics as gauges. Histograms are exposed as histograms, with a bucket
+// for each upper bound of the metric's buckets. A bucket's bound is
+// exclusive in runtime/metrics, but inclusive in Prometheus, which makes
+// no difference in practice. Since runtime/metrics histograms don't
+// record the sum of their values, the sum is estimated from the middle
+// of each bucket, or its finite bound for buckets that extend to
+// infinity.
+func WritePrometheus(w io.Writer, samples []Sample) error {
+	descs := All()
+	var b []byte
+	for _, s := range samples {
+		if s.Value.kind == KindBad {
+			continue
+		}
+		var d Description
+		if i, ok := slices.BinarySearchFunc(descs, s.Name, func(d Description, name string) int {
+			return cmp.Compare(d.Name, name)
+		}); ok {
+			d = descs[i]
+		}
+		name := PrometheusName(s.Name)
+		typ := "gauge"
+		switch {
+		case s.Value.kind == KindFloat64Histogram:
+			typ = "histogram"
+		case d.Cumulative:
+			typ = "counter"
+			name += "_total"
+		}
+
+		b = append(b, "# HELP "...)
+		b = append(b, name...)
+		if d.Description != "" {
+			b = append(b, ' ')
+			b = appendEscaped(b, d.Description)
+		}
+		b = append(b, "\n# TYPE "...)
+		b = append(b, name...)
+		b = append(b, ' ')
+		b = append(b, typ...)
+		b = append(b, '\n')
+
+		switch s.Value.kind {
+		case KindUint64:
+			b = append(b, name...)
+			b = append(b, ' ')
+			b = strconv.AppendUint(b, s.Value.Uint64(), 10)
+			b = append(b, '\n')
+		case KindFloat64:
+			b = append(b, name...)
+			b = append(b, ' ')
+			b = appendFloat(b, s.Value.Float64())
+			b = append(b, '\n')
+		case KindFloat64Histogram:
+			b = appendHistogram(b, name, s.Value.Float64Histogram())
+		}
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// appendHistogram appends the lines of histogram h, named name, to b.
+func appendHistogram(b []byte, name string, h *Float64Histogram) []byte {
+	var count uint64
+	var sum float64
+	for i, n := range h.Counts {
+		count += n
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+		if n != 0 {
+			switch {
+			case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+			case math.IsInf(lo, -1):
+				sum += float64(n) * hi
+			case math.IsInf(hi, 1):
+				sum += float64(n) * lo
+			default:
+				sum += float64(n) * (lo + (hi-lo)/2)
+			}
+		}
+		if i == len(h.Counts)-1 && math.IsInf(hi, 1) {
+			// The +Inf bucket is written below.
+			break
+		}
+		b = append(b, name...)
+		b = append(b, `_bucket{le="`...)
+		b = appendFloat(b, hi)
+		b = append(b, `"} `...)
+		b = strconv.AppendUint(b, count, 10)
+		b = append(b, '\n')
+	}
+	b = append(b, name...)
+	b = append(b, `_bucket{le="+Inf"} `...)
+	b = strconv.AppendUint(b, count, 10)
+	b = append(b, '\n')
+	b = append(b, name...)
+	b = append(b, "_sum "...)
+	b = appendFloat(b, sum)
+	b = append(b, '\n')
+	b = append(b, name...)
+	b = append(b, "_count "...)
+	b = strconv.AppendUint(b, count, 10)
+	b = append(b, '\n')
+	return b
+}
+
+// appendFloat appends x to b as Prometheus writes floating-point values.
+func appendFloat(b []byte, x float64) []byte {
+	switch {
+	case math.IsInf(x, 1):
+		return append(b, "+Inf"...)
+	case math.IsInf(x, -1):
+		return append(b, "-Inf"...)
+	case math.IsNaN(x):
+		return append(b, "NaN"...)
+	}
+	return strconv.AppendFloat(b, x, 'g', -1, 64)
+}
+
+// appendEscaped appends help text s to b, escaping backslashes and
+// newlines.
+func appendEscaped(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			b = append(b, `\\`...)
+		case '\n':
+			b = append(b, `\n`...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"regexp"
+	"runtime/metrics"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusName(t *testing.T) {
+	for _, tc := range []struct {
+		name, want string
+	}{
+		{"/gc/heap/allocs:bytes", "go_gc_heap_allocs_bytes"},
+		{"/gc/cycles/total:gc-cycles", "go_gc_cycles_total_gc_cycles"},
+		{"/cpu/classes/gc/mark/assist:cpu-seconds", "go_cpu_classes_gc_mark_assist_cpu_seconds"},
+		{"/godebug/non-default-behavior/http2client:events", "go_godebug_non_default_behavior_http2client_events"},
+		{"/gc/scan/rate:bytes/second", "go_gc_scan_rate_bytes_per_second"},
+		{"/app/energy:watt*seconds", "go_app_energy_watt_seconds"},
+	} {
+		if got := metrics.PrometheusName(tc.name); got != tc.want {
+			t.Errorf("PrometheusName(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	counter := appMetricName("prom/requests:requests")
+	gauge := appMetricName("prom/queue:requests")
+	histogram := appMetricName("prom/latency:seconds")
+	c := metrics.NewCounter(counter, "Count of requests.")
+	g := metrics.NewGauge(gauge, "Requests waiting,\nwith a \\ in the description.")
+	h := metrics.NewHistogram(histogram, "Request latency.", []float64{0.1, 1})
+	c.Add(3)
+	g.Set(1.5)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	samples := []metrics.Sample{
+		{Name: counter},
+		{Name: gauge},
+		{Name: histogram},
+		{Name: appMetricName("prom/unknown:requests")},
+	}
+	metrics.Read(samples)
+	var b strings.Builder
+	if err := metrics.WritePrometheus(&b, samples); err != nil {
+		t.Fatal(err)
+	}
+	cname := metrics.PrometheusName(counter) + "_total"
+	gname := metrics.PrometheusName(gauge)
+	hname := metrics.PrometheusName(histogram)
+	want := "# HELP " + cname + " Count of requests.\n" +
+		"# TYPE " + cname + " counter\n" +
+		cname + " 3\n" +
+		"# HELP " + gname + " Requests waiting,\\nwith a \\\\ in the description.\n" +
+		"# TYPE " + gname + " gauge\n" +
+		gname + " 1.5\n" +
+		"# HELP " + hname + " Request latency.\n" +
+		"# TYPE " + hname + " histogram\n" +
+		hname + "_bucket{le=\"0.1\"} 1\n" +
+		hname + "_bucket{le=\"1\"} 3\n" +
+		hname + "_bucket{le=\"+Inf\"} 4\n" +
+		hname + "_sum 2.2\n" +
+		hname + "_count 4\n"
+	if got := b.String(); got != want {
+		t.Errorf("WritePrometheus wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// promLine matches a line of the Prometheus text exposition format, as
+// WritePrometheus writes it.
+var promLine = regexp.MustCompile(`^(# HELP [a-zA-Z_:][a-zA-Z0-9_:]*( .*)?|# TYPE [a-zA-Z_:][a-zA-Z0-9_:]* (counter|gauge|histogram)|[a-zA-Z_:][a-zA-Z0-9_:]*(\{le="[^"]+"\})? \S+)$`)
+
+func TestWritePrometheusAll(t *testing.T) {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i := range samples {
+		samples[i].Name = descs[i].Name
+	}
+	metrics.Read(samples)
+	var b strings.Builder
+	if err := metrics.WritePrometheus(&b, samples); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if !promLine.MatchString(line) {
+			t.Errorf("invalid line %q", line)
+		}
+		if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, _, _ = strings.Cut(name, " ")
+			if seen[name] {
+				t.Errorf("metric %s written twice", name)
+			}
+			seen[name] = true
+		}
+	}
+	if len(seen) != len(samples) {
+		t.Errorf("wrote %d metrics, want %d", len(seen), len(samples))
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"cmp"
+	"internal/stringslite"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// A Counter is an application metric that counts events. Its value has
+// kind [KindUint64] and is cumulative.
+type Counter struct {
+	n atomic.Uint64
+}
+
+// Add adds n to the counter.
+func (c *Counter) Add(n uint64) {
+	c.n.Add(n)
+}
+
+func (c *Counter) read(v *Value) {
+	v.kind = KindUint64
+	v.scalar = c.n.Load()
+}
+
+// A Gauge is an application metric that measures a quantity that may go
+// up and down. Its value has kind [KindFloat64] and is not cumulative.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the gauge to x. It panics if x is NaN or infinite.
+func (g *Gauge) Set(x float64) {
+	checkFinite(x)
+	g.bits.Store(math.Float64bits(x))
+}
+
+// Add adds delta to the gauge. It panics if the result is NaN or
+// infinite.
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := g.bits.Load()
+		x := math.Float64frombits(old) + delta
+		checkFinite(x)
+		if g.bits.CompareAndSwap(old, math.Float64bits(x)) {
+			return
+		}
+	}
+}
+
+func (g *Gauge) read(v *Value) {
+	v.kind = KindFloat64
+	v.scalar = g.bits.Load()
+}
+
+func checkFinite(x float64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		panic("metrics: Gauge value is NaN or infinite")
+	}
+}
+
+// A Histogram is an application metric that counts observed values by
+// ranges, or buckets. Its value has kind [KindFloat64Histogram] and is
+// cumulative.
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64
+}
+
+// Observe counts x in the bucket that contains it. NaN values are
+// ignored.
+func (h *Histogram) Observe(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	// buckets starts with -Inf and ends with +Inf, so i is in range,
+	// except for +Inf itself, which goes in the last bucket.
+	i, _ := slices.BinarySearch(h.buckets, x)
+	if i == len(h.buckets) || h.buckets[i] != x {
+		i--
+	}
+	h.counts[min(i, len(h.counts)-1)].Add(1)
+}
+
+func (h *Histogram) read(v *Value) {
+	var hist *Float64Histogram
+	if v.pointer != nil {
+		// Reuse the histogram of the previous Read into this Value,
+		// as the runtime does.
+		hist = (*Float64Histogram)(v.pointer)
+	} else {
+		hist = new(Float64Histogram)
+		v.pointer = unsafe.Pointer(hist)
+	}
+	v.kind = KindFloat64Histogram
+	hist.Buckets = h.buckets
+	if len(hist.Counts) != len(h.counts) {
+		hist.Counts = make([]uint64, len(h.counts))
+	}
+	for i := range h.counts {
+		hist.Counts[i] = h.counts[i].Load()
+	}
+}
+
+// NewCounter registers and returns a new [Counter] named name.
+// See "Application metrics" in the package documentation for the
+// requirements on name and description.
+func NewCounter(name, description string) *Counter {
+	c := new(Counter)
+	register(Description{Name: name, Description: description, Kind: KindUint64, Cumulative: true}, c)
+	return c
+}
+
+// NewGauge registers and returns a new [Gauge] named name.
+// See "Application metrics" in the package documentation for the
+// requirements on name and description.
+func NewGauge(name, description string) *Gauge {
+	g := new(Gauge)
+	register(Description{Name: name, Description: description, Kind: KindFloat64}, g)
+	return g
+}
+
+// NewHistogram registers and returns a new [Histogram] named name,
+// with the given bucket boundaries, which must be in increasing order.
+// The histogram's buckets always start at -Inf and end at +Inf, which
+// are added to buckets if they're missing, so that every value falls
+// in a bucket. See "Application metrics" in the package documentation
+// for the requirements on name and description.
+func NewHistogram(name, description string, buckets []float64) *Histogram {
+	bounds := make([]float64, 0, len(buckets)+2)
+	if len(buckets) == 0 || !math.IsInf(buckets[0], -1) {
+		bounds = append(bounds, math.Inf(-1))
+	}
+	for i, b := range buckets {
+		if math.IsNaN(b) || i > 0 && b <= buckets[i-1] {
+			panic("metrics: NewHistogram with buckets not in increasing order")
+		}
+		bounds = append(bounds, b)
+	}
+	if !math.IsInf(bounds[len(bounds)-1], 1) {
+		bounds = append(bounds, math.Inf(1))
+	}
+	h := &Histogram{buckets: bounds, counts: make([]atomic.Uint64, len(bounds)-1)}
+	register(Description{Name: name, Description: description, Kind: KindFloat64Histogram, Cumulative: true}, h)
+	return h
+}
+
+var registry struct {
+	mu sync.Mutex // serializes registrations
+
+	// metrics is the set of application metrics, replaced by each
+	// registration.
+	metrics atomic.Pointer[appMetrics]
+}
+
+type appMetrics struct {
+	byName map[string]appMetric
+
+	// all describes the runtime's metrics and the application's, in
+	// the order of their names.
+	all []Description
+}
+
+type appMetric interface {
+	read(v *Value)
+}
+
+// register adds m to the application metrics, as described by d.
+func register(d Description, m appMetric) {
+	if !validName(d.Name) {
+		panic("metrics: invalid metric name " + d.Name)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	old := registry.metrics.Load()
+	all := allDesc
+	if old != nil {
+		all = old.all
+	}
+	i, found := slices.BinarySearchFunc(all, d.Name, func(d Description, name string) int {
+		return cmp.Compare(d.Name, name)
+	})
+	if found {
+		panic("metrics: metric name " + d.Name + " already in use")
+	}
+	r := &appMetrics{
+		byName: make(map[string]appMetric),
+		all:    slices.Insert(slices.Clip(all), i, d),
+	}
+	if old != nil {
+		for name, m := range old.byName {
+			r.byName[name] = m
+		}
+	}
+	r.byName[d.Name] = m
+	registry.metrics.Store(r)
+}
+
+// validName reports whether name has the format described by
+// Description.Name:
+//
+//	^(?P<name>/[^:]+):(?P<unit>[^:*/]+(?:[*/][^:*/]+)*)$
+func validName(name string) bool {
+	i := stringslite.IndexByte(name, ':')
+	if i < 2 || name[0] != '/' {
+		return false
+	}
+	unit := name[i+1:]
+	n := 0 // length of the current unit name
+	for j := 0; j < len(unit); j++ {
+		switch unit[j] {
+		case ':':
+			return false
+		case '*', '/':
+			if n == 0 {
+				return false
+			}
+			n = 0
+		default:
+			n++
+		}
+	}
+	return n > 0
+}
+
+// readApp reads the application metrics named in m, which the runtime
+// didn't know.
+func readApp(m []Sample) {
+	r := registry.metrics.Load()
+	if r == nil {
+		return
+	}
+	for i := range m {
+		if m[i].Value.kind != KindBad {
+			continue
+		}
+		if am, ok := r.byName[m[i].Name]; ok {
+			am.read(&m[i].Value)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// appMetricPrefix starts the names of the application metrics that
+// tests register.
+const appMetricPrefix = "/runtime/metrics_test/"
+
+var appMetricSeq atomic.Int32
+
+// appMetricName returns a name for an application metric registered by a
+// test, unique even if the test runs more than once.
+func appMetricName(name string) string {
+	return fmt.Sprintf("%s%d/%s", appMetricPrefix, appMetricSeq.Add(1), name)
+}
+
+func TestApplicationMetrics(t *testing.T) {
+	counter := appMetricName("requests:requests")
+	gauge := appMetricName("queue:requests")
+	histogram := appMetricName("latency:seconds")
+	c := metrics.NewCounter(counter, "Count of requests.")
+	g := metrics.NewGauge(gauge, "Requests waiting.")
+	h := metrics.NewHistogram(histogram, "Distribution of request latencies.", []float64{0, 0.5, 1})
+
+	c.Add(3)
+	c.Add(4)
+	g.Set(2)
+	g.Add(-0.5)
+	for _, x := range []float64{-1, 0, 0.25, 0.5, 2, math.Inf(1), math.NaN()} {
+		h.Observe(x)
+	}
+
+	samples := []metrics.Sample{
+		{Name: counter},
+		{Name: "/gc/cycles/total:gc-cycles"},
+		{Name: gauge},
+		{Name: histogram},
+		{Name: appMetricName("unknown:requests")},
+	}
+	for range 2 {
+		// Read twice, to check that histograms are reused properly.
+		metrics.Read(samples)
+		if v := samples[0].Value; v.Kind() != metrics.KindUint64 || v.Uint64() != 7 {
+			t.Errorf("counter value is %v, want 7", v)
+		}
+		if v := samples[1].Value; v.Kind() != metrics.KindUint64 {
+			t.Errorf("runtime metric has kind %v, want KindUint64", v.Kind())
+		}
+		if v := samples[2].Value; v.Kind() != metrics.KindFloat64 || v.Float64() != 1.5 {
+			t.Errorf("gauge value is %v, want 1.5", v)
+		}
+		v := samples[3].Value
+		if v.Kind() != metrics.KindFloat64Histogram {
+			t.Fatalf("histogram has kind %v", v.Kind())
+		}
+		hist := v.Float64Histogram()
+		wantBuckets := []float64{math.Inf(-1), 0, 0.5, 1, math.Inf(1)}
+		if !slices.Equal(hist.Buckets, wantBuckets) {
+			t.Errorf("histogram buckets are %v, want %v", hist.Buckets, wantBuckets)
+		}
+		if want := []uint64{1, 2, 1, 2}; !slices.Equal(hist.Counts, want) {
+			t.Errorf("histogram counts are %v, want %v", hist.Counts, want)
+		}
+		if v := samples[4].Value; v.Kind() != metrics.KindBad {
+			t.Errorf("unregistered metric has kind %v, want KindBad", v.Kind())
+		}
+	}
+
+	// All describes the application metrics among the runtime's.
+	all := metrics.All()
+	i := slices.IndexFunc(all, func(d metrics.Description) bool {
+		return d.Name == histogram
+	})
+	if i < 0 {
+		t.Fatalf("All doesn't describe the histogram")
+	}
+	if d := all[i]; d.Kind != metrics.KindFloat64Histogram || !d.Cumulative || d.Description != "Distribution of request latencies." {
+		t.Errorf("All describes the histogram as %+v", d)
+	}
+	if !slices.IsSortedFunc(all, func(a, b metrics.Description) int { return strings.Compare(a.Name, b.Name) }) {
+		t.Errorf("All isn't sorted by name")
+	}
+
+	for _, name := range []string{
+		"/gc/cycles/total:gc-cycles", // runtime metric
+		gauge,                        // already registered
+		"requests:requests",
+		"/:requests",
+		"/requests",
+		"/requests:",
+		"/requests:a//b",
+		"/requests:a*",
+		"/requests:a:b",
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewCounter(%q) didn't panic", name)
+				}
+			}()
+			metrics.NewCounter(name, "")
+		}()
+	}
+}
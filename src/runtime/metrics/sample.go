@@ -42,6 +42,10 @@ func runtime_readMetrics(unsafe.Pointer, int, int)
 //
 // Sample values with names not appearing in [All] will have their Value populated
 // as KindBad to indicate that the name is unknown.
+//
+// Read samples application metrics right after the runtime's metrics, so
+// that they can be correlated.
 func Read(m []Sample) {
 	runtime_readMetrics(unsafe.Pointer(&m[0]), len(m), cap(m))
+	readApp(m)
 }
@@ -66,8 +66,19 @@ func TestReadMetrics(t *testing.T) {
 		}
 	}
 
+	// Memory in user arena chunks is part of HeapAlloc, but is split out
+	// of the heap objects memory class.
+	var userArenaBytes uint64
+	for i := range samples {
+		if strings.HasPrefix(samples[i].Name, "/memory/classes/heap/user-arena/") {
+			userArenaBytes += samples[i].Value.Uint64()
+		}
+	}
+
 	// Check to make sure the values we read line up with other values we read.
 	var allocsBySize, gcPauses, schedPausesTotalGC *metrics.Float64Histogram
+	var sweepTermPauses, markTermPauses *metrics.Float64Histogram
+	var gcPauseCPU, sweepTermCPU, markTermCPU float64
 	var tinyAllocs uint64
 	var mallocs, frees uint64
 	for i := range samples {
@@ -79,7 +90,7 @@ func TestReadMetrics(t *testing.T) {
 		case "/memory/classes/heap/released:bytes":
 			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapReleased)
 		case "/memory/classes/heap/objects:bytes":
-			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapAlloc)
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapAlloc-userArenaBytes)
 		case "/memory/classes/heap/unused:bytes":
 			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapInuse-mstats.HeapAlloc)
 		case "/memory/classes/heap/stacks:bytes":
@@ -174,6 +185,10 @@ func TestReadMetrics(t *testing.T) {
 			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapObjects)
 		case "/gc/heap/goal:bytes":
 			checkUint64(t, name, samples[i].Value.Uint64(), mstats.NextGC)
+		case "/gc/heap/largest-free:bytes":
+			if v := samples[i].Value.Uint64(); v > mstats.HeapIdle {
+				t.Errorf("%s: largest free run %d is larger than HeapIdle %d", name, v, mstats.HeapIdle)
+			}
 		case "/gc/gogc:percent":
 			checkUint64(t, name, samples[i].Value.Uint64(), uint64(gcPercent))
 		case "/gc/cycles/automatic:gc-cycles":
@@ -186,6 +201,16 @@ func TestReadMetrics(t *testing.T) {
 			gcPauses = samples[i].Value.Float64Histogram()
 		case "/sched/pauses/total/gc:seconds":
 			schedPausesTotalGC = samples[i].Value.Float64Histogram()
+		case "/gc/pauses/sweep-termination:seconds":
+			sweepTermPauses = samples[i].Value.Float64Histogram()
+		case "/gc/pauses/mark-termination:seconds":
+			markTermPauses = samples[i].Value.Float64Histogram()
+		case "/cpu/classes/gc/pause:cpu-seconds":
+			gcPauseCPU = samples[i].Value.Float64()
+		case "/gc/pauses/sweep-termination:cpu-seconds":
+			sweepTermCPU = samples[i].Value.Float64()
+		case "/gc/pauses/mark-termination:cpu-seconds":
+			markTermCPU = samples[i].Value.Float64()
 		}
 	}
 
@@ -207,6 +232,16 @@ func TestReadMetrics(t *testing.T) {
 	if !slices.Equal(gcPauses.Counts, schedPausesTotalGC.Counts) {
 		t.Errorf("/gc/pauses:seconds counts %v do not match /sched/pauses/total/gc:seconds counts %v", gcPauses.Counts, schedPausesTotalGC.Counts)
 	}
+
+	// Verify that the per-phase pause metrics split the GC pause metrics.
+	for i := range schedPausesTotalGC.Counts {
+		if got, want := sweepTermPauses.Counts[i]+markTermPauses.Counts[i], schedPausesTotalGC.Counts[i]; got != want {
+			t.Errorf("sweep and mark termination pause counts for bucket %d add up to %d, want %d", i, got, want)
+		}
+	}
+	if !withinEpsilon(sweepTermCPU+markTermCPU, gcPauseCPU, 1e-9) {
+		t.Errorf("sweep and mark termination pause CPU time adds up to %f, want %f", sweepTermCPU+markTermCPU, gcPauseCPU)
+	}
 }
 
 func TestReadMetricsConsistency(t *testing.T) {
@@ -305,8 +340,11 @@ func TestReadMetricsConsistency(t *testing.T) {
 			cpu.user = samples[i].Value.Float64()
 		case "/memory/classes/total:bytes":
 			totalVirtual.got = samples[i].Value.Uint64()
-		case "/memory/classes/heap/objects:bytes":
-			objects.totalBytes = samples[i].Value.Uint64()
+		case "/memory/classes/heap/objects:bytes",
+			"/memory/classes/heap/user-arena/inuse:bytes",
+			"/memory/classes/heap/user-arena/reusable:bytes":
+			// User arena chunks are allocated as heap objects.
+			objects.totalBytes += samples[i].Value.Uint64()
 		case "/gc/heap/objects:objects":
 			objects.total = samples[i].Value.Uint64()
 		case "/gc/heap/allocs:bytes":
@@ -345,6 +383,22 @@ func TestReadMetricsConsistency(t *testing.T) {
 			if samples[i].Value.Uint64() < 1 {
 				t.Error("number of goroutines is less than one")
 			}
+		case "/sched/latencies/longest-runnable:seconds":
+			if v := samples[i].Value.Float64(); v < 0 {
+				t.Errorf("longest runnable wait is negative: %f", v)
+			}
+		case "/sched/goroutines/running:goroutines":
+			if samples[i].Value.Uint64() < 1 {
+				t.Error("number of running goroutines is less than one")
+			}
+		case "/sched/runqueue/per-p:goroutines":
+			var total uint64
+			for _, c := range samples[i].Value.Float64Histogram().Counts {
+				total += c
+			}
+			if total != uint64(runtime.GOMAXPROCS(0)) {
+				t.Errorf("per-P run queue lengths count %d Ps, want %d", total, runtime.GOMAXPROCS(0))
+			}
 		}
 	}
 	// Only check this on Linux where we can be reasonably sure we have a high-resolution timer.
@@ -1380,3 +1434,133 @@ func TestMetricHeapUnusedLargeObjectOverflow(t *testing.T) {
 	done <- struct{}{}
 	wg.Wait()
 }
+
+func TestReadMetricsObjectsByClass(t *testing.T) {
+	// Keep objects of a known size class and a large object live
+	// across a GC, which completes its sweep before returning.
+	small := make([]*[64]byte, 1000)
+	for i := range small {
+		small[i] = new([64]byte)
+	}
+	large := make([]byte, 1<<20)
+	runtime.GC()
+
+	samples := []metrics.Sample{
+		{Name: "/gc/heap/objects-by-class:objects"},
+		{Name: "/gc/heap/objects-by-class:bytes"},
+	}
+	metrics.Read(samples)
+	objects := samples[0].Value.Float64Histogram()
+	bytes := samples[1].Value.Float64Histogram()
+	last := len(objects.Counts) - 1
+	for i, n := range objects.Counts[:last] {
+		size := uint64(objects.Buckets[i+1]) - 1
+		if got, want := bytes.Counts[i], n*size; got != want {
+			t.Errorf("%d-byte size class: got %d bytes, want %d objects * %d bytes = %d", size, got, n, size, want)
+		}
+		if size == 64 && n < uint64(len(small)) {
+			t.Errorf("64-byte size class: got %d live objects, want at least %d", n, len(small))
+		}
+	}
+	if n := objects.Counts[last]; n == 0 {
+		t.Errorf("got no live large objects, want at least 1")
+	}
+	if b := bytes.Counts[last]; b < uint64(len(large)) {
+		t.Errorf("got %d bytes of live large objects, want at least %d", b, len(large))
+	}
+	runtime.KeepAlive(small)
+	runtime.KeepAlive(large)
+}
+
+func TestRunQueueMetrics(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	// Scheduling latencies are only all recorded with aging enabled.
+	defer debug.SetSchedulerAging(debug.SetSchedulerAging(time.Hour))
+
+	samples := []metrics.Sample{
+		{Name: "/sched/runqueue/global:goroutines"},
+		{Name: "/sched/runqueue/local:goroutines"},
+		{Name: "/sched/latencies/all:seconds"},
+	}
+	latencies := func() uint64 {
+		var total uint64
+		for _, c := range samples[2].Value.Float64Histogram().Counts {
+			total += c
+		}
+		return total
+	}
+	metrics.Read(samples)
+	before := latencies()
+
+	// With one P, the new goroutines wait on the run queues until this
+	// goroutine blocks.
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for range n {
+		go wg.Done()
+	}
+	metrics.Read(samples)
+	if queued := samples[0].Value.Uint64() + samples[1].Value.Uint64(); queued < n {
+		t.Errorf("run queues hold %d goroutines, want at least %d", queued, n)
+	}
+	wg.Wait()
+
+	metrics.Read(samples)
+	if after := latencies(); after < before+n {
+		t.Errorf("recorded %d scheduling latencies, want at least %d", after-before, n)
+	}
+}
+
+func TestGoroutineLifetimeMetrics(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	samples := []metrics.Sample{
+		{Name: "/sched/goroutines-created:goroutines"},
+		{Name: "/sched/goroutines/lifetimes:seconds"},
+	}
+	// lifetimes returns the number of lifetimes recorded, and how many
+	// of them were at least atLeast seconds.
+	lifetimes := func(atLeast float64) (total, long uint64) {
+		h := samples[1].Value.Float64Histogram()
+		for i, c := range h.Counts {
+			total += c
+			if h.Buckets[i] >= atLeast {
+				long += c
+			}
+		}
+		return total, long
+	}
+	metrics.Read(samples)
+	createdBefore := samples[0].Value.Uint64()
+	totalBefore, longBefore := lifetimes(0.01)
+
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for range n {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	// Destroy Ps, whose statistics must be kept.
+	runtime.GOMAXPROCS(1)
+
+	// The goroutines record their lifetimes after wg.Done, so wait for
+	// them to exit.
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		metrics.Read(samples)
+		total, long := lifetimes(0.01)
+		if total-totalBefore >= n && long-longBefore >= n {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("recorded %d lifetimes, %d of them at least 10ms, want at least %d", total-totalBefore, long-longBefore, n)
+		}
+	}
+	if created := samples[0].Value.Uint64() - createdBefore; created < n {
+		t.Errorf("created %d goroutines, want at least %d", created, n)
+	}
+}
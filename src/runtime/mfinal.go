@@ -53,7 +53,7 @@ type finalizer struct {
 	fn   *funcval       // function to call (may be a heap pointer)
 	arg  unsafe.Pointer // ptr to object (may be a heap pointer)
 	nret uintptr        // bytes of return values from fn
-	fint *_type         // type of first argument of fn
+	fint *_type         // type of first argument of fn, or nil for a cleanup
 	ot   *ptrtype       // type of ptr to object (may be a heap pointer)
 }
 
@@ -190,6 +190,13 @@ func runfinq() {
 		fb := finq
 		finq = nil
 		if fb == nil {
+			if c := finqDrained; c != nil {
+				// See finalizersDrained.
+				finqDrained = nil
+				unlock(&finlock)
+				close(c)
+				continue
+			}
 			gopark(finalizercommit, unsafe.Pointer(&finlock), waitReasonFinalizerWait, traceBlockSystemGoroutine, 1)
 			continue
 		}
@@ -202,6 +209,19 @@ func runfinq() {
 			for i := fb.cnt; i > 0; i-- {
 				f := &fb.fin[i-1]
 
+				if f.fint == nil {
+					// This is a cleanup, which takes no arguments
+					// and returns no results.
+					cleanup := *(*func())(unsafe.Pointer(&f.fn))
+					fingStatus.Or(fingRunningFinalizer)
+					cleanup()
+					fingStatus.And(^fingRunningFinalizer)
+
+					f.fn = nil
+					atomic.Store(&fb.cnt, i-1)
+					continue
+				}
+
 				var regs abi.RegArgs
 				// The args may be passed in registers or on stack. Even for
 				// the register case, we still need the spill slots.
@@ -221,9 +241,6 @@ func runfinq() {
 					framecap = framesz
 				}
 
-				if f.fint == nil {
-					throw("missing type in runfinq")
-				}
 				r := frame
 				if argRegs > 0 {
 					r = unsafe.Pointer(&regs.Ints)
@@ -248,3 +248,13 @@ func TestDeferKeepAlive(t *testing.T) {
 		t.Errorf("finalizer ran prematurely")
 	}
 }
+
+func TestShutdown(t *testing.T) {
+	for _, name := range []string{"Shutdown", "ShutdownTimeout"} {
+		t.Run(name, func(t *testing.T) {
+			if got := runTestProg(t, "testprog", name); got != "OK\n" {
+				t.Errorf("expected OK, got %q", got)
+			}
+		})
+	}
+}
@@ -185,6 +185,9 @@ func gcinit() {
 	// Use the environment variable GOGC for the initial gcPercent value.
 	// Use the environment variable GOMEMLIMIT for the initial memoryLimit value.
 	gcController.init(readGOGC(), readGOMEMLIMIT())
+	scavengeInit()
+	hugePagesInit()
+	gcController.dedicatedWorkers.Store(max(debug.gcworkers, 0))
 
 	work.startSema = 1
 	work.markDoneSema = 1
@@ -205,6 +208,10 @@ func gcenable() {
 	<-c
 	<-c
 	memstats.enablegc = true // now that runtime is initialized, GC is okay
+
+	if debug.mempressure != 0 {
+		startMemPressureWatcher()
+	}
 }
 
 // Garbage collector phase.
@@ -398,6 +405,10 @@ type workType struct {
 	// explicit user call.
 	userForced bool
 
+	// triggerKind is the kind of trigger that started the current
+	// GC cycle.
+	triggerKind gcTriggerKind
+
 	// initialHeapLive is the value of gcController.heapLive at the
 	// beginning of this GC cycle.
 	initialHeapLive uint64
@@ -630,6 +641,16 @@ func gcStart(trigger gcTrigger) {
 	for trigger.test() && sweepone() != ^uintptr(0) {
 	}
 
+	// Run runtime/debug's sweep termination hooks once per cycle, on
+	// whichever goroutine gets here first. They run before the world
+	// is stopped so they can drop references for this cycle to collect.
+	if gcPhaseHook != nil && trigger.test() {
+		n := work.cycles.Load()
+		if last := gcPhaseHookCycle.Load(); int32(n-last) >= 0 && gcPhaseHookCycle.CompareAndSwap(last, n+1) {
+			gcPhaseHook(gcPhaseHookSweepTermination)
+		}
+	}
+
 	// Perform GC initialization and the sweep termination
 	// transition.
 	semacquire(&work.startSema)
@@ -657,6 +678,7 @@ func gcStart(trigger gcTrigger) {
 	// For stats, check if this GC was forced by the user.
 	// Update it under gcsema to avoid gctrace getting wrong values.
 	work.userForced = trigger.kind == gcTriggerCycle
+	work.triggerKind = trigger.kind
 
 	trace := traceAcquire()
 	if trace.ok() {
@@ -694,7 +716,7 @@ func gcStart(trigger gcTrigger) {
 	})
 
 	// Accumulate fine-grained stopping time.
-	work.cpuStats.accumulateGCPauseTime(stw.stoppingCPUTime, 1)
+	work.cpuStats.accumulateGCPauseTime(stw.stoppingCPUTime, 1, stw.reason)
 
 	// Finish sweep before we start concurrent scan.
 	systemstack(func() {
@@ -763,7 +785,7 @@ func gcStart(trigger gcTrigger) {
 	// Use maxprocs instead of stwprocs here because the total time
 	// computed in the CPU stats is based on maxprocs, and we want them
 	// to be comparable.
-	work.cpuStats.accumulateGCPauseTime(nanotime()-stw.finishedStopping, work.maxprocs)
+	work.cpuStats.accumulateGCPauseTime(nanotime()-stw.finishedStopping, work.maxprocs, stw.reason)
 
 	// Concurrent mark.
 	systemstack(func() {
@@ -789,6 +811,11 @@ func gcStart(trigger gcTrigger) {
 	}
 
 	semrelease(&work.startSema)
+
+	// Run runtime/debug's mark hooks on the goroutine that started the cycle.
+	if gcPhaseHook != nil {
+		gcPhaseHook(gcPhaseHookMark)
+	}
 }
 
 // gcMarkDoneFlushed counts the number of P's with flushed work.
@@ -871,6 +898,14 @@ top:
 		goto top
 	}
 
+	// Values of ephemerons are only reachable through their keys
+	// and owners, which may have been marked since the last check.
+	// If any such values were shaded, there's more work to do.
+	if gcMarkEphemerons() {
+		semrelease(&worldsema)
+		goto top
+	}
+
 	// There was no global work, no local work, and no Ps
 	// communicated work since we took markDoneSema. Therefore
 	// there are no grey objects and no more objects can be
@@ -887,7 +922,7 @@ top:
 	// all marking is complete. This includes writes made by the GC.
 
 	// Accumulate fine-grained stopping time.
-	work.cpuStats.accumulateGCPauseTime(stw.stoppingCPUTime, 1)
+	work.cpuStats.accumulateGCPauseTime(stw.stoppingCPUTime, 1, stw.reason)
 
 	// There is sometimes work left over when we enter mark termination due
 	// to write barriers performed after the completion barrier above.
@@ -912,7 +947,7 @@ top:
 		getg().m.preemptoff = ""
 		systemstack(func() {
 			// Accumulate the time we were stopped before we had to start again.
-			work.cpuStats.accumulateGCPauseTime(nanotime()-stw.finishedStopping, work.maxprocs)
+			work.cpuStats.accumulateGCPauseTime(nanotime()-stw.finishedStopping, work.maxprocs, stw.reason)
 
 			// Start the world again.
 			now := startTheWorldWithSema(0, stw)
@@ -953,6 +988,59 @@ top:
 	gcMarkTermination(stw)
 }
 
+// printGCTraceJSON prints the GODEBUG=gctracejson=1 record for the
+// cycle that just finished mark termination. Like the gctrace line,
+// it must be called before worldsema is dropped.
+func printGCTraceJSON() {
+	markCPU := gcController.assistTime.Load() +
+		gcController.dedicatedMarkTime.Load() + gcController.fractionalMarkTime.Load() +
+		gcController.idleMarkTime.Load()
+	scanWork := gcController.heapScanWork.Load() +
+		gcController.stackScanWork.Load() + gcController.globalsScanWork.Load()
+	var scanRate int64 // bytes of scan work per second of mark CPU time
+	if markCPU > 0 {
+		scanRate = int64(float64(scanWork) / (float64(markCPU) / 1e9))
+	}
+	var trigger string
+	switch work.triggerKind {
+	case gcTriggerHeap:
+		trigger = "heap"
+	case gcTriggerTime:
+		trigger = "time"
+	case gcTriggerCycle:
+		trigger = "forced"
+	}
+
+	var fbuf [24]byte
+	printlock()
+	print(`{"gc":`, memstats.numgc,
+		`,"start_ns":`, work.tSweepTerm-runtimeInitTime,
+		`,"gc_cpu_fraction":`, string(itoaDiv(fbuf[:], uint64(memstats.gc_cpu_fraction*1e6), 6)),
+		`,"trigger":"`, trigger, `"`,
+		`,"sweep_term_ns":`, work.tMark-work.tSweepTerm,
+		`,"mark_ns":`, work.tMarkTerm-work.tMark,
+		`,"mark_term_ns":`, work.tEnd-work.tMarkTerm,
+		`,"pause_ns":`, work.pauseNS,
+		`,"sweep_term_cpu_ns":`, int64(work.stwprocs)*(work.tMark-work.tSweepTerm),
+		`,"assist_cpu_ns":`, gcController.assistTime.Load(),
+		`,"background_cpu_ns":`, gcController.dedicatedMarkTime.Load()+gcController.fractionalMarkTime.Load(),
+		`,"idle_cpu_ns":`, gcController.idleMarkTime.Load(),
+		`,"mark_term_cpu_ns":`, int64(work.stwprocs)*(work.tEnd-work.tMarkTerm),
+		`,"heap_start_bytes":`, work.heap0,
+		`,"heap_end_bytes":`, work.heap1,
+		`,"heap_live_bytes":`, work.heap2,
+		`,"heap_goal_bytes":`, gcController.lastHeapGoal,
+		`,"stack_scan_bytes":`, gcController.lastStackScan.Load(),
+		`,"globals_scan_bytes":`, gcController.globalsScan.Load(),
+		`,"heap_scan_work_bytes":`, gcController.heapScanWork.Load(),
+		`,"stack_scan_work_bytes":`, gcController.stackScanWork.Load(),
+		`,"globals_scan_work_bytes":`, gcController.globalsScanWork.Load(),
+		`,"scan_rate_bytes_per_cpu_s":`, scanRate,
+		`,"procs":`, work.maxprocs,
+		"}\n")
+	printunlock()
+}
+
 // World must be stopped and mark assists and background workers must be
 // disabled.
 func gcMarkTermination(stw worldStop) {
@@ -1052,7 +1140,7 @@ func gcMarkTermination(stw worldStop) {
 	//
 	// Pass gcMarkPhase=true to accumulate so we can get all the latest GC CPU stats
 	// in there too.
-	work.cpuStats.accumulateGCPauseTime(now-stw.finishedStopping, work.maxprocs)
+	work.cpuStats.accumulateGCPauseTime(now-stw.finishedStopping, work.maxprocs, stw.reason)
 	work.cpuStats.accumulate(now, true)
 
 	// Compute overall GC CPU utilization.
@@ -1073,9 +1161,11 @@ func gcMarkTermination(stw worldStop) {
 		memstats.numforcedgc++
 	}
 
-	// Bump GC cycle count and wake goroutines waiting on sweep.
+	// Bump GC cycle count and wake goroutines waiting on sweep,
+	// including those waiting for a GC event.
 	lock(&work.sweepWaiters.lock)
 	memstats.numgc++
+	recordGCEvent(unixNow)
 	injectglist(&work.sweepWaiters.list)
 	unlock(&work.sweepWaiters.lock)
 
@@ -1217,6 +1307,9 @@ func gcMarkTermination(stw worldStop) {
 		print("\n")
 		printunlock()
 	}
+	if debug.gctracejson > 0 {
+		printGCTraceJSON()
+	}
 
 	// Set any arena chunks that were deferred to fault.
 	lock(&userArenaState.lock)
@@ -1702,6 +1795,16 @@ func gcResetMarkState() {
 var poolcleanup func()
 var boringCaches []unsafe.Pointer  // for crypto/internal/boring
 var uniqueMapCleanup chan struct{} // for unique
+var gcPhaseHook func(phase int)    // for runtime/debug
+
+// gcPhaseHookCycle is the cycle whose sweep termination hooks last ran.
+var gcPhaseHookCycle atomic.Uint32
+
+// Phases passed to gcPhaseHook. They must match runtime/debug.GCPhase.
+const (
+	gcPhaseHookSweepTermination = 0
+	gcPhaseHookMark             = 1
+)
 
 // sync_runtime_registerPoolCleanup should be an internal detail,
 // but widely used packages access it using linkname.
@@ -1717,6 +1820,25 @@ func sync_runtime_registerPoolCleanup(f func()) {
 	poolcleanup = f
 }
 
+//go:linkname runtime_debug_registerGCPhaseHook runtime/debug.registerGCPhaseHook
+func runtime_debug_registerGCPhaseHook(f func(phase int)) {
+	gcPhaseHook = f
+}
+
+// runtime_debug_finishSweep helps sweep until the sweep phase of GC
+// cycle n is complete, either because all spans have been swept or
+// because the next cycle started, which completes the sweep first.
+//
+//go:linkname runtime_debug_finishSweep runtime/debug.finishSweep
+func runtime_debug_finishSweep(n uint32) {
+	for work.cycles.Load() == n && sweepone() != ^uintptr(0) {
+		Gosched()
+	}
+	for work.cycles.Load() == n && !isSweepDone() {
+		Gosched()
+	}
+}
+
 //go:linkname boring_registerCache crypto/internal/boring/bcache.registerCache
 func boring_registerCache(p unsafe.Pointer) {
 	boringCaches = append(boringCaches, p)
@@ -1858,7 +1980,7 @@ func gcTestIsReachable(ptrs ...unsafe.Pointer) (mask uint64) {
 		s := (*specialReachable)(mheap_.specialReachableAlloc.alloc())
 		unlock(&mheap_.speciallock)
 		s.special.kind = _KindSpecialReachable
-		if !addspecial(p, &s.special) {
+		if !addspecial(p, &s.special, false) {
 			throw("already have a reachable special (duplicate pointer?)")
 		}
 		specials[i] = s
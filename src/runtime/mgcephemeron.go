@@ -0,0 +1,245 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Garbage collector: ephemerons.
+//
+// An ephemeron associates a value with a key object on behalf of an
+// owner (a weak.Map). The value is kept live only as long as both the
+// key and the owner are reachable, and the ephemeron itself keeps
+// neither of them live. In particular, a value that refers back to its
+// own key does not keep the key live.
+//
+// Ephemerons are special records on the key object. The key and value
+// pointers are hidden from the garbage collector, and the owner is
+// referenced through its weak handle. All ephemerons are also linked
+// into a global list, which mark termination walks to find ephemerons
+// whose key and owner were marked but whose value wasn't. It shades
+// those values and resumes marking, repeating until no more values
+// need shading. Ephemerons are only unlinked by the sweeper, when their
+// key dies, so the list doesn't change during the mark phase except by
+// having new ephemerons pushed to its head.
+
+package runtime
+
+import (
+	"internal/goarch"
+	"internal/runtime/atomic"
+	"internal/runtime/sys"
+	"unsafe"
+)
+
+// The described object is the key of an ephemeron.
+//
+// specialEphemeron is allocated from non-GC'd memory, so any heap
+// pointers must be specially handled.
+type specialEphemeron struct {
+	_       sys.NotInHeap
+	special special
+	owner   *atomic.Uintptr // Weak handle of the owner. May be a heap pointer.
+	key     uintptr         // Hidden from the GC.
+	value   atomic.Uintptr  // Hidden from the GC. Zero if the entry was deleted.
+
+	// Links in the ephemerons list, protected by mheap_.speciallock.
+	// next is read without the lock during the mark phase.
+	next, prev *specialEphemeron
+}
+
+// ephemerons is the head of the list of all ephemerons.
+var ephemerons atomic.UnsafePointer // *specialEphemeron
+
+// findEphemeron returns the ephemeron for key owned by owner, or nil.
+// span is key's span, and the caller must hold span.speciallock.
+func findEphemeron(span *mspan, key uintptr, owner *atomic.Uintptr) *specialEphemeron {
+	offset := key - span.base()
+	iter, exists := span.specialFindSplicePoint(offset, _KindSpecialEphemeron)
+	if !exists {
+		return nil
+	}
+	for s := *iter; s != nil && uintptr(s.offset) == offset && s.kind == _KindSpecialEphemeron; s = s.next {
+		if e := (*specialEphemeron)(unsafe.Pointer(s)); e.owner == owner {
+			return e
+		}
+	}
+	return nil
+}
+
+// setEphemeron sets the value of the ephemeron for key owned by owner,
+// adding an ephemeron if there is none. A zero value deletes the entry,
+// but the record stays until key dies, so that the ephemerons list is
+// only modified by the sweeper.
+func setEphemeron(owner *atomic.Uintptr, key, value unsafe.Pointer) {
+	span := spanOfHeap(uintptr(key))
+	if span == nil {
+		if value == nil {
+			// Nothing to delete.
+			return
+		}
+		throw("setEphemeron on invalid pointer")
+	}
+
+	// Ensure that the span is swept.
+	// Sweeping accesses the specials list w/o locks, so we have
+	// to synchronize with it. And it's just much safer.
+	mp := acquirem()
+	span.ensureSwept()
+
+	offset := uintptr(key) - span.base()
+
+	// Look up the ephemeron and add it if necessary under the span's
+	// special lock, so that concurrent calls don't both add one.
+	lock(&span.speciallock)
+	e := findEphemeron(span, uintptr(key), owner)
+	added := false
+	if e != nil {
+		e.value.Store(uintptr(value))
+	} else if value != nil {
+		lock(&mheap_.speciallock)
+		e = (*specialEphemeron)(mheap_.specialEphemeronAlloc.alloc())
+		e.special.kind = _KindSpecialEphemeron
+		e.owner = owner
+		e.key = uintptr(key)
+		e.value.Store(uintptr(value))
+
+		// Link e into the ephemerons list.
+		head := (*specialEphemeron)(ephemerons.Load())
+		e.next = head
+		e.prev = nil
+		if head != nil {
+			head.prev = e
+		}
+		ephemerons.StoreNoWB(unsafe.Pointer(e))
+		unlock(&mheap_.speciallock)
+
+		// Splice e into the span's specials, like addspecial.
+		iter, _ := span.specialFindSplicePoint(offset, _KindSpecialEphemeron)
+		e.special.offset = uint16(offset)
+		e.special.next = *iter
+		*iter = &e.special
+		spanHasSpecials(span)
+		added = true
+	}
+	unlock(&span.speciallock)
+
+	// This is responsible for maintaining the same
+	// GC-related invariants as markrootSpans in any
+	// situation where it's possible that markrootSpans
+	// has already run but mark termination hasn't yet.
+	if added && gcphase != _GCoff {
+		gcw := &mp.p.ptr().gcw
+		// Mark the owner's weak handle, since the
+		// special isn't part of the GC'd heap.
+		scanblock(uintptr(unsafe.Pointer(&e.owner)), goarch.PtrSize, &oneptrmask[0], gcw, nil)
+	}
+
+	// The value may now only be referenced by the ephemeron, which
+	// mark termination will only find if the key is marked. Like
+	// the Dijkstra part of the write barrier, shade it now.
+	if value != nil && gcphase != _GCoff {
+		shade(uintptr(value))
+	}
+	releasem(mp)
+
+	KeepAlive(key)
+	KeepAlive(value)
+}
+
+// getEphemeron returns the value of the ephemeron for key owned by
+// owner, or nil if there is none.
+func getEphemeron(owner *atomic.Uintptr, key unsafe.Pointer) unsafe.Pointer {
+	span := spanOfHeap(uintptr(key))
+	if span == nil {
+		// Not a heap object, so it can't be a key.
+		return nil
+	}
+
+	mp := acquirem()
+	span.ensureSwept()
+
+	var value unsafe.Pointer
+	lock(&span.speciallock)
+	if e := findEphemeron(span, uintptr(key), owner); e != nil {
+		value = unsafe.Pointer(e.value.Load())
+	}
+	unlock(&span.speciallock)
+
+	// This is responsible for maintaining the same GC-related
+	// invariants as the Yuasa part of the write barrier. During
+	// the mark phase, key may not have been marked yet, so value
+	// may not have been either, and we just created a pointer to
+	// it that may only be on our already blackened stack.
+	if value != nil && gcphase != _GCoff {
+		shade(uintptr(value))
+	}
+	releasem(mp)
+
+	KeepAlive(key)
+	return value
+}
+
+// unlinkEphemeron removes e from the ephemerons list.
+// It must only be called by the sweeper, once e's key is dead.
+func unlinkEphemeron(e *specialEphemeron) {
+	lock(&mheap_.speciallock)
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		ephemerons.StoreNoWB(unsafe.Pointer(e.next))
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	}
+	e.next, e.prev = nil, nil
+	unlock(&mheap_.speciallock)
+}
+
+// gcMarkEphemerons shades the values of all ephemerons whose key and
+// owner are marked, and reports whether it shaded any unmarked value.
+// If it did, marking must continue.
+//
+// It is called while transitioning to mark termination, when no Ps
+// have any mark work.
+func gcMarkEphemerons() bool {
+	if ephemerons.Load() == nil {
+		return false
+	}
+
+	shaded := false
+	mp := acquirem()
+	for e := (*specialEphemeron)(ephemerons.Load()); e != nil; e = e.next {
+		// Check the owner first: if it died in an earlier cycle,
+		// value may no longer point to a valid object.
+		owner := e.owner.Load()
+		if owner == 0 || !isMarkedObject(owner) || !isMarkedObject(e.key) {
+			continue
+		}
+		value := e.value.Load()
+		if value == 0 || isMarkedObject(value) {
+			continue
+		}
+		shade(value)
+		shaded = true
+	}
+	releasem(mp)
+	return shaded
+}
+
+// isMarkedObject reports whether the heap object containing p is marked.
+func isMarkedObject(p uintptr) bool {
+	s := spanOfHeap(p)
+	if s == nil {
+		// Not in the heap, so never freed.
+		return true
+	}
+	return s.markBitsForIndex(s.objIndex(p)).isMarked()
+}
+
+//go:linkname weak_runtime_setEphemeron weak.runtime_setEphemeron
+func weak_runtime_setEphemeron(owner, key, value unsafe.Pointer) {
+	setEphemeron((*atomic.Uintptr)(owner), key, value)
+}
+
+//go:linkname weak_runtime_getEphemeron weak.runtime_getEphemeron
+func weak_runtime_getEphemeron(owner, key unsafe.Pointer) unsafe.Pointer {
+	return getEphemeron((*atomic.Uintptr)(owner), key)
+}
@@ -76,6 +76,17 @@ type gcCPULimiterState struct {
 	// lastEnabledCycle is the GC cycle that last had the limiter enabled.
 	lastEnabledCycle atomic.Uint32
 
+	// enabledCount is the number of times the limiter has been enabled.
+	enabledCount atomic.Uint64
+
+	// enabledTime is the total wall-clock time the limiter has been
+	// enabled, as of the last update.
+	enabledTime atomic.Int64
+
+	// skippedAssists is the number of times a goroutine in assist debt
+	// didn't assist because the limiter was enabled.
+	skippedAssists atomic.Uint64
+
 	// nprocs is an internal copy of gomaxprocs, used to determine total available
 	// CPU time.
 	//
@@ -128,7 +139,7 @@ func (l *gcCPULimiterState) finishGCTransition(now int64) {
 	// isn't running on all CPUs, it is preventing user code from doing so,
 	// so it might as well be.
 	if lastUpdate := l.lastUpdate.Load(); now >= lastUpdate {
-		l.accumulate(0, (now-lastUpdate)*int64(l.nprocs))
+		l.accumulate(0, (now-lastUpdate)*int64(l.nprocs), now-lastUpdate)
 	}
 	l.lastUpdate.Store(now)
 	l.transitioning = false
@@ -256,17 +267,24 @@ func (l *gcCPULimiterState) updateLocked(now int64) {
 	// running.
 	windowTotalTime -= idleTime
 
-	l.accumulate(windowTotalTime-windowGCTime, windowGCTime)
+	l.accumulate(windowTotalTime-windowGCTime, windowGCTime, now-lastUpdate)
 }
 
 // accumulate adds time to the bucket and signals whether the limiter is enabled.
+// window is the wall-clock time over which mutatorTime and gcTime were spent.
 //
 // This is an internal function that deals just with the bucket. Prefer update.
 // l.lock must be held.
-func (l *gcCPULimiterState) accumulate(mutatorTime, gcTime int64) {
+func (l *gcCPULimiterState) accumulate(mutatorTime, gcTime, window int64) {
 	headroom := l.bucket.capacity - l.bucket.fill
 	enabled := headroom == 0
 
+	// The limiter only changes state at the end of a window, so it
+	// was in its current state for the whole window.
+	if l.enabled.Load() {
+		l.enabledTime.Add(window)
+	}
+
 	// Let's be careful about three things here:
 	// 1. The addition and subtraction, for the invariants.
 	// 2. Overflow.
@@ -279,8 +297,7 @@ func (l *gcCPULimiterState) accumulate(mutatorTime, gcTime int64) {
 		l.overflow += uint64(change) - headroom
 		l.bucket.fill = l.bucket.capacity
 		if !enabled {
-			l.enabled.Store(true)
-			l.lastEnabledCycle.Store(memstats.numgc + 1)
+			l.setEnabled(true)
 		}
 		return
 	}
@@ -294,7 +311,20 @@ func (l *gcCPULimiterState) accumulate(mutatorTime, gcTime int64) {
 		l.bucket.fill -= uint64(-change)
 	}
 	if change != 0 && enabled {
-		l.enabled.Store(false)
+		l.setEnabled(false)
+	}
+}
+
+// setEnabled enables or disables the limiter.
+//
+// l.lock must be held.
+func (l *gcCPULimiterState) setEnabled(enabled bool) {
+	if enabled && !l.enabled.Load() {
+		l.enabledCount.Add(1)
+	}
+	l.enabled.Store(enabled)
+	if enabled {
+		l.lastEnabledCycle.Store(memstats.numgc + 1)
 	}
 }
 
@@ -331,10 +361,9 @@ func (l *gcCPULimiterState) resetCapacity(now int64, nprocs int32) {
 	l.bucket.capacity = uint64(nprocs) * capacityPerProc
 	if l.bucket.fill > l.bucket.capacity {
 		l.bucket.fill = l.bucket.capacity
-		l.enabled.Store(true)
-		l.lastEnabledCycle.Store(memstats.numgc + 1)
+		l.setEnabled(true)
 	} else if l.bucket.fill < l.bucket.capacity {
-		l.enabled.Store(false)
+		l.setEnabled(false)
 	}
 	l.unlock()
 }
@@ -253,3 +253,50 @@ func TestGCCPULimiter(t *testing.T) {
 		baseOverflow += uint64((CapacityPerProc/2 + 6*time.Millisecond) * procs)
 	}
 }
+
+func TestGCCPULimiterEnabledTime(t *testing.T) {
+	const procs = 4
+
+	ticks := int64(0)
+	advance := func(d time.Duration) int64 {
+		ticks += int64(d)
+		return ticks
+	}
+	assistTime := func(d time.Duration, frac float64) int64 {
+		return int64(frac * float64(d) * procs)
+	}
+
+	l := NewGCCPULimiter(ticks, procs)
+	l.StartGCTransition(true, advance(0))
+	l.FinishGCTransition(advance(0))
+
+	// Fill the bucket exactly full, which enables the limiter at the
+	// end of the window.
+	l.AddAssistTime(assistTime(CapacityPerProc, 1.0-GCBackgroundUtilization))
+	l.Update(advance(CapacityPerProc))
+	if !l.Limiting() {
+		t.Fatal("limiter is not enabled after filling bucket but should be")
+	}
+	if l.EnabledCount() != 1 || l.EnabledTime() != 0 {
+		t.Errorf("limiter enabled %d times for %d ns, want 1 time for 0 ns", l.EnabledCount(), l.EnabledTime())
+	}
+
+	// Keep it enabled with 50% GC work, then drain the bucket.
+	l.AddAssistTime(assistTime(time.Second, 0.5-GCBackgroundUtilization))
+	l.Update(advance(time.Second))
+	l.Update(advance(100 * time.Millisecond))
+	if l.Limiting() {
+		t.Fatal("limiter is enabled after draining bucket but shouldn't be")
+	}
+	l.Update(advance(time.Second))
+	if want := int64(time.Second + 100*time.Millisecond); l.EnabledCount() != 1 || l.EnabledTime() != want {
+		t.Errorf("limiter enabled %d times for %d ns, want 1 time for %d ns", l.EnabledCount(), l.EnabledTime(), want)
+	}
+
+	// Enable it again.
+	l.AddAssistTime(assistTime(2*CapacityPerProc, 1.0-GCBackgroundUtilization))
+	l.Update(advance(2 * CapacityPerProc))
+	if !l.Limiting() || l.EnabledCount() != 2 {
+		t.Errorf("limiter enabled %d times, want 2", l.EnabledCount())
+	}
+}
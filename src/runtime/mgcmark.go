@@ -401,6 +401,16 @@ func markrootSpans(gcw *gcWork, shard int) {
 					// The special itself is a root.
 					spw := (*specialWeakHandle)(unsafe.Pointer(sp))
 					scanblock(uintptr(unsafe.Pointer(&spw.handle)), goarch.PtrSize, &oneptrmask[0], gcw, nil)
+				case _KindSpecialEphemeron:
+					// The owner's weak handle is a root. The key and
+					// value are only reachable through other paths.
+					spe := (*specialEphemeron)(unsafe.Pointer(sp))
+					scanblock(uintptr(unsafe.Pointer(&spe.owner)), goarch.PtrSize, &oneptrmask[0], gcw, nil)
+				case _KindSpecialCleanup:
+					// The special itself is a root. Unlike a finalizer,
+					// a cleanup doesn't retain the object it's attached to.
+					spc := (*specialCleanup)(unsafe.Pointer(sp))
+					scanblock(uintptr(unsafe.Pointer(&spc.fn)), goarch.PtrSize, &oneptrmask[0], gcw, nil)
 				}
 			}
 			unlock(&s.speciallock)
@@ -435,11 +445,14 @@ func gcAssistAlloc(gp *g) {
 	// TODO(mknyszek): Hide the details of inMarkAssist in tracer
 	// functions and simplify all the state tracking. This is a lot.
 	enteredMarkAssistForTracing := false
+	var startTime int64 // when we started assisting
 retry:
 	if gcCPULimiter.limiting() {
 		// If the CPU limiter is enabled, intentionally don't
 		// assist to reduce the amount of CPU time spent in the GC.
+		gcCPULimiter.skippedAssists.Add(1)
 		if enteredMarkAssistForTracing {
+			gcController.recordAssist(nanotime() - startTime)
 			trace := traceAcquire()
 			if trace.ok() {
 				trace.GCMarkAssistDone()
@@ -498,6 +511,7 @@ retry:
 			// We were able to steal all of the credit we
 			// needed.
 			if enteredMarkAssistForTracing {
+				gcController.recordAssist(nanotime() - startTime)
 				trace := traceAcquire()
 				if trace.ok() {
 					trace.GCMarkAssistDone()
@@ -537,6 +551,7 @@ retry:
 		//
 		// See the comment on enteredMarkAssistForTracing.
 		enteredMarkAssistForTracing = true
+		startTime = nanotime()
 	}
 
 	// Perform assist work
@@ -582,6 +597,7 @@ retry:
 		// this G's assist debt, or the GC cycle is over.
 	}
 	if enteredMarkAssistForTracing {
+		gcController.recordAssist(nanotime() - startTime)
 		trace := traceAcquire()
 		if trace.ok() {
 			trace.GCMarkAssistDone()
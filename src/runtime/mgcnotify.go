@@ -0,0 +1,69 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+// gcEvent describes a completed GC cycle.
+//
+// Its layout must match runtime/debug.gcEvent.
+type gcEvent struct {
+	numGC    uint32
+	trigger  gcTriggerKind
+	endUnix  int64 // Unix time in nanoseconds of the end of mark termination
+	pauseNS  int64
+	heapGoal uint64
+	heapLive uint64
+}
+
+// lastGCEvent is the event for the most recently completed GC cycle.
+// It is protected by work.sweepWaiters.lock.
+var lastGCEvent gcEvent
+
+// recordGCEvent records lastGCEvent at the end of mark termination.
+//
+// The world must be stopped, memstats.numgc must already count the
+// cycle, and the caller must hold work.sweepWaiters.lock.
+func recordGCEvent(unixNow int64) {
+	assertWorldStopped()
+	assertLockHeld(&work.sweepWaiters.lock)
+
+	lastGCEvent = gcEvent{
+		numGC:    memstats.numgc,
+		trigger:  work.triggerKind,
+		endUnix:  unixNow,
+		pauseNS:  work.pauseNS,
+		heapGoal: gcController.heapGoal(),
+		heapLive: gcController.heapMarked,
+	}
+}
+
+// readGCEvent copies the event for the most recently completed GC
+// cycle into *e. If no cycle has completed, e.numGC is 0.
+//
+//go:linkname readGCEvent runtime/debug.readGCEvent
+func readGCEvent(e unsafe.Pointer) {
+	lock(&work.sweepWaiters.lock)
+	*(*gcEvent)(e) = lastGCEvent
+	unlock(&work.sweepWaiters.lock)
+}
+
+// waitGCEvent blocks until the GC cycle after cycle n has completed
+// mark termination, then copies the event for the most recently
+// completed cycle into *e. Cycles between n and e.numGC were missed.
+//
+//go:linkname waitGCEvent runtime/debug.waitGCEvent
+func waitGCEvent(e unsafe.Pointer, n uint32) {
+	for {
+		lock(&work.sweepWaiters.lock)
+		if int32(lastGCEvent.numGC-n) > 0 {
+			*(*gcEvent)(e) = lastGCEvent
+			unlock(&work.sweepWaiters.lock)
+			return
+		}
+		work.sweepWaiters.list.push(getg())
+		goparkunlock(&work.sweepWaiters.lock, waitReasonWaitForGCCycle, traceBlockUntilGCEnds, 1)
+	}
+}
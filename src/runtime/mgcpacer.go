@@ -115,6 +115,39 @@ type gcControllerState struct {
 	// debugging.
 	heapMinimum uint64
 
+	// minHeap, if non-zero, overrides heapMinimum regardless of gcPercent.
+	// Set by runtime/debug.SetGCPolicy.
+	minHeap uint64
+
+	// maxHeap, if non-zero, is the maximum heap goal. Like the memory
+	// limit, it may lower the goal below what gcPercent calls for, but
+	// it applies to the heap alone. Set by runtime/debug.SetGCPolicy.
+	maxHeap atomic.Uint64
+
+	// maxAssistLatency, if non-zero, is the target maximum duration of a
+	// single mark assist, in nanoseconds. Set by runtime/debug.SetGCPolicy.
+	//
+	// When assists exceed it, the pacer lets the heap overshoot its goal
+	// by assistBoost to reduce the assist work required of each allocation.
+	maxAssistLatency atomic.Int64
+
+	// assistBoost is the fraction of the runway between the trigger and
+	// the heap goal by which revise extends the heap goal that assists
+	// are paced against. It grows in cycles with assists slower than
+	// maxAssistLatency, and decays in cycles without.
+	//
+	// Read and written with the world stopped.
+	assistBoost float64
+
+	// assistLatency is the distribution of the durations of mark assists.
+	assistLatency timeHistogram
+
+	// slowAssists is the number of mark assists that took longer than
+	// maxAssistLatency, and lastSlowAssists is its value at the end of
+	// the last cycle.
+	slowAssists     atomic.Uint64
+	lastSlowAssists uint64
+
 	// runway is the amount of runway in heap bytes allocated by the
 	// application that we want to give the GC once it starts.
 	//
@@ -346,6 +379,20 @@ type gcControllerState struct {
 	// If this is zero, no fractional workers are needed.
 	fractionalUtilizationGoal float64
 
+	// dedicatedWorkers, if positive, is the number of dedicated mark
+	// workers to run, overriding the gcBackgroundUtilization goal.
+	// It is capped at GOMAXPROCS. Initialized from GODEBUG=gcworkers
+	// and set by runtime/debug.SetGCWorkers.
+	dedicatedWorkers atomic.Int32
+	_                uint32 // align the following 64-bit fields on 32-bit for atomics
+
+	// backgroundUtilization is the background mark utilization goal
+	// of the current cycle. It is gcBackgroundUtilization unless
+	// dedicatedWorkers is set.
+	//
+	// Read and written with the world stopped.
+	backgroundUtilization float64
+
 	// These memory stats are effectively duplicates of fields from
 	// memstats.heapStats but are updated atomically or with the world
 	// stopped and don't provide the same consistency guarantees.
@@ -369,6 +416,7 @@ type gcControllerState struct {
 func (c *gcControllerState) init(gcPercent int32, memoryLimit int64) {
 	c.heapMinimum = defaultHeapMinimum
 	c.triggered = ^uint64(0)
+	c.backgroundUtilization = gcBackgroundUtilization
 	c.setGCPercent(gcPercent)
 	c.setMemoryLimit(memoryLimit)
 	c.commit(true) // No sweep phase in the first GC cycle.
@@ -415,6 +463,15 @@ func (c *gcControllerState) startCycle(markStartTime int64, procs int, trigger g
 	} else {
 		c.fractionalUtilizationGoal = 0
 	}
+	c.backgroundUtilization = gcBackgroundUtilization
+
+	// If the number of dedicated workers was set explicitly, use
+	// exactly that many and no fractional workers.
+	if n := c.dedicatedWorkers.Load(); n > 0 {
+		dedicatedMarkWorkersNeeded = min(int64(n), int64(procs))
+		c.fractionalUtilizationGoal = 0
+		c.backgroundUtilization = float64(dedicatedMarkWorkersNeeded) / float64(procs)
+	}
 
 	// In STW mode, we just want dedicated workers.
 	if debug.gcstoptheworld > 0 {
@@ -502,6 +559,18 @@ func (c *gcControllerState) revise() {
 	// heapGoal assuming the heap is in steady-state.
 	heapGoal := int64(c.heapGoal())
 
+	// If assists have been too slow, pace them against a larger goal,
+	// trading heap growth for assist latency. Don't go past the memory
+	// limit or the maximum heap size, though.
+	if c.assistBoost > 0 && c.triggered != ^uint64(0) && heapGoal > int64(c.triggered) {
+		boosted := heapGoal + int64(float64(heapGoal-int64(c.triggered))*c.assistBoost)
+		limit := int64(c.memoryLimitHeapGoal())
+		if maxHeap := c.maxHeap.Load(); maxHeap != 0 && int64(maxHeap) < limit {
+			limit = int64(maxHeap)
+		}
+		heapGoal = max(heapGoal, min(boosted, limit))
+	}
+
 	// The expected scan work is computed as the amount of bytes scanned last
 	// GC cycle (both heap and stack), plus our estimate of globals work for this cycle.
 	scanWorkExpected := int64(c.lastHeapScan + c.lastStackScan.Load() + c.globalsScan.Load())
@@ -600,11 +669,23 @@ func (c *gcControllerState) endCycle(now int64, procs int, userForced bool) {
 	// We'll be updating the heap goal soon.
 	gcController.lastHeapGoal = c.heapGoal()
 
+	// Adjust the assist boost for the next cycle. Double it after
+	// a cycle with slow assists, and halve it after a cycle without.
+	slow := c.slowAssists.Load()
+	if c.maxAssistLatency.Load() == 0 {
+		c.assistBoost = 0
+	} else if slow > c.lastSlowAssists {
+		c.assistBoost = min(max(2*c.assistBoost, minAssistBoost), maxAssistBoost)
+	} else if c.assistBoost /= 2; c.assistBoost < minAssistBoost {
+		c.assistBoost = 0
+	}
+	c.lastSlowAssists = slow
+
 	// Compute the duration of time for which assists were turned on.
 	assistDuration := now - c.markStartTime
 
 	// Assume background mark hit its utilization goal.
-	utilization := gcBackgroundUtilization
+	utilization := c.backgroundUtilization
 	// Add assist utilization; avoid divide by zero.
 	if assistDuration > 0 {
 		utilization += float64(c.assistTime.Load()) / float64(assistDuration*int64(procs))
@@ -683,6 +764,22 @@ func (c *gcControllerState) endCycle(now int64, procs int, userForced bool) {
 	}
 }
 
+const (
+	// minAssistBoost and maxAssistBoost bound gcControllerState.assistBoost
+	// while it's non-zero. At maxAssistBoost, assists are paced as if the
+	// runway were twice as long.
+	minAssistBoost = 0.125
+	maxAssistBoost = 1.0
+)
+
+// recordAssist records a mark assist that took duration nanoseconds.
+func (c *gcControllerState) recordAssist(duration int64) {
+	c.assistLatency.record(duration)
+	if target := c.maxAssistLatency.Load(); target > 0 && duration > target {
+		c.slowAssists.Add(1)
+	}
+}
+
 // enlistWorker encourages another dedicated mark worker to start on
 // another P if there are spare worker slots. It is used by putfull
 // when more work is made available.
@@ -921,8 +1018,13 @@ func (c *gcControllerState) heapGoalInternal() (goal, minTrigger uint64) {
 	// Start with the goal calculated for gcPercent.
 	goal = c.gcPercentHeapGoal.Load()
 
-	// Check if the memory-limit-based goal is smaller, and if so, pick that.
-	if newGoal := c.memoryLimitHeapGoal(); newGoal < goal {
+	// Check if the memory-limit-based goal, or the maximum heap goal, is
+	// smaller, and if so, pick that.
+	newGoal := c.memoryLimitHeapGoal()
+	if maxHeap := c.maxHeap.Load(); maxHeap != 0 && maxHeap < newGoal {
+		newGoal = maxHeap
+	}
+	if newGoal < goal {
 		goal = newGoal
 	} else {
 		// We're not limited by the memory limit goal, so perform a series of
@@ -1260,7 +1362,11 @@ func (c *gcControllerState) setGCPercent(in int32) int32 {
 	if in < 0 {
 		in = -1
 	}
-	c.heapMinimum = defaultHeapMinimum * uint64(in) / 100
+	if c.minHeap != 0 {
+		c.heapMinimum = c.minHeap
+	} else {
+		c.heapMinimum = defaultHeapMinimum * uint64(in) / 100
+	}
 	c.gcPercent.Store(in)
 
 	return out
@@ -1285,6 +1391,11 @@ func setGCPercent(in int32) (out int32) {
 	return out
 }
 
+//go:linkname setGCWorkers runtime/debug.setGCWorkers
+func setGCWorkers(in int32) (out int32) {
+	return gcController.dedicatedWorkers.Swap(max(in, 0))
+}
+
 func readGOGC() int32 {
 	p := gogetenv("GOGC")
 	if p == "off" {
@@ -1331,6 +1442,78 @@ func setMemoryLimit(in int64) (out int64) {
 	return out
 }
 
+// gcPolicy is the configuration of the GC pacer.
+//
+// Its layout must match runtime/debug.GCPolicy.
+type gcPolicy struct {
+	gcPercent        int
+	memoryLimit      int64
+	minHeap          uint64
+	maxHeap          uint64
+	maxAssistLatency int64
+}
+
+// policy returns the pacer's current configuration.
+//
+// mheap_.lock must be held or the world must be stopped.
+func (c *gcControllerState) policy() gcPolicy {
+	if !c.test {
+		assertWorldStoppedOrLockHeld(&mheap_.lock)
+	}
+	return gcPolicy{
+		gcPercent:        int(c.gcPercent.Load()),
+		memoryLimit:      c.memoryLimit.Load(),
+		minHeap:          c.minHeap,
+		maxHeap:          c.maxHeap.Load(),
+		maxAssistLatency: c.maxAssistLatency.Load(),
+	}
+}
+
+// setPolicy updates the pacer's configuration all at once. commit must be
+// called after. Returns the old configuration.
+//
+// mheap_.lock must be held or the world must be stopped.
+func (c *gcControllerState) setPolicy(p gcPolicy) gcPolicy {
+	out := c.policy()
+	c.minHeap = p.minHeap
+	c.maxHeap.Store(p.maxHeap)
+	c.maxAssistLatency.Store(p.maxAssistLatency)
+	c.setGCPercent(int32(p.gcPercent))
+	c.setMemoryLimit(p.memoryLimit)
+	return out
+}
+
+//go:linkname readGCPolicy runtime/debug.readGCPolicy
+func readGCPolicy(p *gcPolicy) {
+	// Run on the system stack since we grab the heap lock.
+	systemstack(func() {
+		lock(&mheap_.lock)
+		*p = gcController.policy()
+		unlock(&mheap_.lock)
+	})
+}
+
+// setGCPolicy sets the pacer's configuration to *p, and replaces *p with the
+// old configuration.
+//
+//go:linkname setGCPolicy runtime/debug.setGCPolicy
+func setGCPolicy(p *gcPolicy) {
+	in := *p
+	// Run on the system stack since we grab the heap lock.
+	systemstack(func() {
+		lock(&mheap_.lock)
+		*p = gcController.setPolicy(in)
+		gcControllerCommit()
+		unlock(&mheap_.lock)
+	})
+
+	// If we just disabled GC, wait for any concurrent GC mark to
+	// finish so we always return with no GC running, as setGCPercent does.
+	if in.gcPercent < 0 && in.maxHeap == 0 {
+		gcWaitOnMark(work.cycles.Load())
+	}
+}
+
 func readGOMEMLIMIT() int64 {
 	p := gogetenv("GOMEMLIMIT")
 	if p == "" || p == "off" {
@@ -1095,3 +1095,76 @@ func TestIdleMarkWorkerCount(t *testing.T) {
 		t.Fatalf("expected to be able to add idle mark workers after capacity set to 1")
 	}
 }
+
+func TestDedicatedMarkWorkers(t *testing.T) {
+	for _, tc := range []struct {
+		setting, procs int
+		dedicated      int64
+		fractional     bool
+	}{
+		{0, 1, 0, true},
+		{0, 4, 1, false},
+		{0, 6, 1, true},
+		{0, 16, 4, false},
+		{1, 1, 1, false},
+		{2, 6, 2, false},
+		{8, 16, 8, false},
+		{8, 4, 4, false},
+	} {
+		c := NewGCController(100, math.MaxInt64)
+		c.SetDedicatedWorkers(tc.setting)
+		c.StartCycle(0, 0, 1.0, tc.procs)
+		dedicated, fractional := c.MarkWorkers()
+		if dedicated != tc.dedicated || (fractional != 0) != tc.fractional {
+			t.Errorf("with setting %d and GOMAXPROCS=%d: got %d dedicated workers and fractional goal %v, want %d dedicated workers and fractional %v",
+				tc.setting, tc.procs, dedicated, fractional, tc.dedicated, tc.fractional)
+		}
+	}
+}
+
+func TestGcPacerAssistLatency(t *testing.T) {
+	// Run two identical controllers through a few cycles, one of which
+	// sees slow assists, and check that it asks for less assist work.
+	const (
+		heapMarked = 64 << 20
+		procs      = 4
+		target     = 100e3 // 100µs
+	)
+	var fast, slow *GCController
+	assistRatio := func(c *GCController) float64 {
+		c.StartCycle(1<<20, 0, 1.0, procs)
+		c.Revise(GCControllerReviseDelta{HeapLive: 8 << 20, HeapScanWork: 4 << 20})
+		return c.AssistWorkPerByte()
+	}
+	endCycle := func(c *GCController) {
+		c.EndCycle(heapMarked, 10e6, 100e6, procs)
+	}
+	fast = NewGCController(100, math.MaxInt64)
+	slow = NewGCController(100, math.MaxInt64)
+	for _, c := range []*GCController{fast, slow} {
+		c.SetMaxAssistLatency(target)
+		assistRatio(c)
+		endCycle(c)
+	}
+	for i := 0; i < 3; i++ {
+		fastRatio, slowRatio := assistRatio(fast), assistRatio(slow)
+		if i > 0 && slowRatio >= fastRatio {
+			t.Errorf("cycle %d: assist ratio with slow assists is %f, want less than %f", i, slowRatio, fastRatio)
+		}
+		fast.RecordAssist(target / 2)
+		slow.RecordAssist(2 * target)
+		endCycle(fast)
+		endCycle(slow)
+	}
+
+	// Once assists are fast again, the boost decays.
+	for i := 0; i < 5; i++ {
+		assistRatio(slow)
+		endCycle(slow)
+		assistRatio(fast)
+		endCycle(fast)
+	}
+	if fastRatio, slowRatio := assistRatio(fast), assistRatio(slow); slowRatio != fastRatio {
+		t.Errorf("assist ratio after recovery is %f, want %f", slowRatio, fastRatio)
+	}
+}
@@ -101,12 +101,12 @@ const (
 	// The background scavenger is paced according to these parameters.
 	//
 	// scavengePercent represents the portion of mutator time we're willing
-	// to spend on scavenging in percent.
+	// to spend on scavenging in percent, by default.
 	scavengePercent = 1 // 1%
 
 	// retainExtraPercent represents the amount of memory over the heap goal
-	// that the scavenger should keep as a buffer space for the allocator.
-	// This constant is used when we do not have a memory limit set.
+	// that the scavenger should keep as a buffer space for the allocator,
+	// by default. This constant is used when we do not have a memory limit set.
 	//
 	// The purpose of maintaining this overhead is to have a greater pool of
 	// unscavenged memory available for allocation (since using scavenged memory
@@ -201,14 +201,14 @@ func gcPaceScavenger(memoryLimit int64, heapGoal, lastHeapGoal uint64) {
 	// Compute our scavenging goal.
 	goalRatio := float64(heapGoal) / float64(lastHeapGoal)
 	gcPercentGoal := uint64(float64(memstats.lastHeapInUse) * goalRatio)
-	// Add retainExtraPercent overhead to retainedGoal. This calculation
-	// looks strange but the purpose is to arrive at an integer division
-	// (e.g. if retainExtraPercent = 12.5, then we get a divisor of 8)
-	// that also avoids the overflow from a multiplication.
-	gcPercentGoal += gcPercentGoal / (1.0 / (retainExtraPercent / 100.0))
+	// Add scavenge.retainExtraPercent overhead to retainedGoal. Divide
+	// first to avoid overflow from the multiplication.
+	gcPercentGoal += gcPercentGoal / 100 * uint64(scavenge.retainExtraPercent)
 	// Align it to a physical page boundary to make the following calculations
 	// a bit more exact.
 	gcPercentGoal = (gcPercentGoal + uint64(physPageSize) - 1) &^ (uint64(physPageSize) - 1)
+	// Retain at least as much memory as was prefaulted.
+	gcPercentGoal = max(gcPercentGoal, scavenge.retainMin)
 
 	// Represents where we are now in the heap's contribution to RSS in bytes.
 	//
@@ -257,6 +257,146 @@ var scavenge struct {
 	//
 	// This is reset once a GC cycle ends.
 	backgroundTime atomic.Int64
+
+	// retainExtraPercent is the amount of memory over the heap goal that
+	// the scavenger retains, in percent of the heap goal. See the
+	// retainExtraPercent constant, its default.
+	//
+	// Protected by mheap_.lock.
+	retainExtraPercent int
+
+	// retainMin is the minimum amount of retained heap memory that the
+	// background scavenger keeps, as set by runtime/debug.PrefaultHeap.
+	//
+	// Protected by mheap_.lock.
+	retainMin uint64
+
+	// retain is how long, in nanoseconds, free pages are retained
+	// before the scavenger may release them, for each of the size
+	// tiers of scavengeRetainTier. It's applied to the whole chunks
+	// the pages are freed into (see pallocData.freed), and doesn't
+	// apply to scavenging that is forced, such as to stay under the
+	// memory limit.
+	retain [scavengeRetainTiers]atomic.Int64
+
+	// cpuPercent is the portion of mutator time the background scavenger
+	// aims to spend, in percent. See the scavengePercent constant, its
+	// default.
+	cpuPercent atomic.Float64
+
+	// dontNeed indicates that memory is returned to the OS with
+	// MADV_DONTNEED instead of MADV_FREE, on systems that have both.
+	// Initialized from GODEBUG=madvdontneed.
+	dontNeed atomic.Bool
+}
+
+// Size tiers of freed runs of pages, for scavenge.retain. They must
+// match the values of runtime/debug.SpanSizeTier.
+const (
+	scavengeRetainSmall  = iota // under 64 KiB
+	scavengeRetainMedium        // under 1 MiB
+	scavengeRetainLarge
+	scavengeRetainTiers
+)
+
+// scavengeRetainTier returns the size tier of a freed run of npages.
+func scavengeRetainTier(npages uintptr) int {
+	switch {
+	case npages*pageSize < 64<<10:
+		return scavengeRetainSmall
+	case npages*pageSize < 1<<20:
+		return scavengeRetainMedium
+	}
+	return scavengeRetainLarge
+}
+
+// setSpanRetention sets the retention of freed runs of pages of the
+// given size tier to d nanoseconds, unless d is negative, and returns
+// the previous retention.
+//
+//go:linkname setSpanRetention runtime/debug.setSpanRetention
+func setSpanRetention(tier int, d int64) int64 {
+	if d < 0 {
+		return scavenge.retain[tier].Load()
+	}
+	old := scavenge.retain[tier].Swap(d)
+	if d < old {
+		// Memory may be released sooner.
+		scavenger.wake()
+	}
+	return old
+}
+
+// Values of scavengerPolicy.release. They must match the values of
+// runtime/debug.ReleaseMode.
+const (
+	scavengeReleaseDontNeed = iota
+	scavengeReleaseFree
+)
+
+// scavengerPolicy is the configuration of the scavenger.
+//
+// Its layout must match runtime/debug.ScavengerPolicy.
+type scavengerPolicy struct {
+	retainExtraPercent int
+	cpuPercent         float64
+	release            int
+}
+
+// scavengeInit sets the scavenger's default configuration.
+// It must be called after parsedebugvars.
+func scavengeInit() {
+	scavenge.retainExtraPercent = retainExtraPercent
+	scavenge.cpuPercent.Store(scavengePercent)
+	scavenge.dontNeed.Store(debug.madvdontneed != 0)
+}
+
+// scavengePolicy returns the scavenger's configuration.
+//
+// mheap_.lock must be held or the world must be stopped.
+func scavengePolicy() scavengerPolicy {
+	assertWorldStoppedOrLockHeld(&mheap_.lock)
+
+	release := scavengeReleaseFree
+	if scavenge.dontNeed.Load() {
+		release = scavengeReleaseDontNeed
+	}
+	return scavengerPolicy{
+		retainExtraPercent: scavenge.retainExtraPercent,
+		cpuPercent:         scavenge.cpuPercent.Load(),
+		release:            release,
+	}
+}
+
+//go:linkname readScavengerPolicy runtime/debug.readScavengerPolicy
+func readScavengerPolicy(p *scavengerPolicy) {
+	// Run on the system stack since we grab the heap lock.
+	systemstack(func() {
+		lock(&mheap_.lock)
+		*p = scavengePolicy()
+		unlock(&mheap_.lock)
+	})
+}
+
+// setScavengerPolicy sets the scavenger's configuration to *p, and
+// replaces *p with the old configuration.
+//
+//go:linkname setScavengerPolicy runtime/debug.setScavengerPolicy
+func setScavengerPolicy(p *scavengerPolicy) {
+	in := *p
+	// Run on the system stack since we grab the heap lock.
+	systemstack(func() {
+		lock(&mheap_.lock)
+		*p = scavengePolicy()
+		scavenge.retainExtraPercent = in.retainExtraPercent
+		scavenge.cpuPercent.Store(in.cpuPercent)
+		scavenge.dontNeed.Store(in.release == scavengeReleaseDontNeed)
+		gcControllerCommit()
+		unlock(&mheap_.lock)
+	})
+
+	// The scavenger may have more work to do under the new policy.
+	scavenger.wake()
 }
 
 const (
@@ -534,7 +674,7 @@ func (s *scavengerState) sleep(worked float64) {
 
 	// idealFraction is the ideal % of overall application CPU time that we
 	// spend scavenging.
-	idealFraction := float64(scavengePercent) / 100.0
+	idealFraction := scavenge.cpuPercent.Load() / 100.0
 
 	// Calculate the CPU time spent.
 	//
@@ -680,7 +820,7 @@ func (p *pageAlloc) scavenge(nbytes uintptr, shouldStop func() bool, force bool)
 			break
 		}
 		systemstack(func() {
-			released += p.scavengeOne(ci, pageIdx, nbytes-released)
+			released += p.scavengeOne(ci, pageIdx, nbytes-released, force)
 		})
 		if shouldStop != nil && shouldStop() {
 			break
@@ -724,12 +864,15 @@ func printScavTrace(releasedBg, releasedEager uintptr, forced bool) {
 //
 // searchIdx is the page index to start searching from in ci.
 //
+// Unless force is set, scavengeOne skips chunks whose free pages are
+// being retained.
+//
 // Returns the number of bytes scavenged.
 //
 // Must run on the systemstack because it acquires p.mheapLock.
 //
 //go:systemstack
-func (p *pageAlloc) scavengeOne(ci chunkIdx, searchIdx uint, max uintptr) uintptr {
+func (p *pageAlloc) scavengeOne(ci chunkIdx, searchIdx uint, max uintptr, force bool) uintptr {
 	// Calculate the maximum number of pages to scavenge.
 	//
 	// This should be alignUp(max, pageSize) / pageSize but max can and will
@@ -752,6 +895,11 @@ func (p *pageAlloc) scavengeOne(ci chunkIdx, searchIdx uint, max uintptr) uintpt
 	}
 
 	lock(p.mheapLock)
+	if !force && p.chunkOf(ci).retained(nanotime()) {
+		p.scav.index.retain(ci)
+		unlock(p.mheapLock)
+		return 0
+	}
 	if p.summary[len(p.summary)-1][ci].max() >= uint(minPages) {
 		// We only bother looking for a candidate if there at least
 		// minPages free pages at all.
@@ -1042,6 +1190,11 @@ type scavengeIndex struct {
 	// this generation.
 	freeHWM offAddr
 
+	// retainHWM is the highest address (in offset address space) of a chunk
+	// that the background scavenger skipped this generation because its free
+	// pages were being retained.
+	retainHWM offAddr
+
 	// Generation counter. Updated by nextGen at the end of each mark phase.
 	gen uint32
 
@@ -1056,6 +1209,7 @@ func (s *scavengeIndex) init(test bool, sysStat *sysMemStat) uintptr {
 	s.searchAddrBg.Clear()
 	s.searchAddrForce.Clear()
 	s.freeHWM = minOffAddr
+	s.retainHWM = minOffAddr
 	s.test = test
 	return s.sysInit(test, sysStat)
 }
@@ -1172,11 +1326,37 @@ func (s *scavengeIndex) free(ci chunkIdx, page, npages uint) {
 // nextGen may only run concurrently with find.
 func (s *scavengeIndex) nextGen() {
 	s.gen++
+	hwm := s.freeHWM
+	if hwm.lessThan(s.retainHWM) {
+		hwm = s.retainHWM
+	}
 	searchAddr, _ := s.searchAddrBg.Load()
-	if (offAddr{searchAddr}).lessThan(s.freeHWM) {
-		s.searchAddrBg.StoreMarked(s.freeHWM.addr())
+	if (offAddr{searchAddr}).lessThan(hwm) {
+		s.searchAddrBg.StoreMarked(hwm.addr())
 	}
 	s.freeHWM = minOffAddr
+	s.retainHWM = minOffAddr
+}
+
+// retain moves the background search past ci, whose free pages are
+// being retained, and makes sure it's searched again next generation.
+//
+// retain may only run concurrently with find.
+func (s *scavengeIndex) retain(ci chunkIdx) {
+	top := offAddr{chunkBase(ci) + pallocChunkBytes - pageSize}
+	if s.retainHWM.lessThan(top) {
+		s.retainHWM = top
+	}
+	searchAddr, marked := s.searchAddrBg.Load()
+	if chunkIndex(searchAddr) != ci {
+		return
+	}
+	newSearchAddr := chunkBase(ci) - pageSize
+	if marked {
+		s.searchAddrBg.StoreUnmark(searchAddr, newSearchAddr)
+	} else {
+		s.searchAddrBg.StoreMin(newSearchAddr)
+	}
 }
 
 // setEmpty marks that the scavenger has finished looking at ci
@@ -453,6 +453,54 @@ func TestPageAllocScavenge(t *testing.T) {
 	}
 }
 
+func TestPageAllocScavengeRetain(t *testing.T) {
+	if PhysPageSize > 1<<20 {
+		t.Skip("physical pages are too large")
+	}
+	defer SetSpanRetention(ScavengeRetainLarge, SetSpanRetention(ScavengeRetainLarge, -1))
+	defer SetSpanRetention(ScavengeRetainSmall, SetSpanRetention(ScavengeRetainSmall, -1))
+
+	const npages = 1 << 20 / PageSize // a large run
+	newFreed := func() *PageAlloc {
+		b := NewPageAlloc(map[ChunkIdx][]BitRange{
+			BaseChunkIdx: {{0, PallocChunkPages}},
+		}, map[ChunkIdx][]BitRange{
+			BaseChunkIdx: {},
+		})
+		b.Free(PageBase(BaseChunkIdx, 0), npages)
+		b.NextGen()
+		return b
+	}
+
+	// Retaining small runs doesn't affect large ones.
+	SetSpanRetention(ScavengeRetainSmall, int64(time.Hour))
+	SetSpanRetention(ScavengeRetainLarge, 0)
+	b := newFreed()
+	if got := b.ScavengeBg(^uintptr(0)); got != npages*PageSize {
+		t.Errorf("scavenged %d bytes with small runs retained, want %d", got, npages*PageSize)
+	}
+	FreePageAlloc(b)
+
+	// Retained large runs are only scavenged when forced, or once the
+	// retention has passed.
+	SetSpanRetention(ScavengeRetainLarge, int64(100*time.Millisecond))
+	b = newFreed()
+	defer FreePageAlloc(b)
+	if got := b.ScavengeBg(^uintptr(0)); got != 0 {
+		t.Errorf("scavenged %d bytes of retained memory", got)
+	}
+	time.Sleep(200 * time.Millisecond)
+	b.NextGen()
+	if got := b.ScavengeBg(^uintptr(0)); got != npages*PageSize {
+		t.Errorf("scavenged %d bytes after retention, want %d", got, npages*PageSize)
+	}
+	b2 := newFreed()
+	defer FreePageAlloc(b2)
+	if got := b2.Scavenge(^uintptr(0)); got != npages*PageSize {
+		t.Errorf("forced scavenge released %d bytes of retained memory, want %d", got, npages*PageSize)
+	}
+}
+
 func TestScavenger(t *testing.T) {
 	// workedTime is a standard conversion of bytes of scavenge
 	// work to time elapsed.
@@ -50,6 +50,48 @@ type sweepdata struct {
 	// Reset at mark termination.
 	// Used by mheap.nextSpanForSweep.
 	centralIndex sweepClass
+
+	// live counts the objects that survived the last GC.
+	live sweepLiveStats
+}
+
+// sweepLiveStats counts the objects in each size class that the
+// sweeper finds live, that is, that the GC before it marked.
+type sweepLiveStats struct {
+	// objects and largeBytes accumulate, over the current sweep, the
+	// live objects of each size class, with class 0 standing in for
+	// large objects, and the bytes occupied by live large objects.
+	// User arena chunks aren't counted.
+	objects    [_NumSizeClasses]atomic.Uint64
+	largeBytes atomic.Uint64
+
+	// lastObjects and lastLargeBytes are the totals of the last
+	// complete sweep. They're updated when the sweep completes, which
+	// isn't atomic as a whole, so a reader racing with that may see
+	// a mix of two cycles.
+	lastObjects    [_NumSizeClasses]atomic.Uint64
+	lastLargeBytes atomic.Uint64
+}
+
+// add records that the sweeper found n live objects of size elemsize
+// in a span of size class sizeclass.
+func (l *sweepLiveStats) add(sizeclass int8, n, elemsize uintptr) {
+	l.objects[sizeclass].Add(int64(n))
+	if sizeclass == 0 {
+		l.largeBytes.Add(int64(n * elemsize))
+	}
+}
+
+// publish makes the counts of the sweep that just completed the last
+// ones, and resets the counts for the next sweep.
+//
+// It must only be called by the last sweeper to finish once the sweep
+// has drained, when no other sweeper can be counting.
+func (l *sweepLiveStats) publish() {
+	for i := range l.objects {
+		l.lastObjects[i].Store(l.objects[i].Swap(0))
+	}
+	l.lastLargeBytes.Store(l.largeBytes.Swap(0))
 }
 
 // sweepClass is a spanClass and one bit to represent whether we're currently
@@ -169,6 +211,13 @@ func (a *activeSweep) end(sl sweepLocker) {
 		if (state&^sweepDrainedMask)-1 >= sweepDrainedMask {
 			throw("mismatched begin/end of activeSweep")
 		}
+		if state == sweepDrainedMask+1 {
+			// This is the last sweeper and the work is drained, so
+			// no other sweeper can be running or start, and the
+			// CAS below can't fail. Publish the live counts before
+			// the sweep can be observed to be done.
+			sweep.live.publish()
+		}
 		if a.state.CompareAndSwap(state, state-1) {
 			if state != sweepDrainedMask {
 				return
@@ -544,6 +593,9 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 	//    If such object is not marked, we need to queue all finalizers at once.
 	// Both 1 and 2 are possible at the same time.
 	hadSpecials := s.specials != nil
+	if debug.quarantine > 0 && spc.sizeclass() != 0 && !s.isUserArenaChunk {
+		s.sweepQuarantine()
+	}
 	siter := newSpecialsIter(s)
 	for siter.valid() {
 		// A finalizer can be set for an inner byte of an object, find object beginning.
@@ -564,15 +616,16 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 				}
 			}
 			if hasFinAndRevived {
-				// Pass 2: queue all finalizers and clear any weak handles. Weak handles are cleared
-				// before finalization as specified by the internal/weak package. See the documentation
-				// for that package for more details.
+				// Pass 2: queue all finalizers and clear any weak handles and ephemerons. Weak
+				// handles are cleared before finalization as specified by the weak package. See
+				// the documentation for that package for more details. Ephemeron values weren't
+				// marked, since their key wasn't, so they must be dropped along with weak handles.
 				for siter.valid() && uintptr(siter.s.offset) < endOffset {
 					// Find the exact byte for which the special was setup
 					// (as opposed to object beginning).
 					special := siter.s
 					p := s.base() + uintptr(special.offset)
-					if special.kind == _KindSpecialFinalizer || special.kind == _KindSpecialWeakHandle {
+					if special.kind == _KindSpecialFinalizer || special.kind == _KindSpecialWeakHandle || special.kind == _KindSpecialEphemeron {
 						siter.unlinkAndNext()
 						freeSpecial(special, unsafe.Pointer(p), size)
 					} else {
@@ -608,7 +661,7 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 		spanHasNoSpecials(s)
 	}
 
-	if traceAllocFreeEnabled() || debug.clobberfree != 0 || raceenabled || msanenabled || asanenabled {
+	if traceAllocFreeEnabled() || debug.clobberfree != 0 || raceenabled || msanenabled || asanenabled || s.objTypes != nil {
 		// Find all newly freed objects.
 		mbits := s.markBitsForBase()
 		abits := s.allocBitsForIndex(0)
@@ -625,6 +678,9 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 				if debug.clobberfree != 0 {
 					clobberfree(unsafe.Pointer(x), size)
 				}
+				if s.objTypes != nil {
+					heapTypesFree(s, i)
+				}
 				// User arenas are handled on explicit free.
 				if raceenabled && !s.isUserArenaChunk {
 					racefree(unsafe.Pointer(x), size)
@@ -725,6 +781,12 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 			mheap_.central[spc].mcentral.fullSwept(sweepgen).push(s)
 			return false
 		}
+		if debug.arenapoison > 0 && work.cycles.Load()-userArenaPoisonInfoOf(s.base()).faultCycle < uint32(debug.arenapoison) {
+			// Keep the chunk set to fault for longer, to catch more accesses
+			// to freed arena memory.
+			mheap_.central[spc].mcentral.fullSwept(sweepgen).push(s)
+			return false
+		}
 
 		// It's only at this point that the sweeper doesn't actually need to look
 		// at this arena anymore, so subtract from pagesInUse now.
@@ -741,12 +803,17 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 			}
 			lock(&mheap_.lock)
 			mheap_.userArena.quarantineList.remove(s)
+			mheap_.userArena.quarantinedBytes.Add(-s.npages * pageSize)
 			mheap_.userArena.readyList.insert(s)
 			unlock(&mheap_.lock)
 		})
 		return false
 	}
 
+	if nalloc > 0 {
+		sweep.live.add(spc.sizeclass(), uintptr(nalloc), s.elemsize)
+	}
+
 	if spc.sizeclass() != 0 {
 		// Handle spans for small objects.
 		if nfreed > 0 {
@@ -812,6 +879,9 @@ func (sl *sweepLocked) sweep(preserve bool) bool {
 			// have mysterious crashes due to confused memory reuse.
 			// It should be possible to switch back to sysFree if we also
 			// implement and then call some kind of mheap.deleteSpan.
+			if s.largeGuard {
+				s.freeLargeGuard()
+			}
 			if debug.efence > 0 {
 				s.limit = 0 // prevent mlookup from finding this span
 				sysFault(unsafe.Pointer(s.base()), size)
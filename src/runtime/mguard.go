@@ -0,0 +1,76 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Guard pages for large objects.
+//
+// With GODEBUG=guardpages=1, the span of each large object gets an
+// extra page after the object's pages, which is set to fault, so a
+// write that runs off the end of those pages faults immediately. The
+// object still starts at the beginning of its span, so the rest of its
+// last page, beyond the size that was asked for, is filled with a
+// pattern instead, which the sweeper checks when it frees the object.
+//
+// The guard page must be a whole number of physical pages, so on
+// systems whose physical pages are larger than the runtime's, large
+// objects only get the pattern.
+//
+// The guard page is part of the span, but not of the object: the
+// span's elemsize excludes it, so the object's accounting and zeroing
+// don't touch it. It's made accessible again before the span is freed.
+
+package runtime
+
+import "unsafe"
+
+// largeGuardPattern is the value of the bytes after the end of a large
+// object in its last page.
+const largeGuardPattern = 0xfd
+
+// largeGuardPages returns the number of guard pages to allocate after a
+// large object.
+func largeGuardPages() uintptr {
+	if debug.guardpages > 0 && physPageSize <= pageSize {
+		return 1
+	}
+	return 0
+}
+
+// initLargeGuard sets up the guard of s, a new span for a large object
+// of npages pages. s.limit must be set to the end of the object.
+func (s *mspan) initLargeGuard(npages uintptr) {
+	s.largeGuard = true
+	s.elemsize = npages * pageSize
+	if s.npages > npages {
+		sysFaultOS(unsafe.Pointer(s.base()+s.elemsize), (s.npages-npages)*pageSize)
+	}
+}
+
+// fillLargeGuard fills the bytes after the end of the large object in
+// s with largeGuardPattern. It must be called after the object has
+// been zeroed.
+func (s *mspan) fillLargeGuard() {
+	for p := s.limit; p < s.base()+s.elemsize; p++ {
+		*(*uint8)(unsafe.Pointer(p)) = largeGuardPattern
+	}
+}
+
+// freeLargeGuard checks that the bytes after the end of the large
+// object in s still hold largeGuardPattern, and makes the guard page
+// accessible again so that s can be freed.
+func (s *mspan) freeLargeGuard() {
+	for p := s.limit; p < s.base()+s.elemsize; p++ {
+		if *(*uint8)(unsafe.Pointer(p)) != largeGuardPattern {
+			print("runtime: large object at ", hex(s.base()), " of size ", s.limit-s.base(), " was written ", p-s.limit, " bytes past its end\n")
+			throw("write past the end of a large object")
+		}
+	}
+	if guard := s.npages*pageSize - s.elemsize; guard > 0 {
+		// Undo sysFaultOS the same way the user arena code does when
+		// it reuses a faulted chunk.
+		v := unsafe.Pointer(s.base() + s.elemsize)
+		sysMapOS(v, guard)
+		sysUsedOS(v, guard)
+	}
+	s.largeGuard = false
+}
@@ -208,7 +208,11 @@ type mheap struct {
 	specialReachableAlloc  fixalloc // allocator for specialReachable
 	specialPinCounterAlloc fixalloc // allocator for specialPinCounter
 	specialWeakHandleAlloc fixalloc // allocator for specialWeakHandle
+	specialCleanupAlloc    fixalloc // allocator for specialCleanup
+	specialEphemeronAlloc  fixalloc // allocator for specialEphemeron
+	specialQuarantineAlloc fixalloc // allocator for specialQuarantine
 	speciallock            mutex    // lock for special record allocators.
+	cleanupID              uint64   // last cleanup ID handed out; protected by speciallock
 	arenaHintAlloc         fixalloc // allocator for arenaHints
 
 	// User arena state.
@@ -228,6 +232,18 @@ type mheap struct {
 
 		// readyList is a list of empty user arena spans that are ready for reuse.
 		readyList mSpanList
+
+		// quarantinedBytes is the amount of address space in spans on
+		// quarantineList. Updated with lock held, but may be read atomically
+		// without it.
+		quarantinedBytes atomic.Uintptr
+
+		// paddingBytes and tailBytes are the total bytes of user arena
+		// chunks lost to alignment padding between values and left
+		// unused at the end of full chunks, respectively. See
+		// (*userArena).publishStats.
+		paddingBytes atomic.Uint64
+		tailBytes    atomic.Uint64
 	}
 
 	unused *specialfinalizer // never set, just here to force the specialfinalizer type into DWARF
@@ -290,6 +306,11 @@ type heapArena struct {
 	// used if debug.gccheckmark > 0.
 	checkmarks *checkmarksMap
 
+	// userArenaPoison stores what debug.arenapoison records about
+	// the user arena chunks in this arena. It is only allocated if
+	// debug.arenapoison > 0 and the arena contains user arena chunks.
+	userArenaPoison *userArenaPoisonMap
+
 	// zeroedBase marks the first byte of the first page in this
 	// arena which hasn't been used yet and is therefore already
 	// zero. zeroedBase is relative to the arena base.
@@ -479,13 +500,18 @@ type mspan struct {
 	state                 mSpanStateBox // mSpanInUse etc; accessed atomically (get/set methods)
 	needzero              uint8         // needs to be zeroed before allocation
 	isUserArenaChunk      bool          // whether or not this span represents a user arena
+	largeGuard            bool          // large object span with GODEBUG=guardpages=1; see mguard.go
 	allocCountBeforeCache uint16        // a copy of allocCount that is stored just before this span is cached
 	elemsize              uintptr       // computed from sizeclass or from npages
 	limit                 uintptr       // end of data in span
 	speciallock           mutex         // guards specials list and changes to pinnerBits
 	specials              *special      // linked list of special records sorted by offset.
 	userArenaChunkFree    addrRange     // interval for managing chunk allocation
+	userArenaOwner        uintptr       // *userArena holding this chunk, if any; doesn't keep it alive
 	largeType             *_type        // malloc header for large objects.
+
+	// Only used with GODEBUG=heaptypes=1. See mheaptypes.go.
+	objTypes *heapTypeTable // type of each object, or nil
 }
 
 func (s *mspan) base() uintptr {
@@ -747,6 +773,9 @@ func (h *mheap) init() {
 	h.specialReachableAlloc.init(unsafe.Sizeof(specialReachable{}), nil, nil, &memstats.other_sys)
 	h.specialPinCounterAlloc.init(unsafe.Sizeof(specialPinCounter{}), nil, nil, &memstats.other_sys)
 	h.specialWeakHandleAlloc.init(unsafe.Sizeof(specialWeakHandle{}), nil, nil, &memstats.gcMiscSys)
+	h.specialCleanupAlloc.init(unsafe.Sizeof(specialCleanup{}), nil, nil, &memstats.other_sys)
+	h.specialEphemeronAlloc.init(unsafe.Sizeof(specialEphemeron{}), nil, nil, &memstats.gcMiscSys)
+	h.specialQuarantineAlloc.init(unsafe.Sizeof(specialQuarantine{}), nil, nil, &memstats.other_sys)
 	h.arenaHintAlloc.init(unsafe.Sizeof(arenaHint{}), nil, nil, &memstats.other_sys)
 
 	// Don't zero mspan allocations. Background sweeping can
@@ -1336,6 +1365,9 @@ HaveSpan:
 
 	// Initialize the span.
 	h.initSpan(s, typ, spanclass, base, npages)
+	if typ == spanAllocHeap && heapTypes.enabled {
+		heapTypesAllocTable(s)
+	}
 
 	// Commit and account for any scavenged memory that the span now owns.
 	nbytes := npages * pageSize
@@ -1625,6 +1657,9 @@ func (h *mheap) freeSpanLocked(s *mspan, typ spanAllocType) {
 			throw("mheap.freeSpanLocked - invalid free")
 		}
 		h.pagesInUse.Add(-s.npages)
+		if s.objTypes != nil {
+			heapTypesFreeTable(s)
+		}
 
 		// Clear in-use bit in arena page bitmap.
 		arena, pageIdx, pageMask := pageIndexOf(s.base())
@@ -1671,14 +1706,28 @@ func (h *mheap) freeSpanLocked(s *mspan, typ spanAllocType) {
 //
 //go:systemstack
 func (h *mheap) scavengeAll() {
+	// Force scavenge everything.
+	h.forceScavenge(^uintptr(0))
+}
+
+// scavengeTo visits each node in the heap and scavenges free pages
+// until the heap's retained memory is at most retained bytes.
+func (h *mheap) scavengeTo(retained uint64) {
+	if now := heapRetained(); now > retained {
+		h.forceScavenge(uintptr(now - retained))
+	}
+}
+
+// forceScavenge scavenges at least nbytes of free pages, or all of
+// them if there are fewer, regardless of their age or density.
+func (h *mheap) forceScavenge(nbytes uintptr) {
 	// Disallow malloc or panic while holding the heap lock. We do
 	// this here because this is a non-mallocgc entry-point to
 	// the mheap API.
 	gp := getg()
 	gp.m.mallocing++
 
-	// Force scavenge everything.
-	released := h.pages.scavenge(^uintptr(0), nil, true)
+	released := h.pages.scavenge(nbytes, nil, true)
 
 	gp.m.mallocing--
 
@@ -1693,6 +1742,104 @@ func runtime_debug_freeOSMemory() {
 	systemstack(func() { mheap_.scavengeAll() })
 }
 
+//go:linkname runtime_debug_freeOSMemoryTo runtime/debug.freeOSMemoryTo
+func runtime_debug_freeOSMemoryTo(retained uint64) {
+	GC()
+	systemstack(func() { mheap_.scavengeTo(retained) })
+}
+
+//go:linkname runtime_debug_prefaultHeap runtime/debug.prefaultHeap
+func runtime_debug_prefaultHeap(bytes uint64) {
+	mheap_.prefault(bytes)
+}
+
+// prefaultChunk is the header of a run of free pages that prefault has
+// taken from the page allocator. It's written into the first page.
+type prefaultChunk struct {
+	next   uintptr
+	npages uintptr
+}
+
+// prefault makes sure that the heap retains at least bytes of memory,
+// as measured by heapRetained, and that the memory is backed by
+// physical memory, by growing the heap and touching its free pages as
+// needed. It also sets the scavenger to retain at least bytes.
+//
+// prefault runs on the user stack, since touching the pages may take
+// a while, and only takes the heap lock to take and return pages.
+func (h *mheap) prefault(bytes uint64) {
+	// Keep the background scavenger from releasing the memory
+	// as it is faulted in.
+	systemstack(func() {
+		lock(&h.lock)
+		scavenge.retainMin = bytes
+		gcControllerCommit()
+		unlock(&h.lock)
+	})
+
+	// Take free pages from the page allocator, a palloc chunk at a
+	// time, and touch them. Keep them until the end, so that the same
+	// pages aren't taken again, and link them through their first page.
+	//
+	// Pages that were scavenged are accounted for as free right away,
+	// as if they had been allocated and freed; the others already are.
+	var chunks uintptr
+	for {
+		retained := heapRetained()
+		if retained >= bytes {
+			break
+		}
+		npages := min(alignUp(uintptr(bytes-retained), pageSize)/pageSize, pallocChunkPages)
+		var base, scav uintptr
+		systemstack(func() {
+			lock(&h.lock)
+			base, scav = h.pages.alloc(npages)
+			if base == 0 {
+				if _, ok := h.grow(npages); ok {
+					base, scav = h.pages.alloc(npages)
+				}
+			}
+			unlock(&h.lock)
+		})
+		if base == 0 {
+			throw("out of memory")
+		}
+		nbytes := npages * pageSize
+		if scav != 0 {
+			sysUsed(unsafe.Pointer(base), nbytes, scav)
+			gcController.heapReleased.add(-int64(scav))
+			gcController.heapFree.add(int64(scav))
+			stats := memstats.heapStats.acquire()
+			atomic.Xaddint64(&stats.committed, int64(scav))
+			atomic.Xaddint64(&stats.released, -int64(scav))
+			memstats.heapStats.release()
+		}
+		// Write a zero to every physical page. The pages are free,
+		// so they're either zero already or need to be zeroed
+		// before they're allocated anyway.
+		for p := base; p < base+nbytes; p += physPageSize {
+			*(*byte)(unsafe.Pointer(p)) = 0
+		}
+		c := (*prefaultChunk)(unsafe.Pointer(base))
+		c.next = chunks
+		c.npages = npages
+		chunks = base
+	}
+
+	// Return the pages, leaving them free and unscavenged.
+	systemstack(func() {
+		lock(&h.lock)
+		for chunks != 0 {
+			c := (*prefaultChunk)(unsafe.Pointer(chunks))
+			base, npages := chunks, c.npages
+			chunks = c.next
+			*c = prefaultChunk{}
+			h.pages.free(base, npages)
+		}
+		unlock(&h.lock)
+	})
+}
+
 // Initialize a new span with the given start and npages.
 func (span *mspan) init(base uintptr, npages uintptr) {
 	// span is *not* zeroed.
@@ -1712,6 +1859,8 @@ func (span *mspan) init(base uintptr, npages uintptr) {
 	span.allocBits = nil
 	span.gcmarkBits = nil
 	span.pinnerBits = nil
+	span.objTypes = nil
+	span.largeGuard = false
 	span.state.set(mSpanDead)
 	lockInit(&span.speciallock, lockRankMspanSpecial)
 }
@@ -1824,6 +1973,16 @@ const (
 	// _KindSpecialPinCounter is a special used for objects that are pinned
 	// multiple times
 	_KindSpecialPinCounter = 5
+	// _KindSpecialCleanup is for tracking cleanups. An object may have
+	// any number of them.
+	_KindSpecialCleanup = 6
+	// _KindSpecialEphemeron is for associating values with keys without
+	// keeping the keys live. An object may be the key of any number of
+	// ephemerons.
+	_KindSpecialEphemeron = 7
+	// _KindSpecialQuarantine marks objects quarantined by
+	// GODEBUG=quarantine=N.
+	_KindSpecialQuarantine = 8
 )
 
 type special struct {
@@ -1854,8 +2013,8 @@ func spanHasNoSpecials(s *mspan) {
 // offset & next, which this routine will fill in.
 // Returns true if the special was successfully added, false otherwise.
 // (The add will fail only if a record with the same p and s->kind
-// already exists.)
-func addspecial(p unsafe.Pointer, s *special) bool {
+// already exists, unless force is set.)
+func addspecial(p unsafe.Pointer, s *special, force bool) bool {
 	span := spanOfHeap(uintptr(p))
 	if span == nil {
 		throw("addspecial on invalid pointer")
@@ -1874,7 +2033,7 @@ func addspecial(p unsafe.Pointer, s *special) bool {
 
 	// Find splice point, check for existing record.
 	iter, exists := span.specialFindSplicePoint(offset, kind)
-	if !exists {
+	if !exists || force {
 		// Splice in record, fill in offset.
 		s.offset = uint16(offset)
 		s.next = *iter
@@ -1884,7 +2043,7 @@ func addspecial(p unsafe.Pointer, s *special) bool {
 
 	unlock(&span.speciallock)
 	releasem(mp)
-	return !exists // already exists
+	return !exists || force // already exists or addition was forced
 }
 
 // Removes the Special record of the given kind for the object p.
@@ -1968,7 +2127,7 @@ func addfinalizer(p unsafe.Pointer, f *funcval, nret uintptr, fint *_type, ot *p
 	s.nret = nret
 	s.fint = fint
 	s.ot = ot
-	if addspecial(p, &s.special) {
+	if addspecial(p, &s.special, false) {
 		// This is responsible for maintaining the same
 		// GC-related invariants as markrootSpans in any
 		// situation where it's possible that markrootSpans
@@ -2008,6 +2167,46 @@ func removefinalizer(p unsafe.Pointer) {
 	unlock(&mheap_.speciallock)
 }
 
+// The described object has a cleanup set for it.
+//
+// specialCleanup is allocated from non-GC'd memory, so any heap
+// pointers must be specially handled.
+type specialCleanup struct {
+	_       sys.NotInHeap
+	special special
+	fn      *funcval // May be a heap pointer.
+	id      uint64   // A unique identifier for the cleanup, used by Cleanup.Stop.
+}
+
+// addCleanup attaches a cleanup function to the object p. Multiple
+// cleanups may be attached to the same object. It returns the cleanup's
+// identifier, which is never zero.
+func addCleanup(p unsafe.Pointer, f *funcval) uint64 {
+	lock(&mheap_.speciallock)
+	s := (*specialCleanup)(mheap_.specialCleanupAlloc.alloc())
+	mheap_.cleanupID++
+	id := mheap_.cleanupID
+	unlock(&mheap_.speciallock)
+	s.special.kind = _KindSpecialCleanup
+	s.fn = f
+	s.id = id
+
+	mp := acquirem()
+	addspecial(p, &s.special, true)
+	// This is responsible for maintaining the same
+	// GC-related invariants as markrootSpans in any
+	// situation where it's possible that markrootSpans
+	// has already run but mark termination hasn't yet.
+	if gcphase != _GCoff {
+		gcw := &mp.p.ptr().gcw
+		// Mark the cleanup itself, since the
+		// special isn't part of the GC'd heap.
+		scanblock(uintptr(unsafe.Pointer(&s.fn)), goarch.PtrSize, &oneptrmask[0], gcw, nil)
+	}
+	releasem(mp)
+	return id
+}
+
 // The described object has a weak pointer.
 //
 // Weak pointers in the GC have the following invariants:
@@ -2040,13 +2239,13 @@ type specialWeakHandle struct {
 	handle *atomic.Uintptr
 }
 
-//go:linkname internal_weak_runtime_registerWeakPointer internal/weak.runtime_registerWeakPointer
-func internal_weak_runtime_registerWeakPointer(p unsafe.Pointer) unsafe.Pointer {
+//go:linkname weak_runtime_registerWeakPointer weak.runtime_registerWeakPointer
+func weak_runtime_registerWeakPointer(p unsafe.Pointer) unsafe.Pointer {
 	return unsafe.Pointer(getOrAddWeakHandle(unsafe.Pointer(p)))
 }
 
-//go:linkname internal_weak_runtime_makeStrongFromWeak internal/weak.runtime_makeStrongFromWeak
-func internal_weak_runtime_makeStrongFromWeak(u unsafe.Pointer) unsafe.Pointer {
+//go:linkname weak_runtime_makeStrongFromWeak weak.runtime_makeStrongFromWeak
+func weak_runtime_makeStrongFromWeak(u unsafe.Pointer) unsafe.Pointer {
 	handle := (*atomic.Uintptr)(u)
 
 	// Prevent preemption. We want to make sure that another GC cycle can't start.
@@ -2110,7 +2309,7 @@ func getOrAddWeakHandle(p unsafe.Pointer) *atomic.Uintptr {
 	s.special.kind = _KindSpecialWeakHandle
 	s.handle = handle
 	handle.Store(uintptr(p))
-	if addspecial(p, &s.special) {
+	if addspecial(p, &s.special, false) {
 		// This is responsible for maintaining the same
 		// GC-related invariants as markrootSpans in any
 		// situation where it's possible that markrootSpans
@@ -2184,9 +2383,10 @@ func getWeakHandle(p unsafe.Pointer) *atomic.Uintptr {
 
 // The described object is being heap profiled.
 type specialprofile struct {
-	_       sys.NotInHeap
-	special special
-	b       *bucket
+	_         sys.NotInHeap
+	special   special
+	b         *bucket
+	allocTime int64 // nanotime when the object was allocated
 }
 
 // Set the heap profile bucket associated with addr to b.
@@ -2196,7 +2396,8 @@ func setprofilebucket(p unsafe.Pointer, b *bucket) {
 	unlock(&mheap_.speciallock)
 	s.special.kind = _KindSpecialProfile
 	s.b = b
-	if !addspecial(p, &s.special) {
+	s.allocTime = nanotime()
+	if !addspecial(p, &s.special, false) {
 		throw("setprofilebucket: profile already set")
 	}
 }
@@ -2261,7 +2462,7 @@ func freeSpecial(s *special, p unsafe.Pointer, size uintptr) {
 		unlock(&mheap_.speciallock)
 	case _KindSpecialProfile:
 		sp := (*specialprofile)(unsafe.Pointer(s))
-		mProf_Free(sp.b, size)
+		mProf_Free(sp.b, size, nanotime()-sp.allocTime)
 		lock(&mheap_.speciallock)
 		mheap_.specialprofilealloc.free(unsafe.Pointer(sp))
 		unlock(&mheap_.speciallock)
@@ -2273,6 +2474,25 @@ func freeSpecial(s *special, p unsafe.Pointer, size uintptr) {
 		lock(&mheap_.speciallock)
 		mheap_.specialPinCounterAlloc.free(unsafe.Pointer(s))
 		unlock(&mheap_.speciallock)
+	case _KindSpecialEphemeron:
+		se := (*specialEphemeron)(unsafe.Pointer(s))
+		unlinkEphemeron(se)
+		lock(&mheap_.speciallock)
+		mheap_.specialEphemeronAlloc.free(unsafe.Pointer(se))
+		unlock(&mheap_.speciallock)
+	case _KindSpecialCleanup:
+		sc := (*specialCleanup)(unsafe.Pointer(s))
+		// Cleanups, unlike finalizers, do not resurrect the objects
+		// they're attached to, so we only need to pass the cleanup
+		// function, not the object.
+		queuefinalizer(nil, sc.fn, 0, nil, nil)
+		lock(&mheap_.speciallock)
+		mheap_.specialCleanupAlloc.free(unsafe.Pointer(sc))
+		unlock(&mheap_.speciallock)
+	case _KindSpecialQuarantine:
+		lock(&mheap_.speciallock)
+		mheap_.specialQuarantineAlloc.free(unsafe.Pointer(s))
+		unlock(&mheap_.speciallock)
 	default:
 		throw("bad special kind")
 		panic("not reached")
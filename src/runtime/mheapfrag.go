@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Heap fragmentation reporting.
+//
+// Memory in the heap that isn't occupied by objects is either slack
+// in the spans of small objects, which can only be reused for objects
+// of the same size class, or part of free spans, which can be reused
+// for anything that fits. The largest run of free pages bounds the
+// largest object the heap can allocate without growing.
+
+package runtime
+
+import _ "unsafe" // for go:linkname
+
+// largestFree returns the number of pages in the largest run of free
+// pages in the page heap, released or not.
+//
+// p.mheapLock must be held.
+func (p *pageAlloc) largestFree() uintptr {
+	assertLockHeld(p.mheapLock)
+
+	// The summaries of the last level describe a single chunk each,
+	// and are valid for every chunk in inUse. Merge those of the
+	// chunks of each range, which are never contiguous with those of
+	// another range.
+	var largest, run uint
+	for _, r := range p.inUse.ranges {
+		run = 0
+		for ci := chunkIndex(r.base.addr()); ci <= chunkIndex(r.limit.addr()-1); ci++ {
+			start, most, end := p.summary[len(p.summary)-1][ci].unpack()
+			if start == pallocChunkPages {
+				run += pallocChunkPages
+				continue
+			}
+			largest = max(largest, run+start, most)
+			run = end
+		}
+		largest = max(largest, run)
+	}
+	return uintptr(largest)
+}
+
+// heapFragmentation is a snapshot of the fragmentation of the heap.
+type heapFragmentation struct {
+	// spans, spanBytes and objects are the number of in-use spans of
+	// each small size class, their total size and the number of
+	// objects allocated in them.
+	spans     [_NumSizeClasses]uint64
+	spanBytes [_NumSizeClasses]uint64
+	objects   [_NumSizeClasses]uint64
+
+	free        uint64 // bytes in free spans that haven't been released
+	released    uint64 // bytes in free spans that have been released
+	largestFree uint64 // bytes in the largest run of free pages
+}
+
+// readHeapFragmentation_m populates f.
+//
+// The world must be stopped.
+func readHeapFragmentation_m(f *heapFragmentation) {
+	assertWorldStopped()
+
+	lock(&mheap_.lock)
+	for _, s := range mheap_.allspans {
+		if s.state.get() != mSpanInUse || s.isUserArenaChunk {
+			continue
+		}
+		sc := s.spanclass.sizeclass()
+		if sc == 0 {
+			continue
+		}
+		f.spans[sc]++
+		f.spanBytes[sc] += uint64(s.npages * pageSize)
+		f.objects[sc] += uint64(s.allocCount)
+	}
+	f.free = gcController.heapFree.load()
+	f.released = gcController.heapReleased.load()
+	f.largestFree = uint64(mheap_.pages.largestFree() * pageSize)
+	unlock(&mheap_.lock)
+}
+
+//go:linkname runtime_debug_readHeapFragmentation runtime/debug.readHeapFragmentation
+func runtime_debug_readHeapFragmentation(class func(size, spans, spanBytes, objects uint64)) (free, released, largestFree uint64) {
+	var f heapFragmentation
+	stw := stopTheWorld(stwReadHeapFragmentation)
+	systemstack(func() {
+		readHeapFragmentation_m(&f)
+	})
+	startTheWorld(stw)
+
+	for sc := 1; sc < len(f.spans); sc++ {
+		if f.spans[sc] == 0 {
+			continue
+		}
+		class(uint64(class_to_size[sc]), f.spans[sc], f.spanBytes[sc], f.objects[sc])
+	}
+	return f.free, f.released, f.largestFree
+}
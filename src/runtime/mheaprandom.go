@@ -0,0 +1,94 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Heap layout randomization.
+//
+// By default the heap is laid out deterministically: on 64-bit systems
+// it starts at 0x00c000000000, and each span hands out its objects in
+// address order. That makes it easier for an attacker who can corrupt
+// memory to predict where objects are, and which object is next to
+// which. With GODEBUG=heaprandomize=1, the runtime instead
+//
+//   - moves each heap arena hint by a random number of arenas, and
+//     starts the heap at a random one of the hints, and
+//   - allocates the small objects of a span in a random order, within
+//     the 64 objects that the span's allocCache covers at a time.
+//
+// Allocating out of order relies on the alloc bits: an object at or
+// above a span's freeindex is allocated if its alloc bit is set. So an
+// object that's allocated ahead of freeindex gets its alloc bit set,
+// after it's initialized, like freeIndexForScan is advanced for objects
+// allocated in order.
+//
+// Randomizing the order costs a few percent in allocation throughput,
+// and some locality. The heap hints are only randomized on 64-bit
+// systems, and not with the race detector, on iOS or on AIX, whose
+// address spaces are too constrained.
+
+package runtime
+
+import (
+	"internal/runtime/sys"
+	"internal/stringslite"
+)
+
+// heapRandomizeInit enables heap randomization according to the
+// heaprandomize setting in godebug. The setting is parsed again with
+// the other debug variables, but the arena hints are created before
+// that, by mallocinit. It must be called before mallocinit.
+func heapRandomizeInit(godebug string) {
+	for p := godebug; p != ""; {
+		var field string
+		field, p, _ = stringslite.Cut(p, ",")
+		if v, ok := stringslite.CutPrefix(field, "heaprandomize="); ok {
+			debug.heaprandomize = 0
+			if v == "1" {
+				debug.heaprandomize = 1
+			}
+		}
+	}
+}
+
+// heapRandomizeHints reports whether mallocinit should randomize the
+// heap arena hints.
+func heapRandomizeHints() bool {
+	return debug.heaprandomize > 0 && !raceenabled && GOOS != "ios" && GOOS != "aix"
+}
+
+// heapRandomizeHintOffset returns a random offset for a heap arena
+// hint, which is a whole number of arenas less than 256 GiB. That keeps
+// the hints apart, since they're at least 1 TiB apart, and in the
+// user address space, since the last one starts 256 GiB below its top.
+func heapRandomizeHintOffset() uintptr {
+	return uintptr(bootstrapRand() & (1<<38 - 1) &^ (heapArenaBytes - 1))
+}
+
+// nextFreeRandom is like nextFreeFast, but picks a random free object
+// in s.allocCache instead of the first one. It returns 0 if it picks
+// the first one, which the caller then allocates in order, or if there
+// are no free objects in s.allocCache.
+//
+// The caller must set the alloc bit of the object once it has been
+// initialized.
+func nextFreeRandom(s *mspan) gclinkptr {
+	cache := s.allocCache
+	if n := s.nelems - s.freeindex; n < 64 {
+		cache &= 1<<n - 1
+	}
+	n := sys.OnesCount64(cache)
+	if n < 2 {
+		return 0
+	}
+	k := cheaprandn(uint32(n))
+	if k == 0 {
+		return 0
+	}
+	for ; k > 0; k-- {
+		cache &= cache - 1
+	}
+	theBit := sys.TrailingZeros64(cache)
+	s.allocCache &^= 1 << theBit
+	s.allocCount++
+	return gclinkptr(uintptr(s.freeindex+uint16(theBit))*s.elemsize + s.base())
+}
@@ -0,0 +1,206 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Per-type heap accounting.
+//
+// With GODEBUG=heaptypes=1, the runtime counts the heap objects of
+// each type and their total size. The heap doesn't otherwise record
+// the types of most objects, so each span allocated while accounting
+// is enabled gets a table of the type of each of its objects, which
+// mallocgc fills in and the sweeper reads back when it frees them.
+// The counts are kept in a fixed-size open-addressed hash table keyed
+// by type, with atomic counters so mallocgc and the sweeper can update
+// them concurrently.
+//
+// The tiny allocator is disabled while accounting is enabled, since it
+// combines objects of different types into one block.
+//
+// Like heapAlloc, the counts include unreachable objects that haven't
+// been swept yet.
+
+package runtime
+
+import (
+	"internal/goarch"
+	"internal/runtime/atomic"
+	"internal/runtime/sys"
+	"unsafe"
+)
+
+const (
+	heapTypeStatsLen    = 1 << 14 // must be a power of 2
+	heapTypeStatsProbes = 64
+
+	// heapTypeUncounted marks objects that were allocated before
+	// accounting was enabled.
+	heapTypeUncounted = 1
+)
+
+// heapTypeTable holds the types of the objects in a span. Its actual
+// length is heapTypesTableLen(span.nelems).
+//
+// Types are never freed, so the table doesn't need to keep them live.
+type heapTypeTable struct {
+	_   sys.NotInHeap
+	typ [1 << 16]uintptr // *_type
+}
+
+// heapTypeStat counts the heap objects of one type.
+type heapTypeStat struct {
+	typ     atomic.Uintptr // *_type, or 0 if the entry is unused
+	objects atomic.Int64
+	bytes   atomic.Int64
+}
+
+var heapTypes struct {
+	// enabled is set once at startup if GODEBUG=heaptypes=1.
+	// Only spans allocated afterwards have type tables.
+	enabled bool
+
+	stats   *[heapTypeStatsLen]heapTypeStat
+	untyped heapTypeStat // objects allocated without a type
+	other   heapTypeStat // types that didn't fit in stats
+
+	// tables are free lists of objTypes tables for each span class.
+	tables [numSpanClasses]lfstack
+}
+
+// heapTypesInit enables per-type heap accounting if requested.
+// It must be called after parsedebugvars.
+func heapTypesInit() {
+	if debug.heaptypes == 0 {
+		return
+	}
+	p := sysAlloc(unsafe.Sizeof(*heapTypes.stats), &memstats.other_sys)
+	if p == nil {
+		throw("runtime: cannot allocate memory for heap type statistics")
+	}
+	heapTypes.stats = (*[heapTypeStatsLen]heapTypeStat)(p)
+	heapTypes.enabled = true
+
+	// Give the spans allocated so far tables too, so that the
+	// objects allocated from them from now on are counted. There
+	// is only one M at this point, so we can walk allspans.
+	for _, s := range mheap_.allspans {
+		if s.state.get() != mSpanInUse || s.isUserArenaChunk {
+			continue
+		}
+		heapTypesAllocTable(s)
+		for i := range heapTypesTableLen(s.nelems) {
+			s.objTypes.typ[i] = heapTypeUncounted
+		}
+	}
+}
+
+// heapTypeStatFor returns the counters for objects of type typ.
+func heapTypeStatFor(typ *_type) *heapTypeStat {
+	if typ == nil {
+		return &heapTypes.untyped
+	}
+	t := uintptr(unsafe.Pointer(typ))
+	h := uintptr(typ.Hash)
+	for i := uintptr(0); i < heapTypeStatsProbes; i++ {
+		st := &heapTypes.stats[(h+i)&(heapTypeStatsLen-1)]
+		switch st.typ.Load() {
+		case t:
+			return st
+		case 0:
+			if st.typ.CompareAndSwap(0, t) || st.typ.Load() == t {
+				return st
+			}
+		}
+	}
+	return &heapTypes.other
+}
+
+// heapTypesTableLen returns the number of entries in an objTypes table
+// for spans with nelems objects. A table must be able to hold an lfnode
+// while it's on a free list.
+func heapTypesTableLen(nelems uint16) uintptr {
+	return max(uintptr(nelems), (unsafe.Sizeof(lfnode{})+goarch.PtrSize-1)/goarch.PtrSize)
+}
+
+// heapTypesAllocTable gives s, a newly allocated heap span, a table
+// for the types of its objects.
+func heapTypesAllocTable(s *mspan) {
+	p := heapTypes.tables[s.spanclass].pop()
+	if p == nil {
+		n := heapTypesTableLen(s.nelems)
+		p = persistentalloc(n*goarch.PtrSize, 8, &memstats.other_sys)
+	}
+	s.objTypes = (*heapTypeTable)(p)
+}
+
+// heapTypesFreeTable releases the objTypes table of s, which is
+// being freed.
+func heapTypesFreeTable(s *mspan) {
+	p := unsafe.Pointer(s.objTypes)
+	s.objTypes = nil
+	node := (*lfnode)(p)
+	*node = lfnode{}
+	heapTypes.tables[s.spanclass].push(node)
+}
+
+// heapTypesAlloc records the allocation of the object containing x in
+// span s, of type typ.
+func heapTypesAlloc(s *mspan, x uintptr, typ *_type) {
+	if s.objTypes == nil {
+		return
+	}
+	s.objTypes.typ[s.objIndex(x)] = uintptr(unsafe.Pointer(typ))
+	st := heapTypeStatFor(typ)
+	st.objects.Add(1)
+	st.bytes.Add(int64(s.elemsize))
+}
+
+// heapTypesFree records the freeing of object i of span s by the
+// sweeper. s must have an objTypes table.
+func heapTypesFree(s *mspan, i uintptr) {
+	t := s.objTypes.typ[i]
+	if t == heapTypeUncounted {
+		return
+	}
+	st := heapTypeStatFor((*_type)(unsafe.Pointer(t)))
+	st.objects.Add(-1)
+	st.bytes.Add(-int64(s.elemsize))
+}
+
+// heapTypesForEach calls f for each type with live heap objects. typ
+// is nil for objects allocated without a type, and other is set for
+// the types that didn't fit in the table.
+func heapTypesForEach(f func(typ *_type, other bool, objects, bytes int64)) {
+	if !heapTypes.enabled {
+		return
+	}
+	for i := range heapTypes.stats {
+		st := &heapTypes.stats[i]
+		t := st.typ.Load()
+		if t == 0 {
+			continue
+		}
+		if n := st.objects.Load(); n > 0 {
+			f((*_type)(unsafe.Pointer(t)), false, n, st.bytes.Load())
+		}
+	}
+	if n := heapTypes.untyped.objects.Load(); n > 0 {
+		f(nil, false, n, heapTypes.untyped.bytes.Load())
+	}
+	if n := heapTypes.other.objects.Load(); n > 0 {
+		f(nil, true, n, heapTypes.other.bytes.Load())
+	}
+}
+
+//go:linkname runtime_debug_readHeapTypeStats runtime/debug.readHeapTypeStats
+func runtime_debug_readHeapTypeStats(f func(name string, objects, bytes uint64)) {
+	heapTypesForEach(func(typ *_type, other bool, objects, bytes int64) {
+		var name string
+		switch {
+		case other:
+			name = "(other)"
+		case typ != nil:
+			name = toRType(typ).string()
+		}
+		f(name, uint64(objects), uint64(bytes))
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Huge page control for the heap.
+//
+// By default the runtime gives the OS no advice about whether to back
+// the heap with transparent huge pages. The heap's huge page mode asks
+// the OS to use them, or not to, for all heap memory. Newly mapped
+// memory gets the advice in sysMap, since mapping it discards any
+// advice given before.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	"unsafe"
+)
+
+// Huge page modes for the heap. These must match the HugePageMode
+// constants in runtime/debug.
+const (
+	hugePagesDefault  = iota // give no advice
+	hugePagesEnabled         // advise the OS to use huge pages
+	hugePagesDisabled        // advise the OS not to use huge pages
+)
+
+// heapHugePages is the heap's huge page mode. It is set under the heap
+// lock, but may be read without it.
+var heapHugePages atomic.Int32
+
+// hugePagesInit sets the initial huge page mode. It must be called
+// after parsedebugvars.
+func hugePagesInit() {
+	if debug.disablethp != 0 {
+		heapHugePages.Store(hugePagesDisabled)
+	}
+}
+
+//go:linkname setHeapHugePages runtime/debug.setHeapHugePages
+func setHeapHugePages(mode int32) int32 {
+	var prev int32
+	systemstack(func() {
+		lock(&mheap_.lock)
+		prev = heapHugePages.Swap(mode)
+		if mode != prev {
+			// The OS has no way to withdraw advice, so leave the
+			// heap as it is if the mode is now hugePagesDefault.
+			for _, ai := range mheap_.allArenas {
+				switch mode {
+				case hugePagesEnabled:
+					sysHugePage(unsafe.Pointer(arenaBase(ai)), heapArenaBytes)
+				case hugePagesDisabled:
+					sysNoHugePage(unsafe.Pointer(arenaBase(ai)), heapArenaBytes)
+				}
+			}
+		}
+		unlock(&mheap_.lock)
+	})
+	return prev
+}
+
+//go:linkname collapseHeapHugePages runtime/debug.collapseHeapHugePages
+func collapseHeapHugePages() {
+	// Collapse each chunk of the page heap that is fully backed by
+	// memory. Collapsing a chunk with released pages would back them
+	// again, so skip those. Don't hold the heap lock while the OS
+	// collapses a chunk, which may take a while.
+	var ci chunkIdx
+	for {
+		found := false
+		systemstack(func() {
+			lock(&mheap_.lock)
+			ci, found = mheap_.pages.nextBackedChunk(ci)
+			unlock(&mheap_.lock)
+		})
+		if !found {
+			break
+		}
+		sysHugePageCollapse(unsafe.Pointer(chunkBase(ci)), pallocChunkBytes)
+		ci++
+	}
+}
+
+// nextBackedChunk returns the index of the first chunk at or after ci
+// that is in use and has no released pages.
+//
+// p.mheapLock must be held.
+func (p *pageAlloc) nextBackedChunk(ci chunkIdx) (chunkIdx, bool) {
+	assertLockHeld(p.mheapLock)
+
+	for _, r := range p.inUse.ranges {
+		for c := max(ci, chunkIndex(r.base.addr())); c <= chunkIndex(r.limit.addr()-1); c++ {
+			if p.chunkOf(c).scavenged.popcntRange(0, pallocChunkPages) == 0 {
+				return c, true
+			}
+		}
+	}
+	return 0, false
+}
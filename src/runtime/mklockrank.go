@@ -41,7 +41,7 @@ const ranks = `
 # Sysmon
 NONE
 < sysmon
-< scavenge, forcegc;
+< scavenge, forcegc, maxProcs;
 
 # Defer
 NONE < defer;
@@ -57,13 +57,17 @@ NONE < testR, testW;
 
 NONE < timerSend;
 
+NONE < goroutineLimit;
+
 # Scheduler, timers, netpoll
 NONE < allocmW, execW, cpuprof, pollCache, pollDesc, wakeableSleep;
 scavenge, sweep, testR, wakeableSleep, timerSend < hchan;
 assistQueue,
   cpuprof,
   forcegc,
+  goroutineLimit,
   hchan,
+  maxProcs,
   pollDesc, # pollDesc can interact with timers, which can lock sched.
   scavenge,
   sweep,
@@ -96,6 +100,12 @@ NONE
 # User arena state
 NONE < userArenaState;
 
+# Memory pressure watching
+NONE < memPressure;
+
+# The io_uring network poller. sysmon polls the network.
+sysmon < netpollUring;
+
 # Tracing without a P uses a global trace buffer.
 scavenge
 # Above TRACEGLOBAL can emit a trace event without a P.
@@ -114,6 +124,7 @@ allg,
   execR, # May grow stack
   execW, # May allocate after BeforeFork
   hchan,
+  memPressure,
   notifyList,
   reflectOffs,
   timer,
@@ -123,6 +134,7 @@ allg,
 < MALLOC
 # Below MALLOC is the malloc implementation.
 < fin,
+  allocSampler,
   spanSetSpine,
   mspanSpecial,
   traceTypeTab,
@@ -137,7 +149,8 @@ MPROF < profInsert, profBlock, profMemActive;
 profMemActive < profMemFuture;
 
 # Stack allocation and copying
-gcBitsArenas,
+allocSampler,
+  gcBitsArenas,
   netpollInit,
   profBlock,
   profInsert,
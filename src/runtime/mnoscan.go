@@ -0,0 +1,52 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// No-scan regions for package runtime/mem.
+//
+// A no-scan region is memory mapped directly from the OS, outside the
+// heap arenas. The garbage collector never sees it: pointers into it
+// are not heap pointers, so marking ignores them, and it's not in any
+// span, so the scavenger never returns it to the OS. It's accounted as
+// other_sys, so it counts toward the memory limit but not the heap goal.
+//
+// A region can also be mapped from a file. The operating system can
+// write its pages back to the file and drop them at any time, so file
+// regions are not accounted as memory mapped by the runtime, and only
+// count toward mappedFileBytes.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	"unsafe"
+)
+
+// mappedFileBytes is the memory in file regions mapped by runtime/mem.
+var mappedFileBytes atomic.Uint64
+
+//go:linkname mem_runtime_allocNoScan runtime/mem.runtime_allocNoScan
+func mem_runtime_allocNoScan(n uintptr) unsafe.Pointer {
+	n = alignUp(n, physPageSize)
+	var p unsafe.Pointer
+	systemstack(func() {
+		p = sysAlloc(n, &memstats.other_sys)
+		if p == nil {
+			// Undo sysAlloc's accounting.
+			memstats.other_sys.add(-int64(n))
+			gcController.mappedReady.Add(-int64(n))
+		}
+	})
+	return p
+}
+
+//go:linkname mem_runtime_freeNoScan runtime/mem.runtime_freeNoScan
+func mem_runtime_freeNoScan(p unsafe.Pointer, n uintptr) {
+	if uintptr(p)&(physPageSize-1) != 0 {
+		throw("runtime/mem: freeing memory that wasn't allocated by runtime/mem")
+	}
+	n = alignUp(n, physPageSize)
+	systemstack(func() {
+		sysFree(p, n, &memstats.other_sys)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package runtime
+
+import "unsafe"
+
+// mapFileShared is MAP_SHARED, which is the same on every Unix-like
+// system.
+const mapFileShared = 0x1
+
+//go:linkname mem_runtime_mapFile runtime/mem.runtime_mapFile
+func mem_runtime_mapFile(fd uintptr, n uintptr, writable, shared bool) (unsafe.Pointer, int) {
+	n = alignUp(n, physPageSize)
+	prot := int32(_PROT_READ)
+	if writable {
+		prot |= _PROT_WRITE
+	}
+	flags := int32(_MAP_PRIVATE)
+	if shared {
+		flags = mapFileShared
+	}
+	var p unsafe.Pointer
+	var err int
+	systemstack(func() {
+		p, err = mmap(nil, n, prot, flags, int32(fd), 0)
+	})
+	if err != 0 {
+		return nil, err
+	}
+	mappedFileBytes.Add(int64(n))
+	return p, 0
+}
+
+//go:linkname mem_runtime_unmapFile runtime/mem.runtime_unmapFile
+func mem_runtime_unmapFile(p unsafe.Pointer, n uintptr) {
+	if uintptr(p)&(physPageSize-1) != 0 {
+		throw("runtime/mem: unmapping memory that wasn't mapped by runtime/mem")
+	}
+	n = alignUp(n, physPageSize)
+	systemstack(func() {
+		munmap(p, n)
+	})
+	mappedFileBytes.Add(-int64(n))
+}
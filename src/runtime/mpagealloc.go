@@ -216,14 +216,14 @@ type pageAlloc struct {
 	//
 	// heapAddrBits | L1 Bits | L2 Bits | L2 Entry Size
 	// ------------------------------------------------
-	// 32           | 0       | 10      | 128 KiB
-	// 33 (iOS)     | 0       | 11      | 256 KiB
-	// 48           | 13      | 13      | 1 MiB
+	// 32           | 0       | 10      | 152 KiB
+	// 33 (iOS)     | 0       | 11      | 304 KiB
+	// 48           | 13      | 13      | 1216 KiB
 	//
 	// There's no reason to use the L1 part of chunks on 32-bit, the
 	// address space is small so the L2 is small. For platforms with a
-	// 48-bit address space, we pick the L1 such that the L2 is 1 MiB
-	// in size, which is a good balance between low granularity without
+	// 48-bit address space, we pick the L1 such that the L2 is about
+	// 1 MiB in size, which is a good balance between low granularity without
 	// making the impact on BSS too high (note the L1 is stored directly
 	// in pageAlloc).
 	//
@@ -938,6 +938,12 @@ func (p *pageAlloc) free(base, npages uintptr) {
 		p.searchAddr = b
 	}
 	limit := base + npages*pageSize - 1
+	if tier := scavengeRetainTier(npages); scavenge.retain[tier].Load() > 0 {
+		now := nanotime()
+		for c := chunkIndex(base); c <= chunkIndex(limit); c++ {
+			p.chunkOf(c).freed[tier] = now
+		}
+	}
 	if npages == 1 {
 		// Fast path: we're clearing a single bit, and we know exactly
 		// where it is, so mark it directly.
@@ -419,6 +419,22 @@ func findBitRange64(c uint64, n uint) uint {
 type pallocData struct {
 	pallocBits
 	scavenged pageBits
+
+	// freed is the nanotime when pages of each size tier were last
+	// freed into the chunk, if they were to be retained. See
+	// scavenge.retain.
+	freed [scavengeRetainTiers]int64
+}
+
+// retained reports whether the background scavenger should leave the
+// free pages of the chunk alone at time now.
+func (m *pallocData) retained(now int64) bool {
+	for tier, t := range m.freed {
+		if t != 0 && now-t < scavenge.retain[tier].Load() {
+			return true
+		}
+	}
+	return false
 }
 
 // allocRange sets bits [i, i+n) in the bitmap to 1 and
@@ -37,6 +37,7 @@ const (
 	memProfile bucketType = 1 + iota
 	blockProfile
 	mutexProfile
+	offCPUProfile
 
 	// size of bucket hash table
 	buckHashSize = 179999
@@ -63,7 +64,8 @@ type bucketType int
 // The representation is a bit sleazy, inherited from C.
 // This struct defines the bucket header. It is followed in
 // memory by the stack words and then the actual record
-// data, either a memRecord or a blockRecord.
+// data, either a memRecord or a blockRecord, and then by
+// the name of the allocated type, if any.
 //
 // Per-call-stack profiling information.
 // Lookup by hashing call stack into a linked-list hash table.
@@ -80,6 +82,7 @@ type bucket struct {
 	hash    uintptr
 	size    uintptr
 	nstk    uintptr
+	ntyp    uintptr // length of the allocated type's name, for memProfile
 }
 
 // A memRecord is the bucket data for a bucket of type memProfile,
@@ -148,6 +151,7 @@ type memRecord struct {
 type memRecordCycle struct {
 	allocs, frees           uintptr
 	alloc_bytes, free_bytes uintptr
+	free_lifetime           uint64 // total lifetime of the freed objects, in nanoseconds
 }
 
 // add accumulates b into a. It does not zero b.
@@ -156,6 +160,7 @@ func (a *memRecordCycle) add(b *memRecordCycle) {
 	a.frees += b.frees
 	a.alloc_bytes += b.alloc_bytes
 	a.free_bytes += b.free_bytes
+	a.free_lifetime += b.free_lifetime
 }
 
 // A blockRecord is the bucket data for a bucket of type blockProfile,
@@ -169,6 +174,7 @@ var (
 	mbuckets atomic.UnsafePointer // *bucket, memory profile buckets
 	bbuckets atomic.UnsafePointer // *bucket, blocking profile buckets
 	xbuckets atomic.UnsafePointer // *bucket, mutex profile buckets
+	obuckets atomic.UnsafePointer // *bucket, off-CPU profile buckets
 	buckhash atomic.UnsafePointer // *buckhashArray
 
 	mProfCycle mProfCycleHolder
@@ -224,21 +230,24 @@ func (c *mProfCycleHolder) increment() {
 	}
 }
 
-// newBucket allocates a bucket with the given type and number of stack entries.
-func newBucket(typ bucketType, nstk int) *bucket {
+// newBucket allocates a bucket with the given type, number of stack entries
+// and length of the allocated type's name.
+func newBucket(typ bucketType, nstk, ntyp int) *bucket {
 	size := unsafe.Sizeof(bucket{}) + uintptr(nstk)*unsafe.Sizeof(uintptr(0))
 	switch typ {
 	default:
 		throw("invalid profile bucket type")
 	case memProfile:
 		size += unsafe.Sizeof(memRecord{})
-	case blockProfile, mutexProfile:
+	case blockProfile, mutexProfile, offCPUProfile:
 		size += unsafe.Sizeof(blockRecord{})
 	}
+	size += uintptr(ntyp)
 
 	b := (*bucket)(persistentalloc(size, 0, &memstats.buckhash_sys))
 	b.typ = typ
 	b.nstk = uintptr(nstk)
+	b.ntyp = uintptr(ntyp)
 	return b
 }
 
@@ -264,15 +273,35 @@ func (b *bucket) mp() *memRecord {
 
 // bp returns the blockRecord associated with the blockProfile bucket b.
 func (b *bucket) bp() *blockRecord {
-	if b.typ != blockProfile && b.typ != mutexProfile {
+	if b.typ != blockProfile && b.typ != mutexProfile && b.typ != offCPUProfile {
 		throw("bad use of bucket.bp")
 	}
 	data := add(unsafe.Pointer(b), unsafe.Sizeof(*b)+b.nstk*unsafe.Sizeof(uintptr(0)))
 	return (*blockRecord)(data)
 }
 
-// Return the bucket for stk[0:nstk], allocating new bucket if needed.
-func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket {
+// typeName returns the name of the type allocated at the memProfile
+// bucket b, or "" if b has no type. The name is copied into the bucket
+// rather than referring to the type, which may be in the heap.
+func (b *bucket) typeName() (s string) {
+	if b.ntyp == 0 {
+		return ""
+	}
+	ss := stringStructOf(&s)
+	ss.str = b.typeNameData()
+	ss.len = int(b.ntyp)
+	return s
+}
+
+// typeNameData returns a pointer to the name of the type allocated at the
+// memProfile bucket b.
+func (b *bucket) typeNameData() unsafe.Pointer {
+	return add(unsafe.Pointer(b.mp()), unsafe.Sizeof(memRecord{}))
+}
+
+// Return the bucket for stk[0:nstk] and the allocated type named tname,
+// allocating new bucket if needed.
+func stkbucket(typ bucketType, size uintptr, tname string, stk []uintptr, alloc bool) *bucket {
 	bh := (*buckhashArray)(buckhash.Load())
 	if bh == nil {
 		lock(&profInsertLock)
@@ -299,6 +328,12 @@ func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket
 	h += size
 	h += h << 10
 	h ^= h >> 6
+	// hash in type name
+	for i := 0; i < len(tname); i++ {
+		h += uintptr(tname[i])
+		h += h << 10
+		h ^= h >> 6
+	}
 	// finalize
 	h += h << 3
 	h ^= h >> 11
@@ -306,7 +341,7 @@ func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket
 	i := int(h % buckHashSize)
 	// first check optimistically, without the lock
 	for b := (*bucket)(bh[i].Load()); b != nil; b = b.next {
-		if b.typ == typ && b.hash == h && b.size == size && eqslice(b.stk(), stk) {
+		if b.typ == typ && b.hash == h && b.size == size && b.typeName() == tname && eqslice(b.stk(), stk) {
 			return b
 		}
 	}
@@ -318,23 +353,28 @@ func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket
 	lock(&profInsertLock)
 	// check again under the insertion lock
 	for b := (*bucket)(bh[i].Load()); b != nil; b = b.next {
-		if b.typ == typ && b.hash == h && b.size == size && eqslice(b.stk(), stk) {
+		if b.typ == typ && b.hash == h && b.size == size && b.typeName() == tname && eqslice(b.stk(), stk) {
 			unlock(&profInsertLock)
 			return b
 		}
 	}
 
 	// Create new bucket.
-	b := newBucket(typ, len(stk))
+	b := newBucket(typ, len(stk), len(tname))
 	copy(b.stk(), stk)
 	b.hash = h
 	b.size = size
+	if len(tname) > 0 {
+		memmove(b.typeNameData(), unsafe.Pointer(unsafe.StringData(tname)), uintptr(len(tname)))
+	}
 
 	var allnext *atomic.UnsafePointer
 	if typ == memProfile {
 		allnext = &mbuckets
 	} else if typ == mutexProfile {
 		allnext = &xbuckets
+	} else if typ == offCPUProfile {
+		allnext = &obuckets
 	} else {
 		allnext = &bbuckets
 	}
@@ -433,8 +473,9 @@ func mProf_PostSweep() {
 	unlock(&profMemActiveLock)
 }
 
-// Called by malloc to record a profiled block.
-func mProf_Malloc(mp *m, p unsafe.Pointer, size uintptr) {
+// Called by malloc to record a profiled block. typ is the type of
+// the allocated object, or nil.
+func mProf_Malloc(mp *m, p unsafe.Pointer, size uintptr, typ *_type) {
 	if mp.profStack == nil {
 		// mp.profStack is nil if we happen to sample an allocation during the
 		// initialization of mp. This case is rare, so we just ignore such
@@ -447,7 +488,11 @@ func mProf_Malloc(mp *m, p unsafe.Pointer, size uintptr) {
 	nstk := callers(4, mp.profStack[:debug.profstackdepth])
 	index := (mProfCycle.read() + 2) % uint32(len(memRecord{}.future))
 
-	b := stkbucket(memProfile, size, mp.profStack[:nstk], true)
+	var tname string
+	if typ != nil && debug.memprofiletype.Load() != 0 {
+		tname = toRType(typ).string()
+	}
+	b := stkbucket(memProfile, size, tname, mp.profStack[:nstk], true)
 	mr := b.mp()
 	mpc := &mr.future[index]
 
@@ -466,7 +511,11 @@ func mProf_Malloc(mp *m, p unsafe.Pointer, size uintptr) {
 }
 
 // Called when freeing a profiled block.
-func mProf_Free(b *bucket, size uintptr) {
+//
+// lifetime is the time since the object was allocated. Since objects
+// are freed when they are swept, it overestimates the time until the
+// object became unreachable by up to a GC cycle.
+func mProf_Free(b *bucket, size uintptr, lifetime int64) {
 	index := (mProfCycle.read() + 1) % uint32(len(memRecord{}.future))
 
 	mp := b.mp()
@@ -475,6 +524,7 @@ func mProf_Free(b *bucket, size uintptr) {
 	lock(&profMemFutureLock[index])
 	mpc.frees++
 	mpc.free_bytes += size
+	mpc.free_lifetime += uint64(lifetime)
 	unlock(&profMemFutureLock[index])
 }
 
@@ -514,6 +564,48 @@ func blockevent(cycles int64, skip int) {
 	}
 }
 
+var offcpuprofilerate uint64 // in CPU ticks
+
+// SetOffCPUProfileRate controls the fraction of the time goroutines
+// spend off CPU that is reported in the off-CPU profile. A goroutine is
+// off CPU while it's blocked for any reason, such as on a channel, a
+// lock, a sleep or network I/O, or while it's in a system call. The
+// profiler aims to sample an average of one event per rate nanoseconds
+// spent off CPU.
+//
+// To include every event in the profile, pass rate = 1.
+// To turn off profiling entirely, pass rate <= 0.
+func SetOffCPUProfileRate(rate int) {
+	var r int64
+	if rate <= 0 {
+		r = 0 // disable profiling
+	} else if rate == 1 {
+		r = 1 // profile everything
+	} else {
+		// convert ns to cycles, use float64 to prevent overflow during multiplication
+		r = int64(float64(rate) * float64(ticksPerSecond()) / (1000 * 1000 * 1000))
+		if r == 0 {
+			r = 1
+		}
+	}
+
+	atomic.Store64(&offcpuprofilerate, uint64(r))
+}
+
+// offcpuevent records, in the off-CPU profile, that the current
+// goroutine was off CPU for the given number of cycles. The runtime's
+// own goroutines aren't profiled.
+func offcpuevent(cycles int64, skip int) {
+	if cycles <= 0 {
+		cycles = 1
+	}
+
+	rate := int64(atomic.Load64(&offcpuprofilerate))
+	if blocksampled(cycles, rate) && !isSystemGoroutine(getg(), false) {
+		saveblockevent(cycles, rate, skip+1, offCPUProfile)
+	}
+}
+
 // blocksampled returns true for all events where cycles >= rate. Shorter
 // events have a cycles/rate random chance of returning true.
 func blocksampled(cycles, rate int64) bool {
@@ -857,7 +949,7 @@ func (prof *mLockProfile) store() {
 }
 
 func saveBlockEventStack(cycles, rate int64, stk []uintptr, which bucketType) {
-	b := stkbucket(which, 0, stk, true)
+	b := stkbucket(which, 0, "", stk, true)
 	bp := b.bp()
 
 	lock(&profBlockLock)
@@ -866,7 +958,7 @@ func saveBlockEventStack(cycles, rate int64, stk []uintptr, which bucketType) {
 	// the sample probability is 1 if cycles >= rate, and cycles / rate
 	// otherwise. For mutex profile events, the sample probability is 1 / rate.
 	// We scale the events by 1 / (probability the event was sampled).
-	if which == blockProfile && cycles < rate {
+	if (which == blockProfile || which == offCPUProfile) && cycles < rate {
 		// Remove sampling bias, see discussion on http://golang.org/cl/299991.
 		bp.count += float64(rate) / float64(cycles)
 		bp.cycles += rate
@@ -955,6 +1047,14 @@ type MemProfileRecord struct {
 	AllocBytes, FreeBytes     int64       // number of bytes allocated, freed
 	AllocObjects, FreeObjects int64       // number of objects allocated, freed
 	Stack0                    [32]uintptr // stack trace for this record; ends at first 0 entry
+
+	// Type is the type of the objects allocated by the call
+	// sequence, or of their elements, if GODEBUG=memprofiletype=1
+	// was set when they were allocated and they have a type.
+	// Otherwise it is empty. With memprofiletype=1, allocations
+	// of different types by the same call sequence have separate
+	// records.
+	Type string
 }
 
 // InUseBytes returns the number of bytes in use (AllocBytes - FreeBytes).
@@ -1064,6 +1164,8 @@ func memProfileInternal(size int, inuseZero bool, copyFn func(profilerecord.MemP
 					FreeBytes:    int64(mp.active.free_bytes),
 					AllocObjects: int64(mp.active.allocs),
 					FreeObjects:  int64(mp.active.frees),
+					FreeLifetime: int64(mp.active.free_lifetime),
+					Type:         b.typeName(),
 					Stack:        b.stk(),
 				}
 				copyFn(r)
@@ -1079,6 +1181,7 @@ func copyMemProfileRecord(dst *MemProfileRecord, src profilerecord.MemProfileRec
 	dst.FreeBytes = src.FreeBytes
 	dst.AllocObjects = src.AllocObjects
 	dst.FreeObjects = src.FreeObjects
+	dst.Type = src.Type
 	if raceenabled {
 		racewriterangepc(unsafe.Pointer(&dst.Stack0[0]), unsafe.Sizeof(dst.Stack0), sys.GetCallerPC(), abi.FuncPCABIInternal(MemProfile))
 	}
@@ -1268,6 +1371,42 @@ func pprof_mutexProfileInternal(p []profilerecord.BlockProfileRecord) (n int, ok
 	})
 }
 
+// offCPUProfileInternal returns the number of records n in the profile.
+// If there are less than size records, copyFn is invoked for each
+// record, and ok returns true.
+func offCPUProfileInternal(size int, copyFn func(profilerecord.BlockProfileRecord)) (n int, ok bool) {
+	lock(&profBlockLock)
+	head := (*bucket)(obuckets.Load())
+	for b := head; b != nil; b = b.allnext {
+		n++
+	}
+	if n <= size {
+		ok = true
+		for b := head; b != nil; b = b.allnext {
+			bp := b.bp()
+			r := profilerecord.BlockProfileRecord{
+				Count:  int64(bp.count),
+				Cycles: bp.cycles,
+				Stack:  b.stk(),
+			}
+			if r.Count == 0 {
+				r.Count = 1
+			}
+			copyFn(r)
+		}
+	}
+	unlock(&profBlockLock)
+	return
+}
+
+//go:linkname pprof_offCPUProfileInternal
+func pprof_offCPUProfileInternal(p []profilerecord.BlockProfileRecord) (n int, ok bool) {
+	return offCPUProfileInternal(len(p), func(r profilerecord.BlockProfileRecord) {
+		p[0] = r
+		p = p[1:]
+	})
+}
+
 // ThreadCreateProfile returns n, the number of records in the thread creation profile.
 // If len(p) >= n, ThreadCreateProfile copies the profile into p and returns n, true.
 // If len(p) < n, ThreadCreateProfile does not change p and returns n, false.
@@ -1307,6 +1446,11 @@ func pprof_threadCreateInternal(p []profilerecord.StackRecord) (n int, ok bool)
 	})
 }
 
+//go:linkname pprof_goroutineAllocBytes
+func pprof_goroutineAllocBytes() bool {
+	return debug.goroutineallocbytes != 0
+}
+
 //go:linkname pprof_goroutineProfileWithLabels
 func pprof_goroutineProfileWithLabels(p []profilerecord.StackRecord, labels []unsafe.Pointer) (n int, ok bool) {
 	return goroutineProfileWithLabels(p, labels)
@@ -1684,6 +1828,7 @@ func saveg(pc, sp uintptr, gp *g, r *profilerecord.StackRecord, pcbuf []uintptr)
 	n := tracebackPCs(&u, 0, pcbuf)
 	r.Stack = make([]uintptr, n)
 	copy(r.Stack, pcbuf)
+	r.AllocBytes = gp.allocBytes
 }
 
 // Stack formats a stack trace of the calling goroutine into buf
@@ -0,0 +1,179 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Heap quarantine.
+//
+// With GODEBUG=quarantine=N, the sweeper doesn't free small objects
+// as soon as it finds them unreachable. Instead it fills them with
+// quarantinePoison, keeps them allocated by setting their mark bit,
+// and attaches a quarantine special recording the GC cycle. The
+// object is freed by the first sweep at least N cycles later. Every
+// sweep in between checks that the poison is intact, and throws if
+// the object was written to, printing the stack that allocated it.
+//
+// Go has no explicit free, so a write to a quarantined object is a
+// write through a pointer the garbage collector couldn't see, such as
+// one hidden in a uintptr or held by C code. Reads of such pointers
+// return the poison, which isn't a valid heap address.
+//
+// Allocation stacks come from the memory profiler, so the quarantine
+// sets MemProfileRate to 1.
+//
+// Objects with finalizers, cleanups, weak handles or ephemerons are
+// freed as usual, as are large objects and objects in user arenas.
+
+package runtime
+
+import (
+	"internal/runtime/sys"
+	"unsafe"
+)
+
+// quarantinePoison is the byte quarantined objects are filled with.
+const quarantinePoison = 0xd1
+
+// specialQuarantine marks an object as quarantined.
+type specialQuarantine struct {
+	_       sys.NotInHeap
+	special special
+	cycle   uint32 // work.cycles when the object was quarantined
+}
+
+// quarantineBounds returns the part of the object at x in span s that
+// is poisoned, which excludes the malloc header.
+func quarantineBounds(s *mspan, x uintptr) (start, end uintptr) {
+	start, end = x, x+s.elemsize
+	if !s.spanclass.noscan() && !heapBitsInSpan(s.elemsize) {
+		start += mallocHeaderSize
+	}
+	return start, end
+}
+
+// sweepQuarantine quarantines the newly unreachable objects of s and
+// releases those whose quarantine has ended. It must be called by the
+// sweeper before it frees the unmarked objects of s, which must be a
+// span of small objects.
+func (s *mspan) sweepQuarantine() {
+	size := s.elemsize
+	cycle := work.cycles.Load()
+	added := false
+
+	// The specials are sorted by offset, so walk them alongside the
+	// objects.
+	next := &s.specials
+	mbits := s.markBitsForBase()
+	abits := s.allocBitsForIndex(0)
+	for i := uintptr(0); i < uintptr(s.nelems); i++ {
+		off := i * size
+		for *next != nil && uintptr((*next).offset) < off {
+			next = &(*next).next
+		}
+
+		// Look at the specials of this object. Tiny blocks may have
+		// specials at inner offsets.
+		var (
+			quarantined **special
+			prof        *specialprofile
+			insert      = next
+			other       bool
+		)
+		for sp := next; *sp != nil && uintptr((*sp).offset) < off+size; sp = &(*sp).next {
+			switch (*sp).kind {
+			case _KindSpecialQuarantine:
+				quarantined = sp
+			case _KindSpecialProfile:
+				if prof == nil {
+					prof = (*specialprofile)(unsafe.Pointer(*sp))
+				}
+			default:
+				other = true
+			}
+			if uintptr((*sp).offset) == off {
+				insert = &(*sp).next
+			}
+		}
+
+		x := s.base() + off
+		marked := mbits.isMarked()
+		switch {
+		case quarantined != nil:
+			q := (*specialQuarantine)(unsafe.Pointer(*quarantined))
+			quarantineCheck(s, x, q, prof)
+			// A quarantined object may be marked if a stack
+			// conservatively points to it. Keep it until it isn't.
+			if !marked && cycle-q.cycle >= uint32(debug.quarantine) {
+				// Let the sweeper free it.
+				*quarantined = q.special.next
+				lock(&mheap_.speciallock)
+				mheap_.specialQuarantineAlloc.free(unsafe.Pointer(q))
+				unlock(&mheap_.speciallock)
+			} else {
+				mbits.setMarkedNonAtomic()
+			}
+		case !marked && !other && (abits.index < uintptr(s.freeindex) || abits.isMarked()):
+			lock(&mheap_.speciallock)
+			q := (*specialQuarantine)(mheap_.specialQuarantineAlloc.alloc())
+			unlock(&mheap_.speciallock)
+			q.special.kind = _KindSpecialQuarantine
+			q.special.offset = uint16(off)
+			q.special.next = *insert
+			q.cycle = cycle
+			*insert = &q.special
+			added = true
+
+			start, end := quarantineBounds(s, x)
+			for p := start; p < end; p++ {
+				*(*uint8)(unsafe.Pointer(p)) = quarantinePoison
+			}
+			mbits.setMarkedNonAtomic()
+		}
+		mbits.advance()
+		abits.advance()
+	}
+	if added {
+		spanHasSpecials(s)
+	}
+}
+
+// quarantineCheck throws if the quarantined object at x in span s,
+// whose allocation was recorded by prof, was written to.
+func quarantineCheck(s *mspan, x uintptr, q *specialQuarantine, prof *specialprofile) {
+	start, end := quarantineBounds(s, x)
+	for p := start; p < end; p++ {
+		if *(*uint8)(unsafe.Pointer(p)) == quarantinePoison {
+			continue
+		}
+		print("runtime: object at ", hex(x), " of size ", s.elemsize, " was written at offset ", p-x,
+			" after it became unreachable in GC cycle ", q.cycle, "\n")
+		if prof != nil {
+			print("object allocated at:\n")
+			printQuarantineStack(prof.b.stk())
+		} else {
+			print("object allocated before memory profiling was enabled\n")
+		}
+		throw("write to freed object")
+	}
+}
+
+// printQuarantineStack prints the allocation stack stk recorded by the
+// memory profiler, without allocating.
+func printQuarantineStack(stk []uintptr) {
+	for _, pc := range stk {
+		f := findfunc(pc)
+		if !f.valid() {
+			print("non-Go function at pc=", hex(pc), "\n")
+			continue
+		}
+		// pc is a return address. Look up the call instruction.
+		tracepc := pc
+		if pc > f.entry() {
+			tracepc--
+		}
+		u, uf := newInlineUnwinder(f, tracepc)
+		file, line := u.fileLine(uf)
+		printFuncName(u.srcFunc(uf).name())
+		print("(...)\n")
+		print("\t", file, ":", line, "\n")
+	}
+}
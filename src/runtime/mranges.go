@@ -73,8 +73,8 @@ func (a addrRange) subtract(b addrRange) addrRange {
 // takeFromFront takes len bytes from the front of the address range, aligning
 // the base to align first. On success, returns the aligned start of the region
 // taken and true.
-func (a *addrRange) takeFromFront(len uintptr, align uint8) (uintptr, bool) {
-	base := alignUp(a.base.addr(), uintptr(align)) + len
+func (a *addrRange) takeFromFront(len uintptr, align uintptr) (uintptr, bool) {
+	base := alignUp(a.base.addr(), align) + len
 	if base > a.limit.addr() {
 		return 0, false
 	}
@@ -85,8 +85,8 @@ func (a *addrRange) takeFromFront(len uintptr, align uint8) (uintptr, bool) {
 // takeFromBack takes len bytes from the end of the address range, aligning
 // the limit to align after subtracting len. On success, returns the aligned
 // start of the region taken and true.
-func (a *addrRange) takeFromBack(len uintptr, align uint8) (uintptr, bool) {
-	limit := alignDown(a.limit.addr()-len, uintptr(align))
+func (a *addrRange) takeFromBack(len uintptr, align uintptr) (uintptr, bool) {
+	limit := alignDown(a.limit.addr()-len, align)
 	if a.base.addr() > limit {
 		return 0, false
 	}
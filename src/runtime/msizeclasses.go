@@ -0,0 +1,172 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Alternative size-class tables.
+//
+// The size classes in sizeclasses.go are chosen by mksizeclasses.go to
+// bound both the memory wasted by rounding objects up to their size
+// class and the memory wasted at the end of spans, while keeping the
+// number of classes small. Some programs do better with a different
+// trade-off, so GODEBUG=sizeclasses=... replaces the tables at
+// startup, before the heap is initialized:
+//
+//	fine  Every multiple of 8 bytes up to 256 bytes is a size class.
+//	      To keep the number of classes the same, the classes above
+//	      256 bytes whose removal wastes the least memory are merged
+//	      into the next larger class.
+//	pow2  Objects are only allocated in the power-of-two size classes.
+//	      The other classes remain but are never used.
+//
+// The number of size classes is a constant, so the alternative tables
+// have as many classes as the default ones. They are checked before
+// they are used.
+
+package runtime
+
+import "internal/stringslite"
+
+// sizeClassesFineMax is the largest size class of the fine tables
+// that is a multiple of 8 bytes.
+const sizeClassesFineMax = 256
+
+// sizeClassesInit replaces the size-class tables according to the
+// sizeclasses setting in godebug. It must be called before mallocinit
+// and must not allocate.
+func sizeClassesInit(godebug string) {
+	var mode string
+	for p := godebug; p != ""; {
+		var field string
+		field, p, _ = stringslite.Cut(p, ",")
+		if v, ok := stringslite.CutPrefix(field, "sizeclasses="); ok {
+			mode = v
+		}
+	}
+	switch mode {
+	case "", "default":
+		return
+	case "fine":
+		sizeClassesFine()
+		sizeClassesLookup(false)
+	case "pow2":
+		sizeClassesLookup(true)
+	default:
+		print("runtime: unknown GODEBUG setting sizeclasses=", mode, "; using the default size classes\n")
+		return
+	}
+	sizeClassesCheck()
+}
+
+// sizeClassesFine replaces class_to_size, class_to_allocnpages and
+// class_to_divmagic with the fine size classes.
+func sizeClassesFine() {
+	var size [_NumSizeClasses + sizeClassesFineMax/smallSizeDiv]uint16
+	var npages [len(size)]uint8
+
+	// Start with every multiple of 8 up to sizeClassesFineMax, then
+	// the larger default classes. The added classes use one page.
+	n, c := 1, 1
+	for s := uint16(smallSizeDiv); s <= sizeClassesFineMax; s += smallSizeDiv {
+		size[n], npages[n] = s, 1
+		if class_to_size[c] == s {
+			npages[n] = class_to_allocnpages[c]
+			c++
+		}
+		n++
+	}
+	for ; c < _NumSizeClasses; c++ {
+		size[n], npages[n] = class_to_size[c], class_to_allocnpages[c]
+		n++
+	}
+
+	// Remove larger classes until there are as many as before. Objects
+	// of a removed class are allocated in the next larger class, so
+	// remove the class whose smallest objects would waste the least
+	// of the next class. The largest class must stay, and so must
+	// minSizeForMallocHeader, which mallocinit checks.
+	for n > _NumSizeClasses {
+		best, bestWaste := 0, uintptr(0)
+		for i := 1; i < n-1; i++ {
+			s := uintptr(size[i])
+			if s <= sizeClassesFineMax || s == minSizeForMallocHeader {
+				continue
+			}
+			next := uintptr(size[i+1])
+			waste := (next - uintptr(size[i-1]) - smallSizeDiv) << 16 / next
+			if best == 0 || waste < bestWaste {
+				best, bestWaste = i, waste
+			}
+		}
+		copy(size[best:n], size[best+1:n])
+		copy(npages[best:n], npages[best+1:n])
+		n--
+	}
+
+	for c := 1; c < _NumSizeClasses; c++ {
+		class_to_size[c] = size[c]
+		class_to_allocnpages[c] = npages[c]
+		class_to_divmagic[c] = ^uint32(0)/uint32(size[c]) + 1
+	}
+}
+
+// sizeClassesLookup recomputes size_to_class8 and size_to_class128 from
+// class_to_size. If pow2 is set, only the power-of-two size classes are
+// used.
+func sizeClassesLookup(pow2 bool) {
+	lookup := func(s uintptr) uint8 {
+		for c := 1; c < _NumSizeClasses; c++ {
+			cs := uintptr(class_to_size[c])
+			if cs >= s && (!pow2 || cs&(cs-1) == 0) {
+				return uint8(c)
+			}
+		}
+		throw("no size class for small size")
+		return 0
+	}
+	for i := 1; i < len(size_to_class8); i++ {
+		size_to_class8[i] = lookup(uintptr(i) * smallSizeDiv)
+	}
+	for i := range size_to_class128 {
+		size_to_class128[i] = lookup(smallSizeMax + uintptr(i)*largeSizeDiv)
+	}
+}
+
+// sizeClassesCheck throws if the size-class tables are inconsistent.
+func sizeClassesCheck() {
+	if class_to_size[_NumSizeClasses-1] != _MaxSmallSize {
+		throw("largest size class is not maxSmallSize")
+	}
+	for c := 1; c < _NumSizeClasses; c++ {
+		size := uintptr(class_to_size[c])
+		npages := uintptr(class_to_allocnpages[c])
+		if size <= uintptr(class_to_size[c-1]) || size%smallSizeDiv != 0 || size > smallSizeMax && size%largeSizeDiv != 0 {
+			print("runtime: size class ", c, " has size ", size, "\n")
+			throw("bad size class")
+		}
+		if nelems := npages * pageSize / size; nelems == 0 || nelems > maxObjsPerSpan {
+			print("runtime: size class ", c, " has size ", size, " and ", npages, " pages\n")
+			throw("bad size class")
+		}
+		// mspan.objIndex divides offsets in the span by multiplying
+		// by the magic number.
+		divMul := uint64(class_to_divmagic[c])
+		for off := uintptr(0); off < npages*pageSize; off++ {
+			if uintptr((uint64(off)*divMul)>>32) != off/size {
+				print("runtime: size class ", c, " has size ", size, " and magic ", divMul, "\n")
+				throw("bad size class magic")
+			}
+		}
+	}
+	for s := uintptr(1); s <= _MaxSmallSize; s++ {
+		var c uint8
+		if s <= smallSizeMax-8 {
+			c = size_to_class8[divRoundUp(s, smallSizeDiv)]
+		} else {
+			c = size_to_class128[divRoundUp(s-smallSizeMax, largeSizeDiv)]
+		}
+		if c == 0 || uintptr(class_to_size[c]) < s {
+			print("runtime: size ", s, " has size class ", c, "\n")
+			throw("bad size class lookup table")
+		}
+	}
+}
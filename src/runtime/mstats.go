@@ -674,6 +674,11 @@ type heapStatsDelta struct {
 	inWorkBufs      int64 // byte delta of memory reserved for work bufs
 	inPtrScalarBits int64 // byte delta of memory reserved for unrolled GC prog bits
 
+	// User arena stats. Memory in user arena chunks is modeled as large
+	// objects, so these are a subset of the memory counted as objects.
+	inUserArena         int64 // byte delta of object memory in user arena chunks
+	inUserArenaReusable int64 // byte delta of that memory in chunks waiting for reuse
+
 	// Allocator stats.
 	//
 	// These are all uint64 because they're cumulative, and could quickly wrap
@@ -699,6 +704,8 @@ func (a *heapStatsDelta) merge(b *heapStatsDelta) {
 	a.inStacks += b.inStacks
 	a.inWorkBufs += b.inWorkBufs
 	a.inPtrScalarBits += b.inPtrScalarBits
+	a.inUserArena += b.inUserArena
+	a.inUserArenaReusable += b.inUserArenaReusable
 
 	a.tinyAllocCount += b.tinyAllocCount
 	a.largeAlloc += b.largeAlloc
@@ -911,6 +918,9 @@ type cpuStats struct {
 	GCPauseTime     int64 // GC pauses (all GOMAXPROCS, even if just 1 is running)
 	GCTotalTime     int64
 
+	GCSweepTermTime int64 // GC sweep termination pauses (subset of GCPauseTime)
+	GCMarkTermTime  int64 // GC mark termination pauses (subset of GCPauseTime)
+
 	ScavengeAssistTime int64 // background scavenger
 	ScavengeBgTime     int64 // scavenge assists
 	ScavengeTotalTime  int64
@@ -924,11 +934,16 @@ type cpuStats struct {
 // accumulateGCPauseTime add dt*stwProcs to the GC CPU pause time stats. dt should be
 // the actual time spent paused, for orthogonality. maxProcs should be GOMAXPROCS,
 // not work.stwprocs, since this number must be comparable to a total time computed
-// from GOMAXPROCS.
-func (s *cpuStats) accumulateGCPauseTime(dt int64, maxProcs int32) {
+// from GOMAXPROCS. reason is the reason the GC stopped the world.
+func (s *cpuStats) accumulateGCPauseTime(dt int64, maxProcs int32, reason stwReason) {
 	cpu := dt * int64(maxProcs)
 	s.GCPauseTime += cpu
 	s.GCTotalTime += cpu
+	if reason == stwGCSweepTerm {
+		s.GCSweepTermTime += cpu
+	} else {
+		s.GCMarkTermTime += cpu
+	}
 }
 
 // accumulate takes a cpuStats and adds in the current state of all GC CPU
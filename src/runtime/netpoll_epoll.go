@@ -19,6 +19,9 @@ var (
 )
 
 func netpollinit() {
+	if debug.netpolluring != 0 && uringinit() {
+		return
+	}
 	var errno uintptr
 	epfd, errno = syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
 	if errno != 0 {
@@ -43,10 +46,16 @@ func netpollinit() {
 }
 
 func netpollIsPollDescriptor(fd uintptr) bool {
+	if uring.enabled {
+		return fd == uintptr(uring.fd) || fd == netpollEventFd
+	}
 	return fd == uintptr(epfd) || fd == netpollEventFd
 }
 
 func netpollopen(fd uintptr, pd *pollDesc) uintptr {
+	if uring.enabled {
+		return uringopen(fd, pd)
+	}
 	var ev syscall.EpollEvent
 	ev.Events = syscall.EPOLLIN | syscall.EPOLLOUT | syscall.EPOLLRDHUP | syscall.EPOLLET
 	tp := taggedPointerPack(unsafe.Pointer(pd), pd.fdseq.Load())
@@ -55,6 +64,9 @@ func netpollopen(fd uintptr, pd *pollDesc) uintptr {
 }
 
 func netpollclose(fd uintptr) uintptr {
+	if uring.enabled {
+		return uringclose(fd)
+	}
 	var ev syscall.EpollEvent
 	return syscall.EpollCtl(epfd, syscall.EPOLL_CTL_DEL, int32(fd), &ev)
 }
@@ -63,7 +75,7 @@ func netpollarm(pd *pollDesc, mode int) {
 	throw("runtime: unused")
 }
 
-// netpollBreak interrupts an epollwait.
+// netpollBreak interrupts an epollwait, or a wait for the io_uring.
 func netpollBreak() {
 	// Failing to cas indicates there is an in-flight wakeup, so we're done here.
 	if !netpollWakeSig.CompareAndSwap(0, 1) {
@@ -94,6 +106,9 @@ func netpollBreak() {
 // delay == 0: does not block, just polls
 // delay > 0: block for up to that many nanoseconds
 func netpoll(delay int64) (gList, int32) {
+	if uring.enabled {
+		return uringnetpoll(delay)
+	}
 	if epfd == -1 {
 		return gList{}, 0
 	}
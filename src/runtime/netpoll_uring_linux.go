@@ -0,0 +1,500 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// io_uring network poller.
+//
+// With GODEBUG=netpolluring=1, the network poller uses an io_uring
+// instead of epoll. Each file descriptor has a multishot
+// IORING_OP_POLL_ADD request, which posts a completion each time the
+// descriptor's wait queue is woken with it ready, like an edge-triggered
+// epoll registration, with the descriptor's tagged pollDesc as its user
+// data. netpoll reaps the completions, after waiting for one in
+// io_uring_enter if it's asked to block. netpollBreak wakes it by
+// writing to netpollEventFd, which has a poll request of its own.
+//
+// The kernel may end a multishot request, for example when the
+// completion queue overflows. netpoll then arms a new one, and reports
+// the descriptor ready, in case it missed a wakeup in between.
+//
+// The ring needs Linux 5.19 or later, to cancel a descriptor's requests
+// by descriptor. If the ring can't be set up, the poller uses epoll.
+//
+// The ring is only used for readiness, so far. Reads and writes could
+// be submitted to it too, with registered buffers, saving the system
+// calls that do them.
+
+package runtime
+
+import (
+	"internal/goarch"
+	"internal/runtime/atomic"
+	"internal/runtime/syscall"
+	"unsafe"
+)
+
+const (
+	_IORING_SETUP_CQSIZE = 1 << 3
+
+	_IORING_FEAT_SINGLE_MMAP = 1 << 0
+	_IORING_FEAT_NODROP      = 1 << 1
+	_IORING_FEAT_EXT_ARG     = 1 << 8
+
+	_IORING_OFF_SQ_RING = 0
+	_IORING_OFF_SQES    = 0x10000000
+
+	_IORING_ENTER_GETEVENTS = 1 << 0
+	_IORING_ENTER_EXT_ARG   = 1 << 3
+
+	_IORING_SQ_CQ_OVERFLOW = 1 << 1
+
+	_IORING_OP_POLL_ADD     = 6
+	_IORING_OP_ASYNC_CANCEL = 14
+
+	_IORING_POLL_ADD_MULTI = 1 << 0
+
+	_IORING_ASYNC_CANCEL_ALL = 1 << 0
+	_IORING_ASYNC_CANCEL_FD  = 1 << 1
+
+	_IORING_CQE_F_MORE = 1 << 1
+
+	_MAP_SHARED = 0x1
+
+	// These errno values are the same on all Linux architectures.
+	_EPERM  = 0x1
+	_ENOENT = 0x2
+	_EBUSY  = 0x10
+	_EEXIST = 0x11
+	_ETIME  = 0x3e
+)
+
+// uringParams is struct io_uring_params.
+type uringParams struct {
+	sqEntries    uint32
+	cqEntries    uint32
+	flags        uint32
+	sqThreadCPU  uint32
+	sqThreadIdle uint32
+	features     uint32
+	wqFd         uint32
+	resv         [3]uint32
+	sqOff        uringSQRingOffsets
+	cqOff        uringCQRingOffsets
+}
+
+type uringSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+// uringSQE is struct io_uring_sqe.
+type uringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	opFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	addr3       uint64
+	_           uint64
+}
+
+// uringCQE is struct io_uring_cqe.
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// uringGeteventsArg is struct io_uring_getevents_arg.
+type uringGeteventsArg struct {
+	sigmask   uint64
+	sigmaskSz uint32
+	_         uint32
+	ts        uint64
+}
+
+// uringTimespec is struct __kernel_timespec, which is 64-bit on all
+// architectures.
+type uringTimespec struct {
+	sec  int64
+	nsec int64
+}
+
+// Size of the ring. Completions are more plentiful than submissions,
+// since every readiness wakeup of every descriptor posts one, and the
+// kernel holds on to the ones that don't fit, so the completion queue
+// is larger.
+const (
+	uringSQEntries = 256
+	uringCQEntries = 8192
+)
+
+var uring struct {
+	enabled bool  // the poller uses the ring; set by netpollinit
+	fd      int32 // the io_uring descriptor
+
+	// lock serializes submissions, and the reaping of completions.
+	lock mutex
+
+	sqHead, sqTail, sqFlags *uint32
+	sqMask                  uint32
+	sqArray                 unsafe.Pointer // [sqEntries]uint32
+	sqes                    unsafe.Pointer // [sqEntries]uringSQE
+
+	cqHead, cqTail *uint32
+	cqMask         uint32
+	cqes           unsafe.Pointer // [cqEntries]uringCQE
+
+	// registered has a bit for each descriptor with poll requests, so
+	// that registering one twice fails, like with epoll. It's allocated
+	// off the heap, and grows with the descriptors.
+	registered []uint64
+}
+
+// uringinit sets up the ring, and reports whether it did. It's called
+// by netpollinit.
+func uringinit() bool {
+	p := uringParams{flags: _IORING_SETUP_CQSIZE, cqEntries: uringCQEntries}
+	r, _, errno := syscall.Syscall6(syscall.SYS_IO_URING_SETUP, uringSQEntries, uintptr(unsafe.Pointer(&p)), 0, 0, 0, 0)
+	if errno != 0 {
+		return false
+	}
+	fd := int32(r)
+	const need = _IORING_FEAT_SINGLE_MMAP | _IORING_FEAT_NODROP | _IORING_FEAT_EXT_ARG
+	if p.features&need != need {
+		closefd(fd)
+		return false
+	}
+
+	ringSize := max(uintptr(p.sqOff.array)+uintptr(p.sqEntries)*4, uintptr(p.cqOff.cqes)+uintptr(p.cqEntries)*unsafe.Sizeof(uringCQE{}))
+	ring, err := mmap(nil, ringSize, _PROT_READ|_PROT_WRITE, _MAP_SHARED, fd, _IORING_OFF_SQ_RING)
+	if err != 0 {
+		closefd(fd)
+		return false
+	}
+	sqesSize := uintptr(p.sqEntries) * unsafe.Sizeof(uringSQE{})
+	sqes, err := mmap(nil, sqesSize, _PROT_READ|_PROT_WRITE, _MAP_SHARED, fd, _IORING_OFF_SQES)
+	if err != 0 {
+		munmap(ring, ringSize)
+		closefd(fd)
+		return false
+	}
+
+	uring.fd = fd
+	uring.sqHead = (*uint32)(add(ring, uintptr(p.sqOff.head)))
+	uring.sqTail = (*uint32)(add(ring, uintptr(p.sqOff.tail)))
+	uring.sqFlags = (*uint32)(add(ring, uintptr(p.sqOff.flags)))
+	uring.sqMask = *(*uint32)(add(ring, uintptr(p.sqOff.ringMask)))
+	uring.sqArray = add(ring, uintptr(p.sqOff.array))
+	uring.sqes = sqes
+	uring.cqHead = (*uint32)(add(ring, uintptr(p.cqOff.head)))
+	uring.cqTail = (*uint32)(add(ring, uintptr(p.cqOff.tail)))
+	uring.cqMask = *(*uint32)(add(ring, uintptr(p.cqOff.ringMask)))
+	uring.cqes = add(ring, uintptr(p.cqOff.cqes))
+	lockInit(&uring.lock, lockRankNetpollUring)
+
+	if !uringProbe() {
+		munmap(sqes, sqesSize)
+		munmap(ring, ringSize)
+		closefd(fd)
+		return false
+	}
+
+	efd, errno := syscall.Eventfd(0, syscall.EFD_CLOEXEC|syscall.EFD_NONBLOCK)
+	if errno != 0 {
+		println("runtime: eventfd failed with", -errno)
+		throw("runtime: eventfd failed")
+	}
+	netpollEventFd = uintptr(efd)
+	lock(&uring.lock)
+	uringPollAdd(efd, uint64(uintptr(unsafe.Pointer(&netpollEventFd))))
+	unlock(&uring.lock)
+	uring.enabled = true
+	return true
+}
+
+// uringProbe reports whether the kernel can cancel requests by file
+// descriptor. It cancels the requests for the ring's own descriptor, of
+// which there are none, and waits for the result: the number of
+// requests canceled, or -EINVAL from kernels that don't know the flags.
+func uringProbe() bool {
+	lock(&uring.lock)
+	uringSubmit(uringSQE{
+		opcode:  _IORING_OP_ASYNC_CANCEL,
+		fd:      uring.fd,
+		opFlags: _IORING_ASYNC_CANCEL_FD | _IORING_ASYNC_CANCEL_ALL,
+	})
+	unlock(&uring.lock)
+	for {
+		head := *uring.cqHead
+		if head != atomic.Load(uring.cqTail) {
+			res := uringCQEAt(head).res
+			atomic.Store(uring.cqHead, head+1)
+			return res >= 0
+		}
+		_, _, errno := syscall.Syscall6(syscall.SYS_IO_URING_ENTER, uintptr(uring.fd), 0, 1, _IORING_ENTER_GETEVENTS, 0, 0)
+		if errno != 0 && errno != _EINTR {
+			return false
+		}
+	}
+}
+
+func uringCQEAt(i uint32) *uringCQE {
+	return (*uringCQE)(add(uring.cqes, uintptr(i&uring.cqMask)*unsafe.Sizeof(uringCQE{})))
+}
+
+// uringSubmit submits sqe to the kernel. uring.lock must be held.
+func uringSubmit(sqe uringSQE) {
+	tail := *uring.sqTail
+	i := tail & uring.sqMask
+	*(*uringSQE)(add(uring.sqes, uintptr(i)*unsafe.Sizeof(uringSQE{}))) = sqe
+	*(*uint32)(add(uring.sqArray, uintptr(i)*4)) = i
+	atomic.Store(uring.sqTail, tail+1)
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_IO_URING_ENTER, uintptr(uring.fd), 1, 0, 0, 0, 0)
+		switch errno {
+		case 0:
+			return
+		case _EINTR:
+		case _EAGAIN, _EBUSY:
+			// The kernel is short of memory for the request, or
+			// of room for completions. Wait for netpoll to reap
+			// some.
+			osyield()
+		default:
+			println("runtime: io_uring_enter failed with", errno)
+			throw("runtime: netpoll submission failed")
+		}
+	}
+}
+
+// uringPollAdd submits a multishot poll request for fd, with user data
+// tag. uring.lock must be held.
+func uringPollAdd(fd int32, tag uint64) {
+	events := uint32(syscall.EPOLLIN | syscall.EPOLLOUT | syscall.EPOLLRDHUP)
+	if goarch.BigEndian {
+		// The kernel reads the 32-bit poll mask as two 16-bit
+		// halves, for compatibility with a former 16-bit field.
+		events = events<<16 | events>>16
+	}
+	uringSubmit(uringSQE{
+		opcode:   _IORING_OP_POLL_ADD,
+		fd:       fd,
+		len:      _IORING_POLL_ADD_MULTI,
+		opFlags:  events,
+		userData: tag,
+	})
+}
+
+func uringopen(fd uintptr, pd *pollDesc) uintptr {
+	tp := taggedPointerPack(unsafe.Pointer(pd), pd.fdseq.Load())
+	lock(&uring.lock)
+	if uringRegister(fd, true) {
+		unlock(&uring.lock)
+		return _EEXIST
+	}
+	uringPollAdd(int32(fd), uint64(tp))
+	// A poll request for a file that doesn't support polling, like a
+	// regular file or a directory, ends as soon as it's armed, before
+	// io_uring_enter returns, with the file reported ready. Rearming
+	// it would spin, so refuse such files with EPERM, as epoll_ctl
+	// does, which callers take to mean that the file is to be read and
+	// written with blocking system calls.
+	if uringRefused(uint64(tp)) {
+		uringRegister(fd, false)
+		unlock(&uring.lock)
+		return _EPERM
+	}
+	unlock(&uring.lock)
+	return 0
+}
+
+// uringRefused reports whether the completion queue holds the final
+// completion of the poll request with user data tag, and if so, clears
+// its user data so that netpoll ignores it. uring.lock must be held.
+func uringRefused(tag uint64) bool {
+	for i, tail := *uring.cqHead, atomic.Load(uring.cqTail); i != tail; i++ {
+		cqe := uringCQEAt(i)
+		if cqe.userData == tag && cqe.flags&_IORING_CQE_F_MORE == 0 {
+			cqe.userData = 0
+			return true
+		}
+	}
+	return false
+}
+
+func uringclose(fd uintptr) uintptr {
+	// The poll requests hold a reference to the file, so they must be
+	// cancelled for the file to be closed. Completions for them that
+	// are reaped after this are ignored, since the pollDesc's fdseq
+	// changes.
+	lock(&uring.lock)
+	if !uringRegister(fd, false) {
+		unlock(&uring.lock)
+		return _ENOENT
+	}
+	uringSubmit(uringSQE{
+		opcode:  _IORING_OP_ASYNC_CANCEL,
+		fd:      int32(fd),
+		opFlags: _IORING_ASYNC_CANCEL_FD | _IORING_ASYNC_CANCEL_ALL,
+	})
+	unlock(&uring.lock)
+	return 0
+}
+
+// uringRegister sets or clears fd's bit in uring.registered, and
+// reports whether it was set. uring.lock must be held.
+func uringRegister(fd uintptr, set bool) bool {
+	i, bit := fd/64, uint64(1)<<(fd%64)
+	if i >= uintptr(len(uring.registered)) {
+		if !set {
+			return false
+		}
+		n := max(i+1, uintptr(len(uring.registered))*2, 64)
+		p := sysAlloc(n*8, &memstats.other_sys)
+		if p == nil {
+			throw("runtime: cannot allocate memory")
+		}
+		old := uring.registered
+		uring.registered = unsafe.Slice((*uint64)(p), n)
+		copy(uring.registered, old)
+		if len(old) != 0 {
+			sysFree(unsafe.Pointer(&old[0]), uintptr(len(old))*8, &memstats.other_sys)
+		}
+	}
+	was := uring.registered[i]&bit != 0
+	if set {
+		uring.registered[i] |= bit
+	} else {
+		uring.registered[i] &^= bit
+	}
+	return was
+}
+
+// uringnetpoll is netpoll for the ring.
+func uringnetpoll(delay int64) (gList, int32) {
+	if delay != 0 && atomic.Load(uring.cqTail) == atomic.Load(uring.cqHead) {
+		var ts uringTimespec
+		arg := uringGeteventsArg{}
+		if delay > 0 {
+			// An arbitrary cap on how long to wait for a timer,
+			// like epoll's.
+			delay = min(delay, 1e15)
+			ts.sec = delay / 1e9
+			ts.nsec = delay % 1e9
+			arg.ts = uint64(uintptr(unsafe.Pointer(&ts)))
+		}
+		_, _, errno := syscall.Syscall6(syscall.SYS_IO_URING_ENTER, uintptr(uring.fd), 0, 1,
+			_IORING_ENTER_GETEVENTS|_IORING_ENTER_EXT_ARG, uintptr(unsafe.Pointer(&arg)), unsafe.Sizeof(arg))
+		switch errno {
+		case 0, _ETIME:
+		case _EINTR:
+			// If a timed sleep was interrupted, just return to
+			// recalculate how long we should sleep now. Otherwise
+			// check for completions and return, like a spurious
+			// wakeup.
+			if delay > 0 {
+				return gList{}, 0
+			}
+		default:
+			println("runtime: io_uring_enter on fd", uring.fd, "failed with", errno)
+			throw("runtime: netpoll failed")
+		}
+	}
+
+	var toRun gList
+	delta := int32(0)
+	lock(&uring.lock)
+	for {
+		head := *uring.cqHead
+		tail := atomic.Load(uring.cqTail)
+		for ; head != tail; head++ {
+			cqe := *uringCQEAt(head)
+			delta += uringComplete(&toRun, &cqe, delay)
+		}
+		atomic.Store(uring.cqHead, head)
+
+		if atomic.Load(uring.sqFlags)&_IORING_SQ_CQ_OVERFLOW == 0 {
+			break
+		}
+		// Completions didn't fit in the queue. Have the kernel
+		// move them there, now that there's room.
+		syscall.Syscall6(syscall.SYS_IO_URING_ENTER, uintptr(uring.fd), 0, 0, _IORING_ENTER_GETEVENTS, 0, 0)
+	}
+	unlock(&uring.lock)
+	return toRun, delta
+}
+
+// uringComplete handles the completion cqe, adding the goroutines it
+// makes ready to toRun, and returns the delta to add to netpollWaiters.
+// uring.lock must be held.
+func uringComplete(toRun *gList, cqe *uringCQE, delay int64) int32 {
+	if cqe.userData == 0 {
+		// A cancellation.
+		return 0
+	}
+	more := cqe.flags&_IORING_CQE_F_MORE != 0
+
+	if cqe.userData == uint64(uintptr(unsafe.Pointer(&netpollEventFd))) {
+		if !more {
+			uringPollAdd(int32(netpollEventFd), cqe.userData)
+		}
+		if cqe.res > 0 && delay != 0 {
+			// netpollBreak could be picked up by a nonblocking
+			// poll. Only read the 8-byte integer if blocking.
+			var one uint64
+			read(int32(netpollEventFd), noescape(unsafe.Pointer(&one)), int32(unsafe.Sizeof(one)))
+			netpollWakeSig.Store(0)
+		}
+		return 0
+	}
+
+	tp := taggedPointer(cqe.userData)
+	pd := (*pollDesc)(tp.pointer())
+	tag := tp.tag()
+	if pd.fdseq.Load() != tag {
+		// The descriptor has been closed.
+		return 0
+	}
+	// pd.closing is set before uringclose cancels the descriptor's
+	// request, which is serialized with this by uring.lock. Once it's
+	// set, the request's end is expected, and mustn't be rearmed: the
+	// new request would keep the file open.
+	if pd.closing {
+		return 0
+	}
+	events := uint32(cqe.res)
+	if cqe.res < 0 {
+		events = syscall.EPOLLERR
+	} else if !more {
+		// The kernel ended the request. Arm a new one, and report
+		// the descriptor ready, in case it missed a wakeup.
+		uringPollAdd(int32(pd.fd), cqe.userData)
+		events |= syscall.EPOLLIN | syscall.EPOLLOUT
+	}
+
+	var mode int32
+	if events&(syscall.EPOLLIN|syscall.EPOLLRDHUP|syscall.EPOLLHUP|syscall.EPOLLERR) != 0 {
+		mode += 'r'
+	}
+	if events&(syscall.EPOLLOUT|syscall.EPOLLHUP|syscall.EPOLLERR) != 0 {
+		mode += 'w'
+	}
+	if mode == 0 {
+		return 0
+	}
+	pd.setEventErr(events == syscall.EPOLLERR, tag)
+	return netpollready(toRun, pd, mode)
+}
@@ -897,3 +897,13 @@ func mprotect(addr unsafe.Pointer, n uintptr, prot int32) (ret int32, errno int3
 	r, _, err := syscall.Syscall6(syscall.SYS_MPROTECT, uintptr(addr), n, uintptr(prot), 0, 0, 0)
 	return int32(r), int32(err)
 }
+
+func mbind(addr unsafe.Pointer, n uintptr, mode int32, nodemask *uint64, maxnode uintptr, flags uint32) (errno int32) {
+	_, _, err := syscall.Syscall6(syscall.SYS_MBIND, uintptr(addr), n, uintptr(mode), uintptr(unsafe.Pointer(nodemask)), maxnode, uintptr(flags))
+	return int32(err)
+}
+
+func getcpu(cpu, node *uint32) (errno int32) {
+	_, _, err := syscall.Syscall6(syscall.SYS_GETCPU, uintptr(unsafe.Pointer(cpu)), uintptr(unsafe.Pointer(node)), 0, 0, 0, 0)
+	return int32(err)
+}
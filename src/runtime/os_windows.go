@@ -674,6 +674,24 @@ func semasleep(ns int64) int32 {
 	var result uintptr
 	if ns < 0 {
 		result = stdcall2(_WaitForSingleObject, getg().m.waitsema, uintptr(_INFINITE))
+	} else if mp := getg().m; haveHighResTimer && mp.highResTimer != 0 {
+		// WaitForMultipleObjects rounds its timeout up to the system
+		// timer resolution, typically 15.6ms, so wait for the high
+		// resolution timer instead.
+		dt := -ns / 100 // relative sleep (negative), 100ns units
+		stdcall6(_SetWaitableTimer, mp.highResTimer, uintptr(unsafe.Pointer(&dt)), 0, 0, 0, 0)
+		for {
+			result = stdcall4(_WaitForMultipleObjects, 3,
+				uintptr(unsafe.Pointer(&[3]uintptr{mp.waitsema, mp.resumesema, mp.highResTimer})),
+				0, uintptr(_INFINITE))
+			if result != _WAIT_OBJECT_0+1 {
+				// Not a suspend/resume event
+				break
+			}
+		}
+		if result == _WAIT_OBJECT_0+2 {
+			result = _WAIT_TIMEOUT
+		}
 	} else {
 		start := nanotime()
 		elapsed := int64(0)
@@ -659,6 +659,60 @@ func preprintpanics(p *_panic) {
 	}
 }
 
+// panicHook is the function set by runtime/debug.SetPanicHook, if any.
+var panicHook atomic.Pointer[func(value any, stack []byte)]
+
+// panicHookGoid is the ID of the goroutine that called the panic hook,
+// if it's been called, and panicHookDone is set once it returns.
+var (
+	panicHookGoid atomic.Uint64
+	panicHookDone atomic.Bool
+)
+
+//go:linkname setPanicHook runtime/debug.setPanicHook
+func setPanicHook(hook func(value any, stack []byte)) {
+	if hook == nil {
+		panicHook.Store(nil)
+		return
+	}
+	h := new(func(value any, stack []byte))
+	*h = hook
+	panicHook.Store(h)
+}
+
+// runPanicHook calls the panic hook, if there is one, for the panic p,
+// which is about to crash the program. The hook is called only once: a
+// goroutine that panics while it runs waits for it to return, and a
+// panic in the hook itself crashes the program without calling it
+// again.
+func runPanicHook(p *_panic) {
+	hook := panicHook.Load()
+	if hook == nil {
+		return
+	}
+	gp := getg()
+	if !panicHookGoid.CompareAndSwap(0, gp.goid) {
+		if panicHookGoid.Load() != gp.goid {
+			for !panicHookDone.Load() {
+				timeSleep(1e6)
+			}
+		}
+		return
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n := Stack(buf, false)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	(*hook)(p.arg, buf)
+	panicHookDone.Store(true)
+}
+
 // Print all currently active panics. Used when crashing.
 // Should only be called after preprintpanics.
 func printpanics(p *_panic) {
@@ -798,6 +852,8 @@ func gopanic(e any) {
 	}
 
 	// ran out of deferred calls - old-school panic now
+	runPanicHook(&p)
+
 	// Because it is unsafe to call arbitrary user code after freezing
 	// the world, we call preprintpanics to invoke all necessary Error
 	// and String methods to prepare the panic strings before startpanic.
@@ -11,8 +11,9 @@ import (
 )
 
 // A Pinner is a set of Go objects each pinned to a fixed location in memory. The
-// [Pinner.Pin] method pins one object, while [Pinner.Unpin] unpins all pinned
-// objects. See their comments for more information.
+// [Pinner.Pin] method pins one object, [Pinner.PinSlice] pins the backing array
+// of a slice, while [Pinner.Unpin] unpins all pinned objects. See their comments
+// for more information. [Pinned] reports whether an object is pinned.
 type Pinner struct {
 	*pinner
 }
@@ -28,6 +29,22 @@ type Pinner struct {
 // The argument must be a pointer of any type or an [unsafe.Pointer].
 // It's safe to call Pin on non-Go pointers, in which case Pin will do nothing.
 func (p *Pinner) Pin(pointer any) {
+	p.pin(pinnerGetPtr(&pointer))
+}
+
+// PinSlice pins the backing array of a slice, like calling [Pinner.Pin] with a
+// pointer to the slice's first element. Since Pin pins whole objects, this pins
+// all the elements up to the slice's capacity in one call, but not any objects
+// the elements point to.
+//
+// The argument must be a slice of any type. PinSlice does nothing if the slice
+// has a capacity of zero.
+func (p *Pinner) PinSlice(slice any) {
+	p.pin(pinnerGetSliceData(&slice))
+}
+
+// pin pins the object ptr points to.
+func (p *Pinner) pin(ptr unsafe.Pointer) {
 	if p.pinner == nil {
 		// Check the pinner cache first.
 		mp := acquirem()
@@ -56,12 +73,24 @@ func (p *Pinner) Pin(pointer any) {
 			})
 		}
 	}
-	ptr := pinnerGetPtr(&pointer)
 	if setPinned(ptr, true) {
 		p.refs = append(p.refs, ptr)
 	}
 }
 
+// Pinned reports whether the object a pointer points to is pinned, whether
+// by a [Pinner] or otherwise. Memory the garbage collector does not manage,
+// such as global variables and non-Go memory, is always considered pinned.
+//
+// The argument must be a pointer of any type or an [unsafe.Pointer].
+func Pinned(pointer any) bool {
+	ptr := pinnerCheckPtr(&pointer)
+	if ptr == nil {
+		return false
+	}
+	return isPinned(ptr)
+}
+
 // Unpin unpins all pinned objects of the [Pinner].
 func (p *Pinner) Unpin() {
 	p.pinner.unpin()
@@ -103,6 +132,11 @@ func (p *pinner) unpin() {
 }
 
 func pinnerGetPtr(i *any) unsafe.Pointer {
+	return pinnerCheckArena(pinnerCheckPtr(i))
+}
+
+// pinnerCheckPtr returns the pointer in *i, which must hold a pointer.
+func pinnerCheckPtr(i *any) unsafe.Pointer {
 	e := efaceOf(i)
 	etyp := e._type
 	if etyp == nil {
@@ -111,11 +145,42 @@ func pinnerGetPtr(i *any) unsafe.Pointer {
 	if kind := etyp.Kind_ & abi.KindMask; kind != abi.Pointer && kind != abi.UnsafePointer {
 		panic(errorString("runtime.Pinner: argument is not a pointer: " + toRType(etyp).string()))
 	}
-	if inUserArenaChunk(uintptr(e.data)) {
-		// Arena-allocated objects are not eligible for pinning.
+	return e.data
+}
+
+// pinnerGetSliceData returns a pointer to the backing array of the slice
+// in *i, or nil if the slice has no capacity.
+func pinnerGetSliceData(i *any) unsafe.Pointer {
+	e := efaceOf(i)
+	etyp := e._type
+	if etyp == nil {
+		panic(errorString("runtime.Pinner: argument is nil"))
+	}
+	if etyp.Kind_&abi.KindMask != abi.Slice {
+		panic(errorString("runtime.Pinner: argument is not a slice: " + toRType(etyp).string()))
+	}
+	s := (*slice)(e.data)
+	if s.cap == 0 {
+		// The array pointer may point just past the end of
+		// another object, which must not be pinned.
+		return nil
+	}
+	return pinnerCheckArena(s.array)
+}
+
+// pinnerCheckArena returns ptr, or nil if there's no need to pin it because
+// it's already pinned by its user arena.
+func pinnerCheckArena(ptr unsafe.Pointer) unsafe.Pointer {
+	if inUserArenaChunk(uintptr(ptr)) {
+		if isPinned(ptr) {
+			// The object was allocated with arena.NewPinned, so it's
+			// already pinned by its arena.
+			return nil
+		}
+		// Other arena-allocated objects are not eligible for pinning.
 		panic(errorString("runtime.Pinner: object was allocated into an arena"))
 	}
-	return e.data
+	return ptr
 }
 
 // isPinned checks if a Go pointer is pinned.
@@ -142,6 +207,10 @@ func isPinned(ptr unsafe.Pointer) bool {
 	return pinState.isPinned()
 }
 
+// pinnedObjects is the number of heap objects currently pinned, for the
+// /gc/heap/pinned:objects metric.
+var pinnedObjects atomic.Int64
+
 // setPinned marks or unmarks a Go pointer as pinned, when the ptr is a Go pointer.
 // It will be ignored while try to pin a non-Go pointer,
 // and it will be panic while try to unpin a non-Go pointer,
@@ -186,6 +255,7 @@ func setPinned(ptr unsafe.Pointer, pin bool) bool {
 		} else {
 			// set pin bit
 			pinState.setPinned(true)
+			pinnedObjects.Add(1)
 		}
 	} else {
 		// unpin
@@ -204,6 +274,7 @@ func setPinned(ptr unsafe.Pointer, pin bool) bool {
 			} else {
 				// no multipins recorded. unpin object.
 				pinState.setPinned(false)
+				pinnedObjects.Add(-1)
 			}
 		} else {
 			// unpinning unpinned object, bail out
@@ -6,6 +6,7 @@ package runtime_test
 
 import (
 	"runtime"
+	"runtime/metrics"
 	"testing"
 	"time"
 	"unsafe"
@@ -234,6 +235,90 @@ func TestPinnerPinNonPtrPanics(t *testing.T) {
 	pinner.Pin(i)
 }
 
+func TestPinnerPinSlice(t *testing.T) {
+	var pinner runtime.Pinner
+	s := make([]obj, 10)
+	pinner.PinSlice(s[2:4])
+	for i := range cap(s) {
+		if !runtime.Pinned(&s[:cap(s)][i]) {
+			t.Fatalf("element %d not pinned", i)
+		}
+	}
+	runtime.CgoCheckPointer(&s, true)
+	pinner.Unpin()
+	if runtime.Pinned(&s[0]) {
+		t.Fatal("still pinned after Unpin")
+	}
+}
+
+func TestPinnerPinSliceZeroCap(t *testing.T) {
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	s := make([]obj, 1)
+	pinner.PinSlice(s[1:])
+	pinner.PinSlice([]obj(nil))
+	if runtime.Pinned(&s[0]) {
+		t.Fatal("PinSlice of a slice with no capacity pinned its array")
+	}
+}
+
+func TestPinnerPinSliceNonSlicePanics(t *testing.T) {
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	defer assertDidPanic(t)
+	pinner.PinSlice(new(obj))
+}
+
+func TestPinned(t *testing.T) {
+	var pinner runtime.Pinner
+	p := new(obj)
+	if runtime.Pinned(p) {
+		t.Fatal("new object is pinned")
+	}
+	pinner.Pin(p)
+	if !runtime.Pinned(p) || !runtime.Pinned(unsafe.Pointer(&p.y)) {
+		t.Fatal("object is not pinned after Pin")
+	}
+	pinner.Unpin()
+	if runtime.Pinned(p) {
+		t.Fatal("object is pinned after Unpin")
+	}
+	if !runtime.Pinned(&globalUintptr) {
+		t.Fatal("global variable is not pinned")
+	}
+	if runtime.Pinned((*obj)(nil)) {
+		t.Fatal("nil pointer is pinned")
+	}
+}
+
+func TestPinnedNonPtrPanics(t *testing.T) {
+	defer assertDidPanic(t)
+	runtime.Pinned(0)
+}
+
+func TestPinnerMetric(t *testing.T) {
+	s := []metrics.Sample{{Name: "/gc/heap/pinned:objects"}}
+	read := func() uint64 {
+		metrics.Read(s)
+		return s[0].Value.Uint64()
+	}
+	var pinner runtime.Pinner
+	objs := make([]*obj, 10)
+	before := read()
+	for i := range objs {
+		objs[i] = new(obj)
+		pinner.Pin(objs[i])
+		pinner.Pin(objs[i]) // counted once
+	}
+	if got := read(); got < before+uint64(len(objs)) {
+		t.Errorf("%d objects pinned after pinning %d, want at least %d", got, len(objs), before+uint64(len(objs)))
+	}
+	pinner.Unpin()
+	if got := read(); got > before {
+		t.Errorf("%d objects pinned after Unpin, want at most %d", got, before)
+	}
+}
+
 func TestPinnerReuse(t *testing.T) {
 	var pinner runtime.Pinner
 	p := new(obj)
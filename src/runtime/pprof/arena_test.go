@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package pprof
+
+import (
+	"arena"
+	"bytes"
+	"internal/profile"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type arenaAllocValue struct {
+	p *int
+	n [13]int
+}
+
+//go:noinline
+func arenaAllocFunc(a *arena.Arena, n int) {
+	for range n {
+		_ = arena.New[arenaAllocValue](a)
+	}
+}
+
+//go:noinline
+func newProfiledArena() *arena.Arena {
+	return arena.NewArena()
+}
+
+func TestArenaAllocsProfile(t *testing.T) {
+	previousRate := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() {
+		runtime.MemProfileRate = previousRate
+	}()
+
+	a := newProfiledArena()
+	defer a.Free()
+	arenaAllocFunc(a, 7)
+
+	var buf bytes.Buffer
+	if err := Lookup("arenaallocs").WriteTo(&buf, 1); err != nil {
+		t.Fatalf("writing profile: %v", err)
+	}
+	text := buf.String()
+	for _, want := range []string{
+		"arenaallocs profile: ",
+		"7: 784 @",
+		"# arena created at runtime/pprof.newProfiledArena ",
+		"runtime/pprof.arenaAllocFunc+",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("debug=1 profile does not contain %q:\n%s", want, text)
+		}
+	}
+
+	buf.Reset()
+	if err := Lookup("arenaallocs").WriteTo(&buf, 0); err != nil {
+		t.Fatalf("writing profile: %v", err)
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatalf("profile.Parse: %v", err)
+	}
+	found := false
+	for _, s := range p.Sample {
+		if len(s.Location) == 0 || len(s.Location[0].Line) == 0 {
+			continue
+		}
+		if s.Location[0].Line[0].Function.Name != "runtime/pprof.arenaAllocFunc" {
+			continue
+		}
+		found = true
+		if s.Value[0] != 7 || s.Value[1] != 784 {
+			t.Errorf("sample values = %v, want [7 784]", s.Value)
+		}
+		if origin := s.Label["arena"]; len(origin) != 1 || !strings.HasPrefix(origin[0], "runtime/pprof.newProfiledArena ") {
+			t.Errorf("sample arena label = %q, want runtime/pprof.newProfiledArena", origin)
+		}
+	}
+	if !found {
+		t.Errorf("no sample for runtime/pprof.arenaAllocFunc in profile:\n%v", p)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"cmp"
+	"errors"
+	"internal/profilerecord"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"unsafe"
+)
+
+// A Snapshot holds the counts of a profile at some point in time, for
+// [Profile.WriteDelta] to report what the profile recorded since then.
+type Snapshot struct {
+	name string // the profile's name, or "heap" for the allocs profile
+
+	mem   map[string]profilerecord.MemProfileRecord
+	block map[string]profilerecord.BlockProfileRecord
+}
+
+// Snapshot returns the current counts of the profile, which must be
+// one of the "heap", "allocs", "block", "mutex" and "offcpu" profiles,
+// whose counts grow over the program's lifetime. It panics for other
+// profiles.
+func (p *Profile) Snapshot() *Snapshot {
+	switch p {
+	case heapProfile, allocsProfile:
+		s := &Snapshot{name: "heap", mem: make(map[string]profilerecord.MemProfileRecord)}
+		for _, r := range readMemProfile() {
+			addMemRecord(s.mem, r)
+		}
+		return s
+	case blockProfile, mutexProfile, offCPUProfile:
+		s := &Snapshot{name: p.name, block: make(map[string]profilerecord.BlockProfileRecord)}
+		for _, r := range readBlockProfile(blockProfileFetcher(p)) {
+			addBlockRecord(s.block, r)
+		}
+		return s
+	}
+	panic("pprof: Snapshot of profile " + p.name + ", which has no cumulative counts")
+}
+
+// WriteDelta writes to w, in the format WriteTo uses with debug=0, a
+// profile of what the profile recorded since snapshot since was taken.
+// since must come from the same profile, or, for the "heap" and
+// "allocs" profiles, from either of them.
+//
+// Unlike a profile computed by subtracting two profiles written by
+// WriteTo, the delta is computed from the runtime's unscaled counts,
+// for each of its records, before they are scaled to estimate the
+// unsampled values. Records with no change since the snapshot are left
+// out. In heap deltas, in-use values are the change in use, which is
+// negative for records whose objects were mostly freed.
+func (p *Profile) WriteDelta(w io.Writer, since *Snapshot) error {
+	if since == nil {
+		return errors.New("pprof: WriteDelta with nil snapshot")
+	}
+	name := p.name
+	if p == allocsProfile {
+		name = "heap"
+	}
+	if since.name != name {
+		return errors.New("pprof: WriteDelta of profile " + p.name + " with snapshot of profile " + since.name)
+	}
+	switch p {
+	case heapProfile, allocsProfile:
+		var defaultSampleType string
+		if p == allocsProfile {
+			defaultSampleType = "alloc_space"
+		}
+		cur := make(map[string]profilerecord.MemProfileRecord)
+		for _, r := range readMemProfile() {
+			addMemRecord(cur, r)
+		}
+		var d []profilerecord.MemProfileRecord
+		for k, r := range cur {
+			b := since.mem[k]
+			r.AllocBytes -= b.AllocBytes
+			r.AllocObjects -= b.AllocObjects
+			r.FreeBytes -= b.FreeBytes
+			r.FreeObjects -= b.FreeObjects
+			r.FreeLifetime -= b.FreeLifetime
+			if r.AllocObjects != 0 || r.FreeObjects != 0 {
+				d = append(d, r)
+			}
+		}
+		slices.SortFunc(d, func(a, b profilerecord.MemProfileRecord) int {
+			return slices.Compare(a.Stack, b.Stack)
+		})
+		return writeHeapProto(w, d, int64(runtime.MemProfileRate), defaultSampleType)
+	case blockProfile, mutexProfile, offCPUProfile:
+		cur := make(map[string]profilerecord.BlockProfileRecord)
+		for _, r := range readBlockProfile(blockProfileFetcher(p)) {
+			addBlockRecord(cur, r)
+		}
+		var d []profilerecord.BlockProfileRecord
+		for k, r := range cur {
+			b := since.block[k]
+			r.Count -= b.Count
+			r.Cycles -= b.Cycles
+			if r.Count != 0 {
+				d = append(d, r)
+			}
+		}
+		slices.SortFunc(d, func(a, b profilerecord.BlockProfileRecord) int {
+			return cmp.Compare(b.Cycles, a.Cycles)
+		})
+		countName, cycleName := cycleSampleNames(p.name)
+		return printCountCycleProfile(w, countName, cycleName, d)
+	}
+	return errors.New("pprof: WriteDelta of profile " + p.name + ", which has no cumulative counts")
+}
+
+// blockProfileFetcher returns the function that reads the runtime's
+// records for p, the blocking, mutex or off-CPU profile.
+func blockProfileFetcher(p *Profile) func([]profilerecord.BlockProfileRecord) (int, bool) {
+	switch p {
+	case mutexProfile:
+		return pprof_mutexProfileInternal
+	case offCPUProfile:
+		return pprof_offCPUProfileInternal
+	}
+	return pprof_blockProfileInternal
+}
+
+// addMemRecord adds heap profile record r to m. The runtime keeps a
+// record for each stack, object size and, with GODEBUG=memprofiletype=1,
+// type, so the key of r in m is made of those.
+func addMemRecord(m map[string]profilerecord.MemProfileRecord, r profilerecord.MemProfileRecord) {
+	var size int64
+	if r.AllocObjects > 0 {
+		size = r.AllocBytes / r.AllocObjects
+	}
+	k := strconv.FormatInt(size, 10) + " " + strconv.Itoa(len(r.Type)) + " " + r.Type + stackKey(r.Stack)
+	if old, ok := m[k]; ok {
+		// Records are normally unique, but don't lose counts if not.
+		r.AllocBytes += old.AllocBytes
+		r.AllocObjects += old.AllocObjects
+		r.FreeBytes += old.FreeBytes
+		r.FreeObjects += old.FreeObjects
+		r.FreeLifetime += old.FreeLifetime
+	}
+	m[k] = r
+}
+
+// addBlockRecord adds blocking or mutex profile record r to m, by stack.
+func addBlockRecord(m map[string]profilerecord.BlockProfileRecord, r profilerecord.BlockProfileRecord) {
+	k := stackKey(r.Stack)
+	if old, ok := m[k]; ok {
+		r.Count += old.Count
+		r.Cycles += old.Cycles
+	}
+	m[k] = r
+}
+
+// stackKey returns a string that identifies stk, for use as a map key.
+func stackKey(stk []uintptr) string {
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(stk))), len(stk)*int(unsafe.Sizeof(uintptr(0)))))
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bytes"
+	"internal/profile"
+	"runtime"
+	"testing"
+)
+
+var deltaSink [8]*[256]byte
+
+//go:noinline
+func allocateDelta(n int) {
+	for i := range n {
+		deltaSink[i] = new([256]byte)
+	}
+}
+
+func blockDelta(t *testing.T) {
+	c := make(chan bool)
+	go func() {
+		awaitBlockedGoroutine(t, "chan receive", "blockDelta", 1)
+		c <- true
+	}()
+	<-c
+}
+
+// deltaValues returns the sums of the values of the samples of p whose
+// stacks include function fn.
+func deltaValues(t *testing.T, p *Profile, since *Snapshot, fn string) []int64 {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := p.WriteDelta(&buf, since); err != nil {
+		t.Fatalf("WriteDelta: %v", err)
+	}
+	prof, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatalf("parsing delta profile: %v", err)
+	}
+	sums := make([]int64, len(prof.SampleType))
+	for _, s := range prof.Sample {
+		if !sampleHasFunc(s, fn) {
+			continue
+		}
+		for i, v := range s.Value {
+			sums[i] += v
+		}
+	}
+	return sums
+}
+
+func sampleHasFunc(s *profile.Sample, fn string) bool {
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function.Name == fn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestWriteDeltaHeap(t *testing.T) {
+	oldRate := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() {
+		runtime.MemProfileRate = oldRate
+	}()
+	// Allocate a meg to ensure that mcache.nextSample is updated to 1.
+	for i := 0; i < 1024; i++ {
+		memSink = make([]byte, 1024)
+	}
+	memSink = nil
+
+	allocateDelta(5)
+	runtime.GC()
+	since := Lookup("heap").Snapshot()
+	allocateDelta(3)
+	runtime.GC()
+
+	for _, name := range []string{"heap", "allocs"} {
+		// alloc_objects and alloc_space, unscaled at rate 1.
+		v := deltaValues(t, Lookup(name), since, "runtime/pprof.allocateDelta")
+		if v[0] != 3 || v[1] != 3*256 {
+			t.Errorf("%s delta: allocateDelta allocated %d objects, %d bytes, want 3, %d", name, v[0], v[1], 3*256)
+		}
+	}
+}
+
+func TestWriteDeltaBlock(t *testing.T) {
+	runtime.SetBlockProfileRate(1)
+	defer runtime.SetBlockProfileRate(0)
+
+	blockDelta(t)
+	since := Lookup("block").Snapshot()
+	for range 3 {
+		blockDelta(t)
+	}
+	if v := deltaValues(t, Lookup("block"), since, "runtime/pprof.blockDelta"); v[0] != 3 {
+		t.Errorf("block delta: blockDelta blocked %d times, want 3", v[0])
+	}
+	if v := deltaValues(t, Lookup("block"), Lookup("block").Snapshot(), "runtime/pprof.blockDelta"); v[0] != 0 {
+		t.Errorf("block delta since now: blockDelta blocked %d times, want 0", v[0])
+	}
+}
+
+func TestWriteDeltaErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Lookup("mutex").WriteDelta(&buf, Lookup("block").Snapshot()); err == nil {
+		t.Errorf("WriteDelta of mutex profile with block snapshot succeeded")
+	}
+	if err := Lookup("block").WriteDelta(&buf, nil); err == nil {
+		t.Errorf("WriteDelta with nil snapshot succeeded")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Snapshot of goroutine profile didn't panic")
+		}
+	}()
+	Lookup("goroutine").Snapshot()
+}
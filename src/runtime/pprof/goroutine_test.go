@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bytes"
+	"context"
+	"internal/profile"
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"testing"
+)
+
+var goroutineAllocSink [][]byte
+
+func TestGoroutineProfileAllocBytes(t *testing.T) {
+	if os.Getenv("GODEBUG") != "goroutineallocbytes=1" {
+		// The goroutine profile only reports allocations with the
+		// setting on, and keeps its single sample type otherwise.
+		cmd := testenv.CleanCmdEnv(exec.Command(testenv.Executable(t), "-test.run=^TestGoroutineProfileAllocBytes$"))
+		cmd.Env = append(cmd.Env, "GODEBUG=goroutineallocbytes=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %v\n%s", cmd, err, out)
+		}
+		return
+	}
+
+	const perGoroutine = 1 << 20
+	ready := make(chan struct{})
+	c := make(chan struct{})
+	Do(context.Background(), Labels("alloc-label", "alloc-value"), func(context.Context) {
+		for i := 0; i < 4; i++ {
+			go func() {
+				before := debug.GoroutineAllocBytes()
+				b := make([]byte, perGoroutine)
+				if got := debug.GoroutineAllocBytes() - before; got < perGoroutine {
+					t.Errorf("GoroutineAllocBytes grew by %d bytes after allocating %d", got, perGoroutine)
+				}
+				ready <- struct{}{}
+				<-c
+				goroutineAllocSink = append(goroutineAllocSink, b)
+				ready <- struct{}{}
+			}()
+		}
+	})
+	for i := 0; i < 4; i++ {
+		<-ready
+	}
+	defer func() {
+		close(c)
+		for i := 0; i < 4; i++ {
+			<-ready
+		}
+		goroutineAllocSink = nil
+	}()
+
+	var w bytes.Buffer
+	if err := Lookup("goroutine").WriteTo(&w, 0); err != nil {
+		t.Fatal(err)
+	}
+	p, err := profile.Parse(&w)
+	if err != nil {
+		t.Fatalf("error parsing protobuf profile: %v", err)
+	}
+
+	if len(p.SampleType) != 2 || p.SampleType[1].Type != "alloc_space" || p.SampleType[1].Unit != "bytes" {
+		t.Fatalf("goroutine profile has sample types %v, want goroutine and alloc_space", p.SampleType)
+	}
+	if p.DefaultSampleType != "goroutine" {
+		t.Errorf("goroutine profile has default sample type %q, want goroutine", p.DefaultSampleType)
+	}
+	var goroutines, allocated int64
+	for _, s := range p.Sample {
+		if s.Label["alloc-label"] != nil {
+			goroutines += s.Value[0]
+			allocated += s.Value[1]
+		}
+	}
+	if goroutines != 4 {
+		t.Errorf("profile has %d labeled goroutines, want 4", goroutines)
+	}
+	if allocated < 4*perGoroutine {
+		t.Errorf("labeled goroutines allocated %d bytes, want at least %d", allocated, 4*perGoroutine)
+	}
+}
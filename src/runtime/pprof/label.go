@@ -35,6 +35,10 @@ func labelValue(ctx context.Context) labelMap {
 // labelMap is the representation of the label set held in the context type.
 // This is an initial implementation, but it will be replaced with something
 // that admits incremental immutable modification more efficiently.
+//
+// The runtime reads a goroutine's labels as a *map[string]string, for
+// runtime.Goroutines, and relies on a labelMap not being modified once
+// it's set as a goroutine's labels.
 type labelMap map[string]string
 
 // String satisfies Stringer and returns key, value pairs in a consistent
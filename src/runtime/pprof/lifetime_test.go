@@ -0,0 +1,93 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !js
+
+package pprof
+
+import (
+	"bytes"
+	"internal/profile"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+type lifetimeObj struct {
+	link *lifetimeObj
+	pad  [200]byte
+}
+
+//go:noinline
+func allocateLifetimeObjs(n int) []*lifetimeObj {
+	objs := make([]*lifetimeObj, n)
+	for i := range objs {
+		objs[i] = &lifetimeObj{}
+	}
+	return objs
+}
+
+func TestLifetimeProfile(t *testing.T) {
+	old := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() { runtime.MemProfileRate = old }()
+
+	const n = 100
+	const hold = 20 * time.Millisecond
+	objs := allocateLifetimeObjs(n)
+	time.Sleep(hold)
+	runtime.KeepAlive(objs)
+	objs = nil
+	// Frees appear in the profile at the end of the cycle after the
+	// one that swept them.
+	runtime.GC()
+	runtime.GC()
+	runtime.GC()
+
+	var buf bytes.Buffer
+	if err := Lookup("lifetime").WriteTo(&buf, 0); err != nil {
+		t.Fatalf("writing profile: %v", err)
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatalf("profile.Parse: %v", err)
+	}
+	var types []string
+	for _, st := range p.SampleType {
+		types = append(types, st.Type+"/"+st.Unit)
+	}
+	if want := []string{"free_objects/count", "free_space/bytes", "lifetime/nanoseconds", "space_time/byte-seconds"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("sample types = %v, want %v", types, want)
+	}
+	found := false
+	for _, s := range p.Sample {
+		if len(s.Location) == 0 || len(s.Location[0].Line) == 0 {
+			continue
+		}
+		if s.Location[0].Line[0].Function.Name != "runtime/pprof.allocateLifetimeObjs" || s.NumLabel["bytes"][0] != int64(unsafe.Sizeof(lifetimeObj{})) {
+			continue
+		}
+		found = true
+		if s.Value[0] != n {
+			t.Errorf("freed objects = %d, want %d", s.Value[0], n)
+		}
+		if min := int64(n * hold); s.Value[2] < min {
+			t.Errorf("total lifetime = %v, want at least %v", time.Duration(s.Value[2]), time.Duration(min))
+		}
+	}
+	if !found {
+		t.Errorf("no sample for runtime/pprof.allocateLifetimeObjs in profile:\n%v", p)
+	}
+
+	buf.Reset()
+	if err := Lookup("lifetime").WriteTo(&buf, 1); err != nil {
+		t.Fatalf("writing profile: %v", err)
+	}
+	if text := buf.String(); !strings.HasPrefix(text, "lifetime profile: ") || !strings.Contains(text, "runtime/pprof.allocateLifetimeObjs+") {
+		t.Errorf("debug=1 profile is missing the header or allocation site:\n%s", text)
+	}
+}
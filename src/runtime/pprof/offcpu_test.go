@@ -0,0 +1,95 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bytes"
+	"internal/profile"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+//go:noinline
+func offCPUSleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func offCPUChanRecv(t *testing.T) {
+	c := make(chan bool)
+	go func() {
+		awaitBlockedGoroutine(t, "chan receive", "offCPUChanRecv", 1)
+		c <- true
+	}()
+	<-c
+}
+
+func TestOffCPUProfile(t *testing.T) {
+	runtime.SetOffCPUProfileRate(1)
+	defer runtime.SetOffCPUProfileRate(0)
+
+	p := Lookup("offcpu")
+	since := p.Snapshot()
+	const n = 3
+	const d = 10 * time.Millisecond
+	for range n {
+		offCPUSleep(d)
+		offCPUChanRecv(t)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteDelta(&buf, since); err != nil {
+		t.Fatal(err)
+	}
+	prof, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatalf("parsing profile: %v", err)
+	}
+	if len(prof.SampleType) != 2 || prof.SampleType[0].Type != "events" || prof.SampleType[1].Type != "off_cpu" || prof.SampleType[1].Unit != "nanoseconds" {
+		t.Fatalf("sample types are %v, want events/count and off_cpu/nanoseconds", prof.SampleType)
+	}
+
+	for _, fn := range []string{"runtime/pprof.offCPUSleep", "runtime/pprof.offCPUChanRecv"} {
+		var events, offCPU int64
+		for _, s := range prof.Sample {
+			if sampleHasFunc(s, fn) {
+				events += s.Value[0]
+				offCPU += s.Value[1]
+			}
+		}
+		if events < n {
+			t.Errorf("%s went off CPU %d times, want at least %d", fn, events, n)
+		}
+		if fn == "runtime/pprof.offCPUSleep" && offCPU < int64(n*d) {
+			t.Errorf("%s was off CPU for %v, want at least %v", fn, time.Duration(offCPU), n*d)
+		}
+	}
+
+	// Samples start at the function that blocked, not in the off-CPU
+	// profiler or gopark.
+	for _, s := range prof.Sample {
+		if len(s.Location) == 0 || len(s.Location[0].Line) == 0 {
+			continue
+		}
+		if name := s.Location[0].Line[0].Function.Name; strings.Contains(name, "offcpu") || name == "runtime.saveblockevent" || name == "runtime.gopark" {
+			t.Errorf("sample starts at %s", name)
+		}
+	}
+
+	runtime.SetOffCPUProfileRate(0)
+	since = p.Snapshot()
+	offCPUSleep(d)
+	buf.Reset()
+	if err := p.WriteDelta(&buf, since); err != nil {
+		t.Fatal(err)
+	}
+	if prof, err = profile.Parse(&buf); err != nil {
+		t.Fatalf("parsing profile: %v", err)
+	}
+	if len(prof.Sample) != 0 {
+		t.Errorf("profile with rate 0 has %d samples, want 0", len(prof.Sample))
+	}
+}
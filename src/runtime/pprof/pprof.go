@@ -108,14 +108,33 @@ import (
 //	threadcreate - stack traces that led to the creation of new OS threads
 //	block        - stack traces that led to blocking on synchronization primitives
 //	mutex        - stack traces of holders of contended mutexes
+//	offcpu       - stack traces that led to time spent blocked or in system calls
+//	arenaallocs  - a sampling of all past memory allocations from arenas
+//	lifetime     - a sampling of the lifetimes of freed objects
 //
 // These predefined profiles maintain themselves and panic on an explicit
 // [Profile.Add] or [Profile.Remove] method call.
 //
+// The heap, allocs, block, mutex and offcpu profiles accumulate counts
+// over the program's lifetime. To see only what they recorded over an
+// interval, take a [Profile.Snapshot] at its start and call
+// [Profile.WriteDelta] at its end.
+//
 // The CPU profile is not available as a Profile. It has a special API,
 // the [StartCPUProfile] and [StopCPUProfile] functions, because it streams
 // output to a writer during profiling.
 //
+// # Goroutine profile
+//
+// The goroutine profile reports the stack traces of all current goroutines,
+// grouped by stack trace and profiler labels. With
+// GODEBUG=goroutineallocbytes=1, it also reports alloc_space, the total
+// bytes of heap memory the goroutines of each sample have requested since
+// they were created, as returned by [runtime/debug.GoroutineAllocBytes].
+// Pprof's -sample_index flag selects alloc_space, and its -tagfocus flag
+// breaks it down by label, for example to find how much memory the
+// goroutines serving a request have allocated.
+//
 // # Heap profile
 //
 // The heap profile reports statistics as of the most recently completed
@@ -137,6 +156,38 @@ import (
 // pprof display to -alloc_space, the total number of bytes allocated since
 // the program began (including garbage-collected bytes).
 //
+// # Arena allocation profile
+//
+// The arenaallocs profile tracks the allocation sites of values allocated from
+// arenas (see the arena package, available with GOEXPERIMENT=arenas), which
+// don't otherwise appear in memory profiles because their memory is obtained
+// from the heap in large chunks. Allocations are sampled at the same rate as
+// heap allocations, as set by [runtime.MemProfileRate], and all allocations
+// since the program began are reported. Each sample is labeled with "arena",
+// the location of the function that created the arena it was allocated from.
+// Values too large to be allocated from an arena's memory are allocated on
+// the heap, so they appear in both the heap profile and this profile.
+//
+// # Lifetime profile
+//
+// The lifetime profile tracks how long objects live, by allocation site.
+// It covers the same sampled allocations as the heap profile, and reports,
+// for the sampled objects that have been freed, their number and size and
+// their total lifetime, from allocation until the garbage collector freed
+// them. Since the garbage collector frees unreachable objects only during
+// the cycle after they became unreachable, lifetimes are overestimated by
+// up to the time between two collections. Like the heap profile, the
+// lifetime profile reports statistics as of the most recently completed
+// garbage collection.
+//
+// Besides the total lifetime, the profile reports space-time, the
+// lifetime multiplied by the size, which is the amount of memory the
+// objects kept in use over time. Pprof's -sample_index flag selects the
+// sample to display, defaulting to space_time. Allocation sites with a
+// high space-time per object are candidates for longer-lived memory
+// management, such as arenas, while those with many short-lived objects
+// are candidates for pooling.
+//
 // # Block profile
 //
 // The block profile tracks time spent blocked on synchronization primitives,
@@ -172,6 +223,23 @@ import (
 // runtime-internal locks can be obtained by setting
 // `GODEBUG=runtimecontentionstacks=1` (see package [runtime] docs for
 // caveats).
+//
+// # Off-CPU profile
+//
+// The offcpu profile tracks where goroutines spend time off CPU, which
+// the CPU profile doesn't show: blocked for any reason, such as on
+// channels, locks, sleeps and network I/O, or in system calls. It
+// answers where the latency of a goroutine comes from, without a full
+// execution trace.
+//
+// Stack traces correspond to the location that blocked, or that made
+// the system call. Sample values correspond to the number of times
+// goroutines went off CPU there, and the cumulative time they spent off
+// CPU, subject to time-based sampling specified by
+// [runtime.SetOffCPUProfileRate]. The runtime's own goroutines aren't
+// profiled. Since the profile accumulates over the program's lifetime,
+// [Profile.WriteDelta] is the usual way to look at it, for the time
+// spent off CPU during an interval.
 type Profile struct {
 	name  string
 	mu    sync.Mutex
@@ -222,6 +290,24 @@ var mutexProfile = &Profile{
 	write: writeMutex,
 }
 
+var offCPUProfile = &Profile{
+	name:  "offcpu",
+	count: countOffCPU,
+	write: writeOffCPU,
+}
+
+var lifetimeProfile = &Profile{
+	name:  "lifetime",
+	count: countLifetime,
+	write: writeLifetime,
+}
+
+var arenaAllocsProfile = &Profile{
+	name:  "arenaallocs",
+	count: countArenaAllocs,
+	write: writeArenaAllocs,
+}
+
 func lockProfiles() {
 	profiles.mu.Lock()
 	if profiles.m == nil {
@@ -233,6 +319,9 @@ func lockProfiles() {
 			"allocs":       allocsProfile,
 			"block":        blockProfile,
 			"mutex":        mutexProfile,
+			"offcpu":       offCPUProfile,
+			"arenaallocs":  arenaAllocsProfile,
+			"lifetime":     lifetimeProfile,
 		}
 	}
 }
@@ -404,6 +493,13 @@ type countProfile interface {
 	Label(i int) *labelMap
 }
 
+// A goroutineCountProfile is a countProfile whose stacks are those of
+// goroutines, which also reports the bytes each goroutine allocated.
+type goroutineCountProfile interface {
+	countProfile
+	AllocBytes(i int) uint64
+}
+
 // expandInlinedFrames copies the call stack from pcs into dst, expanding any
 // PCs corresponding to inlined calls into the corresponding PCs for the inlined
 // functions. Returns the number of frames copied to dst.
@@ -472,6 +568,8 @@ func printCountProfile(w io.Writer, debug int, name string, p countProfile) erro
 	}
 	count := map[string]int{}
 	index := map[string]int{}
+	allocBytes := map[string]uint64{}
+	gp, isGoroutines := p.(goroutineCountProfile)
 	var keys []string
 	n := p.Len()
 	for i := 0; i < n; i++ {
@@ -481,6 +579,9 @@ func printCountProfile(w io.Writer, debug int, name string, p countProfile) erro
 			keys = append(keys, k)
 		}
 		count[k]++
+		if isGoroutines {
+			allocBytes[k] += gp.AllocBytes(i)
+		}
 	}
 
 	sort.Sort(&keysByCount{keys, count})
@@ -501,11 +602,19 @@ func printCountProfile(w io.Writer, debug int, name string, p countProfile) erro
 	b.pbValueType(tagProfile_PeriodType, name, "count")
 	b.pb.int64Opt(tagProfile_Period, 1)
 	b.pbValueType(tagProfile_SampleType, name, "count")
-
 	values := []int64{0}
+	if isGoroutines {
+		b.pbValueType(tagProfile_SampleType, "alloc_space", "bytes")
+		b.pb.int64Opt(tagProfile_DefaultSampleType, b.stringIndex(name))
+		values = append(values, 0)
+	}
+
 	var locs []uint64
 	for _, k := range keys {
 		values[0] = int64(count[k])
+		if isGoroutines {
+			values[1] = int64(allocBytes[k])
+		}
 		// For count profiles, all stack addresses are
 		// return PCs, which is what appendLocsForStack expects.
 		locs = b.appendLocsForStack(locs[:0], p.Stack(index[k]))
@@ -596,15 +705,8 @@ func writeAlloc(w io.Writer, debug int) error {
 	return writeHeapInternal(w, debug, "alloc_space")
 }
 
-func writeHeapInternal(w io.Writer, debug int, defaultSampleType string) error {
-	var memStats *runtime.MemStats
-	if debug != 0 {
-		// Read mem stats first, so that our other allocations
-		// do not appear in the statistics.
-		memStats = new(runtime.MemStats)
-		runtime.ReadMemStats(memStats)
-	}
-
+// readMemProfile returns the records of the current runtime heap profile.
+func readMemProfile() []profilerecord.MemProfileRecord {
 	// Find out how many records there are (the call
 	// pprof_memProfileInternal(nil, true) below),
 	// allocate that many records, and get the data.
@@ -621,11 +723,22 @@ func writeHeapInternal(w io.Writer, debug int, defaultSampleType string) error {
 		p = make([]profilerecord.MemProfileRecord, n+50)
 		n, ok = pprof_memProfileInternal(p, true)
 		if ok {
-			p = p[0:n]
-			break
+			return p[0:n]
 		}
 		// Profile grew; try again.
 	}
+}
+
+func writeHeapInternal(w io.Writer, debug int, defaultSampleType string) error {
+	var memStats *runtime.MemStats
+	if debug != 0 {
+		// Read mem stats first, so that our other allocations
+		// do not appear in the statistics.
+		memStats = new(runtime.MemStats)
+		runtime.ReadMemStats(memStats)
+	}
+
+	p := readMemProfile()
 
 	if debug == 0 {
 		return writeHeapProto(w, p, int64(runtime.MemProfileRate), defaultSampleType)
@@ -723,6 +836,132 @@ func writeHeapInternal(w io.Writer, debug int, defaultSampleType string) error {
 	return b.Flush()
 }
 
+// readLifetimeProfile returns the records of the current runtime heap
+// profile that have freed objects.
+func readLifetimeProfile() []profilerecord.MemProfileRecord {
+	return slices.DeleteFunc(readMemProfile(), func(r profilerecord.MemProfileRecord) bool {
+		return r.FreeObjects == 0
+	})
+}
+
+// countLifetime returns the number of records in the lifetime profile.
+func countLifetime() int {
+	return len(readLifetimeProfile())
+}
+
+// writeLifetime writes the current object lifetime profile to w.
+func writeLifetime(w io.Writer, debug int) error {
+	p := readLifetimeProfile()
+	rate := int64(runtime.MemProfileRate)
+	if debug == 0 {
+		return writeLifetimeProto(w, p, rate)
+	}
+
+	// Sort by decreasing space-time.
+	spaceTime := func(r profilerecord.MemProfileRecord) float64 {
+		return float64(r.FreeLifetime) * float64(r.FreeBytes/r.FreeObjects)
+	}
+	slices.SortFunc(p, func(a, b profilerecord.MemProfileRecord) int {
+		return cmp.Compare(spaceTime(b), spaceTime(a))
+	})
+
+	b := bufio.NewWriter(w)
+	tw := tabwriter.NewWriter(b, 1, 8, 1, '\t', 0)
+	w = tw
+
+	var totalObjects, totalBytes, totalLifetime int64
+	for i := range p {
+		totalObjects += p[i].FreeObjects
+		totalBytes += p[i].FreeBytes
+		totalLifetime += p[i].FreeLifetime
+	}
+	fmt.Fprintf(w, "lifetime profile: %d: %d [%d] @ lifetime/%d\n", totalObjects, totalBytes, totalLifetime, rate)
+	for i := range p {
+		r := &p[i]
+		fmt.Fprintf(w, "%d: %d [%d] @", r.FreeObjects, r.FreeBytes, r.FreeLifetime)
+		for _, pc := range r.Stack {
+			fmt.Fprintf(w, " %#x", pc)
+		}
+		fmt.Fprintf(w, "\n")
+		fmt.Fprintf(w, "# mean lifetime %v\n", time.Duration(r.FreeLifetime/r.FreeObjects))
+		printStackRecord(w, r.Stack, false)
+	}
+
+	tw.Flush()
+	return b.Flush()
+}
+
+// countArenaAllocs returns the number of records in the arena allocation profile.
+func countArenaAllocs() int {
+	n, _ := pprof_arenaAllocProfile(nil)
+	return n
+}
+
+// writeArenaAllocs writes the current arena allocation profile to w.
+func writeArenaAllocs(w io.Writer, debug int) error {
+	// As with the heap profile, allocate a few extra records in case
+	// more are added between the two calls, and retry if we're unlucky.
+	var p []profilerecord.ArenaAllocRecord
+	n, ok := pprof_arenaAllocProfile(nil)
+	for {
+		p = make([]profilerecord.ArenaAllocRecord, n+50)
+		n, ok = pprof_arenaAllocProfile(p)
+		if ok {
+			p = p[0:n]
+			break
+		}
+		// Profile grew; try again.
+	}
+
+	rate := int64(runtime.MemProfileRate)
+	if debug == 0 {
+		return writeArenaAllocsProto(w, p, rate)
+	}
+
+	slices.SortFunc(p, func(a, b profilerecord.ArenaAllocRecord) int {
+		return cmp.Compare(b.AllocBytes, a.AllocBytes)
+	})
+
+	b := bufio.NewWriter(w)
+	tw := tabwriter.NewWriter(b, 1, 8, 1, '\t', 0)
+	w = tw
+
+	var totalObjects, totalBytes int64
+	for i := range p {
+		totalObjects += p[i].AllocObjects
+		totalBytes += p[i].AllocBytes
+	}
+	fmt.Fprintf(w, "arenaallocs profile: %d: %d @ arenaallocs/%d\n", totalObjects, totalBytes, rate)
+	for i := range p {
+		r := &p[i]
+		fmt.Fprintf(w, "%d: %d @", r.AllocObjects, r.AllocBytes)
+		for _, pc := range r.Stack {
+			fmt.Fprintf(w, " %#x", pc)
+		}
+		fmt.Fprintf(w, "\n")
+		if r.Origin != 0 {
+			fmt.Fprintf(w, "# arena created at %s\n", arenaOrigin(r.Origin))
+		}
+		printStackRecord(w, r.Stack, false)
+	}
+
+	tw.Flush()
+	return b.Flush()
+}
+
+// arenaOrigin describes the location of the function that created an arena,
+// given a return PC in it, skipping any inlined frames of the runtime and the
+// arena package.
+func arenaOrigin(pc uintptr) string {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") && !strings.HasPrefix(f.Function, "arena.") || !more {
+			return fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+		}
+	}
+}
+
 // countThreadCreate returns the size of the current ThreadCreateProfile.
 func countThreadCreate() int {
 	n, _ := runtime.ThreadCreateProfile(nil)
@@ -749,7 +988,11 @@ func writeGoroutine(w io.Writer, debug int) error {
 	if debug >= 2 {
 		return writeGoroutineStacks(w)
 	}
-	return writeRuntimeProfile(w, debug, "goroutine", pprof_goroutineProfileWithLabels)
+	if !pprof_goroutineAllocBytes() {
+		return writeRuntimeProfile(w, debug, "goroutine", pprof_goroutineProfileWithLabels)
+	}
+	p, labels := fetchRuntimeProfile(pprof_goroutineProfileWithLabels)
+	return printCountProfile(w, debug, "goroutine", &goroutineProfileRecords{runtimeProfile{p, labels}})
 }
 
 func writeGoroutineStacks(w io.Writer) error {
@@ -774,6 +1017,11 @@ func writeGoroutineStacks(w io.Writer) error {
 }
 
 func writeRuntimeProfile(w io.Writer, debug int, name string, fetch func([]profilerecord.StackRecord, []unsafe.Pointer) (int, bool)) error {
+	p, labels := fetchRuntimeProfile(fetch)
+	return printCountProfile(w, debug, name, &runtimeProfile{p, labels})
+}
+
+func fetchRuntimeProfile(fetch func([]profilerecord.StackRecord, []unsafe.Pointer) (int, bool)) ([]profilerecord.StackRecord, []unsafe.Pointer) {
 	// Find out how many records there are (fetch(nil)),
 	// allocate that many records, and get the data.
 	// There's a race—more records might be added between
@@ -797,8 +1045,7 @@ func writeRuntimeProfile(w io.Writer, debug int, name string, fetch func([]profi
 		}
 		// Profile grew; try again.
 	}
-
-	return printCountProfile(w, debug, name, &runtimeProfile{p, labels})
+	return p, labels
 }
 
 type runtimeProfile struct {
@@ -810,6 +1057,13 @@ func (p *runtimeProfile) Len() int              { return len(p.stk) }
 func (p *runtimeProfile) Stack(i int) []uintptr { return p.stk[i].Stack }
 func (p *runtimeProfile) Label(i int) *labelMap { return (*labelMap)(p.labels[i]) }
 
+// goroutineProfileRecords is a runtimeProfile of goroutines.
+type goroutineProfileRecords struct {
+	runtimeProfile
+}
+
+func (p *goroutineProfileRecords) AllocBytes(i int) uint64 { return p.stk[i].AllocBytes }
+
 var cpu struct {
 	sync.Mutex
 	profiling bool
@@ -851,6 +1105,8 @@ func StartCPUProfile(w io.Writer) error {
 	cpu.profiling = true
 	runtime.SetCPUProfileRate(hz)
 	go profileWriter(w)
+	// Let runtime/debug.Shutdown finish the profile.
+	runtime_setShutdownHook(StopCPUProfile)
 	return nil
 }
 
@@ -920,25 +1176,28 @@ func writeMutex(w io.Writer, debug int) error {
 	return writeProfileInternal(w, debug, "mutex", pprof_mutexProfileInternal)
 }
 
+// countOffCPU returns the number of records in the off-CPU profile.
+func countOffCPU() int {
+	n, _ := pprof_offCPUProfileInternal(nil)
+	return n
+}
+
+// writeOffCPU writes the current off-CPU profile to w.
+func writeOffCPU(w io.Writer, debug int) error {
+	return writeProfileInternal(w, debug, "offcpu", pprof_offCPUProfileInternal)
+}
+
 // writeProfileInternal writes the current blocking or mutex profile depending on the passed parameters.
 func writeProfileInternal(w io.Writer, debug int, name string, runtimeProfile func([]profilerecord.BlockProfileRecord) (int, bool)) error {
-	var p []profilerecord.BlockProfileRecord
-	n, ok := runtimeProfile(nil)
-	for {
-		p = make([]profilerecord.BlockProfileRecord, n+50)
-		n, ok = runtimeProfile(p)
-		if ok {
-			p = p[:n]
-			break
-		}
-	}
+	p := readBlockProfile(runtimeProfile)
 
 	slices.SortFunc(p, func(a, b profilerecord.BlockProfileRecord) int {
 		return cmp.Compare(b.Cycles, a.Cycles)
 	})
 
 	if debug <= 0 {
-		return printCountCycleProfile(w, "contentions", "delay", p)
+		countName, cycleName := cycleSampleNames(name)
+		return printCountCycleProfile(w, countName, cycleName, p)
 	}
 
 	b := bufio.NewWriter(w)
@@ -971,21 +1230,55 @@ func writeProfileInternal(w io.Writer, debug int, name string, runtimeProfile fu
 	return b.Flush()
 }
 
+// cycleSampleNames returns the names of the samples in the protocol
+// buffer form of the blocking, mutex or off-CPU profile, named name.
+func cycleSampleNames(name string) (countName, cycleName string) {
+	if name == "offcpu" {
+		return "events", "off_cpu"
+	}
+	return "contentions", "delay"
+}
+
+// readBlockProfile returns the records of the current blocking or mutex
+// profile, as read by runtimeProfile.
+func readBlockProfile(runtimeProfile func([]profilerecord.BlockProfileRecord) (int, bool)) []profilerecord.BlockProfileRecord {
+	n, ok := runtimeProfile(nil)
+	for {
+		p := make([]profilerecord.BlockProfileRecord, n+50)
+		n, ok = runtimeProfile(p)
+		if ok {
+			return p[:n]
+		}
+	}
+}
+
 //go:linkname pprof_goroutineProfileWithLabels runtime.pprof_goroutineProfileWithLabels
 func pprof_goroutineProfileWithLabels(p []profilerecord.StackRecord, labels []unsafe.Pointer) (n int, ok bool)
 
+// pprof_goroutineAllocBytes reports whether the runtime counts the bytes
+// each goroutine allocates.
+//
+//go:linkname pprof_goroutineAllocBytes runtime.pprof_goroutineAllocBytes
+func pprof_goroutineAllocBytes() bool
+
 //go:linkname pprof_cyclesPerSecond runtime/pprof.runtime_cyclesPerSecond
 func pprof_cyclesPerSecond() int64
 
 //go:linkname pprof_memProfileInternal runtime.pprof_memProfileInternal
 func pprof_memProfileInternal(p []profilerecord.MemProfileRecord, inuseZero bool) (n int, ok bool)
 
+//go:linkname pprof_arenaAllocProfile runtime.pprof_arenaAllocProfile
+func pprof_arenaAllocProfile(p []profilerecord.ArenaAllocRecord) (n int, ok bool)
+
 //go:linkname pprof_blockProfileInternal runtime.pprof_blockProfileInternal
 func pprof_blockProfileInternal(p []profilerecord.BlockProfileRecord) (n int, ok bool)
 
 //go:linkname pprof_mutexProfileInternal runtime.pprof_mutexProfileInternal
 func pprof_mutexProfileInternal(p []profilerecord.BlockProfileRecord) (n int, ok bool)
 
+//go:linkname pprof_offCPUProfileInternal runtime.pprof_offCPUProfileInternal
+func pprof_offCPUProfileInternal(p []profilerecord.BlockProfileRecord) (n int, ok bool)
+
 //go:linkname pprof_threadCreateInternal runtime.pprof_threadCreateInternal
 func pprof_threadCreateInternal(p []profilerecord.StackRecord) (n int, ok bool)
 
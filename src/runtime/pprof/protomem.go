@@ -61,6 +61,99 @@ func writeHeapProto(w io.Writer, p []profilerecord.MemProfileRecord, rate int64,
 			if blockSize != 0 {
 				b.pbLabel(tagSample_Label, "bytes", "", blockSize)
 			}
+			if r.Type != "" {
+				b.pbLabel(tagSample_Label, "type", r.Type, 0)
+			}
+		})
+	}
+	b.build()
+	return nil
+}
+
+// writeLifetimeProto writes the lifetime profile p, sampled at the given
+// rate, to w in protobuf format.
+func writeLifetimeProto(w io.Writer, p []profilerecord.MemProfileRecord, rate int64) error {
+	b := newProfileBuilder(w)
+	b.pbValueType(tagProfile_PeriodType, "space", "bytes")
+	b.pb.int64Opt(tagProfile_Period, rate)
+	b.pbValueType(tagProfile_SampleType, "free_objects", "count")
+	b.pbValueType(tagProfile_SampleType, "free_space", "bytes")
+	b.pbValueType(tagProfile_SampleType, "lifetime", "nanoseconds")
+	b.pbValueType(tagProfile_SampleType, "space_time", "byte-seconds")
+	b.pb.int64Opt(tagProfile_DefaultSampleType, b.stringIndex("space_time"))
+
+	values := []int64{0, 0, 0, 0}
+	var locs []uint64
+	for _, r := range p {
+		stk := r.Stack
+		for i, addr := range stk {
+			if f := runtime.FuncForPC(addr); f != nil && strings.HasPrefix(f.Name(), "runtime.") {
+				continue
+			}
+			stk = stk[i:]
+			break
+		}
+		locs = b.appendLocsForStack(locs[:0], stk)
+		if len(locs) == 0 {
+			locs = b.appendLocsForStack(locs[:0], r.Stack)
+		}
+
+		// Scale the lifetime like the object count, and compute the
+		// space-time from the scaled values so that it doesn't
+		// overflow.
+		values[0], values[1] = scaleHeapSample(r.FreeObjects, r.FreeBytes, rate)
+		scale := float64(values[0]) / float64(r.FreeObjects)
+		blockSize := r.FreeBytes / r.FreeObjects
+		lifetime := float64(r.FreeLifetime) * scale
+		values[2] = int64(lifetime)
+		values[3] = int64(lifetime * float64(blockSize) / 1e9)
+		b.pbSample(values, locs, func() {
+			b.pbLabel(tagSample_Label, "bytes", "", blockSize)
+			if r.Type != "" {
+				b.pbLabel(tagSample_Label, "type", r.Type, 0)
+			}
+		})
+	}
+	b.build()
+	return nil
+}
+
+// writeArenaAllocsProto writes the arena allocation profile p, sampled at
+// the given rate, to w in protobuf format.
+func writeArenaAllocsProto(w io.Writer, p []profilerecord.ArenaAllocRecord, rate int64) error {
+	b := newProfileBuilder(w)
+	b.pbValueType(tagProfile_PeriodType, "space", "bytes")
+	b.pb.int64Opt(tagProfile_Period, rate)
+	b.pbValueType(tagProfile_SampleType, "alloc_objects", "count")
+	b.pbValueType(tagProfile_SampleType, "alloc_space", "bytes")
+
+	values := []int64{0, 0}
+	var locs []uint64
+	for _, r := range p {
+		// Hide the frames of the runtime and the arena package that
+		// performed the allocation, unless that leaves nothing.
+		stk := r.Stack
+		for i, addr := range stk {
+			if f := runtime.FuncForPC(addr); f != nil && (strings.HasPrefix(f.Name(), "runtime.") || strings.HasPrefix(f.Name(), "arena.")) {
+				continue
+			}
+			stk = stk[i:]
+			break
+		}
+		locs = b.appendLocsForStack(locs[:0], stk)
+		if len(locs) == 0 {
+			locs = b.appendLocsForStack(locs[:0], r.Stack)
+		}
+
+		values[0], values[1] = scaleHeapSample(r.AllocObjects, r.AllocBytes, rate)
+		var origin string
+		if r.Origin != 0 {
+			origin = arenaOrigin(r.Origin)
+		}
+		b.pbSample(values, locs, func() {
+			if origin != "" {
+				b.pbLabel(tagSample_Label, "arena", origin, 0)
+			}
 		})
 	}
 	b.build()
@@ -222,3 +222,58 @@ func TestGenericsInlineLocations(t *testing.T) {
 		t.Errorf("expected a location with at least 3 functions\n%s\ngot\n%s\n", expectedLocation, actual)
 	}
 }
+
+type typedAllocA [48]byte
+type typedAllocB [48]byte
+
+var typedAllocSink []any
+
+// typedAllocFunc has the same shape for typedAllocA and typedAllocB,
+// so their allocations have the same stacks.
+//
+//go:noinline
+func typedAllocFunc[T any]() *T {
+	return new(T)
+}
+
+func TestHeapProtoType(t *testing.T) {
+	t.Setenv("GODEBUG", "memprofiletype=1")
+	previousRate := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() {
+		runtime.MemProfileRate = previousRate
+		typedAllocSink = nil
+	}()
+	for range 10 {
+		typedAllocSink = append(typedAllocSink, typedAllocFunc[typedAllocA]())
+		typedAllocSink = append(typedAllocSink, typedAllocFunc[typedAllocB]())
+	}
+
+	runtime.GC()
+	buf := bytes.NewBuffer(nil)
+	if err := WriteHeapProfile(buf); err != nil {
+		t.Fatalf("writing profile: %v", err)
+	}
+	p, err := profile.Parse(buf)
+	if err != nil {
+		t.Fatalf("profile.Parse: %v", err)
+	}
+
+	types := make(map[string]bool)
+	for _, s := range p.Sample {
+		if len(s.Location) == 0 {
+			continue
+		}
+		if f := s.Location[0].Line[0].Function.Name; !strings.HasPrefix(f, "runtime/pprof.typedAllocFunc[") {
+			continue
+		}
+		for _, typ := range s.Label["type"] {
+			types[typ] = true
+		}
+	}
+	for _, want := range []string{"pprof.typedAllocA", "pprof.typedAllocB"} {
+		if !types[want] {
+			t.Errorf("no samples of type %s in typedAllocFunc; got types %v\n%s", want, types, strings.Join(profileToStrings(p), "\n"))
+		}
+	}
+}
@@ -205,6 +205,7 @@ func suspendG(gp *g) suspendGState {
 			gp.preemptStop = true
 			gp.preempt = true
 			gp.stackguard0 = stackPreempt
+			expireCritical(gp)
 
 			// Prepare for asynchronous preemption.
 			asyncM2 := gp.m
@@ -341,7 +342,7 @@ func init() {
 // queued for gp.
 func wantAsyncPreempt(gp *g) bool {
 	// Check both the G and the P.
-	return (gp.preempt || gp.m.p != 0 && gp.m.p.ptr().preempt) && readgstatus(gp)&^_Gscan == _Grunning
+	return (gp.preempt || gp.m.p != 0 && gp.m.p.ptr().preempt) && readgstatus(gp)&^_Gscan == _Grunning && !inCritical(gp)
 }
 
 // isAsyncSafePoint reports whether gp at instruction PC is an
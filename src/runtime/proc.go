@@ -431,8 +431,15 @@ func gopark(unlockf func(*g, unsafe.Pointer) bool, lock unsafe.Pointer, reason w
 	mp.waitTraceBlockReason = traceReason
 	mp.waitTraceSkip = traceskip
 	releasem(mp)
+	var t0 int64
+	if atomic.Load64(&offcpuprofilerate) > 0 {
+		t0 = cputicks()
+	}
 	// can't do anything that might move the G between Ms here.
 	mcall(park_m)
+	if t0 != 0 {
+		offcpuevent(cputicks()-t0, 2)
+	}
 }
 
 // Puts the current goroutine into a waiting state and unlocks the lock.
@@ -755,7 +762,8 @@ func cpuinit(env string) {
 
 // getGodebugEarly extracts the environment variable GODEBUG from the environment on
 // Unix-like operating systems and returns it. This function exists to extract GODEBUG
-// early before much of the runtime is initialized.
+// early before much of the runtime is initialized. It doesn't allocate, so it can be
+// called before mallocinit.
 func getGodebugEarly() string {
 	const prefix = "GODEBUG="
 	var env string
@@ -774,7 +782,7 @@ func getGodebugEarly() string {
 			s := unsafe.String(p, findnull(p))
 
 			if stringslite.HasPrefix(s, prefix) {
-				env = gostring(p)[len(prefix):]
+				env = s[len(prefix):]
 				break
 			}
 		}
@@ -802,6 +810,8 @@ func schedinit() {
 	lockInit(&reflectOffs.lock, lockRankReflectOffs)
 	lockInit(&finlock, lockRankFin)
 	lockInit(&cpuprof.lock, lockRankCpuprof)
+	lockInit(&memPressure.lock, lockRankMemPressure)
+	lockInit(&goroutineLimit.lock, lockRankGoroutineLimit)
 	allocmLock.init(lockRankAllocmR, lockRankAllocmRInternal, lockRankAllocmW)
 	execLock.init(lockRankExecR, lockRankExecRInternal, lockRankExecW)
 	traceLockInit()
@@ -826,10 +836,12 @@ func schedinit() {
 	ticks.init() // run as early as possible
 	moduledataverify()
 	stackinit()
-	mallocinit()
 	godebug := getGodebugEarly()
+	sizeClassesInit(godebug)   // must run before mallocinit
+	heapRandomizeInit(godebug) // must run before mallocinit
+	randinit()                 // must run before mallocinit, alginit, mcommoninit
+	mallocinit()
 	cpuinit(godebug) // must run before alginit
-	randinit()       // must run before alginit, mcommoninit
 	alginit()        // maps, hash, rand must not be used before this call
 	mcommoninit(gp.m, -1)
 	modulesinit()   // provides activeModules
@@ -845,6 +857,7 @@ func schedinit() {
 	secure()
 	checkfds()
 	parsedebugvars()
+	heapTypesInit()
 	gcinit()
 
 	// Allocate stack space that can be used when crashing due to bad stack
@@ -864,12 +877,10 @@ func schedinit() {
 	// mcommoninit runs before parsedebugvars, so init profstacks again.
 	mProfStackInit(gp.m)
 
+	spinInit()
+	procs := initMaxProcs()
 	lock(&sched.lock)
 	sched.lastpoll.Store(nanotime())
-	procs := ncpu
-	if n, ok := atoi32(gogetenv("GOMAXPROCS")); ok && n > 0 {
-		procs = n
-	}
 	if procresize(procs) != nil {
 		throw("unknown runnable goroutine during bootstrap")
 	}
@@ -1047,8 +1058,10 @@ func ready(gp *g, traceskip int, next bool) {
 		trace.GoUnpark(gp, traceskip)
 		traceRelease(trace)
 	}
-	runqput(mp.p.ptr(), gp, next)
-	wakep()
+	if gp.affinity == 0 || !runqputAffinity(mp.p.ptr(), gp) {
+		runqputClass(mp.p.ptr(), gp, next)
+	}
+	wakepReady(mp.p.ptr())
 	releasem(mp)
 }
 
@@ -1222,6 +1235,14 @@ func casgstatus(gp *g, oldval, newval uint32) {
 		}
 	}
 
+	// now is the time of the transition, read at most once, and only if
+	// something below needs it.
+	var now int64
+	if oldval == _Grunning || newval == _Grunning {
+		now = cpuTimeNow()
+		gp.accountCPUTime(oldval, newval, now)
+	}
+
 	if oldval == _Grunning {
 		// Track every gTrackingPeriod time a goroutine transitions out of running.
 		if casgstatusAlwaysTrack || gp.trackingSeq%gTrackingPeriod == 0 {
@@ -1229,6 +1250,28 @@ func casgstatus(gp *g, oldval, newval uint32) {
 		}
 		gp.trackingSeq++
 	}
+	aging := schedAging.Load() != 0
+	if newval == _Grunnable {
+		// Record when gp became runnable, for scheduler aging. Without
+		// aging, only tracked goroutines are timestamped, which is
+		// enough for the longest runnable wait metric to be an estimate.
+		gp.runnableSince = 0
+		if aging || gp.tracking {
+			if now == 0 {
+				now = nanotime()
+			}
+			gp.runnableSince = now
+		}
+	}
+	if oldval == _Grunnable && newval == _Grunning && aging && gp.runnableSince != 0 {
+		if now == 0 {
+			now = nanotime()
+		}
+		gp.m.p.ptr().runLatency.record(now - gp.runnableSince)
+	}
+	if schedEvents.enabled.Load() {
+		recordSchedEvent(gp, oldval, newval)
+	}
 	if !gp.tracking {
 		return
 	}
@@ -1243,7 +1286,9 @@ func casgstatus(gp *g, oldval, newval uint32) {
 		// We transitioned out of runnable, so measure how much
 		// time we spent in this state and add it to
 		// runnableTime.
-		now := nanotime()
+		if now == 0 {
+			now = nanotime()
+		}
 		gp.runnableTime += now - gp.trackingStamp
 		gp.trackingStamp = 0
 	case _Gwaiting:
@@ -1256,7 +1301,9 @@ func casgstatus(gp *g, oldval, newval uint32) {
 		// a more representative estimate of the absolute value.
 		// gTrackingPeriod also represents an accurate sampling period
 		// because we can only enter this state from _Grunning.
-		now := nanotime()
+		if now == 0 {
+			now = nanotime()
+		}
 		sched.totalMutexWaitTime.Add((now - gp.trackingStamp) * gTrackingPeriod)
 		gp.trackingStamp = 0
 	}
@@ -1267,13 +1314,14 @@ func casgstatus(gp *g, oldval, newval uint32) {
 			break
 		}
 		// Blocking on a lock. Write down the timestamp.
-		now := nanotime()
+		if now == 0 {
+			now = nanotime()
+		}
 		gp.trackingStamp = now
 	case _Grunnable:
 		// We just transitioned into runnable, so record what
 		// time that happened.
-		now := nanotime()
-		gp.trackingStamp = now
+		gp.trackingStamp = gp.runnableSince
 	case _Grunning:
 		// We're transitioning into running, so turn off
 		// tracking and record how much time we spent in
@@ -1334,6 +1382,7 @@ func casGToPreemptScan(gp *g, old, new uint32) {
 	acquireLockRankAndM(lockRankGscan)
 	for !gp.atomicstatus.CompareAndSwap(_Grunning, _Gscan|_Gpreempted) {
 	}
+	gp.accountCPUTime(_Grunning, _Gpreempted, cpuTimeNow())
 }
 
 // casGFromPreempted attempts to transition gp from _Gpreempted to
@@ -1371,6 +1420,8 @@ const (
 	stwForTestReadMemStatsSlow                      // "ReadMemStatsSlow (test)"
 	stwForTestPageCachePagesLeaked                  // "PageCachePagesLeaked (test)"
 	stwForTestResetDebugLog                         // "ResetDebugLog (test)"
+	stwReadHeapFragmentation                        // "read heap fragmentation"
+	stwSetAllocSampler                              // "set alloc sampler"
 )
 
 func (r stwReason) String() string {
@@ -1402,6 +1453,8 @@ var stwReasonStrings = [...]string{
 	stwForTestReadMemStatsSlow:     "ReadMemStatsSlow (test)",
 	stwForTestPageCachePagesLeaked: "PageCachePagesLeaked (test)",
 	stwForTestResetDebugLog:        "ResetDebugLog (test)",
+	stwReadHeapFragmentation:       "read heap fragmentation",
+	stwSetAllocSampler:             "set alloc sampler",
 }
 
 // worldStop provides context from the stop-the-world required by the
@@ -1717,6 +1770,11 @@ func startTheWorldWithSema(now int64, w worldStop) int64 {
 	totalTime := now - w.startedStopping
 	if w.reason.isGC() {
 		sched.stwTotalTimeGC.record(totalTime)
+		if w.reason == stwGCSweepTerm {
+			sched.stwTotalTimeSweepTerm.record(totalTime)
+		} else {
+			sched.stwTotalTimeMarkTerm.record(totalTime)
+		}
 	} else {
 		sched.stwTotalTimeOther.record(totalTime)
 	}
@@ -2797,6 +2855,11 @@ func newm1(mp *m) {
 		if _cgo_thread_start == nil {
 			throw("_cgo_thread_start missing")
 		}
+		if debug.cgothreadstack > 0 {
+			// Ask for the stack size set by GODEBUG. The new thread
+			// replaces stack.hi with the size it gets; see mstart0.
+			mp.g0.stack.hi = alignUp(uintptr(debug.cgothreadstack), physPageSize)
+		}
 		ts.g.set(mp.g0)
 		ts.tls = (*uint64)(unsafe.Pointer(&mp.tls[0]))
 		ts.fn = unsafe.Pointer(abi.FuncPCABI0(mstart))
@@ -3020,6 +3083,12 @@ func handoffp(pp *p) {
 	// handoffp must start an M in any situation where
 	// findrunnable would return a G to run on pp.
 
+	if pp.bgbatchn != 0 {
+		lock(&sched.lock)
+		bgbatchflush(pp)
+		unlock(&sched.lock)
+	}
+
 	// if it has local work, start it straight away
 	if !runqempty(pp) || sched.runqsize != 0 {
 		startm(pp, false, false)
@@ -3234,9 +3303,13 @@ func execute(gp *g, inheritTime bool) {
 	gp.waitsince = 0
 	gp.preempt = false
 	gp.stackguard0 = gp.stack.lo + stackGuard
+	if gp.affinity != 0 {
+		gp.affinity = uint16(mp.p.ptr().id + 1)
+	}
 	if !inheritTime {
 		mp.p.ptr().schedtick++
 	}
+	mp.p.ptr().runningClass.Store(gp.schedClass)
 
 	// Check whether the profiler needs to be turned on or off.
 	hz := sched.profilehz
@@ -3264,6 +3337,10 @@ func findRunnable() (gp *g, inheritTime, tryWakeP bool) {
 	// findrunnable would return a G to run, handoffp must start
 	// an M.
 
+	// spinUntil is when this M stops spinning for lack of work,
+	// if the spin policy keeps it spinning. See spinpolicy.go.
+	var spinUntil int64
+
 top:
 	pp := mp.p.ptr()
 	if sched.gcwaiting.Load() {
@@ -3273,6 +3350,15 @@ top:
 	if pp.runSafePointFn != 0 {
 		runSafePointFn()
 	}
+	if pp.bgbatchn != 0 {
+		lock(&sched.lock)
+		n := bgbatchflush(pp)
+		unlock(&sched.lock)
+		if n > 1 && sched.npidle.Load() != 0 {
+			// Let idle Ps run the rest.
+			wakep()
+		}
+	}
 
 	// now and pollUntil are saved for work stealing later,
 	// which may steal timers. It's important that between now
@@ -3305,8 +3391,9 @@ top:
 
 	// Check the global runnable queue once in a while to ensure fairness.
 	// Otherwise two goroutines can completely occupy the local runqueue
-	// by constantly respawning each other.
-	if pp.schedtick%61 == 0 && sched.runqsize > 0 {
+	// by constantly respawning each other. Check it first while it has
+	// goroutines that have waited too long; see schedaging.go.
+	if (pp.schedtick%61 == 0 || sched.agedrunq.Load() > 0) && sched.runqsize > 0 {
 		lock(&sched.lock)
 		gp := globrunqget(pp, 1)
 		unlock(&sched.lock)
@@ -3314,6 +3401,17 @@ top:
 			return gp, false, false
 		}
 	}
+	// Likewise, check the global background queue once in a while,
+	// so that background goroutines make progress even if there's
+	// always other work.
+	if pp.schedtick%251 == 0 && sched.bgrunqsize > 0 {
+		lock(&sched.lock)
+		gp := bgrunqget()
+		unlock(&sched.lock)
+		if gp != nil {
+			return gp, false, false
+		}
+	}
 
 	// Wake up the finalizer G.
 	if fingStatus.Load()&(fingWait|fingWake) == fingWait|fingWake {
@@ -3325,13 +3423,16 @@ top:
 		asmcgocall(*cgo_yield, nil)
 	}
 
-	// local runq
+	// local runq, including a goroutine with affinity for pp that
+	// another P made runnable
+	affinityget(pp)
 	if gp, inheritTime := runqget(pp); gp != nil {
 		return gp, inheritTime, false
 	}
 
-	// global runq
-	if sched.runqsize != 0 {
+	// global runq, leaving background and idle goroutines
+	// until there's nothing else to run
+	if sched.runqsize > sched.bgrunqsize+sched.idlerunqsize {
 		lock(&sched.lock)
 		gp := globrunqget(pp, 0)
 		unlock(&sched.lock)
@@ -3364,10 +3465,11 @@ top:
 
 	// Spinning Ms: steal work from other Ps.
 	//
-	// Limit the number of spinning Ms to half the number of busy Ps.
-	// This is necessary to prevent excessive CPU consumption when
-	// GOMAXPROCS>>1 but the program parallelism is low.
-	if mp.spinning || 2*sched.nmspinning.Load() < gomaxprocs-sched.npidle.Load() {
+	// Limit the number of spinning Ms to a portion of the number of
+	// busy Ps, half by default. This is necessary to prevent excessive
+	// CPU consumption when GOMAXPROCS>>1 but the program parallelism
+	// is low. See spinpolicy.go.
+	if mp.spinning || canSpin() {
 		if !mp.spinning {
 			mp.becomeSpinning()
 		}
@@ -3388,6 +3490,17 @@ top:
 			// Earlier timer to wait for.
 			pollUntil = w
 		}
+
+		// Keep spinning for as long as the spin policy asks.
+		if spinNS := spin.spinNS.Load(); spinNS > 0 {
+			if spinUntil == 0 {
+				spinUntil = now + spinNS
+			}
+			if now < spinUntil {
+				osyield()
+				goto top
+			}
+		}
 	}
 
 	// We have nothing to do.
@@ -3445,6 +3558,7 @@ top:
 		unlock(&sched.lock)
 		goto top
 	}
+	bgbatchflush(pp)
 	if sched.runqsize != 0 {
 		gp := globrunqget(pp, 0)
 		unlock(&sched.lock)
@@ -3935,6 +4049,14 @@ func injectglist(glist *gList) {
 		return
 	}
 
+	if schedClassUsed.Load() {
+		// Background and idle goroutines don't go on the local
+		// run queue.
+		lock(&sched.lock)
+		qsize = int(globrunqputbackground(&q, int32(qsize)))
+		unlock(&sched.lock)
+	}
+
 	npidle := int(sched.npidle.Load())
 	var (
 		globq gQueue
@@ -3954,6 +4076,7 @@ func injectglist(glist *gList) {
 
 	if !q.empty() {
 		runqputbatch(pp, &q, qsize)
+		preemptIdle(pp)
 	}
 
 	// Some P's might have become idle after we loaded `sched.npidle`
@@ -4256,7 +4379,7 @@ func goyield_m(gp *g) {
 		traceRelease(trace)
 	}
 	dropg()
-	runqput(pp, gp, false)
+	runqputClass(pp, gp, false)
 	schedule()
 }
 
@@ -4287,6 +4410,11 @@ func gdestroy(gp *g) {
 	gcController.addScannableStack(pp, -int64(gp.stack.hi-gp.stack.lo))
 	if isSystemGoroutine(gp, false) {
 		sched.ngsys.Add(-1)
+	} else {
+		pp.goroutineLifetimes.record(nanotime() - gp.createdAt)
+	}
+	if gp.schedClass == uint8(schedClassIdle) {
+		idleGoroutines.Add(-1)
 	}
 	gp.m = nil
 	locked := gp.lockedm != 0
@@ -4300,7 +4428,9 @@ func gdestroy(gp *g) {
 	gp.waitreason = waitReasonZero
 	gp.param = nil
 	gp.labels = nil
+	gp.locals = nil
 	gp.timer = nil
+	gp.allocBytes = 0
 
 	if gcBlackenEnabled != 0 && gp.gcAssistBytes > 0 {
 		// Flush assist credit to the global pool. This gives
@@ -4316,6 +4446,9 @@ func gdestroy(gp *g) {
 
 	if GOARCH == "wasm" { // no threads yet on wasm
 		gfput(pp, gp)
+		if goroutineLimit.max.Load() != 0 {
+			goroutineLimitExit()
+		}
 		return
 	}
 
@@ -4327,6 +4460,9 @@ func gdestroy(gp *g) {
 		throw("exited a goroutine internally locked to the OS thread")
 	}
 	gfput(pp, gp)
+	if goroutineLimit.max.Load() != 0 {
+		goroutineLimitExit()
+	}
 	if locked {
 		// The goroutine may have locked this thread because
 		// it put it in an unusual kernel state. Kill it
@@ -4421,6 +4557,9 @@ func reentersyscall(pc, sp, bp uintptr) {
 	gp.syscallsp = sp
 	gp.syscallpc = pc
 	gp.syscallbp = bp
+	if atomic.Load64(&offcpuprofilerate) > 0 {
+		gp.syscallStart = cputicks()
+	}
 	casgstatus(gp, _Grunning, _Gsyscall)
 	if staticLockRanking {
 		// When doing static lock ranking casgstatus can call
@@ -4465,6 +4604,7 @@ func reentersyscall(pc, sp, bp uintptr) {
 	gp.m.syscalltick = gp.m.p.ptr().syscalltick
 	pp := gp.m.p.ptr()
 	pp.m = 0
+	pp.syscallNoBlock = gp.m.syscallNoBlock
 	gp.m.oldp.set(pp)
 	gp.m.p = 0
 	atomic.Store(&pp.status, _Psyscall)
@@ -4499,6 +4639,23 @@ func entersyscall() {
 	reentersyscall(sys.GetCallerPC(), sys.GetCallerSP(), fp)
 }
 
+// entersyscallnoblock is like entersyscall, for system calls that are
+// known not to block, such as clock_gettime or a futex wake. sysmon
+// doesn't retake the P from such a system call, and hand it off to
+// another M, unless the call turns out to take longer than
+// forcePreemptNS. The goroutine gets its P back in exitsyscall without
+// the cost of a handoff, which matters in tight loops over such calls.
+//
+// This is exported via linkname to the syscall package and x/sys.
+//
+//go:nosplit
+//go:linkname entersyscallnoblock
+func entersyscallnoblock() {
+	fp := getcallerfp()
+	getg().m.syscallNoBlock = true
+	reentersyscall(sys.GetCallerPC(), sys.GetCallerSP(), fp)
+}
+
 func entersyscall_sysmon() {
 	lock(&sched.lock)
 	if sched.sysmonwait.Load() {
@@ -4577,6 +4734,9 @@ func entersyscallblock() {
 			throw("entersyscallblock")
 		})
 	}
+	if atomic.Load64(&offcpuprofilerate) > 0 {
+		gp.syscallStart = cputicks()
+	}
 	casgstatus(gp, _Grunning, _Gsyscall)
 	if gp.syscallsp < gp.stack.lo || gp.stack.hi < gp.syscallsp {
 		systemstack(func() {
@@ -4637,6 +4797,7 @@ func exitsyscall() {
 	}
 
 	gp.waitsince = 0
+	gp.m.syscallNoBlock = false
 	oldp := gp.m.oldp.ptr()
 	gp.m.oldp = 0
 	if exitsyscallfast(oldp) {
@@ -4688,6 +4849,9 @@ func exitsyscall() {
 			gp.stackguard0 = gp.stack.lo + stackGuard
 		}
 		gp.throwsplit = false
+		if gp.syscallStart != 0 {
+			exitsyscallOffCPU(gp)
+		}
 
 		if sched.disable.user && !schedEnabled(gp) {
 			// Scheduling of this goroutine is disabled.
@@ -4711,6 +4875,17 @@ func exitsyscall() {
 	gp.syscallsp = 0
 	gp.m.p.ptr().syscalltick++
 	gp.throwsplit = false
+	if gp.syscallStart != 0 {
+		exitsyscallOffCPU(gp)
+	}
+}
+
+// exitsyscallOffCPU records the system call gp, the current goroutine,
+// returned from in the off-CPU profile, for exitsyscall.
+func exitsyscallOffCPU(gp *g) {
+	cycles := cputicks() - gp.syscallStart
+	gp.syscallStart = 0
+	offcpuevent(cycles, 3)
 }
 
 //go:nosplit
@@ -4985,14 +5160,17 @@ func malg(stacksize int32) *g {
 func newproc(fn *funcval) {
 	gp := getg()
 	pc := sys.GetCallerPC()
+	if goroutineLimit.max.Load() != 0 {
+		checkGoroutineLimit(pc)
+	}
 	systemstack(func() {
 		newg := newproc1(fn, gp, pc, false, waitReasonZero)
 
 		pp := getg().m.p.ptr()
-		runqput(pp, newg, true)
+		runqputClass(pp, newg, true)
 
 		if mainStarted {
-			wakep()
+			wakepReady(pp)
 		}
 	})
 }
@@ -5044,12 +5222,24 @@ func newproc1(fn *funcval, callergp *g, callerpc uintptr, parked bool, waitreaso
 	newg.gopc = callerpc
 	newg.ancestors = saveAncestors(callergp)
 	newg.startpc = fn.fn
+	newg.schedClass = 0
+	newg.affinity = 0
+	newg.cpuTime = 0
+	newg.critical.Store(criticalNone)
 	if isSystemGoroutine(newg, false) {
 		sched.ngsys.Add(1)
 	} else {
-		// Only user goroutines inherit pprof labels.
+		pp.goroutinesCreated.Add(1)
+		// Only user goroutines inherit the scheduling class.
+		newg.schedClass = callergp.schedClass
+		if newg.schedClass == uint8(schedClassIdle) {
+			idleGoroutines.Add(1)
+		}
+		// Only user goroutines inherit pprof labels and
+		// goroutine-local values.
 		if mp.curg != nil {
 			newg.labels = mp.curg.labels
+			newg.locals = mp.curg.locals
 		}
 		if goroutineProfile.active {
 			// A concurrent goroutine profile is running. It should include
@@ -5074,7 +5264,6 @@ func newproc1(fn *funcval, callergp *g, callerpc uintptr, parked bool, waitreaso
 		status = _Gwaiting
 		newg.waitreason = waitreason
 	}
-	casgstatus(newg, _Gdead, status)
 	if pp.goidcache == pp.goidcacheend {
 		// Sched.goidgen is the last allocated id,
 		// this batch must be [sched.goidgen+1, sched.goidgen+GoidCacheBatch].
@@ -5085,6 +5274,12 @@ func newproc1(fn *funcval, callergp *g, callerpc uintptr, parked bool, waitreaso
 	}
 	newg.goid = pp.goidcache
 	pp.goidcache++
+	casgstatus(newg, _Gdead, status)
+	// casgstatus may just have recorded when newg became runnable.
+	newg.createdAt = newg.runnableSince
+	if newg.createdAt == 0 {
+		newg.createdAt = nanotime()
+	}
 	newg.trace.reset()
 	if trace.ok() {
 		trace.GoCreate(newg, newg.startpc, parked)
@@ -5343,7 +5538,8 @@ func dounlockOSThread() {
 // UnlockOSThread undoes an earlier call to LockOSThread.
 // If this drops the number of active LockOSThread calls on the
 // calling goroutine to zero, it unwires the calling goroutine from
-// its fixed operating system thread.
+// its fixed operating system thread, and restores any scheduling
+// attributes of the thread that [LockOSThreadAttr] changed.
 // If there are no active LockOSThread calls, this is a no-op.
 //
 // Before calling UnlockOSThread, the caller must ensure that the OS
@@ -5359,6 +5555,13 @@ func UnlockOSThread() {
 	if gp.m.lockedExt == 0 {
 		return
 	}
+	if gp.m.lockedExt == 1 && gp.m.threadAttr != nil && !restoreThreadAttr() {
+		// The thread isn't fit to run other goroutines. Leave the
+		// goroutine wired to it, so that it exits with the goroutine.
+		// The goroutine is still wired to the thread while
+		// restoreThreadAttr splits the stack, so it can't move.
+		return
+	}
 	gp.m.lockedExt--
 	dounlockOSThread()
 }
@@ -5629,10 +5832,20 @@ func (pp *p) destroy() {
 		globrunqputhead(pp.runnext.ptr())
 		pp.runnext = 0
 	}
+	if pp.affinityNext != 0 {
+		globrunqputhead(pp.affinityNext.ptr())
+		pp.affinityNext = 0
+	}
+	bgbatchflush(pp)
 
 	// Move all timers to the local P.
 	getg().m.p.ptr().timers.take(&pp.timers)
 
+	// Keep the scheduling latencies and goroutine statistics pp recorded.
+	pp.runLatency.flushTo(&sched.runLatency)
+	sched.goroutinesCreated.Add(int64(pp.goroutinesCreated.Swap(0)))
+	pp.goroutineLifetimes.flushTo(&sched.goroutineLifetimes)
+
 	// Flush p's write barrier buffer.
 	if gcphase != _GCoff {
 		wbBufFlush1(pp)
@@ -5914,7 +6127,7 @@ func releasepNoTrace() *p {
 	}
 	gp.m.p = 0
 	pp.m = 0
-	pp.status = _Pidle
+	atomic.Store(&pp.status, _Pidle) // see affinity.go
 	return pp
 }
 
@@ -6060,6 +6273,7 @@ func sysmon() {
 	unlock(&sched.lock)
 
 	lasttrace := int64(0)
+	lastaging := int64(0)
 	idle := 0 // how many cycles in succession we had not wokeup somebody
 	delay := uint32(0)
 
@@ -6072,6 +6286,15 @@ func sysmon() {
 		if delay > 10*1000 { // up to 10ms
 			delay = 10 * 1000
 		}
+		if delay > backgroundPreemptNS/1000 && runningBackground() != nil {
+			// Check background goroutines often enough to
+			// preempt them on time.
+			delay = backgroundPreemptNS / 1000
+		}
+		if t := schedAging.Load() / 4 / 1000; t > 0 && int64(delay) > t {
+			// Check for aged goroutines often enough.
+			delay = uint32(t)
+		}
 		usleep(delay)
 
 		// sysmon should not enter deep sleep if schedtrace is enabled so that
@@ -6183,6 +6406,11 @@ func sysmon() {
 		} else {
 			idle++
 		}
+		// promote goroutines that have waited too long to run
+		if t := schedAging.Load(); t > 0 && lastaging+t/4 <= now {
+			lastaging = now
+			ageRunqueues(now, t)
+		}
 		// check if we need to force a GC
 		if t := (gcTrigger{kind: gcTriggerTime, now: now}); t.test() && forcegc.idle.Load() {
 			lock(&forcegc.lock)
@@ -6192,6 +6420,7 @@ func sysmon() {
 			injectglist(&list)
 			unlock(&forcegc.lock)
 		}
+		sysmonCheckMaxProcs(now)
 		if debug.schedtrace > 0 && lasttrace+int64(debug.schedtrace)*1000000 <= now {
 			lasttrace = now
 			schedtrace(debug.scheddetail > 0)
@@ -6238,7 +6467,7 @@ func retake(now int64) uint32 {
 			if int64(pd.schedtick) != t {
 				pd.schedtick = uint32(t)
 				pd.schedwhen = now
-			} else if pd.schedwhen+forcePreemptNS <= now {
+			} else if pd.schedwhen+forcePreemptNS <= now || s == _Prunning && preemptBackground(pp, pd, now) {
 				preemptone(pp)
 				// In case of syscall, preemptone() doesn't
 				// work, because there is no M wired to P.
@@ -6253,6 +6482,11 @@ func retake(now int64) uint32 {
 				pd.syscallwhen = now
 				continue
 			}
+			// A system call that's known not to block keeps its P,
+			// unless it blocks after all.
+			if !sysretake && pp.syscallNoBlock && pd.syscallwhen+forcePreemptNS > now {
+				continue
+			}
 			// On the one hand we don't want to retake Ps if there is no other work to do,
 			// but on the other hand we want to retake them eventually
 			// because they can prevent the sysmon thread from deep sleep.
@@ -6331,6 +6565,7 @@ func preemptone(pp *p) bool {
 	// Setting gp->stackguard0 to StackPreempt folds
 	// preemption into the normal stack overflow check.
 	gp.stackguard0 = stackPreempt
+	expireCritical(gp)
 
 	// Request an async preemption of this P.
 	if preemptMSupported && debug.asyncpreemptoff == 0 {
@@ -6505,7 +6740,16 @@ func mget() *m {
 func globrunqput(gp *g) {
 	assertLockHeld(&sched.lock)
 
-	sched.runq.pushBack(gp)
+	switch SchedClass(gp.schedClass) {
+	case SchedClassBackground:
+		sched.bgrunq.pushBack(gp)
+		sched.bgrunqsize++
+	case schedClassIdle:
+		sched.idlerunq.pushBack(gp)
+		sched.idlerunqsize++
+	default:
+		sched.runq.pushBack(gp)
+	}
 	sched.runqsize++
 }
 
@@ -6517,7 +6761,16 @@ func globrunqput(gp *g) {
 func globrunqputhead(gp *g) {
 	assertLockHeld(&sched.lock)
 
-	sched.runq.push(gp)
+	switch SchedClass(gp.schedClass) {
+	case SchedClassBackground:
+		sched.bgrunq.push(gp)
+		sched.bgrunqsize++
+	case schedClassIdle:
+		sched.idlerunq.push(gp)
+		sched.idlerunqsize++
+	default:
+		sched.runq.push(gp)
+	}
 	sched.runqsize++
 }
 
@@ -6530,6 +6783,7 @@ func globrunqputhead(gp *g) {
 func globrunqputbatch(batch *gQueue, n int32) {
 	assertLockHeld(&sched.lock)
 
+	n = globrunqputbackground(batch, n)
 	sched.runq.pushBackAll(*batch)
 	sched.runqsize += n
 	*batch = gQueue{}
@@ -6543,10 +6797,19 @@ func globrunqget(pp *p, max int32) *g {
 	if sched.runqsize == 0 {
 		return nil
 	}
+	if sched.runqsize == sched.bgrunqsize+sched.idlerunqsize {
+		// Only background and idle goroutines are waiting. Take one
+		// at a time, so they stay off the local run queue.
+		if gp := bgrunqget(); gp != nil {
+			return gp
+		}
+		return idlerunqget()
+	}
 
-	n := sched.runqsize/gomaxprocs + 1
-	if n > sched.runqsize {
-		n = sched.runqsize
+	runqsize := sched.runqsize - sched.bgrunqsize - sched.idlerunqsize
+	n := runqsize/gomaxprocs + 1
+	if n > runqsize {
+		n = runqsize
 	}
 	if max > 0 && n > max {
 		n = max
@@ -6556,6 +6819,11 @@ func globrunqget(pp *p, max int32) *g {
 	}
 
 	sched.runqsize -= n
+	if aged := sched.agedrunq.Load(); aged > n {
+		sched.agedrunq.Store(aged - n)
+	} else if aged > 0 {
+		sched.agedrunq.Store(0)
+	}
 
 	gp := sched.runq.pop()
 	n--
@@ -6604,6 +6872,8 @@ func (p pMask) clear(id int32) {
 func pidleput(pp *p, now int64) int64 {
 	assertLockHeld(&sched.lock)
 
+	affinityflush(pp)
+	bgbatchflush(pp)
 	if !runqempty(pp) {
 		throw("pidleput: P has non-empty run queue")
 	}
@@ -6684,8 +6954,9 @@ func runqempty(pp *p) bool {
 		head := atomic.Load(&pp.runqhead)
 		tail := atomic.Load(&pp.runqtail)
 		runnext := atomic.Loaduintptr((*uintptr)(unsafe.Pointer(&pp.runnext)))
+		affinityNext := atomic.Loaduintptr((*uintptr)(unsafe.Pointer(&pp.affinityNext)))
 		if tail == atomic.Load(&pp.runqtail) {
-			return head == tail && runnext == 0
+			return head == tail && runnext == 0 && affinityNext == 0
 		}
 	}
 }
@@ -6931,6 +7202,14 @@ func runqgrab(pp *p, batch *[256]guintptr, batchHead uint32, stealRunNextG bool)
 					batch[batchHead%uint32(len(batch))] = next
 					return 1
 				}
+				// Then from pp.affinityNext.
+				if next := guintptr(atomic.Loaduintptr((*uintptr)(unsafe.Pointer(&pp.affinityNext)))); next != 0 {
+					if !pp.affinityNext.cas(next, 0) {
+						continue
+					}
+					batch[batchHead%uint32(len(batch))] = next
+					return 1
+				}
 			}
 			return 0
 		}
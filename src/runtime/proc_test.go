@@ -103,6 +103,232 @@ func TestYieldLocked(t *testing.T) {
 	<-c
 }
 
+func TestSchedClass(t *testing.T) {
+	defer runtime.SetSchedClass(runtime.SetSchedClass(runtime.SchedClassLatency))
+	if old := runtime.SetSchedClass(runtime.SchedClassBackground); old != runtime.SchedClassLatency {
+		t.Errorf("SetSchedClass returned %v, want %v", old, runtime.SchedClassLatency)
+	}
+	// New goroutines inherit the class.
+	c := make(chan runtime.SchedClass)
+	go func() {
+		c <- runtime.SetSchedClass(runtime.SchedClassThroughput)
+	}()
+	if got := <-c; got != runtime.SchedClassBackground {
+		t.Errorf("new goroutine has class %v, want %v", got, runtime.SchedClassBackground)
+	}
+}
+
+func TestSchedClassBackground(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	// A background goroutine runs after a throughput goroutine created
+	// before it, even though the last goroutine created normally runs
+	// first. Allow for the background goroutine being picked to keep it
+	// from starving.
+	const trials = 20
+	first := 0
+	for range trials {
+		c := make(chan runtime.SchedClass, 2)
+		go func() { c <- runtime.SchedClassThroughput }()
+		runtime.SetSchedClass(runtime.SchedClassBackground)
+		go func() { c <- runtime.SchedClassBackground }()
+		runtime.SetSchedClass(runtime.SchedClassThroughput)
+		if <-c == runtime.SchedClassThroughput {
+			first++
+		}
+		<-c
+	}
+	if first < trials-2 {
+		t.Errorf("throughput goroutine ran first in %d of %d trials", first, trials)
+	}
+
+	// A background goroutine makes progress while a throughput goroutine
+	// keeps the P busy.
+	var done atomic.Bool
+	runtime.SetSchedClass(runtime.SchedClassBackground)
+	go func() { done.Store(true) }()
+	runtime.SetSchedClass(runtime.SchedClassThroughput)
+	for !done.Load() {
+		runtime.Gosched()
+	}
+
+	// So do many background goroutines, which the P collects in
+	// batches before moving them to the global background queue.
+	var n atomic.Int32
+	const count = 100
+	runtime.SetSchedClass(runtime.SchedClassBackground)
+	for range count {
+		go func() { n.Add(1) }()
+	}
+	runtime.SetSchedClass(runtime.SchedClassThroughput)
+	for n.Load() < count {
+		runtime.Gosched()
+	}
+}
+
+func TestRunWhenIdle(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	// An idle goroutine doesn't run while the P is busy, even if the
+	// busy goroutine yields, but it runs once the P is idle.
+	var ran atomic.Bool
+	done := make(chan runtime.SchedClass)
+	runtime.RunWhenIdle(func() {
+		ran.Store(true)
+		// Its goroutines are idle too.
+		go func() {
+			old := runtime.SetSchedClass(runtime.SchedClassThroughput)
+			runtime.SetSchedClass(old)
+			done <- old
+		}()
+	})
+	for range 1000 {
+		runtime.Gosched()
+	}
+	if ran.Load() {
+		t.Errorf("idle goroutine ran while the P was busy")
+	}
+	c := <-done
+	if c != runtime.SchedClassIdle {
+		t.Errorf("idle goroutine's goroutine has class %v, want %v", c, runtime.SchedClassIdle)
+	}
+
+	// An idle goroutine that keeps the P busy gives it up as soon as
+	// other goroutines are ready to run.
+	var stop atomic.Bool
+	defer stop.Store(true)
+	runtime.RunWhenIdle(func() {
+		for !stop.Load() {
+		}
+	})
+	start := time.Now()
+	for range 10 {
+		time.Sleep(time.Millisecond)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("10 sleeps of 1ms took %v while an idle goroutine ran", d)
+	}
+}
+
+func TestGoroutineCPUTime(t *testing.T) {
+	t.Setenv("GODEBUG", "goroutinecputime=1")
+	spin := func(d time.Duration) {
+		for start := time.Now(); time.Since(start) < d; {
+		}
+	}
+
+	// A goroutine's time grows while it runs, but not while it sleeps.
+	start := make(chan struct{})
+	handle := make(chan runtime.GoroutineHandle)
+	done := make(chan int64)
+	go func() {
+		handle <- runtime.CurrentGoroutine()
+		<-start
+		spin(50 * time.Millisecond)
+		done <- runtime.GoroutineCPUTime()
+		time.Sleep(100 * time.Millisecond)
+		done <- runtime.GoroutineCPUTime()
+	}()
+	h := <-handle
+	before, ok := runtime.GoroutineCPUTimeOf(h)
+	if !ok {
+		t.Fatalf("GoroutineCPUTimeOf reported a live goroutine as exited")
+	}
+	close(start)
+	spun := <-done
+	slept := <-done
+	if d := spun - before; d < int64(40*time.Millisecond) {
+		t.Errorf("goroutine ran for %v while spinning for 50ms", time.Duration(d))
+	}
+	if d := slept - spun; d > int64(50*time.Millisecond) {
+		t.Errorf("goroutine ran for %v while sleeping for 100ms", time.Duration(d))
+	}
+
+	// Once the goroutine exits, its handle reports that.
+	for {
+		if _, ok := runtime.GoroutineCPUTimeOf(h); !ok {
+			break
+		}
+		runtime.Gosched()
+	}
+	if _, ok := runtime.GoroutineCPUTimeOf(runtime.GoroutineHandle{}); ok {
+		t.Errorf("GoroutineCPUTimeOf reported the zero handle as live")
+	}
+
+	// The calling goroutine's time is monotonic.
+	t0 := runtime.GoroutineCPUTime()
+	spin(time.Millisecond)
+	if t1 := runtime.GoroutineCPUTime(); t1 < t0+int64(time.Millisecond) {
+		t.Errorf("GoroutineCPUTime went from %d to %d while spinning for 1ms", t0, t1)
+	}
+
+	// With the setting off, time stops counting once the goroutine
+	// is next scheduled.
+	t.Setenv("GODEBUG", "goroutinecputime=0")
+	runtime.Gosched()
+	t0 = runtime.GoroutineCPUTime()
+	spin(time.Millisecond)
+	if t1 := runtime.GoroutineCPUTime(); t1 != t0 {
+		t.Errorf("GoroutineCPUTime went from %d to %d with goroutinecputime=0", t0, t1)
+	}
+}
+
+func TestCritical(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s didn't panic", name)
+			}
+		}()
+		f()
+	}
+
+	runtime.BeginCritical()
+	runtime.EndCritical()
+	mustPanic("EndCritical outside a critical section", runtime.EndCritical)
+	mustPanic("nested BeginCritical", func() {
+		runtime.BeginCritical()
+		defer runtime.EndCritical()
+		runtime.BeginCritical()
+	})
+	mustPanic("EndCritical after the time limit", func() {
+		runtime.BeginCritical()
+		for start := time.Now(); time.Since(start) < 2*time.Millisecond; {
+		}
+		runtime.EndCritical()
+	})
+}
+
+func TestCriticalTimeLimit(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(2))
+
+	// A critical section that runs past its time limit doesn't hold off
+	// stopping the world.
+	started := make(chan bool)
+	ended := make(chan time.Time)
+	go func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("EndCritical didn't panic after the time limit")
+			}
+			ended <- time.Now()
+		}()
+		runtime.BeginCritical()
+		started <- true
+		for start := time.Now(); time.Since(start) < 100*time.Millisecond; {
+		}
+		runtime.EndCritical()
+	}()
+	<-started
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	stopped := time.Now()
+	if end := <-ended; end.Before(stopped) {
+		t.Errorf("critical section held off stopping the world until it ended")
+	}
+}
+
 func TestGoroutineParallelism(t *testing.T) {
 	if runtime.NumCPU() == 1 {
 		// Takes too long, too easy to deadlock, etc.
@@ -621,6 +847,51 @@ func TestSchedLocalQueue(t *testing.T) {
 	runtime.RunSchedLocalQueueTest()
 }
 
+func TestSchedAgingLocalQueue(t *testing.T) {
+	runtime.RunSchedAgingLocalQueueTest()
+}
+
+func TestPAffinity(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	const n = 1000
+	ping := make(chan bool)
+	moves := make(chan int)
+	go func() {
+		if runtime.SetPAffinity(true) {
+			t.Error("goroutine started with P affinity")
+		}
+		id, m := runtime.ProcID(), 0
+		for range ping {
+			if next := runtime.ProcID(); next != id {
+				id = next
+				m++
+			}
+		}
+		if !runtime.SetPAffinity(false) {
+			t.Error("SetPAffinity(true) didn't set P affinity")
+		}
+		moves <- m
+	}()
+
+	// Make the goroutine runnable from goroutines that run on any P.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n/4; j++ {
+				ping <- true
+			}
+		}()
+	}
+	wg.Wait()
+	close(ping)
+	if m := <-moves; m > n/10 {
+		t.Errorf("goroutine with P affinity moved between Ps %d times in %d wakeups", m, n)
+	}
+}
+
 func TestSchedLocalQueueSteal(t *testing.T) {
 	runtime.RunSchedLocalQueueStealTest()
 }
@@ -307,8 +307,16 @@ type dbgVar struct {
 // existing int var for that value, which may
 // already have an initial value.
 var debug struct {
+	arenachunksize           int32
+	arenahugepages           int32
+	arenaheap                int32
+	arenaleak                int32
+	arenapoison              int32
+	cgocallbackstack         int32
 	cgocheck                 int32
+	cgothreadstack           int32
 	clobberfree              int32
+	containermaxprocs        int32
 	disablethp               int32
 	dontfreezetheworld       int32
 	efence                   int32
@@ -316,14 +324,22 @@ var debug struct {
 	gcpacertrace             int32
 	gcshrinkstackoff         int32
 	gcstoptheworld           int32
+	gcworkers                int32
 	gctrace                  int32
+	gctracejson              int32
+	heaprandomize            int32
 	invalidptr               int32
 	madvdontneed             int32 // for Linux; issue 28466
+	mempressure              int32
+	netpolluring             int32
+	nontemporal              int32
+	quarantine               int32
 	runtimeContentionStacks  atomic.Int32
 	scavtrace                int32
 	scheddetail              int32
 	schedtrace               int32
 	tracebackancestors       int32
+	updatemaxprocs           int32
 	asyncpreemptoff          int32
 	harddecommit             int32
 	adaptivestackstart       int32
@@ -335,9 +351,12 @@ var debug struct {
 	// debug.malloc is used as a combined debug check
 	// in the malloc function and should be set
 	// if any of the below debug options is != 0.
-	malloc    bool
-	inittrace int32
-	sbrk      int32
+	malloc              bool
+	goroutineallocbytes int32
+	guardpages          int32
+	heaptypes           int32
+	inittrace           int32
+	sbrk                int32
 	// traceallocfree controls whether execution traces contain
 	// detailed trace data about memory allocation. This value
 	// affects debug.malloc only if it is != 0 and the execution
@@ -350,6 +369,17 @@ var debug struct {
 
 	panicnil atomic.Int32
 
+	// goroutinecputime controls whether the runtime measures the
+	// running time of each goroutine. The value can change at any
+	// time (in response to os.Setenv("GODEBUG")).
+	goroutinecputime atomic.Int32
+
+	// memprofiletype controls whether memory profile records
+	// include the type of the allocated objects. The value can
+	// change at any time (in response to os.Setenv("GODEBUG")),
+	// and affects allocations sampled from then on.
+	memprofiletype atomic.Int32
+
 	// asynctimerchan controls whether timer channels
 	// behave asynchronously (as in Go 1.22 and earlier)
 	// instead of their Go 1.23+ synchronous behavior.
@@ -363,10 +393,18 @@ var debug struct {
 
 var dbgvars = []*dbgVar{
 	{name: "adaptivestackstart", value: &debug.adaptivestackstart},
+	{name: "arenachunksize", value: &debug.arenachunksize},
+	{name: "arenahugepages", value: &debug.arenahugepages},
+	{name: "arenaheap", value: &debug.arenaheap},
+	{name: "arenaleak", value: &debug.arenaleak},
+	{name: "arenapoison", value: &debug.arenapoison},
 	{name: "asyncpreemptoff", value: &debug.asyncpreemptoff},
 	{name: "asynctimerchan", atomic: &debug.asynctimerchan},
+	{name: "cgocallbackstack", value: &debug.cgocallbackstack},
 	{name: "cgocheck", value: &debug.cgocheck},
+	{name: "cgothreadstack", value: &debug.cgothreadstack},
 	{name: "clobberfree", value: &debug.clobberfree},
+	{name: "containermaxprocs", value: &debug.containermaxprocs, def: 1},
 	{name: "disablethp", value: &debug.disablethp},
 	{name: "dontfreezetheworld", value: &debug.dontfreezetheworld},
 	{name: "efence", value: &debug.efence},
@@ -374,13 +412,25 @@ var dbgvars = []*dbgVar{
 	{name: "gcpacertrace", value: &debug.gcpacertrace},
 	{name: "gcshrinkstackoff", value: &debug.gcshrinkstackoff},
 	{name: "gcstoptheworld", value: &debug.gcstoptheworld},
+	{name: "gcworkers", value: &debug.gcworkers},
 	{name: "gctrace", value: &debug.gctrace},
+	{name: "gctracejson", value: &debug.gctracejson},
+	{name: "goroutineallocbytes", value: &debug.goroutineallocbytes},
+	{name: "goroutinecputime", atomic: &debug.goroutinecputime},
+	{name: "guardpages", value: &debug.guardpages},
 	{name: "harddecommit", value: &debug.harddecommit},
+	{name: "heaprandomize", value: &debug.heaprandomize},
+	{name: "heaptypes", value: &debug.heaptypes},
 	{name: "inittrace", value: &debug.inittrace},
 	{name: "invalidptr", value: &debug.invalidptr},
 	{name: "madvdontneed", value: &debug.madvdontneed},
+	{name: "mempressure", value: &debug.mempressure},
+	{name: "memprofiletype", atomic: &debug.memprofiletype},
+	{name: "netpolluring", value: &debug.netpolluring},
+	{name: "nontemporal", value: &debug.nontemporal, def: defaultNonTemporalThreshold},
 	{name: "panicnil", atomic: &debug.panicnil},
 	{name: "profstackdepth", value: &debug.profstackdepth, def: 128},
+	{name: "quarantine", value: &debug.quarantine},
 	{name: "runtimecontentionstacks", atomic: &debug.runtimeContentionStacks},
 	{name: "sbrk", value: &debug.sbrk},
 	{name: "scavtrace", value: &debug.scavtrace},
@@ -391,6 +441,7 @@ var dbgvars = []*dbgVar{
 	{name: "tracecheckstackownership", value: &debug.traceCheckStackOwnership},
 	{name: "tracebackancestors", value: &debug.tracebackancestors},
 	{name: "tracefpunwindoff", value: &debug.tracefpunwindoff},
+	{name: "updatemaxprocs", value: &debug.updatemaxprocs, def: 1},
 }
 
 func parsedebugvars() {
@@ -435,8 +486,13 @@ func parsedebugvars() {
 	// apply environment settings
 	parsegodebug(godebug, nil)
 
-	debug.malloc = (debug.inittrace | debug.sbrk) != 0
+	debug.malloc = (debug.goroutineallocbytes | debug.guardpages | debug.heaptypes | debug.inittrace | debug.sbrk) != 0
 	debug.profstackdepth = min(debug.profstackdepth, maxProfStackDepth)
+	if debug.quarantine > 0 {
+		// Record the allocation stack of every object, to report
+		// writes to quarantined objects.
+		MemProfileRate = 1
+	}
 
 	setTraceback(gogetenv("GOTRACEBACK"))
 	traceback_env = traceback_cache
@@ -459,14 +459,30 @@ type g struct {
 	inMarkAssist bool
 	coroexit     bool // argument to coroswitch_m
 
-	raceignore    int8  // ignore race detection events
-	nocgocallback bool  // whether disable callback from C
-	tracking      bool  // whether we're tracking this G for sched latency statistics
-	trackingSeq   uint8 // used to decide whether to track this G
-	trackingStamp int64 // timestamp of when the G last started being tracked
-	runnableTime  int64 // the amount of time spent runnable, cleared when running, only used when tracking
+	// cpuStart and cpuTime record the time the G has spent running.
+	// Other Ms read them under the cpuSeq sequence lock, which is odd
+	// while they're being updated. See goroutinecpu.go.
+	cpuSeq   atomic.Uint32
+	cpuStart int64 // when the G last started running, or 0 if it's not running or accounting is off
+	cpuTime  int64 // time spent running, up to cpuStart
+
+	raceignore    int8   // ignore race detection events
+	nocgocallback bool   // whether disable callback from C
+	tracking      bool   // whether we're tracking this G for sched latency statistics
+	trackingSeq   uint8  // used to decide whether to track this G
+	schedClass    uint8  // SchedClass of this G
+	affinity      uint16 // 1 + id of the P this G last ran on, if it has P affinity; see affinity.go
+	trackingStamp int64  // timestamp of when the G last started being tracked
+	runnableTime  int64  // the amount of time spent runnable, cleared when running, only used when tracking
+	runnableSince int64  // nanotime when the G last became runnable, or 0; see schedaging.go
+	createdAt     int64  // nanotime when the G was created
+	syscallStart  int64  // cputicks when the G entered its system call, if off-CPU profiling
 	lockedm       muintptr
 	sig           uint32
+	// critical is the state of the G's critical section, and
+	// criticalStart is when it began. See critical.go.
+	critical      atomic.Uint32
+	criticalStart int64
 	writebuf      []byte
 	sigcode0      uintptr
 	sigcode1      uintptr
@@ -475,6 +491,7 @@ type g struct {
 	gopc          uintptr         // pc of go statement that created this goroutine
 	ancestors     *[]ancestorInfo // ancestor information goroutine(s) that created this goroutine (only used if debug.tracebackancestors)
 	startpc       uintptr         // pc of goroutine function
+	locals        *goroutineLocal // goroutine-local values
 	racectx       uintptr
 	waiting       *sudog         // sudog structures this g is waiting on (that have a valid elem ptr); in lock order
 	cgoCtxt       []uintptr      // cgo traceback context
@@ -502,6 +519,10 @@ type g struct {
 	// and check for debt in the malloc hot path. The assist ratio
 	// determines how this corresponds to scan work debt.
 	gcAssistBytes int64
+
+	// allocBytes is the number of bytes this G has requested from
+	// the heap since it was created, with GODEBUG=goroutineallocbytes=1.
+	allocBytes uint64
 }
 
 // gTrackingPeriod is the number of transitions out of _Grunning between
@@ -571,6 +592,10 @@ type m struct {
 	lockedInt     uint32      // tracking for internal lockOSThread
 	nextwaitm     muintptr    // next m waiting for lock
 
+	// threadAttr holds the thread's scheduling attributes from before
+	// LockOSThreadAttr changed them, to restore when it's unlocked.
+	threadAttr *threadAttrState
+
 	mLockProfile mLockProfile // fields relating to runtime.lock contention
 	profStack    []uintptr    // used for memory/block/mutex stack traces
 
@@ -585,6 +610,10 @@ type m struct {
 	freelink    *m // on sched.freem
 	trace       mTraceState
 
+	// syscallNoBlock is set while the M is in a system call entered
+	// with entersyscallnoblock.
+	syscallNoBlock bool
+
 	// these are here because they are too large to be on the stack
 	// of low-level NOSPLIT functions.
 	libcall    libcall
@@ -656,6 +685,11 @@ type p struct {
 	// only the owner P can CAS it to a valid G.
 	runnext guintptr
 
+	// affinityNext, if non-nil, is a runnable G with affinity for this
+	// P that another P made runnable. Other Ps may CAS it from zero to
+	// a valid G, and back. See affinity.go.
+	affinityNext guintptr
+
 	// Available G's (status == Gdead)
 	gFree struct {
 		gList
@@ -738,9 +772,38 @@ type p struct {
 	// scheduler ASAP (regardless of what G is running on it).
 	preempt bool
 
+	// syscallNoBlock is set when the P enters _Psyscall for a system
+	// call entered with entersyscallnoblock. sysmon doesn't retake
+	// such a P unless the system call blocks after all.
+	syscallNoBlock bool
+
+	// runningClass is the SchedClass of the G this P last started
+	// running. It's read by other Ps and sysmon to find Ps running
+	// background goroutines.
+	runningClass atomic.Uint8
+
+	// bgbatch holds background and idle goroutines this P made
+	// runnable, until they're moved to their global queues together.
+	// Only this P accesses it. See bgbatchput.
+	bgbatch  gQueue
+	bgbatchn int32
+
 	// gcStopTime is the nanotime timestamp that this P last entered _Pgcstop.
 	gcStopTime int64
 
+	// runLatency is the distribution of the time goroutines run by this
+	// P spent runnable before they started running, recorded while
+	// scheduler aging is enabled. It's folded into sched.runLatency when
+	// the P is destroyed.
+	runLatency timeHistogram
+
+	// goroutinesCreated is the number of user goroutines created on
+	// this P, and goroutineLifetimes is the distribution of the
+	// lifetimes of user goroutines that exited on it. They're folded
+	// into sched's when the P is destroyed.
+	goroutinesCreated  atomic.Uint64
+	goroutineLifetimes timeHistogram
+
 	// Padding is no longer needed. False sharing is now not a worry because p is large enough
 	// that its size class is an integer multiple of the cache line size (for any of our architectures).
 }
@@ -774,6 +837,21 @@ type schedt struct {
 	runq     gQueue
 	runqsize int32
 
+	// Global background queue, of goroutines in SchedClassBackground.
+	// They're also counted in runqsize.
+	bgrunq     gQueue
+	bgrunqsize int32
+
+	// Global idle queue, of goroutines started by RunWhenIdle. They're
+	// also counted in runqsize.
+	idlerunq     gQueue
+	idlerunqsize int32
+
+	// Number of goroutines at the head of runq that have been runnable
+	// for longer than the scheduler aging threshold. Set under lock.
+	// See schedaging.go.
+	agedrunq atomic.Int32
+
 	// disable controls selective disabling of the scheduler.
 	//
 	// Use schedEnableUser to control this.
@@ -834,6 +912,17 @@ type schedt struct {
 	// it transitions to _Grunning.
 	timeToRun timeHistogram
 
+	// runLatency is the part of the distribution of scheduling latencies
+	// of every goroutine, unlike timeToRun, recorded by Ps that have since
+	// been destroyed. See p.runLatency.
+	runLatency timeHistogram
+
+	// goroutinesCreated and goroutineLifetimes are the parts of
+	// the goroutine statistics recorded by Ps that have since been
+	// destroyed. See p.goroutinesCreated.
+	goroutinesCreated  atomic.Uint64
+	goroutineLifetimes timeHistogram
+
 	// idleTime is the total CPU time Ps have "spent" idle.
 	//
 	// Reset on each GC cycle.
@@ -858,6 +947,11 @@ type schedt struct {
 	stwTotalTimeGC    timeHistogram
 	stwTotalTimeOther timeHistogram
 
+	// stwTotalTimeSweepTerm/MarkTerm split stwTotalTimeGC by the GC
+	// phase that stopped the world.
+	stwTotalTimeSweepTerm timeHistogram
+	stwTotalTimeMarkTerm  timeHistogram
+
 	// totalRuntimeLockWaitTime (plus the value of lockWaitTime on each M in
 	// allm) is the sum of time goroutines have spent in _Grunnable and with an
 	// M, but waiting for locks within the runtime. This field stores the value
@@ -1068,6 +1162,10 @@ const (
 	waitReasonTraceProcStatus                         // "trace proc status"
 	waitReasonPageTraceFlush                          // "page trace flush"
 	waitReasonCoroutine                               // "coroutine"
+	waitReasonAllocSamplerWait                        // "alloc sampler wait"
+	waitReasonMemPressureWait                         // "memory pressure wait"
+	waitReasonMaxProcsIdle                            // "GOMAXPROCS updater (idle)"
+	waitReasonGoroutineLimit                          // "goroutine limit"
 )
 
 var waitReasonStrings = [...]string{
@@ -1108,6 +1206,10 @@ var waitReasonStrings = [...]string{
 	waitReasonTraceProcStatus:       "trace proc status",
 	waitReasonPageTraceFlush:        "page trace flush",
 	waitReasonCoroutine:             "coroutine",
+	waitReasonAllocSamplerWait:      "alloc sampler wait",
+	waitReasonMemPressureWait:       "memory pressure wait",
+	waitReasonMaxProcsIdle:          "GOMAXPROCS updater (idle)",
+	waitReasonGoroutineLimit:        "goroutine limit",
 }
 
 func (w waitReason) String() string {
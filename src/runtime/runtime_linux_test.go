@@ -5,7 +5,10 @@
 package runtime_test
 
 import (
+	"errors"
 	. "runtime"
+	"slices"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
@@ -63,3 +66,148 @@ func TestKernelStructSize(t *testing.T) {
 		t.Errorf("Go's sigevent struct is %d bytes long; kernel expects %d", have, want)
 	}
 }
+
+func TestMemPressureParse(t *testing.T) {
+	events := []byte("low 0\nhigh 12\nmax 3\noom 1\noom_kill 2\noom_group_kill 0\n")
+	for _, tt := range []struct {
+		key  string
+		want uint64
+	}{
+		{"low", 0},
+		{"high", 12},
+		{"max", 3},
+		{"oom", 1},
+		{"oom_kill", 2},
+		{"missing", 0},
+	} {
+		if got := MemEventsCount(events, tt.key); got != tt.want {
+			t.Errorf("MemEventsCount(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	psi := []byte("some avg10=12.34 avg60=5.00 avg300=1.00 total=123456\nfull avg10=0.50 avg60=0.10 avg300=0.00 total=4567\n")
+	if got := MemPSIAvg10(psi, "some"); got != 12 {
+		t.Errorf(`MemPSIAvg10("some") = %d, want 12`, got)
+	}
+	if got := MemPSIAvg10(psi, "full"); got != 0 {
+		t.Errorf(`MemPSIAvg10("full") = %d, want 0`, got)
+	}
+	if got := MemPSIAvg10([]byte("garbage"), "some"); got != 0 {
+		t.Errorf(`MemPSIAvg10 of garbage = %d, want 0`, got)
+	}
+}
+
+func TestCgroupCPUParse(t *testing.T) {
+	v1, v2, ok := CgroupPaths("12:cpu,cpuacct:/docker/abc\n11:memory:/docker/abc\n0::/system.slice/x.service\n")
+	if !ok || v1 != "/docker/abc" || v2 != "/system.slice/x.service" {
+		t.Errorf("CgroupPaths = %q, %q, %v, want %q, %q, true", v1, v2, ok, "/docker/abc", "/system.slice/x.service")
+	}
+	if _, _, ok := CgroupPaths("garbage\n"); ok {
+		t.Errorf("CgroupPaths of garbage succeeded")
+	}
+
+	mountinfo := "" +
+		"22 1 0:21 / /proc rw,nosuid - proc proc rw\n" +
+		"30 25 0:26 / /sys/fs/cgroup/memory rw - cgroup cgroup rw,memory\n" +
+		"31 25 0:27 /docker /sys/fs/cgroup/cpu,cpuacct rw master:1 - cgroup cgroup rw,cpu,cpuacct\n" +
+		"35 25 0:30 / /sys/fs/cgroup/unified rw - cgroup2 cgroup2 rw\n"
+	for _, tt := range []struct {
+		path     string
+		v1       bool
+		dir, mnt string
+		ok       bool
+	}{
+		{"/docker/abc", true, "/sys/fs/cgroup/cpu,cpuacct/abc", "/sys/fs/cgroup/cpu,cpuacct", true},
+		{"/docker", true, "/sys/fs/cgroup/cpu,cpuacct", "/sys/fs/cgroup/cpu,cpuacct", true},
+		{"/dockerx/abc", true, "", "", false},
+		{"/a/b", false, "/sys/fs/cgroup/unified/a/b", "/sys/fs/cgroup/unified", true},
+		{"/", false, "/sys/fs/cgroup/unified", "/sys/fs/cgroup/unified", true},
+	} {
+		dir, mnt, ok := CgroupDir(mountinfo, tt.path, tt.v1)
+		if dir != tt.dir || mnt != tt.mnt || ok != tt.ok {
+			t.Errorf("CgroupDir(%q, %v) = %q, %q, %v, want %q, %q, %v", tt.path, tt.v1, dir, mnt, ok, tt.dir, tt.mnt, tt.ok)
+		}
+	}
+
+	for _, tt := range []struct {
+		s    string
+		want float64
+		ok   bool
+	}{
+		{"max 100000\n", 0, false},
+		{"150000 100000\n", 1.5, true},
+		{"50000 100000", 0.5, true},
+		{"garbage", 0, false},
+	} {
+		if got, ok := ParseCgroupCPUMax(tt.s); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseCgroupCPUMax(%q) = %v, %v, want %v, %v", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got, ok := ParseCgroupV1CPULimit("-1\n", "100000\n"); ok {
+		t.Errorf("ParseCgroupV1CPULimit with no quota = %v, true, want false", got)
+	}
+	if got, ok := ParseCgroupV1CPULimit("400000\n", "100000\n"); got != 4 || !ok {
+		t.Errorf("ParseCgroupV1CPULimit = %v, %v, want 4, true", got, ok)
+	}
+}
+
+func TestLockOSThreadAttr(t *testing.T) {
+	cpus := ThreadCPUs()
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Raising the nice value needs no privileges, but lowering it back
+	// when the thread is unlocked does.
+	setNice := syscall.Getuid() == 0
+	wantPrio := prio
+	if setNice {
+		wantPrio = prio - 1
+	}
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		attr := ThreadAttr{CPUs: cpus[:1], SetNice: setNice, Nice: 20 - prio + 1}
+		if err := LockOSThreadAttr(attr); err != nil {
+			t.Errorf("LockOSThreadAttr: %v", err)
+			return
+		}
+		if got := ThreadCPUs(); !slices.Equal(got, cpus[:1]) {
+			t.Errorf("locked thread has CPUs %v, want %v", got, cpus[:1])
+		}
+		if got, _ := syscall.Getpriority(syscall.PRIO_PROCESS, 0); got != wantPrio {
+			t.Errorf("locked thread has priority %d, want %d", got, wantPrio)
+		}
+		UnlockOSThread()
+		if got := ThreadCPUs(); !slices.Equal(got, cpus) {
+			t.Errorf("unlocked thread has CPUs %v, want %v", got, cpus)
+		}
+		if got, _ := syscall.Getpriority(syscall.PRIO_PROCESS, 0); got != prio {
+			t.Errorf("unlocked thread has priority %d, want %d", got, prio)
+		}
+
+		// A failure undoes the attributes that were set.
+		attr = ThreadAttr{CPUs: cpus[:1], FIFOPriority: 1000}
+		var aerr *ThreadAttrError
+		if err := LockOSThreadAttr(attr); !errors.As(err, &aerr) || aerr.Op != "sched_setscheduler" {
+			t.Errorf("LockOSThreadAttr with an invalid priority returned %v, want a sched_setscheduler error", err)
+		}
+		if got := ThreadCPUs(); !slices.Equal(got, cpus) {
+			t.Errorf("thread has CPUs %v after failed LockOSThreadAttr, want %v", got, cpus)
+		}
+	}()
+	<-done
+}
+
+func TestNetpollURing(t *testing.T) {
+	t.Parallel()
+	output := runTestProg(t, "testprognet", "NetpollURing", "GODEBUG=netpolluring=1")
+	if strings.HasPrefix(output, "SKIP: ") {
+		t.Skip(strings.TrimSpace(output[len("SKIP: "):]))
+	}
+	want := "OK\n"
+	if output != want {
+		t.Fatalf("output is not %q\n%s", want, output)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Scheduler aging.
+//
+// While aging is enabled, every goroutine records in runnableSince when it
+// last became runnable. The run queues are roughly first in, first out, so
+// the goroutine at the head of a queue has usually been waiting on it the
+// longest. Reading the clock each time a goroutine becomes runnable has a
+// measurable cost, so while aging is disabled, only the goroutines sampled
+// for scheduler latency tracking (see gTrackingPeriod) are timestamped,
+// and the others have a runnableSince of 0. Goroutines without a timestamp
+// aren't aged.
+//
+// When aging is enabled, by runtime/debug.SetSchedulerAging, sysmon
+// checks the run queues every quarter of the threshold. It moves the
+// goroutines at the head of each P's local run queue, and in its runnext
+// slot, that have waited longer than the threshold to the head of the
+// global run queue, and counts, in sched.agedrunq, the goroutines at the
+// head of the global run queue that have waited that long. While
+// sched.agedrunq isn't zero, Ps take goroutines from the global run queue
+// before their local run queues. sysmon also starts an M if a P is idle,
+// or else preempts the P that has been running the same goroutines the
+// longest, so that an aged goroutine runs promptly.
+//
+// Under sustained overload, when every waiting goroutine has aged, this
+// degrades to running goroutines in the order in which they were
+// promoted, which is roughly the order in which they became runnable.
+//
+// Background and idle goroutines aren't aged: the scheduler keeps them
+// waiting on purpose.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+// schedAging is the scheduler aging threshold, in nanoseconds, or 0 if
+// aging is disabled.
+var schedAging atomic.Int64
+
+// minSchedAgingNS is the smallest scheduler aging threshold.
+const minSchedAgingNS = 1000 * 1000 // 1ms
+
+//go:linkname setSchedulerAging runtime/debug.setSchedulerAging
+func setSchedulerAging(ns int64) int64 {
+	if ns < 0 {
+		ns = 0
+	} else if ns > 0 && ns < minSchedAgingNS {
+		ns = minSchedAgingNS
+	}
+	return schedAging.Swap(ns)
+}
+
+// ageRunqueues promotes the goroutines that have been waiting on run
+// queues since before now-threshold, as described at the top of this
+// file. It's called by sysmon.
+func ageRunqueues(now, threshold int64) {
+	before := now - threshold
+
+	var aged gQueue
+	var n int32
+	lock(&allpLock)
+	for _, pp := range allp {
+		if pp == nil {
+			continue
+		}
+		for {
+			gp := runqgetaged(pp, before)
+			if gp == nil {
+				break
+			}
+			aged.pushBack(gp)
+			n++
+		}
+		if next := pp.runnext; next != 0 && agedBefore(next.ptr(), before) && pp.runnext.cas(next, 0) {
+			aged.pushBack(next.ptr())
+			n++
+		}
+	}
+	unlock(&allpLock)
+
+	lock(&sched.lock)
+	if n > 0 {
+		aged.pushBackAll(sched.runq)
+		sched.runq = aged
+		sched.runqsize += n
+	}
+	var count int32
+	for gp := sched.runq.head.ptr(); gp != nil && agedBefore(gp, before); gp = gp.schedlink.ptr() {
+		count++
+	}
+	sched.agedrunq.Store(count)
+	unlock(&sched.lock)
+
+	if count == 0 {
+		return
+	}
+	if sched.npidle.Load() > 0 {
+		startm(nil, false, false)
+		return
+	}
+	// Preempt the P that has been running the same goroutines longest.
+	// retake has just updated its sysmontick.
+	var oldest *p
+	lock(&allpLock)
+	for _, pp := range allp {
+		if pp != nil && pp.status == _Prunning && (oldest == nil || pp.sysmontick.schedwhen < oldest.sysmontick.schedwhen) {
+			oldest = pp
+		}
+	}
+	if oldest != nil {
+		preemptone(oldest)
+	}
+	unlock(&allpLock)
+}
+
+// agedBefore reports whether gp has a timestamp and became runnable before the
+// given time.
+func agedBefore(gp *g, before int64) bool {
+	return gp.runnableSince != 0 && gp.runnableSince <= before
+}
+
+// runqgetaged takes the goroutine at the head of pp's local run queue if
+// it became runnable before the given time. Like runqgrab, it may be
+// called by any thread.
+func runqgetaged(pp *p, before int64) *g {
+	for {
+		h := atomic.LoadAcq(&pp.runqhead) // load-acquire, synchronize with other consumers
+		t := atomic.LoadAcq(&pp.runqtail) // load-acquire, synchronize with the producer
+		if t == h {
+			return nil
+		}
+		if t-h > uint32(len(pp.runq)) { // read inconsistent h and t
+			continue
+		}
+		gp := pp.runq[h%uint32(len(pp.runq))].ptr()
+		if !agedBefore(gp, before) {
+			return nil
+		}
+		if atomic.CasRel(&pp.runqhead, h, h+1) { // cas-release, commits consume
+			return gp
+		}
+	}
+}
+
+// longestRunnableWait returns how long, as of now, the goroutine that
+// has been waiting the longest on a run queue has been waiting. It
+// doesn't count background and idle goroutines, or goroutines without
+// a timestamp, so it's an estimate while aging is disabled.
+//
+// It doesn't lock the local run queues, so the goroutines may be running
+// by the time they're looked at, and it may miss some.
+func longestRunnableWait(now int64) int64 {
+	oldest := now
+	for _, pp := range allp {
+		if pp == nil {
+			continue
+		}
+		if gp := pp.runnext.ptr(); gp != nil && gp.runnableSince != 0 {
+			oldest = min(oldest, gp.runnableSince)
+		}
+		h := atomic.LoadAcq(&pp.runqhead)
+		t := atomic.LoadAcq(&pp.runqtail)
+		if t-h > uint32(len(pp.runq)) {
+			// Inconsistent h and t. Just look at the head.
+			t = h + 1
+		}
+		for i := h; i != t; i++ {
+			if gp := pp.runq[i%uint32(len(pp.runq))].ptr(); gp != nil && gp.runnableSince != 0 {
+				oldest = min(oldest, gp.runnableSince)
+			}
+		}
+	}
+	lock(&sched.lock)
+	if gp := sched.runq.head.ptr(); gp != nil && gp.runnableSince != 0 {
+		oldest = min(oldest, gp.runnableSince)
+	}
+	unlock(&sched.lock)
+	return now - oldest
+}
@@ -0,0 +1,340 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Goroutine scheduling classes.
+//
+// Every goroutine has a SchedClass, which it inherits from the
+// goroutine that created it. The scheduler treats the classes as
+// follows:
+//
+//   - Throughput goroutines, the default, are scheduled as always.
+//
+//   - Background goroutines never go on a P's local run queue. When
+//     they become runnable, they go on the global background queue,
+//     sched.bgrunq, in batches (see bgbatchput), and a P only takes
+//     one from there when it has no
+//     other work, apart from a check of the background queue every 251
+//     schedule ticks, which keeps them from starving. A background
+//     goroutine that has run for more than backgroundPreemptNS while
+//     other goroutines are waiting, or timers are due on its P, is
+//     preempted. sysmon checks at least that often while a background
+//     goroutine is running.
+//
+//   - Latency goroutines made runnable by a running goroutine while no
+//     P is idle, but some other P is running a background or idle
+//     goroutine, go on the head of the global run queue, and that P is
+//     preempted to run them. Otherwise they're queued like throughput
+//     goroutines.
+//
+//   - Idle goroutines, started by RunWhenIdle, go on the global idle
+//     queue, sched.idlerunq, and a P only takes one from there when it
+//     has nothing else to do, not even a background goroutine. There's
+//     no check for fairness: they may never run. A P running one is
+//     preempted as soon as other goroutines are waiting: by a running
+//     goroutine that makes one runnable while no P is idle, or else by
+//     sysmon, which checks as often as for background goroutines.
+//
+// The goroutines on sched.bgrunq and sched.idlerunq are also counted in
+// sched.runqsize, so the checks for work before a P is released see
+// them. A P moves its batch of them to the global queues before then.
+
+package runtime
+
+import (
+	"internal/abi"
+	"internal/runtime/atomic"
+	"internal/runtime/sys"
+	"unsafe"
+)
+
+// A SchedClass tells the scheduler how to trade off a goroutine's
+// latency against the throughput of the program's other goroutines.
+type SchedClass uint8
+
+const (
+	// SchedClassThroughput is the default class. Its goroutines are
+	// scheduled fairly with each other, favoring throughput.
+	SchedClassThroughput SchedClass = iota
+
+	// SchedClassLatency is for latency-critical goroutines, such as
+	// those serving requests. When one becomes runnable, the scheduler
+	// runs it as soon as possible, preempting a background goroutine
+	// if necessary.
+	SchedClassLatency
+
+	// SchedClassBackground is for goroutines doing work that can wait,
+	// such as compaction or cache refreshes. They run when the other
+	// goroutines leave a P idle, and only occasionally otherwise.
+	SchedClassBackground
+
+	// schedClassIdle is the class of goroutines started by RunWhenIdle,
+	// and of the goroutines they start.
+	schedClassIdle
+)
+
+// bgbatchMax is the number of background and idle goroutines a P
+// collects before moving them to their global queues.
+const bgbatchMax = 32
+
+// backgroundPreemptNS is the time slice given to a background goroutine
+// while other goroutines are waiting to run.
+const backgroundPreemptNS = 1000 * 1000 // 1ms
+
+// schedClassUsed is set once any goroutine is put in a class other than
+// SchedClassThroughput. Until then, the scheduler skips looking for
+// background goroutines in batches of goroutines.
+var schedClassUsed atomic.Bool
+
+// idleGoroutines is the number of live goroutines in schedClassIdle.
+// Until there's one, the scheduler skips looking for Ps to preempt
+// when goroutines become runnable.
+var idleGoroutines atomic.Int32
+
+// SetSchedClass sets the scheduling class of the calling goroutine to c,
+// and returns its previous class. Goroutines start in the class of the
+// goroutine that created them.
+//
+// The classes are hints: the scheduler prefers latency-critical
+// goroutines over others and runs background goroutines when there is
+// nothing else to do, but it doesn't guarantee an order, and background
+// goroutines still make progress when the program is busy.
+//
+// A goroutine started by RunWhenIdle can leave idle priority by calling
+// SetSchedClass, and return to it by passing the class it returned.
+func SetSchedClass(c SchedClass) SchedClass {
+	if c > schedClassIdle {
+		panic("runtime: SetSchedClass with unknown SchedClass")
+	}
+	if c != SchedClassThroughput && !schedClassUsed.Load() {
+		schedClassUsed.Store(true)
+	}
+	gp := getg()
+	old := SchedClass(gp.schedClass)
+	if old == schedClassIdle && c != schedClassIdle {
+		idleGoroutines.Add(-1)
+	} else if old != schedClassIdle && c == schedClassIdle {
+		idleGoroutines.Add(1)
+	}
+	gp.schedClass = uint8(c)
+	mp := acquirem()
+	if pp := mp.p.ptr(); pp != nil {
+		pp.runningClass.Store(uint8(c))
+	}
+	releasem(mp)
+	return old
+}
+
+// RunWhenIdle starts a goroutine running f at idle priority: it runs
+// only when a P would otherwise be idle, like the garbage collector's
+// idle workers, and it's preempted as soon as other goroutines are ready
+// to run. It's meant for optional work, such as compaction or warming
+// caches, that should use only spare CPU time.
+//
+// Unlike a goroutine in SchedClassBackground, an idle goroutine isn't
+// guaranteed to make progress: while the program keeps all Ps busy, it
+// doesn't run at all. So it shouldn't hold locks, or hold up other
+// goroutines, for long. Goroutines it starts are idle too, unless they
+// call SetSchedClass.
+func RunWhenIdle(f func()) {
+	if f == nil {
+		panic("runtime: RunWhenIdle with nil function")
+	}
+	if !schedClassUsed.Load() {
+		schedClassUsed.Store(true)
+	}
+	fv := *(**funcval)(unsafe.Pointer(&f))
+	fv = abi.Escape(fv)
+	gp := getg()
+	pc := sys.GetCallerPC()
+	systemstack(func() {
+		newg := newproc1(fv, gp, pc, false, waitReasonZero)
+		if newg.schedClass != uint8(schedClassIdle) {
+			newg.schedClass = uint8(schedClassIdle)
+			idleGoroutines.Add(1)
+		}
+
+		lock(&sched.lock)
+		globrunqput(newg)
+		unlock(&sched.lock)
+
+		if mainStarted {
+			wakep()
+		}
+	})
+}
+
+// runqputClass puts gp on a run queue according to its class. It's like
+// runqput, but puts background and idle goroutines on their global
+// queues, and latency goroutines where they'll run soonest.
+//
+// Must not be called with sched.lock held.
+func runqputClass(pp *p, gp *g, next bool) {
+	switch SchedClass(gp.schedClass) {
+	case SchedClassBackground, schedClassIdle:
+		bgbatchput(pp, gp)
+		return
+	case SchedClassLatency:
+		// If the current P is in the scheduler, it'll run gp next
+		// anyway. Otherwise, gp may have to wait for the current
+		// goroutine, so preempt a background goroutine instead.
+		if getg().m.curg != nil && sched.npidle.Load() == 0 {
+			if bp := runningBackground(); bp != nil && bp != pp {
+				lock(&sched.lock)
+				globrunqputhead(gp)
+				unlock(&sched.lock)
+				preemptone(bp)
+				return
+			}
+		}
+	}
+	runqput(pp, gp, next)
+	if getg().m.curg != nil {
+		preemptIdle(pp)
+	}
+}
+
+// bgbatchput adds the background or idle goroutine gp to pp's batch of
+// them. Like runqputslow, it moves the batch to the global queues once
+// it's full, so that making many of them runnable doesn't take
+// sched.lock for each one. It also moves it right away while a P is
+// idle, which can run them now. Otherwise, pp moves it when it next
+// looks for work, or stops running.
+func bgbatchput(pp *p, gp *g) {
+	pp.bgbatch.pushBack(gp)
+	pp.bgbatchn++
+	if pp.bgbatchn >= bgbatchMax || sched.npidle.Load() != 0 {
+		lock(&sched.lock)
+		bgbatchflush(pp)
+		unlock(&sched.lock)
+	}
+}
+
+// bgbatchflush moves pp's batch of background and idle goroutines to
+// their global queues, and returns how many it moved.
+//
+// sched.lock must be held.
+// May run during STW, so write barriers are not allowed.
+//
+//go:nowritebarrierrec
+func bgbatchflush(pp *p) int32 {
+	assertLockHeld(&sched.lock)
+
+	n := pp.bgbatchn
+	if n != 0 {
+		globrunqputbatch(&pp.bgbatch, n)
+		pp.bgbatchn = 0
+	}
+	return n
+}
+
+// runningBackground returns a P that's running a background or idle
+// goroutine, or nil if there is none.
+func runningBackground() *p {
+	if !schedClassUsed.Load() {
+		return nil
+	}
+	for _, pp := range allp {
+		if pp != nil && pp.status == _Prunning {
+			if c := pp.runningClass.Load(); c == uint8(SchedClassBackground) || c == uint8(schedClassIdle) {
+				return pp
+			}
+		}
+	}
+	return nil
+}
+
+// preemptIdle preempts a P other than pp that's running an idle
+// goroutine, if no P is idle, so that it runs the goroutines that were
+// just made runnable instead. The P will steal them from pp's run queue,
+// or take them from the global run queue.
+func preemptIdle(pp *p) {
+	if idleGoroutines.Load() == 0 || sched.npidle.Load() != 0 {
+		return
+	}
+	for _, ip := range allp {
+		if ip != nil && ip != pp && ip.status == _Prunning && ip.runningClass.Load() == uint8(schedClassIdle) {
+			preemptone(ip)
+			return
+		}
+	}
+}
+
+// bgrunqget takes a goroutine from the global background queue.
+// sched.lock must be held.
+func bgrunqget() *g {
+	assertLockHeld(&sched.lock)
+
+	if sched.bgrunqsize == 0 {
+		return nil
+	}
+	sched.runqsize--
+	sched.bgrunqsize--
+	return sched.bgrunq.pop()
+}
+
+// idlerunqget takes a goroutine from the global idle queue.
+// sched.lock must be held.
+func idlerunqget() *g {
+	assertLockHeld(&sched.lock)
+
+	if sched.idlerunqsize == 0 {
+		return nil
+	}
+	sched.runqsize--
+	sched.idlerunqsize--
+	return sched.idlerunq.pop()
+}
+
+// globrunqputbackground moves the background and idle goroutines in
+// batch, of n goroutines, to their global queues, and returns the number
+// of goroutines left in batch.
+//
+// sched.lock must be held.
+// May run during STW, so write barriers are not allowed.
+//
+//go:nowritebarrierrec
+func globrunqputbackground(batch *gQueue, n int32) int32 {
+	assertLockHeld(&sched.lock)
+
+	if !schedClassUsed.Load() {
+		return n
+	}
+	var q gQueue
+	for !batch.empty() {
+		gp := batch.pop()
+		if c := gp.schedClass; c == uint8(SchedClassBackground) || c == uint8(schedClassIdle) {
+			globrunqput(gp)
+			n--
+		} else {
+			q.pushBack(gp)
+		}
+	}
+	*batch = q
+	return n
+}
+
+// preemptBackground reports whether the background or idle goroutine
+// running on pp, for which pd records when it started running, should be
+// preempted because other goroutines are waiting to run, or pp has timers
+// to run. Idle goroutines get no time slice, and yield to background
+// goroutines too.
+func preemptBackground(pp *p, pd *sysmontick, now int64) bool {
+	var waiting int32
+	switch SchedClass(pp.runningClass.Load()) {
+	case SchedClassBackground:
+		if pd.schedwhen+backgroundPreemptNS > now {
+			return false
+		}
+		waiting = sched.runqsize - sched.bgrunqsize - sched.idlerunqsize
+	case schedClassIdle:
+		waiting = sched.runqsize - sched.idlerunqsize
+	default:
+		return false
+	}
+	if !runqempty(pp) || waiting > 0 {
+		return true
+	}
+	when := pp.timers.wakeTime()
+	return when != 0 && when <= now
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Goroutine scheduling events, for runtime/debug.SubscribeGoroutineEvents.
+//
+// While events are enabled, casgstatus records each goroutine state
+// transition in a fixed-size ring buffer, without locks or allocation.
+// A single reader goroutine, started by runtime/debug, drains the buffer
+// periodically and passes the events to the subscribers. Its own
+// transitions aren't recorded, so that reading events doesn't generate
+// more of them. When the buffer is full, events are dropped and counted.
+//
+// The buffer is a bounded queue with a sequence number per slot. Slot
+// i%len(buf) holds position i once its sequence number is i+1, and is
+// free to hold position i+len(buf) once the reader has set its sequence
+// number to i+len(buf). Writers claim positions by advancing head with a
+// compare-and-swap, and only claim free slots.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	"unsafe"
+)
+
+// Values of schedEvent.kind. They must match the values of
+// runtime/debug.GoroutineEventKind.
+const (
+	schedEventCreated = iota
+	schedEventRunnable
+	schedEventRunning
+	schedEventBlocked
+	schedEventSyscall
+	schedEventExited
+)
+
+// schedEventsLen is the number of events the buffer holds.
+const schedEventsLen = 1 << 16
+
+// schedEventSlot is an entry of the event buffer.
+type schedEventSlot struct {
+	seq    atomic.Uint64
+	goid   uint64
+	when   int64 // nanotime
+	kind   uint8
+	reason waitReason
+}
+
+var schedEvents struct {
+	enabled atomic.Bool
+
+	// reader is the ID of the goroutine reading events. Its own
+	// transitions aren't recorded.
+	reader atomic.Uint64
+
+	head    atomic.Uint64 // next position to write
+	tail    uint64        // next position to read; only used by the reader
+	dropped atomic.Uint64
+
+	buf *[schedEventsLen]schedEventSlot
+
+	// unixBase converts nanotime to Unix time.
+	unixBase int64
+}
+
+// schedEvent is a goroutine state transition.
+//
+// Its layout must match runtime/debug.schedEvent.
+type schedEvent struct {
+	kind     int
+	goid     uint64
+	unixNano int64
+	reason   string
+}
+
+// setSchedEvents enables or disables recording goroutine events. When
+// enabling, the calling goroutine becomes the reader, and any events left
+// in the buffer are discarded. Calls must not be concurrent with each
+// other or with readSchedEvents.
+//
+//go:linkname setSchedEvents runtime/debug.setSchedEvents
+func setSchedEvents(on bool) {
+	if !on {
+		schedEvents.enabled.Store(false)
+		return
+	}
+	if schedEvents.buf == nil {
+		buf := (*[schedEventsLen]schedEventSlot)(persistentalloc(unsafe.Sizeof(*schedEvents.buf), 8, &memstats.other_sys))
+		for i := range buf {
+			buf[i].seq.Store(uint64(i))
+		}
+		schedEvents.buf = buf
+	}
+	sec, nsec, mono := time_now()
+	schedEvents.unixBase = sec*1e9 + int64(nsec) - mono
+	schedEvents.reader.Store(getg().goid)
+	schedEvents.enabled.Store(true)
+
+	// Discard stale events.
+	var discard [64]schedEvent
+	for readSchedEvents(discard[:]) > 0 {
+	}
+	schedEvents.dropped.Store(0)
+}
+
+// readSchedEvents copies recorded events into buf and returns how many
+// it copied. It doesn't block.
+//
+//go:linkname readSchedEvents runtime/debug.readSchedEvents
+func readSchedEvents(buf []schedEvent) int {
+	if schedEvents.buf == nil {
+		return 0
+	}
+	n := 0
+	for n < len(buf) {
+		pos := schedEvents.tail
+		s := &schedEvents.buf[pos%schedEventsLen]
+		if s.seq.Load() != pos+1 {
+			// Empty, or still being written.
+			break
+		}
+		e := &buf[n]
+		e.kind = int(s.kind)
+		e.goid = s.goid
+		e.unixNano = schedEvents.unixBase + s.when
+		e.reason = ""
+		if s.kind == schedEventBlocked {
+			e.reason = s.reason.String()
+		}
+		s.seq.Store(pos + schedEventsLen)
+		schedEvents.tail = pos + 1
+		n++
+	}
+	return n
+}
+
+// schedEventsDropped returns the number of events dropped since the
+// last call.
+//
+//go:linkname schedEventsDropped runtime/debug.schedEventsDropped
+func schedEventsDropped() uint64 {
+	return schedEvents.dropped.Swap(0)
+}
+
+// recordSchedEvent records the transition of gp from oldval to newval,
+// if it's one that subscribers see. It's called by casgstatus.
+//
+//go:nosplit
+func recordSchedEvent(gp *g, oldval, newval uint32) {
+	var kind uint8
+	switch {
+	case oldval == _Gidle || oldval == _Gcopystack || newval == _Gcopystack:
+		return
+	case oldval == _Gdead:
+		kind = schedEventCreated
+	case newval == _Gdead:
+		kind = schedEventExited
+	case newval == _Grunnable:
+		kind = schedEventRunnable
+	case newval == _Grunning:
+		kind = schedEventRunning
+	case newval == _Gwaiting:
+		kind = schedEventBlocked
+	case newval == _Gsyscall:
+		kind = schedEventSyscall
+	default:
+		return
+	}
+	if gp.goid == schedEvents.reader.Load() {
+		return
+	}
+	buf := schedEvents.buf
+	for {
+		pos := schedEvents.head.Load()
+		s := &buf[pos%schedEventsLen]
+		seq := s.seq.Load()
+		if seq == pos {
+			if schedEvents.head.CompareAndSwap(pos, pos+1) {
+				s.goid = gp.goid
+				s.when = nanotime()
+				s.kind = kind
+				s.reason = gp.waitreason
+				s.seq.Store(pos + 1)
+				return
+			}
+		} else if seq < pos {
+			// The slot still holds an event the reader hasn't read.
+			schedEvents.dropped.Add(1)
+			return
+		}
+		// Another writer claimed pos first. Try the next one.
+	}
+}
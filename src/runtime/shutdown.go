@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Cooperative process shutdown, for runtime/debug.Shutdown.
+//
+// Once shutdown starts, timers started or reset through package time
+// never fire, so that no new timer-driven work begins. Timers started
+// before then, including context deadlines, fire as usual.
+// runtime/debug.Shutdown then runs a garbage collection, waits for the
+// finalizer goroutine to drain the finalizer queue, and stops CPU
+// profiling and tracing, through the hooks that runtime/pprof and
+// runtime/trace register when they start.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+var shutdown struct {
+	// started is set once shutdown starts. It's never cleared.
+	started atomic.Bool
+
+	// Hooks that stop CPU profiling and tracing, and wait for their
+	// output to be written.
+	stopCPUProfile atomic.Pointer[func()]
+	stopTrace      atomic.Pointer[func()]
+}
+
+//go:linkname startShutdown runtime/debug.startShutdown
+func startShutdown() {
+	shutdown.started.Store(true)
+}
+
+//go:linkname shutdownHooks runtime/debug.shutdownHooks
+func shutdownHooks() []func() {
+	var hooks []func()
+	if f := shutdown.stopCPUProfile.Load(); f != nil {
+		hooks = append(hooks, *f)
+	}
+	if f := shutdown.stopTrace.Load(); f != nil {
+		hooks = append(hooks, *f)
+	}
+	return hooks
+}
+
+//go:linkname pprof_setShutdownHook runtime/pprof.runtime_setShutdownHook
+func pprof_setShutdownHook(f func()) {
+	p := new(func())
+	*p = f
+	shutdown.stopCPUProfile.Store(p)
+}
+
+//go:linkname trace_setShutdownHook runtime/trace.runtime_setShutdownHook
+func trace_setShutdownHook(f func()) {
+	p := new(func())
+	*p = f
+	shutdown.stopTrace.Store(p)
+}
+
+// finqDrained, if not nil, is closed by the finalizer goroutine the next
+// time it finds the finalizer queue empty. It's protected by finlock.
+var finqDrained chan struct{}
+
+// finalizersDrained returns a channel that's closed once the finalizer
+// queue is empty and no finalizer or cleanup is running.
+//
+//go:linkname finalizersDrained runtime/debug.finalizersDrained
+func finalizersDrained() <-chan struct{} {
+	c := make(chan struct{})
+	drained := false
+	lock(&finlock)
+	if finqDrained != nil {
+		c = finqDrained
+	} else if finq == nil && (fing == nil || readgstatus(fing) == _Gwaiting && fing.waitreason == waitReasonFinalizerWait) {
+		drained = true
+	} else {
+		// The finalizer goroutine is running, or will be woken to
+		// run the queued finalizers.
+		finqDrained = c
+	}
+	unlock(&finlock)
+	if drained {
+		close(c)
+	}
+	return c
+}
@@ -908,6 +908,7 @@ func sigpanic() {
 			// but the fact that we faulted on accessing it is enough to prove
 			// that it is.
 			print("accessed data from freed user arena ", hex(gp.sigcode1), "\n")
+			printUserArenaOrigin(gp.sigcode1)
 		} else {
 			print("unexpected fault address ", hex(gp.sigcode1), "\n")
 		}
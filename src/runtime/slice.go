@@ -84,7 +84,7 @@ func makeslicecopy(et *_type, tolen int, fromlen int, from unsafe.Pointer) unsaf
 		asanread(from, copymem)
 	}
 
-	memmove(to, from, copymem)
+	memmoveLarge(to, from, copymem)
 
 	return to
 }
@@ -266,7 +266,7 @@ func growslice(oldPtr unsafe.Pointer, newLen, oldCap, num int, et *_type) slice
 		// Only clear the part that will not be overwritten.
 		// The reflect_growslice() that calls growslice will manually clear
 		// the region not cleared here.
-		memclrNoHeapPointers(add(p, newlenmem), capmem-newlenmem)
+		memclrNoHeapPointersLarge(add(p, newlenmem), capmem-newlenmem)
 	} else {
 		// Note: can't use rawmem (which avoids zeroing of memory), because then GC can scan uninitialized memory.
 		p = mallocgc(capmem, et, true)
@@ -280,7 +280,7 @@ func growslice(oldPtr unsafe.Pointer, newLen, oldCap, num int, et *_type) slice
 			bulkBarrierPreWriteSrcOnly(uintptr(p), uintptr(oldPtr), lenmem-et.Size_+et.PtrBytes, et)
 		}
 	}
-	memmove(p, oldPtr, lenmem)
+	memmoveLarge(p, oldPtr, lenmem)
 
 	return slice{p, newLen, newcap}
 }
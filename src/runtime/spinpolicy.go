@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Spinning and wakeup policy.
+//
+// See "Worker thread parking/unparking" at the top of proc.go for how Ms
+// spin and are woken. runtime/debug.SetSpinPolicy tunes three points:
+//
+//   - findRunnable lets an M without work start spinning while fewer
+//     than maxSpinningPercent of the busy Ps have a spinning M.
+//   - A spinning M that finds nothing to steal keeps looking, yielding
+//     the CPU between rounds, until it has been spinning for spinNS,
+//     before it gives up its P.
+//   - ready and newproc only call wakep once the readying P has at
+//     least wakeThreshold goroutines waiting to run, counting the new
+//     one. Below that, the goroutines wait for the P itself, or for an
+//     M that starts spinning for another reason.
+
+package runtime
+
+import (
+	"internal/runtime/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+var spin struct {
+	maxSpinningPercent atomic.Int32
+	spinNS             atomic.Int64
+	wakeThreshold      atomic.Int32
+}
+
+// spinInit sets the default spinning and wakeup policy.
+func spinInit() {
+	spin.maxSpinningPercent.Store(50)
+	spin.wakeThreshold.Store(1)
+}
+
+// spinPolicy is the spinning and wakeup policy.
+//
+// Its layout must match runtime/debug.SpinPolicy.
+type spinPolicy struct {
+	maxSpinningPercent int
+	spinNS             int64
+	wakeThreshold      int
+}
+
+//go:linkname readSpinPolicy runtime/debug.readSpinPolicy
+func readSpinPolicy(p *spinPolicy) {
+	*p = spinPolicy{
+		maxSpinningPercent: int(spin.maxSpinningPercent.Load()),
+		spinNS:             spin.spinNS.Load(),
+		wakeThreshold:      int(spin.wakeThreshold.Load()),
+	}
+}
+
+// setSpinPolicy sets the spinning and wakeup policy to *p, and replaces
+// *p with the old policy.
+//
+//go:linkname setSpinPolicy runtime/debug.setSpinPolicy
+func setSpinPolicy(p *spinPolicy) {
+	in := *p
+	readSpinPolicy(p)
+	spin.maxSpinningPercent.Store(int32(in.maxSpinningPercent))
+	spin.spinNS.Store(in.spinNS)
+	spin.wakeThreshold.Store(int32(min(in.wakeThreshold, 1<<31-1)))
+}
+
+// canSpin reports whether an M that isn't spinning may start spinning,
+// given the current number of spinning Ms.
+func canSpin() bool {
+	busy := gomaxprocs - sched.npidle.Load()
+	return 100*sched.nmspinning.Load() < spin.maxSpinningPercent.Load()*busy
+}
+
+// wakepReady calls wakep after a goroutine became runnable on pp,
+// unless fewer than wakeThreshold goroutines wait on pp's run queue.
+func wakepReady(pp *p) {
+	if n := spin.wakeThreshold.Load(); n > 1 {
+		waiting := atomic.Load(&pp.runqtail) - atomic.Load(&pp.runqhead)
+		if pp.runnext != 0 {
+			waiting++
+		}
+		if int32(waiting) < n {
+			return
+		}
+	}
+	wakep()
+}
@@ -1034,7 +1034,7 @@ func newstack() {
 	// into a real deadlock.
 	preempt := stackguard0 == stackPreempt
 	if preempt {
-		if !canPreemptM(thisg.m) {
+		if !canPreemptM(thisg.m) || inCritical(gp) {
 			// Let the goroutine keep running for now.
 			// gp->preempt is set, so it will be preempted next time.
 			gp.stackguard0 = gp.stack.lo + stackGuard
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -951,6 +952,22 @@ func TestTimeBeginPeriod(t *testing.T) {
 	}
 }
 
+func TestSemasleepHighResTimer(t *testing.T) {
+	if !runtime.HaveHighResTimer() {
+		t.Skip("no high resolution timer")
+	}
+	// With the system timer resolution, typically 15.6ms, each sleep
+	// would take at least 15ms.
+	const n = 10
+	start := time.Now()
+	for range n {
+		runtime.Semasleep(int64(time.Millisecond))
+	}
+	if elapsed := time.Since(start); elapsed > n*10*time.Millisecond {
+		t.Errorf("%d 1ms semaphore sleeps took %v", n, elapsed)
+	}
+}
+
 // removeOneCPU removes one (any) cpu from affinity mask.
 // It returns new affinity mask.
 func removeOneCPU(mask uintptr) (uintptr, error) {
@@ -26,6 +26,58 @@ func init() {
 	register("GCZombie", GCZombie)
 	register("GCMemoryLimit", GCMemoryLimit)
 	register("GCMemoryLimitNoGCPercent", GCMemoryLimitNoGCPercent)
+	register("GCTraceJSON", GCTraceJSON)
+	register("HeapTypes", HeapTypes)
+}
+
+func GCTraceJSON() {
+	runtime.GC()
+	runtime.GC()
+	fmt.Println("OK")
+}
+
+type heapTypesObj struct {
+	next *heapTypesObj
+	x    [3]int
+}
+
+var heapTypesObjs []*heapTypesObj
+
+func HeapTypes() {
+	const n = 1000
+	count := func() (objects, bytes uint64) {
+		for _, st := range debug.ReadHeapTypeStats() {
+			if st.Type == "main.heapTypesObj" {
+				return st.Objects, st.Bytes
+			}
+		}
+		return 0, 0
+	}
+
+	for range n {
+		heapTypesObjs = append(heapTypesObjs, new(heapTypesObj))
+	}
+	runtime.GC()
+	size := uint64(unsafe.Sizeof(heapTypesObj{}))
+	if objects, bytes := count(); objects < n || bytes < n*size {
+		fmt.Printf("counted %d objects in %d bytes of heapTypesObj, want at least %d objects of %d bytes\n", objects, bytes, n, size)
+		return
+	}
+	s := []metrics.Sample{{Name: "/gc/heap/types:types"}}
+	metrics.Read(s)
+	if s[0].Value.Uint64() == 0 {
+		fmt.Println("/gc/heap/types:types is 0")
+		return
+	}
+
+	heapTypesObjs = nil
+	runtime.GC()
+	// Allow for a few objects kept live by conservative scanning.
+	if objects, _ := count(); objects > n/10 {
+		fmt.Printf("counted %d objects of heapTypesObj after dropping them\n", objects)
+		return
+	}
+	fmt.Println("OK")
 }
 
 func GCSys() {
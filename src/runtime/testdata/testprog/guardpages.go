@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+func init() {
+	register("GuardPages", GuardPages)
+	register("GuardPagesFault", GuardPagesFault)
+	register("GuardPagesOverwrite", GuardPagesOverwrite)
+}
+
+// guardPagesSize is the size of the large objects the tests allocate.
+// It isn't a multiple of the page size, so the objects' last pages
+// have room after their ends.
+const guardPagesSize = 40000
+
+var guardPagesSink []byte
+
+// GuardPages allocates and frees large objects with GODEBUG=guardpages=1
+// without going out of bounds.
+func GuardPages() {
+	for i := 0; i < 100; i++ {
+		b := make([]byte, guardPagesSize+i)
+		for j := range b {
+			b[j] = byte(j)
+		}
+		guardPagesSink = b
+		p := make([]*int, guardPagesSize/8+i)
+		for j := range p {
+			p[j] = new(int)
+		}
+		runtime.GC()
+		for j := range b {
+			if b[j] != byte(j) {
+				panic("corrupted large object")
+			}
+		}
+		runtime.KeepAlive(p)
+	}
+	guardPagesSink = nil
+	runtime.GC()
+	fmt.Println("OK")
+}
+
+// GuardPagesFault writes to the page after a large object.
+func GuardPagesFault() {
+	guardPagesSink = make([]byte, guardPagesSize)
+	end := unsafe.Pointer(unsafe.SliceData(guardPagesSink))
+	end = unsafe.Add(end, (guardPagesSize+8191)&^8191)
+	*(*byte)(end) = 1
+	fmt.Println("no fault")
+}
+
+// GuardPagesOverwrite writes just past the end of a large object and
+// frees it.
+func GuardPagesOverwrite() {
+	guardPagesSink = make([]byte, guardPagesSize)
+	*(*byte)(unsafe.Add(unsafe.Pointer(unsafe.SliceData(guardPagesSink)), guardPagesSize)) = 1
+	guardPagesSink = nil
+	runtime.GC()
+	runtime.GC()
+	fmt.Println("no crash")
+}
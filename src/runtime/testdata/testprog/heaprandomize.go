@@ -0,0 +1,68 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+)
+
+func init() {
+	register("HeapRandomize", HeapRandomize)
+}
+
+type heapRandomizeNode struct {
+	next *heapRandomizeNode
+	val  int
+}
+
+// HeapRandomize allocates, frees and checks linked lists of small
+// objects with GODEBUG=heaprandomize=1, and prints the address of a
+// heap object, which should differ between runs.
+func HeapRandomize() {
+	var lists [8]*heapRandomizeNode
+	outOfOrder := false
+	for round := 0; round < 20; round++ {
+		for i := range lists {
+			lists[i] = nil
+			var prev uintptr
+			for j := 0; j < 1000; j++ {
+				n := &heapRandomizeNode{next: lists[i], val: j}
+				if p := uintptr(unsafe.Pointer(n)); p < prev {
+					outOfOrder = true
+				} else {
+					prev = p
+				}
+				lists[i] = n
+			}
+		}
+		// Drop half the lists, so their objects are reused.
+		for i := 0; i < len(lists); i += 2 {
+			lists[i] = nil
+		}
+		runtime.GC()
+		for i := 1; i < len(lists); i += 2 {
+			j := 1000
+			for n := lists[i]; n != nil; n = n.next {
+				j--
+				if n.val != j {
+					fmt.Printf("list %d: got %d, want %d\n", i, n.val, j)
+					os.Exit(1)
+				}
+			}
+			if j != 0 {
+				fmt.Printf("list %d: %d nodes missing\n", i, j)
+				os.Exit(1)
+			}
+		}
+	}
+	if !outOfOrder {
+		fmt.Println("objects were allocated in address order")
+		os.Exit(1)
+	}
+	fmt.Printf("%p\n", lists[1])
+}
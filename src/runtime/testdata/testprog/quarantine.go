@@ -0,0 +1,72 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+func init() {
+	register("Quarantine", Quarantine)
+	register("QuarantineWrite", QuarantineWrite)
+}
+
+type quarantineNode struct {
+	next *quarantineNode
+	val  int
+	pad  [100]byte
+}
+
+var quarantineSink any
+
+// Quarantine allocates and drops objects of various sizes with
+// GODEBUG=quarantine=2 and checks that the live ones are intact.
+func Quarantine() {
+	var live *quarantineNode
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 1000; j++ {
+			n := &quarantineNode{next: live, val: j}
+			if j%10 == 0 {
+				live = n
+			}
+			quarantineSink = make([]*int, j%200)
+			quarantineSink = make([]byte, j)
+		}
+		runtime.GC()
+		for n := live; n != nil; n = n.next {
+			if n.val%10 != 0 {
+				panic("corrupted object")
+			}
+		}
+		if i%10 == 9 {
+			live = nil
+		}
+	}
+	fmt.Println("OK")
+}
+
+type quarantineObj struct {
+	a [8]uint64
+}
+
+//go:noinline
+func quarantineAlloc() uintptr {
+	o := new(quarantineObj)
+	quarantineSink = o
+	quarantineSink = nil
+	return uintptr(unsafe.Pointer(o))
+}
+
+// QuarantineWrite writes to an object after it becomes unreachable.
+func QuarantineWrite() {
+	p := quarantineAlloc()
+	runtime.GC()
+	(*quarantineObj)(unsafe.Pointer(p)).a[3] = 1
+	runtime.GC()
+	runtime.GC()
+	fmt.Println("no error")
+}
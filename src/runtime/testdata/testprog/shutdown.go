@@ -0,0 +1,117 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	register("Shutdown", Shutdown)
+	register("ShutdownTimeout", ShutdownTimeout)
+}
+
+type shutdownObj struct {
+	_ [16]byte
+}
+
+// Shutdown checks that runtime/debug.Shutdown runs pending finalizers
+// and cleanups, finishes the CPU profile and the trace, and keeps new
+// timers from firing.
+func Shutdown() {
+	var cpuProf, tr bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpuProf); err != nil {
+		panic(err)
+	}
+	if err := trace.Start(&tr); err != nil {
+		panic(err)
+	}
+
+	var finalized, cleaned atomic.Bool
+	func() {
+		p := new(shutdownObj)
+		runtime.SetFinalizer(p, func(*shutdownObj) {
+			time.Sleep(10 * time.Millisecond)
+			finalized.Store(true)
+		})
+		q := new(shutdownObj)
+		runtime.AddCleanup(q, func(int) {
+			cleaned.Store(true)
+		}, 0)
+	}()
+
+	if !debug.Shutdown(time.Minute) {
+		fmt.Println("Shutdown timed out")
+		return
+	}
+	if !finalized.Load() {
+		fmt.Println("finalizer didn't run")
+		return
+	}
+	if !cleaned.Load() {
+		fmt.Println("cleanup didn't run")
+		return
+	}
+	if trace.IsEnabled() {
+		fmt.Println("still tracing")
+		return
+	}
+	if cpuProf.Len() == 0 || tr.Len() == 0 {
+		fmt.Printf("CPU profile has %d bytes, trace %d bytes\n", cpuProf.Len(), tr.Len())
+		return
+	}
+
+	var fired atomic.Bool
+	time.AfterFunc(time.Millisecond, func() {
+		fired.Store(true)
+	})
+	t := time.NewTimer(time.Hour)
+	t.Reset(time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if fired.Load() {
+		fmt.Println("timer started after Shutdown fired")
+		return
+	}
+	select {
+	case <-t.C:
+		fmt.Println("timer reset after Shutdown fired")
+		return
+	default:
+	}
+	fmt.Println("OK")
+}
+
+// ShutdownTimeout checks that runtime/debug.Shutdown returns once its
+// timeout has elapsed, even if a finalizer never returns, and that
+// timers stay stopped afterwards.
+func ShutdownTimeout() {
+	func() {
+		p := new(shutdownObj)
+		runtime.SetFinalizer(p, func(*shutdownObj) {
+			select {}
+		})
+	}()
+	if debug.Shutdown(100 * time.Millisecond) {
+		fmt.Println("Shutdown finished with a blocked finalizer")
+		return
+	}
+
+	t := time.NewTimer(time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-t.C:
+		fmt.Println("timer started after Shutdown timed out fired")
+		return
+	default:
+	}
+	fmt.Println("OK")
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func init() {
+	register("SizeClasses", SizeClasses)
+}
+
+type sizeClassesNode struct {
+	next *sizeClassesNode
+	pad  [4]uintptr
+}
+
+// SizeClasses prints the size that allocations of various sizes are
+// rounded up to, after exercising the heap with objects of one of the
+// classes that GODEBUG=sizeclasses=fine adds on 64-bit systems.
+func SizeClasses() {
+	var list *sizeClassesNode
+	for i := 0; i < 100000; i++ {
+		list = &sizeClassesNode{next: list}
+		if i%3 == 0 {
+			list = list.next
+		}
+	}
+	runtime.GC()
+	n := 0
+	for ; list != nil; list = list.next {
+		n++
+	}
+	if n != 66666 {
+		fmt.Printf("list has %d nodes, want 66666\n", n)
+	}
+
+	for _, size := range []int{20, 33, 100, 300, 5000} {
+		b := append([]byte(nil), make([]byte, size)...)
+		fmt.Printf("%d:%d\n", size, cap(b))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !android
+
+#define _GNU_SOURCE
+#include <assert.h>
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/syscall.h>
+#include <unistd.h>
+#include <ucontext.h>
+
+extern int cgoStackRecurse(int);
+
+// cgoThreadStackSize returns the stack size of the current thread, or 0
+// if it's the main thread.
+int cgoThreadStackSize(void) {
+	pthread_attr_t attr;
+	size_t size;
+
+	if (syscall(SYS_gettid) == getpid()) {
+		return 0;
+	}
+	assert(pthread_getattr_np(pthread_self(), &attr) == 0);
+	assert(pthread_attr_getstacksize(&attr, &size) == 0);
+	pthread_attr_destroy(&attr);
+	return size;
+}
+
+// callCgoStackRecurse uses 64 kB of stack and calls back into Go.
+int callCgoStackRecurse(int n) {
+	volatile char buf[64 << 10];
+
+	memset((char*)buf, 0, sizeof buf);
+	return cgoStackRecurse(n) + buf[n];
+}
+
+// musl libc does not provide getcontext, etc. Assume any non-glibc
+// linux is using musl.
+#if !defined(__GLIBC__)
+int callCgoStackRecurseOnStack(int n) {
+	printf("SKIP\n");
+	exit(0);
+}
+#else
+
+#define STACK_SIZE (1 << 20)
+
+static ucontext_t uctx_save, uctx_switch;
+static int recurseN, recurseResult;
+
+static void recurseOnStack(void) {
+	recurseResult = cgoStackRecurse(recurseN);
+}
+
+static void *recurseThread(void *arg) {
+	char *stack = malloc(STACK_SIZE);
+
+	assert(stack != NULL);
+	assert(getcontext(&uctx_switch) == 0);
+	uctx_switch.uc_stack.ss_sp = stack;
+	uctx_switch.uc_stack.ss_size = STACK_SIZE;
+	uctx_switch.uc_link = &uctx_save;
+	makecontext(&uctx_switch, recurseOnStack, 0);
+	assert(swapcontext(&uctx_save, &uctx_switch) == 0);
+	free(stack);
+	return NULL;
+}
+
+// callCgoStackRecurseOnStack makes n nested calls between C and Go on a
+// new thread, running on a stack allocated with malloc, so that the
+// runtime can't find its bounds.
+int callCgoStackRecurseOnStack(int n) {
+	pthread_t thread;
+
+	recurseN = n;
+	assert(pthread_create(&thread, NULL, recurseThread, NULL) == 0);
+	assert(pthread_join(thread, NULL) == 0);
+	return recurseResult;
+}
+
+#endif
@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !android
+
+package main
+
+/*
+int cgoThreadStackSize(void);
+int callCgoStackRecurse(int);
+int callCgoStackRecurseOnStack(int);
+*/
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func init() {
+	register("CgoThreadStack", CgoThreadStack)
+	register("CgoCallbackStack", CgoCallbackStack)
+}
+
+// CgoThreadStack checks that the threads the runtime creates have the
+// stack size set by GODEBUG=cgothreadstack, which the test sets to 4 MB.
+func CgoThreadStack() {
+	for range 100 {
+		c := make(chan int)
+		go func() {
+			// Keep this goroutine's thread from running the next
+			// one, in case it's the main thread.
+			runtime.LockOSThread()
+			c <- int(C.cgoThreadStackSize())
+		}()
+		size := <-c
+		if size == 0 {
+			// On the main thread, which the runtime didn't create.
+			continue
+		}
+		if size != 4<<20 {
+			fmt.Printf("thread stack size is %d, want %d\n", size, 4<<20)
+			os.Exit(1)
+		}
+		fmt.Println("OK")
+		return
+	}
+	fmt.Println("no goroutine ran on a thread created by the runtime")
+}
+
+//export cgoStackRecurse
+func cgoStackRecurse(n C.int) C.int {
+	if n == 0 {
+		return 0
+	}
+	return C.callCgoStackRecurse(n-1) + 1
+}
+
+// CgoCallbackStack makes nested calls between C and Go, on a C thread
+// running on a stack the runtime can't find the bounds of, with C code
+// using more than the 32 kB of stack the runtime assumes by default
+// before calling back into Go.
+func CgoCallbackStack() {
+	if n := C.callCgoStackRecurseOnStack(4); n != 4 {
+		fmt.Printf("recursed %d times, want 4\n", n)
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+func init() {
+	register("NetpollURing", NetpollURing)
+}
+
+// NetpollURing exercises the network poller, which is expected to be
+// using an io_uring, with GODEBUG=netpolluring=1.
+func NetpollURing() {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer ln.Close()
+
+	// The poller is set up now. Check what it's using.
+	fds, _ := filepath.Glob("/proc/self/fd/*")
+	var uring, epoll bool
+	for _, fd := range fds {
+		switch link, _ := os.Readlink(fd); link {
+		case "anon_inode:[io_uring]":
+			uring = true
+		case "anon_inode:[eventpoll]":
+			epoll = true
+		}
+	}
+	if !uring || epoll {
+		fmt.Println("SKIP: poller isn't using io_uring")
+		return
+	}
+
+	// Echo server.
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(c, c)
+				c.Close()
+			}()
+		}
+	}()
+
+	// Many connections, each blocking in reads and writes.
+	const conns = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, conns)
+	for i := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer c.Close()
+			msg := make([]byte, 64<<10)
+			for j := range msg {
+				msg[j] = byte(i + j)
+			}
+			go c.Write(msg)
+			buf := make([]byte, len(msg))
+			if _, err := io.ReadFull(c, buf); err != nil {
+				errs <- err
+				return
+			}
+			for j := range buf {
+				if buf[j] != msg[j] {
+					errs <- fmt.Errorf("connection %d: wrong byte at %d", i, j)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		fmt.Println(err)
+		return
+	}
+
+	// A read deadline.
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	c.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		fmt.Println("read with deadline:", err)
+		return
+	}
+
+	// Closing a connection while it's being read.
+	c.SetReadDeadline(time.Time{})
+	done := make(chan error)
+	go func() {
+		_, err := c.Read(make([]byte, 1))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	if err := <-done; !errors.Is(err, net.ErrClosed) {
+		fmt.Println("read of closed connection:", err)
+		return
+	}
+
+	// Regular files aren't pollable, like with epoll.
+	f, err := os.Open("/proc/self/exe")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer f.Close()
+	if err := f.SetReadDeadline(time.Now()); !errors.Is(err, os.ErrNoDeadline) {
+		fmt.Println("deadline on regular file:", err)
+		return
+	}
+
+	fmt.Println("OK")
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+// ThreadAttr holds scheduling attributes to set on an operating system
+// thread with [LockOSThreadAttr]. The zero value leaves all of the
+// thread's attributes unchanged.
+type ThreadAttr struct {
+	// CPUs, if not empty, lists the CPUs the thread may run on.
+	CPUs []int
+
+	// SetNice is whether to set the thread's nice value to Nice.
+	// Lower nice values get more CPU time, and lowering the nice
+	// value may require privileges.
+	SetNice bool
+	Nice    int
+
+	// FIFOPriority, if positive, runs the thread under the real-time
+	// SCHED_FIFO policy with that priority, which is 1 to 99 on Linux.
+	// A SCHED_FIFO thread runs until it blocks or a thread with a
+	// higher priority is ready, so it can starve the rest of the
+	// system. It usually requires privileges.
+	FIFOPriority int
+}
+
+// A ThreadAttrError is returned by [LockOSThreadAttr] when it fails to
+// set an attribute of the thread.
+type ThreadAttrError struct {
+	Op string // the system call that failed, such as "sched_setaffinity"
+
+	// Errno is the error number returned by the system call, which
+	// converts to a syscall.Errno, or 0 if the attribute isn't
+	// supported on this system.
+	Errno uintptr
+}
+
+func (e *ThreadAttrError) Error() string {
+	if e.Errno == 0 {
+		return "runtime: " + e.Op + " not supported on " + GOOS
+	}
+	var buf [20]byte
+	return "runtime: " + e.Op + " failed: errno " + string(itoa(buf[:], uint64(e.Errno)))
+}
+
+// LockOSThreadAttr is like [LockOSThread], but also sets the scheduling
+// attributes in attr on the thread. The thread's previous attributes are
+// restored when the goroutine unwires from it, by calling
+// [UnlockOSThread] as many times as it called LockOSThread and
+// LockOSThreadAttr. If they can't be restored, for example because
+// restoring a lower nice value requires privileges, the goroutine stays
+// wired to the thread, and the thread is terminated when the goroutine
+// exits.
+//
+// If it fails to set an attribute, LockOSThreadAttr restores the
+// attributes it set, leaves the goroutine's locking unchanged, and
+// returns a *[ThreadAttrError]. Only Linux supports thread attributes;
+// elsewhere LockOSThreadAttr fails unless attr is the zero value.
+func LockOSThreadAttr(attr ThreadAttr) error {
+	LockOSThread()
+	if len(attr.CPUs) == 0 && !attr.SetNice && attr.FIFOPriority <= 0 {
+		return nil
+	}
+	// Set the attributes, saving their current values to undo this
+	// call if it fails.
+	var prev threadAttrState
+	if err := setThreadAttr(&prev, &attr); err != nil {
+		if !restoreThreadAttrState(&prev) {
+			// Leave it to UnlockOSThread.
+			saveThreadAttr(&prev)
+		}
+		UnlockOSThread()
+		return err
+	}
+	saveThreadAttr(&prev)
+	return nil
+}
+
+// saveThreadAttr saves the attributes in s for UnlockOSThread to
+// restore, unless an earlier call to LockOSThreadAttr saved them.
+func saveThreadAttr(s *threadAttrState) {
+	mp := getg().m
+	if mp.threadAttr == nil {
+		mp.threadAttr = new(threadAttrState)
+	}
+	mp.threadAttr.merge(s)
+}
+
+// restoreThreadAttr restores the scheduling attributes of the current
+// thread that LockOSThreadAttr changed, and reports whether it
+// succeeded. The calling goroutine must be locked to the thread.
+func restoreThreadAttr() bool {
+	mp := getg().m
+	if !restoreThreadAttrState(mp.threadAttr) {
+		return false
+	}
+	mp.threadAttr = nil
+	return true
+}
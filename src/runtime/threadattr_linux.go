@@ -0,0 +1,141 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"internal/goarch"
+	"internal/runtime/syscall"
+	"unsafe"
+)
+
+const (
+	_PRIO_PROCESS = 0
+	_SCHED_FIFO   = 1
+)
+
+// threadAttrState holds scheduling attributes of a thread, to restore
+// them later. Each attribute is only set if it was saved.
+type threadAttrState struct {
+	cpus []byte // CPU affinity mask, or nil
+
+	saveNice bool
+	nice     int32
+
+	saveSched bool
+	policy    int32
+	priority  int32
+}
+
+// merge saves the attributes in s2 that s doesn't already have.
+func (s *threadAttrState) merge(s2 *threadAttrState) {
+	if s.cpus == nil {
+		s.cpus = s2.cpus
+	}
+	if !s.saveNice && s2.saveNice {
+		s.saveNice, s.nice = true, s2.nice
+	}
+	if !s.saveSched && s2.saveSched {
+		s.saveSched, s.policy, s.priority = true, s2.policy, s2.priority
+	}
+}
+
+// setThreadAttr sets the attributes in attr on the current thread,
+// after saving their values in s.
+func setThreadAttr(s *threadAttrState, attr *ThreadAttr) error {
+	if len(attr.CPUs) > 0 {
+		// The kernel's mask is an array of unsigned longs.
+		const bits = goarch.PtrSize * 8
+		var mask []uintptr
+		for _, cpu := range attr.CPUs {
+			if cpu < 0 {
+				return &ThreadAttrError{"sched_setaffinity", _EINVAL}
+			}
+			if n := cpu/bits + 1; n > len(mask) {
+				mask = append(mask, make([]uintptr, n-len(mask))...)
+			}
+			mask[cpu/bits] |= 1 << (cpu % bits)
+		}
+		cpus, errno := threadAffinity()
+		if errno != 0 {
+			return &ThreadAttrError{"sched_getaffinity", errno}
+		}
+		s.cpus = cpus
+		if errno := setThreadAffinity(unsafe.Slice((*byte)(unsafe.Pointer(&mask[0])), len(mask)*goarch.PtrSize)); errno != 0 {
+			return &ThreadAttrError{"sched_setaffinity", errno}
+		}
+	}
+	if attr.SetNice {
+		// The system call returns 20-nice, so that it's never negative.
+		r, _, errno := syscall.Syscall6(syscall.SYS_GETPRIORITY, _PRIO_PROCESS, 0, 0, 0, 0, 0)
+		if errno != 0 {
+			return &ThreadAttrError{"getpriority", errno}
+		}
+		s.saveNice, s.nice = true, 20-int32(r)
+		// On Linux, setpriority of process 0 sets the calling thread's
+		// nice value, not the whole process's.
+		if _, _, errno := syscall.Syscall6(syscall.SYS_SETPRIORITY, _PRIO_PROCESS, 0, uintptr(attr.Nice), 0, 0, 0); errno != 0 {
+			return &ThreadAttrError{"setpriority", errno}
+		}
+	}
+	if attr.FIFOPriority > 0 {
+		r, _, errno := syscall.Syscall6(syscall.SYS_SCHED_GETSCHEDULER, 0, 0, 0, 0, 0, 0)
+		if errno != 0 {
+			return &ThreadAttrError{"sched_getscheduler", errno}
+		}
+		var priority int32
+		if _, _, errno := syscall.Syscall6(syscall.SYS_SCHED_GETPARAM, 0, uintptr(unsafe.Pointer(&priority)), 0, 0, 0, 0); errno != 0 {
+			return &ThreadAttrError{"sched_getparam", errno}
+		}
+		s.saveSched, s.policy, s.priority = true, int32(r), priority
+		if errno := setThreadScheduler(_SCHED_FIFO, int32(attr.FIFOPriority)); errno != 0 {
+			return &ThreadAttrError{"sched_setscheduler", errno}
+		}
+	}
+	return nil
+}
+
+// restoreThreadAttrState restores the attributes saved in s on the
+// current thread, and reports whether it succeeded. It clears the
+// attributes that it restores from s.
+func restoreThreadAttrState(s *threadAttrState) bool {
+	if s.cpus != nil && setThreadAffinity(s.cpus) == 0 {
+		s.cpus = nil
+	}
+	if s.saveNice {
+		if _, _, errno := syscall.Syscall6(syscall.SYS_SETPRIORITY, _PRIO_PROCESS, 0, uintptr(s.nice), 0, 0, 0); errno == 0 {
+			s.saveNice = false
+		}
+	}
+	if s.saveSched && setThreadScheduler(s.policy, s.priority) == 0 {
+		s.saveSched = false
+	}
+	return s.cpus == nil && !s.saveNice && !s.saveSched
+}
+
+// threadAffinity returns the CPU affinity mask of the current thread.
+func threadAffinity() ([]byte, uintptr) {
+	// The buffer must be at least as large as the kernel's CPU mask,
+	// whose size we don't know. Start with room for 1024 CPUs.
+	for n := 128; ; n *= 2 {
+		buf := make([]byte, n)
+		r := sched_getaffinity(0, uintptr(n), &buf[0])
+		if r > 0 {
+			return buf[:r], 0
+		}
+		if r != -_EINVAL || n >= 1<<16 {
+			return nil, uintptr(-r)
+		}
+	}
+}
+
+func setThreadAffinity(mask []byte) uintptr {
+	_, _, errno := syscall.Syscall6(syscall.SYS_SCHED_SETAFFINITY, 0, uintptr(len(mask)), uintptr(unsafe.Pointer(&mask[0])), 0, 0, 0)
+	return errno
+}
+
+func setThreadScheduler(policy, priority int32) uintptr {
+	_, _, errno := syscall.Syscall6(syscall.SYS_SCHED_SETSCHEDULER, 0, uintptr(policy), uintptr(unsafe.Pointer(&priority)), 0, 0, 0)
+	return errno
+}
@@ -0,0 +1,19 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package runtime
+
+type threadAttrState struct{}
+
+func (s *threadAttrState) merge(s2 *threadAttrState) {}
+
+func setThreadAttr(s *threadAttrState, attr *ThreadAttr) error {
+	return &ThreadAttrError{Op: "LockOSThreadAttr"}
+}
+
+func restoreThreadAttrState(s *threadAttrState) bool {
+	return true
+}
@@ -62,6 +62,12 @@ type timer struct {
 	arg    any
 	seq    uintptr
 
+	// slack is how long after when the timer may run, so that it can
+	// run together with other timers. The timer runs no earlier than
+	// when, and otherwise as soon as its deadline, when+slack, is past.
+	// See timers.run.
+	slack int64
+
 	// If non-nil, the timers containing t.
 	ts *timers
 
@@ -86,7 +92,8 @@ type timers struct {
 	// access the timers of another P, so we have to lock.
 	mu mutex
 
-	// heap is the set of timers, ordered by heap[i].when.
+	// heap is the set of timers, ordered by heap[i].when,
+	// which is the timer's deadline (see timer.deadline).
 	// Must hold lock to access.
 	heap []timerWhen
 
@@ -114,7 +121,8 @@ type timers struct {
 
 type timerWhen struct {
 	timer *timer
-	when  int64
+	when  int64 // t.deadline()
+	slack int64 // t.slack
 }
 
 func (ts *timers) lock() {
@@ -241,7 +249,7 @@ func (t *timer) updateHeap() (updated bool) {
 	if t.state&timerModified != 0 {
 		// Update ts.heap[0].when and move within heap.
 		t.state &^= timerModified
-		ts.heap[0].when = t.when
+		ts.heap[0].when, ts.heap[0].slack = t.deadline(), t.slack
 		ts.siftDown(0)
 		ts.updateMinWhenHeap()
 		return true
@@ -253,6 +261,16 @@ func (t *timer) updateHeap() (updated bool) {
 // maxWhen is the maximum value for timer's when field.
 const maxWhen = 1<<63 - 1
 
+// deadline returns the time by which t should run: t.when plus its slack.
+// The caller must hold t's lock, or the world can be stopped instead.
+func (t *timer) deadline() int64 {
+	d := t.when + t.slack
+	if d < t.when { // check for overflow.
+		d = maxWhen
+	}
+	return d
+}
+
 // verifyTimers can be set to true to add debugging checks that the
 // timer heaps are valid.
 const verifyTimers = false
@@ -322,7 +340,13 @@ func newTimer(when, period int64, f func(arg any, seq uintptr, delay int64), arg
 			throw("invalid timer channel: no capacity")
 		}
 	}
-	t.modify(when, period, f, arg, 0)
+	t.slack = defaultTimerSlack.Load()
+	if shutdown.started.Load() {
+		// Timers started during shutdown never fire. See shutdown.go.
+		t.f, t.arg, t.period = f, arg, period
+	} else {
+		t.modify(when, period, f, arg, 0)
+	}
 	t.init = true
 	return t
 }
@@ -344,9 +368,28 @@ func resetTimer(t *timeTimer, when, period int64) bool {
 	if raceenabled {
 		racerelease(unsafe.Pointer(&t.timer))
 	}
+	if shutdown.started.Load() {
+		// Timers reset during shutdown never fire. See shutdown.go.
+		return t.stop()
+	}
 	return t.reset(when, period)
 }
 
+// setTimerSlack sets the slack of a time.Timer or time.Ticker.
+//
+//go:linkname setTimerSlack time.setTimerSlack
+func setTimerSlack(t *timeTimer, slack int64) {
+	t.setSlack(slack)
+}
+
+// defaultTimerSlack is the slack of timers created by package time.
+var defaultTimerSlack atomic.Int64
+
+//go:linkname setDefaultTimerSlack runtime/debug.setDefaultTimerSlack
+func setDefaultTimerSlack(slack int64) int64 {
+	return defaultTimerSlack.Swap(max(slack, 0))
+}
+
 // Go runtime.
 
 // Ready the goroutine arg.
@@ -371,7 +414,7 @@ func (ts *timers) addHeap(t *timer) {
 		throw("ts set in timer")
 	}
 	t.ts = ts
-	ts.heap = append(ts.heap, timerWhen{t, t.when})
+	ts.heap = append(ts.heap, timerWhen{t, t.deadline(), t.slack})
 	ts.siftUp(len(ts.heap) - 1)
 	if t == ts.heap[0].timer {
 		ts.updateMinWhenHeap()
@@ -501,7 +544,6 @@ func (t *timer) modify(when, period int64, f func(arg any, seq uintptr, delay in
 	pending := t.when > 0
 	t.when = when
 	if t.state&timerHeaped != 0 {
-		t.state |= timerModified
 		if t.state&timerZombie != 0 {
 			// In the heap but marked for removal (by a Stop).
 			// Unmark it, since it has been Reset and will be running again.
@@ -510,16 +552,11 @@ func (t *timer) modify(when, period int64, f func(arg any, seq uintptr, delay in
 		}
 		// The corresponding heap[i].when is updated later.
 		// See comment in type timer above and in timers.adjust below.
-		if min := t.ts.minWhenModified.Load(); min == 0 || when < min {
-			wake = true
-			// Force timerModified bit out to t.astate before updating t.minWhenModified,
-			// to synchronize with t.ts.adjust. See comment in adjust.
-			t.astate.Store(t.state)
-			t.ts.updateMinWhenModified(when)
-		}
+		wake = t.markModified()
 	}
 
 	add := t.needsAdd()
+	deadline := t.deadline()
 
 	if !async && t.isChan {
 		// Stop any future sends with stale values.
@@ -538,12 +575,48 @@ func (t *timer) modify(when, period int64, f func(arg any, seq uintptr, delay in
 		t.maybeAdd()
 	}
 	if wake {
-		wakeNetPoller(when)
+		wakeNetPoller(deadline)
 	}
 
 	return pending
 }
 
+// markModified records that t's deadline has changed, for a timer in
+// a heap that isn't marked for removal, and reports whether the poller
+// needs to be woken for the new deadline.
+// t must be locked.
+func (t *timer) markModified() bool {
+	assertLockHeld(&t.mu)
+	t.state |= timerModified
+	if min := t.ts.minWhenModified.Load(); min == 0 || t.deadline() < min {
+		// Force timerModified bit out to t.astate before updating t.minWhenModified,
+		// to synchronize with t.ts.adjust. See comment in adjust.
+		t.astate.Store(t.state)
+		t.ts.updateMinWhenModified(t.deadline())
+		return true
+	}
+	return false
+}
+
+// setSlack sets t's slack.
+func (t *timer) setSlack(slack int64) {
+	if slack < 0 {
+		slack = 0
+	}
+	t.lock()
+	t.trace("setSlack")
+	t.slack = slack
+	wake := false
+	if t.state&timerHeaped != 0 && t.state&timerZombie == 0 {
+		wake = t.markModified()
+	}
+	deadline := t.deadline()
+	t.unlock()
+	if wake {
+		wakeNetPoller(deadline)
+	}
+}
+
 // needsAdd reports whether t needs to be added to a timers heap.
 // t must be locked.
 func (t *timer) needsAdd() bool {
@@ -595,7 +668,7 @@ func (t *timer) maybeAdd() {
 	wake := false
 	if t.needsAdd() {
 		t.state |= timerHeaped
-		when = t.when
+		when = t.deadline()
 		wakeTime := ts.wakeTime()
 		wake = wakeTime == 0 || when < wakeTime
 		ts.addHeap(t)
@@ -811,7 +884,7 @@ func (ts *timers) adjust(now int64, force bool) {
 			changed = true
 
 		case t.state&timerModified != 0:
-			tw.when = t.when
+			tw.when, tw.slack = t.deadline(), t.slack
 			t.state &^= timerModified
 			changed = true
 		}
@@ -936,7 +1009,13 @@ Redo:
 		throw("bad ts")
 	}
 
-	if t.astate.Load()&(timerModified|timerZombie) == 0 && tw.when > now {
+	// A timer is ready to run once its when is past, but it needn't
+	// run until its deadline, when+slack, is. Timers only wait for the
+	// earliest deadline, and then run the timers that are ready in
+	// deadline order, so timers with slack run together with the timers
+	// due before them. A ready timer waits behind a timer with an earlier
+	// deadline that isn't ready.
+	if t.astate.Load()&(timerModified|timerZombie) == 0 && tw.when-tw.slack > now {
 		// Fast path: not ready to run.
 		return tw.when
 	}
@@ -954,7 +1033,7 @@ Redo:
 	if t.when > now {
 		// Not ready to run.
 		t.unlock()
-		return t.when
+		return t.deadline()
 	}
 
 	t.unlockAndRun(now)
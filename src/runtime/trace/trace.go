@@ -135,9 +135,14 @@ func Start(w io.Writer) error {
 		}
 	}()
 	tracing.enabled.Store(true)
+	// Let runtime/debug.Shutdown finish the trace.
+	runtime_setShutdownHook(Stop)
 	return nil
 }
 
+// runtime_setShutdownHook is defined in runtime/shutdown.go.
+func runtime_setShutdownHook(f func())
+
 // Stop stops the current tracing, if any.
 // Stop only returns after all the writes for the trace have completed.
 func Stop() {
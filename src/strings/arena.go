@@ -0,0 +1,52 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package strings
+
+import "arena"
+
+// SetAllocator causes the Builder to allocate the memory it grows into from
+// the provided arena instead of the heap, growing its buffer in place when
+// possible. Strings returned by [Builder.String] share that memory, so they
+// must not be used after the arena is freed. [Builder.Reset] does not change
+// the Builder's allocator.
+//
+// Calling SetAllocator with a nil arena restores heap allocation.
+func (b *Builder) SetAllocator(a *arena.Arena) {
+	b.copyCheck()
+	b.arena.a = a
+}
+
+// builderArena is the arena, if any, that a Builder grows into.
+type builderArena struct {
+	a *arena.Arena
+}
+
+// reserve ensures there are at least n bytes of capacity beyond len(b.buf)
+// if the builder has an arena, so that appending to b.buf doesn't grow it
+// into the heap.
+func (b *Builder) reserve(n int) {
+	if b.arena.a != nil && cap(b.buf)-len(b.buf) < n {
+		b.grow(n)
+	}
+}
+
+// growInArena is like grow, but allocates from the builder's arena. It
+// reports whether the builder has an arena.
+func (b *Builder) growInArena(n int) bool {
+	a := b.arena.a
+	if a == nil {
+		return false
+	}
+	if buf, ok := arena.GrowInPlace(a, b.buf, cap(b.buf)+n); ok {
+		b.buf = buf
+		return true
+	}
+	buf := arena.MakeSlice[byte](a, len(b.buf), 2*cap(b.buf)+n)
+	copy(buf, b.buf)
+	b.buf = buf
+	return true
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package strings_test
+
+import (
+	"arena"
+	. "strings"
+	"testing"
+	"unsafe"
+)
+
+func TestBuilderSetAllocator(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	var b Builder
+	b.SetAllocator(a)
+	want := ""
+	for i := 0; i < 1000; i++ {
+		b.WriteString("hello, ")
+		b.WriteRune('世')
+		b.WriteByte('0' + byte(i%10))
+		want += "hello, 世" + string(rune('0'+i%10))
+	}
+	if got := b.String(); got != want {
+		t.Fatalf("Builder.String() = %q, want %q", got, want)
+	}
+	if !a.Contains(unsafe.Pointer(unsafe.StringData(b.String()))) {
+		t.Errorf("Builder with arena grew into the heap")
+	}
+
+	// Reset keeps the allocator.
+	b.Reset()
+	b.WriteString("hello")
+	if !a.Contains(unsafe.Pointer(unsafe.StringData(b.String()))) {
+		t.Errorf("Builder grew into the heap after Reset")
+	}
+
+	// Without an allocator, the builder grows into the heap again.
+	b.SetAllocator(nil)
+	b.Grow(2 * b.Cap())
+	if a.Contains(unsafe.Pointer(unsafe.StringData(b.String()))) {
+		t.Errorf("Builder without arena grew into the arena")
+	}
+}
+
+func TestBuilderSetAllocatorGrowsInPlace(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	var b Builder
+	b.SetAllocator(a)
+	b.WriteByte('x')
+	data := unsafe.StringData(b.String())
+	cap0 := b.Cap()
+	for b.Cap() < 64*cap0 {
+		b.WriteString(Repeat("y", b.Cap()-b.Len()+1))
+		if got := unsafe.StringData(b.String()); got != data {
+			t.Fatalf("Builder moved from %p to %p when growing to capacity %d", data, got, b.Cap())
+		}
+	}
+	// Nothing but the builder's buffer was allocated, so the arena holds
+	// no abandoned copies of it.
+	if s := a.Stats(); s.AllocBytes > uint64(b.Cap()) {
+		t.Errorf("arena has %d bytes allocated for a buffer of capacity %d", s.AllocBytes, b.Cap())
+	}
+}
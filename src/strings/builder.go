@@ -17,6 +17,8 @@ import (
 type Builder struct {
 	addr *Builder // of receiver, to detect copies by value
 
+	arena builderArena // arena to grow buf in, if any
+
 	// External users should never get direct access to this buffer, since
 	// the slice at some point will be converted to a string using unsafe, also
 	// data between len(buf) and cap(buf) might be uninitialized.
@@ -58,6 +60,9 @@ func (b *Builder) Reset() {
 // grow copies the buffer to a new, larger buffer so that there are at least n
 // bytes of capacity beyond len(b.buf).
 func (b *Builder) grow(n int) {
+	if b.growInArena(n) {
+		return
+	}
 	buf := bytealg.MakeNoZero(2*cap(b.buf) + n)[:len(b.buf)]
 	copy(buf, b.buf)
 	b.buf = buf
@@ -80,6 +85,7 @@ func (b *Builder) Grow(n int) {
 // Write always returns len(p), nil.
 func (b *Builder) Write(p []byte) (int, error) {
 	b.copyCheck()
+	b.reserve(len(p))
 	b.buf = append(b.buf, p...)
 	return len(p), nil
 }
@@ -88,6 +94,7 @@ func (b *Builder) Write(p []byte) (int, error) {
 // The returned error is always nil.
 func (b *Builder) WriteByte(c byte) error {
 	b.copyCheck()
+	b.reserve(1)
 	b.buf = append(b.buf, c)
 	return nil
 }
@@ -96,6 +103,7 @@ func (b *Builder) WriteByte(c byte) error {
 // It returns the length of r and a nil error.
 func (b *Builder) WriteRune(r rune) (int, error) {
 	b.copyCheck()
+	b.reserve(utf8.UTFMax)
 	n := len(b.buf)
 	b.buf = utf8.AppendRune(b.buf, r)
 	return len(b.buf) - n, nil
@@ -105,6 +113,7 @@ func (b *Builder) WriteRune(r rune) (int, error) {
 // It returns the length of s and a nil error.
 func (b *Builder) WriteString(s string) (int, error) {
 	b.copyCheck()
+	b.reserve(len(s))
 	b.buf = append(b.buf, s...)
 	return len(s), nil
 }
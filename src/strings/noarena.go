@@ -0,0 +1,15 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !goexperiment.arenas
+
+package strings
+
+// builderArena is empty without the arenas experiment; a Builder always
+// grows into the heap.
+type builderArena struct{}
+
+func (b *Builder) reserve(n int) {}
+
+func (b *Builder) growInArena(n int) bool { return false }
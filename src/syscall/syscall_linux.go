@@ -28,6 +28,9 @@ import (
 //go:linkname runtime_entersyscall runtime.entersyscall
 func runtime_entersyscall()
 
+//go:linkname runtime_entersyscallnoblock runtime.entersyscallnoblock
+func runtime_entersyscallnoblock()
+
 //go:linkname runtime_exitsyscall runtime.exitsyscall
 func runtime_exitsyscall()
 
@@ -98,6 +101,34 @@ func Syscall6(trap, a1, a2, a3, a4, a5, a6 uintptr) (r1, r2 uintptr, err Errno)
 	return
 }
 
+// SyscallNoBlock is like [Syscall], for system calls that are known not
+// to block, such as clock_gettime or a futex wake. Unlike Syscall, it
+// doesn't let the scheduler hand the calling goroutine's processor to
+// another thread during the call, unless the call blocks after all,
+// which makes tight loops over such calls faster. Unlike [RawSyscall],
+// it lets the scheduler and the garbage collector proceed without
+// waiting for the call to return.
+//
+//go:uintptrkeepalive
+//go:nosplit
+func SyscallNoBlock(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err Errno) {
+	runtime_entersyscallnoblock()
+	r1, r2, err = RawSyscall6(trap, a1, a2, a3, 0, 0, 0)
+	runtime_exitsyscall()
+	return
+}
+
+// SyscallNoBlock6 is like [SyscallNoBlock], with six arguments.
+//
+//go:uintptrkeepalive
+//go:nosplit
+func SyscallNoBlock6(trap, a1, a2, a3, a4, a5, a6 uintptr) (r1, r2 uintptr, err Errno) {
+	runtime_entersyscallnoblock()
+	r1, r2, err = RawSyscall6(trap, a1, a2, a3, a4, a5, a6)
+	runtime_exitsyscall()
+	return
+}
+
 func rawSyscallNoError(trap, a1, a2, a3 uintptr) (r1, r2 uintptr)
 func rawVforkSyscall(trap, a1, a2, a3 uintptr) (r1 uintptr, err Errno)
 
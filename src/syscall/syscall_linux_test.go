@@ -889,3 +889,33 @@ func testPrlimitFileLimitHelper2(t *testing.T) {
 
 	fmt.Println("OK")
 }
+
+func TestSyscallNoBlock(t *testing.T) {
+	pid, _, errno := syscall.SyscallNoBlock(syscall.SYS_GETPID, 0, 0, 0)
+	if errno != 0 {
+		t.Fatalf("getpid failed: %v", errno)
+	}
+	if int(pid) != os.Getpid() {
+		t.Errorf("getpid returned %d, want %d", pid, os.Getpid())
+	}
+
+	// A system call that blocks after all still lets other goroutines
+	// run.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	slept := make(chan bool)
+	go func() {
+		ts := syscall.Timespec{Nsec: 200e6}
+		syscall.SyscallNoBlock(syscall.SYS_NANOSLEEP, uintptr(unsafe.Pointer(&ts)), 0, 0)
+		slept <- true
+	}()
+	ran := make(chan bool)
+	go func() {
+		ran <- true
+	}()
+	select {
+	case <-ran:
+		<-slept
+	case <-slept:
+		t.Error("goroutine didn't run during a blocking system call")
+	}
+}
@@ -77,6 +77,9 @@ func stopTimer(*Timer) bool
 //go:linkname resetTimer
 func resetTimer(t *Timer, when, period int64) bool
 
+//go:linkname setTimerSlack
+func setTimerSlack(t *Timer, slack int64)
+
 // Note: The runtime knows the layout of struct Timer, since newTimer allocates it.
 // The runtime also knows that Ticker and Timer have the same layout.
 // There are extra fields after the channel, reserved for the runtime
@@ -176,6 +179,21 @@ func (t *Timer) Reset(d Duration) bool {
 	return resetTimer(t, w, 0)
 }
 
+// SetSlack allows the timer to expire up to d later than it otherwise
+// would, so that the runtime can expire it together with other timers,
+// and wake the program less often. It applies to the current and future
+// expirations of the timer, until changed again. A zero or negative d
+// removes the slack, so that the timer expires as soon as it can.
+//
+// Timers start with the slack set by [runtime/debug.SetTimerSlack], zero
+// by default.
+func (t *Timer) SetSlack(d Duration) {
+	if !t.initTimer {
+		panic("time: SetSlack called on uninitialized Timer")
+	}
+	setTimerSlack(t, int64(d))
+}
+
 // sendTime does a non-blocking send of the current time on c.
 func sendTime(c any, seq uintptr, delta int64) {
 	// delta is how long ago the channel send was supposed to happen.
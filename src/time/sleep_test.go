@@ -718,6 +718,42 @@ func TestTimerModifiedEarlier(t *testing.T) {
 	}
 }
 
+func TestTimerSlack(t *testing.T) {
+	// Keep both timers on the same P.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	start := Now()
+	a := make(chan Time, 1)
+	ta := AfterFunc(10*Millisecond, func() { a <- Now() })
+	defer ta.Stop()
+	ta.SetSlack(Second)
+	b := make(chan bool, 1)
+	AfterFunc(100*Millisecond, func() { b <- true })
+
+	// The first timer may expire along with the second, or later if
+	// other timers are due before it, but no later than its slack allows.
+	<-b
+	select {
+	case at := <-a:
+		if d := at.Sub(start); d < 10*Millisecond {
+			t.Errorf("timer with slack expired after %v, before its duration", d)
+		}
+	case <-After(10 * Second):
+		t.Fatal("timer with slack didn't expire")
+	}
+
+	// Removing the slack of a ticker makes it tick again.
+	tk := NewTicker(10 * Millisecond)
+	defer tk.Stop()
+	tk.SetSlack(Hour)
+	tk.SetSlack(0)
+	select {
+	case <-tk.C:
+	case <-After(10 * Second):
+		t.Fatal("ticker without slack didn't tick")
+	}
+}
+
 // Test that rapidly moving timers earlier and later doesn't cause
 // some of the sleep times to be lost.
 // Issue 47762
@@ -72,6 +72,21 @@ func (t *Ticker) Reset(d Duration) {
 	resetTimer((*Timer)(unsafe.Pointer(t)), when(d), int64(d))
 }
 
+// SetSlack allows each tick to arrive up to d later than it otherwise
+// would, so that the runtime can deliver it together with other timers,
+// and wake the program less often. Ticks stay spaced by the ticker's
+// period on average: a late tick doesn't delay the following ones.
+// A zero or negative d removes the slack.
+//
+// Tickers start with the slack set by [runtime/debug.SetTimerSlack], zero
+// by default.
+func (t *Ticker) SetSlack(d Duration) {
+	if !t.initTicker {
+		panic("time: SetSlack called on uninitialized Ticker")
+	}
+	setTimerSlack((*Timer)(unsafe.Pointer(t)), int64(d))
+}
+
 // Tick is a convenience wrapper for [NewTicker] providing access to the ticking
 // channel only. Unlike NewTicker, Tick will return nil if d <= 0.
 //
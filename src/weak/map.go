@@ -0,0 +1,88 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package weak
+
+import (
+	"internal/abi"
+	"runtime"
+	"unsafe"
+)
+
+// Map is a map from objects of type K to values of type *V that holds
+// its keys weakly. Each entry is an ephemeron: the map keeps the value
+// live only as long as both the key and the map itself are reachable,
+// and never keeps the key live, even if the value refers to the key.
+// Once the key becomes unreachable, the entry disappears.
+//
+// A Map is useful for attaching side tables to objects a package does
+// not own, and for canonicalization maps, without leaking memory or
+// having to evict entries manually.
+//
+// Keys are compared by identity, as pointers. Keys must be pointers to
+// heap-allocated objects, as with Make. A key that becomes reachable
+// again due to a finalizer loses its entries before the finalizer runs.
+//
+// A Map must be created with NewMap. It is safe for concurrent use.
+type Map[K, V any] struct {
+	_     [0]*V
+	owner *mapOwner
+	self  Pointer[mapOwner]
+}
+
+// mapOwner identifies a Map to the runtime. The runtime only keeps a
+// Map's values live while its owner is reachable.
+type mapOwner struct {
+	// N.B. This must contain a pointer, so that owner isn't
+	// placed in a tiny block, where it could be kept live by
+	// unrelated objects.
+	_ *mapOwner
+}
+
+// NewMap returns a new, empty Map.
+func NewMap[K, V any]() *Map[K, V] {
+	owner := new(mapOwner)
+	return &Map[K, V]{owner: owner, self: Make(owner)}
+}
+
+// Get returns the value associated with key, or nil if there is none.
+func (m *Map[K, V]) Get(key *K) *V {
+	if key == nil {
+		return nil
+	}
+	v := (*V)(runtime_getEphemeron(m.self.u, unsafe.Pointer(key)))
+	runtime.KeepAlive(m)
+	return v
+}
+
+// Set associates value with key, replacing any existing value.
+// Setting a nil value is equivalent to calling Delete.
+//
+// Set panics if key is nil.
+func (m *Map[K, V]) Set(key *K, value *V) {
+	if key == nil {
+		panic("weak: Map.Set with nil key")
+	}
+	// Explicitly force key to escape to the heap.
+	key = abi.Escape(key)
+	runtime_setEphemeron(m.self.u, unsafe.Pointer(key), unsafe.Pointer(value))
+	runtime.KeepAlive(m)
+}
+
+// Delete removes the value associated with key, if any.
+func (m *Map[K, V]) Delete(key *K) {
+	if key == nil {
+		return
+	}
+	runtime_setEphemeron(m.self.u, unsafe.Pointer(key), nil)
+	runtime.KeepAlive(m)
+}
+
+// Implemented in runtime.
+
+//go:linkname runtime_setEphemeron
+func runtime_setEphemeron(owner, key, value unsafe.Pointer)
+
+//go:linkname runtime_getEphemeron
+func runtime_getEphemeron(owner, key unsafe.Pointer) unsafe.Pointer
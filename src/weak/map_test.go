@@ -0,0 +1,170 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package weak_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"weak"
+)
+
+func TestMap(t *testing.T) {
+	m := weak.NewMap[T, T]()
+	k1, k2 := new(T), new(T)
+	v1, v2 := &T{a: 1}, &T{a: 2}
+
+	if v := m.Get(k1); v != nil {
+		t.Fatalf("Get on empty map = %p, want nil", v)
+	}
+	m.Set(k1, v1)
+	m.Set(k2, v2)
+	if v := m.Get(k1); v != v1 {
+		t.Errorf("Get(k1) = %p, want %p", v, v1)
+	}
+	if v := m.Get(k2); v != v2 {
+		t.Errorf("Get(k2) = %p, want %p", v, v2)
+	}
+	m.Set(k1, v2)
+	if v := m.Get(k1); v != v2 {
+		t.Errorf("Get(k1) after replacing = %p, want %p", v, v2)
+	}
+	m.Delete(k1)
+	if v := m.Get(k1); v != nil {
+		t.Errorf("Get(k1) after Delete = %p, want nil", v)
+	}
+	m.Set(k1, v1)
+	if v := m.Get(k1); v != v1 {
+		t.Errorf("Get(k1) after re-adding = %p, want %p", v, v1)
+	}
+	m.Set(k1, nil)
+	if v := m.Get(k1); v != nil {
+		t.Errorf("Get(k1) after Set(k1, nil) = %p, want nil", v)
+	}
+
+	// Maps are independent of each other.
+	m2 := weak.NewMap[T, T]()
+	m2.Set(k2, v1)
+	if v := m.Get(k2); v != v2 {
+		t.Errorf("Get(k2) = %p after setting it in another map, want %p", v, v2)
+	}
+	if v := m2.Get(k2); v != v1 {
+		t.Errorf("other map Get(k2) = %p, want %p", v, v1)
+	}
+}
+
+func TestMapRetainsValue(t *testing.T) {
+	m := weak.NewMap[T, T]()
+	k := new(T)
+	m.Set(k, &T{a: 97531})
+	runtime.GC()
+	runtime.GC()
+	if v := m.Get(k); v == nil || v.a != 97531 {
+		t.Fatalf("Get after GC = %+v, want value with a = 97531", v)
+	}
+	runtime.KeepAlive(k)
+}
+
+func TestMapRetainsChain(t *testing.T) {
+	// The value of each entry is the key of the next, so the values
+	// are only reachable through a chain of ephemerons.
+	const n = 10
+	m := weak.NewMap[T, T]()
+	k := new(T)
+	p := k
+	for i := range n {
+		v := &T{a: i}
+		m.Set(p, v)
+		p = v
+	}
+	runtime.GC()
+	runtime.GC()
+	p = k
+	for i := range n {
+		v := m.Get(p)
+		if v == nil || v.a != i {
+			t.Fatalf("entry %d = %+v, want value with a = %d", i, v, i)
+		}
+		p = v
+	}
+	runtime.KeepAlive(k)
+}
+
+func TestMapDropsDeadKey(t *testing.T) {
+	m := weak.NewMap[T, T]()
+	k := new(T)
+	// The value refers to its key, which must not keep the key live.
+	v := &T{t: k}
+	m.Set(k, v)
+	wk, wv := weak.Make(k), weak.Make(v)
+	k, v = nil, nil
+
+	runtime.GC()
+	if wk.Get() != nil {
+		t.Error("key is still live")
+	}
+	if wv.Get() != nil {
+		t.Error("value is still live after key died")
+	}
+	runtime.KeepAlive(m)
+}
+
+func TestMapDropsValuesOfDeadMap(t *testing.T) {
+	m := weak.NewMap[T, T]()
+	k := new(T)
+	v := new(T)
+	m.Set(k, v)
+	wv := weak.Make(v)
+	m, v = nil, nil
+
+	runtime.GC()
+	if wv.Get() != nil {
+		t.Error("value is still live after map died")
+	}
+	runtime.KeepAlive(k)
+}
+
+func TestMapConcurrent(t *testing.T) {
+	n := 1000
+	if testing.Short() {
+		n = 100
+	}
+	m := weak.NewMap[T, T]()
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys := make([]*T, n)
+			for i := range keys {
+				keys[i] = new(T)
+				m.Set(keys[i], &T{a: g*n + i})
+			}
+			for round := range 3 {
+				if g == 0 {
+					runtime.GC()
+				}
+				for i, k := range keys {
+					v := m.Get(k)
+					if v == nil || v.a != g*n+i {
+						t.Errorf("round %d: entry %d = %+v, want value with a = %d", round, i, v, g*n+i)
+						return
+					}
+					// Replace some values, and drop some keys.
+					switch i % 3 {
+					case 1:
+						m.Set(k, &T{a: g*n + i})
+					case 2:
+						if round == 0 {
+							keys[i] = new(T)
+							m.Set(keys[i], &T{a: g*n + i})
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -3,7 +3,7 @@
 // license that can be found in the LICENSE file.
 
 /*
-The weak package is a package for managing weak pointers.
+Package weak provides weak pointers.
 
 Weak pointers are pointers that explicitly do not keep a value live and
 must be queried for a regular Go pointer.
@@ -18,14 +18,9 @@ the semantics of "short" weak references.
 In terms of the Java language, these semantics are roughly equivalent to the
 semantics of the WeakReference type.
 
-Using go:linkname to access this package and the functions it references
-is explicitly forbidden by the toolchain because the semantics of this
-package have not gone through the proposal process. By exposing this
-functionality, we risk locking in the existing semantics due to Hyrum's Law.
-
-If you believe you have a good use-case for weak references not already
-covered by the standard library, file a proposal issue at
-https://github.com/golang/go/issues instead of relying on this package.
+Weak pointers are useful for caches and canonicalization maps keyed by
+object identity: unlike a finalizer, a weak pointer does not delay the
+reclamation of its referent by a GC cycle.
 */
 package weak
 
@@ -37,10 +32,13 @@ import (
 
 // Pointer is a weak pointer to a value of type T.
 //
-// This value is comparable is guaranteed to compare equal if the pointers
-// that they were created from compare equal. This property is retained even
-// after the object referenced by the pointer used to create a weak reference
-// is reclaimed.
+// The zero value of Pointer is a weak pointer to nil, for which Get
+// returns nil.
+//
+// Pointer values are comparable and are guaranteed to compare equal if the
+// pointers that they were created from compare equal. This property is
+// retained even after the object referenced by the pointer used to create
+// a weak reference is reclaimed.
 //
 // If multiple weak pointers are made to different offsets within same object
 // (for example, pointers to different fields of the same struct), those pointers
@@ -49,6 +47,7 @@ import (
 // to a finalizer, that weak pointer will not compare equal with weak pointers
 // created before it became unreachable.
 type Pointer[T any] struct {
+	_ [0]*T
 	u unsafe.Pointer
 }
 
@@ -62,15 +61,15 @@ func Make[T any](ptr *T) Pointer[T] {
 		u = runtime_registerWeakPointer(unsafe.Pointer(ptr))
 	}
 	runtime.KeepAlive(ptr)
-	return Pointer[T]{u}
+	return Pointer[T]{u: u}
 }
 
-// Strong creates a strong pointer from the weak pointer.
-// Returns nil if the original value for the weak pointer was reclaimed by
-// the garbage collector.
-// If a weak pointer points to an object with a finalizer, then Strong will
+// Get returns the original pointer used to create the weak pointer.
+// It returns nil if the value pointed to by the original pointer was
+// reclaimed by the garbage collector.
+// If a weak pointer points to an object with a finalizer, then Get will
 // return nil as soon as the object's finalizer is queued for execution.
-func (p Pointer[T]) Strong() *T {
+func (p Pointer[T]) Get() *T {
 	return (*T)(runtime_makeStrongFromWeak(p.u))
 }
 